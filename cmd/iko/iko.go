@@ -1,16 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
+	"time"
 
+	_ "github.com/mattn/go-sqlite3" // sql driver of the "sqlite" chain db
 	"github.com/skycoin/skycoin/src/cipher"
 	"gopkg.in/sirupsen/logrus.v1"
 	"gopkg.in/urfave/cli.v1"
 
+	"github.com/kittycash/wallet/src/grpc"
+	kchttp "github.com/kittycash/wallet/src/http"
 	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/node"
 	"github.com/kittycash/wallet/src/rpc"
+	"github.com/kittycash/wallet/src/signer"
 	"github.com/kittycash/wallet/src/util"
+	"github.com/kittycash/wallet/src/util/metrics"
 )
 
 const (
@@ -25,10 +37,52 @@ const (
 	fRootNonce  = "root-nonce"
 	fTxPubKey   = "tx-public-key"
 
+	fCreatorPubKeys = "creator-public-keys"
+
+	fChainID   = "chain-id"
+	fGenesisTS = "genesis-ts"
+	fNetworkID = "network-id"
+
 	fTestMode     = "test"
 	fTestTxCount  = "test-tx-count"
 	fTestTxSecKey = "test-tx-secret-key"
 
+	fChainDB      = "chain-db"
+	fChainDBPath  = "chain-db-path"
+	fChainDBCodec = "chain-db-codec"
+	fWALPath      = "wal-path"
+
+	fChainDBSync         = "chain-db-sync"
+	fChainDBSyncCount    = "chain-db-sync-count"
+	fChainDBSyncInterval = "chain-db-sync-interval"
+
+	fStateSnapshot    = "state-snapshot"
+	fSnapshotInterval = "snapshot-interval"
+	fSnapshotDir      = "snapshot-dir"
+	fSnapshotKeep     = "snapshot-keep"
+
+	fArchival       = "archival"
+	fPruneRetention = "prune-retention"
+
+	fCheckpoints        = "checkpoint"
+	fCheckpointSnapshot = "checkpoint-snapshot"
+
+	fVerifyWorkers = "verify-workers"
+	fLogLevel      = "log-level"
+
+	fMempoolSize           = "mempool-size"
+	fMempoolExpiry         = "mempool-expiry"
+	fMempoolCommitInterval = "mempool-commit-interval"
+
+	fTxCacheSize    = "tx-cache-size"
+	fStateCacheSize = "state-cache-size"
+
+	fTransferFee = "transfer-fee"
+
+	fMetadataPolicy = "metadata-policy"
+
+	fBreedCooldown = "breed-cooldown"
+
 	fCXODir             = "cxo-dir"
 	fCXOAddress         = "cxo-address"
 	fCXORPCAddress      = "cxo-rpc-address"
@@ -36,6 +90,33 @@ const (
 
 	fRPCAddress  = "rpc-address"
 	fRemoteClose = "remote-close"
+
+	fGRPCAddress = "grpc-address"
+	fGRPCTLSCert = "grpc-tls-cert"
+	fGRPCTLSKey  = "grpc-tls-key"
+	fGRPCTLSCA   = "grpc-tls-client-ca"
+
+	fMetricsAddress = "metrics-address"
+	fAdmin          = "admin"
+
+	fAPIKeys           = "api-keys"
+	fAPIHMACSecretFile = "api-hmac-secret-file"
+
+	fNodeAddress   = "node-address"
+	fNodePeers     = "node-peers"
+	fNodeSyncBatch = "node-sync-batch"
+	fNodeMaxPeers  = "node-max-peers"
+	fNodeOrdering  = "node-ordering"
+	fNodeTLSCert   = "node-tls-cert"
+	fNodeTLSKey    = "node-tls-key"
+	fNodeTLSCA     = "node-tls-ca"
+
+	fReplicaOf    = "replica-of"
+	fReplicaRetry = "replica-retry"
+
+	fSignerURL        = "signer-url"
+	fSignerSecretFile = "signer-secret-file"
+	fSignerCAFile     = "signer-ca-file"
 )
 
 func Flag(flag string, short ...string) string {
@@ -73,10 +154,26 @@ func init() {
 			Name:  Flag(fTxPubKey, "tpk"),
 			Usage: "public key that is trusted for transactions",
 		},
+		cli.StringSliceFlag{
+			Name:  Flag(fCreatorPubKeys),
+			Usage: "additional public keys that are trusted to create kitties, until replaced by a rotation transaction",
+		},
 		cli.BoolFlag{
 			Name:  Flag(fInit),
 			Usage: "whether to init the root if it doesn't exist",
 		},
+		cli.StringFlag{
+			Name:  Flag(fChainID),
+			Usage: "id of the network, which the genesis transaction should define, so that chains of other networks are refused (not checked if empty)",
+		},
+		cli.Int64Flag{
+			Name:  Flag(fGenesisTS),
+			Usage: "unix time (in nanoseconds) of the genesis, only valid with '--" + fChainID + "'",
+		},
+		cli.StringFlag{
+			Name:  Flag(fNetworkID),
+			Usage: "id of the network that transactions should be signed for, so that transactions of other networks cannot be replayed (empty for the legacy network)",
+		},
 		/*
 			<<< TEST MODE >>>
 		*/
@@ -93,6 +190,134 @@ func init() {
 			Usage: "secret key for signing test transactions",
 			Value: new(cipher.SecKey).Hex(),
 		},
+		/*
+			<<< CHAIN DB CONFIG >>>
+		*/
+		cli.StringFlag{
+			Name:  Flag(fChainDB),
+			Usage: "chain db to use, either 'cxo', 'bolt' or 'leveldb'",
+			Value: string(iko.CXOChainDBType),
+		},
+		cli.StringFlag{
+			Name:  Flag(fChainDBPath),
+			Usage: "path to store chain db files, only valid for 'bolt' and 'leveldb' chain dbs",
+			Value: "./kc/chain",
+		},
+		cli.StringFlag{
+			Name:  Flag(fChainDBCodec),
+			Usage: "encoding of stored transactions, either 'binary' or 'cbor', only valid for 'bolt' and 'leveldb' chain dbs",
+			Value: string(iko.BinaryTxCodecType),
+		},
+		cli.StringFlag{
+			Name:  Flag(fStateSnapshot),
+			Usage: "file to save the state snapshot to on exit and restore from on start, disabled if empty",
+		},
+		cli.StringFlag{
+			Name:  Flag(fChainDBSync),
+			Usage: "when added transactions are synced to disk, either 'write', 'count' or 'interval', only valid for 'bolt' and 'leveldb' chain dbs",
+			Value: string(iko.SyncEveryWrite),
+		},
+		cli.IntFlag{
+			Name:  Flag(fChainDBSyncCount),
+			Usage: "number of writes between syncs of the 'count' sync mode",
+			Value: 100,
+		},
+		cli.DurationFlag{
+			Name:  Flag(fChainDBSyncInterval),
+			Usage: "time between syncs of the 'interval' sync mode",
+			Value: time.Second,
+		},
+		cli.StringFlag{
+			Name:  Flag(fWALPath),
+			Usage: "file of the write-ahead log of chain writes, disabled if empty",
+		},
+		cli.Uint64Flag{
+			Name:  Flag(fSnapshotInterval),
+			Usage: "number of transactions between automatic state snapshots, disabled if 0",
+		},
+		cli.StringFlag{
+			Name:  Flag(fSnapshotDir),
+			Usage: "directory of automatic state snapshots, the newest valid of which is restored from on start",
+			Value: "./kc/snapshots",
+		},
+		cli.IntFlag{
+			Name:  Flag(fSnapshotKeep),
+			Usage: "number of automatic state snapshots to keep",
+			Value: iko.DefaultSnapshotKeep,
+		},
+		cli.BoolTFlag{
+			Name:  Flag(fArchival),
+			Usage: "whether to keep every tx of the chain, old txs are pruned otherwise (requires automatic state snapshots)",
+		},
+		cli.Uint64Flag{
+			Name:  Flag(fPruneRetention),
+			Usage: "number of most recent txs to keep when not archival",
+			Value: iko.DefaultPruneRetention,
+		},
+		cli.StringSliceFlag{
+			Name:  Flag(fCheckpoints),
+			Usage: "trusted checkpoint of the form '<seq>:<state_hash>' that the replayed state is checked against, which can be repeated",
+		},
+		cli.StringFlag{
+			Name:  Flag(fCheckpointSnapshot),
+			Usage: "file to save the state snapshot of the latest checkpoint to, and restore from on start if there is no state snapshot",
+		},
+		cli.IntFlag{
+			Name:  Flag(fVerifyWorkers),
+			Usage: "number of goroutines verifying signatures when replaying the chain, the number of CPUs if 0",
+		},
+		cli.StringFlag{
+			Name:  Flag(fLogLevel),
+			Usage: "level of blockchain logs, either 'debug', 'info', 'warning' or 'error'",
+			Value: "debug",
+		},
+		/*
+			<<< MEMPOOL >>>
+		*/
+		cli.IntFlag{
+			Name:  Flag(fMempoolSize),
+			Usage: "maximum number of pending transactions",
+			Value: iko.DefaultMempoolSize,
+		},
+		cli.DurationFlag{
+			Name:  Flag(fMempoolExpiry),
+			Usage: "duration after which uncommitted pending transactions are evicted, disabled if 0",
+		},
+		cli.DurationFlag{
+			Name:  Flag(fMempoolCommitInterval),
+			Usage: "interval in which pending transactions are committed, disabled if 0",
+			Value: time.Second,
+		},
+		cli.IntFlag{
+			Name:  Flag(fTxCacheSize),
+			Usage: "number of transactions of the lookup cache, disabled if 0",
+		},
+		cli.IntFlag{
+			Name:  Flag(fStateCacheSize),
+			Usage: "number of kitty and address states of the state cache, disabled if 0",
+		},
+		/*
+			<<< FEES >>>
+		*/
+		cli.Uint64Flag{
+			Name:  Flag(fTransferFee),
+			Usage: "fee required per kitty of transfer transactions, disabled if 0",
+		},
+		/*
+			<<< METADATA >>>
+		*/
+		cli.StringFlag{
+			Name:  Flag(fMetadataPolicy),
+			Usage: "who may set the metadata of kitties, either 'creator' or 'owner'",
+			Value: string(iko.MetadataByCreator),
+		},
+		/*
+			<<< BREEDING >>>
+		*/
+		cli.Uint64Flag{
+			Name:  Flag(fBreedCooldown),
+			Usage: "number of transactions that kitties cannot breed for after breeding, disabled if 0",
+		},
 		/*
 			<<< CXO CONFIG >>>
 		*/
@@ -126,6 +351,108 @@ func init() {
 			Name:  Flag(fRemoteClose),
 			Usage: "whether to enable remote close",
 		},
+		/*
+			<<< GRPC SERVER >>>
+		*/
+		cli.StringFlag{
+			Name:  Flag(fGRPCAddress),
+			Usage: "address used to serve grpc, keep empty to not serve grpc",
+		},
+		cli.StringFlag{
+			Name:  Flag(fGRPCTLSCert),
+			Usage: "tls certificate file for grpc (required as grpc uses HTTP/2)",
+		},
+		cli.StringFlag{
+			Name:  Flag(fGRPCTLSKey),
+			Usage: "tls key file for grpc",
+		},
+		cli.StringFlag{
+			Name:  Flag(fGRPCTLSCA),
+			Usage: "ca certificate file that grpc client certificates are required to be signed by (mutual tls)",
+		},
+		/*
+			<<< METRICS >>>
+		*/
+		cli.StringFlag{
+			Name:  Flag(fMetricsAddress),
+			Usage: "address used to serve prometheus metrics at '/metrics' and health checks at '/health' and '/ready', keep empty to not serve them",
+		},
+		cli.BoolFlag{
+			Name:  Flag(fAdmin),
+			Usage: "whether to also serve pprof and runtime stats under '/admin/' of the metrics address, to loopback clients",
+		},
+		cli.StringSliceFlag{
+			Name:  Flag(fAPIKeys),
+			Usage: "api keys (of the 'X-API-Key' header) that authorise admin routes",
+		},
+		cli.StringFlag{
+			Name:  Flag(fAPIHMACSecretFile),
+			Usage: "file of the secret that authorises HMAC-signed requests of admin routes",
+		},
+		/*
+			<<< P2P NODE >>>
+		*/
+		cli.StringFlag{
+			Name:  Flag(fNodeAddress),
+			Usage: "address to accept peer nodes on, keep empty to not accept peers",
+		},
+		cli.StringSliceFlag{
+			Name:  Flag(fNodePeers),
+			Usage: "addresses of bootstrap peer nodes to synchronize the chain with",
+		},
+		cli.Uint64Flag{
+			Name:  Flag(fNodeSyncBatch),
+			Usage: "maximum number of txs requested from a peer at once",
+			Value: node.DefaultSyncBatchSize,
+		},
+		cli.IntFlag{
+			Name:  Flag(fNodeMaxPeers),
+			Usage: "maximum number of connected peer nodes",
+			Value: node.DefaultMaxPeers,
+		},
+		cli.BoolFlag{
+			Name:  Flag(fNodeOrdering),
+			Usage: "whether this node orders txs relayed by peer nodes, by adding them to it's mempool",
+		},
+		cli.StringFlag{
+			Name:  Flag(fNodeTLSCert),
+			Usage: "tls certificate file of the p2p node, enables mutual tls between peers",
+		},
+		cli.StringFlag{
+			Name:  Flag(fNodeTLSKey),
+			Usage: "tls key file of the p2p node",
+		},
+		cli.StringFlag{
+			Name:  Flag(fNodeTLSCA),
+			Usage: "ca certificate file that certificates of peers are required to be signed by",
+		},
+		/*
+			<<< READ REPLICA >>>
+		*/
+		cli.StringFlag{
+			Name:  Flag(fReplicaOf),
+			Usage: "rpc address of the primary node to replicate, which makes this node a read replica that does not accept transactions (disabled if empty)",
+		},
+		cli.DurationFlag{
+			Name:  Flag(fReplicaRetry),
+			Usage: "delay before reconnecting to the transaction stream of the primary node",
+			Value: iko.DefaultReplicaRetry,
+		},
+		/*
+			<<< REMOTE SIGNER >>>
+		*/
+		cli.StringFlag{
+			Name:  Flag(fSignerURL),
+			Usage: "url of a kittysigner daemon to sign test transactions with, instead of the test secret key",
+		},
+		cli.StringFlag{
+			Name:  Flag(fSignerSecretFile),
+			Usage: "file of the secret shared with the kittysigner daemon",
+		},
+		cli.StringFlag{
+			Name:  Flag(fSignerCAFile),
+			Usage: "ca certificate file to trust the kittysigner daemon's tls certificate",
+		},
 	}
 	app.Action = cli.ActionFunc(action)
 }
@@ -133,17 +460,66 @@ func init() {
 func action(ctx *cli.Context) error {
 	quit := util.CatchInterrupt()
 
+	// Quitting cancels the context, which also stops replaying the chain.
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go func() {
+		<-quit
+		stop()
+	}()
+
 	var (
-		rootPK    = cipher.MustPubKeyFromHex(ctx.String(fRootPubKey))
-		rootSK    = cipher.MustSecKeyFromHex(ctx.String(fRootSecKey))
-		rootNonce = ctx.Uint64(fRootNonce)
-		txPK      = cipher.MustPubKeyFromHex(ctx.String(fTxPubKey))
-		doInit    = ctx.Bool(fInit)
+		rootPK     = cipher.MustPubKeyFromHex(ctx.String(fRootPubKey))
+		rootSK     = cipher.MustSecKeyFromHex(ctx.String(fRootSecKey))
+		rootNonce  = ctx.Uint64(fRootNonce)
+		txPK       = cipher.MustPubKeyFromHex(ctx.String(fTxPubKey))
+		creatorPKs = ctx.StringSlice(fCreatorPubKeys)
+		doInit     = ctx.Bool(fInit)
+		chainID    = ctx.String(fChainID)
+		genesisTS  = ctx.Int64(fGenesisTS)
+		networkID  = ctx.String(fNetworkID)
 
 		testMode  = ctx.Bool(fTestMode)
 		testCount = ctx.Int(fTestTxCount)
 		testSK    = cipher.MustSecKeyFromHex(ctx.String(fTestTxSecKey))
 
+		chainDBType  = ctx.String(fChainDB)
+		chainDBPath  = ctx.String(fChainDBPath)
+		chainDBCodec = iko.TxCodecType(ctx.String(fChainDBCodec))
+		walPath      = ctx.String(fWALPath)
+		chainDBSync  = iko.SyncPolicy{
+			Mode:     iko.SyncMode(ctx.String(fChainDBSync)),
+			Count:    ctx.Int(fChainDBSyncCount),
+			Interval: ctx.Duration(fChainDBSyncInterval),
+		}
+
+		stateSnapshot    = ctx.String(fStateSnapshot)
+		snapshotInterval = ctx.Uint64(fSnapshotInterval)
+		snapshotDir      = ctx.String(fSnapshotDir)
+		snapshotKeep     = ctx.Int(fSnapshotKeep)
+
+		archival       = ctx.BoolT(fArchival)
+		pruneRetention = ctx.Uint64(fPruneRetention)
+
+		checkpoints        = ctx.StringSlice(fCheckpoints)
+		checkpointSnapshot = ctx.String(fCheckpointSnapshot)
+
+		verifyWorkers = ctx.Int(fVerifyWorkers)
+		logLevel      = ctx.String(fLogLevel)
+
+		mempoolSize           = ctx.Int(fMempoolSize)
+		mempoolExpiry         = ctx.Duration(fMempoolExpiry)
+		mempoolCommitInterval = ctx.Duration(fMempoolCommitInterval)
+
+		txCacheSize    = ctx.Int(fTxCacheSize)
+		stateCacheSize = ctx.Int(fStateCacheSize)
+
+		transferFee = ctx.Uint64(fTransferFee)
+
+		metadataPolicy = iko.MetadataPolicy(ctx.String(fMetadataPolicy))
+
+		breedCooldown = ctx.Uint64(fBreedCooldown)
+
 		cxoDir             = ctx.String(fCXODir)
 		cxoAddress         = ctx.String(fCXOAddress)
 		cxoRPCAddress      = ctx.String(fCXORPCAddress)
@@ -151,11 +527,39 @@ func action(ctx *cli.Context) error {
 
 		rpcAddress  = ctx.String(fRPCAddress)
 		remoteClose = ctx.Bool(fRemoteClose)
+
+		grpcAddress = ctx.String(fGRPCAddress)
+		grpcTLSCert = ctx.String(fGRPCTLSCert)
+		grpcTLSKey  = ctx.String(fGRPCTLSKey)
+		grpcTLSCA   = ctx.String(fGRPCTLSCA)
+
+		metricsAddress = ctx.String(fMetricsAddress)
+		admin          = ctx.Bool(fAdmin)
+
+		apiKeys           = ctx.StringSlice(fAPIKeys)
+		apiHMACSecretFile = ctx.String(fAPIHMACSecretFile)
+
+		nodeAddress   = ctx.String(fNodeAddress)
+		nodePeers     = ctx.StringSlice(fNodePeers)
+		nodeSyncBatch = ctx.Uint64(fNodeSyncBatch)
+		nodeMaxPeers  = ctx.Int(fNodeMaxPeers)
+		nodeOrdering  = ctx.Bool(fNodeOrdering)
+		nodeTLSCert   = ctx.String(fNodeTLSCert)
+		nodeTLSKey    = ctx.String(fNodeTLSKey)
+		nodeTLSCA     = ctx.String(fNodeTLSCA)
+
+		replicaOf    = ctx.String(fReplicaOf)
+		replicaRetry = ctx.Duration(fReplicaRetry)
+
+		signerURL        = ctx.String(fSignerURL)
+		signerSecretFile = ctx.String(fSignerSecretFile)
+		signerCAFile     = ctx.String(fSignerCAFile)
 	)
 
 	var (
 		e        error
 		stateDB  iko.StateDB
+		chainDB  iko.ChainDB
 		cxoChain *iko.CXOChain
 	)
 
@@ -163,33 +567,99 @@ func action(ctx *cli.Context) error {
 	stateDB = iko.NewMemoryState()
 
 	// Prepare ChainDB.
-	cxoChain, e = iko.NewCXOChain(&iko.CXOChainConfig{
-		Dir:                cxoDir,
-		Public:             true,
-		Memory:             testMode,
-		MessengerAddresses: discoveryAddresses,
-		CXOAddress:         cxoAddress,
-		CXORPCAddress:      cxoRPCAddress,
-		MasterRooter:       true,
-		MasterRootPK:       rootPK,
-		MasterRootSK:       rootSK,
-		MasterRootNonce:    rootNonce,
+	chainDB, e = iko.NewChainDB(&iko.ChainDBConfig{
+		Type: iko.ChainDBType(chainDBType),
+		CXO: &iko.CXOChainConfig{
+			Dir:                cxoDir,
+			Public:             true,
+			Memory:             testMode,
+			MessengerAddresses: discoveryAddresses,
+			CXOAddress:         cxoAddress,
+			CXORPCAddress:      cxoRPCAddress,
+			MasterRooter:       true,
+			MasterRootPK:       rootPK,
+			MasterRootSK:       rootSK,
+			MasterRootNonce:    rootNonce,
+		},
+		Bolt: &iko.BoltChainConfig{
+			Path:  chainDBPath,
+			Codec: chainDBCodec,
+			Sync:  chainDBSync,
+		},
+		Level: &iko.LevelChainConfig{
+			Dir:   chainDBPath,
+			Codec: chainDBCodec,
+			Sync:  chainDBSync,
+		},
 	})
 	if e != nil {
 		return e
 	}
-	defer cxoChain.Close()
+	defer chainDB.Close()
+
+	// CXO specific features are only available with CXO chain db.
+	cxoChain, _ = chainDB.(*iko.CXOChain)
 
 	// Prepare blockchain config.
 	bcConfig := &iko.BlockChainConfig{
 		GenerationPK: txPK,
+		NetworkID:    networkID,
 		TxAction: func(tx *iko.Transaction) error {
 			return nil
 		},
+		MetadataPolicy:    metadataPolicy,
+		BreedCooldown:     breedCooldown,
+		StateSnapshotPath: stateSnapshot,
+		SnapshotInterval:  snapshotInterval,
+		SnapshotDir:       snapshotDir,
+		SnapshotKeep:      snapshotKeep,
+		PruneRetention:    pruneRetention,
+		WALPath:           walPath,
+
+		CheckpointSnapshotPath: checkpointSnapshot,
+		VerifyWorkers:          verifyWorkers,
+		LogLevel:               logLevel,
+
+		MempoolSize:           mempoolSize,
+		MempoolExpiry:         mempoolExpiry,
+		MempoolCommitInterval: mempoolCommitInterval,
+
+		TxCacheSize:    txCacheSize,
+		StateCacheSize: stateCacheSize,
+
+		Replica:      replicaOf != "",
+		ReplicaRetry: replicaRetry,
+	}
+	if transferFee > 0 {
+		bcConfig.FeePolicy = iko.FlatFeePolicy(transferFee)
+	}
+	if !archival {
+		bcConfig.ChainMode = iko.PrunedChainMode
+	}
+	if chainID != "" {
+		bcConfig.Genesis = &iko.Genesis{
+			ChainID:   chainID,
+			CreatorPK: txPK,
+			TS:        genesisTS,
+		}
+	}
+	for _, v := range creatorPKs {
+		pk, e := cipher.PubKeyFromHex(v)
+		if e != nil {
+			return fmt.Errorf("invalid '--%s': %v", fCreatorPubKeys, e)
+		}
+		bcConfig.CreatorPKs = append(bcConfig.CreatorPKs, pk)
+	}
+	for _, v := range checkpoints {
+		cp, e := iko.CheckpointFromString(v)
+		if e != nil {
+			return fmt.Errorf("invalid '--%s': %v", fCheckpoints, e)
+		}
+		bcConfig.Checkpoints = append(bcConfig.Checkpoints, cp)
 	}
 
 	// Prepare blockchain.
-	bc, e := iko.NewBlockChain(bcConfig, cxoChain, stateDB)
+	bc, e := iko.NewBlockChain(runCtx, bcConfig, chainDB, stateDB)
 	if e != nil {
 		return e
 	}
@@ -199,7 +669,7 @@ func action(ctx *cli.Context) error {
 		cxoChain.RunTxService(iko.MakeTxChecker(bc))
 	}
 
-	if doInit || testMode {
+	if cxoChain != nil && (doInit || testMode) {
 		if e := cxoChain.MasterInitChain(); e != nil {
 			return e
 		}
@@ -207,16 +677,64 @@ func action(ctx *cli.Context) error {
 
 	log.Info("finished preparing blockchain")
 
+	// Replicate the primary node, if a read replica.
+	if replicaOf != "" {
+		if testMode {
+			return fmt.Errorf("'--%s' cannot be used in test mode", fReplicaOf)
+		}
+		go func() {
+			e := bc.Replicate(runCtx, kchttp.NewRPCClient(replicaOf))
+			if e != nil && e != context.Canceled && e != iko.ErrChainStopped {
+				log.WithError(e).Error("replication of primary node stopped")
+			}
+		}()
+		log.WithField("primary", replicaOf).Info("replicating primary node")
+	}
+
+	// Prepare signer of test data. With a remote signer, the generation secret
+	// key is held by the kittysigner daemon rather than by this node.
+	var testSigner iko.Signer = iko.NewSecKeySigner(testSK)
+	if signerURL != "" {
+		secret, e := signer.ReadSecretFile(signerSecretFile)
+		if e != nil {
+			return e
+		}
+		signerClient, e := signer.NewClient(&signer.ClientConfig{
+			URL:    signerURL,
+			Secret: secret,
+			CAFile: signerCAFile,
+		})
+		if e != nil {
+			return e
+		}
+		testSigner = signerClient.Signer(cipher.AddressFromPubKey(txPK))
+	}
+
 	// Prepare test data.
 	if testMode {
-		var tx *iko.Transaction
-		for i := 0; i < testCount; i++ {
-			tx = iko.NewGenTx(iko.KittyID(i), testSK)
+		txs := make([]iko.Transaction, testCount)
+		for i := range txs {
+			// The first test tx is the genesis tx, which defines the genesis.
+			tx := iko.NewUnsignedGenTx(iko.KittyID(i), testSigner.Address())
+			if i == 0 {
+				tx.Genesis = bcConfig.Genesis
+			}
+			tx.Network = bcConfig.NetworkID
+			if e := tx.SignWith(testSigner); e != nil {
+				return e
+			}
 
 			log.WithField("tx", tx.String()).
 				Debugf("test:tx_inject(%d)", i)
 
-			if _, e := bc.InjectTx(tx); e != nil {
+			txs[i] = *tx
+		}
+		errs, e := bc.InjectTxs(txs)
+		if e != nil {
+			return e
+		}
+		for _, e := range errs {
+			if e != nil {
 				return e
 			}
 		}
@@ -246,7 +764,85 @@ func action(ctx *cli.Context) error {
 	}
 	defer rpcServer.Close()
 
-	<-quit
+	// Prepare grpc server.
+	if grpcAddress != "" {
+		grpcServer, e := grpc.NewServer(
+			&grpc.ServerConfig{
+				Address:         grpcAddress,
+				TLSCertFile:     grpcTLSCert,
+				TLSKeyFile:      grpcTLSKey,
+				TLSClientCAFile: grpcTLSCA,
+			},
+			bc,
+		)
+		if e != nil {
+			return e
+		}
+		defer grpcServer.Close()
+	}
+
+	// Prepare p2p node.
+	var p2pNode *node.Node
+	if nodeAddress != "" || len(nodePeers) > 0 {
+		var nodeTLS *tls.Config
+		if nodeTLSCert != "" {
+			if nodeTLS, e = util.MutualTLSConfig(nodeTLSCert, nodeTLSKey, nodeTLSCA); e != nil {
+				return e
+			}
+		}
+		p2pNode, e = node.NewNode(
+			&node.NodeConfig{
+				Address:       nodeAddress,
+				Peers:         nodePeers,
+				SyncBatchSize: nodeSyncBatch,
+				MaxPeers:      nodeMaxPeers,
+				Ordering:      nodeOrdering,
+				TLS:           nodeTLS,
+			},
+			bc,
+		)
+		if e != nil {
+			return e
+		}
+		defer p2pNode.Close()
+	}
+
+	// Prepare metrics and health check server.
+	if metricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.DefaultRegistry)
+		if e := kchttp.HealthGateway(mux, bc, p2pNode); e != nil {
+			return e
+		}
+		if admin {
+			if e := kchttp.AdminGateway(mux); e != nil {
+				return e
+			}
+		}
+		var handler http.Handler = mux
+		if len(apiKeys) > 0 || apiHMACSecretFile != "" {
+			authConfig := &kchttp.AuthConfig{APIKeys: apiKeys}
+			if apiHMACSecretFile != "" {
+				if authConfig.HMACSecret, e = signer.ReadSecretFile(apiHMACSecretFile); e != nil {
+					return e
+				}
+			}
+			auth, e := kchttp.NewAuthenticator(authConfig)
+			if e != nil {
+				return e
+			}
+			handler = auth.Middleware(handler)
+		}
+		l, e := net.Listen("tcp", metricsAddress)
+		if e != nil {
+			return e
+		}
+		metricsServer := &http.Server{Handler: handler}
+		go metricsServer.Serve(l)
+		defer metricsServer.Close()
+	}
+
+	<-runCtx.Done()
 	return nil
 }
 
@@ -0,0 +1,1319 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/kittycash/wallet/src/http"
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/wallet"
+)
+
+const (
+	DefaultNodeAddress = "127.0.0.1:7908"
+	DefaultPerPage     = 100
+)
+
+var (
+	// DefaultWalletDir matches the wallet directory of the wallet node.
+	DefaultWalletDir = filepath.Join(os.Getenv("HOME"), ".kittycash", "wallets")
+)
+
+const (
+	fNode    = "node"
+	fAPIKey  = "api-key"
+	fPerPage = "per-page"
+	fAudit   = "audit"
+
+	fSeed      = "seed"
+	fCount     = "count"
+	fSecretKey = "secret-key"
+	fSignerCmd = "signer-cmd"
+
+	fHex       = "hex"
+	fMemo      = "memo"
+	fFee       = "fee"
+	fLockSeq   = "lock-seq"
+	fLockUntil = "lock-until"
+	fRoyalty   = "royalty"
+	fPrice     = "price"
+
+	fName       = "name"
+	fDNA        = "dna"
+	fGeneration = "generation"
+	fImageURI   = "image-uri"
+
+	fReserve  = "reserve"
+	fDeadline = "deadline"
+
+	fRevoke = "revoke"
+	fFreeze = "freeze"
+
+	fWalletDir = "wallet-dir"
+	fWallet    = "wallet"
+	fPassword  = "password"
+	fNotes     = "notes"
+
+	// EnvSecretKey can be used to provide the secret key, so that it is not
+	// recorded in shell history.
+	EnvSecretKey = "KITTYCLI_SECRET_KEY"
+
+	// EnvPassword can be used to provide the wallet password.
+	EnvPassword = "KITTYCLI_WALLET_PASSWORD"
+
+	// EnvAPIKey can be used to provide the api key of the node.
+	EnvAPIKey = "KITTYCLI_API_KEY"
+)
+
+func Flag(flag string, short ...string) string {
+	if len(short) == 0 {
+		return flag
+	}
+	return flag + ", " + short[0]
+}
+
+var (
+	app = cli.NewApp()
+)
+
+func init() {
+	app.Name = "kittycli"
+	app.Usage = "inspect and administrate a running kittycash node"
+	app.Flags = cli.FlagsByName{
+		cli.StringFlag{
+			Name:  Flag(fNode, "n"),
+			Usage: "http address of the node to talk to",
+			Value: DefaultNodeAddress,
+		},
+		cli.StringFlag{
+			Name:   Flag(fAPIKey),
+			Usage:  "api key (of the 'X-API-Key' header) that authorises injecting transactions into a remote node",
+			EnvVar: EnvAPIKey,
+		},
+		cli.StringFlag{
+			Name:  Flag(fWalletDir),
+			Usage: "directory of wallet files",
+			Value: DefaultWalletDir,
+		},
+		cli.StringFlag{
+			Name:  Flag(fWallet, "w"),
+			Usage: "label of the wallet whose address book names addresses in output",
+		},
+		cli.StringFlag{
+			Name:   Flag(fPassword, "p"),
+			Usage:  "password of the wallet, if encrypted",
+			EnvVar: EnvPassword,
+		},
+	}
+	signerFlags := cli.FlagsByName{
+		cli.StringFlag{
+			Name:   Flag(fSecretKey, "sk"),
+			Usage:  "hex encoded secret key used to sign the transaction",
+			EnvVar: EnvSecretKey,
+		},
+		cli.StringFlag{
+			Name: Flag(fSignerCmd),
+			Usage: "program that signs with an external device instead of a secret key, " +
+				"invoked as '<cmd> address' and '<cmd> sign <hash_hex>'",
+		},
+	}
+	hexFlag := cli.BoolFlag{
+		Name:  Flag(fHex),
+		Usage: "output the transaction as hex, instead of json",
+	}
+	feeFlag := cli.Uint64Flag{
+		Name:  Flag(fFee),
+		Usage: "fee to pay, as required by the fee policy of the node",
+	}
+	transferFlags := cli.FlagsByName{
+		cli.StringFlag{
+			Name:  Flag(fMemo),
+			Usage: fmt.Sprintf("memo to attach to the transaction, such as an order ID (at most %d bytes)", iko.MaxMemoSize),
+		},
+		feeFlag,
+		cli.Uint64Flag{
+			Name:  Flag(fLockSeq),
+			Usage: "chain sequence until which the receiver cannot transfer the kitty",
+		},
+		cli.StringFlag{
+			Name:  Flag(fLockUntil),
+			Usage: "time (RFC 3339) until which the receiver cannot transfer the kitty",
+		},
+		cli.Uint64Flag{
+			Name:  Flag(fPrice),
+			Usage: "sale price of the kitty, of which the royalty of the kitty (if any) is paid to it's creator",
+		},
+	}
+	royaltyFlag := cli.UintFlag{
+		Name:  Flag(fRoyalty),
+		Usage: fmt.Sprintf("royalty (at most %d percent) paid to the creator on subsequent transfers", iko.MaxRoyaltyPercent),
+	}
+	app.Commands = cli.Commands{
+		{
+			Name:  "wallet",
+			Usage: "manage keys",
+			Subcommands: cli.Commands{
+				{
+					Name:  "gen-address",
+					Usage: "generate addresses with their public and secret keys",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  Flag(fSeed),
+							Usage: "seed to deterministically generate addresses from, a new seed is generated if empty",
+						},
+						cli.IntFlag{
+							Name:  Flag(fCount, "c"),
+							Usage: "number of addresses to generate",
+							Value: 1,
+						},
+					},
+					Action: walletGenAddress,
+				},
+			},
+		},
+		{
+			Name:  "addressbook",
+			Usage: "manage the address book of the wallet set with '--" + fWallet + "'",
+			Subcommands: cli.Commands{
+				{
+					Name:   "list",
+					Usage:  "list contacts",
+					Action: addressBookList,
+				},
+				{
+					Name:      "set",
+					Usage:     "add or rename a contact",
+					ArgsUsage: "<address> <name>",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  Flag(fNotes),
+							Usage: "notes about the contact",
+						},
+					},
+					Action: addressBookSet,
+				},
+				{
+					Name:      "remove",
+					Usage:     "remove a contact",
+					ArgsUsage: "<address>",
+					Action:    addressBookRemove,
+				},
+			},
+		},
+		{
+			Name:  "tx",
+			Usage: "inspect, and sign offline, transactions",
+			Subcommands: cli.Commands{
+				{
+					Name:      "get",
+					Usage:     "get a transaction of hash or seq",
+					ArgsUsage: "<hash|seq>",
+					Action:    txGet,
+				},
+				{
+					Name:      "build-mint",
+					Usage:     "build an unsigned generation transaction, for signing offline",
+					ArgsUsage: "<kitty_id> <creator_address>",
+					Flags:     cli.FlagsByName{hexFlag, royaltyFlag},
+					Action:    txBuildMint,
+				},
+				{
+					Name:      "build-transfer",
+					Usage:     "build an unsigned transfer transaction from the node's state, for signing offline",
+					ArgsUsage: "<kitty_id> <to_address>",
+					Flags:     append(cli.FlagsByName{hexFlag}, transferFlags...),
+					Action:    txBuildTransfer,
+				},
+				{
+					Name:  "build-admin",
+					Usage: "build an unsigned admin transaction of the node's creator quorum, for signing offline by a majority of the creators",
+					Flags: cli.FlagsByName{
+						hexFlag,
+						cli.StringSliceFlag{
+							Name:  Flag(fRevoke),
+							Usage: "public key of a creator to revoke, which can be repeated",
+						},
+						cli.BoolFlag{
+							Name:  Flag(fFreeze),
+							Usage: "permanently freeze the generation of kitties",
+						},
+					},
+					Action: txBuildAdmin,
+				},
+				{
+					Name:      "build-rotation",
+					Usage:     "build an unsigned rotation transaction of the node's creator quorum, that replaces the creator keys, for signing offline by a majority of the creators",
+					ArgsUsage: "<public_key>...",
+					Flags:     cli.FlagsByName{hexFlag},
+					Action:    txBuildRotation,
+				},
+				{
+					Name:      "sign",
+					Usage:     "sign a transaction of a json or hex file ('-' for stdin), without contacting the node",
+					ArgsUsage: "<file>",
+					Flags:     append(cli.FlagsByName{hexFlag}, signerFlags...),
+					Action:    txSign,
+				},
+				{
+					Name:      "inject",
+					Usage:     "inject a signed transaction of a json or hex file ('-' for stdin)",
+					ArgsUsage: "<file>",
+					Action:    txInject,
+				},
+			},
+		},
+		{
+			Name:  "kitty",
+			Usage: "inspect kitties",
+			Subcommands: cli.Commands{
+				{
+					Name:      "state",
+					Usage:     "get the owner and transactions of a kitty",
+					ArgsUsage: "<kitty_id>",
+					Action:    kittyState,
+				},
+				{
+					Name:      "mint",
+					Usage:     "create a kitty, signed with the creator's key",
+					ArgsUsage: "<kitty_id>",
+					Flags:     append(cli.FlagsByName{royaltyFlag}, signerFlags...),
+					Action:    kittyMint,
+				},
+				{
+					Name:      "transfer",
+					Usage:     "transfer a kitty, signed with the owner's key",
+					ArgsUsage: "<kitty_id> <to_address>",
+					Flags:     append(transferFlags, signerFlags...),
+					Action:    kittyTransfer,
+				},
+				{
+					Name:      "burn",
+					Usage:     "permanently retire a kitty, signed with the owner's key",
+					ArgsUsage: "<kitty_id>",
+					Flags:     append(transferFlags, signerFlags...),
+					Action:    kittyBurn,
+				},
+				{
+					Name:      "set-metadata",
+					Usage:     "set the metadata of a kitty, signed with the key allowed by the node's metadata policy",
+					ArgsUsage: "<kitty_id>",
+					Flags: append(cli.FlagsByName{
+						cli.StringFlag{
+							Name:  Flag(fName),
+							Usage: "name of the kitty",
+						},
+						cli.StringFlag{
+							Name:  Flag(fDNA),
+							Usage: "hex encoded dna of the kitty",
+						},
+						cli.UintFlag{
+							Name:  Flag(fGeneration),
+							Usage: "generation of the kitty",
+						},
+						cli.StringFlag{
+							Name:  Flag(fImageURI),
+							Usage: "uri of the kitty's image",
+						},
+						feeFlag,
+					}, signerFlags...),
+					Action: kittySetMetadata,
+				},
+				{
+					Name:      "breed",
+					Usage:     "breed a child from two kitties, signed with the owner's key",
+					ArgsUsage: "<parent_id> <parent_id> <child_id>",
+					Flags:     append(cli.FlagsByName{feeFlag}, signerFlags...),
+					Action:    kittyBreed,
+				},
+			},
+		},
+		{
+			Name:  "auction",
+			Usage: "list kitties for auction, and bid on them",
+			Subcommands: cli.Commands{
+				{
+					Name:   "ls",
+					Usage:  "get the active listings",
+					Action: auctionListings,
+				},
+				{
+					Name:      "list",
+					Usage:     "list a kitty for auction, signed with the owner's key",
+					ArgsUsage: "<kitty_id>",
+					Flags: append(cli.FlagsByName{
+						cli.Uint64Flag{
+							Name:  Flag(fReserve),
+							Usage: "minimum bid",
+						},
+						cli.StringFlag{
+							Name:  Flag(fDeadline),
+							Usage: "time at which bidding closes (RFC3339)",
+						},
+						feeFlag,
+					}, signerFlags...),
+					Action: auctionList,
+				},
+				{
+					Name:      "bid",
+					Usage:     "bid on a listed kitty, signed with the bidder's key",
+					ArgsUsage: "<kitty_id> <amount>",
+					Flags:     append(cli.FlagsByName{feeFlag}, signerFlags...),
+					Action:    auctionBid,
+				},
+				{
+					Name:      "settle",
+					Usage:     "release a listed kitty to the highest bidder after the deadline, signed with the seller's or bidder's key",
+					ArgsUsage: "<kitty_id>",
+					Flags:     append(cli.FlagsByName{feeFlag}, signerFlags...),
+					Action:    auctionSettle,
+				},
+				{
+					Name:      "cancel",
+					Usage:     "return a listed kitty without bids to the seller, signed with the seller's key",
+					ArgsUsage: "<kitty_id>",
+					Flags:     append(cli.FlagsByName{feeFlag}, signerFlags...),
+					Action:    auctionCancel,
+				},
+			},
+		},
+		{
+			Name:  "address",
+			Usage: "inspect addresses",
+			Subcommands: cli.Commands{
+				{
+					Name:      "state",
+					Usage:     "get the kitties and transactions of an address",
+					ArgsUsage: "<address>",
+					Action:    addressState,
+				},
+			},
+		},
+		{
+			Name:  "chain",
+			Usage: "inspect the blockchain",
+			Subcommands: cli.Commands{
+				{
+					Name:   "status",
+					Usage:  "get the status of the blockchain",
+					Action: chainStatus,
+				},
+				{
+					Name:   "head",
+					Usage:  "get the head transaction",
+					Action: chainHead,
+				},
+				{
+					Name:   "checkpoint",
+					Usage:  "get the checkpoint of the current state, for configuring as a trusted checkpoint",
+					Action: chainCheckpoint,
+				},
+				{
+					Name:  "verify",
+					Usage: "download and verify all transactions of the node",
+					Flags: cli.FlagsByName{
+						cli.Uint64Flag{
+							Name:  Flag(fPerPage),
+							Usage: "number of transactions to download per request",
+							Value: DefaultPerPage,
+						},
+						cli.BoolFlag{
+							Name:  Flag(fAudit),
+							Usage: "have the node re-validate it's chain and cross-check it's state instead, reporting the first divergence (only served to loopback addresses)",
+						},
+					},
+					Action: chainVerify,
+				},
+				{
+					Name:   "disk-usage",
+					Usage:  "get the disk usage of the node's chain db (only served to loopback addresses)",
+					Action: chainDiskUsage,
+				},
+				{
+					Name:   "compact",
+					Usage:  "reclaim unused space of the node's chain db, such as after pruning (only served to loopback addresses)",
+					Action: chainCompact,
+				},
+				{
+					Name:   "rotate-creators",
+					Usage:  "remove the signer's key from the keys trusted to create kitties, signed with that key (other rotations are built with 'tx build-rotation')",
+					Flags:  signerFlags,
+					Action: chainRotateCreators,
+				},
+			},
+		},
+	}
+}
+
+func client(ctx *cli.Context) *http.RPCClient {
+	c := http.NewRPCClient(ctx.GlobalString(fNode))
+	c.SetAPIKey(ctx.GlobalString(fAPIKey))
+	return c
+}
+
+func arg(ctx *cli.Context, name string) (string, error) {
+	if ctx.NArg() != 1 {
+		return "", fmt.Errorf("expected one argument <%s>", name)
+	}
+	return ctx.Args().First(), nil
+}
+
+// signer obtains the signer of either the '--signer-cmd' or '--secret-key'
+// flags.
+func signer(ctx *cli.Context) (iko.Signer, error) {
+	if cmd := ctx.String(fSignerCmd); cmd != "" {
+		return wallet.NewCommandSigner(cmd)
+	}
+	v := ctx.String(fSecretKey)
+	if v == "" {
+		return nil, fmt.Errorf("signer is required, set '--%s', '--%s' or '%s'",
+			fSignerCmd, fSecretKey, EnvSecretKey)
+	}
+	sk, e := cipher.SecKeyFromHex(v)
+	if e != nil {
+		return nil, e
+	}
+	if e := sk.Verify(); e != nil {
+		return nil, e
+	}
+	return iko.NewSecKeySigner(sk), nil
+}
+
+func walletGenAddress(ctx *cli.Context) error {
+	var (
+		seed  = ctx.String(fSeed)
+		count = ctx.Int(fCount)
+	)
+	if count < 1 {
+		return errors.New("count should be at least 1")
+	}
+	if seed == "" {
+		var e error
+		if seed, e = wallet.NewSeed(); e != nil {
+			return e
+		}
+	}
+	// This derivation matches that of wallet files.
+	sks := cipher.GenerateDeterministicKeyPairs([]byte(seed), count)
+	out := struct {
+		Seed    string                  `json:"seed"`
+		Entries []*wallet.FloatingEntry `json:"entries"`
+	}{
+		Seed: seed,
+	}
+	for _, sk := range sks {
+		entry, e := wallet.NewEntry(sk)
+		if e != nil {
+			return e
+		}
+		out.Entries = append(out.Entries, entry.ToFloating())
+	}
+	return printJson(out)
+}
+
+// loadWallet loads the wallet of the '--wallet' flag. It returns nil if the
+// flag is not set.
+func loadWallet(ctx *cli.Context) (*wallet.Wallet, error) {
+	label := ctx.GlobalString(fWallet)
+	if label == "" {
+		return nil, nil
+	}
+	if e := wallet.SetRootDir(ctx.GlobalString(fWalletDir)); e != nil {
+		return nil, e
+	}
+	f, e := os.Open(wallet.LabelPath(label))
+	if e != nil {
+		return nil, e
+	}
+	defer f.Close()
+	return wallet.LoadFloatingWallet(f, label, ctx.GlobalString(fPassword))
+}
+
+func requireWallet(ctx *cli.Context) (*wallet.Wallet, error) {
+	w, e := loadWallet(ctx)
+	if e == nil && w == nil {
+		e = fmt.Errorf("wallet is required, set '--%s'", fWallet)
+	}
+	return w, e
+}
+
+func addressBookList(ctx *cli.Context) error {
+	w, e := requireWallet(ctx)
+	if e != nil {
+		return e
+	}
+	return printJson(w.AddressBook.ToFloating())
+}
+
+func addressBookSet(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("expected arguments <address> <name>")
+	}
+	address, e := cipher.DecodeBase58Address(ctx.Args().Get(0))
+	if e != nil {
+		return e
+	}
+	w, e := requireWallet(ctx)
+	if e != nil {
+		return e
+	}
+	contact := wallet.Contact{
+		Address: address,
+		Name:    ctx.Args().Get(1),
+		Notes:   ctx.String(fNotes),
+	}
+	if e := w.AddressBook.Set(contact); e != nil {
+		return e
+	}
+	if e := w.Save(); e != nil {
+		return e
+	}
+	return printJson(contact.ToFloating())
+}
+
+func addressBookRemove(ctx *cli.Context) error {
+	v, e := arg(ctx, "address")
+	if e != nil {
+		return e
+	}
+	address, e := cipher.DecodeBase58Address(v)
+	if e != nil {
+		return e
+	}
+	w, e := requireWallet(ctx)
+	if e != nil {
+		return e
+	}
+	if e := w.AddressBook.Remove(address); e != nil {
+		return e
+	}
+	return w.Save()
+}
+
+func txGet(ctx *cli.Context) error {
+	v, e := arg(ctx, "hash|seq")
+	if e != nil {
+		return e
+	}
+	var reply *http.TxReply
+	if seq, e := strconv.ParseUint(v, 10, 64); e == nil {
+		reply, e = client(ctx).GetTxOfSeq(seq)
+		if e != nil {
+			return e
+		}
+	} else {
+		txHash, e := cipher.SHA256FromHex(v)
+		if e != nil {
+			return errors.New("argument should either be a tx hash or seq")
+		}
+		if reply, e = client(ctx).GetTxOfHash(iko.TxHash(txHash)); e != nil {
+			return e
+		}
+	}
+	return printJson(reply)
+}
+
+func txBuildMint(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("expected arguments <kitty_id> <creator_address>")
+	}
+	kittyID, e := iko.KittyIDFromString(ctx.Args().Get(0))
+	if e != nil {
+		return e
+	}
+	creator, e := cipher.DecodeBase58Address(ctx.Args().Get(1))
+	if e != nil {
+		return e
+	}
+	tx := iko.NewUnsignedGenTx(kittyID, creator)
+	if e := setGenRoyalty(ctx, tx); e != nil {
+		return e
+	}
+	if e := setNetwork(client(ctx), tx); e != nil {
+		return e
+	}
+	return printTx(ctx, tx, creator)
+}
+
+func txBuildAdmin(ctx *cli.Context) error {
+	admin := iko.TxAdmin{Freeze: ctx.Bool(fFreeze)}
+	for _, v := range ctx.StringSlice(fRevoke) {
+		pk, e := cipher.PubKeyFromHex(v)
+		if e != nil {
+			return e
+		}
+		admin.Revoke = append(admin.Revoke, pk)
+	}
+	status, e := client(ctx).GetStatus()
+	if e != nil {
+		return e
+	}
+	quorum, e := creatorQuorum(status)
+	if e != nil {
+		return e
+	}
+	tx, e := iko.NewAdminTx(quorum, admin)
+	if e != nil {
+		return e
+	}
+	tx.Network = status.NetworkID
+	return printTx(ctx, tx, quorum.Address())
+}
+
+func txBuildRotation(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errors.New("expected arguments <public_key>...")
+	}
+	creators := make([]cipher.PubKey, ctx.NArg())
+	for i, v := range ctx.Args() {
+		pk, e := cipher.PubKeyFromHex(v)
+		if e != nil {
+			return e
+		}
+		creators[i] = pk
+	}
+	status, e := client(ctx).GetStatus()
+	if e != nil {
+		return e
+	}
+	quorum, e := creatorQuorum(status)
+	if e != nil {
+		return e
+	}
+	tx, e := iko.NewQuorumRotationTx(quorum, creators, status.CreatorEpoch)
+	if e != nil {
+		return e
+	}
+	tx.Network = status.NetworkID
+	return printTx(ctx, tx, quorum.Address())
+}
+
+// creatorQuorum obtains the creator quorum of the node's status, as of
+// 'iko.BlockChain.CreatorQuorum'.
+func creatorQuorum(status *http.StatusReply) (*iko.Multisig, error) {
+	quorum := &iko.Multisig{M: uint8(len(status.CreatorPKs)/2 + 1)}
+	for _, v := range status.CreatorPKs {
+		pk, e := cipher.PubKeyFromHex(v)
+		if e != nil {
+			return nil, e
+		}
+		quorum.PubKeys = append(quorum.PubKeys, pk)
+	}
+	return quorum, nil
+}
+
+func txBuildTransfer(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("expected arguments <kitty_id> <to_address>")
+	}
+	kittyID, e := iko.KittyIDFromString(ctx.Args().Get(0))
+	if e != nil {
+		return e
+	}
+	to, e := cipher.DecodeBase58Address(ctx.Args().Get(1))
+	if e != nil {
+		return e
+	}
+	c := client(ctx)
+	tx, owner, e := c.NewUnsignedTransferTx(kittyID, to)
+	if e != nil {
+		return e
+	}
+	if e := setTransferFlags(ctx, tx); e != nil {
+		return e
+	}
+	if e := setTransferRoyalty(ctx, c, tx); e != nil {
+		return e
+	}
+	return printTx(ctx, tx, owner)
+}
+
+// setNetwork sets the network of an unsigned tx to that of the node, so that
+// the tx is signed for the node's network.
+func setNetwork(c *http.RPCClient, tx *iko.Transaction) error {
+	network, e := c.NetworkID()
+	if e != nil {
+		return e
+	}
+	tx.Network = network
+	return nil
+}
+
+// setTransferFlags sets the optional fields of an unsigned transfer tx from the
+// flags of 'transferFlags'.
+func setTransferFlags(ctx *cli.Context, tx *iko.Transaction) error {
+	tx.Memo = []byte(ctx.String(fMemo))
+	tx.Fee = ctx.Uint64(fFee)
+	tx.Lock.Seq = ctx.Uint64(fLockSeq)
+	if v := ctx.String(fLockUntil); v != "" {
+		t, e := time.Parse(time.RFC3339, v)
+		if e != nil {
+			return fmt.Errorf("invalid '--%s': %v", fLockUntil, e)
+		}
+		tx.Lock.TS = t.UnixNano()
+	}
+	return tx.VerifyMemo()
+}
+
+// setGenRoyalty sets the royalty of an unsigned generation tx, from the
+// '--royalty' flag.
+func setGenRoyalty(ctx *cli.Context, tx *iko.Transaction) error {
+	if v := ctx.Uint(fRoyalty); v > 0 {
+		if v > iko.MaxRoyaltyPercent {
+			return fmt.Errorf("invalid '--%s': at most %d percent", fRoyalty, iko.MaxRoyaltyPercent)
+		}
+		tx.Royalty = iko.NewTxRoyalty(uint8(v), 0)
+	}
+	return nil
+}
+
+// setTransferRoyalty acknowledges the royalty of the kitty of an unsigned
+// transfer tx (if any), at the price of the '--price' flag.
+func setTransferRoyalty(ctx *cli.Context, c *http.RPCClient, tx *iko.Transaction) error {
+	if !tx.IsRoyaltyTransfer() {
+		return nil
+	}
+	kitty, e := c.GetKitty(tx.KittyID)
+	if e != nil {
+		return e
+	}
+	if kitty.Royalty != nil {
+		tx.Royalty = iko.NewTxRoyalty(kitty.Royalty.Percent, ctx.Uint64(fPrice))
+	}
+	return nil
+}
+
+func txSign(ctx *cli.Context) error {
+	o, e := readTx(ctx)
+	if e != nil {
+		return e
+	}
+	s, e := signer(ctx)
+	if e != nil {
+		return e
+	}
+	if e := o.Sign(s); e != nil {
+		return e
+	}
+	if ctx.Bool(fHex) {
+		tx, _, e := o.Decode()
+		if e != nil {
+			return e
+		}
+		fmt.Println(iko.EncodeTxHex(tx))
+		return nil
+	}
+	return printJson(o)
+}
+
+func txInject(ctx *cli.Context) error {
+	o, e := readTx(ctx)
+	if e != nil {
+		return e
+	}
+	tx, _, e := o.Decode()
+	if e != nil {
+		return e
+	}
+	if !tx.IsSigned() {
+		return errors.New("transaction is not signed")
+	}
+	reply, e := client(ctx).InjectTx(tx)
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+// readTx reads a transaction of the file argument, encoded as either json or
+// hex.
+func readTx(ctx *cli.Context) (*iko.OfflineTx, error) {
+	path, e := arg(ctx, "file")
+	if e != nil {
+		return nil, e
+	}
+	var raw []byte
+	if path == "-" {
+		raw, e = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, e = ioutil.ReadFile(path)
+	}
+	if e != nil {
+		return nil, e
+	}
+	if raw = bytes.TrimSpace(raw); len(raw) > 0 && raw[0] == '{' {
+		o := new(iko.OfflineTx)
+		return o, json.Unmarshal(raw, o)
+	}
+	tx, e := iko.DecodeTxHex(string(raw))
+	if e != nil {
+		return nil, fmt.Errorf("transaction is neither json nor hex: %v", e)
+	}
+	return iko.NewOfflineTx(tx, cipher.Address{}), nil
+}
+
+func printTx(ctx *cli.Context, tx *iko.Transaction, owner cipher.Address) error {
+	if ctx.Bool(fHex) {
+		fmt.Println(iko.EncodeTxHex(tx))
+		return nil
+	}
+	return printJson(iko.NewOfflineTx(tx, owner))
+}
+
+func kittyState(ctx *cli.Context) error {
+	v, e := arg(ctx, "kitty_id")
+	if e != nil {
+		return e
+	}
+	kittyID, e := iko.KittyIDFromString(v)
+	if e != nil {
+		return e
+	}
+	reply, e := client(ctx).GetKitty(kittyID)
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func kittyMint(ctx *cli.Context) error {
+	v, e := arg(ctx, "kitty_id")
+	if e != nil {
+		return e
+	}
+	kittyID, e := iko.KittyIDFromString(v)
+	if e != nil {
+		return e
+	}
+	s, e := signer(ctx)
+	if e != nil {
+		return e
+	}
+	c := client(ctx)
+	tx := iko.NewUnsignedGenTx(kittyID, s.Address())
+	if e := setGenRoyalty(ctx, tx); e != nil {
+		return e
+	}
+	if e := setNetwork(c, tx); e != nil {
+		return e
+	}
+	if e := tx.SignWith(s); e != nil {
+		return e
+	}
+	reply, e := c.InjectTx(tx)
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func kittyTransfer(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("expected arguments <kitty_id> <to_address>")
+	}
+	kittyID, e := iko.KittyIDFromString(ctx.Args().Get(0))
+	if e != nil {
+		return e
+	}
+	to, e := cipher.DecodeBase58Address(ctx.Args().Get(1))
+	if e != nil {
+		return e
+	}
+	return sendTransfer(ctx, kittyID, to)
+}
+
+func kittyBurn(ctx *cli.Context) error {
+	v, e := arg(ctx, "kitty_id")
+	if e != nil {
+		return e
+	}
+	kittyID, e := iko.KittyIDFromString(v)
+	if e != nil {
+		return e
+	}
+	return sendTransfer(ctx, kittyID, iko.BurnAddress)
+}
+
+func kittySetMetadata(ctx *cli.Context) error {
+	v, e := arg(ctx, "kitty_id")
+	if e != nil {
+		return e
+	}
+	kittyID, e := iko.KittyIDFromString(v)
+	if e != nil {
+		return e
+	}
+	dna, e := hex.DecodeString(ctx.String(fDNA))
+	if e != nil {
+		return fmt.Errorf("invalid '--%s': %v", fDNA, e)
+	}
+	metadata := iko.KittyMetadata{
+		Name:       ctx.String(fName),
+		DNA:        dna,
+		Generation: uint32(ctx.Uint(fGeneration)),
+		ImageURI:   ctx.String(fImageURI),
+	}
+	if e := metadata.Verify(); e != nil {
+		return e
+	}
+	s, e := signer(ctx)
+	if e != nil {
+		return e
+	}
+	c := client(ctx)
+	in, e := c.GetKittyUnspentTx(kittyID)
+	if e != nil {
+		return e
+	}
+	tx := iko.NewUnsignedMetadataTx(in, metadata)
+	tx.KittyID = kittyID
+	tx.Fee = ctx.Uint64(fFee)
+	if e := setNetwork(c, tx); e != nil {
+		return e
+	}
+	if e := tx.SignWith(s); e != nil {
+		return e
+	}
+	reply, e := c.InjectTx(tx)
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func kittyBreed(ctx *cli.Context) error {
+	if ctx.NArg() != 3 {
+		return errors.New("expected arguments <parent_id> <parent_id> <child_id>")
+	}
+	var kittyIDs [3]iko.KittyID
+	for i := range kittyIDs {
+		kittyID, e := iko.KittyIDFromString(ctx.Args().Get(i))
+		if e != nil {
+			return e
+		}
+		kittyIDs[i] = kittyID
+	}
+	s, e := signer(ctx)
+	if e != nil {
+		return e
+	}
+	var (
+		c         = client(ctx)
+		parentIDs = kittyIDs[:2]
+		ins       = make([]*iko.Transaction, len(parentIDs))
+	)
+	for i, kittyID := range parentIDs {
+		if ins[i], e = c.GetKittyUnspentTx(kittyID); e != nil {
+			return e
+		}
+	}
+	if ins[0].Out != s.Address() {
+		return iko.ErrSignerNotOwner
+	}
+	tx, e := iko.NewUnsignedBreedTx(ins, parentIDs, kittyIDs[2])
+	if e != nil {
+		return e
+	}
+	tx.Fee = ctx.Uint64(fFee)
+	if e := setNetwork(c, tx); e != nil {
+		return e
+	}
+	if e := tx.SignWith(s); e != nil {
+		return e
+	}
+	reply, e := c.InjectTx(tx)
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func auctionListings(ctx *cli.Context) error {
+	reply, e := client(ctx).GetAuctions()
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func auctionList(ctx *cli.Context) error {
+	v := ctx.String(fDeadline)
+	if v == "" {
+		return fmt.Errorf("'--%s' is required", fDeadline)
+	}
+	deadline, e := time.Parse(time.RFC3339, v)
+	if e != nil {
+		return fmt.Errorf("invalid '--%s': %v", fDeadline, e)
+	}
+	return sendAuctionTx(ctx, func(in *iko.Transaction, _ iko.AuctionState, s iko.Signer) (*iko.Transaction, error) {
+		listing, e := iko.NewListing(s.Address(), ctx.Uint64(fReserve), deadline)
+		if e != nil {
+			return nil, e
+		}
+		return iko.NewAuctionListTx(in, listing)
+	})
+}
+
+func auctionBid(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("expected arguments <kitty_id> <amount>")
+	}
+	bid, e := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if e != nil {
+		return e
+	}
+	return sendAuctionTx(ctx, func(in *iko.Transaction, aState iko.AuctionState, _ iko.Signer) (*iko.Transaction, error) {
+		return iko.NewAuctionBidTx(in, &aState.Listing, bid)
+	})
+}
+
+func auctionSettle(ctx *cli.Context) error {
+	return sendAuctionTx(ctx, func(in *iko.Transaction, aState iko.AuctionState, _ iko.Signer) (*iko.Transaction, error) {
+		return iko.NewAuctionSettleTx(in, &aState.Listing, aState.Winner())
+	})
+}
+
+func auctionCancel(ctx *cli.Context) error {
+	return sendAuctionTx(ctx, func(in *iko.Transaction, aState iko.AuctionState, _ iko.Signer) (*iko.Transaction, error) {
+		return iko.NewAuctionCancelTx(in, &aState.Listing)
+	})
+}
+
+// sendAuctionTx builds an auction tx of the kitty of the first argument from
+// it's unspent tx and auction state (zero if not listed), then signs and
+// injects it.
+func sendAuctionTx(ctx *cli.Context, build func(in *iko.Transaction, aState iko.AuctionState, s iko.Signer) (*iko.Transaction, error)) error {
+	v := ctx.Args().First()
+	if v == "" {
+		return errors.New("expected argument <kitty_id>")
+	}
+	kittyID, e := iko.KittyIDFromString(v)
+	if e != nil {
+		return e
+	}
+	s, e := signer(ctx)
+	if e != nil {
+		return e
+	}
+	c := client(ctx)
+	kitty, e := c.GetKitty(kittyID)
+	if e != nil {
+		return e
+	}
+	var aState iko.AuctionState
+	if kitty.Auction != nil {
+		if aState, e = kitty.Auction.State(); e != nil {
+			return e
+		}
+	}
+	in, e := c.GetKittyUnspentTx(kittyID)
+	if e != nil {
+		return e
+	}
+	tx, e := build(in, aState, s)
+	if e != nil {
+		return e
+	}
+	tx.KittyID = kittyID
+	tx.Fee = ctx.Uint64(fFee)
+	if e := setNetwork(c, tx); e != nil {
+		return e
+	}
+	if e := tx.SignWith(s); e != nil {
+		return e
+	}
+	reply, e := c.InjectTx(tx)
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+// sendTransfer signs a transfer of the kitty with the owner's key, and injects
+// it.
+func sendTransfer(ctx *cli.Context, kittyID iko.KittyID, to cipher.Address) error {
+	s, e := signer(ctx)
+	if e != nil {
+		return e
+	}
+	c := client(ctx)
+	tx, owner, e := c.NewUnsignedTransferTx(kittyID, to)
+	if e != nil {
+		return e
+	}
+	if owner != s.Address() {
+		return iko.ErrSignerNotOwner
+	}
+	if e := setTransferFlags(ctx, tx); e != nil {
+		return e
+	}
+	if e := setTransferRoyalty(ctx, c, tx); e != nil {
+		return e
+	}
+	if e := tx.SignWith(s); e != nil {
+		return e
+	}
+	reply, e := c.InjectTx(tx)
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func addressState(ctx *cli.Context) error {
+	v, e := arg(ctx, "address")
+	if e != nil {
+		return e
+	}
+	address, e := cipher.DecodeBase58Address(v)
+	if e != nil {
+		return e
+	}
+	reply, e := client(ctx).GetAddress(address)
+	if e != nil {
+		return e
+	}
+	w, e := loadWallet(ctx)
+	if e != nil {
+		return e
+	}
+	if w == nil {
+		return printJson(reply)
+	}
+	return printJson(struct {
+		Name string `json:"name,omitempty"`
+		*http.AddressReply
+	}{
+		Name:         w.AddressBook.Name(address),
+		AddressReply: reply,
+	})
+}
+
+func chainStatus(ctx *cli.Context) error {
+	reply, e := client(ctx).GetStatus()
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func chainHead(ctx *cli.Context) error {
+	reply, e := client(ctx).GetHeadTx()
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func chainCheckpoint(ctx *cli.Context) error {
+	reply, e := client(ctx).GetCheckpoint()
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func chainRotateCreators(ctx *cli.Context) error {
+	s, e := signer(ctx)
+	if e != nil {
+		return e
+	}
+	c := client(ctx)
+	status, e := c.GetStatus()
+	if e != nil {
+		return e
+	}
+	var (
+		creators  []cipher.PubKey
+		isCreator bool
+	)
+	for _, v := range status.CreatorPKs {
+		pk, e := cipher.PubKeyFromHex(v)
+		if e != nil {
+			return e
+		}
+		if cipher.AddressFromPubKey(pk) == s.Address() {
+			isCreator = true
+			continue
+		}
+		creators = append(creators, pk)
+	}
+	if !isCreator {
+		return iko.ErrNotCreator
+	}
+	tx := iko.NewUnsignedRotationTx(creators, status.CreatorEpoch, s.Address())
+	if e := tx.VerifyRotation(); e != nil {
+		return e
+	}
+	tx.Network = status.NetworkID
+	if e := tx.SignWith(s); e != nil {
+		return e
+	}
+	reply, e := c.InjectTx(tx)
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func chainVerify(ctx *cli.Context) error {
+	if ctx.Bool(fAudit) {
+		reply, e := client(ctx).AuditChain()
+		if e != nil {
+			return e
+		}
+		if reply.Diverged {
+			return fmt.Errorf("chain diverges at seq %d: %s: %s", reply.Seq, reply.Reason, reply.Error)
+		}
+		fmt.Println("chain and state agree")
+		return nil
+	}
+	count, e := client(ctx).VerifyChain(ctx.Uint64(fPerPage))
+	if e != nil {
+		return fmt.Errorf("verification failed after %d txs: %v", count, e)
+	}
+	fmt.Printf("verified %d transactions\n", count)
+	return nil
+}
+
+func chainDiskUsage(ctx *cli.Context) error {
+	reply, e := client(ctx).GetDiskUsage()
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func chainCompact(ctx *cli.Context) error {
+	reply, e := client(ctx).Compact()
+	if e != nil {
+		return e
+	}
+	return printJson(reply)
+}
+
+func printJson(v interface{}) error {
+	out, e := json.MarshalIndent(v, "", "    ")
+	if e != nil {
+		return e
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func main() {
+	if e := app.Run(os.Args); e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/kittycash/wallet/src/signer"
+	"github.com/kittycash/wallet/src/util"
+	"github.com/kittycash/wallet/src/wallet"
+)
+
+const (
+	DefaultAddress  = "127.0.0.1:7909"
+	DefaultKeyStore = "./kittysigner.json"
+)
+
+const (
+	fKeyStore   = "key-store"
+	fAddress    = "address"
+	fSecretFile = "secret-file"
+	fTLSCert    = "tls-cert"
+	fTLSKey     = "tls-key"
+	fLabel      = "label"
+
+	// EnvPassword can be used to provide the key store password, instead of
+	// being prompted for it.
+	EnvPassword = "KITTYSIGNER_PASSWORD"
+
+	// EnvSecretKey can be used to provide the secret key to add, instead of
+	// being prompted for it.
+	EnvSecretKey = "KITTYSIGNER_SECRET_KEY"
+)
+
+func Flag(flag string, short ...string) string {
+	if len(short) == 0 {
+		return flag
+	}
+	return flag + ", " + short[0]
+}
+
+var (
+	app = cli.NewApp()
+)
+
+func init() {
+	app.Name = "kittysigner"
+	app.Usage = "sign kittycash transactions for remote nodes, so that secret keys stay on this machine"
+	app.Flags = cli.FlagsByName{
+		cli.StringFlag{
+			Name:  Flag(fKeyStore, "k"),
+			Usage: "encrypted key store file holding the secret keys",
+			Value: DefaultKeyStore,
+		},
+	}
+	app.Commands = cli.Commands{
+		{
+			Name:   "init",
+			Usage:  "create an empty key store",
+			Action: initKeyStore,
+		},
+		{
+			Name:  "add",
+			Usage: "add a secret key to the key store",
+			Flags: cli.FlagsByName{
+				cli.StringFlag{
+					Name:  Flag(fLabel),
+					Usage: "label of the key",
+				},
+			},
+			Action: addKey,
+		},
+		{
+			Name:  "serve",
+			Usage: "serve signatures to nodes that know the shared secret",
+			Flags: cli.FlagsByName{
+				cli.StringFlag{
+					Name:  Flag(fAddress, "a"),
+					Usage: "address to listen on",
+					Value: DefaultAddress,
+				},
+				cli.StringFlag{
+					Name:  Flag(fSecretFile),
+					Usage: fmt.Sprintf("file of the secret shared with nodes, of at least %d bytes", signer.MinSecretSize),
+				},
+				cli.StringFlag{
+					Name:  Flag(fTLSCert),
+					Usage: "tls certificate file, strongly recommended",
+				},
+				cli.StringFlag{
+					Name:  Flag(fTLSKey),
+					Usage: "tls key file, strongly recommended",
+				},
+			},
+			Action: serve,
+		},
+	}
+}
+
+func initKeyStore(ctx *cli.Context) error {
+	path := ctx.GlobalString(fKeyStore)
+	if _, e := os.Stat(path); e == nil {
+		return fmt.Errorf("key store '%s' already exists", path)
+	}
+	password, e := readPassword("new key store password: ")
+	if e != nil {
+		return e
+	}
+	if os.Getenv(EnvPassword) == "" {
+		confirm, e := readPassword("confirm password: ")
+		if e != nil {
+			return e
+		}
+		if confirm != password {
+			return errors.New("passwords do not match")
+		}
+	}
+	ks, e := wallet.NewKeyStore(path, password, nil)
+	if e != nil {
+		return e
+	}
+	return ks.Save()
+}
+
+func addKey(ctx *cli.Context) error {
+	ks, e := unlockKeyStore(ctx)
+	if e != nil {
+		return e
+	}
+	defer ks.Lock()
+
+	skHex := os.Getenv(EnvSecretKey)
+	if skHex == "" {
+		if skHex, e = readSecret("secret key: "); e != nil {
+			return e
+		}
+	}
+	sk, e := cipher.SecKeyFromHex(skHex)
+	if e != nil {
+		return e
+	}
+	address, e := ks.Add(sk, ctx.String(fLabel))
+	if e != nil {
+		return e
+	}
+	if e := ks.Save(); e != nil {
+		return e
+	}
+	fmt.Println(address.String())
+	return nil
+}
+
+func serve(ctx *cli.Context) error {
+	secretFile := ctx.String(fSecretFile)
+	if secretFile == "" {
+		return fmt.Errorf("shared secret is required, set '--%s'", fSecretFile)
+	}
+	secret, e := signer.ReadSecretFile(secretFile)
+	if e != nil {
+		return e
+	}
+	ks, e := unlockKeyStore(ctx)
+	if e != nil {
+		return e
+	}
+	defer ks.Lock()
+
+	srv, e := signer.NewServer(&signer.ServerConfig{
+		Address:     ctx.String(fAddress),
+		Secret:      secret,
+		TLSCertFile: ctx.String(fTLSCert),
+		TLSKeyFile:  ctx.String(fTLSKey),
+	}, ks)
+	if e != nil {
+		return e
+	}
+	defer srv.Close()
+
+	<-util.CatchInterrupt()
+	return nil
+}
+
+func unlockKeyStore(ctx *cli.Context) (*wallet.KeyStore, error) {
+	ks, e := wallet.LoadKeyStore(ctx.GlobalString(fKeyStore))
+	if e != nil {
+		return nil, e
+	}
+	password, e := readPassword("key store password: ")
+	if e != nil {
+		return nil, e
+	}
+	return ks, ks.Unlock(password, 0)
+}
+
+func readPassword(prompt string) (string, error) {
+	if password := os.Getenv(EnvPassword); password != "" {
+		return password, nil
+	}
+	return readSecret(prompt)
+}
+
+func readSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+	raw, e := terminal.ReadPassword(int(syscall.Stdin))
+	return string(raw), e
+}
+
+func main() {
+	if e := app.Run(os.Args); e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		os.Exit(1)
+	}
+}
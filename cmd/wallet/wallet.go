@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
+	_ "github.com/mattn/go-sqlite3" // sql driver of the "sqlite" chain db
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/util/file"
 	"gopkg.in/sirupsen/logrus.v1"
@@ -12,7 +14,9 @@ import (
 
 	"github.com/kittycash/wallet/src/http"
 	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/signer"
 	"github.com/kittycash/wallet/src/util"
+	"github.com/kittycash/wallet/src/util/metrics"
 	"github.com/kittycash/wallet/src/wallet"
 )
 
@@ -29,6 +33,8 @@ const (
 	DirRoot         = ".kittycash"
 	DirChildCXO     = "cxo"
 	DirChildWallets = "wallets"
+
+	FileBroadcastQueue = "broadcast_queue.db"
 )
 
 const (
@@ -39,12 +45,33 @@ const (
 
 	fWalletDir = "wallet-dir"
 
+	fUpstreamNode   = "upstream-node"
+	fUpstreamCA     = "upstream-ca"
+	fUpstreamCert   = "upstream-cert"
+	fUpstreamKey    = "upstream-key"
+	fUpstreamAPIKey = "upstream-api-key"
+	fBroadcastQueue = "broadcast-queue"
+
 	fHttpAddress = "http-address"
 	fGUI         = "gui"
 	fGUIDir      = "gui-dir"
 	fTLS         = "tls"
 	fTLSCert     = "tls-cert"
 	fTLSKey      = "tls-key"
+	fTLSClientCA = "tls-client-ca"
+	fAdmin       = "admin"
+
+	fRateLimit      = "rate-limit"
+	fRateBurst      = "rate-burst"
+	fRateLimitKeys  = "rate-limit-keys"
+	fTrustForwarded = "trust-forwarded"
+
+	fAPIKeys           = "api-keys"
+	fAPIHMACSecretFile = "api-hmac-secret-file"
+
+	fCORSOrigins = "cors-origins"
+	fCORSMethods = "cors-methods"
+	fCORSHeaders = "cors-headers"
 
 	fTest          = "test"
 	fTestGenPK     = "test-gen-pk"
@@ -105,6 +132,34 @@ func init() {
 			Usage: "directory to store wallet files",
 			Value: filepath.Join(homeDir, DirRoot, DirChildWallets),
 		},
+		/*
+			<<< BROADCAST >>>
+		*/
+		cli.StringFlag{
+			Name:  Flag(fUpstreamNode),
+			Usage: "http address of the iko node to relay transactions to, leave blank to disable relaying",
+		},
+		cli.StringFlag{
+			Name:  Flag(fUpstreamCA),
+			Usage: "ca certificate file to verify the upstream node's tls certificate, enables https",
+		},
+		cli.StringFlag{
+			Name:  Flag(fUpstreamCert),
+			Usage: "client certificate file to present to the upstream node (mutual tls), enables https",
+		},
+		cli.StringFlag{
+			Name:  Flag(fUpstreamKey),
+			Usage: "client key file of the upstream client certificate",
+		},
+		cli.StringFlag{
+			Name:  Flag(fUpstreamAPIKey),
+			Usage: "api key (of the 'X-API-Key' header) that authorises relaying to the upstream node",
+		},
+		cli.StringFlag{
+			Name:  Flag(fBroadcastQueue),
+			Usage: "file of the queue of transactions awaiting acknowledgement of the upstream node",
+			Value: filepath.Join(homeDir, DirRoot, FileBroadcastQueue),
+		},
 		/*
 			<<< HTTP SERVER >>>
 		*/
@@ -134,6 +189,59 @@ func init() {
 			Name:  Flag(fTLSKey),
 			Usage: "tls key file path",
 		},
+		cli.StringFlag{
+			Name:  Flag(fTLSClientCA),
+			Usage: "ca certificate file that client certificates are required to be signed by (mutual tls)",
+		},
+		cli.BoolFlag{
+			Name:  Flag(fAdmin),
+			Usage: "whether to serve pprof and runtime stats under '/admin/' to loopback clients",
+		},
+		/*
+			<<< RATE LIMIT >>>
+		*/
+		cli.Float64Flag{
+			Name:  Flag(fRateLimit),
+			Usage: "requests per second allowed of each client ip, 0 to not limit",
+		},
+		cli.IntFlag{
+			Name:  Flag(fRateBurst),
+			Usage: "requests allowed at once of each client ip, 0 to default to the rate limit",
+		},
+		cli.StringSliceFlag{
+			Name:  Flag(fRateLimitKeys),
+			Usage: "rate limits of api keys (of the 'X-API-Key' header), of the form '<key>:<rate>[:<burst>]'",
+		},
+		cli.BoolFlag{
+			Name:  Flag(fTrustForwarded),
+			Usage: "whether to rate limit client ips of the 'X-Forwarded-For' header, when behind a trusted proxy",
+		},
+		/*
+			<<< AUTH >>>
+		*/
+		cli.StringSliceFlag{
+			Name:  Flag(fAPIKeys),
+			Usage: "api keys (of the 'X-API-Key' header) that authorise mutating and admin routes",
+		},
+		cli.StringFlag{
+			Name:  Flag(fAPIHMACSecretFile),
+			Usage: "file of the secret that authorises HMAC-signed requests of mutating and admin routes",
+		},
+		/*
+			<<< CORS >>>
+		*/
+		cli.StringSliceFlag{
+			Name:  Flag(fCORSOrigins),
+			Usage: "origins allowed to make cross-origin requests, or '*' for any, leave blank to disable cors",
+		},
+		cli.StringSliceFlag{
+			Name:  Flag(fCORSMethods),
+			Usage: "methods allowed of cross-origin requests, defaults to GET and POST",
+		},
+		cli.StringSliceFlag{
+			Name:  Flag(fCORSHeaders),
+			Usage: "request headers allowed of cross-origin requests, defaults to the content type and auth headers",
+		},
 		/*
 			<<< TEST MODE >>>
 		*/
@@ -160,6 +268,14 @@ func init() {
 func action(ctx *cli.Context) error {
 	quit := util.CatchInterrupt()
 
+	// Quitting cancels the context, which also stops replaying the chain.
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go func() {
+		<-quit
+		stop()
+	}()
+
 	var (
 		rootPK = cipher.MustPubKeyFromHex(TrustedRootPK)
 		rootNc = TrustedRootNonce
@@ -167,6 +283,13 @@ func action(ctx *cli.Context) error {
 
 		walletDir = ctx.String(fWalletDir)
 
+		upstreamNode   = ctx.String(fUpstreamNode)
+		upstreamCA     = ctx.String(fUpstreamCA)
+		upstreamCert   = ctx.String(fUpstreamCert)
+		upstreamKey    = ctx.String(fUpstreamKey)
+		upstreamAPIKey = ctx.String(fUpstreamAPIKey)
+		broadcastQueue = ctx.String(fBroadcastQueue)
+
 		cxoDir             = ctx.String(fCXODir)
 		cxoAddress         = ctx.String(fCXOAddress)
 		cxoRPCAddress      = ctx.String(fCXORPCAddress)
@@ -178,6 +301,20 @@ func action(ctx *cli.Context) error {
 		tls         = ctx.Bool(fTLS)
 		tlsCert     = ctx.String(fTLSCert)
 		tlsKey      = ctx.String(fTLSKey)
+		tlsClientCA = ctx.String(fTLSClientCA)
+		admin       = ctx.Bool(fAdmin)
+
+		rateLimit      = ctx.Float64(fRateLimit)
+		rateBurst      = ctx.Int(fRateBurst)
+		rateLimitKeys  = ctx.StringSlice(fRateLimitKeys)
+		trustForwarded = ctx.Bool(fTrustForwarded)
+
+		apiKeys           = ctx.StringSlice(fAPIKeys)
+		apiHMACSecretFile = ctx.String(fAPIHMACSecretFile)
+
+		corsOrigins = ctx.StringSlice(fCORSOrigins)
+		corsMethods = ctx.StringSlice(fCORSMethods)
+		corsHeaders = ctx.StringSlice(fCORSHeaders)
 
 		test = ctx.Bool(fTest)
 	)
@@ -194,6 +331,7 @@ func action(ctx *cli.Context) error {
 		}
 		defer os.RemoveAll(tempDir)
 		walletDir = tempDir
+		broadcastQueue = filepath.Join(tempDir, FileBroadcastQueue)
 	}
 
 	// Prepare StateDB.
@@ -225,7 +363,7 @@ func action(ctx *cli.Context) error {
 	}
 
 	// Prepare blockchain.
-	bc, err := iko.NewBlockChain(bcConfig, cxoChain, stateDB)
+	bc, err := iko.NewBlockChain(runCtx, bcConfig, cxoChain, stateDB)
 	if err != nil {
 		return err
 	}
@@ -246,19 +384,85 @@ func action(ctx *cli.Context) error {
 		return err
 	}
 
+	// Prepare broadcast queue.
+	var bq *iko.BroadcastQueue
+	if upstreamNode != "" {
+		upstream := http.NewRPCClient(upstreamNode)
+		if upstreamCA != "" || upstreamCert != "" {
+			tlsConfig, err := util.ClientTLSConfig(upstreamCA, upstreamCert, upstreamKey)
+			if err != nil {
+				return err
+			}
+			upstream = http.NewRPCClientTLS(upstreamNode, tlsConfig)
+		}
+		upstream.SetAPIKey(upstreamAPIKey)
+		bq, err = iko.NewBroadcastQueue(&iko.BroadcastQueueConfig{
+			Path: broadcastQueue,
+		}, upstream)
+		if err != nil {
+			return err
+		}
+		defer bq.Close()
+	}
+
+	// Prepare rate limiting.
+	var rateLimitConfig *http.RateLimitConfig
+	if rateLimit > 0 || len(rateLimitKeys) > 0 {
+		rateLimitConfig = &http.RateLimitConfig{
+			IP:             http.RateLimit{Rate: rateLimit, Burst: rateBurst},
+			Keys:           make(map[string]http.RateLimit),
+			TrustForwarded: trustForwarded,
+		}
+		for _, spec := range rateLimitKeys {
+			key, limit, err := http.ParseRateLimitKey(spec)
+			if err != nil {
+				return err
+			}
+			rateLimitConfig.Keys[key] = limit
+		}
+	}
+
+	// Prepare auth.
+	var authConfig *http.AuthConfig
+	if len(apiKeys) > 0 || apiHMACSecretFile != "" {
+		authConfig = &http.AuthConfig{APIKeys: apiKeys}
+		if apiHMACSecretFile != "" {
+			if authConfig.HMACSecret, err = signer.ReadSecretFile(apiHMACSecretFile); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Prepare cors.
+	var corsConfig *http.CORSConfig
+	if len(corsOrigins) > 0 {
+		corsConfig = &http.CORSConfig{
+			AllowedOrigins: corsOrigins,
+			AllowedMethods: corsMethods,
+			AllowedHeaders: corsHeaders,
+		}
+	}
+
 	// Prepare http server.
 	httpServer, err := http.NewServer(
 		&http.ServerConfig{
-			Address:     httpAddress,
-			EnableGUI:   gui,
-			GUIDir:      guiDir,
-			EnableTLS:   tls,
-			TLSCertFile: tlsCert,
-			TLSKeyFile:  tlsKey,
+			Address:         httpAddress,
+			EnableGUI:       gui,
+			GUIDir:          guiDir,
+			EnableTLS:       tls,
+			TLSCertFile:     tlsCert,
+			TLSKeyFile:      tlsKey,
+			TLSClientCAFile: tlsClientCA,
+			RateLimit:       rateLimitConfig,
+			Auth:            authConfig,
+			CORS:            corsConfig,
 		},
 		&http.Gateway{
-			IKO:    bc,
-			Wallet: walletManager,
+			IKO:       bc,
+			Wallet:    walletManager,
+			Broadcast: bq,
+			Metrics:   metrics.DefaultRegistry,
+			Admin:     admin,
 		},
 	)
 	if err != nil {
@@ -266,7 +470,7 @@ func action(ctx *cli.Context) error {
 	}
 	defer httpServer.Close()
 
-	<-quit
+	<-runCtx.Done()
 	return nil
 }
 
@@ -0,0 +1,205 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/util/protowire"
+)
+
+// Message is a protobuf message of the IKO service.
+type Message interface {
+	MarshalProto() []byte
+	UnmarshalProto(b []byte) error
+}
+
+type GetTxRequest struct {
+	Hash  *iko.TxHash // Set to query by hash.
+	Seq   uint64
+	BySeq bool // Set to query by seq.
+}
+
+func (r GetTxRequest) MarshalProto() []byte {
+	var b []byte
+	switch {
+	case r.Hash != nil:
+		b = protowire.AppendBytes(b, 1, r.Hash[:])
+	case r.BySeq:
+		b = protowire.AppendVarint(b, 2, r.Seq)
+	}
+	return b
+}
+
+func (r *GetTxRequest) UnmarshalProto(b []byte) error {
+	*r = GetTxRequest{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			r.Hash, r.BySeq = new(iko.TxHash), false
+			return protowire.CopyFixed(r.Hash[:], raw, "hash")
+		case 2:
+			r.Hash, r.Seq, r.BySeq = nil, v, true
+		}
+		return nil
+	})
+}
+
+type GetKittyStateRequest struct {
+	KittyID iko.KittyID
+}
+
+func (r GetKittyStateRequest) MarshalProto() []byte {
+	return protowire.AppendVarint(nil, 1, uint64(r.KittyID))
+}
+
+func (r *GetKittyStateRequest) UnmarshalProto(b []byte) error {
+	*r = GetKittyStateRequest{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		if field == 1 {
+			r.KittyID = iko.KittyID(v)
+		}
+		return nil
+	})
+}
+
+type GetAddressStateRequest struct {
+	Address cipher.Address
+}
+
+func (r GetAddressStateRequest) MarshalProto() []byte {
+	return protowire.AppendBytes(nil, 1, []byte(r.Address.String()))
+}
+
+func (r *GetAddressStateRequest) UnmarshalProto(b []byte) error {
+	*r = GetAddressStateRequest{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		var e error
+		if field == 1 {
+			r.Address, e = cipher.DecodeBase58Address(string(raw))
+		}
+		return e
+	})
+}
+
+type InjectTxRequest struct {
+	Tx iko.Transaction
+}
+
+func (r InjectTxRequest) MarshalProto() []byte {
+	return protowire.AppendBytes(nil, 1, r.Tx.MarshalProto())
+}
+
+func (r *InjectTxRequest) UnmarshalProto(b []byte) error {
+	*r = InjectTxRequest{}
+	seen := false
+	e := protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		if field == 1 {
+			seen = true
+			return r.Tx.UnmarshalProto(raw)
+		}
+		return nil
+	})
+	if e == nil && !seen {
+		e = errors.New("no tx in request")
+	}
+	return e
+}
+
+type InjectTxResponse struct {
+	Meta iko.TxMeta
+}
+
+func (r InjectTxResponse) MarshalProto() []byte {
+	return protowire.AppendBytes(nil, 1, r.Meta.MarshalProto())
+}
+
+func (r *InjectTxResponse) UnmarshalProto(b []byte) error {
+	*r = InjectTxResponse{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		if field == 1 {
+			return r.Meta.UnmarshalProto(raw)
+		}
+		return nil
+	})
+}
+
+type SubscribeTxsRequest struct {
+	Addresses []cipher.Address
+	KittyIDs  []iko.KittyID
+	Bloom     *iko.BloomFilter // Optional.
+}
+
+func (r SubscribeTxsRequest) MarshalProto() []byte {
+	var b []byte
+	for _, address := range r.Addresses {
+		b = protowire.AppendBytes(b, 1, []byte(address.String()))
+	}
+	var packed []byte
+	for _, kittyID := range r.KittyIDs {
+		packed = protowire.AppendUvarint(packed, uint64(kittyID))
+	}
+	if len(packed) > 0 {
+		b = protowire.AppendBytes(b, 2, packed)
+	}
+	if r.Bloom != nil {
+		b = protowire.AppendBytes(b, 3, r.Bloom.MarshalProto())
+	}
+	return b
+}
+
+func (r *SubscribeTxsRequest) UnmarshalProto(b []byte) error {
+	*r = SubscribeTxsRequest{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			address, e := cipher.DecodeBase58Address(string(raw))
+			if e != nil {
+				return e
+			}
+			r.Addresses = append(r.Addresses, address)
+		case 2:
+			if wire == protowire.Varint {
+				r.KittyIDs = append(r.KittyIDs, iko.KittyID(v))
+				return nil
+			}
+			ids, e := protowire.Uvarints(raw)
+			if e != nil {
+				return e
+			}
+			for _, id := range ids {
+				r.KittyIDs = append(r.KittyIDs, iko.KittyID(id))
+			}
+		case 3:
+			r.Bloom = new(iko.BloomFilter)
+			if e := r.Bloom.UnmarshalProto(raw); e != nil {
+				return e
+			}
+			return r.Bloom.Verify()
+		}
+		return nil
+	})
+}
+
+type StreamTxsRequest struct {
+	SinceSeq uint64
+	HasSince bool // Set to stream txs of seq greater than 'SinceSeq'.
+}
+
+func (r StreamTxsRequest) MarshalProto() []byte {
+	if !r.HasSince {
+		return nil
+	}
+	return protowire.AppendVarint(nil, 1, r.SinceSeq)
+}
+
+func (r *StreamTxsRequest) UnmarshalProto(b []byte) error {
+	*r = StreamTxsRequest{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		if field == 1 {
+			r.SinceSeq, r.HasSince = v, true
+		}
+		return nil
+	})
+}
@@ -0,0 +1,342 @@
+// Package grpc serves the IKO service defined in 'src/iko/iko.proto' using
+// the gRPC protocol over HTTP/2, so that typed clients can be generated from
+// the .proto file in any language.
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/sirupsen/logrus.v1"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/util"
+)
+
+const (
+	ServiceName = "iko.IKO"
+
+	contentType    = "application/grpc"
+	maxMessageSize = 4 << 20
+	subBufSize     = 128
+)
+
+// Code is a gRPC status code.
+type Code int
+
+const (
+	CodeOK                Code = 0
+	CodeInvalidArgument   Code = 3
+	CodeNotFound          Code = 5
+	CodeAlreadyExists     Code = 6
+	CodePermissionDenied  Code = 7
+	CodeResourceExhausted Code = 8
+	CodeUnimplemented     Code = 12
+	CodeInternal          Code = 13
+	CodeUnavailable       Code = 14
+)
+
+// Status is the result of a gRPC call.
+type Status struct {
+	Code    Code
+	Message string
+}
+
+func (s *Status) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", s.Code, s.Message)
+}
+
+func statusf(code Code, format string, a ...interface{}) *Status {
+	return &Status{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// statusOfError obtains the status of an error returned by the blockchain,
+// with code 'def' if the error is not of a known kind.
+func statusOfError(e error, def Code) *Status {
+	code := def
+	switch {
+	case errors.Is(e, iko.ErrTxNotFound), errors.Is(e, iko.ErrKittyNotFound):
+		code = CodeNotFound
+	case errors.Is(e, iko.ErrKittyAlreadyExists):
+		code = CodeAlreadyExists
+	case errors.Is(e, iko.ErrNotOwner), errors.Is(e, iko.ErrReplica):
+		code = CodePermissionDenied
+	case errors.Is(e, iko.ErrChainFull):
+		code = CodeResourceExhausted
+	case errors.Is(e, iko.ErrChainStopped):
+		code = CodeUnavailable
+	}
+	return &Status{Code: code, Message: e.Error()}
+}
+
+type ServerConfig struct {
+	Address         string
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string // Optional, to require client certificates of the CAs (mutual TLS).
+}
+
+// Server serves the IKO service. HTTP/2 is only available over TLS in the
+// standard library, hence a certificate and key are required.
+type Server struct {
+	c   *ServerConfig
+	l   *logrus.Logger
+	lis net.Listener
+	srv *http.Server
+	wg  sync.WaitGroup
+}
+
+func NewServer(c *ServerConfig, bc *iko.BlockChain) (*Server, error) {
+	if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+		return nil, errors.New("grpc server requires a tls certificate and key")
+	}
+	tlsConfig, e := util.ServerTLSConfig(c.TLSCertFile, c.TLSKeyFile, c.TLSClientCAFile)
+	if e != nil {
+		return nil, e
+	}
+	s := &Server{
+		c: c,
+		l: logrus.New(),
+		srv: &http.Server{
+			Handler:   NewHandler(bc),
+			TLSConfig: tlsConfig,
+		},
+	}
+	if s.lis, e = net.Listen("tcp", c.Address); e != nil {
+		return nil, e
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if e := s.srv.ServeTLS(s.lis, "", ""); e != http.ErrServerClosed {
+			s.l.WithError(e).Error("grpc server stopped")
+		}
+	}()
+	s.l.Infof("grpc listening on: '%s'", c.Address)
+	return s, nil
+}
+
+func (s *Server) Close() {
+	if e := s.srv.Close(); e != nil {
+		s.l.WithError(e).Error("error on close")
+	}
+	s.wg.Wait()
+}
+
+// Handler serves the IKO service over gRPC. It can also be mounted on an
+// existing HTTP/2 capable server under "/iko.IKO/".
+type Handler struct {
+	bc *iko.BlockChain
+}
+
+func NewHandler(bc *iko.BlockChain) *Handler {
+	return &Handler{bc: bc}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" || !strings.HasPrefix(r.Header.Get("Content-Type"), contentType) {
+		http.Error(w, "expected a grpc request", http.StatusUnsupportedMediaType)
+		return
+	}
+	if r.ProtoMajor != 2 {
+		http.Error(w, "grpc requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Trailer", "Grpc-Status")
+	w.Header().Add("Trailer", "Grpc-Message")
+
+	st := h.call(w, r)
+	if st == nil {
+		st = &Status{Code: CodeOK}
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code)))
+	w.Header().Set("Grpc-Message", encodeGrpcMessage(st.Message))
+}
+
+func (h *Handler) call(w http.ResponseWriter, r *http.Request) *Status {
+	method := strings.TrimPrefix(r.URL.Path, "/"+ServiceName+"/")
+	switch method {
+	case "GetTx":
+		var req GetTxRequest
+		if st := readMessage(r.Body, &req); st != nil {
+			return st
+		}
+		var (
+			txWrap iko.TxWrapper
+			e      error
+		)
+		switch {
+		case req.Hash != nil:
+			txWrap, e = h.bc.GetTxOfHash(*req.Hash)
+		case req.BySeq:
+			txWrap, e = h.bc.GetTxOfSeq(req.Seq)
+		default:
+			return statusf(CodeInvalidArgument, "either hash or seq is required")
+		}
+		if e != nil {
+			return statusOfError(e, CodeInternal)
+		}
+		return writeMessage(w, txWrap)
+
+	case "GetKittyState":
+		var req GetKittyStateRequest
+		if st := readMessage(r.Body, &req); st != nil {
+			return st
+		}
+		kState, ok := h.bc.GetKittyState(req.KittyID)
+		if !ok {
+			return statusf(CodeNotFound, "kitty of id '%d' not found", req.KittyID)
+		}
+		return writeMessage(w, kState)
+
+	case "GetAddressState":
+		var req GetAddressStateRequest
+		if st := readMessage(r.Body, &req); st != nil {
+			return st
+		}
+		return writeMessage(w, h.bc.GetAddressState(req.Address))
+
+	case "InjectTx":
+		var req InjectTxRequest
+		if st := readMessage(r.Body, &req); st != nil {
+			return st
+		}
+		meta, e := h.bc.InjectTx(&req.Tx)
+		if e != nil {
+			return statusOfError(e, CodeInvalidArgument)
+		}
+		return writeMessage(w, &InjectTxResponse{Meta: *meta})
+
+	case "SubscribeTxs":
+		var req SubscribeTxsRequest
+		if st := readMessage(r.Body, &req); st != nil {
+			return st
+		}
+		filter := iko.NewTxFilter(req.Addresses, req.KittyIDs)
+		filter.Bloom = req.Bloom
+		txs, unsubscribe := h.bc.SubscribeTxs(subBufSize)
+		defer unsubscribe()
+
+		// Send headers so that the client knows the stream has started.
+		w.WriteHeader(http.StatusOK)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		for {
+			select {
+			case <-r.Context().Done():
+				return nil
+			case txWrap, ok := <-txs:
+				if !ok {
+					return nil
+				}
+				if !filter.Match(h.bc, &txWrap.Tx) {
+					continue
+				}
+				if st := writeMessage(w, txWrap); st != nil {
+					return st
+				}
+			}
+		}
+
+	case "StreamTxs":
+		var req StreamTxsRequest
+		if st := readMessage(r.Body, &req); st != nil {
+			return st
+		}
+		var start uint64
+		if req.HasSince {
+			start = req.SinceSeq + 1
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		var st *Status
+		e := h.bc.StreamTxs(r.Context(), start, func(txWrap iko.TxWrapper) error {
+			if st = writeMessage(w, txWrap); st != nil {
+				return st
+			}
+			return nil
+		})
+		switch {
+		case st != nil:
+			return st
+		case e == nil, errors.Is(e, context.Canceled):
+			return nil
+		default:
+			return statusOfError(e, CodeInternal)
+		}
+
+	default:
+		return statusf(CodeUnimplemented, "unknown method '%s'", r.URL.Path)
+	}
+}
+
+/*
+	<<< HELPER FUNCTIONS >>>
+*/
+
+// readMessage reads a single length-prefixed message.
+func readMessage(r io.Reader, msg Message) *Status {
+	var prefix [5]byte
+	if _, e := io.ReadFull(r, prefix[:]); e != nil {
+		return statusf(CodeInvalidArgument, "failed to read message: %v", e)
+	}
+	if prefix[0] != 0 {
+		return statusf(CodeUnimplemented, "compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return statusf(CodeResourceExhausted, "message of %d bytes is too large", size)
+	}
+	raw := make([]byte, size)
+	if _, e := io.ReadFull(r, raw); e != nil {
+		return statusf(CodeInvalidArgument, "failed to read message: %v", e)
+	}
+	if e := msg.UnmarshalProto(raw); e != nil {
+		return statusf(CodeInvalidArgument, "invalid message: %v", e)
+	}
+	return nil
+}
+
+// writeMessage writes a single length-prefixed message and flushes it.
+func writeMessage(w http.ResponseWriter, msg interface {
+	MarshalProto() []byte
+}) *Status {
+	raw := msg.MarshalProto()
+	frame := make([]byte, 5, 5+len(raw))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(raw)))
+	if _, e := w.Write(append(frame, raw...)); e != nil {
+		return statusf(CodeInternal, "failed to write message: %v", e)
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// encodeGrpcMessage percent-encodes the status message as required by the
+// gRPC protocol.
+func encodeGrpcMessage(msg string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(msg); i++ {
+		if c := msg[i]; c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
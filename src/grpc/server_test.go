@@ -0,0 +1,204 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var (
+	testGenPK, testGenSK = cipher.GenerateDeterministicKeyPair([]byte("grpc server test"))
+)
+
+func newTestServer(t *testing.T) (*iko.BlockChain, *httptest.Server, func()) {
+	dir, err := ioutil.TempDir("", "kc_grpc_test")
+	require.NoError(t, err, "creation of temp dir should succeed")
+
+	chainDB, err := iko.NewBoltChain(&iko.BoltChainConfig{
+		Path: filepath.Join(dir, "chain.db"),
+	})
+	require.NoError(t, err, "creation of chain db should succeed")
+
+	bc, err := iko.NewBlockChain(context.Background(), &iko.BlockChainConfig{
+		GenerationPK: testGenPK,
+	}, chainDB, iko.NewMemoryState())
+	require.NoError(t, err, "creation of blockchain should succeed")
+
+	srv := httptest.NewUnstartedServer(NewHandler(bc))
+	srv.TLS = nil
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+
+	return bc, srv, func() {
+		srv.Close()
+		bc.Close()
+		chainDB.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func frame(msg Message) []byte {
+	raw := msg.MarshalProto()
+	out := make([]byte, 5, 5+len(raw))
+	binary.BigEndian.PutUint32(out[1:], uint32(len(raw)))
+	return append(out, raw...)
+}
+
+func post(t *testing.T, srv *httptest.Server, method string, body io.Reader) *http.Response {
+	req, err := http.NewRequest("POST", srv.URL+"/"+ServiceName+"/"+method, body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err, "request should succeed")
+	require.Equal(t, 2, resp.ProtoMajor, "should use HTTP/2")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return resp
+}
+
+// call does a unary call, decoding the response message into 'out' (if any)
+// and returning the grpc status code.
+func call(t *testing.T, srv *httptest.Server, method string, in, out Message) Code {
+	resp := post(t, srv, method, bytes.NewReader(frame(in)))
+	defer resp.Body.Close()
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	code, err := strconv.Atoi(resp.Trailer.Get("Grpc-Status"))
+	require.NoError(t, err, "should have grpc status trailer")
+	if code == int(CodeOK) {
+		require.True(t, len(raw) >= 5, "should have response message")
+		require.Equal(t, int(binary.BigEndian.Uint32(raw[1:5])), len(raw)-5)
+		require.NoError(t, out.UnmarshalProto(raw[5:]))
+	}
+	return Code(code)
+}
+
+func TestServer(t *testing.T) {
+	bc, srv, closeServer := newTestServer(t)
+	defer closeServer()
+
+	toPK, _ := cipher.GenerateDeterministicKeyPair([]byte("grpc server test receiver"))
+	toAddr := cipher.AddressFromPubKey(toPK)
+
+	// Subscribe to the last kitty only, so that all txs are applied to the
+	// state once it's received. The subscription is registered before the
+	// response headers are sent.
+	subReq := &SubscribeTxsRequest{KittyIDs: []iko.KittyID{2}}
+	subResp := post(t, srv, "SubscribeTxs", bytes.NewReader(frame(subReq)))
+	defer subResp.Body.Close()
+
+	var txs []*iko.Transaction
+	for i := 0; i < 3; i++ {
+		tx := iko.NewGenTx(iko.KittyID(i), testGenSK)
+		var resp InjectTxResponse
+		require.Equal(t, CodeOK, call(t, srv, "InjectTx", &InjectTxRequest{Tx: *tx}, &resp))
+		require.Equal(t, uint64(i), resp.Meta.Seq)
+		txs = append(txs, tx)
+	}
+
+	t.Run("SubscribeTxs", func(t *testing.T) {
+		var prefix [5]byte
+		_, err := io.ReadFull(subResp.Body, prefix[:])
+		require.NoError(t, err, "should receive streamed tx")
+		raw := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+		_, err = io.ReadFull(subResp.Body, raw)
+		require.NoError(t, err)
+
+		var txWrap iko.TxWrapper
+		require.NoError(t, txWrap.UnmarshalProto(raw))
+		require.Equal(t, iko.KittyID(2), txWrap.Tx.KittyID, "should only receive filtered txs")
+		require.Equal(t, txs[2].Hash(), txWrap.Tx.Hash())
+	})
+
+	t.Run("StreamTxs", func(t *testing.T) {
+		resp := post(t, srv, "StreamTxs", bytes.NewReader(frame(&StreamTxsRequest{SinceSeq: 0, HasSince: true})))
+		defer resp.Body.Close()
+
+		for seq := uint64(1); seq < 3; seq++ {
+			var prefix [5]byte
+			_, err := io.ReadFull(resp.Body, prefix[:])
+			require.NoError(t, err, "should receive streamed tx")
+			raw := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+			_, err = io.ReadFull(resp.Body, raw)
+			require.NoError(t, err)
+
+			var txWrap iko.TxWrapper
+			require.NoError(t, txWrap.UnmarshalProto(raw))
+			require.Equal(t, seq, txWrap.Meta.Seq, "txs should be streamed in order")
+			require.Equal(t, txs[seq].Hash(), txWrap.Tx.Hash())
+		}
+	})
+
+	t.Run("GetTx", func(t *testing.T) {
+		var txWrap iko.TxWrapper
+		hash := txs[2].Hash()
+		require.Equal(t, CodeOK, call(t, srv, "GetTx", &GetTxRequest{Hash: &hash}, &txWrap))
+		require.Equal(t, uint64(2), txWrap.Meta.Seq)
+
+		require.Equal(t, CodeOK, call(t, srv, "GetTx", &GetTxRequest{Seq: 0, BySeq: true}, &txWrap))
+		require.Equal(t, txs[0].Hash(), txWrap.Tx.Hash())
+
+		require.Equal(t, CodeNotFound, call(t, srv, "GetTx", &GetTxRequest{Seq: 10, BySeq: true}, &txWrap))
+		require.Equal(t, CodeInvalidArgument, call(t, srv, "GetTx", &GetTxRequest{}, &txWrap))
+	})
+
+	t.Run("InjectTx", func(t *testing.T) {
+		// A transfer with an invalid signature is rejected.
+		tx, err := iko.NewTransferTx(txs[0], toAddr, testGenSK)
+		require.NoError(t, err)
+		tx.Sig = cipher.Sig{}
+		var resp InjectTxResponse
+		require.Equal(t, CodeInvalidArgument, call(t, srv, "InjectTx", &InjectTxRequest{Tx: *tx}, &resp))
+
+		// A transfer not signed by the owner is denied.
+		_, sk := cipher.GenerateKeyPair()
+		tx = iko.NewUnsignedTransferTx(txs[0], toAddr)
+		tx.Sig = tx.Sign(sk)
+		require.Equal(t, CodePermissionDenied, call(t, srv, "InjectTx", &InjectTxRequest{Tx: *tx}, &resp))
+	})
+
+	t.Run("GetKittyState", func(t *testing.T) {
+		var kState iko.KittyState
+		require.Equal(t, CodeOK, call(t, srv, "GetKittyState", &GetKittyStateRequest{KittyID: 1}, &kState))
+		require.Equal(t, bc.CreatorAddress(), kState.Address)
+
+		require.Equal(t, CodeNotFound, call(t, srv, "GetKittyState", &GetKittyStateRequest{KittyID: 5}, &kState))
+	})
+
+	t.Run("GetAddressState", func(t *testing.T) {
+		var aState iko.AddressState
+		req := &GetAddressStateRequest{Address: bc.CreatorAddress()}
+		require.Equal(t, CodeOK, call(t, srv, "GetAddressState", req, &aState))
+		require.Len(t, aState.Kitties, 3)
+	})
+
+	t.Run("Unimplemented", func(t *testing.T) {
+		var resp InjectTxResponse
+		require.Equal(t, CodeUnimplemented, call(t, srv, "Unknown", &GetKittyStateRequest{}, &resp))
+	})
+}
+
+func TestSubscribeTxsRequest_Bloom(t *testing.T) {
+	bloom := iko.NewBloomFilter(10, 0.01, 3)
+	bloom.Add(cipher.AddressFromPubKey(testGenPK))
+
+	var got SubscribeTxsRequest
+	require.NoError(t, got.UnmarshalProto((&SubscribeTxsRequest{Bloom: bloom}).MarshalProto()))
+	require.Equal(t, bloom, got.Bloom)
+
+	bad := &SubscribeTxsRequest{Bloom: &iko.BloomFilter{Hashes: 1}}
+	require.Error(t, got.UnmarshalProto(bad.MarshalProto()), "invalid bloom filter should be rejected")
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kittycash/wallet/src/util"
+)
+
+const (
+	// MaxAuthClockSkew is the maximum difference between the timestamp of a
+	// HMAC-signed request and the time of the server.
+	MaxAuthClockSkew = 30 * time.Second
+
+	// MinAuthSecretSize is the minimum size of the HMAC shared secret.
+	MinAuthSecretSize = 16
+
+	hAuthTimestamp = "X-Auth-Timestamp"
+	hAuthNonce     = "X-Auth-Nonce"
+	hAuthMAC       = "X-Auth-MAC"
+)
+
+// authHeaders are the headers of HMAC-signed requests.
+var authHeaders = util.HMACHeaders{
+	Timestamp: hAuthTimestamp,
+	Nonce:     hAuthNonce,
+	MAC:       hAuthMAC,
+}
+
+var (
+	// ErrUnauthorized occurs when a request of an authenticated route has no
+	// valid API key or HMAC signature.
+	ErrUnauthorized = util.ErrUnauthorized
+
+	// ErrAuthSecretTooShort occurs when the HMAC shared secret is too short.
+	ErrAuthSecretTooShort = fmt.Errorf("hmac secret should be at least %d bytes", MinAuthSecretSize)
+)
+
+// DefaultAuthRoutes are the routes that require authentication by default,
+// which are the mutating endpoints of operators and the admin endpoints. The
+// RPC methods that change the chain are served to authorised requests of the
+// RPC endpoint (see 'rpcAdminMethods'), which is not itself authenticated.
+var DefaultAuthRoutes = []string{
+	"/api/iko/inject_tx",
+	"/api/iko/broadcast_tx",
+	"/api/iko/broadcast_queue/remove/",
+	"/api/iko/mempool/submit",
+	"/api/iko/mempool/evict/",
+	"/api/iko/mempool/commit",
+	"/network/submit_tx",
+	"/api/network/submit_tx",
+	"/network/connect",
+	"/api/network/connect",
+	"/admin/",
+}
+
+// AuthConfig configures the authentication of routes. Requests are authorised
+// by either a static API key of the 'X-API-Key' header, or a HMAC signature of
+// the shared secret (see 'SignRequest').
+type AuthConfig struct {
+	APIKeys    []string // Static API keys.
+	HMACSecret []byte   // Shared secret of HMAC-signed requests, nil to disable.
+	Routes     []string // Path prefixes that require auth, defaults to 'DefaultAuthRoutes'.
+}
+
+// Authenticator authenticates requests of the configured routes.
+type Authenticator struct {
+	c      *AuthConfig
+	routes []string
+	hmac   *util.HMACVerifier // nil if HMAC-signed requests are disabled
+}
+
+// NewAuthenticator creates an Authenticator of the config.
+func NewAuthenticator(c *AuthConfig) (*Authenticator, error) {
+	if c.HMACSecret != nil && len(c.HMACSecret) < MinAuthSecretSize {
+		return nil, ErrAuthSecretTooShort
+	}
+	a := &Authenticator{
+		c:      c,
+		routes: c.Routes,
+	}
+	if len(a.routes) == 0 {
+		a.routes = DefaultAuthRoutes
+	}
+	if c.HMACSecret != nil {
+		a.hmac = util.NewHMACVerifier(c.HMACSecret, authHeaders, MaxAuthClockSkew)
+	}
+	return a, nil
+}
+
+// Middleware replies with 401 to requests of the configured routes that are
+// not authorised. Requests of other routes are only verified if they have
+// credentials, so that they can be served admin endpoints and RPC methods
+// (see 'isAdmin').
+func (a *Authenticator) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.requiresAuth(r.URL.Path) && !hasCredentials(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if e := a.Verify(r); e != nil {
+			sendJson(w, http.StatusUnauthorized, e.Error())
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(
+			context.WithValue(r.Context(), authKey{}, true)))
+	})
+}
+
+func (a *Authenticator) requiresAuth(path string) bool {
+	for _, route := range a.routes {
+		if path == route || (strings.HasSuffix(route, "/") && strings.HasPrefix(path, route)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCredentials returns true if the request has an API key or HMAC signature.
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get(APIKeyHeader) != "" || r.Header.Get(hAuthMAC) != ""
+}
+
+// Verify checks that the request has a valid API key or HMAC signature. The
+// body of the request is read, and replaced so that it can be read again.
+func (a *Authenticator) Verify(r *http.Request) error {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		for _, k := range a.c.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+				return nil
+			}
+		}
+		return ErrUnauthorized
+	}
+	if a.hmac == nil || r.Header.Get(hAuthMAC) == "" {
+		return ErrUnauthorized
+	}
+	body, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		return e
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return a.hmac.Verify(r, body)
+}
+
+// SignRequest adds HMAC authentication headers of the shared secret to a
+// request of the body.
+func SignRequest(secret []byte, r *http.Request, body []byte) {
+	util.SignHMAC(secret, authHeaders, r, body)
+}
+
+type authKey struct{}
+
+// isAuthenticated returns true if the request was authorised by an
+// Authenticator.
+func isAuthenticated(r *http.Request) bool {
+	ok, _ := r.Context().Value(authKey{}).(bool)
+	return ok
+}
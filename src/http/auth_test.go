@@ -0,0 +1,123 @@
+package http
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/util"
+)
+
+var testAuthSecret = []byte("0123456789abcdef")
+
+func TestAuthenticator(t *testing.T) {
+	_, err := NewAuthenticator(&AuthConfig{HMACSecret: []byte("short")})
+	require.Equal(t, ErrAuthSecretTooShort, err)
+
+	auth, err := NewAuthenticator(&AuthConfig{
+		APIKeys:    []string{"operator"},
+		HMACSecret: testAuthSecret,
+	})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	require.NoError(t, AdminGateway(mux))
+	mux.HandleFunc("/api/iko/inject_tx", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	})
+	mux.HandleFunc("/api/iko/head_tx", func(w http.ResponseWriter, r *http.Request) {})
+	h := auth.Middleware(mux)
+
+	newReq := func(method, url string, body []byte) *http.Request {
+		req := httptest.NewRequest(method, url, bytes.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		return req
+	}
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Unprotected", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do(newReq("GET", "/api/iko/head_tx", nil)).Code)
+	})
+
+	t.Run("Submit", func(t *testing.T) {
+		for _, path := range []string{
+			"/api/iko/broadcast_tx",
+			"/api/iko/broadcast_queue/remove/hash",
+			"/api/iko/mempool/submit",
+			"/network/submit_tx",
+			"/api/network/submit_tx",
+		} {
+			require.Equal(t, http.StatusUnauthorized, do(newReq("POST", path, nil)).Code,
+				"%s should require auth", path)
+		}
+	})
+
+	t.Run("APIKey", func(t *testing.T) {
+		req := newReq("POST", "/api/iko/inject_tx", []byte("tx"))
+		require.Equal(t, http.StatusUnauthorized, do(req).Code)
+
+		req = newReq("POST", "/api/iko/inject_tx", []byte("tx"))
+		req.Header.Set(APIKeyHeader, "wrong")
+		require.Equal(t, http.StatusUnauthorized, do(req).Code)
+
+		req = newReq("POST", "/api/iko/inject_tx", []byte("tx"))
+		req.Header.Set(APIKeyHeader, "operator")
+		rec := do(req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "tx", rec.Body.String())
+	})
+
+	t.Run("HMAC", func(t *testing.T) {
+		body := []byte("tx")
+		req := newReq("POST", "/api/iko/inject_tx", body)
+		SignRequest(testAuthSecret, req, body)
+		rec := do(req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "tx", rec.Body.String(), "the body should be readable after verifying")
+
+		replay := newReq("POST", "/api/iko/inject_tx", body)
+		replay.Header = req.Header
+		require.Equal(t, http.StatusUnauthorized, do(replay).Code,
+			"replayed requests should be rejected")
+
+		req = newReq("POST", "/api/iko/inject_tx", []byte("other"))
+		SignRequest(testAuthSecret, req, body)
+		require.Equal(t, http.StatusUnauthorized, do(req).Code,
+			"requests of a modified body should be rejected")
+
+		req = newReq("POST", "/api/iko/inject_tx", body)
+		SignRequest([]byte("fedcba9876543210"), req, body)
+		require.Equal(t, http.StatusUnauthorized, do(req).Code)
+
+		req = newReq("POST", "/api/iko/inject_tx", body)
+		ts := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+		nonce := "00112233445566778899aabbccddeeff"
+		req.Header.Set(hAuthTimestamp, ts)
+		req.Header.Set(hAuthNonce, nonce)
+		req.Header.Set(hAuthMAC, hex.EncodeToString(
+			util.RequestMAC(testAuthSecret, "POST", "/api/iko/inject_tx", "", ts, nonce, body)))
+		require.Equal(t, http.StatusUnauthorized, do(req).Code,
+			"requests of old timestamps should be rejected")
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, do(newReq("GET", "/admin/runtime", nil)).Code)
+
+		req := newReq("GET", "/admin/runtime", nil)
+		req.Header.Set(APIKeyHeader, "operator")
+		require.Equal(t, http.StatusOK, do(req).Code,
+			"authorised remote requests should be served admin endpoints")
+	})
+}
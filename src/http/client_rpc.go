@@ -0,0 +1,479 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+const (
+	rpcClientTimeout = time.Second * 30
+)
+
+// RPCClient calls the JSON-RPC endpoint of a running node.
+type RPCClient struct {
+	url    string
+	c      *http.Client
+	id     uint64
+	apiKey string
+}
+
+// NewRPCClient creates a client of the node at 'addr', which is either a host
+// and port or a full URL. The '/webrpc' path is assumed if not specified.
+func NewRPCClient(addr string) *RPCClient {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+	if !strings.HasSuffix(addr, "/webrpc") {
+		addr = strings.TrimSuffix(addr, "/") + "/webrpc"
+	}
+	return &RPCClient{
+		url: addr,
+		c:   &http.Client{Timeout: rpcClientTimeout},
+	}
+}
+
+// NewRPCClientTLS creates a client of the node over TLS of the config, such as
+// to verify the node against a private CA, or to present a client certificate.
+// The 'https' scheme is assumed if not specified.
+func NewRPCClientTLS(addr string, config *tls.Config) *RPCClient {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "https://" + addr
+	}
+	c := NewRPCClient(addr)
+	c.c.Transport = &http.Transport{TLSClientConfig: config}
+	return c
+}
+
+// SetAPIKey sets the API key (of the 'X-API-Key' header) of requests, which
+// authorises methods that change the chain of remote nodes, such as
+// 'inject_transaction'.
+func (c *RPCClient) SetAPIKey(key string) {
+	c.apiKey = key
+}
+
+// Call calls the method, decoding the result into 'result'. Errors replied by
+// the node are returned as '*RPCError'.
+func (c *RPCClient) Call(method string, params []string, result interface{}) error {
+	id := strconv.FormatUint(atomic.AddUint64(&c.id, 1), 10)
+	body, e := json.Marshal(RPCRequest{
+		ID:      json.RawMessage(strconv.Quote(id)),
+		JSONRPC: jsonRPCVersion,
+		Method:  method,
+		Params:  params,
+	})
+	if e != nil {
+		return e
+	}
+	req, e := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if e != nil {
+		return e
+	}
+	req.Header.Set(ContTypeKey, string(CtApplicationJson))
+	if c.apiKey != "" {
+		req.Header.Set(APIKeyHeader, c.apiKey)
+	}
+	resp, e := c.c.Do(req)
+	if e != nil {
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("node replied with status '%s'", resp.Status)
+	}
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if e := json.NewDecoder(resp.Body).Decode(&reply); e != nil {
+		return e
+	}
+	if reply.Error != nil {
+		return reply.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Result, result)
+}
+
+func (c *RPCClient) GetStatus() (*StatusReply, error) {
+	out := new(StatusReply)
+	return out, c.Call("get_status", nil, out)
+}
+
+// NetworkID obtains the network ID of the node, which txs should be signed for
+// (see 'Transaction.VerifyNetwork').
+func (c *RPCClient) NetworkID() (string, error) {
+	status, e := c.GetStatus()
+	if e != nil {
+		return "", e
+	}
+	return status.NetworkID, nil
+}
+
+// GetCheckpoint obtains the checkpoint of the node's current state, which can
+// be configured as a trusted checkpoint of other nodes.
+func (c *RPCClient) GetCheckpoint() (*CheckpointReply, error) {
+	out := new(CheckpointReply)
+	return out, c.Call("get_checkpoint", nil, out)
+}
+
+// AuditChain has the node re-validate every tx of it's chain and cross-check
+// it's state, which is only served to loopback addresses or authorised
+// requests. As the audit may take long, the call does not time out.
+func (c *RPCClient) AuditChain() (*VerifyChainReply, error) {
+	audit := &RPCClient{url: c.url, c: &http.Client{Transport: c.c.Transport}}
+	out := new(VerifyChainReply)
+	return out, audit.Call("verify_chain", nil, out)
+}
+
+// Compact has the node reclaim unused space of it's ChainDB, which is only
+// served to loopback addresses or authorised requests. As compaction may take
+// long, the call does not time out.
+func (c *RPCClient) Compact() (*CompactReply, error) {
+	compact := &RPCClient{url: c.url, c: &http.Client{Transport: c.c.Transport}}
+	out := new(CompactReply)
+	return out, compact.Call("compact", nil, out)
+}
+
+// GetDiskUsage obtains the disk usage of the node's ChainDB, which is only
+// served to loopback addresses or authorised requests.
+func (c *RPCClient) GetDiskUsage() (*DiskUsageReply, error) {
+	out := new(DiskUsageReply)
+	return out, c.Call("get_disk_usage", nil, out)
+}
+
+// GetSegmentRoot obtains the Merkle root of the txs of the segment.
+func (c *RPCClient) GetSegmentRoot(segment uint64) (*SegmentRootReply, error) {
+	out := new(SegmentRootReply)
+	return out, c.Call("get_segment_root", []string{strconv.FormatUint(segment, 10)}, out)
+}
+
+// GetTxProof obtains a proof of inclusion of the tx of hash, which should be
+// verified against a trusted segment root.
+func (c *RPCClient) GetTxProof(txHash iko.TxHash) (*TxProofReply, error) {
+	out := new(TxProofReply)
+	return out, c.Call("get_transaction_proof", []string{txHash.Hex()}, out)
+}
+
+func (c *RPCClient) GetHeadTx() (*TxReply, error) {
+	out := new(TxReply)
+	return out, c.Call("get_head_transaction", nil, out)
+}
+
+func (c *RPCClient) GetTxOfHash(txHash iko.TxHash) (*TxReply, error) {
+	out := new(TxReply)
+	return out, c.Call("get_transaction", []string{txHash.Hex()}, out)
+}
+
+func (c *RPCClient) GetTxOfSeq(seq uint64) (*TxReply, error) {
+	out := new(TxReply)
+	return out, c.Call("get_transaction_by_seq", []string{strconv.FormatUint(seq, 10)}, out)
+}
+
+func (c *RPCClient) GetTxPage(currentPage, perPage uint64) (*PaginatedTxsReply, error) {
+	out := new(PaginatedTxsReply)
+	return out, c.Call("get_transactions", []string{
+		strconv.FormatUint(currentPage, 10),
+		strconv.FormatUint(perPage, 10),
+	}, out)
+}
+
+func (c *RPCClient) GetKitty(kittyID iko.KittyID) (*KittyReply, error) {
+	out := new(KittyReply)
+	return out, c.Call("get_kitty", []string{strconv.FormatUint(uint64(kittyID), 10)}, out)
+}
+
+// GetAuctions obtains the active listings, in ascending order of kitty ID.
+func (c *RPCClient) GetAuctions() (*AuctionsReply, error) {
+	out := new(AuctionsReply)
+	return out, c.Call("get_auctions", nil, out)
+}
+
+func (c *RPCClient) GetAddress(address cipher.Address) (*AddressReply, error) {
+	out := new(AddressReply)
+	return out, c.Call("get_address", []string{address.String()}, out)
+}
+
+func (c *RPCClient) InjectTx(tx *iko.Transaction) (*InjectTxReply, error) {
+	out := new(InjectTxReply)
+	return out, c.Call("inject_transaction", []string{hex.EncodeToString(tx.Serialize())}, out)
+}
+
+// BroadcastTx implements 'iko.Broadcaster' by injecting the tx into the node.
+// Errors replied by the node are rejections of the tx, except for when the
+// client is not authorised to inject txs (so that the tx is retried).
+func (c *RPCClient) BroadcastTx(tx *iko.Transaction) error {
+	_, e := c.InjectTx(tx)
+	if rpcErr, ok := e.(*RPCError); ok && rpcErr.Code != RPCErrUnauthorized {
+		return &iko.TxRejectedError{Reason: rpcErr.Message}
+	}
+	return e
+}
+
+// Transaction decodes the raw transaction of the reply, checking it against
+// the replied hash.
+func (r *TxReply) Transaction() (*iko.Transaction, error) {
+	raw, e := hex.DecodeString(r.Meta.Raw)
+	if e != nil {
+		return nil, e
+	}
+	tx, e := iko.DeserializeTx(raw)
+	if e != nil {
+		return nil, e
+	}
+	if hash := tx.Hash().Hex(); hash != r.Meta.Hash {
+		return nil, fmt.Errorf("tx of seq %d has hash '%s', but '%s' was replied",
+			r.Meta.Seq, hash, r.Meta.Hash)
+	}
+	return tx, nil
+}
+
+// StreamTxs implements 'iko.TxSource' by reading the tx stream of the node
+// ('/api/iko/txs/stream'), so that a replica can tail it. The stream does not
+// time out, and ends with an error when the node closes it.
+func (c *RPCClient) StreamTxs(ctx context.Context, start uint64, fn func(iko.TxWrapper) error) error {
+	url := strings.TrimSuffix(c.url, "/webrpc") + "/api/iko/txs/stream"
+	if start > 0 {
+		url += "?since=" + strconv.FormatUint(start-1, 10)
+	}
+	req, e := http.NewRequest(http.MethodGet, url, nil)
+	if e != nil {
+		return e
+	}
+	stream := &http.Client{Transport: c.c.Transport}
+	resp, e := stream.Do(req.WithContext(ctx))
+	if e != nil {
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("node replied with status '%s'", resp.Status)
+	}
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var reply TxReply
+		if e := dec.Decode(&reply); e != nil {
+			if e == io.EOF {
+				return errors.New("tx stream closed by node")
+			}
+			return e
+		}
+		tx, e := reply.Transaction()
+		if e != nil {
+			return e
+		}
+		txWrap := iko.TxWrapper{
+			Tx:   *tx,
+			Meta: iko.TxMeta{Seq: reply.Meta.Seq, TS: reply.Meta.TS},
+		}
+		if e := fn(txWrap); e != nil {
+			return e
+		}
+	}
+}
+
+// VerifyChain obtains all transactions from the node, in pages of 'perPage',
+// and checks their sequence, hashes, inputs and signatures. It returns the
+// number of verified transactions.
+func (c *RPCClient) VerifyChain(perPage uint64) (uint64, error) {
+	status, e := c.GetStatus()
+	if e != nil {
+		return 0, e
+	}
+	if status.Empty {
+		return 0, nil
+	}
+	// Generation and rotation txs should be signed by a known creator: a
+	// current creator, or one named by a rotation tx. As the initial creators
+	// are of the node's config, the genesis creator is also known.
+	creators := make(map[cipher.Address]bool)
+	for _, v := range append(status.CreatorAddresses, status.CreatorAddress) {
+		creator, e := cipher.DecodeBase58Address(v)
+		if e != nil {
+			return 0, e
+		}
+		creators[creator] = true
+	}
+	var (
+		seq     uint64
+		epoch   uint64 // creator epoch, as of the verified txs
+		frozen  bool
+		unspent = make(map[iko.KittyID]*iko.Transaction)
+	)
+	for page := uint64(0); ; page++ {
+		paginated, e := c.GetTxPage(page, perPage)
+		if e != nil {
+			return seq, e
+		}
+		for _, reply := range paginated.TxReplies {
+			if reply.Meta.Seq != seq {
+				return seq, fmt.Errorf("expected tx of seq %d, got %d", seq, reply.Meta.Seq)
+			}
+			tx, e := reply.Transaction()
+			if e != nil {
+				return seq, e
+			}
+			if tx.IsRotation() {
+				if seq == 0 {
+					return seq, errors.New("genesis tx is a rotation tx")
+				}
+				if e := tx.VerifyRotation(); e != nil {
+					return seq, fmt.Errorf("tx of seq %d is invalid: %v", seq, e)
+				}
+				if tx.Rotation.Epoch != epoch {
+					return seq, fmt.Errorf("tx of seq %d is of creator epoch %d, expected %d",
+						seq, tx.Rotation.Epoch, epoch)
+				}
+				// Rotation txs are witnessed by known creators, or signed by one.
+				if tx.Witness != nil {
+					for _, pk := range tx.Witness.Multisig.PubKeys {
+						if !creators[cipher.AddressFromPubKey(pk)] {
+							return seq, fmt.Errorf("tx of seq %d is not of a creator", seq)
+						}
+					}
+				} else if !creators[tx.Out] {
+					return seq, fmt.Errorf("tx of seq %d is not of a creator", seq)
+				}
+				if e := tx.VerifyOwner(tx.Out); e != nil {
+					return seq, fmt.Errorf("tx of seq %d is invalid: %v", seq, e)
+				}
+				for _, pk := range tx.Rotation.Creators {
+					creators[cipher.AddressFromPubKey(pk)] = true
+				}
+				epoch++
+				seq++
+				continue
+			}
+			// Admin txs should be witnessed by known creators.
+			if tx.IsAdmin() {
+				if seq == 0 {
+					return seq, errors.New("genesis tx is an admin tx")
+				}
+				if e := tx.VerifyAdmin(); e != nil {
+					return seq, fmt.Errorf("tx of seq %d is invalid: %v", seq, e)
+				}
+				for _, pk := range tx.Witness.Multisig.PubKeys {
+					if !creators[cipher.AddressFromPubKey(pk)] {
+						return seq, fmt.Errorf("tx of seq %d is not of a creator", seq)
+					}
+				}
+				if e := tx.VerifyOwner(tx.Out); e != nil {
+					return seq, fmt.Errorf("tx of seq %d is invalid: %v", seq, e)
+				}
+				if len(tx.Admin.Revoke) > 0 {
+					epoch++
+				}
+				frozen = frozen || tx.Admin.Freeze
+				seq++
+				continue
+			}
+			in, hasIn := unspent[tx.KittyID]
+			switch {
+			case tx.In == iko.EmptyTxHash() && seq == 0:
+				creators[tx.Out] = true
+				e = tx.VerifyOwner(tx.Out)
+			case tx.In == iko.EmptyTxHash() && frozen:
+				return seq, fmt.Errorf("tx of seq %d generates kitty %d after generation was frozen", seq, tx.KittyID)
+			case tx.In == iko.EmptyTxHash() && hasIn:
+				return seq, fmt.Errorf("tx of seq %d generates existing kitty %d", seq, tx.KittyID)
+			case tx.In == iko.EmptyTxHash() && !creators[tx.Out]:
+				return seq, fmt.Errorf("tx of seq %d is not of a creator", seq)
+			case tx.In == iko.EmptyTxHash():
+				e = tx.VerifyOwner(tx.Out)
+			case !hasIn:
+				return seq, fmt.Errorf("tx of seq %d transfers non-existent kitty %d", seq, tx.KittyID)
+			default:
+				e = tx.VerifyWith(in, cipher.PubKey{})
+			}
+			if e != nil {
+				return seq, fmt.Errorf("tx of seq %d is invalid: %v", seq, e)
+			}
+			unspent[tx.KittyID] = tx
+			seq++
+		}
+		if !paginated.HasNext {
+			break
+		}
+	}
+	if seq != status.HeadSeq+1 {
+		return seq, fmt.Errorf("verified %d txs, but head is of seq %d", seq, status.HeadSeq)
+	}
+	return seq, nil
+}
+
+// GetKittyUnspentTx obtains the kitty's last transaction as obtained from the
+// node, which is the input of it's next transfer.
+func (c *RPCClient) GetKittyUnspentTx(kittyID iko.KittyID) (*iko.Transaction, error) {
+	kitty, e := c.GetKitty(kittyID)
+	if e != nil {
+		return nil, e
+	}
+	if len(kitty.Transactions) == 0 {
+		return nil, fmt.Errorf("kitty %d has no transactions", kittyID)
+	}
+	inHash, e := cipher.SHA256FromHex(kitty.Transactions[len(kitty.Transactions)-1])
+	if e != nil {
+		return nil, e
+	}
+	inReply, e := c.GetTxOfHash(iko.TxHash(inHash))
+	if e != nil {
+		return nil, e
+	}
+	return inReply.Transaction()
+}
+
+// NewTransferTx builds a transaction, signed by 'signer', that transfers the
+// kitty to 'to'. The input of the transaction is the kitty's last transaction as
+// obtained from the node.
+func (c *RPCClient) NewTransferTx(kittyID iko.KittyID, to cipher.Address, signer iko.Signer) (*iko.Transaction, error) {
+	tx, owner, e := c.NewUnsignedTransferTx(kittyID, to)
+	if e != nil {
+		return nil, e
+	}
+	if owner != signer.Address() {
+		return nil, iko.ErrSignerNotOwner
+	}
+	if e := tx.SignWith(signer); e != nil {
+		return nil, e
+	}
+	return tx, nil
+}
+
+// NewUnsignedTransferTx builds an unsigned transaction that transfers the kitty
+// to 'to', for signing offline. The current owner of the kitty, who should sign
+// the transaction, is also returned.
+func (c *RPCClient) NewUnsignedTransferTx(kittyID iko.KittyID, to cipher.Address) (*iko.Transaction, cipher.Address, error) {
+	in, e := c.GetKittyUnspentTx(kittyID)
+	if e != nil {
+		return nil, cipher.Address{}, e
+	}
+	network, e := c.NetworkID()
+	if e != nil {
+		return nil, cipher.Address{}, e
+	}
+	tx := iko.NewUnsignedTransferTx(in, to)
+	tx.Network = network
+
+	// The input tx may be of several kitties (such as a multi-kitty transfer,
+	// or the breed tx of the kitty), and need not start with the kitty.
+	tx.KittyID = kittyID
+	return tx, in.Out, nil
+}
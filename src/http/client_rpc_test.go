@@ -0,0 +1,189 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+func TestRPCClient(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := NewRPCClient(srv.URL)
+
+	status, err := c.GetStatus()
+	require.NoError(t, err)
+	require.True(t, status.Empty)
+
+	count, err := c.VerifyChain(2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), count, "empty chain should verify")
+
+	sub, unsubscribe := bc.SubscribeTxs(8)
+	defer unsubscribe()
+
+	toPK, _ := cipher.GenerateDeterministicKeyPair([]byte("rpc client test receiver"))
+	toAddr := cipher.AddressFromPubKey(toPK)
+
+	var txs []*iko.Transaction
+	for i := 0; i < 4; i++ {
+		tx := iko.NewGenTx(iko.KittyID(i), testGenSK)
+		reply, err := c.InjectTx(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(i), reply.Seq)
+		txs = append(txs, tx)
+		<-sub
+	}
+	transferTx, err := c.NewTransferTx(3, toAddr, iko.NewSecKeySigner(testGenSK))
+	require.NoError(t, err)
+	require.Equal(t, txs[3].Hash(), transferTx.In)
+	_, err = c.InjectTx(transferTx)
+	require.NoError(t, err)
+	<-sub
+
+	_, err = c.InjectTx(txs[0])
+	require.Error(t, err, "duplicate tx should be rejected")
+	require.IsType(t, &RPCError{}, err)
+
+	head, err := c.GetHeadTx()
+	require.NoError(t, err)
+	headTx, err := head.Transaction()
+	require.NoError(t, err)
+	require.Equal(t, transferTx.Hash(), headTx.Hash())
+
+	reply, err := c.GetTxOfHash(txs[1].Hash())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), reply.Meta.Seq)
+
+	reply, err = c.GetTxOfSeq(2)
+	require.NoError(t, err)
+	require.Equal(t, txs[2].Hash().Hex(), reply.Meta.Hash)
+
+	kitty, err := c.GetKitty(3)
+	require.NoError(t, err)
+	require.Equal(t, toAddr.String(), kitty.Address)
+	require.Len(t, kitty.Transactions, 2)
+
+	_, err = c.NewTransferTx(3, bc.CreatorAddress(), iko.NewSecKeySigner(testGenSK))
+	require.Error(t, err, "creator no longer owns the kitty")
+
+	address, err := c.GetAddress(bc.CreatorAddress())
+	require.NoError(t, err)
+	require.Equal(t, iko.KittyIDs{0, 1, 2}, address.Kitties)
+
+	for _, perPage := range []uint64{1, 2, 5, 10} {
+		count, err := c.VerifyChain(perPage)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), count)
+	}
+
+	rootReply, err := c.GetSegmentRoot(0)
+	require.NoError(t, err)
+	root, err := rootReply.SegmentRoot()
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), root.Size)
+	proofReply, err := c.GetTxProof(txs[2].Hash())
+	require.NoError(t, err)
+	proof, err := proofReply.TxProof()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), proof.Seq)
+	require.NoError(t, proof.Verify(root))
+
+	_, err = c.GetSegmentRoot(1)
+	require.Error(t, err, "segment should not exist")
+}
+
+func TestRPCClient_OfflineSigning(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := NewRPCClient(srv.URL)
+
+	sub, unsubscribe := bc.SubscribeTxs(8)
+	defer unsubscribe()
+
+	// Built online, without the creator's key.
+	genTx := iko.NewUnsignedGenTx(7, bc.CreatorAddress())
+	raw, err := json.Marshal(iko.NewOfflineTx(genTx, bc.CreatorAddress()))
+	require.NoError(t, err)
+
+	// Signed offline.
+	var offline iko.OfflineTx
+	require.NoError(t, json.Unmarshal(raw, &offline))
+	require.NoError(t, offline.Sign(iko.NewSecKeySigner(testGenSK)))
+	signed := iko.NewOfflineTx(genTx, bc.CreatorAddress())
+	signed.Sig = offline.Sig
+
+	// Injected online.
+	tx, _, err := signed.Decode()
+	require.NoError(t, err)
+	require.True(t, tx.IsSigned())
+	_, err = c.InjectTx(tx)
+	require.NoError(t, err)
+	<-sub
+
+	toPK, _ := cipher.GenerateDeterministicKeyPair([]byte("rpc client test receiver"))
+	transferTx, owner, err := c.NewUnsignedTransferTx(7, cipher.AddressFromPubKey(toPK))
+	require.NoError(t, err)
+	require.Equal(t, bc.CreatorAddress(), owner)
+	require.False(t, transferTx.IsSigned())
+
+	// Signed offline, passed around as hex.
+	unsigned, err := iko.DecodeTxHex(iko.EncodeTxHex(transferTx))
+	require.NoError(t, err)
+	require.NoError(t, unsigned.SignWith(iko.NewSecKeySigner(testGenSK)))
+
+	_, err = c.InjectTx(unsigned)
+	require.NoError(t, err)
+	<-sub
+
+	kitty, err := c.GetKitty(7)
+	require.NoError(t, err)
+	require.Equal(t, cipher.AddressFromPubKey(toPK).String(), kitty.Address)
+}
+
+func TestRPCClient_StreamTxs(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got []iko.TxWrapper
+	errDone := errors.New("done")
+	err := NewRPCClient(srv.URL).StreamTxs(ctx, 1, func(txWrap iko.TxWrapper) error {
+		got = append(got, txWrap)
+		if len(got) == 2 {
+			return errDone
+		}
+		return nil
+	})
+	require.Equal(t, errDone, err)
+	for i, txWrap := range got {
+		exp, err := bc.GetTxOfSeq(uint64(i + 1))
+		require.NoError(t, err)
+		require.Equal(t, exp, txWrap, "streamed txs should be of the node's chain")
+	}
+}
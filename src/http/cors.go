@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of 'CORSConfig'.
+var (
+	DefaultCORSMethods = []string{"GET", "POST"}
+	DefaultCORSHeaders = []string{ContTypeKey, APIKeyHeader, hAuthTimestamp, hAuthNonce, hAuthMAC}
+)
+
+// CORSConfig configures the cross-origin requests that are allowed, so that
+// browser wallets and explorers of other origins can call the API.
+type CORSConfig struct {
+	AllowedOrigins   []string      // Origins allowed, or "*" for any origin.
+	AllowedMethods   []string      // Methods allowed, defaults to 'DefaultCORSMethods'.
+	AllowedHeaders   []string      // Request headers allowed, defaults to 'DefaultCORSHeaders'.
+	AllowCredentials bool          // Whether requests may include credentials, such as cookies.
+	MaxAge           time.Duration // Duration that preflight results may be cached, 0 to not specify.
+}
+
+// CORS handles cross-origin requests as of a CORSConfig.
+type CORS struct {
+	c       *CORSConfig
+	any     bool
+	origins map[string]struct{}
+	methods string
+	headers string
+}
+
+// NewCORS creates a CORS handler of the config.
+func NewCORS(c *CORSConfig) *CORS {
+	cors := &CORS{
+		c:       c,
+		origins: make(map[string]struct{}),
+		methods: strings.Join(DefaultCORSMethods, ", "),
+		headers: strings.Join(DefaultCORSHeaders, ", "),
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			cors.any = true
+		}
+		cors.origins[strings.ToLower(origin)] = struct{}{}
+	}
+	if len(c.AllowedMethods) > 0 {
+		cors.methods = strings.ToUpper(strings.Join(c.AllowedMethods, ", "))
+	}
+	if len(c.AllowedHeaders) > 0 {
+		cors.headers = strings.Join(c.AllowedHeaders, ", ")
+	}
+	return cors
+}
+
+// Middleware adds CORS headers to responses of allowed origins, and replies to
+// preflight requests.
+func (c *CORS) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !c.allowed(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), corsKey{}, true))
+		if c.any && !c.c.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.c.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", c.methods)
+		w.Header().Set("Access-Control-Allow-Headers", c.headers)
+		if c.c.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age",
+				strconv.Itoa(int(c.c.MaxAge/time.Second)))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+type corsKey struct{}
+
+// isCORSAllowed returns true if the request is of an origin allowed by CORS.
+func isCORSAllowed(r *http.Request) bool {
+	ok, _ := r.Context().Value(corsKey{}).(bool)
+	return ok
+}
+
+func (c *CORS) allowed(origin string) bool {
+	if c.any {
+		return true
+	}
+	_, ok := c.origins[strings.ToLower(origin)]
+	return ok
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	do := func(c *CORSConfig, method, origin, reqMethod string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/iko/head_tx", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if reqMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", reqMethod)
+		}
+		rec := httptest.NewRecorder()
+		NewCORS(c).Middleware(ok).ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("AllowedOrigins", func(t *testing.T) {
+		c := &CORSConfig{
+			AllowedOrigins: []string{"https://wallet.kittycash.com"},
+			MaxAge:         time.Hour,
+		}
+		rec := do(c, "GET", "https://Wallet.kittycash.com", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "https://Wallet.kittycash.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "Origin", rec.Header().Get("Vary"))
+
+		rec = do(c, "OPTIONS", "https://wallet.kittycash.com", "POST")
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Equal(t, "GET, POST", rec.Header().Get("Access-Control-Allow-Methods"))
+		require.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), APIKeyHeader)
+		require.Equal(t, "3600", rec.Header().Get("Access-Control-Max-Age"))
+
+		rec = do(c, "GET", "https://evil.com", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"),
+			"origins not allowed should not be given cors headers")
+		require.Equal(t, http.StatusForbidden, do(c, "OPTIONS", "https://evil.com", "GET").Code)
+
+		req := httptest.NewRequest("GET", "/api/iko/subscribe", nil)
+		req.Header.Set("Origin", "https://wallet.kittycash.com")
+		require.False(t, wsCheckOrigin(req), "websockets of other origins should be rejected")
+		NewCORS(c).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.True(t, wsCheckOrigin(r), "websockets of allowed origins should be accepted")
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		rec = do(c, "GET", "", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Header().Get("Vary"), "same-origin requests should be untouched")
+	})
+
+	t.Run("AnyOrigin", func(t *testing.T) {
+		c := &CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"get"},
+			AllowedHeaders: []string{"X-Custom"},
+		}
+		rec := do(c, "OPTIONS", "https://explorer.com", "GET")
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "GET", rec.Header().Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "X-Custom", rec.Header().Get("Access-Control-Allow-Headers"))
+		require.Empty(t, rec.Header().Get("Access-Control-Max-Age"))
+
+		c.AllowCredentials = true
+		rec = do(c, "GET", "https://explorer.com", "")
+		require.Equal(t, "https://explorer.com", rec.Header().Get("Access-Control-Allow-Origin"),
+			"the origin should be echoed when credentials are allowed")
+		require.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
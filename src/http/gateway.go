@@ -5,14 +5,30 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/node"
+	"github.com/kittycash/wallet/src/util/metrics"
 	"github.com/kittycash/wallet/src/wallet"
 )
 
+var metricAPILatency = metrics.NewHistogramVec(
+	"http_api_request_duration_seconds",
+	"Latency of API requests, by route pattern and method.",
+	metrics.DefBuckets, "pattern", "method")
+
+func init() {
+	metrics.DefaultRegistry.MustRegister(metricAPILatency)
+}
+
 type Gateway struct {
-	IKO    *iko.BlockChain
-	Wallet *wallet.Manager
+	IKO       *iko.BlockChain
+	Wallet    *wallet.Manager
+	Broadcast *iko.BroadcastQueue // Optional, to relay txs to an upstream node.
+	Node      *node.Node          // Optional, to manage the peers of a p2p node.
+	Metrics   *metrics.Registry   // Optional, to expose metrics at '/metrics'.
+	Admin     bool                // Whether to serve pprof, runtime stats and backups under '/admin/'.
 }
 
 func (g *Gateway) host(mux *http.ServeMux) error {
@@ -20,15 +36,51 @@ func (g *Gateway) host(mux *http.ServeMux) error {
 		if e := ikoGateway(mux, g.IKO); e != nil {
 			return e
 		}
+		if e := HealthGateway(mux, g.IKO, g.Node); e != nil {
+			return e
+		}
 	}
 	if g.Wallet != nil {
 		if e := walletGateway(mux, g.Wallet); e != nil {
 			return e
 		}
 	}
+	if g.Broadcast != nil {
+		if e := broadcastGateway(mux, g.Broadcast); e != nil {
+			return e
+		}
+	}
+	if g.Node != nil {
+		if e := networkGateway(mux, g.Node); e != nil {
+			return e
+		}
+	}
+	if g.Metrics != nil {
+		mux.Handle("/metrics", g.Metrics)
+	}
+	if g.Admin {
+		if e := AdminGateway(mux); e != nil {
+			return e
+		}
+		if g.IKO != nil {
+			if e := ikoAdminGateway(mux, g.IKO); e != nil {
+				return e
+			}
+		}
+	}
 	return nil
 }
 
+// Handler returns a handler that serves the APIs of the gateway, so that they
+// can be served by another server.
+func (g *Gateway) Handler() (http.Handler, error) {
+	mux := http.NewServeMux()
+	if e := g.host(mux); e != nil {
+		return nil, e
+	}
+	return mux, nil
+}
+
 /*
 	<<< ACTION >>>
 */
@@ -37,6 +89,7 @@ type HandlerFunc func(w http.ResponseWriter, r *http.Request, p *Path) error
 
 func Handle(mux *http.ServeMux, pattern, method string, handler HandlerFunc) {
 	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		defer metricAPILatency.With(pattern, method).ObserveSince(time.Now())
 
 		if r.Method != method {
 			sendJson(w, http.StatusBadRequest,
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+)
+
+// AdminGateway serves pprof profiles under '/admin/debug/pprof/' and runtime
+// stats at '/admin/runtime', to diagnose long-running nodes. These are only
+// served to loopback addresses, or requests authorised by an Authenticator.
+func AdminGateway(m *http.ServeMux) error {
+	m.Handle("/admin/debug/pprof/",
+		adminOnly(http.StripPrefix("/admin", http.HandlerFunc(pprof.Index))))
+	m.Handle("/admin/debug/pprof/cmdline", adminOnly(http.HandlerFunc(pprof.Cmdline)))
+	m.Handle("/admin/debug/pprof/profile", adminOnly(http.HandlerFunc(pprof.Profile)))
+	m.Handle("/admin/debug/pprof/symbol", adminOnly(http.HandlerFunc(pprof.Symbol)))
+	m.Handle("/admin/debug/pprof/trace", adminOnly(http.HandlerFunc(pprof.Trace)))
+	Handle(m, "/admin/runtime", "GET", getRuntimeStats)
+	return nil
+}
+
+// adminOnly forbids requests that are not from loopback addresses, nor
+// authenticated.
+func adminOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requireAdmin(w, r) {
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// errAdminOnly is replied to requests of admin endpoints (and RPC methods)
+// that are not from loopback addresses, nor authenticated.
+var errAdminOnly = errors.New("only served to loopback addresses or authorised requests")
+
+// requireAdmin replies with 403 and returns false if the request is not from a
+// loopback address, nor authenticated.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if isAdmin(r) {
+		return true
+	}
+	sendJson(w, http.StatusForbidden, "admin endpoints are "+errAdminOnly.Error())
+	return false
+}
+
+func isAdmin(r *http.Request) bool {
+	return isLoopback(r) || isAuthenticated(r)
+}
+
+func isLoopback(r *http.Request) bool {
+	host, _, e := net.SplitHostPort(r.RemoteAddr)
+	if e != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type RuntimeStatsReply struct {
+	GoVersion    string `json:"go_version"`
+	NumCPU       int    `json:"num_cpu"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	Goroutines   int    `json:"goroutines"`
+	HeapAlloc    uint64 `json:"heap_alloc"` // Bytes of allocated heap objects.
+	HeapSys      uint64 `json:"heap_sys"`   // Bytes of heap memory obtained from the OS.
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	NumGC        uint32 `json:"num_gc"`
+	LastGC       int64  `json:"last_gc"` // Unix nano timestamp, 0 if never.
+	PauseTotalNs uint64 `json:"gc_pause_total_ns"`
+}
+
+func getRuntimeStats(w http.ResponseWriter, r *http.Request, p *Path) error {
+	if !requireAdmin(w, r) {
+		return nil
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return sendJson(w, http.StatusOK, RuntimeStatsReply{
+		GoVersion:    runtime.Version(),
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    ms.HeapAlloc,
+		HeapSys:      ms.HeapSys,
+		HeapIdle:     ms.HeapIdle,
+		HeapReleased: ms.HeapReleased,
+		HeapObjects:  ms.HeapObjects,
+		TotalAlloc:   ms.TotalAlloc,
+		NumGC:        ms.NumGC,
+		LastGC:       int64(ms.LastGC),
+		PauseTotalNs: ms.PauseTotalNs,
+	})
+}
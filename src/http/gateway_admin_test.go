@@ -0,0 +1,48 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminGateway(t *testing.T) {
+	mux := http.NewServeMux()
+	require.NoError(t, AdminGateway(mux))
+
+	get := func(url, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	urls := []string{
+		"/admin/runtime",
+		"/admin/debug/pprof/",
+		"/admin/debug/pprof/goroutine?debug=1",
+		"/admin/debug/pprof/cmdline",
+	}
+	for _, url := range urls {
+		require.Equal(t, http.StatusForbidden, get(url, "192.0.2.1:1234").Code,
+			"'%s' should be forbidden to remote addresses", url)
+		require.Equal(t, http.StatusOK, get(url, "127.0.0.1:1234").Code,
+			"'%s' should be served to loopback addresses", url)
+		require.Equal(t, http.StatusOK, get(url, "[::1]:1234").Code,
+			"'%s' should be served to loopback addresses", url)
+	}
+
+	require.Contains(t, get("/admin/debug/pprof/goroutine?debug=1", "127.0.0.1:1234").Body.String(),
+		"goroutine profile")
+
+	var reply RuntimeStatsReply
+	rec := get("/admin/runtime", "127.0.0.1:1234")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.NotEmpty(t, reply.GoVersion)
+	require.True(t, reply.Goroutines > 0)
+	require.True(t, reply.HeapAlloc > 0)
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/node"
+)
+
+// HealthGateway serves health checks of the blockchain, for load balancers and
+// orchestrators. '/health' succeeds while the chain db is reachable and the
+// blockchain is running, and '/ready' additionally requires the chain to be
+// synced with the peers of the p2p node 'n' (which is optional).
+func HealthGateway(m *http.ServeMux, bc *iko.BlockChain, n *node.Node) error {
+	Handle(m, "/health", "GET", getHealth(bc, n, false))
+	Handle(m, "/ready", "GET", getHealth(bc, n, true))
+	return nil
+}
+
+type HealthReply struct {
+	Status      string     `json:"status"`   // Either "ok" or "unavailable".
+	ChainDB     string     `json:"chain_db"` // Either "ok" or the error of reaching it.
+	Running     bool       `json:"running"`
+	ChainLen    uint64     `json:"chain_length"`
+	HeadSeq     *uint64    `json:"head_seq,omitempty"`
+	SinceLastTx *float64   `json:"seconds_since_last_tx,omitempty"`
+	Sync        *SyncReply `json:"sync,omitempty"`
+}
+
+type SyncReply struct {
+	Peers       int    `json:"peers"`
+	ChainLen    uint64 `json:"chain_length"`
+	BestPeerLen uint64 `json:"best_peer_chain_length"`
+	Synced      bool   `json:"synced"`
+}
+
+func getHealth(bc *iko.BlockChain, n *node.Node, ready bool) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		h := bc.Health()
+		ok := h.OK()
+
+		reply := HealthReply{
+			ChainDB:  "ok",
+			Running:  !h.Stopped,
+			ChainLen: h.Len,
+		}
+		if h.ChainDB != nil {
+			reply.ChainDB = h.ChainDB.Error()
+		}
+		if h.Head != nil {
+			since := h.SinceLastTx().Seconds()
+			reply.HeadSeq = &h.Head.Seq
+			reply.SinceLastTx = &since
+		}
+		if n != nil {
+			s := n.SyncStatus()
+			reply.Sync = &SyncReply{
+				Peers:       s.Peers,
+				ChainLen:    s.Len,
+				BestPeerLen: s.BestLen,
+				Synced:      s.Synced,
+			}
+			if ready && !s.Synced {
+				ok = false
+			}
+		}
+
+		if !ok {
+			reply.Status = "unavailable"
+			return sendJson(w, http.StatusServiceUnavailable, reply)
+		}
+		reply.Status = "ok"
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/node"
+)
+
+func TestHealthGateway(t *testing.T) {
+	bc, _, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	n, err := node.NewNode(&node.NodeConfig{}, bc)
+	require.NoError(t, err)
+	defer n.Close()
+
+	mux := http.NewServeMux()
+	require.NoError(t, HealthGateway(mux, bc, n))
+
+	get := func(url string) (int, HealthReply) {
+		rec := doRequest(mux, "GET", url, "", nil)
+		var reply HealthReply
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+		return rec.Code, reply
+	}
+
+	code, reply := get("/health")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ok", reply.Status)
+	require.Equal(t, "ok", reply.ChainDB)
+	require.True(t, reply.Running)
+	require.Nil(t, reply.HeadSeq, "an empty chain should have no head")
+	require.Nil(t, reply.SinceLastTx)
+
+	_, err = bc.InjectTx(iko.NewGenTx(0, testGenSK))
+	require.NoError(t, err)
+	_, err = bc.InjectTx(iko.NewGenTx(1, testGenSK))
+	require.NoError(t, err)
+
+	code, reply = get("/ready")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, uint64(2), reply.ChainLen)
+	require.Equal(t, uint64(1), *reply.HeadSeq)
+	require.NotNil(t, reply.SinceLastTx)
+	require.Equal(t, &SyncReply{ChainLen: 2, Synced: true}, reply.Sync)
+
+	bc.Close()
+	for _, url := range []string{"/health", "/ready"} {
+		code, reply = get(url)
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, "unavailable", reply.Status)
+		require.False(t, reply.Running)
+	}
+}
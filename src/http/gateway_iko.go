@@ -1,8 +1,10 @@
 package http
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -15,21 +17,109 @@ import (
 	"github.com/kittycash/wallet/src/iko"
 )
 
+// maxBulkQuery is the maximum number of kitties or addresses of a bulk query.
+const maxBulkQuery = 1000
+
 func ikoGateway(m *http.ServeMux, g *iko.BlockChain) error {
 	Handle(m, "/api/iko/kitty/", "GET", getKitty(g))
+	Handle(m, "/api/iko/kitty_history/", "GET", getKittyHistory(g))
+	Handle(m, "/api/iko/kitties", "GET", getKitties(g))
 	Handle(m, "/api/iko/address/", "GET", getAddress(g))
+	Handle(m, "/api/iko/address_txs/", "GET", getAddressTxs(g))
+	Handle(m, "/api/iko/addresses", "GET", getAddresses(g))
 	Handle(m, "/api/iko/balance", "GET", getBalance(g))
+	Handle(m, "/api/iko/auctions", "GET", getAuctions(g))
 	Handle(m, "/api/iko/tx/", "GET", getTx(g))
+	Handle(m, "/api/iko/tx/hash/", "GET", getTx(g))
+	Handle(m, "/api/iko/tx/seq/", "GET", getTx(g))
 	Handle(m, "/api/iko/head_tx", "GET", getHeadTx(g))
 	Handle(m, "/api/iko/txs", "GET", getPaginatedTxs(g))
+	Handle(m, "/api/iko/txs/stream", "GET", streamTxs(g))
+	Handle(m, "/api/iko/txs/after", "GET", getCursorTxs(g, false))
+	Handle(m, "/api/iko/txs/before", "GET", getCursorTxs(g, true))
 	Handle(m, "/api/iko/inject_tx", "POST", injectTx(g))
+	Handle(m, "/api/iko/tx/decode", "POST", decodeTx())
+	Handle(m, "/api/iko/tx/encode", "POST", encodeTx())
+	Handle(m, "/api/iko/tx/verify", "POST", verifyTx(g))
+	Handle(m, "/api/iko/mempool", "GET", getPendingTxs(g))
+	Handle(m, "/api/iko/mempool/tx/", "GET", getPendingTx(g))
+	Handle(m, "/api/iko/mempool/submit", "POST", submitTx(g))
+	Handle(m, "/api/iko/mempool/evict/", "POST", evictPendingTx(g))
+	Handle(m, "/api/iko/mempool/commit", "POST", commitPending(g))
+	Handle(m, "/api/iko/subscribe_txs", "GET", subscribeTxs(g))
+	Handle(m, "/api/iko/graphql", "POST", graphQL(g))
+	MultiHandle(m, []string{"/webrpc", "/api/iko/webrpc"}, "POST", webRPC(g))
 	return nil
 }
 
+// errStatus obtains the HTTP status code of an error returned by the
+// blockchain, or 'def' if the error is not of a known kind.
+func errStatus(e error, def int) int {
+	switch {
+	case errors.Is(e, iko.ErrTxNotFound), errors.Is(e, iko.ErrKittyNotFound):
+		return http.StatusNotFound
+	case errors.Is(e, iko.ErrKittyAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(e, iko.ErrNotOwner), errors.Is(e, iko.ErrReplica):
+		return http.StatusForbidden
+	case errors.Is(e, iko.ErrChainFull), errors.Is(e, iko.ErrChainStopped):
+		return http.StatusServiceUnavailable
+	default:
+		return def
+	}
+}
+
 type KittyReply struct {
-	KittyID      iko.KittyID `json:"kitty_id"`
-	Address      string      `json:"address"`
-	Transactions []string    `json:"transactions"`
+	KittyID      iko.KittyID   `json:"kitty_id"`
+	Address      string        `json:"address"`
+	Transactions []string      `json:"transactions"`
+	Burned       bool          `json:"burned,omitempty"`
+	CooldownSeq  uint64        `json:"cooldown_seq,omitempty"` // Chain sequence from which the kitty can breed.
+	Metadata     *Metadata     `json:"metadata,omitempty"`
+	Auction      *AuctionReply `json:"auction,omitempty"` // Only of listed kitties.
+	Royalty      *RoyaltyReply `json:"royalty,omitempty"` // Only of kitties generated with a royalty.
+}
+
+type RoyaltyReply struct {
+	Creator string `json:"creator"`
+	Percent uint8  `json:"percent"`
+}
+
+type Metadata struct {
+	Name       string `json:"name,omitempty"`
+	DNA        string `json:"dna,omitempty"` // Hex encoded.
+	Generation uint32 `json:"generation"`
+	ImageURI   string `json:"image_uri,omitempty"`
+}
+
+// NewKittyReply obtains the human readable form of the kitty state.
+func NewKittyReply(kittyID iko.KittyID, kState *iko.KittyState) KittyReply {
+	reply := KittyReply{
+		KittyID:      kittyID,
+		Address:      kState.Address.String(),
+		Transactions: kState.Transactions.ToStringArray(),
+		Burned:       kState.Burned,
+		CooldownSeq:  kState.CooldownSeq,
+	}
+	if !kState.Auction.IsZero() {
+		auction := NewAuctionReply(kittyID, kState.Auction)
+		reply.Auction = &auction
+	}
+	if r := kState.Royalty; !r.IsZero() {
+		reply.Royalty = &RoyaltyReply{
+			Creator: r.Creator.String(),
+			Percent: r.Percent,
+		}
+	}
+	if m := kState.Metadata; !m.IsZero() {
+		reply.Metadata = &Metadata{
+			Name:       m.Name,
+			DNA:        hex.EncodeToString(m.DNA),
+			Generation: m.Generation,
+			ImageURI:   m.ImageURI,
+		}
+	}
+	return reply
 }
 
 func getKitty(g *iko.BlockChain) HandlerFunc {
@@ -47,11 +137,7 @@ func getKitty(g *iko.BlockChain) HandlerFunc {
 		return SwitchTypeQuery(w, r, TqJson, TypeQueryActions{
 			TqJson: func() error {
 				return sendJson(w, http.StatusOK,
-					KittyReply{
-						KittyID:      kittyID,
-						Address:      kState.Address.String(),
-						Transactions: kState.Transactions.ToStringArray(),
-					})
+					NewKittyReply(kittyID, kState))
 			},
 			TqEnc: func() error {
 				return sendBin(w, http.StatusOK,
@@ -61,10 +147,81 @@ func getKitty(g *iko.BlockChain) HandlerFunc {
 	}
 }
 
+// KittiesReply is the states of many kitties, in the order requested.
+// 'Missing' are the requested kitties that do not exist.
+type KittiesReply struct {
+	Kitties []KittyReply `json:"kitties"`
+	Missing iko.KittyIDs `json:"missing,omitempty"`
+}
+
+// getKitties obtains the states of the comma separated kitty IDs of the 'ids'
+// query, of at most 'maxBulkQuery' kitties.
+func getKitties(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		idStrs := splitStr(r.URL.Query().Get("ids"))
+		if len(idStrs) > maxBulkQuery {
+			return sendJson(w, http.StatusBadRequest,
+				fmt.Sprintf("at most %d kitties can be queried at once", maxBulkQuery))
+		}
+		kittyIDs := make([]iko.KittyID, len(idStrs))
+		for i, idStr := range idStrs {
+			kittyID, e := iko.KittyIDFromString(idStr)
+			if e != nil {
+				return sendJson(w, http.StatusBadRequest,
+					fmt.Sprintf("invalid kitty id '%s' at index '%d'", idStr, i))
+			}
+			kittyIDs[i] = kittyID
+		}
+		kStates := g.GetKittyStates(kittyIDs)
+		reply := KittiesReply{
+			Kitties: make([]KittyReply, 0, len(kStates)),
+		}
+		for _, kittyID := range kittyIDs {
+			if kState, ok := kStates[kittyID]; ok {
+				reply.Kitties = append(reply.Kitties, NewKittyReply(kittyID, kState))
+			} else {
+				reply.Missing = append(reply.Missing, kittyID)
+			}
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
+// KittyHistoryReply is the provenance of a kitty, of it's txs in chain order.
+type KittyHistoryReply struct {
+	KittyID      iko.KittyID `json:"kitty_id"`
+	Transactions []TxReply   `json:"transactions"`
+}
+
+func getKittyHistory(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		kittyID, e := iko.KittyIDFromString(p.Base)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		txWraps, e := g.GetKittyHistory(kittyID)
+		if e != nil {
+			return sendJson(w, errStatus(e, http.StatusInternalServerError),
+				e.Error())
+		}
+		reply := KittyHistoryReply{
+			KittyID:      kittyID,
+			Transactions: make([]TxReply, len(txWraps)),
+		}
+		for i, txWrap := range txWraps {
+			reply.Transactions[i] = NewTxReplyOfTransaction(txWrap)
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
 type AddressReply struct {
 	Address      string       `json:"address"`
 	Kitties      iko.KittyIDs `json:"kitties"`
 	Transactions []string     `json:"transactions,omitempty"`
+	FeesPaid     uint64       `json:"fees_paid"`
+	Royalties    uint64       `json:"royalties,omitempty"` // Accrued to the address, as a creator.
 }
 
 func getAddress(g *iko.BlockChain) HandlerFunc {
@@ -82,6 +239,8 @@ func getAddress(g *iko.BlockChain) HandlerFunc {
 						Address:      address.String(),
 						Kitties:      aState.Kitties,
 						Transactions: aState.Transactions.ToStringArray(),
+						FeesPaid:     aState.FeesPaid,
+						Royalties:    aState.Royalties,
 					})
 			},
 			TqEnc: func() error {
@@ -92,6 +251,36 @@ func getAddress(g *iko.BlockChain) HandlerFunc {
 	}
 }
 
+// getAddresses obtains the states of the comma separated addresses of the
+// 'addrs' query, of at most 'maxBulkQuery' addresses, in the order requested.
+func getAddresses(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		addrStrs := splitStr(r.URL.Query().Get("addrs"))
+		if len(addrStrs) > maxBulkQuery {
+			return sendJson(w, http.StatusBadRequest,
+				fmt.Sprintf("at most %d addresses can be queried at once", maxBulkQuery))
+		}
+		addrs, e := toAddressArray(addrStrs)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		aStates := g.GetAddressStates(addrs)
+		reply := make([]AddressReply, len(addrs))
+		for i, addr := range addrs {
+			aState := aStates[addr]
+			reply[i] = AddressReply{
+				Address:      addr.String(),
+				Kitties:      aState.Kitties,
+				Transactions: aState.Transactions.ToStringArray(),
+				FeesPaid:     aState.FeesPaid,
+				Royalties:    aState.Royalties,
+			}
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
 type BalanceReply struct {
 	KittyCount int                     `json:"kitty_count"`
 	Kitties    iko.KittyIDs            `json:"kitties"`
@@ -111,8 +300,9 @@ func getBalance(g *iko.BlockChain) HandlerFunc {
 		var reply = BalanceReply{
 			Kitties: make([]iko.KittyID, len(addrs)),
 		}
+		aStates := g.GetAddressStates(addrs)
 		for _, addr := range addrs {
-			aState := g.GetAddressState(addr)
+			aState := aStates[addr]
 			reply.KittyCount += len(aState.Kitties)
 			reply.Kitties = append(reply.Kitties, aState.Kitties...)
 		}
@@ -129,10 +319,210 @@ type TxMeta struct {
 }
 
 type Tx struct {
+	KittyID  iko.KittyID  `json:"kitty_id"`
+	In       string       `json:"in"`
+	Out      string       `json:"out"`
+	Sig      string       `json:"sig"`
+	Inputs   []TxInput    `json:"inputs,omitempty"` // Additional kitties of multi-kitty transfers.
+	Memo     string       `json:"memo,omitempty"`   // Hex encoded.
+	Fee      uint64       `json:"fee,omitempty"`
+	LockSeq  uint64       `json:"lock_seq,omitempty"`
+	LockTS   int64        `json:"lock_ts,omitempty"`  // Unix time in nanoseconds.
+	Witness  string       `json:"witness,omitempty"`  // Hex encoded, of multisig transfers.
+	Escrow   string       `json:"escrow,omitempty"`   // Hex encoded, of txs settling an escrow.
+	Metadata string       `json:"metadata,omitempty"` // Hex encoded, of metadata txs.
+	ChildID  *iko.KittyID `json:"child_id,omitempty"` // Child of breed txs.
+	Auction  string       `json:"auction,omitempty"`  // Hex encoded, of auction txs.
+	Royalty  string       `json:"royalty,omitempty"`  // Hex encoded, of generation and transfer txs.
+	Creators []string     `json:"creators,omitempty"` // Hex encoded public keys, of rotation txs.
+	Admin    string       `json:"admin,omitempty"`    // Hex encoded, of admin txs.
+	Genesis  string       `json:"genesis,omitempty"`  // Hex encoded, of the genesis tx.
+	Network  string       `json:"network,omitempty"`  // Network the tx is signed for.
+	Version  uint8        `json:"version,omitempty"`  // Version of the tx encoding.
+}
+
+type TxInput struct {
 	KittyID iko.KittyID `json:"kitty_id"`
 	In      string      `json:"in"`
-	Out     string      `json:"out"`
-	Sig     string      `json:"sig"`
+}
+
+// NewTx obtains the human readable form of the transaction.
+func NewTx(tx *iko.Transaction) Tx {
+	out := Tx{
+		KittyID: tx.KittyID,
+		In:      tx.In.Hex(),
+		Out:     tx.Out.String(),
+		Sig:     tx.Sig.Hex(),
+		Fee:     tx.Fee,
+		LockSeq: tx.Lock.Seq,
+		LockTS:  tx.Lock.TS,
+	}
+	for _, in := range tx.Inputs {
+		out.Inputs = append(out.Inputs, TxInput{
+			KittyID: in.KittyID,
+			In:      in.In.Hex(),
+		})
+	}
+	if len(tx.Memo) > 0 {
+		out.Memo = hex.EncodeToString(tx.Memo)
+	}
+	if tx.Witness != nil {
+		out.Witness = hex.EncodeToString(tx.Witness.Serialize())
+	}
+	if tx.Escrow != nil {
+		out.Escrow = hex.EncodeToString(tx.Escrow.Serialize())
+	}
+	if tx.Metadata != nil {
+		out.Metadata = hex.EncodeToString(tx.Metadata.Serialize())
+	}
+	if tx.Breed != nil {
+		childID := tx.Breed.ChildID
+		out.ChildID = &childID
+	}
+	if tx.Auction != nil {
+		out.Auction = hex.EncodeToString(tx.Auction.Serialize())
+	}
+	if tx.Royalty != nil {
+		out.Royalty = hex.EncodeToString(tx.Royalty.Serialize())
+	}
+	if tx.Rotation != nil {
+		for _, pk := range tx.Rotation.Creators {
+			out.Creators = append(out.Creators, pk.Hex())
+		}
+	}
+	if tx.Admin != nil {
+		out.Admin = hex.EncodeToString(tx.Admin.Serialize())
+	}
+	if tx.Genesis != nil {
+		out.Genesis = hex.EncodeToString(tx.Genesis.Serialize())
+	}
+	out.Network = tx.Network
+	out.Version = tx.Version
+	return out
+}
+
+// Transaction obtains the transaction of the human readable form. An empty
+// 'sig' results in an unsigned transaction.
+func (t Tx) Transaction() (*iko.Transaction, error) {
+	tx := &iko.Transaction{
+		KittyID: t.KittyID,
+		Fee:     t.Fee,
+		Lock:    iko.TxLock{Seq: t.LockSeq, TS: t.LockTS},
+	}
+	in, e := cipher.SHA256FromHex(t.In)
+	if e != nil {
+		return nil, fmt.Errorf("invalid 'in': %v", e)
+	}
+	tx.In = iko.TxHash(in)
+	if tx.Out, e = cipher.DecodeBase58Address(t.Out); e != nil {
+		return nil, fmt.Errorf("invalid 'out': %v", e)
+	}
+	if t.Sig != "" {
+		if tx.Sig, e = cipher.SigFromHex(t.Sig); e != nil {
+			return nil, fmt.Errorf("invalid 'sig': %v", e)
+		}
+	}
+	for i, in := range t.Inputs {
+		inHash, e := cipher.SHA256FromHex(in.In)
+		if e != nil {
+			return nil, fmt.Errorf("invalid 'in' of input %d: %v", i, e)
+		}
+		tx.Inputs = append(tx.Inputs, iko.KittyInput{
+			KittyID: in.KittyID,
+			In:      iko.TxHash(inHash),
+		})
+	}
+	if t.Memo != "" {
+		if tx.Memo, e = hex.DecodeString(t.Memo); e != nil {
+			return nil, fmt.Errorf("invalid 'memo': %v", e)
+		}
+	}
+	if t.Witness != "" {
+		raw, e := hex.DecodeString(t.Witness)
+		if e != nil {
+			return nil, fmt.Errorf("invalid 'witness': %v", e)
+		}
+		tx.Witness = new(iko.Witness)
+		if e := encoder.DeserializeRaw(raw, tx.Witness); e != nil {
+			return nil, fmt.Errorf("invalid 'witness': %v", e)
+		}
+	}
+	if t.Escrow != "" {
+		raw, e := hex.DecodeString(t.Escrow)
+		if e != nil {
+			return nil, fmt.Errorf("invalid 'escrow': %v", e)
+		}
+		tx.Escrow = new(iko.Escrow)
+		if e := encoder.DeserializeRaw(raw, tx.Escrow); e != nil {
+			return nil, fmt.Errorf("invalid 'escrow': %v", e)
+		}
+	}
+	if t.Metadata != "" {
+		raw, e := hex.DecodeString(t.Metadata)
+		if e != nil {
+			return nil, fmt.Errorf("invalid 'metadata': %v", e)
+		}
+		tx.Metadata = new(iko.KittyMetadata)
+		if e := encoder.DeserializeRaw(raw, tx.Metadata); e != nil {
+			return nil, fmt.Errorf("invalid 'metadata': %v", e)
+		}
+	}
+	if t.ChildID != nil {
+		tx.Breed = &iko.TxBreed{ChildID: *t.ChildID}
+	}
+	if t.Auction != "" {
+		raw, e := hex.DecodeString(t.Auction)
+		if e != nil {
+			return nil, fmt.Errorf("invalid 'auction': %v", e)
+		}
+		tx.Auction = new(iko.TxAuction)
+		if e := encoder.DeserializeRaw(raw, tx.Auction); e != nil {
+			return nil, fmt.Errorf("invalid 'auction': %v", e)
+		}
+	}
+	if t.Royalty != "" {
+		raw, e := hex.DecodeString(t.Royalty)
+		if e != nil {
+			return nil, fmt.Errorf("invalid 'royalty': %v", e)
+		}
+		tx.Royalty = new(iko.TxRoyalty)
+		if e := encoder.DeserializeRaw(raw, tx.Royalty); e != nil {
+			return nil, fmt.Errorf("invalid 'royalty': %v", e)
+		}
+	}
+	if len(t.Creators) > 0 {
+		tx.Rotation = new(iko.TxRotation)
+		for _, v := range t.Creators {
+			pk, e := cipher.PubKeyFromHex(v)
+			if e != nil {
+				return nil, fmt.Errorf("invalid 'creators': %v", e)
+			}
+			tx.Rotation.Creators = append(tx.Rotation.Creators, pk)
+		}
+	}
+	if t.Admin != "" {
+		raw, e := hex.DecodeString(t.Admin)
+		if e != nil {
+			return nil, fmt.Errorf("invalid 'admin': %v", e)
+		}
+		tx.Admin = new(iko.TxAdmin)
+		if e := encoder.DeserializeRaw(raw, tx.Admin); e != nil {
+			return nil, fmt.Errorf("invalid 'admin': %v", e)
+		}
+	}
+	if t.Genesis != "" {
+		raw, e := hex.DecodeString(t.Genesis)
+		if e != nil {
+			return nil, fmt.Errorf("invalid 'genesis': %v", e)
+		}
+		tx.Genesis = new(iko.Genesis)
+		if e := encoder.DeserializeRaw(raw, tx.Genesis); e != nil {
+			return nil, fmt.Errorf("invalid 'genesis': %v", e)
+		}
+	}
+	tx.Network = t.Network
+	tx.Version = t.Version
+	return tx, nil
 }
 
 type TxReply struct {
@@ -148,19 +538,21 @@ func NewTxReplyOfTransaction(txWrap iko.TxWrapper) TxReply {
 			Seq:  txWrap.Meta.Seq,
 			TS:   txWrap.Meta.TS,
 		},
-		Tx: Tx{
-			KittyID: txWrap.Tx.KittyID,
-			In:      txWrap.Tx.In.Hex(),
-			Out:     txWrap.Tx.Out.String(),
-			Sig:     txWrap.Tx.Sig.Hex(),
-		},
+		Tx: NewTx(&txWrap.Tx),
 	}
 }
 
+// getTx obtains a tx of hash or seq. The request type is either specified in
+// the path ('/api/iko/tx/seq/{seq}') or via the 'request' query
+// ('/api/iko/tx/{seq}?request=seq').
 func getTx(g *iko.BlockChain) HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
 		var txWrap iko.TxWrapper
-		ok, e := SwitchReqQuery(w, r, RqHash, ReqQueryActions{
+		defReq := RqHash
+		if v := ReqQueryVal(p.Segment(len(p.SplitPath) - 2)); v == RqHash || v == RqSeq {
+			defReq = v
+		}
+		ok, e := SwitchReqQuery(w, r, defReq, ReqQueryActions{
 			RqHash: func() (bool, error) {
 				txHash, e := cipher.SHA256FromHex(p.Base)
 				if e != nil {
@@ -168,7 +560,7 @@ func getTx(g *iko.BlockChain) HandlerFunc {
 						e.Error())
 				}
 				if txWrap, e = g.GetTxOfHash(iko.TxHash(txHash)); e != nil {
-					return false, sendJson(w, http.StatusNotFound,
+					return false, sendJson(w, errStatus(e, http.StatusInternalServerError),
 						e.Error())
 				}
 				return true, nil
@@ -180,7 +572,7 @@ func getTx(g *iko.BlockChain) HandlerFunc {
 						e.Error())
 				}
 				if txWrap, e = g.GetTxOfSeq(seq); e != nil {
-					return false, sendJson(w, http.StatusNotFound,
+					return false, sendJson(w, errStatus(e, http.StatusInternalServerError),
 						e.Error())
 				}
 				return true, nil
@@ -227,50 +619,139 @@ type InjectTxRequest struct {
 
 func injectTx(g *iko.BlockChain) HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
-		txRaw, e := ioutil.ReadAll(r.Body)
+		tx, e := readTxRequest(r)
 		if e != nil {
 			return sendJson(w, http.StatusBadRequest,
 				e.Error())
 		}
-		var tx = new(iko.Transaction)
-		switch contentType := r.Header.Get("Content-Type"); contentType {
-		case "application/json":
-			req := new(InjectTxRequest)
-			if e := json.Unmarshal(txRaw, req); e != nil {
-				return sendJson(w, http.StatusBadRequest,
-					e.Error())
-			}
-			hexRaw, e := hex.DecodeString(req.Hex)
-			if e != nil {
-				return sendJson(w, http.StatusBadRequest,
-					e.Error())
-			}
-			if e := encoder.DeserializeRaw(hexRaw, tx); e != nil {
-				return sendJson(w, http.StatusBadRequest,
-					e.Error())
-			}
-		case "application/octet-stream":
-			if e := encoder.DeserializeRaw(txRaw, tx); e != nil {
-				return sendJson(w, http.StatusBadRequest,
-					e.Error())
-			}
+		meta, e := g.InjectTx(tx)
+		if e != nil {
+			return sendJson(w, errStatus(e, http.StatusBadRequest),
+				e.Error())
+		}
+		return sendJson(w, http.StatusOK, meta)
+	}
+}
+
+// VerifyTxReply is the reply of the '/api/iko/tx/verify' endpoint.
+type VerifyTxReply struct {
+	Hash  string `json:"hash"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"` // Reason of rejection, of invalid txs.
+}
+
+// verifyTx checks whether a tx would be accepted if injected now, without
+// injecting it. Rejected txs are replied to with their reason.
+func verifyTx(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		tx, e := readTxRequest(r)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		reply := VerifyTxReply{Hash: tx.Hash().Hex(), Valid: true}
+		switch e := g.CheckTx(tx); {
+		case e == nil:
+		case errors.Is(e, iko.ErrChainFull), errors.Is(e, iko.ErrChainStopped):
+			return sendJson(w, http.StatusServiceUnavailable,
+				e.Error())
 		default:
+			reply.Valid = false
+			reply.Error = e.Error()
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
+// readTxRequest reads a tx of the request body, which is either an
+// 'InjectTxRequest' or the raw tx.
+func readTxRequest(r *http.Request) (*iko.Transaction, error) {
+	txRaw, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		return nil, e
+	}
+	switch contentType := r.Header.Get("Content-Type"); contentType {
+	case "application/json":
+		req := new(InjectTxRequest)
+		if e := json.Unmarshal(txRaw, req); e != nil {
+			return nil, e
+		}
+		hexRaw, e := hex.DecodeString(req.Hex)
+		if e != nil {
+			return nil, e
+		}
+		return iko.DeserializeTx(hexRaw)
+	case "application/octet-stream":
+		return iko.DeserializeTx(txRaw)
+	default:
+		return nil, fmt.Errorf("content type '%s' is not supported, expecting '%s'",
+			contentType, []string{"application/json", "application/octet-stream"})
+	}
+}
+
+// RawTxReply is the reply of the '/api/iko/tx/decode' and '/api/iko/tx/encode'
+// endpoints.
+type RawTxReply struct {
+	Hash string `json:"hash"`
+	Raw  string `json:"raw"` // Hex encoded serialized tx.
+	Tx   Tx     `json:"transaction"`
+}
+
+func NewRawTxReply(tx *iko.Transaction) RawTxReply {
+	return RawTxReply{
+		Hash: tx.Hash().Hex(),
+		Raw:  hex.EncodeToString(tx.Serialize()),
+		Tx:   NewTx(tx),
+	}
+}
+
+// decodeTx converts a hex encoded raw tx ('InjectTxRequest') to it's human
+// readable form. The chain is not consulted.
+func decodeTx() HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		req := new(InjectTxRequest)
+		if e := json.NewDecoder(r.Body).Decode(req); e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		raw, e := hex.DecodeString(req.Hex)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		tx, e := iko.DeserializeTx(raw)
+		if e != nil {
 			return sendJson(w, http.StatusBadRequest,
-				fmt.Sprintf("content type '%s' is not supported, expecting '%s'",
-					contentType, []string{"application/json", "application/octet-stream"}))
+				e.Error())
 		}
+		return sendJson(w, http.StatusOK, NewRawTxReply(tx))
+	}
+}
 
-		meta, e := g.InjectTx(tx)
+// encodeTx converts the human readable form of a tx ('Tx') to it's hex encoded
+// raw form. The chain is not consulted.
+func encodeTx() HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		req := new(Tx)
+		if e := json.NewDecoder(r.Body).Decode(req); e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		tx, e := req.Transaction()
 		if e != nil {
 			return sendJson(w, http.StatusBadRequest,
 				e.Error())
 		}
-		return sendJson(w, http.StatusOK, meta)
+		return sendJson(w, http.StatusOK, NewRawTxReply(tx))
 	}
 }
 
 type PaginatedTxsReply struct {
 	TotalPageCount uint64    `json:"total_page_count"`
+	CurrentPage    uint64    `json:"current_page"`
+	PerPage        uint64    `json:"per_page"`
+	HasNext        bool      `json:"has_next"`
+	HasPrev        bool      `json:"has_prev"`
 	TxReplies      []TxReply `json:"transactions"`
 }
 
@@ -280,6 +761,9 @@ func getPaginatedTxs(g *iko.BlockChain) HandlerFunc {
 			qCurrentPage = r.URL.Query().Get("current_page")
 			qPerPage     = r.URL.Query().Get("per_page")
 		)
+		if qCurrentPage == "" {
+			qCurrentPage = r.URL.Query().Get("page")
+		}
 		perPage, e := strconv.ParseUint(qPerPage, 10, 64)
 		if e != nil {
 			return sendJson(w, http.StatusBadRequest,
@@ -290,21 +774,158 @@ func getPaginatedTxs(g *iko.BlockChain) HandlerFunc {
 			return sendJson(w, http.StatusBadRequest,
 				e.Error())
 		}
-		paginated, e := g.GetTransactionPage(currentPage, perPage)
+		filter, e := parseTxPageFilter(r)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		paginated, e := g.GetTransactionPage(currentPage, perPage, filter)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		return sendJson(w, http.StatusOK,
+			NewPaginatedTxsReply(paginated))
+	}
+}
+
+// NewPaginatedTxsReply obtains the human readable form of a page of txs.
+func NewPaginatedTxsReply(paginated iko.PaginatedTransactions) PaginatedTxsReply {
+	var txReplies []TxReply
+	for _, transaction := range paginated.Transactions {
+		txReplies = append(txReplies, NewTxReplyOfTransaction(transaction))
+	}
+	return PaginatedTxsReply{
+		TotalPageCount: paginated.TotalPageCount,
+		CurrentPage:    paginated.CurrentPage,
+		PerPage:        paginated.PerPage,
+		HasNext:        paginated.HasNext,
+		HasPrev:        paginated.HasPrev,
+		TxReplies:      txReplies,
+	}
+}
+
+// CursorTxsReply is a list of txs obtained with a cursor. 'NextCursor' is the
+// cursor to obtain the following txs in the same direction, and is empty if
+// no txs were obtained.
+type CursorTxsReply struct {
+	TxReplies  []TxReply `json:"transactions"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// getCursorTxs obtains txs after (or before) the optional 'cursor' query, of
+// at most the 'limit' query. Cursors should be treated as opaque.
+func getCursorTxs(g *iko.BlockChain, before bool) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		var (
+			qCursor = r.URL.Query().Get("cursor")
+			qLimit  = r.URL.Query().Get("limit")
+		)
+		cursor := iko.EmptyTxHash()
+		if qCursor != "" {
+			txHash, e := cipher.SHA256FromHex(qCursor)
+			if e != nil {
+				return sendJson(w, http.StatusBadRequest,
+					fmt.Sprintf("invalid cursor: %v", e))
+			}
+			cursor = iko.TxHash(txHash)
+		}
+		limit, e := strconv.ParseUint(qLimit, 10, 64)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		var txWraps []iko.TxWrapper
+		if before {
+			txWraps, e = g.GetTxsBefore(cursor, limit)
+		} else {
+			txWraps, e = g.GetTxsAfter(cursor, limit)
+		}
+		if e != nil {
+			return sendJson(w, errStatus(e, http.StatusBadRequest),
+				e.Error())
+		}
+		reply := CursorTxsReply{
+			TxReplies: make([]TxReply, len(txWraps)),
+		}
+		for i, txWrap := range txWraps {
+			reply.TxReplies[i] = NewTxReplyOfTransaction(txWrap)
+		}
+		if len(txWraps) > 0 {
+			reply.NextCursor = txWraps[len(txWraps)-1].Tx.Hash().Hex()
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
+// getAddressTxs obtains a page of the txs of the address of the path, with
+// the 'page' and 'per_page' queries.
+func getAddressTxs(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		address, e := cipher.DecodeBase58Address(p.Base)
 		if e != nil {
 			return sendJson(w, http.StatusBadRequest,
 				e.Error())
 		}
-		var txReplies []TxReply
-		for _, transaction := range paginated.Transactions {
-			txReplies = append(txReplies, NewTxReplyOfTransaction(transaction))
+		var (
+			qPage    = r.URL.Query().Get("page")
+			qPerPage = r.URL.Query().Get("per_page")
+		)
+		page, e := strconv.ParseUint(qPage, 10, 64)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
 		}
-		paginatedTxsReply := PaginatedTxsReply{
-			TotalPageCount: paginated.TotalPageCount,
-			TxReplies:      txReplies,
+		perPage, e := strconv.ParseUint(qPerPage, 10, 64)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		paginated, e := g.GetTransactionsOfAddress(address, page, perPage)
+		if e != nil {
+			return sendJson(w, errStatus(e, http.StatusBadRequest),
+				e.Error())
 		}
 		return sendJson(w, http.StatusOK,
-			paginatedTxsReply)
+			NewPaginatedTxsReply(paginated))
+	}
+}
+
+// streamTxs streams the txs of seq greater than 'since', or all txs if
+// 'since' is not specified, as newline delimited JSON. Once the client has
+// caught up, txs are streamed as they are accepted into the chain. Txs are
+// written as the client reads them.
+func streamTxs(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		var start uint64
+		if qSince := r.URL.Query().Get("since"); qSince != "" {
+			since, e := strconv.ParseUint(qSince, 10, 64)
+			if e != nil {
+				return sendJson(w, http.StatusBadRequest,
+					e.Error())
+			}
+			start = since + 1
+		}
+		flusher, _ := w.(http.Flusher)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		enc := json.NewEncoder(w)
+		e := g.StreamTxs(r.Context(), start, func(txWrap iko.TxWrapper) error {
+			if e := enc.Encode(NewTxReplyOfTransaction(txWrap)); e != nil {
+				return e
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+		if errors.Is(e, context.Canceled) {
+			return nil
+		}
+		return e
 	}
 }
 
@@ -312,6 +933,67 @@ func getPaginatedTxs(g *iko.BlockChain) HandlerFunc {
 	<<< HELPER FUNCTIONS >>>
 */
 
+// parseTxPageFilter parses the optional 'address', 'kitty_id', 'seq_start',
+// 'seq_end', 'ts_start', 'ts_end' and 'order' ("asc" or "desc") queries.
+// Timestamps are in unix nanoseconds, and range ends are exclusive. It
+// returns nil if no queries are specified.
+func parseTxPageFilter(r *http.Request) (*iko.TxPageFilter, error) {
+	var (
+		q      = r.URL.Query()
+		filter iko.TxPageFilter
+		empty  = true
+	)
+	if v := q.Get("address"); v != "" {
+		address, e := cipher.DecodeBase58Address(v)
+		if e != nil {
+			return nil, fmt.Errorf("invalid address '%s': %v", v, e)
+		}
+		filter.Address, empty = &address, false
+	}
+	if v := q.Get("kitty_id"); v != "" {
+		kittyID, e := iko.KittyIDFromString(v)
+		if e != nil {
+			return nil, e
+		}
+		filter.KittyID, empty = &kittyID, false
+	}
+	for key, dst := range map[string]*uint64{
+		"seq_start": &filter.SeqStart,
+		"seq_end":   &filter.SeqEnd,
+	} {
+		if v := q.Get(key); v != "" {
+			n, e := strconv.ParseUint(v, 10, 64)
+			if e != nil {
+				return nil, fmt.Errorf("invalid '%s': %v", key, e)
+			}
+			*dst, empty = n, false
+		}
+	}
+	for key, dst := range map[string]*int64{
+		"ts_start": &filter.TSStart,
+		"ts_end":   &filter.TSEnd,
+	} {
+		if v := q.Get(key); v != "" {
+			n, e := strconv.ParseInt(v, 10, 64)
+			if e != nil {
+				return nil, fmt.Errorf("invalid '%s': %v", key, e)
+			}
+			*dst, empty = n, false
+		}
+	}
+	switch v := iko.TxOrder(q.Get("order")); v {
+	case "":
+	case iko.TxOrderAscending, iko.TxOrderDescending:
+		filter.Order, empty = v, false
+	default:
+		return nil, fmt.Errorf("invalid order '%s'", v)
+	}
+	if empty {
+		return nil, nil
+	}
+	return &filter, nil
+}
+
 func splitStr(in string) []string {
 	out := strings.Split(in, ",")
 	for i := len(out) - 1; i >= 0; i-- {
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+// ikoAdminGateway serves backups of the chain and state at '/admin/backup',
+// and restores them at '/admin/restore'. The ChainDB is compacted at
+// '/admin/compact', and it's disk usage is served at '/admin/disk_usage'. As
+// with the other admin endpoints, these are only served to loopback
+// addresses, or authorised requests.
+func ikoAdminGateway(m *http.ServeMux, g *iko.BlockChain) error {
+	Handle(m, "/admin/backup", "POST", backup(g))
+	Handle(m, "/admin/restore", "POST", restoreBackup(g))
+	Handle(m, "/admin/compact", "POST", compactChain(g))
+	Handle(m, "/admin/disk_usage", "GET", getDiskUsage(g))
+	return nil
+}
+
+type DiskUsageReply struct {
+	ChainDB uint64 `json:"chain_db"` // Bytes occupied by the ChainDB.
+}
+
+type CompactReply struct {
+	Before DiskUsageReply `json:"before"`
+	After  DiskUsageReply `json:"after"`
+}
+
+// compactChain reclaims unused space of the ChainDB (such as after pruning),
+// replying with the disk usage before and after. Compaction stops early if
+// the request is cancelled.
+func compactChain(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		if !requireAdmin(w, r) {
+			return nil
+		}
+		reply, e := compact(r, g)
+		if e != nil {
+			return sendJson(w, http.StatusInternalServerError, e.Error())
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
+func getDiskUsage(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		if !requireAdmin(w, r) {
+			return nil
+		}
+		usage, e := g.DiskUsage()
+		if e != nil {
+			return sendJson(w, http.StatusInternalServerError, e.Error())
+		}
+		return sendJson(w, http.StatusOK, DiskUsageReply{ChainDB: usage})
+	}
+}
+
+func compact(r *http.Request, g *iko.BlockChain) (*CompactReply, error) {
+	before, e := g.DiskUsage()
+	if e != nil {
+		return nil, e
+	}
+	if e := g.Compact(r.Context()); e != nil {
+		return nil, e
+	}
+	after, e := g.DiskUsage()
+	if e != nil {
+		return nil, e
+	}
+	return &CompactReply{
+		Before: DiskUsageReply{ChainDB: before},
+		After:  DiskUsageReply{ChainDB: after},
+	}, nil
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+func TestIKOAdminGateway_Compact(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+	require.NoError(t, ikoAdminGateway(mux, bc))
+
+	for i := 0; i < 3; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+
+	do := func(method, url, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusForbidden, do("GET", "/admin/disk_usage", "192.0.2.1:1234").Code)
+	require.Equal(t, http.StatusForbidden, do("POST", "/admin/compact", "192.0.2.1:1234").Code)
+
+	rec := do("GET", "/admin/disk_usage", "127.0.0.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var usage DiskUsageReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &usage))
+	require.NotZero(t, usage.ChainDB)
+
+	rec = do("POST", "/admin/compact", "127.0.0.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var compacted CompactReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &compacted))
+	require.Equal(t, usage, compacted.Before)
+	require.NotZero(t, compacted.After.ChainDB)
+
+	for i := 0; i < 3; i++ {
+		_, ok := bc.GetKittyState(iko.KittyID(i))
+		require.True(t, ok, "kitties should remain after compaction")
+	}
+}
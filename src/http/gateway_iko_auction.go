@@ -0,0 +1,88 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+type AuctionReply struct {
+	KittyID  iko.KittyID `json:"kitty_id"`
+	Address  string      `json:"address"` // Address of the listing, which holds the kitty.
+	Seller   string      `json:"seller"`
+	Reserve  uint64      `json:"reserve"`
+	Deadline int64       `json:"deadline"` // Unix time in nanoseconds.
+	Bidder   string      `json:"bidder,omitempty"`
+	Bid      uint64      `json:"bid,omitempty"`
+}
+
+// NewAuctionReply obtains the human readable form of the auction state.
+func NewAuctionReply(kittyID iko.KittyID, aState iko.AuctionState) AuctionReply {
+	reply := AuctionReply{
+		KittyID:  kittyID,
+		Address:  aState.Listing.Address().String(),
+		Seller:   aState.Listing.Seller.String(),
+		Reserve:  aState.Listing.Reserve,
+		Deadline: aState.Listing.Deadline,
+		Bid:      aState.Bid,
+	}
+	if aState.Bidder != (cipher.Address{}) {
+		reply.Bidder = aState.Bidder.String()
+	}
+	return reply
+}
+
+// State obtains the auction state of the human readable form.
+func (a AuctionReply) State() (iko.AuctionState, error) {
+	aState := iko.AuctionState{
+		Listing: iko.Listing{
+			Reserve:  a.Reserve,
+			Deadline: a.Deadline,
+		},
+		Bid: a.Bid,
+	}
+	var e error
+	if aState.Listing.Seller, e = cipher.DecodeBase58Address(a.Seller); e != nil {
+		return aState, fmt.Errorf("invalid 'seller': %v", e)
+	}
+	if a.Bidder != "" {
+		if aState.Bidder, e = cipher.DecodeBase58Address(a.Bidder); e != nil {
+			return aState, fmt.Errorf("invalid 'bidder': %v", e)
+		}
+	}
+	return aState, nil
+}
+
+type AuctionsReply struct {
+	Count    int            `json:"count"`
+	Auctions []AuctionReply `json:"auctions"`
+}
+
+func newAuctionsReply(g *iko.BlockChain) AuctionsReply {
+	auctions := g.GetAuctions()
+	reply := AuctionsReply{
+		Count:    len(auctions),
+		Auctions: make([]AuctionReply, len(auctions)),
+	}
+	for i, entry := range auctions {
+		reply.Auctions[i] = NewAuctionReply(entry.KittyID, entry.State)
+	}
+	return reply
+}
+
+// getAuctions obtains the active listings, in ascending order of kitty ID.
+func getAuctions(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		return sendJson(w, http.StatusOK, newAuctionsReply(g))
+	}
+}
+
+func rpcGetAuctions(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 0); e != nil {
+		return nil, e
+	}
+	return newAuctionsReply(g), nil
+}
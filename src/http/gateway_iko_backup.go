@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+type RestoreBackupReply struct {
+	Restored uint64 `json:"restored"`
+	Error    string `json:"error,omitempty"`
+}
+
+// backupWriter sends the headers of a backup on the first write, so that an
+// error before the backup is written can still be replied to.
+type backupWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (bw *backupWriter) Write(p []byte) (int, error) {
+	if !bw.written {
+		bw.w.Header().Set("Content-Type", "application/octet-stream")
+		bw.w.Header().Set("Content-Disposition", `attachment; filename="kittycash.backup"`)
+		bw.w.WriteHeader(http.StatusOK)
+		bw.written = true
+	}
+	return bw.w.Write(p)
+}
+
+// backup streams a backup of the chain and state. Injection of txs is
+// blocked while backing up, but reads are still served. If the backup fails
+// after it is partially sent, it is truncated, which fails on restore.
+func backup(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		if !requireAdmin(w, r) {
+			return nil
+		}
+		bw := &backupWriter{w: w}
+		if e := g.Backup(bw); e != nil {
+			if !bw.written {
+				return sendJson(w, http.StatusInternalServerError, e.Error())
+			}
+			return e
+		}
+		return nil
+	}
+}
+
+// restoreBackup restores the backup of the request body into the chain, which
+// should be empty.
+func restoreBackup(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		if !requireAdmin(w, r) {
+			return nil
+		}
+		n, e := g.RestoreBackup(r.Body)
+		if e != nil {
+			status := http.StatusInternalServerError
+			switch e {
+			case iko.ErrChainNotEmpty:
+				status = http.StatusConflict
+			case iko.ErrNotBackup, iko.ErrBackupMismatch:
+				status = http.StatusBadRequest
+			}
+			return sendJson(w, status, RestoreBackupReply{Restored: n, Error: e.Error()})
+		}
+		return sendJson(w, http.StatusOK, RestoreBackupReply{Restored: n})
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+func TestIKOAdminGateway_Backup(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+	require.NoError(t, ikoAdminGateway(mux, bc))
+
+	for i := 0; i < 3; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+
+	post := func(url, remoteAddr, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", url, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusForbidden, post("/admin/backup", "192.0.2.1:1234", "").Code,
+		"backup should be forbidden to remote addresses")
+
+	rec := post("/admin/backup", "127.0.0.1:1234", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+	backup := rec.Body.String()
+
+	var reply RestoreBackupReply
+	rec = post("/admin/restore", "127.0.0.1:1234", backup)
+	require.Equal(t, http.StatusConflict, rec.Code, "restoring into a chain with txs should fail")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Equal(t, iko.ErrChainNotEmpty.Error(), reply.Error)
+
+	restored, restoredMux, closeRestored := newTestIKOGateway(t)
+	defer closeRestored()
+	require.NoError(t, ikoAdminGateway(restoredMux, restored))
+
+	req := httptest.NewRequest("POST", "/admin/restore", strings.NewReader(backup))
+	req.RemoteAddr = "127.0.0.1:1234"
+	rec = httptest.NewRecorder()
+	restoredMux.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Equal(t, uint64(3), reply.Restored)
+}
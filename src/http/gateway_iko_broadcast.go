@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+func broadcastGateway(m *http.ServeMux, q *iko.BroadcastQueue) error {
+	Handle(m, "/api/iko/broadcast_tx", "POST", broadcastTx(q))
+	Handle(m, "/api/iko/broadcast_queue", "GET", getBroadcastQueue(q))
+	Handle(m, "/api/iko/broadcast_queue/remove/", "POST", removeQueuedTx(q))
+	return nil
+}
+
+type QueuedTxReply struct {
+	Hash      string `json:"hash"`
+	Queued    int64  `json:"queued"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+	Tx        Tx     `json:"transaction"`
+}
+
+type BroadcastQueueReply struct {
+	Count int             `json:"count"`
+	Txs   []QueuedTxReply `json:"transactions"`
+}
+
+// broadcastTx queues a signed tx to be relayed until it is acknowledged. The
+// request body is the same as that of '/api/iko/inject_tx'.
+func broadcastTx(q *iko.BroadcastQueue) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		tx, e := readTxRequest(r)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		if !tx.IsSigned() {
+			return sendJson(w, http.StatusBadRequest,
+				errors.New("tx is not signed").Error())
+		}
+		if e := q.Enqueue(tx); e != nil {
+			return sendJson(w, http.StatusInternalServerError,
+				e.Error())
+		}
+		return sendJson(w, http.StatusOK,
+			SubmitTxReply{Hash: tx.Hash().Hex()})
+	}
+}
+
+func getBroadcastQueue(q *iko.BroadcastQueue) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		qTxs := q.Queued()
+		reply := BroadcastQueueReply{
+			Count: len(qTxs),
+			Txs:   make([]QueuedTxReply, len(qTxs)),
+		}
+		for i, qTx := range qTxs {
+			reply.Txs[i] = QueuedTxReply{
+				Hash:      qTx.Tx.Hash().Hex(),
+				Queued:    qTx.Queued,
+				Attempts:  qTx.Attempts,
+				LastError: qTx.LastError,
+				Tx:        NewTx(&qTx.Tx),
+			}
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
+func removeQueuedTx(q *iko.BroadcastQueue) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		hash, e := cipher.SHA256FromHex(p.Base)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		if e := q.Remove(iko.TxHash(hash)); e != nil {
+			return sendJson(w, http.StatusNotFound,
+				e.Error())
+		}
+		return sendJson(w, http.StatusOK, true)
+	}
+}
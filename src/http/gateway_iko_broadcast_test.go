@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+func TestBroadcastGateway(t *testing.T) {
+	upstream, upstreamMux, closeUpstream := newTestIKOGateway(t)
+	defer closeUpstream()
+
+	srv := httptest.NewServer(upstreamMux)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "kc_http_broadcast_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	q, err := iko.NewBroadcastQueue(&iko.BroadcastQueueConfig{
+		Path:          filepath.Join(dir, "queue.db"),
+		RetryInterval: time.Millisecond * 10,
+	}, NewRPCClient(srv.URL))
+	require.NoError(t, err)
+	defer q.Close()
+
+	mux := http.NewServeMux()
+	require.NoError(t, broadcastGateway(mux, q))
+
+	waitEmpty := func() {
+		deadline := time.Now().Add(time.Second * 5)
+		for len(q.Queued()) > 0 {
+			require.True(t, time.Now().Before(deadline), "queue should be emptied")
+			time.Sleep(time.Millisecond * 5)
+		}
+	}
+
+	tx := iko.NewGenTx(3, testGenSK)
+	rec := doRequest(mux, "POST", "/api/iko/broadcast_tx", "application/octet-stream", tx.Serialize())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	waitEmpty()
+
+	_, ok := upstream.GetKittyState(3)
+	require.True(t, ok, "broadcast tx should be injected upstream")
+
+	// Rejected by upstream, as the kitty exists.
+	rec = doRequest(mux, "POST", "/api/iko/broadcast_tx", "application/octet-stream", tx.Serialize())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	waitEmpty()
+
+	rec = doRequest(mux, "POST", "/api/iko/broadcast_tx", "application/octet-stream",
+		iko.NewUnsignedGenTx(4, upstream.CreatorAddress()).Serialize())
+	require.Equal(t, http.StatusBadRequest, rec.Code, "unsigned tx should not be queued")
+
+	rec = doRequest(mux, "GET", "/api/iko/broadcast_queue", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reply BroadcastQueueReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Equal(t, 0, reply.Count)
+}
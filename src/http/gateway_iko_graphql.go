@@ -0,0 +1,422 @@
+package http
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/util/graphql"
+)
+
+// GraphQLSchema documents the types served by the graphql endpoint.
+const GraphQLSchema = `
+type Query {
+	kitty(id: Int!): Kitty
+	address(address: String!): Address
+	transaction(hash: String, seq: Int): Transaction
+	head: Transaction
+	transactions(page: Int!, perPage: Int!): TransactionPage
+	creator: Address
+	creators: [Address!]!  # Addresses of the current creator keys.
+}
+
+type Kitty {
+	id: Int!
+	owner: Address!
+	burned: Boolean!   # Burned kitties are owned by the null address.
+	name: String       # Null if not set by a metadata transaction.
+	dna: String        # Hex encoded, null if not set by a metadata or breed transaction.
+	generation: Int!
+	imageUri: String   # Null if not set by a metadata transaction.
+	cooldownSeq: Int!  # Chain sequence from which the kitty can breed.
+	royalty: Int!      # Percent paid to the creator on transfers, zero if none.
+	transactions: [Transaction!]!
+}
+
+type Address {
+	address: String!
+	kittyCount: Int!
+	kitties: [Kitty!]!
+	transactions: [Transaction!]!
+	feesPaid: Int!
+	royalties: Int!    # Accrued to the address, as a creator.
+}
+
+type Transaction {
+	hash: String!
+	seq: Int!
+	ts: Int!
+	kittyId: Int!
+	kitty: Kitty!
+	inHash: String!
+	in: Transaction    # Null for generation transactions.
+	from: Address      # Null for generation transactions.
+	to: Address!
+	sig: String!
+	memo: String       # Hex encoded, null for transactions without a memo.
+	fee: Int!
+	lockSeq: Int!      # Zero if not locked by sequence.
+	lockTs: Int!       # Zero if not locked by time.
+	escrow: String     # Address of the escrow settled by the transaction, if any.
+	child: Kitty       # Child bred by the transaction, if any.
+	raw: String!
+}
+
+type TransactionPage {
+	totalPageCount: Int!
+	currentPage: Int!
+	perPage: Int!
+	hasNext: Boolean!
+	hasPrev: Boolean!
+	transactions: [Transaction!]!
+}
+`
+
+// graphQL serves queries of 'GraphQLSchema' so that kitties, owners and
+// transfer history can be obtained within a single request.
+func graphQL(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		body, e := ioutil.ReadAll(r.Body)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		req := new(graphql.Request)
+		switch contentType := r.Header.Get("Content-Type"); contentType {
+		case "application/graphql":
+			req.Query = string(body)
+		default:
+			if e := json.Unmarshal(body, req); e != nil {
+				return sendJson(w, http.StatusBadRequest,
+					e.Error())
+			}
+		}
+		return sendJson(w, http.StatusOK,
+			graphql.Execute(gqlQuery{g: g}, req))
+	}
+}
+
+type gqlQuery struct {
+	g *iko.BlockChain
+}
+
+func (q gqlQuery) TypeName() string {
+	return "Query"
+}
+
+func (q gqlQuery) Resolve(field string, args graphql.Args) (interface{}, error) {
+	switch field {
+	case "kitty":
+		id, e := args.Uint("id")
+		if e != nil {
+			return nil, e
+		}
+		return gqlKittyOfID(q.g, iko.KittyID(id)), nil
+
+	case "address":
+		addrStr, e := args.String("address")
+		if e != nil {
+			return nil, e
+		}
+		address, e := cipher.DecodeBase58Address(addrStr)
+		if e != nil {
+			return nil, e
+		}
+		return gqlAddress{g: q.g, address: address}, nil
+
+	case "transaction":
+		var (
+			txWrap iko.TxWrapper
+			e      error
+		)
+		if args.Has("hash") {
+			txWrap, e = gqlTxOfHash(q.g, args)
+		} else {
+			txWrap, e = gqlTxOfSeq(q.g, args)
+		}
+		switch e {
+		case nil:
+			return gqlTx{g: q.g, txWrap: txWrap}, nil
+		case errGQLTxNotFound:
+			return nil, nil
+		default:
+			return nil, e
+		}
+
+	case "head":
+		txWrap, e := q.g.GetHeadTx()
+		if e != nil {
+			return nil, nil
+		}
+		return gqlTx{g: q.g, txWrap: txWrap}, nil
+
+	case "transactions":
+		page, e := args.Uint("page")
+		if e != nil {
+			return nil, e
+		}
+		perPage, e := args.Uint("perPage")
+		if e != nil {
+			return nil, e
+		}
+		paginated, e := q.g.GetTransactionPage(page, perPage, nil)
+		if e != nil {
+			return nil, e
+		}
+		return gqlTxPage{g: q.g, paginated: paginated}, nil
+
+	case "creator":
+		return gqlAddress{g: q.g, address: q.g.CreatorAddress()}, nil
+
+	case "creators":
+		addresses := q.g.CreatorAddresses()
+		out := make([]graphql.Object, len(addresses))
+		for i, address := range addresses {
+			out[i] = gqlAddress{g: q.g, address: address}
+		}
+		return out, nil
+
+	default:
+		return nil, graphql.UnknownField(q, field)
+	}
+}
+
+type gqlKitty struct {
+	g      *iko.BlockChain
+	id     iko.KittyID
+	kState *iko.KittyState
+}
+
+// gqlKittyOfID returns nil if the kitty does not exist.
+func gqlKittyOfID(g *iko.BlockChain, kittyID iko.KittyID) graphql.Object {
+	kState, ok := g.GetKittyState(kittyID)
+	if !ok {
+		return nil
+	}
+	return gqlKitty{g: g, id: kittyID, kState: kState}
+}
+
+func (k gqlKitty) TypeName() string {
+	return "Kitty"
+}
+
+func (k gqlKitty) Resolve(field string, args graphql.Args) (interface{}, error) {
+	switch field {
+	case "id":
+		return k.id, nil
+	case "owner":
+		return gqlAddress{g: k.g, address: k.kState.Address}, nil
+	case "burned":
+		return k.kState.Burned, nil
+	case "name":
+		return gqlOptionalString(k.kState.Metadata.Name), nil
+	case "dna":
+		return gqlOptionalString(hex.EncodeToString(k.kState.Metadata.DNA)), nil
+	case "generation":
+		return k.kState.Metadata.Generation, nil
+	case "imageUri":
+		return gqlOptionalString(k.kState.Metadata.ImageURI), nil
+	case "cooldownSeq":
+		return k.kState.CooldownSeq, nil
+	case "royalty":
+		return k.kState.Royalty.Percent, nil
+	case "transactions":
+		return gqlTxsOfHashes(k.g, k.kState.Transactions)
+	default:
+		return nil, graphql.UnknownField(k, field)
+	}
+}
+
+type gqlAddress struct {
+	g       *iko.BlockChain
+	address cipher.Address
+}
+
+func (a gqlAddress) TypeName() string {
+	return "Address"
+}
+
+func (a gqlAddress) Resolve(field string, args graphql.Args) (interface{}, error) {
+	switch field {
+	case "address":
+		return a.address.String(), nil
+	case "kittyCount":
+		return len(a.g.GetAddressState(a.address).Kitties), nil
+	case "kitties":
+		aState := a.g.GetAddressState(a.address)
+		out := make([]graphql.Object, 0, len(aState.Kitties))
+		for _, kittyID := range aState.Kitties {
+			if kitty := gqlKittyOfID(a.g, kittyID); kitty != nil {
+				out = append(out, kitty)
+			}
+		}
+		return out, nil
+	case "transactions":
+		return gqlTxsOfHashes(a.g, a.g.GetAddressState(a.address).Transactions)
+	case "feesPaid":
+		return a.g.GetAddressState(a.address).FeesPaid, nil
+	case "royalties":
+		return a.g.GetAddressState(a.address).Royalties, nil
+	default:
+		return nil, graphql.UnknownField(a, field)
+	}
+}
+
+type gqlTx struct {
+	g      *iko.BlockChain
+	txWrap iko.TxWrapper
+}
+
+func (t gqlTx) TypeName() string {
+	return "Transaction"
+}
+
+func (t gqlTx) Resolve(field string, args graphql.Args) (interface{}, error) {
+	tx := &t.txWrap.Tx
+	switch field {
+	case "hash":
+		return tx.Hash().Hex(), nil
+	case "seq":
+		return t.txWrap.Meta.Seq, nil
+	case "ts":
+		return t.txWrap.Meta.TS, nil
+	case "kittyId":
+		return tx.KittyID, nil
+	case "kitty":
+		return gqlKittyOfID(t.g, tx.KittyID), nil
+	case "inHash":
+		return tx.In.Hex(), nil
+	case "in", "from":
+		if tx.In == iko.EmptyTxHash() {
+			return nil, nil
+		}
+		in, e := t.g.GetTxOfHash(tx.In)
+		if e != nil {
+			return nil, e
+		}
+		if field == "from" {
+			return gqlAddress{g: t.g, address: in.Tx.Out}, nil
+		}
+		return gqlTx{g: t.g, txWrap: in}, nil
+	case "to":
+		return gqlAddress{g: t.g, address: tx.Out}, nil
+	case "sig":
+		return tx.Sig.Hex(), nil
+	case "memo":
+		if len(tx.Memo) == 0 {
+			return nil, nil
+		}
+		return hex.EncodeToString(tx.Memo), nil
+	case "fee":
+		return tx.Fee, nil
+	case "lockSeq":
+		return tx.Lock.Seq, nil
+	case "lockTs":
+		return tx.Lock.TS, nil
+	case "escrow":
+		if tx.Escrow == nil {
+			return nil, nil
+		}
+		return tx.Escrow.Address().String(), nil
+	case "child":
+		if tx.Breed == nil {
+			return nil, nil
+		}
+		return gqlKittyOfID(t.g, tx.Breed.ChildID), nil
+	case "raw":
+		return hex.EncodeToString(tx.Serialize()), nil
+	default:
+		return nil, graphql.UnknownField(t, field)
+	}
+}
+
+type gqlTxPage struct {
+	g         *iko.BlockChain
+	paginated iko.PaginatedTransactions
+}
+
+func (p gqlTxPage) TypeName() string {
+	return "TransactionPage"
+}
+
+func (p gqlTxPage) Resolve(field string, args graphql.Args) (interface{}, error) {
+	switch field {
+	case "totalPageCount":
+		return p.paginated.TotalPageCount, nil
+	case "currentPage":
+		return p.paginated.CurrentPage, nil
+	case "perPage":
+		return p.paginated.PerPage, nil
+	case "hasNext":
+		return p.paginated.HasNext, nil
+	case "hasPrev":
+		return p.paginated.HasPrev, nil
+	case "transactions":
+		out := make([]graphql.Object, len(p.paginated.Transactions))
+		for i, txWrap := range p.paginated.Transactions {
+			out[i] = gqlTx{g: p.g, txWrap: txWrap}
+		}
+		return out, nil
+	default:
+		return nil, graphql.UnknownField(p, field)
+	}
+}
+
+/*
+	<<< HELPER FUNCTIONS >>>
+*/
+
+var errGQLTxNotFound = errors.New("tx not found")
+
+func gqlTxOfHash(g *iko.BlockChain, args graphql.Args) (iko.TxWrapper, error) {
+	hashStr, e := args.String("hash")
+	if e != nil {
+		return iko.TxWrapper{}, e
+	}
+	txHash, e := cipher.SHA256FromHex(hashStr)
+	if e != nil {
+		return iko.TxWrapper{}, e
+	}
+	txWrap, e := g.GetTxOfHash(iko.TxHash(txHash))
+	if e != nil {
+		return iko.TxWrapper{}, errGQLTxNotFound
+	}
+	return txWrap, nil
+}
+
+func gqlTxOfSeq(g *iko.BlockChain, args graphql.Args) (iko.TxWrapper, error) {
+	seq, e := args.Uint("seq")
+	if e != nil {
+		return iko.TxWrapper{}, e
+	}
+	txWrap, e := g.GetTxOfSeq(seq)
+	if e != nil {
+		return iko.TxWrapper{}, errGQLTxNotFound
+	}
+	return txWrap, nil
+}
+
+func gqlTxsOfHashes(g *iko.BlockChain, hashes iko.TxHashes) ([]graphql.Object, error) {
+	out := make([]graphql.Object, len(hashes))
+	for i, hash := range hashes {
+		txWrap, e := g.GetTxOfHash(hash)
+		if e != nil {
+			return nil, e
+		}
+		out[i] = gqlTx{g: g, txWrap: txWrap}
+	}
+	return out, nil
+}
+
+// gqlOptionalString resolves empty strings as null.
+func gqlOptionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/util/graphql"
+)
+
+func TestIKOGateway_GraphQL(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	toPK, _ := cipher.GenerateDeterministicKeyPair([]byte("graphql test receiver"))
+	toAddr := cipher.AddressFromPubKey(toPK)
+
+	sub, unsubscribe := bc.SubscribeTxs(4)
+	defer unsubscribe()
+
+	// Create two kitties, and transfer the second.
+	genTx0 := iko.NewGenTx(0, testGenSK)
+	genTx1 := iko.NewGenTx(1, testGenSK)
+	transferTx, err := iko.NewTransferTx(genTx1, toAddr, testGenSK)
+	require.NoError(t, err)
+	for _, tx := range []*iko.Transaction{genTx0, genTx1, transferTx} {
+		_, err := bc.InjectTx(tx)
+		require.NoError(t, err)
+		<-sub
+	}
+
+	query := func(t *testing.T, req graphql.Request) string {
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		rec := doRequest(mux, "POST", "/api/iko/graphql", "application/json", body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec.Body.String()
+	}
+
+	t.Run("KittyHistory", func(t *testing.T) {
+		reply := query(t, graphql.Request{
+			Query: `query History($id: Int!) {
+				kitty(id: $id) {
+					id
+					owner { address kittyCount }
+					transactions { seq from { address } to { address } }
+				}
+			}`,
+			Variables: map[string]interface{}{"id": 1},
+		})
+		require.JSONEq(t, `{"data":{"kitty":{
+			"id": 1,
+			"owner": {"address": "`+toAddr.String()+`", "kittyCount": 1},
+			"transactions": [
+				{"seq": 1, "from": null, "to": {"address": "`+bc.CreatorAddress().String()+`"}},
+				{"seq": 2, "from": {"address": "`+bc.CreatorAddress().String()+`"}, "to": {"address": "`+toAddr.String()+`"}}
+			]
+		}}}`, reply)
+	})
+
+	t.Run("AddressKitties", func(t *testing.T) {
+		reply := query(t, graphql.Request{
+			Query: `{ creator { kitties { id transactions { hash } } } }`,
+		})
+		require.JSONEq(t, `{"data":{"creator":{"kitties":[
+			{"id": 0, "transactions": [{"hash": "`+genTx0.Hash().Hex()+`"}]}
+		]}}}`, reply)
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		reply := query(t, graphql.Request{
+			Query: `{
+				byHash: transaction(hash: "` + transferTx.Hash().Hex() + `") { seq in { seq } kitty { id } }
+				bySeq: transaction(seq: 0) { hash }
+				missing: transaction(seq: 10) { hash }
+				head { seq }
+			}`,
+		})
+		require.JSONEq(t, `{"data":{
+			"byHash": {"seq": 2, "in": {"seq": 1}, "kitty": {"id": 1}},
+			"bySeq": {"hash": "`+genTx0.Hash().Hex()+`"},
+			"missing": null,
+			"head": {"seq": 2}
+		}}`, reply)
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		reply := query(t, graphql.Request{
+			Query: `{ transactions(page: 0, perPage: 2) { totalPageCount hasNext transactions { seq } } }`,
+		})
+		require.JSONEq(t, `{"data":{"transactions":{
+			"totalPageCount": 2, "hasNext": true, "transactions": [{"seq": 0}, {"seq": 1}]
+		}}}`, reply)
+	})
+
+	t.Run("RawQuery", func(t *testing.T) {
+		rec := doRequest(mux, "POST", "/api/iko/graphql", "application/graphql",
+			[]byte(`{ head { seq } }`))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"data":{"head":{"seq":2}}}`, rec.Body.String())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		reply := query(t, graphql.Request{Query: `{ kitty(id: -1) { id } }`})
+		require.JSONEq(t, `{"data":{"kitty":null},"errors":[
+			{"message":"argument 'id' should not be negative","path":["kitty"]}
+		]}`, reply)
+
+		reply = query(t, graphql.Request{Query: `{ kitty(id: 1) { `})
+		var resp graphql.Response
+		require.NoError(t, json.Unmarshal([]byte(reply), &resp))
+		require.Nil(t, resp.Data)
+		require.Len(t, resp.Errors, 1)
+	})
+}
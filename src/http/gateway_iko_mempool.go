@@ -0,0 +1,117 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+type PendingTxReply struct {
+	Hash     string `json:"hash"`
+	Received int64  `json:"received"`
+	Tx       Tx     `json:"transaction"`
+}
+
+func NewPendingTxReply(ptx iko.PendingTx) PendingTxReply {
+	return PendingTxReply{
+		Hash:     ptx.Tx.Hash().Hex(),
+		Received: ptx.Received,
+		Tx:       NewTx(&ptx.Tx),
+	}
+}
+
+type PendingTxsReply struct {
+	Count int              `json:"count"`
+	Txs   []PendingTxReply `json:"transactions"`
+}
+
+type SubmitTxReply struct {
+	Hash string `json:"hash"`
+}
+
+type EvictPendingTxReply struct {
+	Evicted []string `json:"evicted"`
+}
+
+type CommitPendingReply struct {
+	Committed int `json:"committed"`
+	Pending   int `json:"pending"`
+}
+
+func getPendingTxs(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		ptxs := g.PendingTxs()
+		reply := PendingTxsReply{
+			Count: len(ptxs),
+			Txs:   make([]PendingTxReply, len(ptxs)),
+		}
+		for i, ptx := range ptxs {
+			reply.Txs[i] = NewPendingTxReply(ptx)
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
+func getPendingTx(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		hash, e := cipher.SHA256FromHex(p.Base)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		ptx, ok := g.GetPendingTx(iko.TxHash(hash))
+		if !ok {
+			return sendJson(w, http.StatusNotFound,
+				fmt.Sprintf("tx of hash '%s' is not pending", p.Base))
+		}
+		return sendJson(w, http.StatusOK, NewPendingTxReply(ptx))
+	}
+}
+
+// submitTx adds a tx to the mempool. The request body is the same as that of
+// '/api/iko/inject_tx'.
+func submitTx(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		tx, e := readTxRequest(r)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		if e := g.SubmitTx(tx); e != nil {
+			return sendJson(w, errStatus(e, http.StatusBadRequest),
+				e.Error())
+		}
+		return sendJson(w, http.StatusOK,
+			SubmitTxReply{Hash: tx.Hash().Hex()})
+	}
+}
+
+func evictPendingTx(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		hash, e := cipher.SHA256FromHex(p.Base)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		evicted, e := g.EvictPendingTx(iko.TxHash(hash))
+		if e != nil {
+			return sendJson(w, http.StatusNotFound,
+				e.Error())
+		}
+		return sendJson(w, http.StatusOK,
+			EvictPendingTxReply{Evicted: iko.TxHashes(evicted).ToStringArray()})
+	}
+}
+
+func commitPending(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		committed := g.CommitPending()
+		return sendJson(w, http.StatusOK, CommitPendingReply{
+			Committed: committed,
+			Pending:   len(g.PendingTxs()),
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+func TestIKOGateway_Mempool(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	var txs []*iko.Transaction
+	for i := 0; i < 2; i++ {
+		tx := iko.NewGenTx(iko.KittyID(i), testGenSK)
+		rec := doRequest(mux, "POST", "/api/iko/mempool/submit", "application/octet-stream", tx.Serialize())
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var reply SubmitTxReply
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+		require.Equal(t, tx.Hash().Hex(), reply.Hash)
+		txs = append(txs, tx)
+	}
+
+	rec := doRequest(mux, "POST", "/api/iko/mempool/submit", "application/octet-stream", txs[0].Serialize())
+	require.Equal(t, http.StatusBadRequest, rec.Code, "duplicate tx should be rejected")
+
+	rec = doRequest(mux, "GET", "/api/iko/mempool", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var list PendingTxsReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Equal(t, 2, list.Count)
+	require.Equal(t, txs[1].Hash().Hex(), list.Txs[1].Hash)
+
+	rec = doRequest(mux, "GET", "/api/iko/mempool/tx/"+txs[0].Hash().Hex(), "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(mux, "POST", "/api/iko/mempool/evict/"+txs[0].Hash().Hex(), "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var evicted EvictPendingTxReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &evicted))
+	require.Equal(t, []string{txs[0].Hash().Hex()}, evicted.Evicted)
+
+	rec = doRequest(mux, "GET", "/api/iko/mempool/tx/"+txs[0].Hash().Hex(), "", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(mux, "POST", "/api/iko/mempool/commit", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var commit CommitPendingReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &commit))
+	require.Equal(t, CommitPendingReply{Committed: 1, Pending: 0}, commit)
+
+	_, ok := bc.GetKittyState(1)
+	require.True(t, ok, "committed kitty should exist")
+}
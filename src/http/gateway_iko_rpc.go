@@ -0,0 +1,511 @@
+package http
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	RPCErrParse          = -32700
+	RPCErrInvalidRequest = -32600
+	RPCErrMethodNotFound = -32601
+	RPCErrInvalidParams  = -32602
+	RPCErrInternal       = -32603
+
+	// RPCErrServer is the implementation-defined error code for when the
+	// blockchain could not fulfill the request (i.e. not found, rejected tx).
+	RPCErrServer = -32000
+
+	// RPCErrUnauthorized is the implementation-defined error code for when an
+	// admin method is called by a request that is not authorised.
+	RPCErrUnauthorized = -32001
+
+	jsonRPCVersion = "2.0"
+)
+
+// RPCRequest is a JSON-RPC 2.0 request. Following Skycoin's webrpc, params are
+// an array of strings.
+type RPCRequest struct {
+	ID      json.RawMessage `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  []string        `json:"params,omitempty"`
+}
+
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s [code: %d]", e.Message, e.Code)
+}
+
+type RPCResponse struct {
+	ID      json.RawMessage `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+type rpcMethod func(g *iko.BlockChain, params []string) (interface{}, *RPCError)
+
+var rpcMethods = map[string]rpcMethod{
+	"get_status":             rpcGetStatus,
+	"get_checkpoint":         rpcGetCheckpoint,
+	"get_segment_root":       rpcGetSegmentRoot,
+	"get_transaction_proof":  rpcGetTxProof,
+	"get_head_transaction":   rpcGetHeadTx,
+	"get_transaction":        rpcGetTxOfHash,
+	"get_transaction_by_seq": rpcGetTxOfSeq,
+	"get_transactions":       rpcGetPaginatedTxs,
+	"get_kitty":              rpcGetKitty,
+	"get_address":            rpcGetAddress,
+	"get_balance":            rpcGetBalance,
+	"get_auctions":           rpcGetAuctions,
+}
+
+type rpcAdminMethod func(r *http.Request, g *iko.BlockChain, params []string) (interface{}, *RPCError)
+
+// rpcAdminMethods are only served to loopback addresses, or requests
+// authorised by an Authenticator, as with the admin endpoints. These include
+// the methods that change the chain.
+var rpcAdminMethods = map[string]rpcAdminMethod{
+	"verify_chain":       rpcVerifyChain,
+	"compact":            rpcCompact,
+	"get_disk_usage":     rpcGetDiskUsage,
+	"inject_transaction": rpcInjectTx,
+}
+
+// webRPC serves a JSON-RPC 2.0 endpoint following the conventions of Skycoin's
+// '/webrpc', with methods mirroring the BlockChain API. RPC errors are replied
+// with status 200 as the error is contained within the response.
+func webRPC(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		var req RPCRequest
+		if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+			return sendJson(w, http.StatusOK,
+				rpcErrorResponse(nil, RPCErrParse, e.Error()))
+		}
+		if req.JSONRPC != jsonRPCVersion {
+			return sendJson(w, http.StatusOK,
+				rpcErrorResponse(req.ID, RPCErrInvalidRequest,
+					fmt.Sprintf("invalid jsonrpc version '%s'", req.JSONRPC)))
+		}
+		method, ok := rpcMethods[req.Method]
+		if adminMethod, isAdminMethod := rpcAdminMethods[req.Method]; isAdminMethod {
+			if !isAdmin(r) {
+				return sendJson(w, http.StatusOK,
+					rpcErrorResponse(req.ID, RPCErrUnauthorized,
+						fmt.Sprintf("method '%s' is %v", req.Method, errAdminOnly)))
+			}
+			method, ok = func(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+				return adminMethod(r, g, params)
+			}, true
+		}
+		if !ok {
+			return sendJson(w, http.StatusOK,
+				rpcErrorResponse(req.ID, RPCErrMethodNotFound,
+					fmt.Sprintf("method '%s' not found", req.Method)))
+		}
+		result, rpcErr := method(g, req.Params)
+		if rpcErr != nil {
+			return sendJson(w, http.StatusOK,
+				RPCResponse{ID: req.ID, JSONRPC: jsonRPCVersion, Error: rpcErr})
+		}
+		return sendJson(w, http.StatusOK,
+			RPCResponse{ID: req.ID, JSONRPC: jsonRPCVersion, Result: result})
+	}
+}
+
+type StatusReply struct {
+	HeadSeq          uint64   `json:"head_seq"`
+	HeadHash         string   `json:"head_hash"`
+	Empty            bool     `json:"empty"`
+	CreatorAddress   string   `json:"creator_address"`   // Of the primary creator key.
+	CreatorAddresses []string `json:"creator_addresses"` // Of all current creator keys.
+	CreatorPKs       []string `json:"creator_pks"`       // Hex encoded current creator keys, of the creator quorum.
+	CreatorEpoch     uint64   `json:"creator_epoch"`     // That rotation txs should be of.
+	GenerationFrozen bool     `json:"generation_frozen"`
+	ChainID          string   `json:"chain_id,omitempty"`   // Of the genesis tx, if it defines a genesis.
+	NetworkID        string   `json:"network_id,omitempty"` // That txs should be signed for.
+	Replica          bool     `json:"replica,omitempty"`    // Of read replicas, which do not accept txs.
+}
+
+func rpcGetStatus(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 0); e != nil {
+		return nil, e
+	}
+	reply := StatusReply{
+		CreatorAddress:   g.CreatorAddress().String(),
+		CreatorEpoch:     g.CreatorEpoch(),
+		GenerationFrozen: g.IsGenerationFrozen(),
+		NetworkID:        g.NetworkID(),
+		Replica:          g.IsReplica(),
+	}
+	for _, address := range g.CreatorAddresses() {
+		reply.CreatorAddresses = append(reply.CreatorAddresses, address.String())
+	}
+	for _, pk := range g.CreatorPKs() {
+		reply.CreatorPKs = append(reply.CreatorPKs, pk.Hex())
+	}
+	if genesis, e := g.Genesis(); e == nil && genesis != nil {
+		reply.ChainID = genesis.ChainID
+	}
+	if txWrap, e := g.GetHeadTx(); e != nil {
+		reply.Empty = true
+	} else {
+		reply.HeadSeq = txWrap.Meta.Seq
+		reply.HeadHash = txWrap.Tx.Hash().Hex()
+	}
+	return reply, nil
+}
+
+type CheckpointReply struct {
+	Seq        uint64 `json:"seq"`
+	StateHash  string `json:"state_hash"`
+	Checkpoint string `json:"checkpoint"` // Of the form '<seq>:<state_hash>'.
+}
+
+func rpcGetCheckpoint(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 0); e != nil {
+		return nil, e
+	}
+	cp, e := g.Checkpoint()
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	return CheckpointReply{
+		Seq:        cp.Seq,
+		StateHash:  cp.StateHash.Hex(),
+		Checkpoint: cp.String(),
+	}, nil
+}
+
+type VerifyChainReply struct {
+	Diverged bool   `json:"diverged"`
+	Seq      uint64 `json:"seq,omitempty"`    // Of the invalid tx, or the chain height if the state diverges.
+	Reason   string `json:"reason,omitempty"` // Describes the divergence.
+	Error    string `json:"error,omitempty"`  // Of the invalid tx, or of the state divergence.
+}
+
+// rpcVerifyChain re-validates the chain and cross-checks the state (see
+// 'BlockChain.VerifyChain'), until the request is cancelled.
+func rpcVerifyChain(r *http.Request, g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 0); e != nil {
+		return nil, e
+	}
+	d, e := g.VerifyChain(r.Context())
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	if d == nil {
+		return VerifyChainReply{}, nil
+	}
+	return VerifyChainReply{
+		Diverged: true,
+		Seq:      d.Seq,
+		Reason:   d.Reason,
+		Error:    d.Err.Error(),
+	}, nil
+}
+
+// rpcCompact compacts the ChainDB (see 'BlockChain.Compact'), until the request
+// is cancelled.
+func rpcCompact(r *http.Request, g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 0); e != nil {
+		return nil, e
+	}
+	reply, e := compact(r, g)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	return reply, nil
+}
+
+func rpcGetDiskUsage(r *http.Request, g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 0); e != nil {
+		return nil, e
+	}
+	usage, e := g.DiskUsage()
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	return DiskUsageReply{ChainDB: usage}, nil
+}
+
+type SegmentRootReply struct {
+	Segment uint64 `json:"segment"`
+	Size    uint64 `json:"size"`
+	Root    string `json:"root"`
+}
+
+// SegmentRoot converts the reply to the root it represents.
+func (r *SegmentRootReply) SegmentRoot() (iko.SegmentRoot, error) {
+	root, e := cipher.SHA256FromHex(r.Root)
+	if e != nil {
+		return iko.SegmentRoot{}, e
+	}
+	return iko.SegmentRoot{Segment: r.Segment, Size: r.Size, Root: root}, nil
+}
+
+func rpcGetSegmentRoot(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 1); e != nil {
+		return nil, e
+	}
+	segment, e := strconv.ParseUint(params[0], 10, 64)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	root, e := g.GetSegmentRoot(segment)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	return SegmentRootReply{
+		Segment: root.Segment,
+		Size:    root.Size,
+		Root:    root.Root.Hex(),
+	}, nil
+}
+
+type TxProofReply struct {
+	TxHash  string   `json:"tx_hash"`
+	Seq     uint64   `json:"seq"`
+	Segment uint64   `json:"segment"`
+	Size    uint64   `json:"size"`
+	Path    []string `json:"path"` // Hex encoded sibling hashes, from the leaf upwards.
+	Root    string   `json:"root"`
+}
+
+// TxProof converts the reply to the proof it represents.
+func (r *TxProofReply) TxProof() (*iko.TxProof, error) {
+	txHash, e := cipher.SHA256FromHex(r.TxHash)
+	if e != nil {
+		return nil, e
+	}
+	root, e := cipher.SHA256FromHex(r.Root)
+	if e != nil {
+		return nil, e
+	}
+	proof := &iko.TxProof{
+		TxHash:  iko.TxHash(txHash),
+		Seq:     r.Seq,
+		Segment: r.Segment,
+		Size:    r.Size,
+		Path:    make([]cipher.SHA256, len(r.Path)),
+		Root:    root,
+	}
+	for i, hash := range r.Path {
+		if proof.Path[i], e = cipher.SHA256FromHex(hash); e != nil {
+			return nil, e
+		}
+	}
+	return proof, nil
+}
+
+func rpcGetTxProof(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 1); e != nil {
+		return nil, e
+	}
+	txHash, e := cipher.SHA256FromHex(params[0])
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	proof, e := g.GetTxProof(iko.TxHash(txHash))
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	reply := TxProofReply{
+		TxHash:  proof.TxHash.Hex(),
+		Seq:     proof.Seq,
+		Segment: proof.Segment,
+		Size:    proof.Size,
+		Path:    make([]string, len(proof.Path)),
+		Root:    proof.Root.Hex(),
+	}
+	for i, hash := range proof.Path {
+		reply.Path[i] = hash.Hex()
+	}
+	return reply, nil
+}
+
+func rpcGetHeadTx(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 0); e != nil {
+		return nil, e
+	}
+	txWrap, e := g.GetHeadTx()
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	return NewTxReplyOfTransaction(txWrap), nil
+}
+
+func rpcGetTxOfHash(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 1); e != nil {
+		return nil, e
+	}
+	txHash, e := cipher.SHA256FromHex(params[0])
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	txWrap, e := g.GetTxOfHash(iko.TxHash(txHash))
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	return NewTxReplyOfTransaction(txWrap), nil
+}
+
+func rpcGetTxOfSeq(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 1); e != nil {
+		return nil, e
+	}
+	seq, e := strconv.ParseUint(params[0], 10, 64)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	txWrap, e := g.GetTxOfSeq(seq)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	return NewTxReplyOfTransaction(txWrap), nil
+}
+
+// rpcGetPaginatedTxs expects params of [current_page, per_page].
+func rpcGetPaginatedTxs(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 2); e != nil {
+		return nil, e
+	}
+	currentPage, e := strconv.ParseUint(params[0], 10, 64)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	perPage, e := strconv.ParseUint(params[1], 10, 64)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	paginated, e := g.GetTransactionPage(currentPage, perPage, nil)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	reply := PaginatedTxsReply{
+		TotalPageCount: paginated.TotalPageCount,
+		CurrentPage:    paginated.CurrentPage,
+		PerPage:        paginated.PerPage,
+		HasNext:        paginated.HasNext,
+		HasPrev:        paginated.HasPrev,
+	}
+	for _, transaction := range paginated.Transactions {
+		reply.TxReplies = append(reply.TxReplies, NewTxReplyOfTransaction(transaction))
+	}
+	return reply, nil
+}
+
+func rpcGetKitty(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 1); e != nil {
+		return nil, e
+	}
+	kittyID, e := iko.KittyIDFromString(params[0])
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	kState, ok := g.GetKittyState(kittyID)
+	if !ok {
+		return nil, &RPCError{Code: RPCErrServer,
+			Message: fmt.Sprintf("kitty of id '%d' not found", kittyID)}
+	}
+	return NewKittyReply(kittyID, kState), nil
+}
+
+func rpcGetAddress(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 1); e != nil {
+		return nil, e
+	}
+	address, e := cipher.DecodeBase58Address(params[0])
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	aState := g.GetAddressState(address)
+	return AddressReply{
+		Address:      address.String(),
+		Kitties:      aState.Kitties,
+		Transactions: aState.Transactions.ToStringArray(),
+		FeesPaid:     aState.FeesPaid,
+		Royalties:    aState.Royalties,
+	}, nil
+}
+
+// rpcGetBalance expects the addresses as params.
+func rpcGetBalance(g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	addrs, e := toAddressArray(params)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	reply := BalanceReply{
+		Kitties: iko.KittyIDs{},
+	}
+	for _, addr := range addrs {
+		aState := g.GetAddressState(addr)
+		reply.KittyCount += len(aState.Kitties)
+		reply.Kitties = append(reply.Kitties, aState.Kitties...)
+	}
+	reply.Kitties.Sort()
+	return reply, nil
+}
+
+type InjectTxReply struct {
+	TxID string `json:"txid"`
+	Seq  uint64 `json:"seq"`
+	TS   int64  `json:"ts"`
+}
+
+// rpcInjectTx expects the hex encoded raw transaction as the only param.
+func rpcInjectTx(r *http.Request, g *iko.BlockChain, params []string) (interface{}, *RPCError) {
+	if e := rpcCheckParamCount(params, 1); e != nil {
+		return nil, e
+	}
+	raw, e := hex.DecodeString(params[0])
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	tx, e := iko.DeserializeTx(raw)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrInvalidParams, Message: e.Error()}
+	}
+	meta, e := g.InjectTx(tx)
+	if e != nil {
+		return nil, &RPCError{Code: RPCErrServer, Message: e.Error()}
+	}
+	return InjectTxReply{
+		TxID: tx.Hash().Hex(),
+		Seq:  meta.Seq,
+		TS:   meta.TS,
+	}, nil
+}
+
+/*
+	<<< HELPER FUNCTIONS >>>
+*/
+
+func rpcErrorResponse(id json.RawMessage, code int, msg string) RPCResponse {
+	return RPCResponse{
+		ID:      id,
+		JSONRPC: jsonRPCVersion,
+		Error:   &RPCError{Code: code, Message: msg},
+	}
+}
+
+func rpcCheckParamCount(params []string, n int) *RPCError {
+	if len(params) != n {
+		return &RPCError{Code: RPCErrInvalidParams,
+			Message: fmt.Sprintf("expected %d params, got %d", n, len(params))}
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+// rpcResponse is decoded with a raw result, unlike RPCResponse.
+type rpcResponse struct {
+	ID      json.RawMessage `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+func doRPC(t *testing.T, mux *http.ServeMux, body string) rpcResponse {
+	return doRPCFrom(t, mux, "", body)
+}
+
+// doRPCFrom is as doRPC, of a request of the remote address (or the default
+// remote address of httptest if empty).
+func doRPCFrom(t *testing.T, mux *http.ServeMux, remoteAddr, body string) rpcResponse {
+	req := httptest.NewRequest("POST", "/webrpc", strings.NewReader(body))
+	req.Header.Set(ContTypeKey, "application/json")
+	if remoteAddr != "" {
+		req.RemoteAddr = remoteAddr
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp rpcResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "2.0", resp.JSONRPC)
+	return resp
+}
+
+func callRPC(t *testing.T, mux *http.ServeMux, method string, params []string, result interface{}) *RPCError {
+	return callRPCFrom(t, mux, "", method, params, result)
+}
+
+// callRPCFrom is as callRPC, of a request of the remote address.
+func callRPCFrom(t *testing.T, mux *http.ServeMux, remoteAddr, method string, params []string, result interface{}) *RPCError {
+	body, err := json.Marshal(RPCRequest{
+		ID:      json.RawMessage(`"1"`),
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+	require.NoError(t, err)
+
+	resp := doRPCFrom(t, mux, remoteAddr, string(body))
+	require.Equal(t, `"1"`, string(resp.ID))
+	if resp.Error == nil {
+		require.NoError(t, json.Unmarshal(resp.Result, result))
+	}
+	return resp.Error
+}
+
+func TestIKOGateway_WebRPC(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	sub, unsubscribe := bc.SubscribeTxs(3)
+	defer unsubscribe()
+
+	var txs []*iko.Transaction
+	for i := 0; i < 3; i++ {
+		tx := iko.NewGenTx(iko.KittyID(i), testGenSK)
+		var reply InjectTxReply
+		rpcErr := callRPC(t, mux, "inject_transaction",
+			[]string{hex.EncodeToString(tx.Serialize())}, &reply)
+		require.NotNil(t, rpcErr, "inject_transaction should be forbidden to remote addresses")
+		require.Equal(t, RPCErrUnauthorized, rpcErr.Code)
+
+		require.Nil(t, callRPCFrom(t, mux, "127.0.0.1:1234", "inject_transaction",
+			[]string{hex.EncodeToString(tx.Serialize())}, &reply))
+		require.Equal(t, tx.Hash().Hex(), reply.TxID)
+		require.Equal(t, uint64(i), reply.Seq)
+		txs = append(txs, tx)
+	}
+	// Wait for the state to be updated.
+	for range txs {
+		<-sub
+	}
+
+	t.Run("Status", func(t *testing.T) {
+		var reply StatusReply
+		require.Nil(t, callRPC(t, mux, "get_status", nil, &reply))
+		require.False(t, reply.Empty)
+		require.Equal(t, uint64(2), reply.HeadSeq)
+		require.Equal(t, txs[2].Hash().Hex(), reply.HeadHash)
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		var reply TxReply
+		require.Nil(t, callRPC(t, mux, "get_transaction", []string{txs[1].Hash().Hex()}, &reply))
+		require.Equal(t, uint64(1), reply.Meta.Seq)
+
+		require.Nil(t, callRPC(t, mux, "get_transaction_by_seq", []string{"2"}, &reply))
+		require.Equal(t, txs[2].Hash().Hex(), reply.Meta.Hash)
+
+		require.Nil(t, callRPC(t, mux, "get_head_transaction", nil, &reply))
+		require.Equal(t, uint64(2), reply.Meta.Seq)
+
+		rpcErr := callRPC(t, mux, "get_transaction_by_seq", []string{"10"}, &reply)
+		require.NotNil(t, rpcErr)
+		require.Equal(t, RPCErrServer, rpcErr.Code)
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		var reply PaginatedTxsReply
+		require.Nil(t, callRPC(t, mux, "get_transactions", []string{"0", "2"}, &reply))
+		require.Equal(t, uint64(2), reply.TotalPageCount)
+		require.Len(t, reply.TxReplies, 2)
+	})
+
+	t.Run("KittyAndAddress", func(t *testing.T) {
+		var kReply KittyReply
+		require.Nil(t, callRPC(t, mux, "get_kitty", []string{"1"}, &kReply))
+		require.Equal(t, bc.CreatorAddress().String(), kReply.Address)
+
+		var aReply AddressReply
+		require.Nil(t, callRPC(t, mux, "get_address", []string{bc.CreatorAddress().String()}, &aReply))
+		require.Equal(t, iko.KittyIDs{0, 1, 2}, aReply.Kitties)
+
+		var bReply BalanceReply
+		require.Nil(t, callRPC(t, mux, "get_balance", []string{bc.CreatorAddress().String()}, &bReply))
+		require.Equal(t, 3, bReply.KittyCount)
+		require.Equal(t, iko.KittyIDs{0, 1, 2}, bReply.Kitties)
+	})
+
+	t.Run("VerifyChain", func(t *testing.T) {
+		var reply VerifyChainReply
+		rpcErr := callRPC(t, mux, "verify_chain", nil, &reply)
+		require.NotNil(t, rpcErr, "verify_chain should be forbidden to remote addresses")
+		require.Equal(t, RPCErrUnauthorized, rpcErr.Code)
+
+		req := httptest.NewRequest("POST", "/webrpc",
+			strings.NewReader(`{"jsonrpc":"2.0","id":"1","method":"verify_chain"}`))
+		req.RemoteAddr = "127.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		var resp rpcResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Nil(t, resp.Error, "verify_chain should be served to loopback addresses")
+		require.NoError(t, json.Unmarshal(resp.Result, &reply))
+		require.False(t, reply.Diverged)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		var reply TxReply
+		rpcErr := callRPC(t, mux, "get_transaction", []string{"not hex"}, &reply)
+		require.NotNil(t, rpcErr)
+		require.Equal(t, RPCErrInvalidParams, rpcErr.Code)
+
+		rpcErr = callRPC(t, mux, "get_transaction", nil, &reply)
+		require.NotNil(t, rpcErr)
+		require.Equal(t, RPCErrInvalidParams, rpcErr.Code)
+
+		rpcErr = callRPC(t, mux, "no_such_method", nil, &reply)
+		require.NotNil(t, rpcErr)
+		require.Equal(t, RPCErrMethodNotFound, rpcErr.Code)
+
+		resp := doRPC(t, mux, `{"jsonrpc":"1.0","id":7,"method":"get_status"}`)
+		require.Equal(t, "7", string(resp.ID))
+		require.Equal(t, RPCErrInvalidRequest, resp.Error.Code)
+
+		resp = doRPC(t, mux, `{"jsonrpc":`)
+		require.Equal(t, "null", string(resp.ID))
+		require.Equal(t, RPCErrParse, resp.Error.Code)
+	})
+}
+
+func TestIKOGateway_WebRPC_Auth(t *testing.T) {
+	_, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	auth, err := NewAuthenticator(&AuthConfig{
+		APIKeys:    []string{"operator"},
+		HMACSecret: testAuthSecret,
+	})
+	require.NoError(t, err)
+	h := auth.Middleware(mux)
+
+	inject := func(kittyID iko.KittyID, setAuth func(req *http.Request, body []byte)) *httptest.ResponseRecorder {
+		body, err := json.Marshal(RPCRequest{
+			ID:      json.RawMessage(`"1"`),
+			JSONRPC: jsonRPCVersion,
+			Method:  "inject_transaction",
+			Params:  []string{hex.EncodeToString(iko.NewGenTx(kittyID, testGenSK).Serialize())},
+		})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/webrpc", strings.NewReader(string(body)))
+		req.Header.Set(ContTypeKey, "application/json")
+		req.RemoteAddr = "192.0.2.1:1234"
+		setAuth(req, body)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) rpcResponse {
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp rpcResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := decode(inject(0, func(*http.Request, []byte) {}))
+	require.NotNil(t, resp.Error, "unauthorised remote requests should not inject")
+	require.Equal(t, RPCErrUnauthorized, resp.Error.Code)
+
+	resp = decode(inject(0, func(req *http.Request, _ []byte) {
+		req.Header.Set(APIKeyHeader, "operator")
+	}))
+	require.Nil(t, resp.Error, "requests of an API key should inject")
+
+	resp = decode(inject(1, func(req *http.Request, body []byte) {
+		SignRequest(testAuthSecret, req, body)
+	}))
+	require.Nil(t, resp.Error, "HMAC-signed requests should inject")
+
+	rec := inject(2, func(req *http.Request, _ []byte) {
+		req.Header.Set(APIKeyHeader, "wrong")
+	})
+	require.Equal(t, http.StatusUnauthorized, rec.Code,
+		"requests of invalid credentials should be rejected")
+}
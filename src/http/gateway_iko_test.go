@@ -0,0 +1,448 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var (
+	testGenPK, testGenSK = cipher.GenerateDeterministicKeyPair([]byte("http gateway test"))
+)
+
+func newTestIKOGateway(t *testing.T) (*iko.BlockChain, *http.ServeMux, func()) {
+	dir, err := ioutil.TempDir("", "kc_http_test")
+	require.NoError(t, err, "creation of temp dir should succeed")
+
+	chainDB, err := iko.NewBoltChain(&iko.BoltChainConfig{
+		Path: filepath.Join(dir, "chain.db"),
+	})
+	require.NoError(t, err, "creation of chain db should succeed")
+
+	bc, err := iko.NewBlockChain(context.Background(), &iko.BlockChainConfig{
+		GenerationPK: testGenPK,
+	}, chainDB, iko.NewMemoryState())
+	require.NoError(t, err, "creation of blockchain should succeed")
+
+	mux := http.NewServeMux()
+	require.NoError(t, ikoGateway(mux, bc))
+
+	return bc, mux, func() {
+		bc.Close()
+		chainDB.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func doRequest(mux *http.ServeMux, method, url, contType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, bytes.NewReader(body))
+	if contType != "" {
+		req.Header.Set(ContTypeKey, contType)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIKOGateway(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	var txs []*iko.Transaction
+	for i := 0; i < 3; i++ {
+		tx := iko.NewGenTx(iko.KittyID(i), testGenSK)
+		body, _ := json.Marshal(InjectTxRequest{Hex: hex.EncodeToString(tx.Serialize())})
+
+		rec := doRequest(mux, "POST", "/api/iko/inject_tx", "application/json", body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		txs = append(txs, tx)
+	}
+	head, err := bc.GetHeadTx()
+	require.NoError(t, err, "should obtain head tx")
+	require.Equal(t, uint64(2), head.Meta.Seq, "all txs should be injected")
+
+	t.Run("TxOfHash", func(t *testing.T) {
+		for _, url := range []string{
+			"/api/iko/tx/" + txs[1].Hash().Hex(),
+			"/api/iko/tx/hash/" + txs[1].Hash().Hex(),
+		} {
+			rec := doRequest(mux, "GET", url, "", nil)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var reply TxReply
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+			require.Equal(t, uint64(1), reply.Meta.Seq)
+		}
+	})
+
+	t.Run("TxOfSeq", func(t *testing.T) {
+		for _, url := range []string{
+			"/api/iko/tx/2?request=seq",
+			"/api/iko/tx/seq/2",
+		} {
+			rec := doRequest(mux, "GET", url, "", nil)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var reply TxReply
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+			require.Equal(t, txs[2].Hash().Hex(), reply.Meta.Hash)
+		}
+
+		rec := doRequest(mux, "GET", "/api/iko/tx/seq/10", "", nil)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Head", func(t *testing.T) {
+		rec := doRequest(mux, "GET", "/api/iko/head_tx", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var reply TxReply
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+		require.Equal(t, uint64(2), reply.Meta.Seq)
+	})
+
+	t.Run("Kitty", func(t *testing.T) {
+		rec := doRequest(mux, "GET", "/api/iko/kitty/1", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var reply KittyReply
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+		require.Equal(t, bc.CreatorAddress().String(), reply.Address)
+
+		rec = doRequest(mux, "GET", "/api/iko/kitty/10", "", nil)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Address", func(t *testing.T) {
+		rec := doRequest(mux, "GET", "/api/iko/address/"+bc.CreatorAddress().String(), "", nil)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var reply AddressReply
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+		require.Equal(t, iko.KittyIDs{0, 1, 2}, reply.Kitties)
+	})
+
+	t.Run("Txs", func(t *testing.T) {
+		for _, query := range []string{"current_page=1", "page=1"} {
+			rec := doRequest(mux, "GET", fmt.Sprintf("/api/iko/txs?%s&per_page=2", query), "", nil)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var reply PaginatedTxsReply
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+			require.Equal(t, uint64(2), reply.TotalPageCount)
+			require.Len(t, reply.TxReplies, 1)
+			require.True(t, reply.HasPrev)
+		}
+	})
+
+	t.Run("DecodeEncodeTx", func(t *testing.T) {
+		body, _ := json.Marshal(InjectTxRequest{Hex: hex.EncodeToString(txs[1].Serialize())})
+		rec := doRequest(mux, "POST", "/api/iko/tx/decode", "application/json", body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var decoded RawTxReply
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+		require.Equal(t, txs[1].Hash().Hex(), decoded.Hash)
+		require.Equal(t, bc.CreatorAddress().String(), decoded.Tx.Out)
+
+		body, _ = json.Marshal(decoded.Tx)
+		rec = doRequest(mux, "POST", "/api/iko/tx/encode", "application/json", body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var encoded RawTxReply
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &encoded))
+		require.Equal(t, decoded, encoded, "encoding decoded tx should round-trip")
+
+		memoTx := iko.NewUnsignedTransferTx(txs[1], bc.CreatorAddress())
+		memoTx.Memo = []byte("order:1")
+		body, _ = json.Marshal(InjectTxRequest{Hex: hex.EncodeToString(memoTx.Serialize())})
+		rec = doRequest(mux, "POST", "/api/iko/tx/decode", "application/json", body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+		require.Equal(t, hex.EncodeToString(memoTx.Memo), decoded.Tx.Memo,
+			"memo should be returned")
+
+		rec = doRequest(mux, "POST", "/api/iko/tx/decode", "application/json",
+			[]byte(`{"hex":"00ff"}`))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		decoded.Tx.Out = "invalid"
+		body, _ = json.Marshal(decoded.Tx)
+		rec = doRequest(mux, "POST", "/api/iko/tx/encode", "application/json", body)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("InjectTx_Invalid", func(t *testing.T) {
+		tx := iko.NewGenTx(iko.KittyID(0), testGenSK)
+		rec := doRequest(mux, "POST", "/api/iko/inject_tx", "application/octet-stream", tx.Serialize())
+		require.Equal(t, http.StatusBadRequest, rec.Code,
+			"injecting duplicate kitty should fail")
+	})
+
+	t.Run("InjectTx_NotOwner", func(t *testing.T) {
+		_, sk := cipher.GenerateKeyPair()
+		tx := iko.NewUnsignedTransferTx(txs[1], bc.CreatorAddress())
+		tx.Sig = tx.Sign(sk)
+		rec := doRequest(mux, "POST", "/api/iko/inject_tx", "application/octet-stream", tx.Serialize())
+		require.Equal(t, http.StatusForbidden, rec.Code,
+			"transfer not signed by the owner should be forbidden")
+	})
+
+	t.Run("VerifyTx", func(t *testing.T) {
+		verify := func(tx *iko.Transaction) VerifyTxReply {
+			rec := doRequest(mux, "POST", "/api/iko/tx/verify", "application/octet-stream", tx.Serialize())
+			require.Equal(t, http.StatusOK, rec.Code)
+			var reply VerifyTxReply
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+			require.Equal(t, tx.Hash().Hex(), reply.Hash)
+			return reply
+		}
+
+		reply := verify(iko.NewGenTx(iko.KittyID(100), testGenSK))
+		require.True(t, reply.Valid)
+		require.Empty(t, reply.Error)
+		_, ok := bc.GetKittyState(100)
+		require.False(t, ok, "verified txs should not be injected")
+
+		_, sk := cipher.GenerateKeyPair()
+		tx := iko.NewUnsignedTransferTx(txs[1], bc.CreatorAddress())
+		tx.Sig = tx.Sign(sk)
+		reply = verify(tx)
+		require.False(t, reply.Valid)
+		require.Contains(t, reply.Error, iko.ErrNotOwner.Error(),
+			"the reason of rejection should be replied")
+
+		rec := doRequest(mux, "POST", "/api/iko/tx/verify", "application/octet-stream", []byte("invalid"))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestIKOGateway_StreamTxs(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	for i := 0; i < 3; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequest("GET", srv.URL+"/api/iko/txs/stream?since=0", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	dec := json.NewDecoder(resp.Body)
+	for seq := uint64(1); seq < 3; seq++ {
+		var reply TxReply
+		require.NoError(t, dec.Decode(&reply))
+		require.Equal(t, seq, reply.Meta.Seq)
+	}
+
+	// Txs accepted after catching up are streamed.
+	_, err = bc.InjectTx(iko.NewGenTx(3, testGenSK))
+	require.NoError(t, err)
+	var reply TxReply
+	require.NoError(t, dec.Decode(&reply))
+	require.Equal(t, uint64(3), reply.Meta.Seq)
+
+	rec := doRequest(mux, "GET", "/api/iko/txs/stream?since=abc", "", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIKOGateway_AddressTxs(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	for i := 0; i < 3; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+	creator := cipher.AddressFromSecKey(testGenSK).String()
+
+	rec := doRequest(mux, "GET", "/api/iko/address_txs/"+creator+"?page=1&per_page=2", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reply PaginatedTxsReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Equal(t, uint64(2), reply.TotalPageCount)
+	require.True(t, reply.HasPrev)
+	require.Len(t, reply.TxReplies, 1)
+	require.Equal(t, uint64(2), reply.TxReplies[0].Meta.Seq)
+
+	rec = doRequest(mux, "GET", "/api/iko/address_txs/"+creator+"?page=0&per_page=0", "", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = doRequest(mux, "GET", "/api/iko/address_txs/bad?page=0&per_page=2", "", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIKOGateway_KittyHistory(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	gen := iko.NewGenTx(0, testGenSK)
+	_, err := bc.InjectTx(gen)
+	require.NoError(t, err)
+	pk, _ := cipher.GenerateKeyPair()
+	transfer, err := iko.NewTransferTx(gen, cipher.AddressFromPubKey(pk), testGenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err)
+
+	rec := doRequest(mux, "GET", "/api/iko/kitty_history/0", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reply KittyHistoryReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Len(t, reply.Transactions, 2)
+	require.Equal(t, gen.Hash().Hex(), reply.Transactions[0].Meta.Hash)
+	require.Equal(t, transfer.Hash().Hex(), reply.Transactions[1].Meta.Hash)
+
+	rec = doRequest(mux, "GET", "/api/iko/kitty_history/1", "", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	rec = doRequest(mux, "GET", "/api/iko/kitty_history/abc", "", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIKOGateway_FilteredTxs(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	for i := 0; i < 4; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+
+	rec := doRequest(mux, "GET", "/api/iko/txs?page=0&per_page=10&kitty_id=2", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reply PaginatedTxsReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Len(t, reply.TxReplies, 1)
+	require.Equal(t, uint64(2), reply.TxReplies[0].Meta.Seq)
+
+	rec = doRequest(mux, "GET", "/api/iko/txs?page=0&per_page=10&seq_start=1&seq_end=3", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	reply = PaginatedTxsReply{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Len(t, reply.TxReplies, 2)
+	require.Equal(t, uint64(1), reply.TxReplies[0].Meta.Seq)
+
+	rec = doRequest(mux, "GET", "/api/iko/txs?page=0&per_page=3&order=desc", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	reply = PaginatedTxsReply{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Len(t, reply.TxReplies, 3)
+	require.Equal(t, uint64(3), reply.TxReplies[0].Meta.Seq, "newest tx should be first")
+
+	for _, query := range []string{"address=bad", "kitty_id=abc", "seq_start=-1", "ts_end=abc", "order=up"} {
+		rec = doRequest(mux, "GET", "/api/iko/txs?page=0&per_page=10&"+query, "", nil)
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestIKOGateway_CursorTxs(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	for i := 0; i < 3; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+
+	get := func(url string) CursorTxsReply {
+		rec := doRequest(mux, "GET", url, "", nil)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var reply CursorTxsReply
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+		return reply
+	}
+
+	reply := get("/api/iko/txs/after?limit=2")
+	require.Len(t, reply.TxReplies, 2)
+	reply = get("/api/iko/txs/after?limit=2&cursor=" + reply.NextCursor)
+	require.Len(t, reply.TxReplies, 1)
+	require.Equal(t, uint64(2), reply.TxReplies[0].Meta.Seq)
+	reply = get("/api/iko/txs/after?limit=2&cursor=" + reply.NextCursor)
+	require.Empty(t, reply.TxReplies)
+	require.Empty(t, reply.NextCursor)
+
+	reply = get("/api/iko/txs/before?limit=2")
+	require.Len(t, reply.TxReplies, 2)
+	require.Equal(t, uint64(2), reply.TxReplies[0].Meta.Seq, "newest tx should be first")
+	reply = get("/api/iko/txs/before?limit=2&cursor=" + reply.NextCursor)
+	require.Len(t, reply.TxReplies, 1)
+	require.Equal(t, uint64(0), reply.TxReplies[0].Meta.Seq)
+
+	rec := doRequest(mux, "GET", "/api/iko/txs/after?limit=2&cursor=bad", "", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = doRequest(mux, "GET", "/api/iko/txs/after?limit=2&cursor="+iko.TxHash(cipher.SumSHA256([]byte("x"))).Hex(), "", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestIKOGateway_Kitties(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	for i := 0; i < 3; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+
+	rec := doRequest(mux, "GET", "/api/iko/kitties?ids=2,5,0", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reply KittiesReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Len(t, reply.Kitties, 2)
+	require.Equal(t, iko.KittyID(2), reply.Kitties[0].KittyID)
+	require.Equal(t, iko.KittyID(0), reply.Kitties[1].KittyID)
+	require.Equal(t, iko.KittyIDs{5}, reply.Missing)
+
+	rec = doRequest(mux, "GET", "/api/iko/kitties?ids=1,abc", "", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIKOGateway_Addresses(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	for i := 0; i < 2; i++ {
+		_, err := bc.InjectTx(iko.NewGenTx(iko.KittyID(i), testGenSK))
+		require.NoError(t, err)
+	}
+	var (
+		creator = cipher.AddressFromSecKey(testGenSK)
+		pk, _   = cipher.GenerateKeyPair()
+		other   = cipher.AddressFromPubKey(pk)
+	)
+
+	rec := doRequest(mux, "GET", "/api/iko/addresses?addrs="+other.String()+","+creator.String(), "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reply []AddressReply
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	require.Len(t, reply, 2)
+	require.Equal(t, other.String(), reply[0].Address)
+	require.Empty(t, reply[0].Kitties)
+	require.Equal(t, creator.String(), reply[1].Address)
+	require.Equal(t, iko.KittyIDs{0, 1}, reply[1].Kitties)
+
+	rec = doRequest(mux, "GET", "/api/iko/addresses?addrs=bad", "", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
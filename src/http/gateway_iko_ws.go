@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+const (
+	wsSubBufSize    = 128
+	wsWriteTimeout  = time.Second * 10
+	wsPingInterval  = time.Second * 30
+	wsReadSizeLimit = 512
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     wsCheckOrigin,
+}
+
+// wsCheckOrigin accepts websockets of the same origin, or of origins allowed
+// by the CORS config of the server.
+func wsCheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || isCORSAllowed(r) {
+		return true
+	}
+	u, e := url.Parse(origin)
+	return e == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// parseTxFilter parses the 'addrs' and 'kitty_ids' queries of the request,
+// which are both comma separated lists, and the optional 'bloom' query of a
+// hex encoded serialized 'iko.BloomFilter'.
+func parseTxFilter(r *http.Request) (*iko.TxFilter, error) {
+	var (
+		qAddrs    = r.URL.Query().Get("addrs")
+		qKittyIDs = r.URL.Query().Get("kitty_ids")
+		qBloom    = r.URL.Query().Get("bloom")
+	)
+	addrs, e := toAddressArray(splitStr(qAddrs))
+	if e != nil {
+		return nil, e
+	}
+	var kittyIDs []iko.KittyID
+	for _, idStr := range splitStr(qKittyIDs) {
+		kittyID, e := iko.KittyIDFromString(idStr)
+		if e != nil {
+			return nil, e
+		}
+		kittyIDs = append(kittyIDs, kittyID)
+	}
+	filter := iko.NewTxFilter(addrs, kittyIDs)
+	if qBloom != "" {
+		raw, e := hex.DecodeString(qBloom)
+		if e != nil {
+			return nil, e
+		}
+		if filter.Bloom, e = iko.DeserializeBloomFilter(raw); e != nil {
+			return nil, e
+		}
+	}
+	return filter, nil
+}
+
+// subscribeTxs streams accepted transactions to a websocket connection as JSON
+// encoded 'TxReply's.
+func subscribeTxs(g *iko.BlockChain) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		filter, e := parseTxFilter(r)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		// Subscribe before upgrading, so that no txs are missed once the
+		// client is connected.
+		txs, unsubscribe := g.SubscribeTxs(wsSubBufSize)
+		defer unsubscribe()
+
+		conn, e := wsUpgrader.Upgrade(w, r, nil)
+		if e != nil {
+			// Upgrade already replied with an error.
+			return e
+		}
+		defer conn.Close()
+
+		// Read messages to process control frames and detect closure.
+		closed := make(chan struct{})
+		conn.SetReadLimit(wsReadSizeLimit)
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, e := conn.NextReader(); e != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(wsPingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return nil
+
+			case txWrap, ok := <-txs:
+				if !ok {
+					return nil
+				}
+				if !filter.Match(g, &txWrap.Tx) {
+					continue
+				}
+				conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				if e := conn.WriteJSON(NewTxReplyOfTransaction(txWrap)); e != nil {
+					return e
+				}
+
+			case <-ping.C:
+				deadline := time.Now().Add(wsWriteTimeout)
+				if e := conn.WriteControl(websocket.PingMessage, nil, deadline); e != nil {
+					return e
+				}
+			}
+		}
+	}
+}
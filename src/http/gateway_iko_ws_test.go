@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+func dialSubscribeTxs(t *testing.T, srv *httptest.Server, query string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/iko/subscribe_txs?" + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err, "websocket dial should succeed")
+	return conn
+}
+
+func readTxReply(t *testing.T, conn *websocket.Conn) TxReply {
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	var reply TxReply
+	require.NoError(t, conn.ReadJSON(&reply), "should receive tx")
+	return reply
+}
+
+func TestIKOGateway_SubscribeTxs(t *testing.T) {
+	bc, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var (
+		all     = dialSubscribeTxs(t, srv, "")
+		byKitty = dialSubscribeTxs(t, srv, "kitty_ids=1")
+		toPK, _ = cipher.GenerateDeterministicKeyPair([]byte("ws to"))
+		toAddr  = cipher.AddressFromPubKey(toPK)
+		byAddr  = dialSubscribeTxs(t, srv, "addrs="+toAddr.String())
+		bloom   = iko.NewBloomFilter(10, 0.0001, 0)
+	)
+	bloom.Add(toAddr)
+	byBloom := dialSubscribeTxs(t, srv, "bloom="+hex.EncodeToString(bloom.Serialize()))
+	defer all.Close()
+	defer byKitty.Close()
+	defer byAddr.Close()
+	defer byBloom.Close()
+
+	inject := func(tx *iko.Transaction) {
+		_, err := bc.InjectTx(tx)
+		require.NoError(t, err, "injection should succeed")
+		require.Equal(t, tx.Hash().Hex(), readTxReply(t, all).Meta.Hash,
+			"unfiltered subscriber should receive every tx")
+	}
+
+	gen0 := iko.NewGenTx(iko.KittyID(0), testGenSK)
+	gen1 := iko.NewGenTx(iko.KittyID(1), testGenSK)
+	inject(gen0)
+	inject(gen1)
+
+	transfer, err := iko.NewTransferTx(gen0, toAddr, testGenSK)
+	require.NoError(t, err)
+	inject(transfer)
+
+	require.Equal(t, gen1.Hash().Hex(), readTxReply(t, byKitty).Meta.Hash,
+		"kitty filter should only match tx of kitty")
+	require.Equal(t, transfer.Hash().Hex(), readTxReply(t, byAddr).Meta.Hash,
+		"address filter should only match tx of address")
+	require.Equal(t, transfer.Hash().Hex(), readTxReply(t, byBloom).Meta.Hash,
+		"bloom filter should only match tx of address")
+}
+
+func TestIKOGateway_SubscribeTxs_InvalidFilter(t *testing.T) {
+	_, mux, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	rec := doRequest(mux, "GET", "/api/iko/subscribe_txs?addrs=invalid", "", nil)
+	require.Equal(t, 400, rec.Code, "invalid filter should be rejected")
+
+	empty := iko.BloomFilter{Hashes: 1}
+	rec = doRequest(mux, "GET", "/api/iko/subscribe_txs?bloom="+hex.EncodeToString(empty.Serialize()), "", nil)
+	require.Equal(t, 400, rec.Code, "invalid bloom filter should be rejected")
+}
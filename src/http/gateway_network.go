@@ -0,0 +1,106 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kittycash/wallet/src/node"
+)
+
+func networkGateway(m *http.ServeMux, n *node.Node) error {
+	MultiHandle(m, []string{"/network/peers", "/api/network/peers"}, "GET", getPeers(n))
+	MultiHandle(m, []string{"/network/connect", "/api/network/connect"}, "POST", connectPeer(n))
+	MultiHandle(m, []string{"/network/submit_tx", "/api/network/submit_tx"}, "POST", relayTx(n))
+	return nil
+}
+
+type PeerReply struct {
+	Address  string `json:"address"`
+	Listen   string `json:"listen,omitempty"` // Address the peer accepts peers on.
+	Inbound  bool   `json:"inbound"`
+	ChainLen uint64 `json:"chain_length"`
+}
+
+type BanReply struct {
+	Host  string `json:"host"`
+	Until int64  `json:"until"` // Unix timestamp.
+}
+
+type PeersReply struct {
+	Count  int         `json:"count"`
+	Peers  []PeerReply `json:"peers"`
+	Known  []string    `json:"known"`
+	Banned []BanReply  `json:"banned"`
+}
+
+func getPeers(n *node.Node) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		infos := n.PeerInfos()
+		reply := PeersReply{
+			Count:  len(infos),
+			Peers:  make([]PeerReply, len(infos)),
+			Known:  n.KnownPeers(),
+			Banned: []BanReply{},
+		}
+		for i, info := range infos {
+			reply.Peers[i] = PeerReply{
+				Address:  info.Address,
+				Listen:   info.Listen,
+				Inbound:  info.Inbound,
+				ChainLen: info.Len,
+			}
+		}
+		for _, ban := range n.Bans() {
+			reply.Banned = append(reply.Banned, BanReply{
+				Host:  ban.Host,
+				Until: ban.Until.Unix(),
+			})
+		}
+		return sendJson(w, http.StatusOK, reply)
+	}
+}
+
+// connectPeer connects to the peer of the 'address' form value.
+func connectPeer(n *node.Node) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		address := r.PostFormValue("address")
+		if address == "" {
+			return sendJson(w, http.StatusBadRequest,
+				errors.New("no peer address provided").Error())
+		}
+		if e := n.Connect(address); e != nil {
+			status := http.StatusBadGateway
+			switch {
+			case errors.Is(e, node.ErrPeerBanned):
+				status = http.StatusForbidden
+			case errors.Is(e, node.ErrAlreadyConnected):
+				status = http.StatusConflict
+			case errors.Is(e, node.ErrTooManyPeers), errors.Is(e, node.ErrNodeClosed):
+				status = http.StatusServiceUnavailable
+			}
+			return sendJson(w, status, e.Error())
+		}
+		return sendJson(w, http.StatusOK, true)
+	}
+}
+
+// relayTx submits a signed tx to the network, to be relayed to the ordering
+// node. The request body is the same as that of '/api/iko/inject_tx'.
+func relayTx(n *node.Node) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+		tx, e := readTxRequest(r)
+		if e != nil {
+			return sendJson(w, http.StatusBadRequest,
+				e.Error())
+		}
+		if e := n.SubmitTx(tx); e != nil {
+			status := errStatus(e, http.StatusBadRequest)
+			if errors.Is(e, node.ErrTxSeen) || errors.Is(e, node.ErrTxInChain) {
+				status = http.StatusConflict
+			}
+			return sendJson(w, status, e.Error())
+		}
+		return sendJson(w, http.StatusOK,
+			SubmitTxReply{Hash: tx.Hash().Hex()})
+	}
+}
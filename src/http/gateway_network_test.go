@@ -0,0 +1,56 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/node"
+)
+
+func TestNetworkGateway(t *testing.T) {
+	bcA, _, closeA := newTestIKOGateway(t)
+	defer closeA()
+	bcB, _, closeB := newTestIKOGateway(t)
+	defer closeB()
+
+	nodeA, err := node.NewNode(&node.NodeConfig{Address: "127.0.0.1:0"}, bcA)
+	require.NoError(t, err)
+	defer nodeA.Close()
+	nodeB, err := node.NewNode(&node.NodeConfig{}, bcB)
+	require.NoError(t, err)
+	defer nodeB.Close()
+
+	mux := http.NewServeMux()
+	require.NoError(t, networkGateway(mux, nodeB))
+
+	connect := func(address string) int {
+		form := url.Values{"address": {address}}
+		rec := doRequest(mux, "POST", "/network/connect",
+			"application/x-www-form-urlencoded", []byte(form.Encode()))
+		return rec.Code
+	}
+	require.Equal(t, http.StatusBadRequest, connect(""))
+	require.Equal(t, http.StatusOK, connect(nodeA.Address()))
+	require.Equal(t, http.StatusConflict, connect(nodeA.Address()))
+
+	rec := doRequest(mux, "GET", "/network/peers", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reply PeersReply
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&reply))
+	require.Equal(t, 1, reply.Count)
+	require.Equal(t, nodeA.Address(), reply.Peers[0].Address)
+	require.False(t, reply.Peers[0].Inbound)
+	require.Equal(t, []string{nodeA.Address()}, reply.Known)
+	require.Empty(t, reply.Banned)
+
+	tx := iko.NewGenTx(0, testGenSK)
+	rec = doRequest(mux, "POST", "/network/submit_tx", "application/octet-stream", tx.Serialize())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = doRequest(mux, "POST", "/network/submit_tx", "application/octet-stream", tx.Serialize())
+	require.Equal(t, http.StatusConflict, rec.Code, "tx should only be relayed once")
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/util/metrics"
+)
+
+func TestGateway_Metrics(t *testing.T) {
+	bc, _, closeGateway := newTestIKOGateway(t)
+	defer closeGateway()
+
+	handler, err := (&Gateway{IKO: bc, Metrics: metrics.DefaultRegistry}).Handler()
+	require.NoError(t, err)
+
+	do := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		return rec
+	}
+	require.Equal(t, http.StatusOK, do("/api/iko/kitties?ids=1").Code)
+
+	rec := do("/metrics")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, metrics.ContentType, rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(),
+		`http_api_request_duration_seconds_count{pattern="/api/iko/kitties",method="GET"}`)
+	require.Contains(t, rec.Body.String(), "iko_chain_length ")
+
+	handler, err = (&Gateway{IKO: bc}).Handler()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, do("/metrics").Code,
+		"metrics should not be exposed without a registry")
+}
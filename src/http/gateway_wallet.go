@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/skycoin/skycoin/src/cipher"
+
 	"github.com/kittycash/wallet/src/wallet"
 )
 
@@ -15,6 +17,8 @@ func walletGateway(m *http.ServeMux, g *wallet.Manager) error {
 	Handle(m, "/api/wallets/delete", "POST", deleteWallet(g))
 	Handle(m, "/api/wallets/get", "POST", getWallet(g))
 	Handle(m, "/api/wallets/seed", "GET", newSeed())
+	Handle(m, "/api/wallets/address_book/set", "POST", setContact(g))
+	Handle(m, "/api/wallets/address_book/remove", "POST", removeContact(g))
 	return nil
 }
 
@@ -160,6 +164,67 @@ func getWallet(g *wallet.Manager) HandlerFunc {
 	}
 }
 
+func setContact(g *wallet.Manager) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+
+		// Only allow 'Content-Type' of 'application/x-www-form-urlencoded'.
+		_, e := SwitchContType(w, r, ContTypeActions{
+			CtApplicationForm: func() (bool, error) {
+				var (
+					vLabel    = r.PostFormValue("label")
+					vPassword = r.PostFormValue("password") // Optional.
+					vAddress  = r.PostFormValue("address")
+					vName     = r.PostFormValue("name")
+					vNotes    = r.PostFormValue("notes") // Optional.
+				)
+				address, e := cipher.DecodeBase58Address(vAddress)
+				if e != nil {
+					return false, sendJson(w, http.StatusBadRequest,
+						fmt.Sprintf("Error: invalid address '%s': %v", vAddress, e))
+				}
+				contact := wallet.Contact{
+					Address: address,
+					Name:    vName,
+					Notes:   vNotes,
+				}
+				if e := g.SetContact(vLabel, vPassword, contact); e != nil {
+					return false, sendJson(w, http.StatusBadRequest,
+						fmt.Sprintf("Error: %v", e))
+				}
+				return true, sendJson(w, http.StatusOK, true)
+			},
+		})
+		return e
+	}
+}
+
+func removeContact(g *wallet.Manager) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, p *Path) error {
+
+		// Only allow 'Content-Type' of 'application/x-www-form-urlencoded'.
+		_, e := SwitchContType(w, r, ContTypeActions{
+			CtApplicationForm: func() (bool, error) {
+				var (
+					vLabel    = r.PostFormValue("label")
+					vPassword = r.PostFormValue("password") // Optional.
+					vAddress  = r.PostFormValue("address")
+				)
+				address, e := cipher.DecodeBase58Address(vAddress)
+				if e != nil {
+					return false, sendJson(w, http.StatusBadRequest,
+						fmt.Sprintf("Error: invalid address '%s': %v", vAddress, e))
+				}
+				if e := g.RemoveContact(vLabel, vPassword, address); e != nil {
+					return false, sendJson(w, http.StatusBadRequest,
+						fmt.Sprintf("Error: %v", e))
+				}
+				return true, sendJson(w, http.StatusOK, true)
+			},
+		})
+		return e
+	}
+}
+
 type SeedReply struct {
 	Seed string `json:"seed"`
 }
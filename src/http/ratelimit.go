@@ -0,0 +1,171 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// APIKeyHeader is the header of the API key of a request.
+	APIKeyHeader = "X-API-Key"
+
+	// rateLimitSweep is the interval at which idle buckets are removed.
+	rateLimitSweep = time.Minute
+)
+
+// RateLimit is a token bucket limit of requests.
+type RateLimit struct {
+	Rate  float64 // Requests per second, 0 for no limit.
+	Burst int     // Requests allowed at once, defaults to the rate (at least 1).
+}
+
+func (l RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// RateLimitConfig configures the rate limiting of the API.
+type RateLimitConfig struct {
+	IP             RateLimit            // Limit per client IP, of requests without a known API key.
+	Keys           map[string]RateLimit // Limits per API key, given by the 'X-API-Key' header.
+	TrustForwarded bool                 // Whether the client IP is of the 'X-Forwarded-For' header, when behind a proxy.
+}
+
+// ParseRateLimitKey parses the limit of an API key, of the form
+// '<key>:<rate>[:<burst>]'.
+func ParseRateLimitKey(spec string) (string, RateLimit, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+		return "", RateLimit{}, fmt.Errorf("invalid rate limit '%s', expected '<key>:<rate>[:<burst>]'", spec)
+	}
+	var (
+		limit RateLimit
+		e     error
+	)
+	if limit.Rate, e = strconv.ParseFloat(parts[1], 64); e != nil || limit.Rate < 0 {
+		return "", RateLimit{}, fmt.Errorf("invalid rate of rate limit '%s'", spec)
+	}
+	if len(parts) == 3 {
+		if limit.Burst, e = strconv.Atoi(parts[2]); e != nil || limit.Burst < 0 {
+			return "", RateLimit{}, fmt.Errorf("invalid burst of rate limit '%s'", spec)
+		}
+	}
+	return parts[0], limit, nil
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter limits the requests of each client IP and API key, with a token
+// bucket per client.
+type RateLimiter struct {
+	c         *RateLimitConfig
+	mux       sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a RateLimiter of the config.
+func NewRateLimiter(c *RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		c:       c,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token of the client of the request. If none are available, it
+// returns false and the time until one is.
+func (l *RateLimiter) Allow(r *http.Request) (bool, time.Duration) {
+	id, limit := l.client(r)
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), last: now}
+		l.buckets[id] = b
+	}
+	b.tokens = math.Min(limit.burst(), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Middleware replies with 429 to requests that exceed the rate limit.
+func (l *RateLimiter) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := l.Allow(r); !ok {
+			secs := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			sendJson(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// client identifies the client of the request, and obtains it's limit.
+func (l *RateLimiter) client(r *http.Request) (string, RateLimit) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		if limit, ok := l.c.Keys[key]; ok {
+			return "key:" + key, limit
+		}
+	}
+	return "ip:" + l.clientIP(r), l.c.IP
+}
+
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.c.TrustForwarded {
+		// The last address is the one seen by the trusted proxy.
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			parts := strings.Split(fwd, ",")
+			return strings.TrimSpace(parts[len(parts)-1])
+		}
+	}
+	host, _, e := net.SplitHostPort(r.RemoteAddr)
+	if e != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// sweep removes buckets that have refilled, as they are the same as new ones.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweep {
+		return
+	}
+	l.lastSweep = now
+	for id, b := range l.buckets {
+		limit := l.c.IP
+		if strings.HasPrefix(id, "key:") {
+			limit = l.c.Keys[strings.TrimPrefix(id, "key:")]
+		}
+		if b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= limit.burst() {
+			delete(l.buckets, id)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewRateLimiter(&RateLimitConfig{
+		IP:   RateLimit{Rate: 1, Burst: 2},
+		Keys: map[string]RateLimit{"explorer": {Rate: 10, Burst: 5}},
+	})
+	l.now = func() time.Time { return now }
+
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func(remoteAddr, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/iko/head_tx", nil)
+		req.RemoteAddr = remoteAddr
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("PerIP", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do("1.1.1.1:1", "").Code)
+		require.Equal(t, http.StatusOK, do("1.1.1.1:2", "").Code)
+		rec := do("1.1.1.1:3", "")
+		require.Equal(t, http.StatusTooManyRequests, rec.Code,
+			"requests beyond the burst should be limited")
+		require.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+		require.Equal(t, http.StatusOK, do("2.2.2.2:1", "").Code,
+			"other ips should have their own limit")
+
+		now = now.Add(time.Second)
+		require.Equal(t, http.StatusOK, do("1.1.1.1:1", "").Code,
+			"tokens should refill at the rate")
+		require.Equal(t, http.StatusTooManyRequests, do("1.1.1.1:1", "").Code)
+	})
+
+	t.Run("PerKey", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			require.Equal(t, http.StatusOK, do("3.3.3.3:1", "explorer").Code)
+		}
+		require.Equal(t, http.StatusTooManyRequests, do("4.4.4.4:1", "explorer").Code,
+			"keys should be limited across ips")
+
+		require.Equal(t, http.StatusOK, do("3.3.3.3:1", "unknown").Code)
+		require.Equal(t, http.StatusOK, do("3.3.3.3:1", "other").Code)
+		require.Equal(t, http.StatusTooManyRequests, do("3.3.3.3:1", "another").Code,
+			"unknown keys should be limited by ip")
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		do("5.5.5.5:1", "")
+		require.Len(t, l.buckets, 1, "refilled buckets should be removed")
+	})
+}
+
+func TestParseRateLimitKey(t *testing.T) {
+	key, limit, err := ParseRateLimitKey("explorer:2.5")
+	require.NoError(t, err)
+	require.Equal(t, "explorer", key)
+	require.Equal(t, RateLimit{Rate: 2.5}, limit)
+
+	key, limit, err = ParseRateLimitKey("explorer:10:20")
+	require.NoError(t, err)
+	require.Equal(t, RateLimit{Rate: 10, Burst: 20}, limit)
+
+	for _, spec := range []string{"", "explorer", ":1", "explorer:x", "explorer:-1", "explorer:1:x", "a:1:2:3"} {
+		_, _, err = ParseRateLimitKey(spec)
+		require.Error(t, err, "'%s' should be invalid", spec)
+	}
+}
+
+func TestRateLimiter_TrustForwarded(t *testing.T) {
+	l := NewRateLimiter(&RateLimitConfig{
+		IP:             RateLimit{Rate: 1},
+		TrustForwarded: true,
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1"
+	req.Header.Set("X-Forwarded-For", "6.6.6.6, 7.7.7.7")
+	ok, _ := l.Allow(req)
+	require.True(t, ok)
+
+	req.Header.Set("X-Forwarded-For", "8.8.8.8, 7.7.7.7")
+	ok, wait := l.Allow(req)
+	require.False(t, ok, "the client ip should be the last forwarded address")
+	require.True(t, wait > 0)
+
+	req.Header.Set("X-Forwarded-For", "6.6.6.6")
+	ok, _ = l.Allow(req)
+	require.True(t, ok)
+}
@@ -1,11 +1,14 @@
 package http
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"path"
 	"time"
+
+	"github.com/kittycash/wallet/src/util"
 )
 
 const (
@@ -13,12 +16,16 @@ const (
 )
 
 type ServerConfig struct {
-	Address     string
-	EnableGUI   bool
-	GUIDir      string
-	EnableTLS   bool
-	TLSCertFile string
-	TLSKeyFile  string
+	Address         string
+	EnableGUI       bool
+	GUIDir          string
+	EnableTLS       bool
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string           // Optional, to require client certificates of the CAs (mutual TLS).
+	RateLimit       *RateLimitConfig // Optional, to rate limit requests.
+	Auth            *AuthConfig      // Optional, to authenticate mutating and admin routes.
+	CORS            *CORSConfig      // Optional, to allow cross-origin requests.
 }
 
 type Server struct {
@@ -26,6 +33,8 @@ type Server struct {
 	srv  *http.Server
 	mux  *http.ServeMux
 	api  *Gateway
+	auth *Authenticator
+	tls  *tls.Config
 	quit chan struct{}
 }
 
@@ -36,6 +45,20 @@ func NewServer(config *ServerConfig, api *Gateway) (*Server, error) {
 		api:  api,
 		quit: make(chan struct{}),
 	}
+	if config.EnableTLS {
+		tlsConfig, e := util.ServerTLSConfig(config.TLSCertFile, config.TLSKeyFile, config.TLSClientCAFile)
+		if e != nil {
+			return nil, e
+		}
+		server.tls = tlsConfig
+	}
+	if config.Auth != nil {
+		auth, e := NewAuthenticator(config.Auth)
+		if e != nil {
+			return nil, e
+		}
+		server.auth = auth
+	}
 	if e := server.prepareMux(); e != nil {
 		return nil, e
 	}
@@ -44,13 +67,26 @@ func NewServer(config *ServerConfig, api *Gateway) (*Server, error) {
 }
 
 func (s *Server) serve() {
+	var handler http.Handler = s.mux
+	if s.auth != nil {
+		handler = s.auth.Middleware(handler)
+	}
+	if s.c.CORS != nil {
+		// Preflight requests are replied to before authentication, as they
+		// do not include credentials.
+		handler = NewCORS(s.c.CORS).Middleware(handler)
+	}
+	if s.c.RateLimit != nil {
+		handler = NewRateLimiter(s.c.RateLimit).Middleware(handler)
+	}
 	s.srv = &http.Server{
-		Addr:    s.c.Address,
-		Handler: s.mux,
+		Addr:      s.c.Address,
+		Handler:   handler,
+		TLSConfig: s.tls,
 	}
 	if s.c.EnableTLS {
 		for {
-			if e := s.srv.ListenAndServeTLS(s.c.TLSCertFile, s.c.TLSKeyFile); e != nil {
+			if e := s.srv.ListenAndServeTLS("", ""); e != nil {
 				time.Sleep(100 * time.Millisecond)
 				continue
 			} else {
@@ -0,0 +1,161 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+var (
+	// ErrGenerationFrozen occurs when generating a kitty after generation was
+	// frozen by an admin tx.
+	ErrGenerationFrozen = errors.New("kitty generation is frozen")
+
+	// ErrCreatorRevoked occurs when a rotation tx names a creator key that was
+	// revoked by an admin tx.
+	ErrCreatorRevoked = errors.New("creator key is revoked")
+
+	// ErrAdminPending occurs when submitting an admin tx to the mempool, as
+	// they can only be injected.
+	ErrAdminPending = errors.New("admin txs cannot be submitted to the mempool")
+
+	// ErrQuorumMismatch occurs when the witness of an admin or rotation tx is
+	// not of the quorum of the current creator keys.
+	ErrQuorumMismatch = errors.New("tx is not of the creator quorum")
+)
+
+// TxAdmin is the administrative action of an admin tx, which is authorised by
+// a quorum of the creator keys (see 'BlockChain.CreatorQuorum').
+type TxAdmin struct {
+	Revoke []cipher.PubKey // Creator keys to revoke, which can never be creator keys again.
+	Freeze bool            // Whether to permanently freeze the generation of kitties.
+}
+
+func (a TxAdmin) Serialize() []byte {
+	return encoder.Serialize(a)
+}
+
+// NewAdminTx creates an unsigned admin tx, authorised by the quorum. The
+// signatures of the quorum's keys are added with 'AddMultisigSig'.
+func NewAdminTx(quorum *Multisig, admin TxAdmin) (*Transaction, error) {
+	if e := quorum.Verify(); e != nil {
+		return nil, e
+	}
+	tx := &Transaction{
+		In:      EmptyTxHash(),
+		Out:     quorum.Address(),
+		Admin:   &admin,
+		Witness: &Witness{Multisig: *quorum},
+	}
+	return tx, tx.VerifyAdmin()
+}
+
+// IsAdmin returns true if the tx is an admin tx. Admin txs have no kitties.
+func (tx Transaction) IsAdmin() bool {
+	return tx.Admin != nil
+}
+
+// VerifyAdmin checks the structure of an admin tx. The witness is not checked,
+// as the creator keys are of the state.
+func (tx Transaction) VerifyAdmin() error {
+	if e := tx.VerifyMemo(); e != nil {
+		return e
+	}
+	if tx.KittyID != 0 || tx.In != EmptyTxHash() {
+		return errors.New("admin tx should have no kitty or input")
+	}
+	if len(tx.Inputs) > 0 || tx.Fee > 0 || !tx.Lock.IsZero() || tx.Escrow != nil ||
+		tx.Metadata != nil || tx.Breed != nil || tx.Auction != nil || tx.Royalty != nil ||
+		tx.Rotation != nil || tx.Genesis != nil {
+		return errors.New("admin tx should only perform the admin action")
+	}
+	if tx.Witness == nil {
+		return errors.New("admin tx should have a witness of the creator quorum")
+	}
+	if len(tx.Admin.Revoke) == 0 && !tx.Admin.Freeze {
+		return errors.New("admin tx should revoke creator keys, or freeze generation")
+	}
+	seen := make(map[cipher.PubKey]struct{}, len(tx.Admin.Revoke))
+	for i, pk := range tx.Admin.Revoke {
+		if _, ok := seen[pk]; ok {
+			return fmt.Errorf("revoked creator key %d is duplicated", i)
+		}
+		seen[pk] = struct{}{}
+	}
+	return nil
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// CreatorQuorum returns the multisig of the current creator keys that
+// authorises admin and rotation txs, which requires signatures of a majority of the keys.
+func (bc *BlockChain) CreatorQuorum() *Multisig {
+	pks := bc.CreatorPKs()
+	return &Multisig{
+		M:       uint8(len(pks)/2 + 1),
+		PubKeys: pks,
+	}
+}
+
+// IsGenerationFrozen returns true if the generation of kitties was frozen by an
+// admin tx.
+func (bc *BlockChain) IsGenerationFrozen() bool {
+	return bc.state.IsGenerationFrozen()
+}
+
+// checkAdmin checks that an admin tx is valid, and authorised by the quorum of
+// the current creator keys.
+func (bc *BlockChain) checkAdmin(tx *Transaction) error {
+	if e := tx.VerifyAdmin(); e != nil {
+		return e
+	}
+	quorum := bc.CreatorQuorum()
+	if tx.Out != quorum.Address() {
+		return ErrQuorumMismatch
+	}
+	if e := tx.VerifyOwner(quorum.Address()); e != nil {
+		return e
+	}
+	if tx.Admin.Freeze && bc.state.IsGenerationFrozen() {
+		return errors.New("kitty generation is already frozen")
+	}
+	remaining := len(quorum.PubKeys)
+	for _, pk := range tx.Admin.Revoke {
+		if !bc.IsCreator(cipher.AddressFromPubKey(pk)) {
+			return fmt.Errorf("revoked key '%s' is not a creator key", pk.Hex())
+		}
+		remaining--
+	}
+	if remaining == 0 {
+		return errors.New("admin tx cannot revoke every creator key")
+	}
+	return nil
+}
+
+// applyAdmin performs the action of an admin tx.
+func (bc *BlockChain) applyAdmin(tx *Transaction) error {
+	if len(tx.Admin.Revoke) > 0 {
+		revoked := make(map[cipher.PubKey]struct{}, len(tx.Admin.Revoke))
+		for _, pk := range tx.Admin.Revoke {
+			revoked[pk] = struct{}{}
+		}
+		var creators []cipher.PubKey
+		for _, pk := range bc.CreatorPKs() {
+			if _, ok := revoked[pk]; !ok {
+				creators = append(creators, pk)
+			}
+		}
+		if e := bc.state.RevokeCreators(creators, tx.Admin.Revoke); e != nil {
+			return e
+		}
+		bc.invalidateCreator()
+	}
+	if tx.Admin.Freeze {
+		return bc.state.FreezeGeneration()
+	}
+	return nil
+}
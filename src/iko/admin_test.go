@@ -0,0 +1,102 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction_VerifyAdmin(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	quorum := &Multisig{M: 1, PubKeys: []cipher.PubKey{GenPK, pk}}
+
+	_, err := NewAdminTx(quorum, TxAdmin{})
+	require.Error(t, err, "admin tx should revoke or freeze")
+	_, err = NewAdminTx(quorum, TxAdmin{Revoke: []cipher.PubKey{pk, pk}})
+	require.Error(t, err, "revoked keys should be unique")
+
+	tx, err := NewAdminTx(quorum, TxAdmin{Revoke: []cipher.PubKey{pk}, Freeze: true})
+	require.NoError(t, err)
+	require.True(t, tx.IsAdmin())
+	require.Nil(t, tx.KittyIDs())
+
+	tx.Fee = 1
+	require.Error(t, tx.VerifyAdmin(), "admin tx should have no fee")
+}
+
+func TestBlockChain_Admin(t *testing.T) {
+	var (
+		pk1, sk1 = cipher.GenerateKeyPair()
+		pk2, sk2 = cipher.GenerateKeyPair()
+	)
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		CreatorPKs:   []cipher.PubKey{pk1, pk2},
+	})
+	defer closeBC()
+
+	injectGenTxs(t, bc, 1)
+
+	quorum := bc.CreatorQuorum()
+	require.Equal(t, uint8(2), quorum.M)
+
+	// Revoke the compromised key of sk2, with the signatures of GenSK and sk1.
+	revoke, err := NewAdminTx(quorum, TxAdmin{Revoke: []cipher.PubKey{pk2}})
+	require.NoError(t, err)
+	require.Equal(t, ErrAdminPending, bc.SubmitTx(revoke))
+
+	require.NoError(t, revoke.AddMultisigSig(0, NewSecKeySigner(GenSK)))
+	_, err = bc.InjectTx(revoke)
+	require.Equal(t, ErrMultisigThreshold, err, "one of three signatures should not be enough")
+
+	require.NoError(t, revoke.AddMultisigSig(1, NewSecKeySigner(sk1)))
+	_, err = bc.InjectTx(revoke)
+	require.NoError(t, err)
+	require.Equal(t, []cipher.PubKey{GenPK, pk1}, bc.CreatorPKs())
+
+	_, err = bc.InjectTx(NewGenTx(1, sk2))
+	require.Error(t, err, "revoked key should not create kitties")
+	_, err = bc.InjectTx(NewGenTx(1, sk1))
+	require.NoError(t, err, "remaining creator should create kitties")
+
+	require.Equal(t, uint64(1), bc.CreatorEpoch(), "revocation should change the creator epoch")
+	rotation, err := NewQuorumRotationTx(bc.CreatorQuorum(), []cipher.PubKey{pk1, pk2}, 1)
+	require.NoError(t, err)
+	require.NoError(t, rotation.AddMultisigSig(0, NewSecKeySigner(GenSK)))
+	require.NoError(t, rotation.AddMultisigSig(1, NewSecKeySigner(sk1)))
+	_, err = bc.InjectTx(rotation)
+	require.Equal(t, ErrCreatorRevoked, err, "revoked key should not be a creator again")
+
+	// The quorum of two keys requires both signatures.
+	quorum = bc.CreatorQuorum()
+	freeze, err := NewAdminTx(quorum, TxAdmin{Freeze: true})
+	require.NoError(t, err)
+	require.NoError(t, freeze.AddMultisigSig(1, NewSecKeySigner(sk1)))
+	_, err = bc.InjectTx(freeze)
+	require.Equal(t, ErrMultisigThreshold, err)
+
+	require.NoError(t, freeze.AddMultisigSig(0, NewSecKeySigner(GenSK)))
+	_, err = bc.InjectTx(freeze)
+	require.NoError(t, err)
+	require.True(t, bc.IsGenerationFrozen())
+
+	require.Equal(t, ErrGenerationFrozen, bc.SubmitTx(NewGenTx(2, GenSK)))
+	_, err = bc.InjectTx(NewGenTx(2, GenSK))
+	require.Equal(t, ErrGenerationFrozen, err)
+
+	_, err = bc.InjectTx(freeze)
+	require.Error(t, err, "generation should not be frozen twice")
+
+	t.Run("Replay", func(t *testing.T) {
+		snapshot := bc.state.(SnapshotStateDB).Snapshot()
+		require.Equal(t, []cipher.PubKey{pk2}, snapshot.Revoked)
+		require.True(t, snapshot.Frozen)
+		require.Equal(t, uint64(1), snapshot.Epoch)
+
+		bc.state = NewMemoryState()
+		require.NoError(t, bc.InitState())
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot(),
+			"replayed state should match")
+	})
+}
@@ -0,0 +1,174 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+var (
+	// ErrKittyIDAllocated occurs when checking a kitty ID that the allocator
+	// has already handed out.
+	ErrKittyIDAllocated = errors.New("kitty ID is already allocated")
+
+	// ErrKittyIDsExhausted occurs when there are not enough unused kitty IDs
+	// left to allocate.
+	ErrKittyIDsExhausted = errors.New("kitty IDs are exhausted")
+)
+
+// KittyIDRange is a range of kitty IDs [Start, End).
+type KittyIDRange struct {
+	Start KittyID
+	End   KittyID
+}
+
+// Len returns the number of kitty IDs of the range.
+func (r KittyIDRange) Len() uint64 {
+	return uint64(r.End - r.Start)
+}
+
+// Contains determines whether the kitty ID is of the range.
+func (r KittyIDRange) Contains(kittyID KittyID) bool {
+	return kittyID >= r.Start && kittyID < r.End
+}
+
+// IDs obtains the kitty IDs of the range, in ascending order.
+func (r KittyIDRange) IDs() KittyIDs {
+	ids := make(KittyIDs, 0, r.Len())
+	for id := r.Start; id < r.End; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// KittyAllocator allocates unused kitty IDs for the generation txs of a
+// creator, so that bulk mints do not reuse the IDs of generated kitties (and
+// have their txs rejected mid-batch). IDs are allocated in ascending order
+// from the next unused ID, and skip IDs of which kitties exist in the state.
+// Allocated IDs are not handed out again, but are not persisted: an allocator
+// of a restarted process starts from the IDs that are generated by then.
+type KittyAllocator struct {
+	mux       sync.Mutex
+	exists    func(kittyID KittyID) bool
+	next      KittyID
+	allocated []KittyIDRange // handed out, in ascending order
+}
+
+// NewKittyAllocator creates a KittyAllocator of the state, that allocates IDs
+// from 'start'.
+func NewKittyAllocator(state StateDB, start KittyID) *KittyAllocator {
+	return &KittyAllocator{
+		exists: func(kittyID KittyID) bool {
+			_, ok := state.GetKittyState(kittyID)
+			return ok
+		},
+		next: start,
+	}
+}
+
+// Next allocates the next unused kitty ID.
+func (a *KittyAllocator) Next() (KittyID, error) {
+	r, e := a.Reserve(1)
+	if e != nil {
+		return 0, e
+	}
+	return r.Start, nil
+}
+
+// Reserve allocates a range of 'n' contiguous unused kitty IDs, such as for a
+// bulk mint. IDs of generated kitties are skipped, so the range may start
+// after the next unused ID. The last kitty ID (math.MaxUint64) is never
+// allocated.
+func (a *KittyAllocator) Reserve(n uint64) (KittyIDRange, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if n == 0 {
+		return KittyIDRange{Start: a.next, End: a.next}, nil
+	}
+	start := a.next
+	for {
+		if uint64(math.MaxUint64-start) < n {
+			return KittyIDRange{}, ErrKittyIDsExhausted
+		}
+		r := KittyIDRange{Start: start, End: start + KittyID(n)}
+		id, ok := a.firstExisting(r)
+		if !ok {
+			a.allocate(r)
+			return r, nil
+		}
+		start = id + 1
+	}
+}
+
+// Check returns an error if the kitty ID is of a generated kitty, or if it is
+// allocated. It should be called before signing a generation tx of an ID that
+// is not obtained from the allocator.
+func (a *KittyAllocator) Check(kittyID KittyID) error {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if a.exists(kittyID) {
+		return fmt.Errorf("kitty %d: %w", kittyID, ErrKittyAlreadyExists)
+	}
+	for _, r := range a.allocated {
+		if r.Contains(kittyID) {
+			return fmt.Errorf("kitty %d: %w", kittyID, ErrKittyIDAllocated)
+		}
+	}
+	return nil
+}
+
+// NewGenTx creates a generation tx of an allocated kitty ID, signed by the
+// signer. The kitty is checked to not be generated before the tx is signed,
+// as it may have been generated since it's ID was allocated (such as by
+// another minter of the same creator).
+func (a *KittyAllocator) NewGenTx(kittyID KittyID, s Signer) (*Transaction, error) {
+	if a.exists(kittyID) {
+		return nil, fmt.Errorf("kitty %d: %w", kittyID, ErrKittyAlreadyExists)
+	}
+	return NewGenTxWithSigner(kittyID, s)
+}
+
+// firstExisting obtains the first kitty ID of the range of which the kitty
+// exists, or false if there are none.
+func (a *KittyAllocator) firstExisting(r KittyIDRange) (KittyID, bool) {
+	for id := r.Start; id < r.End; id++ {
+		if a.exists(id) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// allocate records the range as handed out, merging it with the last
+// allocated range if they are contiguous.
+func (a *KittyAllocator) allocate(r KittyIDRange) {
+	a.next = r.End
+	if last := len(a.allocated) - 1; last >= 0 && a.allocated[last].End == r.Start {
+		a.allocated[last].End = r.End
+		return
+	}
+	a.allocated = append(a.allocated, r)
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// NewKittyAllocator creates a KittyAllocator of the BlockChain, that
+// allocates IDs from 'start'. IDs of kitties that are generated by pending txs
+// are skipped, as are those of kitties of the chain.
+func (bc *BlockChain) NewKittyAllocator(start KittyID) *KittyAllocator {
+	return &KittyAllocator{
+		exists: func(kittyID KittyID) bool {
+			if _, ok := bc.GetKittyState(kittyID); ok {
+				return true
+			}
+			_, ok := bc.pool.KittyHead(kittyID)
+			return ok
+		},
+		next: start,
+	}
+}
@@ -0,0 +1,72 @@
+package iko
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKittyAllocator(t *testing.T) {
+	var (
+		state = NewMemoryState()
+		owner = cipher.AddressFromPubKey(GenPK)
+	)
+	for _, kittyID := range []KittyID{0, 1, 4} {
+		txHash := TxHash(cipher.SumSHA256([]byte{byte(kittyID)}))
+		require.NoError(t, state.AddKitty(txHash, kittyID, owner))
+	}
+	a := NewKittyAllocator(state, 0)
+
+	kittyID, err := a.Next()
+	require.NoError(t, err)
+	require.Equal(t, KittyID(2), kittyID, "generated kitties should be skipped")
+
+	r, err := a.Reserve(3)
+	require.NoError(t, err)
+	require.Equal(t, KittyIDRange{Start: 5, End: 8}, r,
+		"range should not hold generated kitties")
+	require.Equal(t, KittyIDs{5, 6, 7}, r.IDs())
+
+	kittyID, err = a.Next()
+	require.NoError(t, err)
+	require.Equal(t, KittyID(8), kittyID)
+
+	err = a.Check(4)
+	require.True(t, errors.Is(err, ErrKittyAlreadyExists), err)
+	err = a.Check(6)
+	require.True(t, errors.Is(err, ErrKittyIDAllocated), err)
+	require.NoError(t, a.Check(3), "skipped kitties should not be allocated")
+
+	tx, err := a.NewGenTx(6, NewSecKeySigner(GenSK))
+	require.NoError(t, err)
+	require.NoError(t, tx.VerifyWith(nil, GenPK))
+	_, err = a.NewGenTx(4, NewSecKeySigner(GenSK))
+	require.True(t, errors.Is(err, ErrKittyAlreadyExists), err)
+
+	_, err = NewKittyAllocator(state, math.MaxUint64-1).Reserve(2)
+	require.Equal(t, ErrKittyIDsExhausted, err)
+}
+
+func TestBlockChain_NewKittyAllocator(t *testing.T) {
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	injectGenTxs(t, bc, 2)
+	require.NoError(t, bc.SubmitTx(NewGenTx(2, GenSK)))
+
+	a := bc.NewKittyAllocator(0)
+	r, err := a.Reserve(2)
+	require.NoError(t, err)
+	require.Equal(t, KittyIDRange{Start: 3, End: 5}, r,
+		"kitties of pending txs should be skipped")
+
+	for _, kittyID := range r.IDs() {
+		tx, err := a.NewGenTx(kittyID, NewSecKeySigner(GenSK))
+		require.NoError(t, err)
+		require.NoError(t, bc.SubmitTx(tx))
+	}
+	require.Equal(t, 3, bc.CommitPending())
+}
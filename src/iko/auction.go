@@ -0,0 +1,330 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+const (
+	// listingAddressDomain separates listing address hashes from those of
+	// public keys, multisigs and escrows.
+	listingAddressDomain = "kittycash listing"
+)
+
+// AuctionAction is the action of an auction tx.
+type AuctionAction uint8
+
+const (
+	// AuctionList lists a kitty for auction, by transferring it from the
+	// seller to the address of the listing.
+	AuctionList AuctionAction = 1
+
+	// AuctionBid places a bid on a listed kitty, which is held by the listing.
+	AuctionBid AuctionAction = 2
+
+	// AuctionSettle releases a listed kitty to the highest bidder (or to the
+	// seller if there are no bids), after the deadline.
+	AuctionSettle AuctionAction = 3
+
+	// AuctionCancel returns a listed kitty without bids to the seller.
+	AuctionCancel AuctionAction = 4
+)
+
+var (
+	// ErrAuctionMismatch occurs when the listing of an auction tx is not that
+	// of the kitty.
+	ErrAuctionMismatch = errors.New("auction listing does not match kitty")
+
+	// ErrAuctionSigner occurs when an auction tx is not signed by a party that
+	// is allowed to perform it's action.
+	ErrAuctionSigner = errors.New("auction tx is not signed by an authorised party")
+
+	// ErrAuctionOutput occurs when an auction tx does not output to the
+	// address required by it's action.
+	ErrAuctionOutput = errors.New("auction tx has an invalid output")
+
+	// ErrAuctionClosed occurs when bidding on a kitty after the deadline of
+	// it's listing.
+	ErrAuctionClosed = errors.New("auction deadline has passed")
+
+	// ErrAuctionOpen occurs when settling an auction before it's deadline.
+	ErrAuctionOpen = errors.New("auction can only be settled after it's deadline")
+
+	// ErrAuctionHasBids occurs when cancelling an auction that has bids.
+	ErrAuctionHasBids = errors.New("auction with bids cannot be cancelled")
+
+	// ErrBidTooLow occurs when a bid is below the reserve of the listing, or
+	// does not exceed the highest bid.
+	ErrBidTooLow = errors.New("bid is too low")
+)
+
+// Listing is the terms of an auction. A listed kitty is held by the address
+// of the listing, and every auction tx of the kitty reveals the listing.
+// As there are no coins on the chain, bids are commitments that are paid
+// outside of the chain.
+type Listing struct {
+	Seller   cipher.Address
+	Reserve  uint64 // Minimum bid.
+	Deadline int64  // Unix time (in nanoseconds) at which bidding closes.
+}
+
+// NewListing creates a listing of the seller, which can be bid on until the
+// deadline.
+func NewListing(seller cipher.Address, reserve uint64, deadline time.Time) (*Listing, error) {
+	l := &Listing{
+		Seller:   seller,
+		Reserve:  reserve,
+		Deadline: deadline.UnixNano(),
+	}
+	return l, l.Verify()
+}
+
+// Verify checks that the listing has a seller and a deadline.
+func (l *Listing) Verify() error {
+	if l.Seller == (cipher.Address{}) {
+		return errors.New("listing should have a seller")
+	}
+	if l.Deadline <= 0 {
+		return errors.New("listing should have a deadline")
+	}
+	return nil
+}
+
+// Address returns the address that holds kitties listed with the listing.
+func (l *Listing) Address() cipher.Address {
+	data := append([]byte(listingAddressDomain), encoder.Serialize(*l)...)
+	hash := cipher.DoubleSHA256(data)
+	return cipher.Address{
+		Version: 0,
+		Key:     cipher.HashRipemd160(hash[:]),
+	}
+}
+
+// IsClosed returns true if bidding has closed at time 'ts' (in nanoseconds).
+func (l *Listing) IsClosed(ts int64) bool {
+	return ts >= l.Deadline
+}
+
+// TxAuction is the auction action of an auction tx.
+type TxAuction struct {
+	Action  AuctionAction
+	Listing Listing
+	Bid     uint64 // Amount of bid txs.
+}
+
+func (a TxAuction) Serialize() []byte {
+	return encoder.Serialize(a)
+}
+
+// AuctionState is the state of the auction of a listed kitty.
+type AuctionState struct {
+	Listing Listing
+	Bidder  cipher.Address // Highest bidder, null if there are no bids.
+	Bid     uint64         // Highest bid.
+}
+
+// IsZero returns true if the kitty is not listed.
+func (a AuctionState) IsZero() bool {
+	return a == AuctionState{}
+}
+
+// Winner returns the address that a settled auction releases the kitty to.
+func (a AuctionState) Winner() cipher.Address {
+	if a.Bidder == (cipher.Address{}) {
+		return a.Listing.Seller
+	}
+	return a.Bidder
+}
+
+// AuctionEntry is the auction state of a listed kitty.
+type AuctionEntry struct {
+	KittyID KittyID
+	State   AuctionState
+}
+
+// NewAuctionListTx creates an unsigned tx that lists the kitty of the input tx.
+// It should be signed by the seller, who should own the kitty.
+func NewAuctionListTx(in *Transaction, listing *Listing) (*Transaction, error) {
+	if in.Out != listing.Seller {
+		return nil, ErrAuctionMismatch
+	}
+	return newAuctionTx(in, listing, listing.Address(), AuctionList)
+}
+
+// NewAuctionBidTx creates an unsigned tx that bids on the listed kitty of the
+// input tx. It should be signed by the bidder.
+func NewAuctionBidTx(in *Transaction, listing *Listing, bid uint64) (*Transaction, error) {
+	tx, e := newAuctionTx(in, listing, listing.Address(), AuctionBid)
+	if e != nil {
+		return nil, e
+	}
+	tx.Auction.Bid = bid
+	return tx, nil
+}
+
+// NewAuctionSettleTx creates an unsigned tx that releases the listed kitty of
+// the input tx to the winner of the auction (see 'AuctionState.Winner'). It
+// should be signed by the seller or the winner.
+func NewAuctionSettleTx(in *Transaction, listing *Listing, winner cipher.Address) (*Transaction, error) {
+	return newAuctionTx(in, listing, winner, AuctionSettle)
+}
+
+// NewAuctionCancelTx creates an unsigned tx that returns the listed kitty of
+// the input tx to the seller. It should be signed by the seller.
+func NewAuctionCancelTx(in *Transaction, listing *Listing) (*Transaction, error) {
+	return newAuctionTx(in, listing, listing.Seller, AuctionCancel)
+}
+
+func newAuctionTx(in *Transaction, listing *Listing, out cipher.Address, action AuctionAction) (*Transaction, error) {
+	if e := listing.Verify(); e != nil {
+		return nil, e
+	}
+	if action != AuctionList && in.Out != listing.Address() {
+		return nil, ErrAuctionMismatch
+	}
+	tx := NewUnsignedTransferTx(in, out)
+	tx.Auction = &TxAuction{
+		Action:  action,
+		Listing: *listing,
+	}
+	return tx, nil
+}
+
+// IsAuction returns true if the tx performs an auction action.
+func (tx Transaction) IsAuction() bool {
+	return tx.Auction != nil
+}
+
+// VerifyAuctionWith checks an auction tx against the unspent tx of it's kitty,
+// and that it is signed by a party allowed to perform it's action. Rules that
+// depend on the state of the auction (bids and the deadline) are not checked.
+func (tx Transaction) VerifyAuctionWith(in *Transaction) error {
+	if in == nil {
+		return fmt.Errorf("kitty %d: %w", tx.KittyID, ErrKittyNotFound)
+	}
+	if e := tx.VerifyMemo(); e != nil {
+		return e
+	}
+	if len(tx.Inputs) > 0 || tx.Escrow != nil || tx.Metadata != nil || tx.Breed != nil {
+		return errors.New("auction tx should be of a single kitty, and only perform the auction action")
+	}
+	if tx.Witness != nil {
+		return errors.New("auction tx cannot have a witness")
+	}
+	if !tx.Lock.IsZero() {
+		return errors.New("auction tx cannot be locked")
+	}
+	if e := tx.VerifyInputs([]*Transaction{in}); e != nil {
+		return e
+	}
+	var (
+		listing = &tx.Auction.Listing
+		address = listing.Address()
+	)
+	if e := listing.Verify(); e != nil {
+		return e
+	}
+	signer, e := tx.SignerAddress()
+	if e != nil {
+		return e
+	}
+	if tx.Auction.Action == AuctionList {
+		if in.Out != listing.Seller {
+			return ErrAuctionMismatch
+		}
+	} else if in.Out != address {
+		return ErrAuctionMismatch
+	}
+	switch tx.Auction.Action {
+	case AuctionList:
+		if tx.Out != address {
+			return ErrAuctionOutput
+		}
+		if signer != listing.Seller {
+			return ErrAuctionSigner
+		}
+	case AuctionBid:
+		if tx.Out != address {
+			return ErrAuctionOutput
+		}
+		if signer == listing.Seller {
+			return ErrAuctionSigner
+		}
+		if tx.Auction.Bid < listing.Reserve {
+			return ErrBidTooLow
+		}
+	case AuctionSettle:
+		// The winner is of the state, see 'BlockChain.checkAuction'.
+	case AuctionCancel:
+		if tx.Out != listing.Seller {
+			return ErrAuctionOutput
+		}
+		if signer != listing.Seller {
+			return ErrAuctionSigner
+		}
+	default:
+		return fmt.Errorf("unknown auction action %d", tx.Auction.Action)
+	}
+	return tx.VerifyOwner(signer)
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// checkAuction checks an auction tx against the auction state of it's kitty,
+// at time 'ts'.
+func (bc *BlockChain) checkAuction(tx *Transaction, ts int64) error {
+	if tx.Auction.Action == AuctionList {
+		return nil
+	}
+	kState, ok := bc.state.GetKittyState(tx.KittyID)
+	if !ok {
+		return fmt.Errorf("kitty %d: %w", tx.KittyID, ErrKittyNotFound)
+	}
+	aState := kState.Auction
+	if aState.IsZero() || aState.Listing != tx.Auction.Listing {
+		return ErrAuctionMismatch
+	}
+	signer, e := tx.SignerAddress()
+	if e != nil {
+		return e
+	}
+	switch tx.Auction.Action {
+	case AuctionBid:
+		if aState.Listing.IsClosed(ts) {
+			return ErrAuctionClosed
+		}
+		if aState.Bidder != (cipher.Address{}) && tx.Auction.Bid <= aState.Bid {
+			return ErrBidTooLow
+		}
+	case AuctionSettle:
+		if !aState.Listing.IsClosed(ts) {
+			return ErrAuctionOpen
+		}
+		if tx.Out != aState.Winner() {
+			return ErrAuctionOutput
+		}
+		if signer != aState.Listing.Seller && signer != aState.Winner() {
+			return ErrAuctionSigner
+		}
+	case AuctionCancel:
+		if aState.Bidder != (cipher.Address{}) {
+			return ErrAuctionHasBids
+		}
+	}
+	return nil
+}
+
+// GetAuctions obtains the auction state of listed kitties, in ascending order
+// of kitty ID.
+func (bc *BlockChain) GetAuctions() []AuctionEntry {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	return bc.state.GetAuctions()
+}
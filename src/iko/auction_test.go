@@ -0,0 +1,170 @@
+package iko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewListing(t *testing.T) {
+	var (
+		creator  = cipher.AddressFromPubKey(GenPK)
+		deadline = time.Now().Add(time.Hour)
+	)
+	_, err := NewListing(cipher.Address{}, 1, deadline)
+	require.Error(t, err, "listing without seller should be rejected")
+
+	_, err = NewListing(creator, 1, time.Unix(0, 0))
+	require.Error(t, err, "listing without deadline should be rejected")
+
+	listing, err := NewListing(creator, 1, deadline)
+	require.NoError(t, err)
+	require.NotEqual(t, creator, listing.Address())
+
+	other := *listing
+	other.Reserve++
+	require.NotEqual(t, listing.Address(), other.Address(),
+		"address should depend on the terms")
+}
+
+func TestBlockChain_Auction(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps  = injectGenTxs(t, bc, 3)
+		pk1, sk1 = cipher.GenerateKeyPair()
+		pk2, sk2 = cipher.GenerateKeyPair()
+		addr1    = cipher.AddressFromPubKey(pk1)
+		addr2    = cipher.AddressFromPubKey(pk2)
+	)
+	// list lists the kitty with a reserve of 10.
+	list := func(in *Transaction, deadline time.Time) (*Transaction, *Listing) {
+		listing, err := NewListing(bc.CreatorAddress(), 10, deadline)
+		require.NoError(t, err)
+		tx, err := NewAuctionListTx(in, listing)
+		require.NoError(t, err)
+		tx.Sig = tx.Sign(GenSK)
+		_, err = bc.InjectTx(tx)
+		require.NoError(t, err)
+
+		kState, ok := bc.GetKittyState(in.KittyID)
+		require.True(t, ok)
+		require.Equal(t, listing.Address(), kState.Address, "listing should hold the kitty")
+		require.Equal(t, AuctionState{Listing: *listing}, kState.Auction)
+		return tx, listing
+	}
+	bid := func(in *Transaction, listing *Listing, amount uint64, sk cipher.SecKey) (*Transaction, error) {
+		tx, err := NewAuctionBidTx(in, listing, amount)
+		require.NoError(t, err)
+		tx.Sig = tx.Sign(sk)
+		_, err = bc.InjectTx(tx)
+		return tx, err
+	}
+
+	t.Run("Bid", func(t *testing.T) {
+		in, listing := list(&txWraps[0].Tx, time.Now().Add(time.Hour))
+
+		_, err := bid(in, listing, 9, sk1)
+		require.Equal(t, ErrBidTooLow, err, "bid should meet the reserve")
+		_, err = bid(in, listing, 10, GenSK)
+		require.Equal(t, ErrAuctionSigner, err, "seller should not bid")
+
+		in, err = bid(in, listing, 10, sk1)
+		require.NoError(t, err)
+		_, err = bid(in, listing, 10, sk2)
+		require.Equal(t, ErrBidTooLow, err, "bid should exceed the highest bid")
+		in, err = bid(in, listing, 20, sk2)
+		require.NoError(t, err)
+
+		kState, ok := bc.GetKittyState(0)
+		require.True(t, ok)
+		require.Equal(t, addr2, kState.Auction.Bidder)
+		require.Equal(t, uint64(20), kState.Auction.Bid)
+		require.Contains(t, bc.GetAddressState(addr1).Transactions, kState.Transactions[2],
+			"bid should be recorded for the bidder")
+
+		cancel, err := NewAuctionCancelTx(in, listing)
+		require.NoError(t, err)
+		cancel.Sig = cancel.Sign(GenSK)
+		_, err = bc.InjectTx(cancel)
+		require.Equal(t, ErrAuctionHasBids, err)
+
+		settle, err := NewAuctionSettleTx(in, listing, addr2)
+		require.NoError(t, err)
+		settle.Sig = settle.Sign(GenSK)
+		_, err = bc.InjectTx(settle)
+		require.Equal(t, ErrAuctionOpen, err)
+
+		require.Equal(t, []AuctionEntry{{KittyID: 0, State: kState.Auction}}, bc.GetAuctions())
+	})
+
+	t.Run("Settle", func(t *testing.T) {
+		in, listing := list(&txWraps[1].Tx, time.Now().Add(50*time.Millisecond))
+		in, err := bid(in, listing, 15, sk1)
+		require.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+		_, err = bid(in, listing, 30, sk2)
+		require.Equal(t, ErrAuctionClosed, err)
+
+		settle, err := NewAuctionSettleTx(in, listing, bc.CreatorAddress())
+		require.NoError(t, err)
+		settle.Sig = settle.Sign(GenSK)
+		_, err = bc.InjectTx(settle)
+		require.Equal(t, ErrAuctionOutput, err, "kitty should be released to the highest bidder")
+
+		settle, err = NewAuctionSettleTx(in, listing, addr1)
+		require.NoError(t, err)
+		settle.Sig = settle.Sign(sk2)
+		_, err = bc.InjectTx(settle)
+		require.Equal(t, ErrAuctionSigner, err)
+
+		settle.Sig = settle.Sign(sk1)
+		_, err = bc.InjectTx(settle)
+		require.NoError(t, err, "winner should settle the auction")
+
+		kState, ok := bc.GetKittyState(1)
+		require.True(t, ok)
+		require.Equal(t, addr1, kState.Address)
+		require.True(t, kState.Auction.IsZero())
+		require.Len(t, bc.GetAuctions(), 1)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		in, listing := list(&txWraps[2].Tx, time.Now().Add(time.Hour))
+
+		cancel, err := NewAuctionCancelTx(in, listing)
+		require.NoError(t, err)
+		cancel.Sig = cancel.Sign(sk1)
+		_, err = bc.InjectTx(cancel)
+		require.Equal(t, ErrAuctionSigner, err)
+
+		cancel.Sig = cancel.Sign(GenSK)
+		_, err = bc.InjectTx(cancel)
+		require.NoError(t, err)
+
+		kState, ok := bc.GetKittyState(2)
+		require.True(t, ok)
+		require.Equal(t, bc.CreatorAddress(), kState.Address)
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		snapshot := bc.state.(SnapshotStateDB).Snapshot()
+		bc.state = NewMemoryState()
+		require.NoError(t, bc.InitState())
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot(),
+			"replayed state should match")
+		require.Equal(t, []KittyID{0}, auctionKittyIDs(bc.GetAuctions()))
+	})
+}
+
+func auctionKittyIDs(entries []AuctionEntry) []KittyID {
+	out := make([]KittyID, len(entries))
+	for i, entry := range entries {
+		out[i] = entry.KittyID
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+package iko
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+var (
+	// ErrStateDiverged occurs when the state rebuilt from the chain does not
+	// match the live state.
+	ErrStateDiverged = errors.New("state diverges from the chain")
+
+	// ErrSeqMismatch occurs when a tx is stored at a seq other than that of
+	// it's meta.
+	ErrSeqMismatch = errors.New("tx is stored at another seq")
+)
+
+// ChainDivergence is the first divergence found by 'VerifyChain'.
+type ChainDivergence struct {
+	// Seq is the seq of the tx that fails validation. If the state diverges,
+	// it is the height of the chain.
+	Seq uint64
+
+	// Reason describes the divergence, such as the kitty or address of which
+	// the state differs.
+	Reason string
+
+	// Err is the error of the tx that fails validation, or 'ErrStateDiverged'.
+	Err error
+}
+
+func (d *ChainDivergence) Error() string {
+	return fmt.Sprintf("seq %d: %s: %v", d.Seq, d.Reason, d.Err)
+}
+
+func (d *ChainDivergence) Unwrap() error {
+	return d.Err
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// VerifyChain re-validates every tx of the chain against a fresh state, as if
+// the chain were replayed on start, and then cross-checks the rebuilt state
+// against the live state. It returns the first divergence, or nil if the chain
+// and state agree. The returned error is that of reading the chain (such as
+// when txs are pruned), or of cancelling 'ctx'. A 'SnapshotStateDB' is
+// required.
+// Injection of transactions is blocked while verifying, but reads are not.
+func (bc *BlockChain) VerifyChain(ctx context.Context) (*ChainDivergence, error) {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	live, e := bc.snapshotState()
+	if e != nil {
+		return nil, e
+	}
+
+	// Verify with a BlockChain of a fresh state, which shares the chain. The
+	// checkpoint snapshot is not saved again.
+	var (
+		config = *bc.c
+		state  = NewMemoryState()
+	)
+	config.CheckpointSnapshotPath = ""
+	vc := &BlockChain{
+		c:       &config,
+		chain:   bc.chain,
+		state:   state,
+		log:     bc.log,
+		ctx:     ctx,
+		genAddr: bc.genAddr,
+	}
+	if e := vc.checkGenesis(); e == ErrCreatorMismatch || e == ErrGenesisMismatch {
+		return &ChainDivergence{Seq: 0, Reason: "invalid genesis tx", Err: e}, nil
+	} else if e != nil {
+		return nil, e
+	}
+
+	var (
+		at    TxMeta
+		check = makeTxChecker(vc, vc.getTx, &at)
+		end   = live.Height
+	)
+	for start := uint64(0); start < end; start += replayBatchSize {
+		to := start + replayBatchSize
+		if to > end {
+			to = end
+		}
+		b := vc.loadReplayBatch(start, to)
+		d, e := vc.verifyBatch(b, check, &at, start)
+		forgetSigs(b.txWraps)
+		if d != nil || e != nil {
+			return d, e
+		}
+	}
+
+	rebuilt := state.Snapshot()
+	rebuilt.Height, rebuilt.HeadHash = live.Height, live.HeadHash
+	if rebuilt.Hash() == live.Hash() {
+		bc.log.
+			WithField("height", end).
+			Info("verified chain")
+		return nil, nil
+	}
+	return &ChainDivergence{
+		Seq:    end,
+		Reason: diffStateSnapshots(rebuilt, live),
+		Err:    ErrStateDiverged,
+	}, nil
+}
+
+// verifyBatch checks the txs of the batch in order, which should start at seq
+// 'start', applying them to the state.
+func (bc *BlockChain) verifyBatch(b replayBatch, check TxChecker, at *TxMeta, start uint64) (*ChainDivergence, error) {
+	for i := range b.txWraps {
+		txWrap := &b.txWraps[i]
+		seq := start + uint64(i)
+
+		if e := bc.ctx.Err(); e != nil {
+			return nil, e
+		}
+		if txWrap.Meta.Seq != seq {
+			return &ChainDivergence{
+				Seq:    seq,
+				Reason: fmt.Sprintf("tx is stored with seq %d", txWrap.Meta.Seq),
+				Err:    ErrSeqMismatch,
+			}, nil
+		}
+		*at = txWrap.Meta
+		if e := check(&txWrap.Tx); e != nil {
+			return &ChainDivergence{
+				Seq:    seq,
+				Reason: fmt.Sprintf("tx '%s' is invalid", txWrap.Tx.Hash().Hex()),
+				Err:    e,
+			}, nil
+		}
+		if e := bc.verifyCheckpoint(txWrap); e != nil {
+			return &ChainDivergence{
+				Seq:    seq,
+				Reason: "state does not match checkpoint",
+				Err:    e,
+			}, nil
+		}
+	}
+	return nil, b.err
+}
+
+// diffStateSnapshots describes the first difference between the 'rebuilt' and
+// 'live' snapshots, of which entries are sorted (see 'MemoryState.Snapshot').
+func diffStateSnapshots(rebuilt, live *StateSnapshot) string {
+	for i, j := 0, 0; i < len(rebuilt.Kitties) || j < len(live.Kitties); {
+		switch {
+		case j == len(live.Kitties) ||
+			i < len(rebuilt.Kitties) && rebuilt.Kitties[i].KittyID < live.Kitties[j].KittyID:
+			return fmt.Sprintf("kitty %d is missing from the state", rebuilt.Kitties[i].KittyID)
+		case i == len(rebuilt.Kitties) ||
+			live.Kitties[j].KittyID < rebuilt.Kitties[i].KittyID:
+			return fmt.Sprintf("kitty %d of the state is not in the chain", live.Kitties[j].KittyID)
+		case !bytes.Equal(encoder.Serialize(rebuilt.Kitties[i]), encoder.Serialize(live.Kitties[j])):
+			return fmt.Sprintf("state of kitty %d differs", rebuilt.Kitties[i].KittyID)
+		}
+		i, j = i+1, j+1
+	}
+	for i, j := 0, 0; i < len(rebuilt.Addresses) || j < len(live.Addresses); {
+		var ra, la string
+		if i < len(rebuilt.Addresses) {
+			ra = rebuilt.Addresses[i].Address.String()
+		}
+		if j < len(live.Addresses) {
+			la = live.Addresses[j].Address.String()
+		}
+		switch {
+		case j == len(live.Addresses) || i < len(rebuilt.Addresses) && ra < la:
+			return fmt.Sprintf("address '%s' is missing from the state", ra)
+		case i == len(rebuilt.Addresses) || la < ra:
+			return fmt.Sprintf("address '%s' of the state is not in the chain", la)
+		case !bytes.Equal(encoder.Serialize(rebuilt.Addresses[i]), encoder.Serialize(live.Addresses[j])):
+			return fmt.Sprintf("state of address '%s' differs", ra)
+		}
+		i, j = i+1, j+1
+	}
+	switch {
+	case !bytes.Equal(encoder.Serialize(rebuilt.Creators), encoder.Serialize(live.Creators)):
+		return "creator keys differ"
+	case !bytes.Equal(encoder.Serialize(rebuilt.Revoked), encoder.Serialize(live.Revoked)):
+		return "revoked creator keys differ"
+	case rebuilt.Frozen != live.Frozen:
+		return "generation freeze differs"
+	}
+	return "state hash differs"
+}
@@ -0,0 +1,59 @@
+package iko
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_VerifyChain(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 3)
+
+	t.Run("Valid", func(t *testing.T) {
+		d, err := bc.VerifyChain(context.Background())
+		require.NoError(t, err)
+		require.Nil(t, d, "chain and state should agree")
+	})
+
+	t.Run("StateDiverged", func(t *testing.T) {
+		ss := bc.state.(SnapshotStateDB)
+		snapshot := ss.Snapshot()
+		defer ss.Restore(snapshot)
+
+		pk, _ := cipher.GenerateKeyPair()
+		to := cipher.AddressFromPubKey(pk)
+		require.NoError(t, bc.state.MoveKitty(txWraps[0].Tx.Hash(), 1, bc.genAddr, to))
+
+		d, err := bc.VerifyChain(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, d, "moved kitty should diverge")
+		require.Equal(t, uint64(3), d.Seq)
+		require.Equal(t, ErrStateDiverged, d.Err)
+		require.Equal(t, "state of kitty 1 differs", d.Reason)
+	})
+
+	t.Run("InvalidTx", func(t *testing.T) {
+		_, sk := cipher.GenerateKeyPair()
+		tx := NewGenTx(KittyID(3), sk)
+		require.NoError(t, bc.chain.AddTx(TxWrapper{Tx: *tx, Meta: TxMeta{Seq: 3}},
+			func(*Transaction) error { return nil }))
+
+		d, err := bc.VerifyChain(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, d, "tx of another creator should diverge")
+		require.Equal(t, uint64(3), d.Seq)
+		require.Error(t, d.Err)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := bc.VerifyChain(ctx)
+		require.Equal(t, context.Canceled, err)
+	})
+}
@@ -0,0 +1,195 @@
+package iko
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// BackupVersion is the version of the backup format.
+const BackupVersion uint32 = 1
+
+// maxBackupRecordSize is the maximum size of a record of a backup (the state
+// snapshot, or an encoded tx), so that a corrupt backup does not exhaust
+// memory on restore.
+const maxBackupRecordSize = 1 << 30
+
+var backupMagic = [8]byte{'K', 'C', 'B', 'A', 'C', 'K', 'U', 'P'}
+
+var (
+	// ErrNotBackup occurs when restoring data that is not a backup.
+	ErrNotBackup = errors.New("data is not a chain backup")
+
+	// ErrChainNotEmpty occurs when restoring a backup into a chain with txs.
+	ErrChainNotEmpty = errors.New("chain is not empty")
+
+	// ErrBackupMismatch occurs when the state rebuilt from the txs of a backup
+	// does not match the state of the backup.
+	ErrBackupMismatch = errors.New("backup state does not match it's txs")
+)
+
+// backupHeader is written before the records of a backup.
+type backupHeader struct {
+	Magic   [8]byte
+	Version uint32
+	Height  uint64 // Number of txs of the backup.
+}
+
+// writeBackupRecord writes the length of the record, then the record.
+func writeBackupRecord(w io.Writer, raw []byte) error {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(raw)))
+	if _, e := w.Write(n[:]); e != nil {
+		return e
+	}
+	_, e := w.Write(raw)
+	return e
+}
+
+// readBackupRecord reads a record written with 'writeBackupRecord'.
+func readBackupRecord(r io.Reader) ([]byte, error) {
+	var n [4]byte
+	if _, e := io.ReadFull(r, n[:]); e != nil {
+		return nil, e
+	}
+	size := binary.BigEndian.Uint32(n[:])
+	if size > maxBackupRecordSize {
+		return nil, fmt.Errorf("backup record of %d bytes is too large", size)
+	}
+	raw := make([]byte, size)
+	_, e := io.ReadFull(r, raw)
+	return raw, e
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// Backup writes a consistent backup of the chain and state to 'w': the
+// snapshot of the state, then every tx up to the height of the snapshot.
+// Injection of transactions is blocked while backing up, but reads are not.
+// A 'SnapshotStateDB' is required, and chains of which txs are pruned cannot
+// be backed up. The backup is restored with 'RestoreBackup'.
+func (bc *BlockChain) Backup(w io.Writer) error {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	snapshot, e := bc.snapshotState()
+	if e != nil {
+		return e
+	}
+	bw := bufio.NewWriter(w)
+	header := backupHeader{
+		Magic:   backupMagic,
+		Version: BackupVersion,
+		Height:  snapshot.Height,
+	}
+	if _, e := bw.Write(encoder.Serialize(header)); e != nil {
+		return e
+	}
+	if e := writeBackupRecord(bw, snapshot.Serialize()); e != nil {
+		return e
+	}
+	codec := BinaryTxCodec{}
+	for seq := uint64(0); seq < snapshot.Height; seq += exportPageSize {
+		txWraps, e := bc.chain.GetTxsOfSeqRange(seq, exportPageSize)
+		if e != nil {
+			return e
+		}
+		for i := range txWraps {
+			if e := writeBackupRecord(bw, codec.EncodeTx(txWraps[i])); e != nil {
+				return e
+			}
+		}
+	}
+	if e := bw.Flush(); e != nil {
+		return e
+	}
+	bc.log.
+		WithField("height", snapshot.Height).
+		Info("backed up chain")
+	return nil
+}
+
+// RestoreBackup restores a backup written by 'Backup' into the chain, which
+// should be empty. Every tx is validated as with 'AppendTxs', and the rebuilt
+// state is then checked against the state of the backup. If restoring fails,
+// the txs restored so far are kept. It returns the number of txs restored.
+func (bc *BlockChain) RestoreBackup(r io.Reader) (uint64, error) {
+	if bc.chain.Len() > 0 {
+		return 0, ErrChainNotEmpty
+	}
+	br := bufio.NewReader(r)
+
+	var header backupHeader
+	raw := make([]byte, encoder.Size(header))
+	if _, e := io.ReadFull(br, raw); e != nil {
+		return 0, ErrNotBackup
+	}
+	if e := encoder.DeserializeRaw(raw, &header); e != nil || header.Magic != backupMagic {
+		return 0, ErrNotBackup
+	}
+	if header.Version != BackupVersion {
+		return 0, fmt.Errorf("unsupported backup version %d", header.Version)
+	}
+	raw, e := readBackupRecord(br)
+	if e != nil {
+		return 0, fmt.Errorf("failed to read backup state: %v", e)
+	}
+	snapshot := new(StateSnapshot)
+	if e := encoder.DeserializeRaw(raw, snapshot); e != nil {
+		return 0, fmt.Errorf("failed to decode backup state: %v", e)
+	}
+	if snapshot.Height != header.Height {
+		return 0, ErrBackupMismatch
+	}
+
+	var (
+		codec   = BinaryTxCodec{}
+		count   uint64
+		txWraps = make([]TxWrapper, 0, exportPageSize)
+	)
+	for seq := uint64(0); seq < header.Height; seq++ {
+		raw, e := readBackupRecord(br)
+		if e != nil {
+			return count, fmt.Errorf("failed to read backup tx of seq %d: %v", seq, e)
+		}
+		var txWrap TxWrapper
+		if e := codec.DecodeTx(raw, &txWrap); e != nil {
+			return count, fmt.Errorf("failed to decode backup tx of seq %d: %v", seq, e)
+		}
+		if txWraps = append(txWraps, txWrap); len(txWraps) == exportPageSize {
+			n, e := bc.AppendTxs(txWraps)
+			if count += n; e != nil {
+				return count, e
+			}
+			txWraps = txWraps[:0]
+		}
+	}
+	n, e := bc.AppendTxs(txWraps)
+	if count += n; e != nil {
+		return count, e
+	}
+
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	rebuilt, e := bc.snapshotState()
+	if e != nil {
+		return count, e
+	}
+	if rebuilt.Hash() != snapshot.Hash() {
+		return count, ErrBackupMismatch
+	}
+	bc.log.
+		WithField("height", count).
+		Info("restored chain backup")
+	return count, nil
+}
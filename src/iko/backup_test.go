@@ -0,0 +1,60 @@
+package iko
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_Backup(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 3)
+	pk, _ := cipher.GenerateKeyPair()
+	transfer, err := NewTransferTx(&txWraps[0].Tx, cipher.AddressFromPubKey(pk), GenSK)
+	require.NoError(t, err)
+	transfer.Memo = []byte("memo")
+	transfer.Sig = transfer.Sign(GenSK)
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, bc.Backup(&buf))
+	backup := buf.Bytes()
+
+	t.Run("Restore", func(t *testing.T) {
+		restored, closeRestored := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+		defer closeRestored()
+
+		n, err := restored.RestoreBackup(bytes.NewReader(backup))
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), n)
+
+		head, err := restored.GetHeadTx()
+		require.NoError(t, err)
+		require.Equal(t, transfer.Hash(), head.Tx.Hash(), "optional fields should be restored")
+
+		exp, err := bc.Checkpoint()
+		require.NoError(t, err)
+		got, err := restored.Checkpoint()
+		require.NoError(t, err)
+		require.Equal(t, exp, got, "restored state should match")
+
+		_, err = restored.RestoreBackup(bytes.NewReader(backup))
+		require.Equal(t, ErrChainNotEmpty, err)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		restored, closeRestored := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+		defer closeRestored()
+
+		_, err := restored.RestoreBackup(bytes.NewReader([]byte("not a backup")))
+		require.Equal(t, ErrNotBackup, err)
+
+		_, err = restored.RestoreBackup(bytes.NewReader(backup[:len(backup)-1]))
+		require.Error(t, err, "truncated backup should fail")
+	})
+}
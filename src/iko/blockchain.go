@@ -1,8 +1,12 @@
 package iko
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,9 +14,209 @@ import (
 	"gopkg.in/sirupsen/logrus.v1"
 )
 
+var (
+	// ErrChainFull occurs when a tx is injected into a chain that has already
+	// reached it's configured 'MaxSequence'.
+	ErrChainFull = errors.New("chain has reached it's maximum sequence")
+
+	// ErrCreatorMismatch occurs when the genesis tx stored in the ChainDB is
+	// not created by the configured 'GenerationPK'.
+	ErrCreatorMismatch = errors.New("genesis tx is not of the configured creator")
+
+	// ErrStateNotSnapshottable occurs when snapshotting or restoring a state
+	// that does not implement 'SnapshotStateDB'.
+	ErrStateNotSnapshottable = errors.New("state db does not support snapshots")
+
+	// ErrSnapshotMismatch occurs when restoring a state snapshot that is not
+	// taken from the current chain.
+	ErrSnapshotMismatch = errors.New("state snapshot does not match chain")
+
+	// ErrInsufficientFee occurs when a transfer tx pays less than the fee
+	// required by the configured 'FeePolicy'.
+	ErrInsufficientFee = errors.New("tx fee is below the required fee")
+)
+
+// FeePolicy returns the minimum fee that a transfer tx should pay.
+type FeePolicy func(tx *Transaction) uint64
+
+// FlatFeePolicy requires transfer txs to pay 'fee' for each kitty transferred.
+func FlatFeePolicy(fee uint64) FeePolicy {
+	return func(tx *Transaction) uint64 {
+		return fee * uint64(len(tx.KittyIDs()))
+	}
+}
+
+// ProgressFn reports the progress of replaying the chain, where 'done' of the
+// 'total' txs of the chain are applied to the state.
+type ProgressFn func(done, total uint64)
+
 type BlockChainConfig struct {
 	GenerationPK cipher.PubKey
 	TxAction     TxAction
+
+	// Genesis is the genesis that the genesis tx (of seq 0) should define, so
+	// that a chain of another network is not loaded (see 'NewGenesisTx').
+	// It's 'CreatorPK' should be 'GenerationPK'. A nil genesis means that the
+	// genesis tx is not checked for a genesis.
+	Genesis *Genesis
+
+	// NetworkID identifies the network, and every tx should be signed for it
+	// (see 'Transaction.VerifyNetwork'), so that txs signed for a testnet are
+	// not accepted on mainnet. An empty network ID is that of the legacy
+	// network, of which txs do not set a network.
+	NetworkID string
+
+	// TxActionPolicy determines how the BlockChain handles a 'TxAction' that
+	// returns an error. It defaults to 'TxActionStop'.
+	TxActionPolicy TxActionPolicy
+
+	// TxActionRetries is the number of retries of the 'TxActionRetry' policy.
+	// A value of 0 results in 'DefaultTxActionRetries'.
+	TxActionRetries int
+
+	// TxActionBackoff is the delay before the first retry of the
+	// 'TxActionRetry' policy, which doubles with each retry. A value of 0
+	// results in 'DefaultTxActionBackoff'.
+	TxActionBackoff time.Duration
+
+	// OnTxActionError is called with the tx and error of a failed 'TxAction',
+	// after the policy is applied (and any retries failed).
+	OnTxActionError func(tx *Transaction, e error)
+
+	// Logger is the logger of the BlockChain, so that embedding applications
+	// can route logs into their own logging. It defaults to a text logger of
+	// stderr, of 'LogLevel'.
+	Logger *logrus.Logger
+
+	// LogLevel is the level of the default logger, and is ignored if 'Logger'
+	// is specified. It defaults to "debug".
+	LogLevel string
+
+	// ProgressFn is called as the chain is replayed on start (or by
+	// 'InitState', 'RestoreState' and rollbacks), once before the first tx and after
+	// each tx, so that applications can show the progress. Txs before a
+	// restored snapshot are reported as done. It is called synchronously, so
+	// it should return quickly.
+	ProgressFn ProgressFn
+
+	// CreatorPKs are additional keys that are trusted to create kitties, along
+	// with 'GenerationPK', until the creator keys are replaced by a rotation
+	// tx. The genesis tx is always of 'GenerationPK'.
+	CreatorPKs []cipher.PubKey
+
+	// FeePolicy determines the fee required of transfer txs. Transfers that
+	// pay less are rejected with 'ErrInsufficientFee'. Generation txs do not
+	// require fees. A nil policy means that fees are not required.
+	FeePolicy FeePolicy
+
+	// MetadataPolicy determines who may set the metadata of kitties with
+	// metadata txs. It defaults to 'MetadataByCreator'.
+	MetadataPolicy MetadataPolicy
+
+	// TxVersionPolicy determines which versions of txs are accepted at each
+	// seq (see 'TxVersionSchedule'). It defaults to 'AnyTxVersionPolicy'.
+	TxVersionPolicy TxVersionPolicy
+
+	// BreedCooldown is the number of sequences that kitties cannot breed for,
+	// after breeding or being bred. A value of 0 means that kitties can breed
+	// in every tx.
+	BreedCooldown uint64
+
+	// TxCheckers are additional checks of txs (e.g. blacklists, or per-address
+	// rate limits), which run in order after the built-in checks when txs are
+	// injected or the chain is replayed. A tx is rejected with the error of
+	// the first failing checker, before it is applied to the state.
+	TxCheckers []TxChecker
+
+	// MaxSequence caps the number of transactions the chain can hold.
+	// Injecting transactions past this cap fails with 'ErrChainFull'.
+	// A value of 0 means that the chain is unlimited.
+	MaxSequence uint64
+
+	// StateSnapshotPath is the file of the state snapshot. If specified, the
+	// state is restored from the snapshot on start (only transactions after
+	// the snapshot are replayed), and a new snapshot is saved on close.
+	StateSnapshotPath string
+
+	// SnapshotInterval is the number of txs between automatic state
+	// snapshots, which are saved to 'SnapshotDir' as txs are injected. On
+	// start, the state is restored from the newest valid snapshot of
+	// 'StateSnapshotPath' and 'SnapshotDir'. A value of 0 disables automatic
+	// snapshots.
+	SnapshotInterval uint64
+
+	// SnapshotDir is the directory of automatic state snapshots.
+	SnapshotDir string
+
+	// WALPath is the file of the write-ahead log of chain writes. If
+	// specified, txs are logged before they are applied to the state, so that
+	// a tx applied to the state is still appended to the chain if the write
+	// fails (or the node stops) in between. See 'WAL'.
+	WALPath string
+
+	// SnapshotKeep is the number of automatic state snapshots that are kept.
+	// A value of 0 results in 'DefaultSnapshotKeep'.
+	SnapshotKeep int
+
+	// ChainMode determines whether old txs are pruned from the ChainDB. It
+	// defaults to 'ArchivalChainMode'. 'PrunedChainMode' requires automatic
+	// snapshots (see 'SnapshotInterval') and a 'PrunableChainDB', and txs are
+	// pruned as automatic snapshots are saved. 'InitState' fails once txs
+	// are pruned.
+	ChainMode ChainMode
+
+	// PruneRetention is the number of most recent txs that are kept in
+	// 'PrunedChainMode'. A value of 0 results in 'DefaultPruneRetention'.
+	PruneRetention uint64
+
+	// Checkpoints are trusted states of the chain, which the state is checked
+	// against when replaying the chain. Replaying fails with
+	// 'ErrCheckpointMismatch' if the state does not match.
+	Checkpoints []Checkpoint
+
+	// CheckpointSnapshotPath is the file of the state snapshot at the latest
+	// checkpoint. If specified, the snapshot is saved when the latest
+	// checkpoint is verified, and on start the state is restored from it if
+	// there is no usable 'StateSnapshotPath' snapshot, so that only txs after
+	// the latest checkpoint are verified.
+	CheckpointSnapshotPath string
+
+	// VerifyWorkers is the number of goroutines that verify signatures in
+	// parallel when replaying the chain. A value of 0 results in the number
+	// of CPUs.
+	VerifyWorkers int
+
+	// MempoolSize is the maximum number of txs pending in the mempool (see
+	// 'SubmitTx'). A value of 0 results in 'DefaultMempoolSize'.
+	MempoolSize int
+
+	// MempoolExpiry evicts pending txs that are not committed within the
+	// duration. A value of 0 means that pending txs do not expire.
+	MempoolExpiry time.Duration
+
+	// MempoolCommitInterval is the interval in which pending txs are committed
+	// to the chain. A value of 0 means that pending txs are only committed
+	// with 'CommitPending'.
+	MempoolCommitInterval time.Duration
+
+	// TxCacheSize is the number of txs of the least recently used cache of tx
+	// lookups by hash and seq, so that hot txs are not read from the ChainDB
+	// again. A value of 0 disables the cache.
+	TxCacheSize int
+
+	// StateCacheSize is the number of kitty states, and of address states, of
+	// a read-through cache of the StateDB (see 'NewCachedState'). A value of
+	// 0 disables the cache.
+	StateCacheSize int
+
+	// Replica makes the BlockChain a read replica, which only appends the txs
+	// of it's primary (see 'Replicate') and serves reads. Injecting or
+	// submitting txs fails with 'ErrReplica'.
+	Replica bool
+
+	// ReplicaRetry is the delay before a replica reconnects to the tx stream
+	// of it's primary. A value of 0 results in 'DefaultReplicaRetry'.
+	ReplicaRetry time.Duration
 }
 
 func (cc *BlockChainConfig) Prepare() error {
@@ -21,99 +225,683 @@ func (cc *BlockChainConfig) Prepare() error {
 			return nil
 		}
 	}
+	switch cc.TxActionPolicy {
+	case "":
+		cc.TxActionPolicy = TxActionStop
+	case TxActionLog, TxActionRetry, TxActionStop:
+	default:
+		return fmt.Errorf("invalid tx action policy '%s'", cc.TxActionPolicy)
+	}
+	if cc.TxActionRetries <= 0 {
+		cc.TxActionRetries = DefaultTxActionRetries
+	}
+	if cc.TxActionBackoff <= 0 {
+		cc.TxActionBackoff = DefaultTxActionBackoff
+	}
+	if cc.OnTxActionError == nil {
+		cc.OnTxActionError = func(tx *Transaction, e error) {}
+	}
+	if cc.Logger == nil {
+		if cc.LogLevel == "" {
+			cc.LogLevel = logrus.DebugLevel.String()
+		}
+		level, e := logrus.ParseLevel(cc.LogLevel)
+		if e != nil {
+			return e
+		}
+		cc.Logger = &logrus.Logger{
+			Out:       os.Stderr,
+			Formatter: new(logrus.TextFormatter),
+			Hooks:     make(logrus.LevelHooks),
+			Level:     level,
+		}
+	}
+	if cc.ProgressFn == nil {
+		cc.ProgressFn = func(done, total uint64) {}
+	}
+	if cc.FeePolicy == nil {
+		cc.FeePolicy = func(tx *Transaction) uint64 {
+			return 0
+		}
+	}
+	if cc.TxVersionPolicy == nil {
+		cc.TxVersionPolicy = AnyTxVersionPolicy
+	}
+	switch cc.MetadataPolicy {
+	case "":
+		cc.MetadataPolicy = MetadataByCreator
+	case MetadataByCreator, MetadataByOwner:
+	default:
+		return fmt.Errorf("invalid metadata policy '%s'", cc.MetadataPolicy)
+	}
 	if e := cc.GenerationPK.Verify(); e != nil {
 		return e
 	}
+	if cc.Genesis != nil {
+		if e := cc.Genesis.Verify(); e != nil {
+			return e
+		}
+		if cc.Genesis.CreatorPK != cc.GenerationPK {
+			return errors.New("genesis creator key should be the generation public key")
+		}
+	}
+	if e := VerifyNetworkID(cc.NetworkID); e != nil {
+		return e
+	}
+	if len(cc.CreatorPKs) > 0 {
+		pks := append([]cipher.PubKey{cc.GenerationPK}, cc.CreatorPKs...)
+		if e := verifyCreatorPKs(pks); e != nil {
+			return e
+		}
+	}
+	if cc.SnapshotInterval > 0 && cc.SnapshotDir == "" {
+		return errors.New("automatic state snapshots require a snapshot directory")
+	}
+	if cc.SnapshotKeep <= 0 {
+		cc.SnapshotKeep = DefaultSnapshotKeep
+	}
+	if cc.ReplicaRetry <= 0 {
+		cc.ReplicaRetry = DefaultReplicaRetry
+	}
+	switch cc.ChainMode {
+	case "":
+		cc.ChainMode = ArchivalChainMode
+	case ArchivalChainMode:
+	case PrunedChainMode:
+		if cc.SnapshotInterval == 0 {
+			return errors.New("pruned chain mode requires automatic state snapshots")
+		}
+	default:
+		return fmt.Errorf("invalid chain mode '%s'", cc.ChainMode)
+	}
+	if cc.PruneRetention == 0 {
+		cc.PruneRetention = DefaultPruneRetention
+	}
+	if cc.VerifyWorkers <= 0 {
+		cc.VerifyWorkers = runtime.NumCPU()
+	}
+	sort.Slice(cc.Checkpoints, func(i, j int) bool {
+		return cc.Checkpoints[i].Seq < cc.Checkpoints[j].Seq
+	})
+	for i := 1; i < len(cc.Checkpoints); i++ {
+		if cc.Checkpoints[i].Seq == cc.Checkpoints[i-1].Seq {
+			return fmt.Errorf("duplicate checkpoints of seq %d", cc.Checkpoints[i].Seq)
+		}
+	}
 	return nil
 }
 
+// Chain is the interface of a BlockChain that consumers (such as wallets and
+// filters) can program against, so that they do not depend on how txs are
+// stored and validated. A Chain is also the 'TxSource' of a replica.
+type Chain interface {
+	TxSource
+
+	// GetHeadTx obtains the tx at the head of the chain.
+	GetHeadTx() (TxWrapper, error)
+
+	// GetTxOfHash obtains a tx of the chain by it's hash.
+	GetTxOfHash(txHash TxHash) (TxWrapper, error)
+
+	// GetTxOfSeq obtains a tx of the chain by it's seq.
+	GetTxOfSeq(seq uint64) (TxWrapper, error)
+
+	// Range calls 'fn' with the txs of seqs [start, end), in order, until
+	// 'fn' returns false.
+	Range(start, end uint64, fn func(txWrap TxWrapper) bool) error
+
+	// GetKittyState obtains the state of a kitty, or false if it does not
+	// exist.
+	GetKittyState(kittyID KittyID) (*KittyState, bool)
+
+	// GetAddressState obtains the state of an address.
+	GetAddressState(address cipher.Address) *AddressState
+
+	// InjectTx validates and appends a tx to the chain.
+	InjectTx(tx *Transaction) (*TxMeta, error)
+
+	// CheckTx validates a tx as if it were injected next, without
+	// injecting it.
+	CheckTx(tx *Transaction) error
+
+	// SubscribeTxs obtains a channel that receives every tx accepted into
+	// the chain, and a function that unsubscribes.
+	SubscribeTxs(bufSize int) (<-chan TxWrapper, func())
+}
+
 type BlockChain struct {
 	c     *BlockChainConfig
 	chain ChainDB
 	state StateDB
 	log   *logrus.Logger
 	mux   sync.RWMutex
+	wmux  sync.Mutex // serializes writes against compaction
+
+	wg        sync.WaitGroup
+	ctx       context.Context // cancelled on close, stopping replay and services
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+
+	subs    map[chan TxWrapper]struct{} // subscribers of accepted txs
+	subsMux sync.Mutex
+	events  *EventBus
+
+	headCh      chan struct{}     // closed when a tx is accepted, see 'headChanged'
+	heads       []chan HeadUpdate // see 'HeadChanges'
+	headsClosed bool
+	headMux     sync.Mutex
 
-	wg   sync.WaitGroup
-	quit chan struct{}
+	pool      *Mempool
+	poolMux   sync.Mutex // serializes submission of pending txs
+	commitMux sync.Mutex // serializes committing of pending txs
+
+	genAddr cipher.Address // address of 'GenerationPK'
+	txCache *txCache       // nil if disabled, see 'TxCacheSize'
+	wal     *WAL           // nil if disabled, see 'WALPath'
+
+	creatorAddr *cipher.Address // cache of 'CreatorAddress', nil if invalidated
+	creatorMux  sync.Mutex
 }
 
-func NewBlockChain(config *BlockChainConfig, chainDB ChainDB, stateDB StateDB) (*BlockChain, error) {
+// NewBlockChain creates a BlockChain of the ChainDB, replaying the chain to
+// build the state. Cancelling 'ctx' stops the replay (failing construction)
+// and the services of the BlockChain, as does 'Close'.
+func NewBlockChain(ctx context.Context, config *BlockChainConfig, chainDB ChainDB, stateDB StateDB) (*BlockChain, error) {
 	if e := config.Prepare(); e != nil {
 		return nil, e
 	}
+	if config.StateCacheSize > 0 {
+		stateDB = NewCachedState(stateDB, config.StateCacheSize)
+	}
+	ctx, cancel := context.WithCancel(ctx)
 	bc := &BlockChain{
-		c:     config,
-		chain: chainDB,
-		state: stateDB,
-		log: &logrus.Logger{
-			Out:       os.Stderr,
-			Formatter: new(logrus.TextFormatter),
-			Hooks:     make(logrus.LevelHooks),
-			Level:     logrus.DebugLevel,
-		},
-		quit: make(chan struct{}),
+		c:       config,
+		chain:   chainDB,
+		state:   stateDB,
+		log:     config.Logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		subs:    make(map[chan TxWrapper]struct{}),
+		events:  NewEventBus(config.Logger),
+		headCh:  make(chan struct{}),
+		pool:    NewMempool(config.MempoolSize),
+		genAddr: cipher.AddressFromPubKey(config.GenerationPK),
+		txCache: newTxCache(config.TxCacheSize),
 	}
 
-	if e := bc.InitState(); e != nil {
+	if _, ok := chainDB.(PrunableChainDB); !ok && config.ChainMode == PrunedChainMode {
+		cancel()
+		return nil, ErrChainNotPrunable
+	}
+
+	if path := config.WALPath; path != "" {
+		var e error
+		if bc.wal, e = OpenWAL(path); e != nil {
+			cancel()
+			return nil, e
+		}
+		if e := bc.recoverWAL(); e != nil {
+			bc.wal.Close()
+			cancel()
+			return nil, e
+		}
+	}
+
+	if e := bc.checkGenesis(); e != nil {
+		bc.closeWAL()
+		cancel()
+		return nil, e
+	}
+
+	if e := bc.initState(); e != nil {
+		bc.closeWAL()
+		cancel()
 		return nil, e
 	}
 
 	bc.wg.Add(1)
 	go bc.service()
 
+	if interval := config.MempoolCommitInterval; interval > 0 {
+		bc.wg.Add(1)
+		go bc.mempoolService(interval)
+	}
+
 	return bc, nil
 }
 
+// checkGenesis ensures that the genesis tx (if any) is a generation tx of the
+// configured 'GenerationPK' which defines the configured 'Genesis' (if any), so
+// that the state is not built from a chain of another creator or network.
+func (bc *BlockChain) checkGenesis() error {
+	if bc.chain.Len() == 0 {
+		return nil
+	}
+	genesis, e := bc.chain.GetTxOfSeq(0)
+	if e != nil {
+		return e
+	}
+	if !genesis.Tx.IsKittyGen(bc.c.GenerationPK) {
+		return ErrCreatorMismatch
+	}
+	if e := genesis.Tx.VerifyWith(nil, bc.c.GenerationPK); e != nil {
+		bc.log.WithError(e).Warning("invalid genesis tx signature")
+		return ErrCreatorMismatch
+	}
+	return bc.checkGenesisTx(&genesis.Tx, 0)
+}
+
+// initState restores the state from the newest configured snapshot (if any),
+// or else the snapshot of the latest checkpoint (if any), falling back to
+// replaying the whole chain if neither can be used.
+func (bc *BlockChain) initState() error {
+	defer func(start time.Time) {
+		metricInitState.Set(time.Since(start).Seconds())
+		metricChainLen.Set(float64(bc.chain.Len()))
+	}(time.Now())
+
+	if bc.restoreNewestSnapshot() {
+		return nil
+	}
+	e := bc.restoreCheckpoint()
+	if e == nil {
+		return nil
+	}
+	bc.resetState(e, "failed to restore checkpoint snapshot")
+	return bc.InitState()
+}
+
+// resetState clears a partially restored state, logging the error unless the
+// snapshot did not exist.
+func (bc *BlockChain) resetState(e error, msg string) {
+	if os.IsNotExist(e) {
+		return
+	}
+	bc.log.WithError(e).Warning(msg)
+	if ss, ok := bc.state.(SnapshotStateDB); ok {
+		bc.restoreSnapshot(ss, new(StateSnapshot))
+	}
+}
+
 func (bc *BlockChain) InitState() error {
-	var check = MakeTxChecker(bc)
-	for i := uint64(1); i < bc.chain.Len(); i++ {
+	return bc.replayState(0)
+}
 
-		// Val transaction.
-		txWrap, e := bc.chain.GetTxOfSeq(i)
+// replayState applies transactions from seq 'start' onwards to the state.
+// Txs are checked at the time of their meta, rather than the current time.
+// Signatures of the next batch of txs are verified in parallel (see
+// 'VerifyWorkers') while the current batch is applied in order.
+func (bc *BlockChain) replayState(start uint64) error {
+	var (
+		at    TxMeta
+		check = makeTxChecker(bc, bc.getTx, &at)
+		end   = bc.chain.Len()
+		next  = make(chan replayBatch, 1)
+		load  = func(from uint64) {
+			to := from + replayBatchSize
+			if to > end {
+				to = end
+			}
+			next <- bc.loadReplayBatch(from, to)
+		}
+		loading = start < end
+	)
+	defer func() {
+		if loading {
+			forgetSigs((<-next).txWraps)
+		}
+	}()
+	if loading {
+		go load(start)
+	}
+	bc.c.ProgressFn(start, end)
+	for i := start; i < end; {
+		b := <-next
+		if loading = i+uint64(len(b.txWraps)) < end && b.err == nil; loading {
+			go load(i + uint64(len(b.txWraps)))
+		}
+		e := bc.applyReplayBatch(b, check, &at, end)
+		forgetSigs(b.txWraps)
 		if e != nil {
 			return e
 		}
+		i += uint64(len(b.txWraps))
+	}
+	return nil
+}
+
+// applyReplayBatch checks the txs of the batch in order, applying them to the
+// state, and reports the progress of the chain of 'total' txs.
+func (bc *BlockChain) applyReplayBatch(b replayBatch, check TxChecker, at *TxMeta, total uint64) error {
+	for i := range b.txWraps {
+		txWrap := &b.txWraps[i]
 		bc.log.
 			WithField("tx", txWrap.Tx.String()).
 			WithField("meta", txWrap.Meta).
-			Infof("InitState (%d)", i)
+			Infof("InitState (%d)", txWrap.Meta.Seq)
 
+		if e := bc.ctx.Err(); e != nil {
+			return e
+		}
+		*at = txWrap.Meta
 		if e := check(&txWrap.Tx); e != nil {
 			return e
 		}
+		if e := bc.verifyCheckpoint(txWrap); e != nil {
+			return e
+		}
+		bc.c.ProgressFn(txWrap.Meta.Seq+1, total)
 	}
-	return nil
+	return b.err
 }
 
+// Close stops the BlockChain: an in-flight replay is cancelled, txs already
+// accepted to 'TxChan' are processed, and the services are waited on before
+// the state snapshot (if configured) is saved. Calling it again does nothing.
 func (bc *BlockChain) Close() {
-	bc.log.Info("closing blockchain manager")
-	close(bc.quit)
+	bc.closeOnce.Do(func() {
+		bc.log.Info("closing blockchain manager")
+		bc.cancel()
+		bc.wg.Wait()
+		bc.events.Close()
+		bc.closeHeadChanges()
+
+		if path := bc.c.StateSnapshotPath; path != "" {
+			if e := bc.SnapshotState(path); e != nil {
+				bc.log.WithError(e).Error("failed to save state snapshot")
+			}
+		}
+		bc.closeWAL()
+	})
 }
 
 func (bc *BlockChain) service() {
 	defer bc.wg.Done()
 
+	tracker := &acceptedTracker{next: bc.chain.Len(), read: make(map[uint64]TxHash)}
 	for {
 		select {
-		case <-bc.quit:
+		case <-bc.ctx.Done():
+			bc.drainTxs(tracker)
 			return
 
 		case txWrap, ok := <-bc.chain.TxChan():
 			if !ok {
 				return
 			}
-			if e := bc.c.TxAction(&txWrap.Tx); e != nil {
-				panic(e)
+			if !bc.processAccepted(txWrap, tracker) {
+				bc.stop()
+				return
 			}
 		}
 	}
 }
 
+// acceptedTracker tracks the seq of the tx that the service expects next from
+// 'TxChan', and the txs that are read from the chain ahead of 'TxChan'.
+type acceptedTracker struct {
+	next uint64
+	read map[uint64]TxHash
+}
+
+// processAccepted processes a tx received from 'TxChan'. As the ChainDB drops
+// txs of 'TxChan' when the service falls behind (such as with a slow
+// 'TxAction'), dropped txs are read from the chain and processed, so that no
+// tx goes unprocessed: those before the tx, and those after it once 'TxChan'
+// is empty. Txs that are read from the chain are skipped when received.
+func (bc *BlockChain) processAccepted(txWrap *TxWrapper, tracker *acceptedTracker) bool {
+	seq := txWrap.Meta.Seq
+	for readSeq := range tracker.read {
+		if readSeq < seq {
+			delete(tracker.read, readSeq)
+		}
+	}
+	if hash, ok := tracker.read[seq]; ok {
+		delete(tracker.read, seq)
+		if hash == txWrap.Tx.Hash() {
+			return true
+		}
+	}
+	if seq > tracker.next && !bc.processDropped(tracker, seq) {
+		return false
+	}
+	tracker.next = seq + 1
+	if !bc.processTx(txWrap) {
+		return false
+	}
+	if len(bc.chain.TxChan()) == 0 {
+		return bc.processDropped(tracker, bc.chain.Len())
+	}
+	return true
+}
+
+// processDropped reads the txs from 'tracker.next' up to seq 'end' from the
+// chain, and processes them.
+func (bc *BlockChain) processDropped(tracker *acceptedTracker, end uint64) bool {
+	if tracker.next >= end {
+		return true
+	}
+	bc.log.
+		WithField("from", tracker.next).
+		WithField("to", end).
+		Warning("txs were dropped by the chain db as processing fell behind, reading them from the chain")
+
+	for tracker.next < end {
+		txWraps, e := bc.chain.GetTxsOfSeqRange(tracker.next, end-tracker.next)
+		if e != nil {
+			bc.log.WithError(e).Error("failed to read dropped txs")
+			return true
+		}
+		if len(txWraps) == 0 {
+			return true
+		}
+		for i := range txWraps {
+			tracker.read[txWraps[i].Meta.Seq] = txWraps[i].Tx.Hash()
+			tracker.next = txWraps[i].Meta.Seq + 1
+			if !bc.processTx(&txWraps[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// processTx runs the 'TxAction' of an accepted tx, and broadcasts it to
+// subscribers and the event bus. It returns false if the BlockChain should
+// stop.
+func (bc *BlockChain) processTx(txWrap *TxWrapper) bool {
+	if !bc.runTxAction(txWrap) {
+		return false
+	}
+	metricChainLen.Set(float64(txWrap.Meta.Seq + 1))
+	bc.notifyHeadChanged(txWrap)
+	bc.broadcastTx(*txWrap)
+	bc.publishEvents(txWrap)
+	return true
+}
+
+// drainTxs processes the txs that are already accepted to 'TxChan', so that
+// they are not dropped on close.
+func (bc *BlockChain) drainTxs(tracker *acceptedTracker) {
+	for {
+		select {
+		case txWrap, ok := <-bc.chain.TxChan():
+			if !ok || !bc.processAccepted(txWrap, tracker) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// stop stops the BlockChain as of the 'TxActionStop' policy. Services are
+// stopped and injecting txs fails, but the BlockChain should still be closed.
+func (bc *BlockChain) stop() {
+	bc.log.Error("stopping blockchain, as of tx action policy")
+	bc.cancel()
+}
+
+// SubscribeTxs obtains a channel that receives every tx accepted into the
+// chain. Txs are dropped for subscribers that are not keeping up, so 'bufSize'
+// should be large enough to cover bursts. The returned function unsubscribes
+// and closes the channel.
+func (bc *BlockChain) SubscribeTxs(bufSize int) (<-chan TxWrapper, func()) {
+	bc.subsMux.Lock()
+	defer bc.subsMux.Unlock()
+
+	sub := make(chan TxWrapper, bufSize)
+	bc.subs[sub] = struct{}{}
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			bc.subsMux.Lock()
+			defer bc.subsMux.Unlock()
+
+			delete(bc.subs, sub)
+			close(sub)
+		})
+	}
+}
+
+func (bc *BlockChain) broadcastTx(txWrap TxWrapper) {
+	bc.subsMux.Lock()
+	defer bc.subsMux.Unlock()
+
+	for sub := range bc.subs {
+		select {
+		case sub <- txWrap:
+		default:
+			bc.log.
+				WithField("seq", txWrap.Meta.Seq).
+				Warning("dropped tx for slow subscriber")
+		}
+	}
+}
+
+// RequiredFee returns the minimum fee of the tx, as determined by the
+// configured 'FeePolicy'. Generation, rotation and admin txs do not require
+// fees.
+func (bc *BlockChain) RequiredFee(tx *Transaction) uint64 {
+	if bc.isKittyGen(tx) || tx.IsRotation() || tx.IsAdmin() {
+		return 0
+	}
+	return bc.c.FeePolicy(tx)
+}
+
+// checkFee returns an error if the tx pays less than the required fee.
+func (bc *BlockChain) checkFee(tx *Transaction) error {
+	if tx.Fee < bc.RequiredFee(tx) {
+		return ErrInsufficientFee
+	}
+	return nil
+}
+
+// txClock returns the sequence and time (in nanoseconds) that a tx is checked
+// at. A nil meta results in the next sequence of the chain and the current
+// time, otherwise those of the meta are used (such as when replaying txs).
+func (bc *BlockChain) txClock(at *TxMeta) (uint64, int64) {
+	if at == nil {
+		return bc.chain.Len(), time.Now().UnixNano()
+	}
+	return at.Seq, at.TS
+}
+
+// checkLocks returns ErrKittyLocked if any of the input txs are time-locked at
+// sequence 'seq' and time 'ts'.
+func (bc *BlockChain) checkLocks(ins []*Transaction, seq uint64, ts int64) error {
+	for _, in := range ins {
+		if in != nil && in.Lock.IsLocked(seq, ts) {
+			return ErrKittyLocked
+		}
+	}
+	return nil
+}
+
+// checkEscrow checks a tx settling an escrow against the escrow's deadline, at
+// time 'ts'.
+func (bc *BlockChain) checkEscrow(tx *Transaction, ts int64) error {
+	if tx.Escrow == nil {
+		return nil
+	}
+	signer, e := tx.SignerAddress()
+	if e != nil {
+		return e
+	}
+	return tx.Escrow.CheckDeadline(tx.Out, signer, ts)
+}
+
+// verifyMetadataTx checks a metadata tx against the unspent tx of it's kitty,
+// and that it is signed by the party of the 'MetadataPolicy'.
+func (bc *BlockChain) verifyMetadataTx(tx *Transaction, in *Transaction) error {
+	if e := tx.VerifyMetadataWith(in); e != nil {
+		return e
+	}
+	return tx.VerifyOwner(bc.metadataSetter(in))
+}
+
+// metadataSetter returns the address that may set the metadata of the kitty of
+// the unspent tx, which also pays the fee of the metadata tx.
+func (bc *BlockChain) metadataSetter(in *Transaction) cipher.Address {
+	if bc.c.MetadataPolicy == MetadataByOwner {
+		return in.Out
+	}
+	return bc.CreatorAddress()
+}
+
+// CreatorAddress returns the address of the primary creator key, which is the
+// first of 'CreatorPKs' (initially the trusted generation public key). The
+// address is cached until the creator keys change.
+func (bc *BlockChain) CreatorAddress() cipher.Address {
+	bc.creatorMux.Lock()
+	defer bc.creatorMux.Unlock()
+
+	if bc.creatorAddr != nil {
+		return *bc.creatorAddr
+	}
+	addr := bc.genAddr
+	if pks := bc.state.GetCreators(); len(pks) > 0 {
+		addr = cipher.AddressFromPubKey(pks[0])
+	}
+	bc.creatorAddr = &addr
+	return addr
+}
+
+// invalidateCreator invalidates the cached 'CreatorAddress'. It should be
+// called after the creator keys of the state change.
+func (bc *BlockChain) invalidateCreator() {
+	bc.creatorMux.Lock()
+	defer bc.creatorMux.Unlock()
+
+	bc.creatorAddr = nil
+}
+
+// restoreSnapshot restores the state from the snapshot, invalidating the
+// cached 'CreatorAddress', as the snapshot may be of other creator keys.
+func (bc *BlockChain) restoreSnapshot(ss SnapshotStateDB, snapshot *StateSnapshot) {
+	ss.Restore(snapshot)
+	bc.invalidateCreator()
+}
+
+// CreatorAddresses returns all addresses that are trusted to create kitties.
+// Kitties are created under these addresses.
+func (bc *BlockChain) CreatorAddresses() []cipher.Address {
+	pks := bc.CreatorPKs()
+	out := make([]cipher.Address, len(pks))
+	for i, pk := range pks {
+		out[i] = cipher.AddressFromPubKey(pk)
+	}
+	return out
+}
+
 func (bc *BlockChain) GetHeadTx() (TxWrapper, error) {
 	bc.mux.RLock()
 	defer bc.mux.RUnlock()
 
+	if cLen := bc.chain.Len(); cLen > 0 {
+		return bc.txOfSeq(cLen - 1)
+	}
 	return bc.chain.Head()
 }
 
@@ -121,14 +909,22 @@ func (bc *BlockChain) GetTxOfHash(txHash TxHash) (TxWrapper, error) {
 	bc.mux.RLock()
 	defer bc.mux.RUnlock()
 
-	return bc.chain.GetTxOfHash(txHash)
+	return bc.txOfHash(txHash)
 }
 
 func (bc *BlockChain) GetTxOfSeq(seq uint64) (TxWrapper, error) {
 	bc.mux.RLock()
 	defer bc.mux.RUnlock()
 
-	return bc.chain.GetTxOfSeq(seq)
+	return bc.txOfSeq(seq)
+}
+
+// Range calls 'fn' with the txs of seqs [start, end), in order, until 'fn'
+// returns false. Txs are obtained as the chain is walked, rather than all at
+// once, and no lock is held while 'fn' is called.
+// Pruned txs are skipped, and txs injected while ranging may not be included.
+func (bc *BlockChain) Range(start, end uint64, fn func(txWrap TxWrapper) bool) error {
+	return bc.chain.Range(start, end, fn)
 }
 
 func (bc *BlockChain) GetKittyState(kittyID KittyID) (*KittyState, bool) {
@@ -145,10 +941,58 @@ func (bc *BlockChain) GetAddressState(address cipher.Address) *AddressState {
 	return bc.state.GetAddressState(address)
 }
 
+// GetAddressStates obtains the states of many addresses with a single
+// acquisition of the lock, so that the states are of the same height of the
+// chain. Addresses without txs have empty states.
+func (bc *BlockChain) GetAddressStates(addresses []cipher.Address) map[cipher.Address]*AddressState {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	out := make(map[cipher.Address]*AddressState, len(addresses))
+	for _, address := range addresses {
+		out[address] = bc.state.GetAddressState(address)
+	}
+	return out
+}
+
+// GetKittyStates obtains the states of many kitties with a single acquisition
+// of the lock, so that the states are of the same height of the chain. Kitties
+// that do not exist are omitted.
+func (bc *BlockChain) GetKittyStates(kittyIDs []KittyID) map[KittyID]*KittyState {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	out := make(map[KittyID]*KittyState, len(kittyIDs))
+	for _, kittyID := range kittyIDs {
+		if kState, ok := bc.state.GetKittyState(kittyID); ok {
+			out[kittyID] = kState
+		}
+	}
+	return out
+}
+
 func (bc *BlockChain) InjectTx(tx *Transaction) (*TxMeta, error) {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
 	bc.mux.Lock()
 	defer bc.mux.Unlock()
 
+	defer bc.autoSnapshotState(bc.chain.Len())
+
+	if bc.ctx.Err() != nil {
+		return nil, ErrChainStopped
+	}
+	if bc.c.Replica {
+		return nil, ErrReplica
+	}
+	if e := bc.redoWAL(); e != nil {
+		return nil, e
+	}
+	if max := bc.c.MaxSequence; max > 0 && bc.chain.Len() >= max {
+		return nil, ErrChainFull
+	}
+
 	var seq uint64
 	if txWrap, e := bc.chain.Head(); e == nil {
 		seq = txWrap.Meta.Seq + 1
@@ -159,31 +1003,386 @@ func (bc *BlockChain) InjectTx(tx *Transaction) (*TxMeta, error) {
 		TS:  time.Now().UnixNano(),
 	}
 
-	return &meta, bc.chain.AddTx(
+	e := bc.addTx(
 		TxWrapper{
 			Tx:   *tx,
 			Meta: meta,
 		},
-		MakeTxChecker(bc),
+		countVerifyFailures(makeTxChecker(bc, bc.getTx, &meta)),
 	)
+	if e == nil {
+		metricTxsInjected.Inc()
+	}
+	return &meta, e
 }
 
+// CheckTx runs the full validation of a tx against the current state, as if it
+// were injected next, without committing it. The tx is applied to a fork of
+// the state (see 'StateDB.Fork'), so the check does not block other reads.
+func (bc *BlockChain) CheckTx(tx *Transaction) error {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	if bc.ctx.Err() != nil {
+		return ErrChainStopped
+	}
+	_, errs := bc.forkTxs([]Transaction{*tx})
+	return errs[0]
+}
+
+// InjectTxs validates and commits an ordered batch of txs under a single lock
+// acquisition. Txs of the batch may spend txs that precede them in the batch.
+// The returned slice holds the error of each tx (nil for accepted txs), and
+// txs that fail are skipped. The returned error is that of writing the
+// accepted txs, in which case none of them are committed (with a WAL, they
+// are kept in the log and redone before the next write).
+// If the ChainDB is a 'BatchChainDB', the accepted txs are written at once.
+func (bc *BlockChain) InjectTxs(txs []Transaction) ([]error, error) {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	defer bc.autoSnapshotState(bc.chain.Len())
+
+	if bc.ctx.Err() != nil {
+		return nil, ErrChainStopped
+	}
+	if bc.c.Replica {
+		return nil, ErrReplica
+	}
+	if e := bc.redoWAL(); e != nil {
+		return nil, e
+	}
+	var (
+		errs = make([]error, len(txs))
+		seq  uint64
+		ts   = time.Now().UnixNano()
+	)
+	if txWrap, e := bc.chain.Head(); e == nil {
+		seq = txWrap.Meta.Seq + 1
+	}
+	isFull := func() bool {
+		max := bc.c.MaxSequence
+		return max > 0 && seq >= max
+	}
+
+	batchDB, ok := bc.chain.(BatchChainDB)
+	if !ok {
+		var (
+			at    TxMeta
+			check = countVerifyFailures(makeTxChecker(bc, bc.getTx, &at))
+		)
+		for i := range txs {
+			if isFull() {
+				errs[i] = ErrChainFull
+				continue
+			}
+			at = TxMeta{Seq: seq, TS: ts}
+			txWrap := TxWrapper{Tx: txs[i], Meta: at}
+			if errs[i] = bc.addTx(txWrap, check); errs[i] == nil {
+				metricTxsInjected.Inc()
+				seq++
+			} else if bc.walPending() {
+				// The tx is applied, but not yet appended.
+				return errs, errs[i]
+			}
+		}
+		return errs, nil
+	}
+
+	// Keep a copy of the state, as the state is updated while checking.
+	var snapshot *StateSnapshot
+	if ss, ok := bc.state.(SnapshotStateDB); ok {
+		snapshot = ss.Snapshot()
+	}
+
+	var (
+		txWraps = make([]TxWrapper, 0, len(txs))
+		batch   = make(map[TxHash]*Transaction) // accepted txs of the batch
+		at      TxMeta
+		check   = countVerifyFailures(makeTxChecker(bc, func(hash TxHash) (*Transaction, error) {
+			if tx, ok := batch[hash]; ok {
+				return tx, nil
+			}
+			return bc.getTx(hash)
+		}, &at))
+	)
+	for i := range txs {
+		if isFull() {
+			errs[i] = ErrChainFull
+			continue
+		}
+		at = TxMeta{Seq: seq, TS: ts}
+		if errs[i] = check(&txs[i]); errs[i] != nil {
+			continue
+		}
+		batch[txs[i].Hash()] = &txs[i]
+		txWraps = append(txWraps, TxWrapper{Tx: txs[i], Meta: TxMeta{Seq: seq, TS: ts}})
+		seq++
+	}
+	if len(txWraps) == 0 {
+		return errs, nil
+	}
+
+	if bc.wal != nil {
+		if e := bc.wal.Log(txWraps...); e != nil {
+			if snapshot != nil {
+				bc.restoreSnapshot(bc.state.(SnapshotStateDB), snapshot)
+			}
+			return errs, e
+		}
+	}
+
+	// Txs are already checked.
+	e := batchDB.AddTxs(txWraps, func(*Transaction) error { return nil })
+	if e != nil && bc.wal != nil {
+		bc.log.WithError(e).Warning("failed to write batch, redoing from wal")
+		if e = bc.redoWAL(); e != nil {
+			return errs, e
+		}
+	} else if e == nil && bc.wal != nil {
+		if e := bc.wal.Reset(); e != nil {
+			bc.log.WithError(e).Error("failed to reset wal")
+		}
+	}
+	if e != nil {
+		if snapshot != nil {
+			bc.restoreSnapshot(bc.state.(SnapshotStateDB), snapshot)
+		} else {
+			bc.log.WithError(e).Error("failed to write batch, state may be inconsistent")
+		}
+		return errs, e
+	}
+	metricTxsInjected.Add(float64(len(txWraps)))
+	return errs, nil
+}
+
+// Compact reclaims unused storage space of the ChainDB.
+// Injection of transactions is blocked while compacting, but reads are not.
+// Cancelling 'ctx' stops the compaction early.
+func (bc *BlockChain) Compact(ctx context.Context) error {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	return bc.chain.Compact(ctx)
+}
+
+// DiskUsage obtains the number of bytes the ChainDB occupies on disk.
+func (bc *BlockChain) DiskUsage() (uint64, error) {
+	return bc.chain.DiskUsage()
+}
+
+// SnapshotState saves the current state to the file of path.
+// Injection of transactions is blocked while snapshotting.
+func (bc *BlockChain) SnapshotState(path string) error {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	snapshot, e := bc.snapshotState()
+	if e != nil {
+		return e
+	}
+	return SaveStateSnapshot(path, snapshot)
+}
+
+// RestoreState replaces the current state with the snapshot saved in the file
+// of path. Transactions that are injected after the snapshot was taken are
+// then replayed.
+func (bc *BlockChain) RestoreState(path string) error {
+	snapshot, e := LoadStateSnapshot(path)
+	if e != nil {
+		return e
+	}
+
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	return bc.restoreState(snapshot)
+}
+
+func (bc *BlockChain) restoreState(snapshot *StateSnapshot) error {
+	ss, ok := bc.state.(SnapshotStateDB)
+	if !ok {
+		return ErrStateNotSnapshottable
+	}
+	if e := bc.matchSnapshot(snapshot); e != nil {
+		return e
+	}
+	bc.restoreSnapshot(ss, snapshot)
+
+	bc.log.
+		WithField("height", snapshot.Height).
+		Info("restored state snapshot")
+
+	return bc.replayState(snapshot.Height)
+}
+
+// matchSnapshot checks that the snapshot is of a height of the chain, and of
+// the head tx at that height.
+func (bc *BlockChain) matchSnapshot(snapshot *StateSnapshot) error {
+	if snapshot.Height > bc.chain.Len() {
+		return ErrSnapshotMismatch
+	}
+	if snapshot.Height > 0 {
+		head, e := bc.chain.GetTxOfSeq(snapshot.Height - 1)
+		if e != nil {
+			return e
+		}
+		if head.Tx.Hash() != snapshot.HeadHash {
+			return ErrSnapshotMismatch
+		}
+	}
+	return nil
+}
+
+// MakeTxChecker creates a TxChecker that checks txs at the next sequence of
+// the chain and the current time.
 func MakeTxChecker(bc *BlockChain) TxChecker {
+	return makeTxChecker(bc, bc.getTx, nil)
+}
+
+// runTxCheckers runs the configured 'TxCheckers' on the tx.
+func (bc *BlockChain) runTxCheckers(tx *Transaction) error {
+	for _, check := range bc.c.TxCheckers {
+		if e := check(tx); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// getTx obtains a tx of hash from the ChainDB.
+func (bc *BlockChain) getTx(hash TxHash) (*Transaction, error) {
+	txWrap, e := bc.chain.GetTxOfHash(hash)
+	if e != nil {
+		return nil, e
+	}
+	return &txWrap.Tx, nil
+}
+
+// makeTxChecker creates a TxChecker that obtains unspent txs with 'getTx',
+// so that txs which are not yet stored in the ChainDB can be spent.
+// Time-dependent rules (locks, escrow deadlines) are checked at the meta that
+// 'at' points to when the tx is checked, see 'txClock'.
+func makeTxChecker(bc *BlockChain, getTx func(hash TxHash) (*Transaction, error), at *TxMeta) TxChecker {
 	return func(tx *Transaction) error {
+		seq, ts := bc.txClock(at)
 
-		var unspent *Transaction
-		if tempHash, ok := bc.state.GetKittyUnspentTx(tx.KittyID); ok {
-			temp, e := bc.chain.GetTxOfHash(tempHash)
-			if e != nil {
+		if e := tx.VerifyNetwork(bc.c.NetworkID); e != nil {
+			return e
+		}
+		if e := bc.c.TxVersionPolicy(tx.Version, seq); e != nil {
+			return e
+		}
+		if e := bc.checkGenesisTx(tx, seq); e != nil {
+			return e
+		}
+		if tx.IsRotation() {
+			if e := bc.checkRotation(tx); e != nil {
 				return e
 			}
-			unspent = &temp.Tx
+			if e := bc.runTxCheckers(tx); e != nil {
+				return e
+			}
+			bc.log.
+				WithField("creators", len(tx.Rotation.Creators)).
+				WithField("signer", tx.Out.String()).
+				Debug("processing rotation tx")
+
+			if e := bc.state.SetCreators(tx.Rotation.Creators); e != nil {
+				return e
+			}
+			bc.invalidateCreator()
+			return nil
+		}
+		if tx.IsAdmin() {
+			if e := bc.checkAdmin(tx); e != nil {
+				return e
+			}
+			if e := bc.runTxCheckers(tx); e != nil {
+				return e
+			}
+			bc.log.
+				WithField("revoke", len(tx.Admin.Revoke)).
+				WithField("freeze", tx.Admin.Freeze).
+				Debug("processing admin tx")
+
+			return bc.applyAdmin(tx)
 		}
+		genPK, isGen := bc.generationPK(tx)
 
-		if e := tx.VerifyWith(unspent, bc.c.GenerationPK); e != nil {
+		var (
+			kittyIDs = tx.KittyIDs()
+			ins      = make([]*Transaction, len(kittyIDs))
+		)
+		for i, kittyID := range kittyIDs {
+			if tempHash, ok := bc.state.GetKittyUnspentTx(kittyID); ok {
+				temp, e := getTx(tempHash)
+				if e != nil {
+					return e
+				}
+				if temp.IsBurn() {
+					return ErrKittyBurned
+				}
+				ins[i] = temp
+			}
+		}
+		unspent := ins[0]
+
+		if tx.IsAuction() {
+			if e := tx.VerifyAuctionWith(unspent); e != nil {
+				return e
+			}
+		} else if tx.IsBreed() {
+			if e := tx.VerifyBreedWith(ins); e != nil {
+				return e
+			}
+		} else if tx.IsMetadata() {
+			if e := bc.verifyMetadataTx(tx, unspent); e != nil {
+				return e
+			}
+		} else if tx.IsMultiTransfer() {
+			if e := tx.VerifyWithInputs(ins); e != nil {
+				return e
+			}
+		} else if unspent == nil && !isGen {
+			return fmt.Errorf("kitty %d: %w", tx.KittyID, ErrKittyNotFound)
+		} else if isGen && bc.state.IsGenerationFrozen() {
+			return ErrGenerationFrozen
+		} else if e := tx.VerifyWith(unspent, genPK); e != nil {
+			return e
+		}
+		if e := bc.checkFee(tx); e != nil {
+			return e
+		}
+		if !tx.IsMetadata() {
+			if e := bc.checkLocks(ins, seq, ts); e != nil {
+				return e
+			}
+		}
+		if e := bc.checkEscrow(tx, ts); e != nil {
 			return e
 		}
-		if tx.IsKittyGen(bc.c.GenerationPK) {
+		if e := bc.checkRoyalty(tx); e != nil {
+			return e
+		}
+		if tx.IsAuction() {
+			if e := bc.checkAuction(tx, ts); e != nil {
+				return e
+			}
+		}
+		if e := bc.runTxCheckers(tx); e != nil {
+			return e
+		}
+		if isGen {
 			bc.log.
 				WithField("kitty_id", tx.KittyID).
 				WithField("input", tx.In.Hex()).
@@ -193,6 +1392,77 @@ func MakeTxChecker(bc *BlockChain) TxChecker {
 			if e := bc.state.AddKitty(tx.Hash(), tx.KittyID, tx.Out); e != nil {
 				return e
 			}
+			if tx.Royalty != nil {
+				royalty := KittyRoyalty{Creator: tx.Out, Percent: tx.Royalty.Percent}
+				if e := bc.state.SetKittyRoyalty(tx.KittyID, royalty); e != nil {
+					return e
+				}
+			}
+			if tx.Fee > 0 {
+				return bc.state.AddFee(tx.Out, tx.Fee)
+			}
+		} else if tx.IsMetadata() {
+			bc.log.
+				WithField("kitty_id", tx.KittyID).
+				WithField("input", tx.In.Hex()).
+				Debug("processing metadata tx")
+
+			if e := bc.state.SetKittyMetadata(tx.Hash(), tx.KittyID, *tx.Metadata); e != nil {
+				return e
+			}
+			if tx.Fee > 0 {
+				return bc.state.AddFee(bc.metadataSetter(unspent), tx.Fee)
+			}
+		} else if tx.IsAuction() {
+			bc.log.
+				WithField("kitty_id", tx.KittyID).
+				WithField("action", tx.Auction.Action).
+				WithField("input", tx.In.Hex()).
+				WithField("output", tx.Out.String()).
+				Debug("processing auction tx")
+
+			// TEMPORARY: Only auctions of creators are allowed, as with
+			// transfer txs.
+			if !bc.IsCreator(tx.Auction.Listing.Seller) {
+				return errors.New("tx rejected")
+			}
+
+			signer, e := tx.SignerAddress()
+			if e != nil {
+				return e
+			}
+			switch tx.Auction.Action {
+			case AuctionList:
+				e = bc.state.ListKitty(tx.Hash(), tx.KittyID, tx.Auction.Listing)
+			case AuctionBid:
+				e = bc.state.BidKitty(tx.Hash(), tx.KittyID, signer, tx.Auction.Bid)
+			default:
+				e = bc.state.CloseAuction(tx.Hash(), tx.KittyID, tx.Out)
+			}
+			if e != nil {
+				return e
+			}
+			if tx.Fee > 0 {
+				return bc.state.AddFee(signer, tx.Fee)
+			}
+		} else if tx.IsBreed() {
+			bc.log.
+				WithField("kitty_ids", kittyIDs).
+				WithField("child_id", tx.Breed.ChildID).
+				WithField("output", tx.Out.String()).
+				Debug("processing breed tx")
+
+			child, e := bc.breedChild(tx, seq)
+			if e != nil {
+				return e
+			}
+			if e := bc.state.BreedKitties(tx.Hash(), kittyIDs, tx.Breed.ChildID,
+				tx.Out, child, seq+bc.c.BreedCooldown); e != nil {
+				return e
+			}
+			if tx.Fee > 0 {
+				return bc.state.AddFee(tx.Out, tx.Fee)
+			}
 		} else {
 			bc.log.
 				WithField("kitty_id", tx.KittyID).
@@ -200,14 +1470,30 @@ func MakeTxChecker(bc *BlockChain) TxChecker {
 				WithField("output", tx.Out.String()).
 				Debug("processing transfer tx")
 
-			// TEMPORARY: If tx is not signed from generation pk, disallow.
-			if unspent.Out != cipher.AddressFromPubKey(bc.c.GenerationPK) {
+			// TEMPORARY: If tx is not signed from a creator pk, disallow.
+			// Escrows opened by a creator may be settled by either party.
+			if !bc.IsCreator(unspent.Out) &&
+				(tx.Escrow == nil || !bc.IsCreator(tx.Escrow.Sender)) {
 				return errors.New("tx rejected")
 			}
 
-			if e := bc.state.MoveKitty(tx.Hash(), tx.KittyID, unspent.Out, tx.Out); e != nil {
+			if tx.IsBurn() {
+				if e := bc.state.BurnKitties(tx.Hash(), kittyIDs, unspent.Out); e != nil {
+					return e
+				}
+			} else if tx.IsMultiTransfer() {
+				if e := bc.state.MoveKitties(tx.Hash(), kittyIDs, unspent.Out, tx.Out); e != nil {
+					return e
+				}
+			} else if e := bc.state.MoveKitty(tx.Hash(), tx.KittyID, unspent.Out, tx.Out); e != nil {
+				return e
+			}
+			if e := bc.payRoyalties(tx); e != nil {
 				return e
 			}
+			if tx.Fee > 0 {
+				return bc.state.AddFee(unspent.Out, tx.Fee)
+			}
 		}
 		return nil
 	}
@@ -215,6 +1501,10 @@ func MakeTxChecker(bc *BlockChain) TxChecker {
 
 type PaginatedTransactions struct {
 	TotalPageCount uint64
+	CurrentPage    uint64
+	PerPage        uint64
+	HasNext        bool
+	HasPrev        bool
 	Transactions   []TxWrapper
 }
 
@@ -228,16 +1518,49 @@ func totalPageCount(len, pageSize uint64) uint64 {
 	}
 }
 
-func (bc *BlockChain) GetTransactionPage(currentPage, perPage uint64) (PaginatedTransactions, error) {
-	txWrappers, err := bc.chain.GetTxsOfSeqRange(
-		uint64(perPage*currentPage),
-		perPage)
-	if err != nil {
-		return PaginatedTransactions{}, err
+// GetTransactionPage obtains a page of the txs that match the filter, in
+// chain order. A nil filter matches all txs.
+func (bc *BlockChain) GetTransactionPage(currentPage, perPage uint64, filter *TxPageFilter) (PaginatedTransactions, error) {
+	if perPage == 0 {
+		return PaginatedTransactions{}, errors.New("'perPage' should be greater than zero")
+	}
+	if filter == nil {
+		filter = new(TxPageFilter)
+	}
+	switch filter.Order {
+	case "", TxOrderAscending, TxOrderDescending:
+	default:
+		return PaginatedTransactions{}, fmt.Errorf("invalid tx order '%s'", filter.Order)
+	}
+	return bc.getFilteredTxPage(currentPage, perPage, filter)
+}
+
+// GetTransactionsOfAddress obtains a page of the txs to and from the address,
+// in chain order. Txs are found with the tx index of the address state, rather
+// than by scanning the chain.
+func (bc *BlockChain) GetTransactionsOfAddress(address cipher.Address, currentPage, perPage uint64) (PaginatedTransactions, error) {
+	return bc.GetTransactionPage(currentPage, perPage, &TxPageFilter{Address: &address})
+}
+
+// GetKittyHistory obtains the txs of the kitty in chain order, from the tx
+// that generated the kitty to the latest. Txs are found with the tx index of
+// the kitty state.
+func (bc *BlockChain) GetKittyHistory(kittyID KittyID) ([]TxWrapper, error) {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	kState, ok := bc.state.GetKittyState(kittyID)
+	if !ok {
+		return nil, fmt.Errorf("kitty %d: %w", kittyID, ErrKittyNotFound)
+	}
+	out := make([]TxWrapper, len(kState.Transactions))
+	for i, txHash := range kState.Transactions {
+		txWrap, e := bc.txOfHash(txHash)
+		if e != nil {
+			return nil, fmt.Errorf("tx '%s' of kitty %d: %w",
+				txHash.Hex(), kittyID, e)
+		}
+		out[i] = txWrap
 	}
-	cLen := bc.chain.Len()
-	return PaginatedTransactions{
-		TotalPageCount: totalPageCount(cLen, perPage),
-		Transactions:   txWrappers,
-	}, nil
+	return out, nil
 }
@@ -1,11 +1,64 @@
 package iko
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/sirupsen/logrus.v1"
 )
 
+func newTestBlockChain(t *testing.T, config *BlockChainConfig) (*BlockChain, func()) {
+	chainDB, err := newCXOChainDB("", true, true, "", nil)
+	require.NoError(t, err,
+		"master root should init with no problem")
+
+	bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+	require.NoError(t, err,
+		"blockchain should be created with no problem")
+
+	return bc, func() {
+		bc.Close()
+		chainDB.Close()
+	}
+}
+
+// newTestBoltBlockChain creates a blockchain of a bolt chain db, which (unlike
+// the cxo chain db) stores the optional fields of txs.
+func newTestBoltBlockChain(t *testing.T, config *BlockChainConfig) (*BlockChain, func()) {
+	path, rmTemp := tempBoltPath(t)
+	chainDB := newBoltChainDB(t, path)
+
+	bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+	require.NoError(t, err,
+		"blockchain should be created with no problem")
+
+	return bc, func() {
+		bc.Close()
+		chainDB.Close()
+		rmTemp()
+	}
+}
+
+func injectGenTxs(t *testing.T, bc *BlockChain, count int) []TxWrapper {
+	out := make([]TxWrapper, count)
+	for i := range out {
+		tx := NewGenTx(KittyID(i), GenSK)
+		meta, err := bc.InjectTx(tx)
+		require.NoErrorf(t, err,
+			"injecting generation tx %d should succeed", i)
+		out[i] = TxWrapper{Tx: *tx, Meta: *meta}
+	}
+	return out
+}
+
 func TestTotalPageCount(t *testing.T) {
 	require.Equal(t, totalPageCount(1, 2), uint64(1),
 		"One item, two items per page, equals one page")
@@ -16,3 +69,877 @@ func TestTotalPageCount(t *testing.T) {
 	require.Equal(t, totalPageCount(4, 2), uint64(2),
 		"Four items, two items per page, equals two pages")
 }
+
+func TestBlockChain_Compact(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	dir, rmDir := tempLevelDir(t)
+	defer rmDir()
+
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	bc, err := NewBlockChain(context.Background(), &BlockChainConfig{
+		GenerationPK:     GenPK,
+		SnapshotInterval: 100,
+		SnapshotDir:      dir,
+		SnapshotKeep:     1,
+		ChainMode:        PrunedChainMode,
+		PruneRetention:   10,
+	}, chainDB, NewMemoryState())
+	require.NoError(t, err)
+	defer bc.Close()
+
+	// Every kitty is transferred, so that the generation txs are pruned.
+	const count = 500
+	var (
+		gens      = injectGenTxs(t, bc, count)
+		transfers = make([]TxWrapper, count)
+		pk, _     = cipher.GenerateKeyPair()
+	)
+	for i, gen := range gens {
+		transfer, err := NewTransferTx(&gen.Tx, cipher.AddressFromPubKey(pk), GenSK)
+		require.NoError(t, err)
+		meta, err := bc.InjectTx(transfer)
+		require.NoError(t, err)
+		transfers[i] = TxWrapper{Tx: *transfer, Meta: *meta}
+	}
+	_, err = bc.GetTxOfSeq(gens[1].Meta.Seq)
+	require.Error(t, err, "spent generation txs should be pruned")
+
+	before, err := bc.DiskUsage()
+	require.NoError(t, err)
+	require.NoError(t, bc.Compact(context.Background()),
+		"compaction should succeed")
+	after, err := bc.DiskUsage()
+	require.NoError(t, err)
+	require.True(t, after < before,
+		"compacted chain (%d bytes) should be smaller than pruned chain (%d bytes)",
+		after, before)
+
+	for _, txWrap := range append(gens[:1], transfers...) {
+		reqTxWrap, err := bc.GetTxOfHash(txWrap.Tx.Hash())
+		require.NoError(t, err,
+			"kept transactions should still be retrievable after compaction")
+		require.Equal(t, txWrap, reqTxWrap,
+			"Should correctly return the right transaction")
+	}
+
+	_, err = bc.InjectTx(NewGenTx(count, GenSK))
+	require.NoError(t, err,
+		"injection should still work after compaction")
+}
+
+func TestBlockChain_MaxSequence(t *testing.T) {
+	const maxSeq = 3
+
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		MaxSequence:  maxSeq,
+	})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, maxSeq)
+
+	t.Run("InjectTx_ChainFull", func(t *testing.T) {
+		_, err := bc.InjectTx(NewGenTx(KittyID(maxSeq), GenSK))
+		require.Equal(t, ErrChainFull, err,
+			"injecting past the max sequence should fail")
+	})
+
+	t.Run("Reads_AfterChainFull", func(t *testing.T) {
+		head, err := bc.GetHeadTx()
+		require.NoError(t, err,
+			"should still be able to obtain head tx")
+		require.Equal(t, txWraps[maxSeq-1], head,
+			"head tx should be the last injected tx")
+
+		for _, txWrap := range txWraps {
+			reqTxWrap, err := bc.GetTxOfSeq(txWrap.Meta.Seq)
+			require.NoError(t, err,
+				"should still be able to obtain tx of seq")
+			require.Equal(t, txWrap, reqTxWrap,
+				"Should correctly return the right transaction")
+		}
+
+		kState, ok := bc.GetKittyState(KittyID(0))
+		require.True(t, ok,
+			"should still be able to obtain kitty state")
+		require.Equal(t, cipher.AddressFromPubKey(GenPK), kState.Address,
+			"kitty should be owned by the generation address")
+	})
+}
+
+func TestBlockChain_CreatorAddress(t *testing.T) {
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	expAddr := cipher.AddressFromPubKey(GenPK)
+
+	require.Equal(t, expAddr, bc.CreatorAddress(),
+		"creator address should be derived from the generation public key")
+	require.Equal(t, bc.CreatorAddress(), bc.CreatorAddress(),
+		"creator address should be stable across calls")
+	require.NotNil(t, bc.creatorAddr, "creator address should be cached")
+	require.Equal(t, []cipher.Address{expAddr}, bc.CreatorAddresses(),
+		"creator addresses should contain the single creator address")
+}
+
+func TestBlockChain_GetTransactionPage(t *testing.T) {
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	injectGenTxs(t, bc, 5)
+
+	cases := []struct {
+		Name        string
+		CurrentPage uint64
+		PerPage     uint64
+		PageCount   uint64
+		TxCount     int
+		HasNext     bool
+		HasPrev     bool
+	}{
+		{"FirstPage", 0, 2, 3, 2, true, false},
+		{"MiddlePage", 1, 2, 3, 2, true, true},
+		{"LastPage", 2, 2, 3, 1, false, true},
+		{"SinglePage", 0, 10, 1, 5, false, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			page, err := bc.GetTransactionPage(c.CurrentPage, c.PerPage, nil)
+			require.NoError(t, err,
+				"should obtain page with no error")
+			require.Equal(t, c.PageCount, page.TotalPageCount,
+				"total page count should match")
+			require.Equal(t, c.CurrentPage, page.CurrentPage,
+				"current page should match")
+			require.Equal(t, c.PerPage, page.PerPage,
+				"per page should match")
+			require.Len(t, page.Transactions, c.TxCount,
+				"transaction count should match")
+			require.Equal(t, c.HasNext, page.HasNext,
+				"has next should match")
+			require.Equal(t, c.HasPrev, page.HasPrev,
+				"has prev should match")
+		})
+	}
+}
+
+func TestBlockChain_GetTransactionPage_Filter(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 4)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+	for _, i := range []int{0, 2} {
+		tx, err := NewTransferTx(&txWraps[i].Tx, addr, GenSK)
+		require.NoError(t, err)
+		meta, err := bc.InjectTx(tx)
+		require.NoError(t, err)
+		txWraps = append(txWraps, TxWrapper{Tx: *tx, Meta: *meta})
+	}
+	kitty2 := KittyID(2)
+
+	seqsOf := func(page PaginatedTransactions) []uint64 {
+		var out []uint64
+		for _, txWrap := range page.Transactions {
+			out = append(out, txWrap.Meta.Seq)
+		}
+		return out
+	}
+
+	cases := []struct {
+		Name   string
+		Filter *TxPageFilter
+		Seqs   []uint64
+	}{
+		{"Address", &TxPageFilter{Address: &addr}, []uint64{4, 5}},
+		{"Kitty", &TxPageFilter{KittyID: &kitty2}, []uint64{2, 5}},
+		{"AddressAndKitty", &TxPageFilter{Address: &addr, KittyID: &kitty2}, []uint64{5}},
+		{"SeqRange", &TxPageFilter{SeqStart: 1, SeqEnd: 4}, []uint64{1, 2, 3}},
+		{"KittyAndSeqRange", &TxPageFilter{KittyID: &kitty2, SeqStart: 3}, []uint64{5}},
+		{"TSRange", &TxPageFilter{TSStart: txWraps[2].Meta.TS, TSEnd: txWraps[4].Meta.TS}, []uint64{2, 3}},
+		{"Empty", &TxPageFilter{SeqStart: 10}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			page, err := bc.GetTransactionPage(0, 10, c.Filter)
+			require.NoError(t, err)
+			require.Equal(t, c.Seqs, seqsOf(page))
+		})
+	}
+
+	t.Run("Paginated", func(t *testing.T) {
+		filter := &TxPageFilter{SeqStart: 1}
+		page, err := bc.GetTransactionPage(1, 2, filter)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{3, 4}, seqsOf(page))
+		require.Equal(t, uint64(3), page.TotalPageCount)
+		require.True(t, page.HasNext)
+
+		filter = &TxPageFilter{TSStart: txWraps[1].Meta.TS}
+		page, err = bc.GetTransactionPage(2, 2, filter)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{5}, seqsOf(page))
+		require.Equal(t, uint64(3), page.TotalPageCount)
+		require.False(t, page.HasNext)
+	})
+
+	t.Run("Descending", func(t *testing.T) {
+		cases := []struct {
+			Name   string
+			Page   uint64
+			Filter TxPageFilter
+			Seqs   []uint64
+		}{
+			{"FirstPage", 0, TxPageFilter{}, []uint64{5, 4, 3, 2}},
+			{"LastPartialPage", 1, TxPageFilter{}, []uint64{1, 0}},
+			{"BeyondLastPage", 2, TxPageFilter{}, nil},
+			{"Address", 0, TxPageFilter{Address: &addr}, []uint64{5, 4}},
+			{"SeqRange", 1, TxPageFilter{SeqStart: 1, SeqEnd: 6}, []uint64{1}},
+			{"KittyAndSeqRange", 0, TxPageFilter{KittyID: &kitty2, SeqEnd: 5}, []uint64{2}},
+			{"TSRange", 0, TxPageFilter{TSStart: txWraps[1].Meta.TS}, []uint64{5, 4, 3, 2}},
+			{"TSRangeLastPartialPage", 1, TxPageFilter{TSStart: txWraps[1].Meta.TS}, []uint64{1}},
+		}
+		for _, c := range cases {
+			t.Run(c.Name, func(t *testing.T) {
+				c.Filter.Order = TxOrderDescending
+				page, err := bc.GetTransactionPage(c.Page, 4, &c.Filter)
+				require.NoError(t, err)
+				require.Equal(t, c.Seqs, seqsOf(page))
+			})
+		}
+	})
+
+	_, err := bc.GetTransactionPage(0, 0, nil)
+	require.Error(t, err)
+	_, err = bc.GetTransactionPage(0, 1, &TxPageFilter{Order: "random"})
+	require.Error(t, err)
+}
+
+func TestBlockChain_GetTransactionsOfAddress(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 5)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+		sent    []TxHash
+	)
+	for _, i := range []int{1, 3, 4} {
+		tx, err := NewTransferTx(&txWraps[i].Tx, addr, GenSK)
+		require.NoError(t, err)
+		_, err = bc.InjectTx(tx)
+		require.NoError(t, err)
+		sent = append(sent, tx.Hash())
+	}
+
+	page, err := bc.GetTransactionsOfAddress(addr, 0, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), page.TotalPageCount)
+	require.True(t, page.HasNext)
+	require.False(t, page.HasPrev)
+	require.Len(t, page.Transactions, 2)
+	require.Equal(t, sent[0], page.Transactions[0].Tx.Hash())
+	require.Equal(t, sent[1], page.Transactions[1].Tx.Hash())
+
+	page, err = bc.GetTransactionsOfAddress(addr, 1, 2)
+	require.NoError(t, err)
+	require.False(t, page.HasNext)
+	require.True(t, page.HasPrev)
+	require.Len(t, page.Transactions, 1)
+	require.Equal(t, sent[2], page.Transactions[0].Tx.Hash())
+	require.Equal(t, uint64(7), page.Transactions[0].Meta.Seq)
+
+	page, err = bc.GetTransactionsOfAddress(addr, 2, 2)
+	require.NoError(t, err)
+	require.Empty(t, page.Transactions, "page beyond the last should be empty")
+
+	page, err = bc.GetTransactionsOfAddress(bc.CreatorAddress(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, page.Transactions, 8, "creator should have generation and sent txs")
+
+	pk, _ = cipher.GenerateKeyPair()
+	page, err = bc.GetTransactionsOfAddress(cipher.AddressFromPubKey(pk), 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, page.Transactions)
+	require.Zero(t, page.TotalPageCount)
+
+	_, err = bc.GetTransactionsOfAddress(addr, 0, 0)
+	require.Error(t, err)
+}
+
+func TestBlockChain_GetAddressStates(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 3)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+		pk2, _  = cipher.GenerateKeyPair()
+		empty   = cipher.AddressFromPubKey(pk2)
+	)
+	tx, err := NewTransferTx(&txWraps[1].Tx, addr, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(tx)
+	require.NoError(t, err)
+
+	aStates := bc.GetAddressStates([]cipher.Address{bc.CreatorAddress(), addr, empty})
+	require.Len(t, aStates, 3)
+	require.Equal(t, KittyIDs{0, 2}, aStates[bc.CreatorAddress()].Kitties)
+	require.Equal(t, KittyIDs{1}, aStates[addr].Kitties)
+	require.Equal(t, TxHashes{tx.Hash()}, aStates[addr].Transactions)
+	require.Empty(t, aStates[empty].Kitties, "address without txs should have an empty state")
+}
+
+func TestBlockChain_GetKittyStates(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 3)
+
+	kStates := bc.GetKittyStates([]KittyID{2, 0, 7})
+	require.Len(t, kStates, 2, "missing kitties should be omitted")
+	for _, kittyID := range []KittyID{0, 2} {
+		require.Contains(t, kStates, kittyID)
+		require.Equal(t, bc.CreatorAddress(), kStates[kittyID].Address)
+		require.Equal(t, TxHashes{txWraps[kittyID].Tx.Hash()}, kStates[kittyID].Transactions)
+	}
+	require.Empty(t, bc.GetKittyStates(nil))
+}
+
+func TestBlockChain_GetKittyHistory(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 2)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+	tx, err := NewTransferTx(&txWraps[1].Tx, addr, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(tx)
+	require.NoError(t, err)
+
+	history, err := bc.GetKittyHistory(1)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, txWraps[1].Tx.Hash(), history[0].Tx.Hash(), "first tx should generate the kitty")
+	require.Equal(t, tx.Hash(), history[1].Tx.Hash())
+	require.Equal(t, uint64(2), history[1].Meta.Seq)
+
+	history, err = bc.GetKittyHistory(0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	_, err = bc.GetKittyHistory(5)
+	require.True(t, errors.Is(err, ErrKittyNotFound))
+}
+
+func TestBlockChain_Genesis(t *testing.T) {
+	t.Run("CreatorMismatch", func(t *testing.T) {
+		chainDB, err := newCXOChainDB("", true, true, "", nil)
+		require.NoError(t, err,
+			"master root should init with no problem")
+		defer chainDB.Close()
+
+		_, otherSK := cipher.GenerateDeterministicKeyPair([]byte("other gen seed"))
+		err = chainDB.AddTx(TxWrapper{
+			Tx:   *NewGenTx(KittyID(0), otherSK),
+			Meta: genTxMeta(0),
+		}, addTxAlwaysApprove)
+		require.NoError(t, err,
+			"should add genesis tx of other creator")
+
+		_, err = NewBlockChain(context.Background(), &BlockChainConfig{GenerationPK: GenPK},
+			chainDB, NewMemoryState())
+		require.Equal(t, ErrCreatorMismatch, err,
+			"creator mismatch should be detected on construction")
+	})
+
+	t.Run("ReplayIncludesGenesis", func(t *testing.T) {
+		chainDB, err := newCXOChainDB("", true, true, "", nil)
+		require.NoError(t, err,
+			"master root should init with no problem")
+		defer chainDB.Close()
+
+		config := &BlockChainConfig{GenerationPK: GenPK}
+
+		bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err,
+			"blockchain should be created with no problem")
+		injectGenTxs(t, bc, 3)
+		bc.Close()
+
+		bc, err = NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err,
+			"blockchain should be re-created from existing chain")
+		defer bc.Close()
+
+		for i := 0; i < 3; i++ {
+			_, ok := bc.GetKittyState(KittyID(i))
+			require.Truef(t, ok,
+				"kitty %d should exist in replayed state", i)
+		}
+	})
+}
+
+func TestBlockChain_ProgressFn(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	bc, err := NewBlockChain(context.Background(), &BlockChainConfig{GenerationPK: GenPK}, chainDB, NewMemoryState())
+	require.NoError(t, err)
+	injectGenTxs(t, bc, 3)
+	bc.Close()
+
+	var progress [][2]uint64
+	bc, err = NewBlockChain(context.Background(), &BlockChainConfig{
+		GenerationPK: GenPK,
+		ProgressFn: func(done, total uint64) {
+			progress = append(progress, [2]uint64{done, total})
+		},
+	}, chainDB, NewMemoryState())
+	require.NoError(t, err)
+	defer bc.Close()
+
+	require.Equal(t, [][2]uint64{{0, 3}, {1, 3}, {2, 3}, {3, 3}}, progress,
+		"progress should be reported before the first tx and after each tx")
+}
+
+func TestBlockChain_Close(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	var actions int
+	config := &BlockChainConfig{
+		GenerationPK: GenPK,
+		TxAction: func(tx *Transaction) error {
+			actions++
+			return nil
+		},
+	}
+	bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+	require.NoError(t, err)
+	injectGenTxs(t, bc, 3)
+	bc.Close()
+	require.Equal(t, 3, actions, "accepted txs should be processed before close returns")
+	bc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewBlockChain(ctx, &BlockChainConfig{GenerationPK: GenPK}, chainDB, NewMemoryState())
+	require.Equal(t, context.Canceled, err, "cancelled replay should fail construction")
+}
+
+func TestBlockChain_Logger(t *testing.T) {
+	require.Error(t, (&BlockChainConfig{GenerationPK: GenPK, LogLevel: "loud"}).Prepare(),
+		"invalid log level should be rejected")
+
+	config := &BlockChainConfig{GenerationPK: GenPK, LogLevel: "error"}
+	require.NoError(t, config.Prepare())
+	require.Equal(t, logrus.ErrorLevel, config.Logger.Level)
+
+	var buf bytes.Buffer
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		Logger: &logrus.Logger{
+			Out:       &buf,
+			Formatter: new(logrus.TextFormatter),
+			Hooks:     make(logrus.LevelHooks),
+			Level:     logrus.InfoLevel,
+		},
+	})
+	closeBC()
+	require.Equal(t, bc.c.Logger, bc.log)
+	require.Contains(t, buf.String(), "closing blockchain manager",
+		"logs should be written to the injected logger")
+}
+
+func TestBlockChain_TxCheckers(t *testing.T) {
+	var (
+		errBlacklisted = errors.New("kitty is blacklisted")
+		checked        []KittyID
+	)
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		TxCheckers: []TxChecker{
+			func(tx *Transaction) error {
+				checked = append(checked, tx.KittyID)
+				return nil
+			},
+			func(tx *Transaction) error {
+				if tx.KittyID == 1 {
+					return errBlacklisted
+				}
+				return nil
+			},
+		},
+	})
+	defer closeBC()
+
+	injectGenTxs(t, bc, 1)
+	_, err := bc.InjectTx(NewGenTx(1, GenSK))
+	require.Equal(t, errBlacklisted, err)
+	_, ok := bc.GetKittyState(1)
+	require.False(t, ok, "rejected tx should not be applied to the state")
+
+	_, sk := cipher.GenerateKeyPair()
+	_, err = bc.InjectTx(NewGenTx(2, sk))
+	require.Error(t, err)
+	require.Equal(t, []KittyID{0, 1}, checked,
+		"checkers should only run after the built-in checks pass")
+
+	checked = nil
+	bc.state = NewMemoryState()
+	require.NoError(t, bc.InitState())
+	require.Equal(t, []KittyID{0}, checked, "checkers should run on replay")
+}
+
+func TestBlockChain_StateSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kc_blockchain_test")
+	require.NoError(t, err, "creation of temp dir should succeed")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.snapshot")
+
+	chainDB, err := newCXOChainDB("", true, true, "", nil)
+	require.NoError(t, err,
+		"master root should init with no problem")
+	defer chainDB.Close()
+
+	config := &BlockChainConfig{GenerationPK: GenPK}
+
+	bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+	require.NoError(t, err,
+		"blockchain should be created with no problem")
+	injectGenTxs(t, bc, 5)
+
+	require.NoError(t, bc.SnapshotState(path),
+		"snapshotting state should succeed")
+
+	for i := 5; i < 8; i++ {
+		_, err := bc.InjectTx(NewGenTx(KittyID(i), GenSK))
+		require.NoError(t, err, "injection should succeed")
+	}
+	bc.Close()
+
+	t.Run("RestoreState", func(t *testing.T) {
+		bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err,
+			"blockchain should be created with no problem")
+		defer bc.Close()
+
+		require.NoError(t, bc.RestoreState(path),
+			"restoring state should succeed")
+
+		for i := 0; i < 8; i++ {
+			_, ok := bc.GetKittyState(KittyID(i))
+			require.Truef(t, ok,
+				"kitty %d should exist in restored state", i)
+		}
+	})
+
+	t.Run("StateSnapshotPath", func(t *testing.T) {
+		bc, err := NewBlockChain(context.Background(), &BlockChainConfig{
+			GenerationPK:      GenPK,
+			StateSnapshotPath: path,
+		}, chainDB, NewMemoryState())
+		require.NoError(t, err,
+			"blockchain should be created from snapshot")
+
+		for i := 0; i < 8; i++ {
+			_, ok := bc.GetKittyState(KittyID(i))
+			require.Truef(t, ok,
+				"kitty %d should exist in restored state", i)
+		}
+		bc.Close()
+
+		snapshot, err := LoadStateSnapshot(path)
+		require.NoError(t, err, "snapshot should be saved on close")
+		require.Equal(t, uint64(8), snapshot.Height,
+			"saved snapshot should be of current height")
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		snapshot, err := LoadStateSnapshot(path)
+		require.NoError(t, err, "loading snapshot should succeed")
+
+		snapshot.HeadHash = EmptyTxHash()
+		badPath := filepath.Join(dir, "bad.snapshot")
+		require.NoError(t, SaveStateSnapshot(badPath, snapshot))
+
+		bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err,
+			"blockchain should be created with no problem")
+		defer bc.Close()
+
+		require.Equal(t, ErrSnapshotMismatch, bc.RestoreState(badPath),
+			"snapshot of another chain should be rejected")
+	})
+}
+
+func TestBlockChain_SubscribeTxs(t *testing.T) {
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	txs, unsubscribe := bc.SubscribeTxs(10)
+
+	for i := 0; i < 3; i++ {
+		tx := NewGenTx(KittyID(i), GenSK)
+		_, err := bc.InjectTx(tx)
+		require.NoError(t, err, "injection should succeed")
+
+		select {
+		case txWrap := <-txs:
+			require.Equal(t, *tx, txWrap.Tx,
+				"subscriber should receive injected tx")
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for tx")
+		}
+	}
+
+	unsubscribe()
+	_, ok := <-txs
+	require.False(t, ok, "channel should be closed on unsubscribe")
+
+	// Unsubscribing twice should not panic.
+	unsubscribe()
+}
+
+func TestBlockChain_InjectTxs(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pk)
+
+	run := func(t *testing.T, chainDB ChainDB) {
+		bc, err := NewBlockChain(context.Background(), &BlockChainConfig{
+			GenerationPK: GenPK,
+			MaxSequence:  4,
+		}, chainDB, NewMemoryState())
+		require.NoError(t, err)
+		defer bc.Close()
+
+		var (
+			gen0 = NewGenTx(0, GenSK)
+			gen1 = NewGenTx(1, GenSK)
+		)
+		transfer, err := NewTransferTx(gen0, addr, GenSK)
+		require.NoError(t, err)
+
+		errs, err := bc.InjectTxs([]Transaction{
+			*gen0, *NewGenTx(0, GenSK), *transfer, *gen1, *NewGenTx(2, GenSK), *NewGenTx(3, GenSK),
+		})
+		require.NoError(t, err)
+		require.Len(t, errs, 6)
+		require.NoError(t, errs[0])
+		require.Error(t, errs[1], "generation of existing kitty should fail")
+		require.NoError(t, errs[2], "transfer of tx in batch should succeed")
+		require.NoError(t, errs[3])
+		require.NoError(t, errs[4])
+		require.Equal(t, ErrChainFull, errs[5])
+
+		require.Equal(t, uint64(4), chainDB.Len())
+		for i, hash := range []TxHash{gen0.Hash(), transfer.Hash(), gen1.Hash()} {
+			txWrap, err := bc.GetTxOfHash(hash)
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), txWrap.Meta.Seq,
+				"failed txs should not take up a sequence")
+		}
+		kState, ok := bc.GetKittyState(0)
+		require.True(t, ok)
+		require.Equal(t, addr, kState.Address)
+	}
+
+	t.Run("CXOChain", func(t *testing.T) {
+		chainDB, err := newCXOChainDB("", true, true, "", nil)
+		require.NoError(t, err)
+		defer chainDB.Close()
+		run(t, chainDB)
+	})
+
+	t.Run("BoltChain", func(t *testing.T) {
+		path, rmTemp := tempBoltPath(t)
+		defer rmTemp()
+
+		chainDB := newBoltChainDB(t, path)
+		defer chainDB.Close()
+		run(t, chainDB)
+	})
+}
+
+func TestBlockChain_CheckTx(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	pk, _ := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pk)
+
+	gen0 := NewGenTx(0, GenSK)
+	require.NoError(t, bc.CheckTx(gen0))
+	_, ok := bc.GetKittyState(0)
+	require.False(t, ok, "checked txs should not change the state")
+	require.Equal(t, uint64(0), bc.chain.Len())
+
+	_, err := bc.InjectTx(gen0)
+	require.NoError(t, err)
+	require.Error(t, bc.CheckTx(NewGenTx(0, GenSK)), "generation of existing kitty should fail")
+
+	transfer, err := NewTransferTx(gen0, addr, GenSK)
+	require.NoError(t, err)
+	require.NoError(t, bc.CheckTx(transfer))
+	kState, ok := bc.GetKittyState(0)
+	require.True(t, ok)
+	require.Equal(t, bc.CreatorAddress(), kState.Address,
+		"checked txs should not change the state")
+
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err, "checked txs should still be injectable")
+	require.Error(t, bc.CheckTx(transfer), "spent txs should fail")
+
+	bc.Close()
+	require.Equal(t, ErrChainStopped, bc.CheckTx(NewGenTx(1, GenSK)))
+}
+
+func TestBlockChain_MultiTransfer(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 3)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+	tx, err := NewMultiTransferTx(
+		[]*Transaction{&txWraps[0].Tx, &txWraps[2].Tx}, KittyIDs{0, 2}, addr, GenSK)
+	require.NoError(t, err)
+
+	require.NoError(t, bc.SubmitTx(tx), "multi-kitty transfer should be accepted")
+	double, err := NewTransferTx(&txWraps[2].Tx, addr, GenSK)
+	require.NoError(t, err)
+	require.Error(t, bc.SubmitTx(double),
+		"transfer of a kitty of a pending multi-kitty transfer should be rejected")
+	require.Equal(t, 1, bc.CommitPending())
+
+	for _, kittyID := range []KittyID{0, 2} {
+		kState, ok := bc.GetKittyState(kittyID)
+		require.True(t, ok)
+		require.Equal(t, addr, kState.Address)
+		require.Equal(t, tx.Hash(), kState.Transactions[len(kState.Transactions)-1])
+	}
+	aState := bc.GetAddressState(addr)
+	require.Equal(t, KittyIDs{0, 2}, aState.Kitties)
+	require.Equal(t, TxHashes{tx.Hash()}, aState.Transactions)
+	require.Equal(t, KittyIDs{1}, bc.GetAddressState(bc.CreatorAddress()).Kitties)
+
+	_, err = bc.InjectTx(tx)
+	require.Error(t, err, "multi-kitty transfer should not be replayed")
+}
+
+func TestBlockChain_FeePolicy(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		FeePolicy:    FlatFeePolicy(5),
+	})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 3)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+	newTransfer := func(fee uint64) *Transaction {
+		tx := NewUnsignedTransferTx(&txWraps[0].Tx, addr)
+		tx.Fee = fee
+		tx.Sig = tx.Sign(GenSK)
+		return tx
+	}
+	require.Equal(t, uint64(0), bc.RequiredFee(NewGenTx(3, GenSK)),
+		"generation txs should not require fees")
+	require.Equal(t, uint64(5), bc.RequiredFee(newTransfer(0)))
+
+	_, err := bc.InjectTx(newTransfer(4))
+	require.Equal(t, ErrInsufficientFee, err)
+	require.Equal(t, ErrInsufficientFee, bc.SubmitTx(newTransfer(0)))
+
+	_, err = bc.InjectTx(newTransfer(6))
+	require.NoError(t, err)
+
+	multi, err := NewUnsignedMultiTransferTx(
+		[]*Transaction{&txWraps[1].Tx, &txWraps[2].Tx}, KittyIDs{1, 2}, addr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), bc.RequiredFee(multi),
+		"fee should be required per kitty")
+	multi.Fee = 10
+	multi.Sig = multi.Sign(GenSK)
+	_, err = bc.InjectTx(multi)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(16), bc.GetAddressState(bc.CreatorAddress()).FeesPaid,
+		"fees should be accounted to the sender")
+	require.Equal(t, uint64(0), bc.GetAddressState(addr).FeesPaid)
+}
+
+func TestBlockChain_TimeLock(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		pk, _ = cipher.GenerateKeyPair()
+		addr  = cipher.AddressFromPubKey(pk)
+	)
+	// lockedGen generates a kitty that is locked under the creator.
+	lockedGen := func(kittyID KittyID, lock TxLock) *Transaction {
+		tx := NewGenTx(kittyID, GenSK)
+		tx.Lock = lock
+		tx.Sig = tx.Sign(GenSK)
+		_, err := bc.InjectTx(tx)
+		require.NoError(t, err)
+		return tx
+	}
+
+	t.Run("Seq", func(t *testing.T) {
+		gen := lockedGen(0, TxLock{Seq: 2})
+		transfer, err := NewTransferTx(gen, addr, GenSK)
+		require.NoError(t, err)
+
+		_, err = bc.InjectTx(transfer)
+		require.Equal(t, ErrKittyLocked, err)
+		require.Equal(t, ErrKittyLocked, bc.SubmitTx(transfer))
+
+		_, err = bc.InjectTx(NewGenTx(1, GenSK))
+		require.NoError(t, err)
+		_, err = bc.InjectTx(transfer)
+		require.NoError(t, err, "lock should expire at it's seq")
+	})
+
+	t.Run("Timestamp", func(t *testing.T) {
+		gen := lockedGen(2, TxLock{TS: time.Now().Add(time.Hour).UnixNano()})
+		transfer, err := NewTransferTx(gen, addr, GenSK)
+		require.NoError(t, err)
+		_, err = bc.InjectTx(transfer)
+		require.Equal(t, ErrKittyLocked, err)
+
+		kState, ok := bc.GetKittyState(2)
+		require.True(t, ok)
+		require.Equal(t, bc.CreatorAddress(), kState.Address,
+			"receiver should own the locked kitty")
+
+		gen = lockedGen(3, TxLock{TS: time.Now().Add(-time.Hour).UnixNano()})
+		transfer, err = NewTransferTx(gen, addr, GenSK)
+		require.NoError(t, err)
+		_, err = bc.InjectTx(transfer)
+		require.NoError(t, err, "lock should expire at it's timestamp")
+	})
+}
@@ -0,0 +1,130 @@
+package iko
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+const (
+	// MaxBloomFilterSize is the maximum size of a bloom filter, in bytes.
+	MaxBloomFilterSize = 36000
+
+	// MaxBloomFilterHashes is the maximum number of hash functions of a bloom
+	// filter.
+	MaxBloomFilterHashes = 50
+)
+
+var (
+	// ErrInvalidBloomFilter occurs when a bloom filter is empty, or exceeds
+	// the maximum size or number of hash functions.
+	ErrInvalidBloomFilter = errors.New("invalid bloom filter")
+)
+
+// BloomFilter is a probabilistic set of addresses, with which a light wallet
+// can watch addresses without revealing exactly which. It may contain
+// addresses that were not added, with the false positive rate it was created
+// with, but never misses an address that was added.
+type BloomFilter struct {
+	Bits   []byte
+	Hashes uint32 // Number of hash functions.
+	Tweak  uint32 // Varies the hash functions, so that false positives differ between filters.
+}
+
+// NewBloomFilter creates an empty bloom filter sized for 'n' addresses, with
+// a false positive rate of 'fpRate'. The size and number of hash functions are
+// limited to 'MaxBloomFilterSize' and 'MaxBloomFilterHashes'.
+func NewBloomFilter(n int, fpRate float64, tweak uint32) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.0001
+	}
+	bits := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	size := int(math.Min(math.Ceil(bits/8), MaxBloomFilterSize))
+	hashes := uint32(math.Min(
+		math.Max(math.Round(float64(size*8)/float64(n)*math.Ln2), 1),
+		MaxBloomFilterHashes))
+	return &BloomFilter{
+		Bits:   make([]byte, size),
+		Hashes: hashes,
+		Tweak:  tweak,
+	}
+}
+
+// DeserializeBloomFilter decodes and verifies a bloom filter encoded with
+// 'Serialize'.
+func DeserializeBloomFilter(raw []byte) (*BloomFilter, error) {
+	f := new(BloomFilter)
+	if e := encoder.DeserializeRaw(raw, f); e != nil {
+		return nil, e
+	}
+	if e := f.Verify(); e != nil {
+		return nil, e
+	}
+	return f, nil
+}
+
+func (f BloomFilter) Serialize() []byte {
+	return encoder.Serialize(f)
+}
+
+// Verify checks that the filter is not empty, and within the maximum size and
+// number of hash functions.
+func (f *BloomFilter) Verify() error {
+	switch {
+	case len(f.Bits) == 0:
+		return fmt.Errorf("%w: no bits", ErrInvalidBloomFilter)
+	case len(f.Bits) > MaxBloomFilterSize:
+		return fmt.Errorf("%w: size of %d bytes exceeds maximum of %d bytes",
+			ErrInvalidBloomFilter, len(f.Bits), MaxBloomFilterSize)
+	case f.Hashes == 0 || f.Hashes > MaxBloomFilterHashes:
+		return fmt.Errorf("%w: %d hash functions, expected 1 to %d",
+			ErrInvalidBloomFilter, f.Hashes, MaxBloomFilterHashes)
+	default:
+		return nil
+	}
+}
+
+// Add adds the address to the filter.
+func (f *BloomFilter) Add(address cipher.Address) {
+	f.eachBit(address, func(i uint64) bool {
+		f.Bits[i/8] |= 1 << (i % 8)
+		return true
+	})
+}
+
+// MayContain returns true if the address may have been added to the filter.
+func (f *BloomFilter) MayContain(address cipher.Address) bool {
+	if len(f.Bits) == 0 {
+		return false
+	}
+	return f.eachBit(address, func(i uint64) bool {
+		return f.Bits[i/8]&(1<<(i%8)) != 0
+	})
+}
+
+// eachBit calls 'fn' with the index of each bit of the address, stopping if
+// 'fn' returns false. The indexes are obtained by double hashing a SHA256 of
+// the tweak and the address. It returns false if 'fn' returned false.
+func (f *BloomFilter) eachBit(address cipher.Address, fn func(i uint64) bool) bool {
+	var tweak [4]byte
+	binary.LittleEndian.PutUint32(tweak[:], f.Tweak)
+	h := cipher.SumSHA256(append(tweak[:], address.Bytes()...))
+	var (
+		h1 = binary.LittleEndian.Uint64(h[0:8])
+		h2 = binary.LittleEndian.Uint64(h[8:16]) | 1
+		m  = uint64(len(f.Bits)) * 8
+	)
+	for i := uint64(0); i < uint64(f.Hashes); i++ {
+		if !fn((h1 + i*h2) % m) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package iko
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter(t *testing.T) {
+	var (
+		f      = NewBloomFilter(100, 0.001, 7)
+		added  = make([]cipher.Address, 100)
+		others = make([]cipher.Address, 10000)
+	)
+	require.NoError(t, f.Verify())
+	for i := range added {
+		pk, _ := cipher.GenerateDeterministicKeyPair([]byte{byte(i), 1})
+		added[i] = cipher.AddressFromPubKey(pk)
+		f.Add(added[i])
+	}
+	for _, address := range added {
+		require.True(t, f.MayContain(address), "added address should never be missed")
+	}
+	var falsePositives int
+	for i := range others {
+		pk, _ := cipher.GenerateDeterministicKeyPair([]byte{byte(i), byte(i >> 8), 2})
+		others[i] = cipher.AddressFromPubKey(pk)
+		if f.MayContain(others[i]) {
+			falsePositives++
+		}
+	}
+	require.True(t, falsePositives < 100,
+		"false positive rate should be near 0.001, got %d of %d", falsePositives, len(others))
+
+	t.Run("Serialize", func(t *testing.T) {
+		got, err := DeserializeBloomFilter(f.Serialize())
+		require.NoError(t, err)
+		require.Equal(t, f, got)
+
+		var proto BloomFilter
+		require.NoError(t, proto.UnmarshalProto(f.MarshalProto()))
+		require.Equal(t, *f, proto)
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		for _, bad := range []BloomFilter{
+			{Hashes: 1},
+			{Bits: make([]byte, MaxBloomFilterSize+1), Hashes: 1},
+			{Bits: make([]byte, 8)},
+			{Bits: make([]byte, 8), Hashes: MaxBloomFilterHashes + 1},
+		} {
+			require.True(t, errors.Is(bad.Verify(), ErrInvalidBloomFilter))
+			_, err := DeserializeBloomFilter(bad.Serialize())
+			require.True(t, errors.Is(err, ErrInvalidBloomFilter))
+		}
+	})
+
+	t.Run("Limits", func(t *testing.T) {
+		f := NewBloomFilter(1000000, 0.000001, 0)
+		require.Len(t, f.Bits, MaxBloomFilterSize)
+		require.NoError(t, f.Verify())
+	})
+}
+
+func TestTxFilter_Bloom(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 2)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+	transfer, err := NewTransferTx(&txWraps[0].Tx, addr, GenSK)
+	require.NoError(t, err)
+
+	f := &TxFilter{Bloom: NewBloomFilter(10, 0.0001, 0)}
+	require.False(t, f.Match(bc, transfer))
+
+	f.Bloom.Add(addr)
+	require.True(t, f.Match(bc, transfer), "tx to an address of the filter should match")
+	require.False(t, f.Match(bc, &txWraps[1].Tx))
+
+	f = &TxFilter{Bloom: NewBloomFilter(10, 0.0001, 0)}
+	f.Bloom.Add(bc.CreatorAddress())
+	require.True(t, f.Match(bc, transfer), "tx from an address of the filter should match")
+}
@@ -0,0 +1,170 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+var (
+	// ErrKittyCooldown occurs when breeding a kitty before it's cooldown has
+	// passed.
+	ErrKittyCooldown = errors.New("kitty is in breeding cooldown")
+
+	// ErrBreedNotOwner occurs when a breed tx changes the owner of the parent
+	// kitties.
+	ErrBreedNotOwner = errors.New("breed tx should output to the owner of the parent kitties")
+)
+
+// TxBreed is the breeding of a breed tx. The parents are the kitties of the tx,
+// which remain with their owner, and the child is created under the owner.
+type TxBreed struct {
+	ChildID KittyID
+}
+
+// NewBreedTx creates a tx where the kitties 'parentIDs' (of the input txs
+// 'ins') breed the child 'childID'. The parents should be owned by the secret
+// key.
+func NewBreedTx(ins []*Transaction, parentIDs []KittyID, childID KittyID, sk cipher.SecKey) (*Transaction, error) {
+	tx, e := NewUnsignedBreedTx(ins, parentIDs, childID)
+	if e != nil {
+		return nil, e
+	}
+	if expAddr := cipher.AddressFromSecKey(sk); ins[0].Out != expAddr {
+		return nil, errors.New("secret key does not own input tx address")
+	}
+	tx.Sig = tx.Sign(sk)
+	return tx, nil
+}
+
+// NewUnsignedBreedTx creates an unsigned breed tx, where the kitty
+// 'parentIDs[i]' is of the input tx 'ins[i]'. It should be signed by the owner
+// of the parents.
+func NewUnsignedBreedTx(ins []*Transaction, parentIDs []KittyID, childID KittyID) (*Transaction, error) {
+	if len(ins) != 2 {
+		return nil, fmt.Errorf("breed tx should have 2 parents, got %d", len(ins))
+	}
+	tx, e := NewUnsignedMultiTransferTx(ins, parentIDs, ins[0].Out)
+	if e != nil {
+		return nil, e
+	}
+	tx.Breed = &TxBreed{ChildID: childID}
+	return tx, tx.verifyBreed()
+}
+
+// IsBreed returns true if the tx breeds a child from it's kitties, rather than
+// transferring them.
+func (tx Transaction) IsBreed() bool {
+	return tx.Breed != nil
+}
+
+// OutputKittyIDs returns the kitties that the tx is the unspent tx of. These
+// are the kitties of 'KittyIDs', followed by the child of a breed tx.
+func (tx Transaction) OutputKittyIDs() KittyIDs {
+	out := tx.KittyIDs()
+	if tx.Breed != nil {
+		out = append(out, tx.Breed.ChildID)
+	}
+	return out
+}
+
+// VerifyBreedWith checks the inputs and signature of a breed tx, where 'ins'
+// are the unspent txs of the parents (in the order of 'KittyIDs'). Cooldowns
+// are not checked, as they are of the state.
+func (tx Transaction) VerifyBreedWith(ins []*Transaction) error {
+	if e := tx.verifyBreed(); e != nil {
+		return e
+	}
+	if e := tx.VerifyWithInputs(ins); e != nil {
+		return e
+	}
+	if tx.Out != ins[0].Out {
+		return ErrBreedNotOwner
+	}
+	return nil
+}
+
+// verifyBreed checks the structure of a breed tx.
+func (tx Transaction) verifyBreed() error {
+	if len(tx.Inputs) != 1 {
+		return fmt.Errorf("breed tx should have 2 parents, got %d", len(tx.Inputs)+1)
+	}
+	if tx.Escrow != nil || tx.Metadata != nil {
+		return errors.New("breed tx cannot settle an escrow, or set metadata")
+	}
+	if child := tx.Breed.ChildID; tx.KittyID == child || tx.Inputs[0].KittyID == child {
+		return fmt.Errorf("breed tx child 'kitty_id:%d' is a parent", child)
+	}
+	return nil
+}
+
+// BreedMetadata derives the metadata of a child from that of it's parents.
+// The derivation is deterministic, so that every node derives the same child:
+//   - DNA: each byte is inherited from either parent, as chosen by a hash of
+//     the child's ID and the parents' DNA. Bytes that neither parent has are
+//     taken from the hash.
+//   - Generation: one above the highest generation of the parents.
+//
+// The name and image URI are left to be set with a metadata tx.
+func BreedMetadata(childID KittyID, a, b KittyMetadata) KittyMetadata {
+	var (
+		seed = cipher.SumSHA256(encoder.Serialize([][]byte{
+			encoder.Serialize(childID), a.DNA, b.DNA}))
+		size = len(a.DNA)
+	)
+	if len(b.DNA) > size {
+		size = len(b.DNA)
+	}
+	if size == 0 {
+		size = len(seed)
+	}
+	dna := make([]byte, size)
+	for i := range dna {
+		first, second := a.DNA, b.DNA
+		if seed[(i/8)%len(seed)]>>uint(i%8)&1 == 1 {
+			first, second = second, first
+		}
+		switch {
+		case i < len(first):
+			dna[i] = first[i]
+		case i < len(second):
+			dna[i] = second[i]
+		default:
+			dna[i] = seed[i%len(seed)]
+		}
+	}
+	generation := a.Generation
+	if b.Generation > generation {
+		generation = b.Generation
+	}
+	return KittyMetadata{
+		DNA:        dna,
+		Generation: generation + 1,
+	}
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// breedChild checks the cooldown of the parents of a breed tx at sequence
+// 'seq', and derives the metadata of the child. Parents that are not in the
+// state (such as pending kitties) have no cooldown or metadata.
+func (bc *BlockChain) breedChild(tx *Transaction, seq uint64) (KittyMetadata, error) {
+	var parents [2]KittyMetadata
+	for i, kittyID := range tx.KittyIDs() {
+		if kState, ok := bc.state.GetKittyState(kittyID); ok {
+			if seq < kState.CooldownSeq {
+				return KittyMetadata{}, ErrKittyCooldown
+			}
+			parents[i] = kState.Metadata
+		}
+	}
+	if _, ok := bc.state.GetKittyState(tx.Breed.ChildID); ok {
+		return KittyMetadata{}, fmt.Errorf("kitty of id '%d': %w",
+			tx.Breed.ChildID, ErrKittyAlreadyExists)
+	}
+	return BreedMetadata(tx.Breed.ChildID, parents[0], parents[1]), nil
+}
@@ -0,0 +1,97 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreedMetadata(t *testing.T) {
+	var (
+		a = KittyMetadata{DNA: []byte{1, 1, 1, 1, 1, 1, 1, 1}, Generation: 2}
+		b = KittyMetadata{DNA: []byte{2, 2, 2, 2}, Generation: 5}
+	)
+	child := BreedMetadata(10, a, b)
+	require.Equal(t, child, BreedMetadata(10, a, b), "derivation should be deterministic")
+	require.NotEqual(t, child.DNA, BreedMetadata(11, a, b).DNA, "dna should depend on the child")
+	require.Equal(t, uint32(6), child.Generation)
+	require.Len(t, child.DNA, len(a.DNA))
+	for i, v := range child.DNA {
+		if i < len(b.DNA) {
+			require.Contains(t, []byte{1, 2}, v, "dna should be inherited")
+		} else {
+			require.Equal(t, byte(1), v, "dna should be of the longer parent")
+		}
+	}
+
+	none := BreedMetadata(10, KittyMetadata{}, KittyMetadata{})
+	require.Len(t, none.DNA, 32, "dna should be derived without parent dna")
+	require.Equal(t, uint32(1), none.Generation)
+}
+
+func TestBlockChain_Breed(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK:  GenPK,
+		BreedCooldown: 2,
+	})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 3)
+		ins     = []*Transaction{&txWraps[0].Tx, &txWraps[1].Tx}
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+
+	_, err := NewUnsignedBreedTx(ins, KittyIDs{0, 1}, 1)
+	require.Error(t, err, "child should not be a parent")
+
+	tx, err := NewBreedTx(ins, KittyIDs{0, 1}, 2, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(tx)
+	require.Error(t, err, "child should not already exist")
+
+	tx, err = NewBreedTx(ins, KittyIDs{0, 1}, 10, GenSK)
+	require.NoError(t, err)
+	meta, err := bc.InjectTx(tx)
+	require.NoError(t, err)
+
+	child, ok := bc.GetKittyState(10)
+	require.True(t, ok)
+	require.Equal(t, bc.CreatorAddress(), child.Address)
+	require.Equal(t, TxHashes{tx.Hash()}, child.Transactions)
+	require.Equal(t, BreedMetadata(10, KittyMetadata{}, KittyMetadata{}), child.Metadata)
+	require.Equal(t, meta.Seq+2, child.CooldownSeq)
+
+	for _, kittyID := range []KittyID{0, 1} {
+		parent, ok := bc.GetKittyState(kittyID)
+		require.True(t, ok)
+		require.Equal(t, bc.CreatorAddress(), parent.Address, "parents should keep their owner")
+		require.Equal(t, meta.Seq+2, parent.CooldownSeq)
+	}
+	require.Contains(t, bc.GetAddressState(bc.CreatorAddress()).Kitties, KittyID(10))
+
+	again, err := NewBreedTx([]*Transaction{tx, &txWraps[2].Tx}, KittyIDs{0, 2}, 11, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(again)
+	require.Equal(t, ErrKittyCooldown, err)
+
+	// The breed tx is the unspent tx of the child.
+	transfer := NewUnsignedTransferTx(tx, addr)
+	transfer.KittyID = 10
+	transfer.Sig = transfer.Sign(GenSK)
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err, "child should be transferred")
+
+	_, err = bc.InjectTx(again)
+	require.NoError(t, err, "parent should breed after the cooldown")
+
+	t.Run("Replay", func(t *testing.T) {
+		snapshot := bc.state.(SnapshotStateDB).Snapshot()
+		bc.state = NewMemoryState()
+		require.NoError(t, bc.InitState())
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot(),
+			"replayed state should match")
+	})
+}
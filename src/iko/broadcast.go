@@ -0,0 +1,361 @@
+package iko
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	bolt "go.etcd.io/bbolt"
+	"gopkg.in/sirupsen/logrus.v1"
+)
+
+const (
+	DefaultBroadcastRetryInterval    = 5 * time.Second
+	DefaultBroadcastMaxRetryInterval = 5 * time.Minute
+)
+
+var (
+	// ErrTxNotQueued occurs when a tx is not found in the broadcast queue.
+	ErrTxNotQueued = errors.New("tx is not queued for broadcast")
+
+	boltBroadcastBucket = []byte("broadcast_queue") // tx hash -> serialized queuedTx
+)
+
+// Broadcaster sends transactions to peers or an upstream node.
+type Broadcaster interface {
+
+	// BroadcastTx sends the tx, returning nil once it is acknowledged.
+	// Txs that are rejected (rather than not delivered) should result in a
+	// '*TxRejectedError', so that they are not retried.
+	BroadcastTx(tx *Transaction) error
+}
+
+// TxRejectedError occurs when a broadcast tx is rejected by the receiver.
+type TxRejectedError struct {
+	Reason string
+}
+
+func (e *TxRejectedError) Error() string {
+	return "tx rejected: " + e.Reason
+}
+
+type BroadcastQueueConfig struct {
+	Path string // File of the queue.
+
+	// RetryInterval is the delay before the first retry. Subsequent retries
+	// back off exponentially, up to 'MaxRetryInterval'.
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+
+	// MaxAttempts is the number of attempts after which a tx is dropped.
+	// A value of 0 means that txs are retried until acknowledged.
+	MaxAttempts int
+}
+
+func (c *BroadcastQueueConfig) Process() error {
+	if c.Path == "" {
+		return errors.New("no path specified for broadcast queue")
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = DefaultBroadcastRetryInterval
+	}
+	if c.MaxRetryInterval < c.RetryInterval {
+		c.MaxRetryInterval = DefaultBroadcastMaxRetryInterval
+		if c.MaxRetryInterval < c.RetryInterval {
+			c.MaxRetryInterval = c.RetryInterval
+		}
+	}
+	return nil
+}
+
+// QueuedTx is a transaction awaiting acknowledgement of it's broadcast.
+type QueuedTx struct {
+	Tx        Transaction
+	Queued    int64 // Unix time (in nanoseconds) of enqueueing.
+	Attempts  int
+	LastError string
+	NextTry   time.Time // Not persisted, txs are retried on restart.
+}
+
+// queuedTx is the persisted form of QueuedTx.
+type queuedTx struct {
+	Tx        []byte // Serialized tx, including the witness.
+	Queued    int64
+	Attempts  uint32
+	LastError string
+}
+
+// BroadcastQueue persists outgoing transactions and retries broadcasting them
+// until they are acknowledged, so that they survive restarts of the node.
+type BroadcastQueue struct {
+	c   *BroadcastQueueConfig
+	l   *logrus.Logger
+	db  *bolt.DB
+	b   Broadcaster
+	mux sync.Mutex
+	txs map[TxHash]*QueuedTx
+
+	notify chan struct{}
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewBroadcastQueue(config *BroadcastQueueConfig, b Broadcaster) (*BroadcastQueue, error) {
+	if e := config.Process(); e != nil {
+		return nil, e
+	}
+	if e := os.MkdirAll(filepath.Dir(config.Path), os.FileMode(0700)); e != nil {
+		return nil, e
+	}
+	db, e := bolt.Open(config.Path, os.FileMode(0600), &bolt.Options{
+		Timeout: time.Millisecond * 500,
+	})
+	if e != nil {
+		return nil, e
+	}
+	q := &BroadcastQueue{
+		c: config,
+		l: &logrus.Logger{
+			Out:       os.Stderr,
+			Formatter: new(logrus.TextFormatter),
+			Hooks:     make(logrus.LevelHooks),
+			Level:     logrus.DebugLevel,
+		},
+		db:     db,
+		b:      b,
+		txs:    make(map[TxHash]*QueuedTx),
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+	if e := q.load(); e != nil {
+		db.Close()
+		return nil, e
+	}
+	if len(q.txs) > 0 {
+		q.l.Infof("loaded %d queued txs for broadcast", len(q.txs))
+	}
+	q.wg.Add(1)
+	go q.service()
+	q.trigger()
+	return q, nil
+}
+
+func (q *BroadcastQueue) load() error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		bkt, e := tx.CreateBucketIfNotExists(boltBroadcastBucket)
+		if e != nil {
+			return e
+		}
+		return bkt.ForEach(func(k, v []byte) error {
+			var rec queuedTx
+			if e := encoder.DeserializeRaw(v, &rec); e != nil {
+				return e
+			}
+			qTx, e := DeserializeTx(rec.Tx)
+			if e != nil {
+				return e
+			}
+			q.txs[qTx.Hash()] = &QueuedTx{
+				Tx:        *qTx,
+				Queued:    rec.Queued,
+				Attempts:  int(rec.Attempts),
+				LastError: rec.LastError,
+			}
+			return nil
+		})
+	})
+}
+
+// Close stops retrying broadcasts. Queued txs are kept for the next start.
+func (q *BroadcastQueue) Close() {
+	close(q.quit)
+	q.wg.Wait()
+	if e := q.db.Close(); e != nil {
+		q.l.WithError(e).Error("failed to close broadcast queue")
+	}
+}
+
+// Enqueue persists the tx and broadcasts it in the background.
+func (q *BroadcastQueue) Enqueue(tx *Transaction) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	hash := tx.Hash()
+	if _, ok := q.txs[hash]; ok {
+		return nil
+	}
+	qTx := &QueuedTx{
+		Tx:     *tx,
+		Queued: time.Now().UnixNano(),
+	}
+	if e := q.put(hash, qTx); e != nil {
+		return e
+	}
+	q.txs[hash] = qTx
+	q.trigger()
+	return nil
+}
+
+// Queued obtains the txs awaiting acknowledgement, in order of enqueueing.
+func (q *BroadcastQueue) Queued() []QueuedTx {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	out := make([]QueuedTx, 0, len(q.txs))
+	for _, qTx := range q.txs {
+		out = append(out, *qTx)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Queued < out[j].Queued
+	})
+	return out
+}
+
+// Remove gives up on broadcasting the tx of hash.
+func (q *BroadcastQueue) Remove(hash TxHash) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	if _, ok := q.txs[hash]; !ok {
+		return ErrTxNotQueued
+	}
+	return q.remove(hash)
+}
+
+func (q *BroadcastQueue) put(hash TxHash, qTx *QueuedTx) error {
+	rec := queuedTx{
+		Tx:        qTx.Tx.Serialize(),
+		Queued:    qTx.Queued,
+		Attempts:  uint32(qTx.Attempts),
+		LastError: qTx.LastError,
+	}
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBroadcastBucket).Put(hash[:], encoder.Serialize(rec))
+	})
+}
+
+func (q *BroadcastQueue) remove(hash TxHash) error {
+	delete(q.txs, hash)
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBroadcastBucket).Delete(hash[:])
+	})
+}
+
+func (q *BroadcastQueue) trigger() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *BroadcastQueue) service() {
+	defer q.wg.Done()
+
+	timer := time.NewTimer(q.c.RetryInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-q.quit:
+			return
+		case <-q.notify:
+		case <-timer.C:
+		}
+		next := q.attemptDue()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(time.Until(next))
+	}
+}
+
+// attemptDue broadcasts the txs that are due, and returns the time of the
+// next due tx.
+func (q *BroadcastQueue) attemptDue() time.Time {
+	now := time.Now()
+	next := now.Add(q.c.MaxRetryInterval)
+	for _, qTx := range q.Queued() {
+		select {
+		case <-q.quit:
+			return next
+		default:
+		}
+		if qTx.NextTry.After(now) {
+			if qTx.NextTry.Before(next) {
+				next = qTx.NextTry
+			}
+			continue
+		}
+		if retry := q.attempt(qTx.Tx); !retry.IsZero() && retry.Before(next) {
+			next = retry
+		}
+	}
+	return next
+}
+
+// attempt broadcasts a single tx, returning the time of it's next retry (or
+// the zero time if it is no longer queued).
+func (q *BroadcastQueue) attempt(tx Transaction) time.Time {
+	var (
+		hash = tx.Hash()
+		e    = q.b.BroadcastTx(&tx)
+		log  = q.l.WithField("tx", hash.Hex())
+	)
+
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	qTx, ok := q.txs[hash]
+	if !ok {
+		return time.Time{}
+	}
+	qTx.Attempts++
+
+	if e == nil {
+		log.Info("broadcast tx acknowledged")
+		if e := q.remove(hash); e != nil {
+			log.WithError(e).Error("failed to remove acknowledged tx")
+		}
+		return time.Time{}
+	}
+	if _, rejected := e.(*TxRejectedError); rejected {
+		log.WithError(e).Warning("broadcast tx rejected, dropping")
+		if e := q.remove(hash); e != nil {
+			log.WithError(e).Error("failed to remove rejected tx")
+		}
+		return time.Time{}
+	}
+	if max := q.c.MaxAttempts; max > 0 && qTx.Attempts >= max {
+		log.WithError(e).Warningf("broadcast tx failed %d times, dropping", qTx.Attempts)
+		if e := q.remove(hash); e != nil {
+			log.WithError(e).Error("failed to remove dropped tx")
+		}
+		return time.Time{}
+	}
+
+	qTx.LastError = e.Error()
+	qTx.NextTry = time.Now().Add(q.retryInterval(qTx.Attempts))
+	log.WithError(e).Debugf("broadcast failed, retrying at %v", qTx.NextTry)
+	if e := q.put(hash, qTx); e != nil {
+		log.WithError(e).Error("failed to persist queued tx")
+	}
+	return qTx.NextTry
+}
+
+func (q *BroadcastQueue) retryInterval(attempts int) time.Duration {
+	interval := q.c.RetryInterval
+	for i := 1; i < attempts && interval < q.c.MaxRetryInterval; i++ {
+		interval *= 2
+	}
+	if interval > q.c.MaxRetryInterval {
+		interval = q.c.MaxRetryInterval
+	}
+	return interval
+}
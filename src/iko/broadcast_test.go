@@ -0,0 +1,117 @@
+package iko
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testBroadcaster fails to deliver txs until it is told to acknowledge or
+// reject them.
+type testBroadcaster struct {
+	mux      sync.Mutex
+	ack      bool
+	reject   bool
+	attempts int
+	acked    []TxHash
+}
+
+func (b *testBroadcaster) set(ack, reject bool) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.ack, b.reject = ack, reject
+}
+
+func (b *testBroadcaster) BroadcastTx(tx *Transaction) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.attempts++
+	switch {
+	case b.reject:
+		return &TxRejectedError{Reason: "invalid"}
+	case b.ack:
+		b.acked = append(b.acked, tx.Hash())
+		return nil
+	default:
+		return errors.New("connection refused")
+	}
+}
+
+func (b *testBroadcaster) stats() (int, int) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.attempts, len(b.acked)
+}
+
+func newTestBroadcastQueue(t *testing.T, path string, b Broadcaster) *BroadcastQueue {
+	q, err := NewBroadcastQueue(&BroadcastQueueConfig{
+		Path:             path,
+		RetryInterval:    time.Millisecond * 10,
+		MaxRetryInterval: time.Millisecond * 20,
+	}, b)
+	require.NoError(t, err, "broadcast queue should open")
+	return q
+}
+
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	deadline := time.Now().Add(time.Second * 5)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for: " + msg)
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+func TestBroadcastQueue(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+
+	var (
+		b   = new(testBroadcaster)
+		q   = newTestBroadcastQueue(t, path, b)
+		txs = []*Transaction{NewGenTx(0, GenSK), NewGenTx(1, GenSK)}
+	)
+	for _, tx := range txs {
+		require.NoError(t, q.Enqueue(tx))
+	}
+	waitFor(t, "retries", func() bool {
+		attempts, _ := b.stats()
+		return attempts >= 6
+	})
+	queued := q.Queued()
+	require.Len(t, queued, 2, "undelivered txs should stay queued")
+	require.Equal(t, txs[0].Hash(), queued[0].Tx.Hash(), "txs should be in order of enqueueing")
+	require.NotEmpty(t, queued[0].LastError)
+	q.Close()
+
+	// Restart.
+	q = newTestBroadcastQueue(t, path, b)
+	require.Len(t, q.Queued(), 2, "queued txs should survive restarts")
+
+	require.NoError(t, q.Remove(txs[1].Hash()))
+	require.Equal(t, ErrTxNotQueued, q.Remove(txs[1].Hash()))
+
+	b.set(true, false)
+	waitFor(t, "acknowledgement", func() bool {
+		return len(q.Queued()) == 0
+	})
+	_, acked := b.stats()
+	require.Equal(t, 1, acked)
+
+	b.set(false, true)
+	require.NoError(t, q.Enqueue(NewGenTx(2, GenSK)))
+	waitFor(t, "rejection", func() bool {
+		return len(q.Queued()) == 0
+	})
+	q.Close()
+
+	// Restart.
+	q = newTestBroadcastQueue(t, path, b)
+	defer q.Close()
+	require.Empty(t, q.Queued(), "acknowledged and rejected txs should not be persisted")
+}
@@ -0,0 +1,37 @@
+package iko
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+var (
+	// BurnAddress is the output address of burn txs. It is the null address,
+	// which is not of any public key, so burned kitties can never be spent.
+	BurnAddress = cipher.Address{}
+
+	// ErrKittyBurned occurs when transferring or generating a kitty that was
+	// burned.
+	ErrKittyBurned = errors.New("kitty is burned")
+)
+
+// NewBurnTx creates a tx that permanently retires the kitty of the input tx,
+// signed by the owner of the input tx.
+func NewBurnTx(in *Transaction, sk cipher.SecKey) *Transaction {
+	tx := NewUnsignedBurnTx(in)
+	tx.Sig = tx.Sign(sk)
+	return tx
+}
+
+// NewUnsignedBurnTx creates an unsigned tx that permanently retires the kitty
+// of the input tx.
+func NewUnsignedBurnTx(in *Transaction) *Transaction {
+	return NewUnsignedTransferTx(in, BurnAddress)
+}
+
+// IsBurn returns true if the tx burns it's kitties, rather than transferring
+// them.
+func (tx Transaction) IsBurn() bool {
+	return tx.Out == BurnAddress && tx.In != EmptyTxHash()
+}
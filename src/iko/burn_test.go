@@ -0,0 +1,37 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_Burn(t *testing.T) {
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 2)
+
+	burn := NewBurnTx(&txWraps[0].Tx, GenSK)
+	require.True(t, burn.IsBurn())
+	require.False(t, txWraps[0].Tx.IsBurn(), "generation tx should not be a burn")
+	_, err := bc.InjectTx(burn)
+	require.NoError(t, err)
+
+	kState, ok := bc.GetKittyState(0)
+	require.True(t, ok)
+	require.True(t, kState.Burned)
+	require.Equal(t, KittyIDs{1}, bc.GetAddressState(bc.CreatorAddress()).Kitties)
+
+	pk, _ := cipher.GenerateKeyPair()
+	transfer := NewUnsignedTransferTx(burn, cipher.AddressFromPubKey(pk))
+	transfer.Sig = transfer.Sign(GenSK)
+	_, err = bc.InjectTx(transfer)
+	require.Equal(t, ErrKittyBurned, err, "burned kitty should not be transferred")
+	require.Equal(t, ErrKittyBurned, bc.SubmitTx(transfer))
+
+	_, err = bc.InjectTx(NewGenTx(0, GenSK))
+	require.Equal(t, ErrKittyBurned, err, "burned kitty should not be regenerated")
+	require.Equal(t, ErrKittyBurned, bc.SubmitTx(NewGenTx(0, GenSK)))
+}
@@ -0,0 +1,125 @@
+package iko
+
+import (
+	"container/list"
+	"sync"
+)
+
+// txCache is a least recently used cache of txs, which can be looked up by
+// hash and by seq. A nil cache is disabled, and caches nothing.
+type txCache struct {
+	mux    sync.Mutex
+	size   int
+	order  *list.List // of TxWrapper, most recently used first
+	hashes map[TxHash]*list.Element
+	seqs   map[uint64]*list.Element
+}
+
+// newTxCache creates a cache of 'size' txs, or nil if 'size' is not positive.
+func newTxCache(size int) *txCache {
+	if size <= 0 {
+		return nil
+	}
+	return &txCache{
+		size:   size,
+		order:  list.New(),
+		hashes: make(map[TxHash]*list.Element, size),
+		seqs:   make(map[uint64]*list.Element, size),
+	}
+}
+
+func (c *txCache) getOfHash(hash TxHash) (TxWrapper, bool) {
+	if c == nil {
+		return TxWrapper{}, false
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.use(c.hashes[hash])
+}
+
+func (c *txCache) getOfSeq(seq uint64) (TxWrapper, bool) {
+	if c == nil {
+		return TxWrapper{}, false
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.use(c.seqs[seq])
+}
+
+func (c *txCache) use(el *list.Element) (TxWrapper, bool) {
+	if el == nil {
+		return TxWrapper{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(TxWrapper), true
+}
+
+// add adds the tx, evicting the least recently used tx if the cache is full.
+func (c *txCache) add(txWrap TxWrapper) {
+	if c == nil {
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if el, ok := c.seqs[txWrap.Meta.Seq]; ok {
+		c.remove(el)
+	}
+	el := c.order.PushFront(txWrap)
+	c.hashes[txWrap.Tx.Hash()] = el
+	c.seqs[txWrap.Meta.Seq] = el
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *txCache) remove(el *list.Element) {
+	txWrap := c.order.Remove(el).(TxWrapper)
+	delete(c.hashes, txWrap.Tx.Hash())
+	delete(c.seqs, txWrap.Meta.Seq)
+}
+
+// purge removes all txs, such as when txs are removed from the chain.
+func (c *txCache) purge() {
+	if c == nil {
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.order.Init()
+	c.hashes = make(map[TxHash]*list.Element, c.size)
+	c.seqs = make(map[uint64]*list.Element, c.size)
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// txOfHash obtains the tx of hash from the tx cache, or from the chain if it
+// is not cached. The caller should hold the read lock.
+func (bc *BlockChain) txOfHash(hash TxHash) (TxWrapper, error) {
+	if txWrap, ok := bc.txCache.getOfHash(hash); ok {
+		return txWrap, nil
+	}
+	txWrap, e := bc.chain.GetTxOfHash(hash)
+	if e != nil {
+		return TxWrapper{}, e
+	}
+	bc.txCache.add(txWrap)
+	return txWrap, nil
+}
+
+// txOfSeq obtains the tx of seq from the tx cache, or from the chain if it is
+// not cached. The caller should hold the read lock.
+func (bc *BlockChain) txOfSeq(seq uint64) (TxWrapper, error) {
+	if txWrap, ok := bc.txCache.getOfSeq(seq); ok {
+		return txWrap, nil
+	}
+	txWrap, e := bc.chain.GetTxOfSeq(seq)
+	if e != nil {
+		return TxWrapper{}, e
+	}
+	bc.txCache.add(txWrap)
+	return txWrap, nil
+}
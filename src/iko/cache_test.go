@@ -0,0 +1,77 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxCache(t *testing.T) {
+	require.Nil(t, newTxCache(0), "cache should be disabled")
+	var disabled *txCache
+	disabled.add(TxWrapper{})
+	_, ok := disabled.getOfSeq(0)
+	require.False(t, ok)
+
+	c := newTxCache(2)
+	txWraps := make([]TxWrapper, 3)
+	for i := range txWraps {
+		txWraps[i] = TxWrapper{Tx: *NewGenTx(KittyID(i), GenSK), Meta: TxMeta{Seq: uint64(i)}}
+	}
+	c.add(txWraps[0])
+	c.add(txWraps[1])
+
+	got, ok := c.getOfSeq(0)
+	require.True(t, ok)
+	require.Equal(t, txWraps[0].Tx.Hash(), got.Tx.Hash())
+
+	// Seq 1 is now the least recently used.
+	c.add(txWraps[2])
+	_, ok = c.getOfHash(txWraps[1].Tx.Hash())
+	require.False(t, ok, "least recently used tx should be evicted")
+	_, ok = c.getOfSeq(1)
+	require.False(t, ok)
+	got, ok = c.getOfHash(txWraps[0].Tx.Hash())
+	require.True(t, ok)
+	require.Equal(t, uint64(0), got.Meta.Seq)
+
+	c.purge()
+	_, ok = c.getOfSeq(2)
+	require.False(t, ok)
+}
+
+func TestBlockChain_TxCache(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		TxCacheSize:  2,
+	})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 3)
+	for _, txWrap := range txWraps {
+		got, err := bc.GetTxOfSeq(txWrap.Meta.Seq)
+		require.NoError(t, err)
+		require.Equal(t, txWrap.Tx.Hash(), got.Tx.Hash())
+		got, err = bc.GetTxOfHash(txWrap.Tx.Hash())
+		require.NoError(t, err)
+		require.Equal(t, txWrap.Meta.Seq, got.Meta.Seq)
+	}
+	head, err := bc.GetHeadTx()
+	require.NoError(t, err)
+	require.Equal(t, txWraps[2].Tx.Hash(), head.Tx.Hash())
+
+	// Removed txs are not served from the cache.
+	_, err = bc.RemoveHeadTx()
+	require.NoError(t, err)
+	_, err = bc.GetTxOfHash(txWraps[2].Tx.Hash())
+	require.Error(t, err)
+
+	_, err = bc.InjectTx(NewGenTx(5, GenSK))
+	require.NoError(t, err)
+	got, err := bc.GetTxOfSeq(2)
+	require.NoError(t, err)
+	require.Equal(t, KittyID(5), got.Tx.KittyID, "tx of seq should be the new tx")
+	head, err = bc.GetHeadTx()
+	require.NoError(t, err)
+	require.Equal(t, got.Tx.Hash(), head.Tx.Hash())
+}
@@ -1,5 +1,23 @@
 package iko
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrTxNotFound occurs when a tx of the requested hash or seq does not
+	// exist in the chain.
+	ErrTxNotFound = errors.New("tx does not exist")
+
+	// ErrTxAlreadyExists occurs when adding a tx of which the hash is of a tx
+	// that is already stored in the chain.
+	ErrTxAlreadyExists = errors.New("tx already exists in the chain")
+)
+
 // TxChecker checks the transaction, returns an error when,
 // there is a problem with the transaction, and it shouldn't
 // be added to the blockchain.
@@ -18,7 +36,8 @@ type ChainDB interface {
 	Len() uint64
 
 	// AddTx should add a transaction to the chain after the specified
-	// 'check' returns nil.
+	// 'check' returns nil. It should fail with 'ErrTxAlreadyExists' (before
+	// calling 'check') if a transaction of the same hash is stored.
 	AddTx(txWrapper TxWrapper, check TxChecker) error
 
 	// GetTxOfHash should obtain a transaction of a given hash.
@@ -30,13 +49,240 @@ type ChainDB interface {
 	//	or the tx doesn't exist.
 	GetTxOfSeq(seq uint64) (TxWrapper, error)
 
+	// RemoveHeadTx should remove the head transaction, returning it.
+	// It should return an error when there are no transactions recorded.
+	RemoveHeadTx() (TxWrapper, error)
+
 	// TxChan obtains a channel where new transactions are sent through.
 	// When a transaction is successfully saved to the `ChainDB` implementation,
 	//	we expect to see it getting sent through here too.
+	// Transactions may be dropped when the consumer falls behind, in which
+	//	case the BlockChain reads them from the chain instead.
 	TxChan() <-chan *TxWrapper
 
 	// GetTxsOfSeqRange returns a paginated portion of the Transactions.
 	// It will return an error if the pageSize is zero
 	// It will also return an error if startSeq is invalid
 	GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapper, error)
+
+	// Range should call 'fn' with the transactions of seqs [start, end), in
+	// order, until 'fn' returns false. Seqs past the head are skipped. Unlike
+	// 'GetTxsOfSeqRange', the transactions are not obtained all at once, so
+	// that the whole chain can be walked (such as by indexers).
+	Range(start, end uint64, fn func(txWrap TxWrapper) bool) error
+
+	// Compact should reclaim storage space that is no longer used by the chain.
+	// It should be safe to run alongside reads, and return early with the
+	// context's error when 'ctx' is cancelled.
+	Compact(ctx context.Context) error
+
+	// DiskUsage should obtain the number of bytes the chain occupies on disk,
+	// including space that 'Compact' would reclaim. In-memory chains occupy
+	// none.
+	DiskUsage() (uint64, error)
+
+	// Close should release all resources used by the chain, and close the
+	// channel obtained from 'TxChan'.
+	Close()
+}
+
+// BatchChainDB is a ChainDB that can add multiple transactions in a single
+// write, so that bulk injection does not pay for a write (and fsync) per tx.
+type BatchChainDB interface {
+	ChainDB
+
+	// AddTxs should add the transactions, in order, in a single write.
+	// Either all transactions are added, or none are (and an error is
+	// returned). Each transaction is checked with 'check' beforehand, and
+	// should be rejected as with 'AddTx' (or if it's hash is that of a
+	// preceding transaction of the batch).
+	AddTxs(txWraps []TxWrapper, check TxChecker) error
+}
+
+// checkTxsNew returns an error wrapping 'ErrTxAlreadyExists' if the hash of a
+// tx is of a stored tx (as determined by 'stored'), or of a preceding tx.
+func checkTxsNew(txWraps []TxWrapper, stored func(hash TxHash) (bool, error)) error {
+	seen := make(map[TxHash]struct{}, len(txWraps))
+	for i := range txWraps {
+		hash := txWraps[i].Tx.Hash()
+		_, dup := seen[hash]
+		if !dup {
+			var e error
+			if dup, e = stored(hash); e != nil {
+				return e
+			}
+		}
+		if dup {
+			return fmt.Errorf("tx of hash '%s': %w", hash.Hex(), ErrTxAlreadyExists)
+		}
+		seen[hash] = struct{}{}
+	}
+	return nil
+}
+
+// PrunableChainDB is a ChainDB that can remove the bodies of old transactions,
+// while keeping the sequences of the remaining transactions (and the length
+// of the chain) unchanged.
+type PrunableChainDB interface {
+	ChainDB
+
+	// PruneTxs should remove the transactions of seqs [start, end) for which
+	// 'keep' returns false, returning the number of removed transactions.
+	// Sequences of which the transaction is already removed are skipped.
+	PruneTxs(start, end uint64, keep func(txWrap *TxWrapper) bool) (uint64, error)
+}
+
+// ChainSnapshot is a read-only view of the chain at a point in time, which is
+// unaffected by later writes. It should be released once it is no longer
+// needed.
+type ChainSnapshot interface {
+
+	// Len should obtain the length of the chain, as of the snapshot.
+	Len() uint64
+
+	// GetTxOfHash should obtain a transaction of a given hash, as with
+	// 'ChainDB.GetTxOfHash'.
+	GetTxOfHash(hash TxHash) (TxWrapper, error)
+
+	// GetTxOfSeq should obtain a transaction of a given sequence, as with
+	// 'ChainDB.GetTxOfSeq'.
+	GetTxOfSeq(seq uint64) (TxWrapper, error)
+
+	// GetTxsOfSeqRange should return a paginated portion of the transactions,
+	// as with 'ChainDB.GetTxsOfSeqRange'.
+	GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapper, error)
+
+	// Release should release the resources held by the snapshot. It should be
+	// safe to call more than once.
+	Release()
+}
+
+// SnapshotChainDB is a ChainDB that can obtain snapshots of the chain, so that
+// long (such as paginated) reads do not block writes, or vice versa.
+type SnapshotChainDB interface {
+	ChainDB
+
+	// Snapshot should obtain a snapshot of the chain as of now.
+	Snapshot() (ChainSnapshot, error)
+}
+
+// txChanBufSize is the buffer size of the channel obtained from 'TxChan', so
+// that accepted txs are not dropped while the consumer is busy.
+const txChanBufSize = 128
+
+// ChainDBType determines the ChainDB implementation.
+type ChainDBType string
+
+const (
+	CXOChainDBType   ChainDBType = "cxo"
+	BoltChainDBType  ChainDBType = "bolt"
+	LevelChainDBType ChainDBType = "leveldb"
+	SQLChainDBType   ChainDBType = "sqlite"
+)
+
+// ChainDBConfig selects the ChainDB implementation to use.
+// Only the config of the selected type needs to be specified.
+type ChainDBConfig struct {
+	Type  ChainDBType
+	CXO   *CXOChainConfig
+	Bolt  *BoltChainConfig
+	Level *LevelChainConfig
+	SQL   *SQLChainConfig
+}
+
+// NewChainDB creates a ChainDB implementation of the type specified in config.
+// This allows the storage backend of a BlockChain to be swapped via config.
+func NewChainDB(config *ChainDBConfig) (ChainDB, error) {
+	switch config.Type {
+	case CXOChainDBType:
+		if config.CXO == nil {
+			return nil, errors.New("no cxo chain config specified")
+		}
+		chain, e := NewCXOChain(config.CXO)
+		if e != nil {
+			return nil, e
+		}
+		return chain, nil
+
+	case BoltChainDBType:
+		if config.Bolt == nil {
+			return nil, errors.New("no bolt chain config specified")
+		}
+		chain, e := NewBoltChain(config.Bolt)
+		if e != nil {
+			return nil, e
+		}
+		return chain, nil
+
+	case LevelChainDBType:
+		if config.Level == nil {
+			return nil, errors.New("no leveldb chain config specified")
+		}
+		chain, e := NewLevelChain(config.Level)
+		if e != nil {
+			return nil, e
+		}
+		return chain, nil
+
+	case SQLChainDBType:
+		if config.SQL == nil {
+			return nil, errors.New("no sql chain config specified")
+		}
+		chain, e := NewSQLChain(config.SQL)
+		if e != nil {
+			return nil, e
+		}
+		return chain, nil
+
+	default:
+		return nil, fmt.Errorf("invalid chain db type '%s'", config.Type)
+	}
+}
+
+// rangePageSize is the number of txs that ChainDBs obtain at once in 'Range',
+// so that 'fn' is not called while the db is locked.
+const rangePageSize = 256
+
+// rangeTxPages calls 'fn' with the txs of seqs [start, end) of the ChainDB, in
+// order, obtaining them in pages of 'rangePageSize'.
+func rangeTxPages(c ChainDB, start, end uint64, fn func(txWrap TxWrapper) bool) error {
+	if cLen := c.Len(); end > cLen {
+		end = cLen
+	}
+	for start < end {
+		size := end - start
+		if size > rangePageSize {
+			size = rangePageSize
+		}
+		txWraps, e := c.GetTxsOfSeqRange(start, size)
+		if e != nil {
+			return e
+		}
+		for i := range txWraps {
+			if !fn(txWraps[i]) {
+				return nil
+			}
+		}
+		start += size
+	}
+	return nil
+}
+
+// dirSize obtains the total size of the files under the directory. Files
+// removed while walking (such as by a compaction) are skipped.
+func dirSize(dir string) (uint64, error) {
+	var size uint64
+	e := filepath.Walk(dir, func(path string, info os.FileInfo, e error) error {
+		if os.IsNotExist(e) && path != dir {
+			return nil
+		}
+		if e != nil {
+			return e
+		}
+		if !info.IsDir() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size, e
 }
@@ -0,0 +1,679 @@
+package iko
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"gopkg.in/sirupsen/logrus.v1"
+
+	"github.com/kittycash/wallet/src/util"
+)
+
+var (
+	boltTxsBucket    = []byte("txs_by_seq")  // seq -> serialized TxWrapper
+	boltHashesBucket = []byte("txs_by_hash") // tx hash -> seq
+	boltMetaBucket   = []byte("meta")        // chain db meta data
+
+	boltCodecKey  = []byte("codec")  // tx codec type, in meta bucket
+	boltSchemaKey = []byte("schema") // schema version, in meta bucket
+)
+
+type BoltChainConfig struct {
+	Path    string
+	Timeout time.Duration // Timeout for obtaining the file lock.
+	Codec   TxCodecType   // Encoding of stored txs, defaults to 'BinaryTxCodecType'.
+	Sync    SyncPolicy    // When added txs are synced, defaults to every write.
+}
+
+func (c *BoltChainConfig) Process(log *logrus.Logger) error {
+	if c.Path == "" {
+		return errors.New("no path specified for bolt chain")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Millisecond * 500
+	}
+	if c.Codec == "" {
+		c.Codec = BinaryTxCodecType
+	}
+	return c.Sync.Process()
+}
+
+// BoltChain is a ChainDB implementation that stores transactions in a BoltDB
+// file. Each call to 'AddTx' is written in a single bolt transaction, so the
+// chain is never left with a partially written transaction. Added txs are
+// synced as of 'BoltChainConfig.Sync', while removals are always synced.
+type BoltChain struct {
+	mux      sync.RWMutex // protects 'db', which is replaced on compaction
+	wmux     sync.Mutex   // serializes writes against compaction
+	c        *BoltChainConfig
+	l        *logrus.Logger
+	db       *bolt.DB
+	codec    TxCodec
+	accepted chan *TxWrapper
+	syncer   *chainSyncer
+
+	len util.SafeInt
+}
+
+func NewBoltChain(config *BoltChainConfig) (*BoltChain, error) {
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.DebugLevel,
+	}
+	if e := config.Process(log); e != nil {
+		return nil, e
+	}
+	codec, e := NewTxCodec(config.Codec)
+	if e != nil {
+		return nil, e
+	}
+	if e := os.MkdirAll(filepath.Dir(config.Path), os.FileMode(0700)); e != nil {
+		return nil, e
+	}
+
+	chain := &BoltChain{
+		c:        config,
+		l:        log,
+		codec:    codec,
+		accepted: make(chan *TxWrapper, txChanBufSize),
+	}
+	if e := chain.open(); e != nil {
+		return nil, e
+	}
+	chain.syncer = newChainSyncer(config.Sync, chain.Sync, func(e error) {
+		log.WithError(e).Error("failed to sync bolt chain")
+	})
+
+	log.WithField("height", chain.len.Val()).
+		Info("bolt blockchain initialized")
+
+	return chain, nil
+}
+
+func (c *BoltChain) Close() {
+	c.syncer.stop()
+
+	c.wmux.Lock()
+	defer c.wmux.Unlock()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	close(c.accepted)
+
+	if c.db.NoSync {
+		if e := c.db.Sync(); e != nil {
+			c.l.WithError(e).
+				Error("error on bolt db sync")
+		}
+	}
+
+	if e := c.db.Close(); e != nil {
+		c.l.WithError(e).
+			Error("error on bolt db close")
+	}
+	c.l.Print("closed bolt chain DB")
+}
+
+/*
+	<<< PREP FUNCTIONS >>>
+*/
+
+func (c *BoltChain) open() error {
+	db, e := bolt.Open(c.c.Path, 0600, &bolt.Options{
+		Timeout: c.c.Timeout,
+	})
+	if e != nil {
+		return e
+	}
+	db.NoSync = c.c.Sync.Mode != SyncEveryWrite
+	e = db.Update(func(tx *bolt.Tx) error {
+		if _, e := tx.CreateBucketIfNotExists(boltTxsBucket); e != nil {
+			return e
+		}
+		if _, e := tx.CreateBucketIfNotExists(boltHashesBucket); e != nil {
+			return e
+		}
+		meta, e := tx.CreateBucketIfNotExists(boltMetaBucket)
+		if e != nil {
+			return e
+		}
+		// The length is of the last seq, as older txs may be pruned.
+		if last, _ := tx.Bucket(boltTxsBucket).Cursor().Last(); last != nil {
+			c.len.Set(int(binary.BigEndian.Uint64(last)) + 1)
+		}
+
+		stored := meta.Get(boltCodecKey)
+		if e := c.migrate(meta, &stored); e != nil {
+			return e
+		}
+		if e := checkStoredCodec(c.codec, stored, c.len.Val()); e != nil {
+			return e
+		}
+		if stored == nil {
+			return meta.Put(boltCodecKey, []byte(c.codec.Type()))
+		}
+		return nil
+	})
+	if e != nil {
+		db.Close()
+		return e
+	}
+	c.db = db
+	return nil
+}
+
+// migrate upgrades the data to 'ChainSchemaVersion', within the bolt tx of the
+// meta bucket. The stored codec is updated by the migrations.
+func (c *BoltChain) migrate(meta *bolt.Bucket, storedCodec *[]byte) error {
+	version, e := storedSchemaVersion(meta.Get(boltSchemaKey), c.len.Val(), *storedCodec)
+	if e != nil {
+		return e
+	}
+	e = migrateSchema(c.l, version, []func() error{
+		func() error {
+			if v := migrateRecordCodec(*storedCodec, c.len.Val()); v != nil {
+				*storedCodec = v
+				return meta.Put(boltCodecKey, v)
+			}
+			return nil
+		},
+	})
+	if e != nil {
+		return e
+	}
+	return meta.Put(boltSchemaKey, encodeSchemaVersion(ChainSchemaVersion))
+}
+
+func (c *BoltChain) attemptPushAccepted(txWrap *TxWrapper) {
+	select {
+	case c.accepted <- txWrap:
+	default:
+	}
+}
+
+/*
+	<<< PUBLIC FUNCTIONS >>>
+*/
+
+func (c *BoltChain) Head() (TxWrapper, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	var txWrap TxWrapper
+	return txWrap, c.db.View(func(tx *bolt.Tx) error {
+		_, v := tx.Bucket(boltTxsBucket).Cursor().Last()
+		if v == nil {
+			return errors.New("no transactions available")
+		}
+		return c.codec.DecodeTx(v, &txWrap)
+	})
+}
+
+func (c *BoltChain) Len() uint64 {
+	return uint64(c.len.Val())
+}
+
+func (c *BoltChain) AddTx(txWrap TxWrapper, check TxChecker) error {
+	defer observeChainDBOp("bolt", "add_tx", time.Now())
+
+	c.wmux.Lock()
+	defer c.wmux.Unlock()
+
+	if e := checkTxsNew([]TxWrapper{txWrap}, c.hasTxOfHash); e != nil {
+		return e
+	}
+	if e := check(&txWrap.Tx); e != nil {
+		c.l.WithError(e).Error("failed")
+		return e
+	}
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	e := c.db.Update(func(tx *bolt.Tx) error {
+		var (
+			seq  = boltSeqKey(uint64(c.len.Val()))
+			hash = txWrap.Tx.Hash()
+		)
+		if e := tx.Bucket(boltTxsBucket).Put(seq, c.codec.EncodeTx(txWrap)); e != nil {
+			return e
+		}
+		return tx.Bucket(boltHashesBucket).Put(hash[:], seq)
+	})
+	if e != nil {
+		return e
+	}
+	c.wrote()
+	c.len.Inc()
+	c.attemptPushAccepted(&txWrap)
+	return nil
+}
+
+// AddTxs adds the transactions in a single bolt transaction.
+func (c *BoltChain) AddTxs(txWraps []TxWrapper, check TxChecker) error {
+	defer observeChainDBOp("bolt", "add_txs", time.Now())
+
+	c.wmux.Lock()
+	defer c.wmux.Unlock()
+
+	if e := checkTxsNew(txWraps, c.hasTxOfHash); e != nil {
+		return e
+	}
+	for i := range txWraps {
+		if e := check(&txWraps[i].Tx); e != nil {
+			c.l.WithError(e).Error("failed")
+			return e
+		}
+	}
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	e := c.db.Update(func(tx *bolt.Tx) error {
+		var (
+			txsBkt    = tx.Bucket(boltTxsBucket)
+			hashesBkt = tx.Bucket(boltHashesBucket)
+			cLen      = uint64(c.len.Val())
+		)
+		for i, txWrap := range txWraps {
+			var (
+				seq  = boltSeqKey(cLen + uint64(i))
+				hash = txWrap.Tx.Hash()
+			)
+			if e := txsBkt.Put(seq, c.codec.EncodeTx(txWrap)); e != nil {
+				return e
+			}
+			if e := hashesBkt.Put(hash[:], seq); e != nil {
+				return e
+			}
+		}
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+	c.wrote()
+	for i := range txWraps {
+		c.len.Inc()
+		c.attemptPushAccepted(&txWraps[i])
+	}
+	return nil
+}
+
+// hasTxOfHash determines whether a tx of the hash is stored.
+func (c *BoltChain) hasTxOfHash(hash TxHash) (bool, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	var ok bool
+	return ok, c.db.View(func(tx *bolt.Tx) error {
+		ok = tx.Bucket(boltHashesBucket).Get(hash[:]) != nil
+		return nil
+	})
+}
+
+func (c *BoltChain) GetTxOfHash(hash TxHash) (TxWrapper, error) {
+	defer observeChainDBOp("bolt", "get_tx_of_hash", time.Now())
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	var txWrap TxWrapper
+	return txWrap, c.db.View(func(tx *bolt.Tx) error {
+		return c.getTxOfHash(tx, hash, &txWrap)
+	})
+}
+
+func (c *BoltChain) GetTxOfSeq(seq uint64) (TxWrapper, error) {
+	defer observeChainDBOp("bolt", "get_tx_of_seq", time.Now())
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	var txWrap TxWrapper
+	return txWrap, c.db.View(func(tx *bolt.Tx) error {
+		return c.getTx(tx, boltSeqKey(seq), &txWrap)
+	})
+}
+
+func (c *BoltChain) TxChan() <-chan *TxWrapper {
+	return c.accepted
+}
+
+func (c *BoltChain) GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapper, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	var txWraps []TxWrapper
+	e := c.db.View(func(tx *bolt.Tx) error {
+		var e error
+		txWraps, e = c.getTxsOfSeqRange(tx, uint64(c.len.Val()), startSeq, pageSize)
+		return e
+	})
+	if e != nil {
+		return nil, e
+	}
+	return txWraps, nil
+}
+
+// Range calls 'fn' with the txs of seqs [start, end), obtained in pages.
+func (c *BoltChain) Range(start, end uint64, fn func(txWrap TxWrapper) bool) error {
+	return rangeTxPages(c, start, end, fn)
+}
+
+// Snapshot obtains a snapshot of the chain of a read-only bolt transaction.
+// Bolt does not block writes on reads, but a write that grows the file waits
+// on open read transactions to remap it, and 'Compact' waits on the snapshot
+// to be released, so snapshots should not be held longer than needed.
+func (c *BoltChain) Snapshot() (ChainSnapshot, error) {
+	c.mux.RLock()
+	tx, e := c.db.Begin(false)
+	if e != nil {
+		c.mux.RUnlock()
+		return nil, e
+	}
+	var cLen uint64
+	if last, _ := tx.Bucket(boltTxsBucket).Cursor().Last(); last != nil {
+		cLen = binary.BigEndian.Uint64(last) + 1
+	}
+	return &boltSnapshot{c: c, tx: tx, len: cLen}, nil
+}
+
+// boltSnapshot is a ChainSnapshot of a read-only bolt transaction, which holds
+// the read lock of the BoltChain until released.
+type boltSnapshot struct {
+	c    *BoltChain
+	tx   *bolt.Tx
+	len  uint64
+	once sync.Once
+}
+
+func (s *boltSnapshot) Len() uint64 {
+	return s.len
+}
+
+func (s *boltSnapshot) GetTxOfHash(hash TxHash) (TxWrapper, error) {
+	var txWrap TxWrapper
+	return txWrap, s.c.getTxOfHash(s.tx, hash, &txWrap)
+}
+
+func (s *boltSnapshot) GetTxOfSeq(seq uint64) (TxWrapper, error) {
+	var txWrap TxWrapper
+	return txWrap, s.c.getTx(s.tx, boltSeqKey(seq), &txWrap)
+}
+
+func (s *boltSnapshot) GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapper, error) {
+	return s.c.getTxsOfSeqRange(s.tx, s.len, startSeq, pageSize)
+}
+
+func (s *boltSnapshot) Release() {
+	s.once.Do(func() {
+		s.tx.Rollback()
+		s.c.mux.RUnlock()
+	})
+}
+
+func (c *BoltChain) RemoveHeadTx() (TxWrapper, error) {
+	defer observeChainDBOp("bolt", "remove_head_tx", time.Now())
+
+	c.wmux.Lock()
+	defer c.wmux.Unlock()
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	var txWrap TxWrapper
+	e := c.db.Update(func(tx *bolt.Tx) error {
+		seq, v := tx.Bucket(boltTxsBucket).Cursor().Last()
+		if v == nil {
+			return errors.New("no transactions available")
+		}
+		if e := c.codec.DecodeTx(v, &txWrap); e != nil {
+			return e
+		}
+		hash := txWrap.Tx.Hash()
+		if e := tx.Bucket(boltHashesBucket).Delete(hash[:]); e != nil {
+			return e
+		}
+		return tx.Bucket(boltTxsBucket).Delete(seq)
+	})
+	if e == nil && c.db.NoSync {
+		e = c.db.Sync()
+	}
+	if e != nil {
+		return TxWrapper{}, e
+	}
+	c.len.Dec()
+	return txWrap, nil
+}
+
+// PruneTxs removes the txs of seqs [start, end) that are not kept, in a single
+// bolt transaction.
+func (c *BoltChain) PruneTxs(start, end uint64, keep func(txWrap *TxWrapper) bool) (uint64, error) {
+	c.wmux.Lock()
+	defer c.wmux.Unlock()
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	var pruned uint64
+	e := c.db.Update(func(tx *bolt.Tx) error {
+		var (
+			txsBkt    = tx.Bucket(boltTxsBucket)
+			hashesBkt = tx.Bucket(boltHashesBucket)
+			cur       = txsBkt.Cursor()
+			endKey    = boltSeqKey(end)
+			seqs      [][]byte
+		)
+		for k, v := cur.Seek(boltSeqKey(start)); k != nil && bytes.Compare(k, endKey) < 0; k, v = cur.Next() {
+			var txWrap TxWrapper
+			if e := c.codec.DecodeTx(v, &txWrap); e != nil {
+				return e
+			}
+			if keep(&txWrap) {
+				continue
+			}
+			hash := txWrap.Tx.Hash()
+			if e := hashesBkt.Delete(hash[:]); e != nil {
+				return e
+			}
+			seqs = append(seqs, k)
+		}
+		// Txs are deleted after iterating, as deleting moves the cursor.
+		for _, seq := range seqs {
+			if e := txsBkt.Delete(seq); e != nil {
+				return e
+			}
+		}
+		pruned = uint64(len(seqs))
+		return nil
+	})
+	if e == nil && c.db.NoSync {
+		e = c.db.Sync()
+	}
+	return pruned, e
+}
+
+// Sync syncs the added txs that are not yet synced, as of the sync policy.
+func (c *BoltChain) Sync() error {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.db.Sync()
+}
+
+// wrote syncs a write of added txs if due, as of the sync policy. As the txs
+// are already committed, failing to sync is only logged.
+func (c *BoltChain) wrote() {
+	if !c.db.NoSync || !c.syncer.wrote() {
+		return
+	}
+	if e := c.db.Sync(); e != nil {
+		c.l.WithError(e).Error("failed to sync bolt chain")
+	}
+}
+
+// Compact rewrites the bolt file into a new file without the free pages, then
+// replaces the original file with it. Reads are only blocked while the files
+// are swapped.
+func (c *BoltChain) Compact(ctx context.Context) error {
+	c.wmux.Lock()
+	defer c.wmux.Unlock()
+
+	tempPath := c.c.Path + ".compact"
+	if e := c.compactTo(ctx, tempPath); e != nil {
+		os.Remove(tempPath)
+		return e
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if e := c.db.Close(); e != nil {
+		return e
+	}
+	e := os.Rename(tempPath, c.c.Path)
+	if e != nil {
+		os.Remove(tempPath)
+	}
+	if e := c.open(); e != nil {
+		return e
+	}
+	return e
+}
+
+// DiskUsage obtains the size of the bolt file, which includes the free pages
+// that are reclaimed by 'Compact'.
+func (c *BoltChain) DiskUsage() (uint64, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	info, e := os.Stat(c.c.Path)
+	if e != nil {
+		return 0, e
+	}
+	return uint64(info.Size()), nil
+}
+
+func (c *BoltChain) compactTo(ctx context.Context, path string) error {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	dst, e := bolt.Open(path, 0600, &bolt.Options{
+		Timeout: c.c.Timeout,
+	})
+	if e != nil {
+		return e
+	}
+	defer dst.Close()
+
+	return c.db.View(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltTxsBucket, boltHashesBucket, boltMetaBucket} {
+			if e := boltCopyBucket(ctx, tx.Bucket(name), dst, name); e != nil {
+				return e
+			}
+		}
+		return nil
+	})
+}
+
+/*
+	<<< HELPER FUNCTIONS >>>
+*/
+
+// boltCompactBatchSize is the number of key-value pairs that are copied per
+// bolt transaction when compacting.
+const boltCompactBatchSize = 1000
+
+func boltCopyBucket(ctx context.Context, src *bolt.Bucket, dst *bolt.DB, name []byte) error {
+	var (
+		cur  = src.Cursor()
+		k, v = cur.First()
+	)
+	for {
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+		e := dst.Update(func(tx *bolt.Tx) error {
+			b, e := tx.CreateBucketIfNotExists(name)
+			if e != nil {
+				return e
+			}
+			b.FillPercent = 1.0
+			for i := 0; k != nil && i < boltCompactBatchSize; i++ {
+				if e := b.Put(k, v); e != nil {
+					return e
+				}
+				k, v = cur.Next()
+			}
+			return nil
+		})
+		if e != nil || k == nil {
+			return e
+		}
+	}
+}
+
+func (c *BoltChain) getTxOfHash(tx *bolt.Tx, hash TxHash, txWrap *TxWrapper) error {
+	seq := tx.Bucket(boltHashesBucket).Get(hash[:])
+	if seq == nil {
+		return fmt.Errorf("tx of hash '%s': %w", hash.Hex(), ErrTxNotFound)
+	}
+	return c.getTx(tx, seq, txWrap)
+}
+
+// getTxsOfSeqRange obtains a page of the txs of the bolt tx, of which the
+// chain is of length 'cLen'.
+func (c *BoltChain) getTxsOfSeqRange(tx *bolt.Tx, cLen, startSeq, pageSize uint64) ([]TxWrapper, error) {
+	if pageSize == 0 {
+		return nil, fmt.Errorf("invalid pageSize: %d", pageSize)
+	}
+	if startSeq >= cLen {
+		return nil, fmt.Errorf("invalid startSeq: %d", startSeq)
+	}
+	if startSeq+pageSize > cLen {
+		pageSize = cLen - startSeq
+	}
+	var (
+		txWraps = make([]TxWrapper, 0, pageSize)
+		cur     = tx.Bucket(boltTxsBucket).Cursor()
+		end     = boltSeqKey(startSeq + pageSize)
+	)
+	for k, v := cur.Seek(boltSeqKey(startSeq)); k != nil; k, v = cur.Next() {
+		if bytes.Compare(k, end) >= 0 {
+			break
+		}
+		var txWrap TxWrapper
+		if e := c.codec.DecodeTx(v, &txWrap); e != nil {
+			return nil, e
+		}
+		txWraps = append(txWraps, txWrap)
+	}
+	return txWraps, nil
+}
+
+func (c *BoltChain) getTx(tx *bolt.Tx, seq []byte, txWrap *TxWrapper) error {
+	raw := tx.Bucket(boltTxsBucket).Get(seq)
+	if raw == nil {
+		return fmt.Errorf("tx of seq '%d': %w",
+			binary.BigEndian.Uint64(seq), ErrTxNotFound)
+	}
+	return c.codec.DecodeTx(raw, txWrap)
+}
+
+func boltSeqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
@@ -0,0 +1,148 @@
+package iko
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tempBoltPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "kc_chain_bolt_test")
+	require.NoError(t, err, "creation of temp dir should succeed")
+
+	return filepath.Join(dir, "chain.db"), func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func newBoltChainDB(t *testing.T, path string) *BoltChain {
+	chainDB, err := NewBoltChain(&BoltChainConfig{Path: path})
+	require.NoError(t, err, "creation of bolt chain should succeed")
+
+	return chainDB
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	require.NoError(t, err, "should obtain file info")
+	return info.Size()
+}
+
+/*
+	<<< TESTS BEGIN >>>
+*/
+
+func TestChainDB_BoltChain(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	runChainDBTest(t, chainDB)
+}
+
+func TestChainDB_BoltChain_CBOR(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+
+	chainDB, err := NewBoltChain(&BoltChainConfig{Path: path, Codec: CBORTxCodecType})
+	require.NoError(t, err, "creation of bolt chain should succeed")
+	defer chainDB.Close()
+
+	runChainDBTest(t, chainDB)
+}
+
+func TestBoltChain_CodecMismatch(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+
+	chainDB, err := NewBoltChain(&BoltChainConfig{Path: path, Codec: CBORTxCodecType})
+	require.NoError(t, err, "creation of bolt chain should succeed")
+	chainDB.Close()
+
+	_, err = NewBoltChain(&BoltChainConfig{Path: path, Codec: BinaryTxCodecType})
+	require.Equal(t, ErrCodecMismatch, err,
+		"opening chain with another codec should fail")
+}
+
+func TestBoltChain_RecoverOnRestart(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+
+	chainDB := newBoltChainDB(t, path)
+
+	txWraps := genTxWraps(10, 0)
+	for _, txWrap := range txWraps {
+		require.NoError(t, chainDB.AddTx(txWrap, addTxAlwaysApprove),
+			"should add tx with no error")
+	}
+	chainDB.Close()
+
+	chainDB = newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	require.Equal(t, uint64(len(txWraps)), chainDB.Len(),
+		"chain length should be recovered")
+
+	head, err := chainDB.Head()
+	require.NoError(t, err, "should obtain head tx")
+	require.Equal(t, txWraps[len(txWraps)-1], head,
+		"head tx should be recovered")
+
+	for _, txWrap := range txWraps {
+		reqTxWrap, err := chainDB.GetTxOfHash(txWrap.Tx.Hash())
+		require.NoError(t, err, "should obtain tx of hash")
+		require.Equal(t, txWrap, reqTxWrap, "tx should be recovered")
+	}
+}
+
+func TestBoltChain_Compact(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	txWraps := genTxWraps(2000, 0)
+	for _, txWrap := range txWraps {
+		require.NoError(t, chainDB.AddTx(txWrap, addTxAlwaysApprove),
+			"should add tx with no error")
+	}
+
+	// Pruning frees the pages of the removed txs, which compaction reclaims.
+	const pruneEnd = 1900
+	pruned, err := chainDB.PruneTxs(1, pruneEnd, func(*TxWrapper) bool { return false })
+	require.NoError(t, err, "pruning should succeed")
+	require.Equal(t, uint64(pruneEnd-1), pruned)
+
+	before := fileSize(t, path)
+	usage, err := chainDB.DiskUsage()
+	require.NoError(t, err, "should obtain disk usage")
+	require.Equal(t, uint64(before), usage, "disk usage should be of the bolt file")
+
+	require.NoError(t, chainDB.Compact(context.Background()),
+		"compaction should succeed")
+	after := fileSize(t, path)
+
+	require.True(t, after < before,
+		"compacted file (%d bytes) should be smaller than pruned file (%d bytes)",
+		after, before)
+
+	for _, txWrap := range txWraps {
+		reqTxWrap, err := chainDB.GetTxOfSeq(txWrap.Meta.Seq)
+		if seq := txWrap.Meta.Seq; seq > 0 && seq < pruneEnd {
+			require.Error(t, err, "pruned tx should not be restored by compaction")
+			continue
+		}
+		require.NoError(t, err, "should obtain tx of seq after compaction")
+		require.Equal(t, txWrap, reqTxWrap, "tx should be retained")
+	}
+
+	require.NoError(t, chainDB.AddTx(genTxWraps(1, len(txWraps))[0], addTxAlwaysApprove),
+		"should add tx after compaction")
+}
@@ -1,10 +1,12 @@
 package iko
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/skycoin/cxo/node"
 	"github.com/skycoin/cxo/skyobject"
@@ -25,6 +27,10 @@ type CXOStore struct {
 }
 
 var (
+	// ErrTxFieldsUnsupported occurs when adding a tx with optional fields
+	// (such as a memo, fee or lock) to a chain db that only stores tx bodies.
+	ErrTxFieldsUnsupported = errors.New("chain db does not support optional tx fields")
+
 	cxoReg = registry.NewRegistry(func(r *registry.Reg) {
 		r.Register("cipher.Address", cipher.Address{})
 		r.Register("iko.Transaction", Transaction{})
@@ -95,7 +101,7 @@ func NewCXOChain(config *CXOChainConfig, modifyNC ...NodeConfigModifier) (*CXOCh
 		c:        config,
 		l:        log,
 		received: make(chan *TxWrapper),
-		accepted: make(chan *TxWrapper),
+		accepted: make(chan *TxWrapper, txChanBufSize),
 	}
 
 	var modify NodeConfigModifier
@@ -405,10 +411,20 @@ func (c *CXOChain) Len() uint64 {
 }
 
 func (c *CXOChain) AddTx(txWrap TxWrapper, check TxChecker) error {
+	defer observeChainDBOp("cxo", "add_tx", time.Now())
 
 	if c.c.MasterRooter == false {
 		return errors.New("not master node")
 	}
+	if txWrap.Tx.Witness != nil {
+		return ErrWitnessUnsupported
+	}
+	if txWrap.Tx.serializeFields(true) != nil {
+		return ErrTxFieldsUnsupported
+	}
+	if e := checkTxsNew([]TxWrapper{txWrap}, c.hasTxOfHash); e != nil {
+		return e
+	}
 	if e := check(&txWrap.Tx); e != nil {
 		c.l.WithError(e).Error("failed")
 		return e
@@ -439,7 +455,53 @@ func (c *CXOChain) AddTx(txWrap TxWrapper, check TxChecker) error {
 	return nil
 }
 
+// RemoveHeadTx removes the head tx and publishes the new root. Only the master
+// node can remove txs, and the new root is rejected by nodes that already
+// received the removed tx.
+func (c *CXOChain) RemoveHeadTx() (TxWrapper, error) {
+	defer observeChainDBOp("cxo", "remove_head_tx", time.Now())
+
+	var txWrap TxWrapper
+	if c.c.MasterRooter == false {
+		return txWrap, errors.New("not master node")
+	}
+
+	defer c.lock()()
+	cLen := c.len.Val()
+	if cLen < 1 {
+		return txWrap, errors.New("no transactions available")
+	}
+
+	store, r, up, e := c.getStore(gsWrite)
+	if e != nil {
+		return txWrap, e
+	}
+	if _, e := store.Txs.ValueByIndex(up, cLen-1, &txWrap.Tx); e != nil {
+		return txWrap, e
+	}
+	if _, e := store.Metas.ValueByIndex(up, cLen-1, &txWrap.Meta); e != nil {
+		return txWrap, e
+	}
+	if e := store.Txs.DeleteByIndex(up, cLen-1); e != nil {
+		return txWrap, e
+	}
+	if e := store.Metas.DeleteByIndex(up, cLen-1); e != nil {
+		return txWrap, e
+	}
+	if e := r.Refs[0].SetValue(up, store); e != nil {
+		return txWrap, e
+	}
+	if e := c.node.Container().Save(up.(*skyobject.Unpack), r); e != nil {
+		return txWrap, e
+	}
+	c.node.Publish(r)
+	c.len.Set(cLen - 1)
+	return txWrap, nil
+}
+
 func (c *CXOChain) GetTxOfHash(hash TxHash) (TxWrapper, error) {
+	defer observeChainDBOp("cxo", "get_tx_of_hash", time.Now())
+
 	defer c.lock()()
 	var txWrap TxWrapper
 
@@ -448,6 +510,9 @@ func (c *CXOChain) GetTxOfHash(hash TxHash) (TxWrapper, error) {
 		return txWrap, e
 	}
 	i, e := store.Txs.ValueOfHashWithIndex(p, cipher.SHA256(hash), &txWrap.Tx)
+	if e == registry.ErrNotFound {
+		return txWrap, fmt.Errorf("tx of hash '%s': %w", hash.Hex(), ErrTxNotFound)
+	}
 	if e != nil {
 		return txWrap, e
 	}
@@ -457,7 +522,18 @@ func (c *CXOChain) GetTxOfHash(hash TxHash) (TxWrapper, error) {
 	return txWrap, nil
 }
 
+// hasTxOfHash determines whether a tx of the hash is stored.
+func (c *CXOChain) hasTxOfHash(hash TxHash) (bool, error) {
+	_, e := c.GetTxOfHash(hash)
+	if errors.Is(e, ErrTxNotFound) {
+		return false, nil
+	}
+	return e == nil, e
+}
+
 func (c *CXOChain) GetTxOfSeq(seq uint64) (TxWrapper, error) {
+	defer observeChainDBOp("cxo", "get_tx_of_seq", time.Now())
+
 	defer c.lock()()
 	var txWrap TxWrapper
 
@@ -465,6 +541,9 @@ func (c *CXOChain) GetTxOfSeq(seq uint64) (TxWrapper, error) {
 	if e != nil {
 		return txWrap, e
 	}
+	if seq >= uint64(c.len.Val()) {
+		return txWrap, fmt.Errorf("tx of seq '%d': %w", seq, ErrTxNotFound)
+	}
 	if _, e := store.Txs.ValueByIndex(p, int(seq), &txWrap.Tx); e != nil {
 		return txWrap, e
 	}
@@ -533,6 +612,25 @@ func (c *CXOChain) GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapp
 	return txWraps, e
 }
 
+// Range calls 'fn' with the txs of seqs [start, end), obtained in pages.
+func (c *CXOChain) Range(start, end uint64, fn func(txWrap TxWrapper) bool) error {
+	return rangeTxPages(c, start, end, fn)
+}
+
+// Compact has nothing to reclaim for CXOChain, as CXO releases objects that are
+// no longer referenced by the root on it's own.
+func (c *CXOChain) Compact(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// DiskUsage obtains the size of the CXO data directory.
+func (c *CXOChain) DiskUsage() (uint64, error) {
+	if c.c.Memory || c.c.Dir == "" {
+		return 0, nil
+	}
+	return dirSize(c.c.Dir)
+}
+
 type getStoreType int
 
 const (
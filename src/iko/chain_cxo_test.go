@@ -390,3 +390,15 @@ func TestNewCXOChain(t *testing.T) {
 		slave.Close()
 	})
 }
+
+func TestCXOChain_TxFields(t *testing.T) {
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	tx := NewGenTx(0, GenSK)
+	tx.Memo = []byte("memo")
+	tx.Sig = tx.Sign(GenSK)
+	_, err := bc.InjectTx(tx)
+	require.Equal(t, ErrTxFieldsUnsupported, err,
+		"txs with optional fields should be rejected rather than stored without them")
+}
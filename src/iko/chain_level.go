@@ -0,0 +1,563 @@
+package iko
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"gopkg.in/sirupsen/logrus.v1"
+
+	kcutil "github.com/kittycash/wallet/src/util"
+)
+
+var (
+	levelTxPrefix   = []byte("s") // "s" + seq -> serialized TxWrapper
+	levelHashPrefix = []byte("h") // "h" + tx hash -> seq
+	levelLenKey     = []byte("l") // chain length
+	levelCodecKey   = []byte("c") // tx codec type
+	levelSchemaKey  = []byte("v") // schema version
+)
+
+type LevelChainConfig struct {
+	Dir   string
+	Codec TxCodecType // Encoding of stored txs, defaults to 'BinaryTxCodecType'.
+	Sync  SyncPolicy  // When added txs are synced, defaults to every write.
+}
+
+func (c *LevelChainConfig) Process(log *logrus.Logger) error {
+	if c.Dir == "" {
+		return errors.New("no directory specified for leveldb chain")
+	}
+	if c.Codec == "" {
+		c.Codec = BinaryTxCodecType
+	}
+	return c.Sync.Process()
+}
+
+// LevelChain is a ChainDB implementation that stores transactions in LevelDB.
+// It has better write throughput than BoltChain when many transactions are
+// injected. Each transaction is written with it's hash index in a single
+// batch, which is synced as of 'LevelChainConfig.Sync'. Removals are always
+// synced.
+type LevelChain struct {
+	mux      sync.Mutex // serializes writes
+	c        *LevelChainConfig
+	l        *logrus.Logger
+	db       *leveldb.DB
+	codec    TxCodec
+	accepted chan *TxWrapper
+	syncer   *chainSyncer
+
+	len kcutil.SafeInt
+}
+
+func NewLevelChain(config *LevelChainConfig) (*LevelChain, error) {
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.DebugLevel,
+	}
+	if e := config.Process(log); e != nil {
+		return nil, e
+	}
+
+	codec, e := NewTxCodec(config.Codec)
+	if e != nil {
+		return nil, e
+	}
+	db, e := leveldb.OpenFile(config.Dir, nil)
+	if e != nil {
+		return nil, e
+	}
+
+	chain := &LevelChain{
+		c:        config,
+		l:        log,
+		db:       db,
+		codec:    codec,
+		accepted: make(chan *TxWrapper, txChanBufSize),
+	}
+
+	switch raw, e := db.Get(levelLenKey, nil); e {
+	case nil:
+		chain.len.Set(int(binary.BigEndian.Uint64(raw)))
+	case leveldb.ErrNotFound:
+	default:
+		db.Close()
+		return nil, e
+	}
+
+	stored, e := db.Get(levelCodecKey, nil)
+	switch e {
+	case nil:
+	case leveldb.ErrNotFound:
+		stored = nil
+	default:
+		db.Close()
+		return nil, e
+	}
+	if e := chain.migrate(&stored); e != nil {
+		db.Close()
+		return nil, e
+	}
+	if e := checkStoredCodec(codec, stored, chain.len.Val()); e != nil {
+		db.Close()
+		return nil, e
+	}
+	if stored == nil {
+		if e := db.Put(levelCodecKey, []byte(codec.Type()), nil); e != nil {
+			db.Close()
+			return nil, e
+		}
+	}
+
+	chain.syncer = newChainSyncer(config.Sync, chain.Sync, func(e error) {
+		log.WithError(e).Error("failed to sync leveldb chain")
+	})
+
+	log.WithField("height", chain.len.Val()).
+		Info("leveldb blockchain initialized")
+
+	return chain, nil
+}
+
+func (c *LevelChain) Close() {
+	c.syncer.stop()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	close(c.accepted)
+
+	if c.c.Sync.Mode != SyncEveryWrite {
+		if e := c.sync(); e != nil {
+			c.l.WithError(e).
+				Error("error on leveldb sync")
+		}
+	}
+
+	if e := c.db.Close(); e != nil {
+		c.l.WithError(e).
+			Error("error on leveldb close")
+	}
+	c.l.Print("closed leveldb chain DB")
+}
+
+// migrate upgrades the data to 'ChainSchemaVersion'. The migrations are
+// written with the new version in a single synced batch. The stored codec is
+// updated by the migrations.
+func (c *LevelChain) migrate(storedCodec *[]byte) error {
+	raw, e := c.db.Get(levelSchemaKey, nil)
+	if e != nil && e != leveldb.ErrNotFound {
+		return e
+	}
+	version, e := storedSchemaVersion(raw, c.len.Val(), *storedCodec)
+	if e != nil {
+		return e
+	}
+	var (
+		batch   = new(leveldb.Batch)
+		updated = *storedCodec
+	)
+	e = migrateSchema(c.l, version, []func() error{
+		func() error {
+			if v := migrateRecordCodec(updated, c.len.Val()); v != nil {
+				updated = v
+				batch.Put(levelCodecKey, v)
+			}
+			return nil
+		},
+	})
+	if e != nil {
+		return e
+	}
+	batch.Put(levelSchemaKey, encodeSchemaVersion(ChainSchemaVersion))
+	if e := c.db.Write(batch, &opt.WriteOptions{Sync: true}); e != nil {
+		return e
+	}
+	*storedCodec = updated
+	return nil
+}
+
+func (c *LevelChain) attemptPushAccepted(txWrap *TxWrapper) {
+	select {
+	case c.accepted <- txWrap:
+	default:
+	}
+}
+
+/*
+	<<< PUBLIC FUNCTIONS >>>
+*/
+
+func (c *LevelChain) Head() (TxWrapper, error) {
+	cLen := uint64(c.len.Val())
+	if cLen < 1 {
+		return TxWrapper{}, errors.New("no transactions available")
+	}
+	return c.GetTxOfSeq(cLen - 1)
+}
+
+func (c *LevelChain) Len() uint64 {
+	return uint64(c.len.Val())
+}
+
+func (c *LevelChain) AddTx(txWrap TxWrapper, check TxChecker) error {
+	defer observeChainDBOp("level", "add_tx", time.Now())
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if e := checkTxsNew([]TxWrapper{txWrap}, c.hasTxOfHash); e != nil {
+		return e
+	}
+	if e := check(&txWrap.Tx); e != nil {
+		c.l.WithError(e).Error("failed")
+		return e
+	}
+
+	var (
+		cLen = uint64(c.len.Val())
+		hash = txWrap.Tx.Hash()
+		b    = new(leveldb.Batch)
+	)
+	b.Put(levelTxKey(cLen), c.codec.EncodeTx(txWrap))
+	b.Put(levelHashKey(hash), levelSeq(cLen))
+	b.Put(levelLenKey, levelSeq(cLen+1))
+
+	if e := c.db.Write(b, &opt.WriteOptions{Sync: c.syncer.wrote()}); e != nil {
+		return e
+	}
+	c.len.Inc()
+	c.attemptPushAccepted(&txWrap)
+	return nil
+}
+
+// AddTxs adds the transactions in a single leveldb batch.
+func (c *LevelChain) AddTxs(txWraps []TxWrapper, check TxChecker) error {
+	defer observeChainDBOp("level", "add_txs", time.Now())
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if e := checkTxsNew(txWraps, c.hasTxOfHash); e != nil {
+		return e
+	}
+	for i := range txWraps {
+		if e := check(&txWraps[i].Tx); e != nil {
+			c.l.WithError(e).Error("failed")
+			return e
+		}
+	}
+
+	var (
+		cLen = uint64(c.len.Val())
+		b    = new(leveldb.Batch)
+	)
+	for i, txWrap := range txWraps {
+		seq := cLen + uint64(i)
+		b.Put(levelTxKey(seq), c.codec.EncodeTx(txWrap))
+		b.Put(levelHashKey(txWrap.Tx.Hash()), levelSeq(seq))
+	}
+	b.Put(levelLenKey, levelSeq(cLen+uint64(len(txWraps))))
+
+	if e := c.db.Write(b, &opt.WriteOptions{Sync: c.syncer.wrote()}); e != nil {
+		return e
+	}
+	for i := range txWraps {
+		c.len.Inc()
+		c.attemptPushAccepted(&txWraps[i])
+	}
+	return nil
+}
+
+// hasTxOfHash determines whether a tx of the hash is stored.
+func (c *LevelChain) hasTxOfHash(hash TxHash) (bool, error) {
+	return c.db.Has(levelHashKey(hash), nil)
+}
+
+// Sync syncs the added txs that are not yet synced, as of the sync policy.
+func (c *LevelChain) Sync() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.sync()
+}
+
+// sync syncs the journal of LevelDB (and so all prior writes) with a synced
+// write of the length.
+func (c *LevelChain) sync() error {
+	b := new(leveldb.Batch)
+	b.Put(levelLenKey, levelSeq(uint64(c.len.Val())))
+	return c.db.Write(b, &opt.WriteOptions{Sync: true})
+}
+
+func (c *LevelChain) RemoveHeadTx() (TxWrapper, error) {
+	defer observeChainDBOp("level", "remove_head_tx", time.Now())
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	cLen := uint64(c.len.Val())
+	if cLen < 1 {
+		return TxWrapper{}, errors.New("no transactions available")
+	}
+	txWrap, e := c.GetTxOfSeq(cLen - 1)
+	if e != nil {
+		return TxWrapper{}, e
+	}
+
+	b := new(leveldb.Batch)
+	b.Delete(levelTxKey(cLen - 1))
+	b.Delete(levelHashKey(txWrap.Tx.Hash()))
+	b.Put(levelLenKey, levelSeq(cLen-1))
+
+	if e := c.db.Write(b, &opt.WriteOptions{Sync: true}); e != nil {
+		return TxWrapper{}, e
+	}
+	c.len.Dec()
+	return txWrap, nil
+}
+
+func (c *LevelChain) GetTxOfHash(hash TxHash) (TxWrapper, error) {
+	defer observeChainDBOp("level", "get_tx_of_hash", time.Now())
+	return c.getTxOfHash(c.db, hash)
+}
+
+func (c *LevelChain) GetTxOfSeq(seq uint64) (TxWrapper, error) {
+	defer observeChainDBOp("level", "get_tx_of_seq", time.Now())
+	return c.getTxOfSeq(c.db, seq)
+}
+
+func (c *LevelChain) TxChan() <-chan *TxWrapper {
+	return c.accepted
+}
+
+func (c *LevelChain) GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapper, error) {
+	return c.getTxsOfSeqRange(c.db, uint64(c.len.Val()), startSeq, pageSize)
+}
+
+// Snapshot obtains a snapshot of the chain of a leveldb snapshot, which does
+// not block writes.
+func (c *LevelChain) Snapshot() (ChainSnapshot, error) {
+	snap, e := c.db.GetSnapshot()
+	if e != nil {
+		return nil, e
+	}
+	var cLen uint64
+	switch raw, e := snap.Get(levelLenKey, nil); e {
+	case nil:
+		cLen = binary.BigEndian.Uint64(raw)
+	case leveldb.ErrNotFound:
+	default:
+		snap.Release()
+		return nil, e
+	}
+	return &levelSnapshot{c: c, snap: snap, len: cLen}, nil
+}
+
+// levelSnapshot is a ChainSnapshot of a leveldb snapshot.
+type levelSnapshot struct {
+	c    *LevelChain
+	snap *leveldb.Snapshot
+	len  uint64
+}
+
+func (s *levelSnapshot) Len() uint64 {
+	return s.len
+}
+
+func (s *levelSnapshot) GetTxOfHash(hash TxHash) (TxWrapper, error) {
+	return s.c.getTxOfHash(s.snap, hash)
+}
+
+func (s *levelSnapshot) GetTxOfSeq(seq uint64) (TxWrapper, error) {
+	return s.c.getTxOfSeq(s.snap, seq)
+}
+
+func (s *levelSnapshot) GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapper, error) {
+	return s.c.getTxsOfSeqRange(s.snap, s.len, startSeq, pageSize)
+}
+
+func (s *levelSnapshot) Release() {
+	s.snap.Release()
+}
+
+// Range calls 'fn' with the txs of seqs [start, end), read with an iterator of
+// a snapshot of the db, so writes are not blocked by 'fn'.
+func (c *LevelChain) Range(start, end uint64, fn func(txWrap TxWrapper) bool) error {
+	if cLen := uint64(c.len.Val()); end > cLen {
+		end = cLen
+	}
+	if start >= end {
+		return nil
+	}
+	it := c.db.NewIterator(&util.Range{
+		Start: levelTxKey(start),
+		Limit: levelTxKey(end),
+	}, nil)
+	defer it.Release()
+
+	for it.Next() {
+		var txWrap TxWrapper
+		if e := c.codec.DecodeTx(it.Value(), &txWrap); e != nil {
+			return e
+		}
+		if !fn(txWrap) {
+			return nil
+		}
+	}
+	return it.Error()
+}
+
+// PruneTxs removes the txs of seqs [start, end) that are not kept, in a single
+// synced batch.
+func (c *LevelChain) PruneTxs(start, end uint64, keep func(txWrap *TxWrapper) bool) (uint64, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var (
+		b      = new(leveldb.Batch)
+		pruned uint64
+		it     = c.db.NewIterator(&util.Range{
+			Start: levelTxKey(start),
+			Limit: levelTxKey(end),
+		}, nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		var txWrap TxWrapper
+		if e := c.codec.DecodeTx(it.Value(), &txWrap); e != nil {
+			return 0, e
+		}
+		if keep(&txWrap) {
+			continue
+		}
+		b.Delete(levelTxKey(txWrap.Meta.Seq))
+		b.Delete(levelHashKey(txWrap.Tx.Hash()))
+		pruned++
+	}
+	if e := it.Error(); e != nil {
+		return 0, e
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+	return pruned, c.db.Write(b, &opt.WriteOptions{Sync: true})
+}
+
+// Compact triggers a full compaction of the LevelDB database. LevelDB allows
+// reads and writes during compaction, so nothing is blocked. If 'ctx' is
+// cancelled, Compact returns early while the compaction finishes in the
+// background.
+func (c *LevelChain) Compact(ctx context.Context) error {
+	if e := ctx.Err(); e != nil {
+		return e
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.db.CompactRange(util.Range{})
+	}()
+	select {
+	case e := <-done:
+		return e
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DiskUsage obtains the size of the LevelDB directory.
+func (c *LevelChain) DiskUsage() (uint64, error) {
+	return dirSize(c.c.Dir)
+}
+
+/*
+	<<< HELPER FUNCTIONS >>>
+*/
+
+// levelReader reads from the db, or from a snapshot of it.
+type levelReader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+func (c *LevelChain) getTxOfHash(r levelReader, hash TxHash) (TxWrapper, error) {
+	seq, e := r.Get(levelHashKey(hash), nil)
+	if e != nil {
+		if e == leveldb.ErrNotFound {
+			e = fmt.Errorf("tx of hash '%s': %w", hash.Hex(), ErrTxNotFound)
+		}
+		return TxWrapper{}, e
+	}
+	return c.getTxOfSeq(r, binary.BigEndian.Uint64(seq))
+}
+
+func (c *LevelChain) getTxOfSeq(r levelReader, seq uint64) (TxWrapper, error) {
+	var txWrap TxWrapper
+	raw, e := r.Get(levelTxKey(seq), nil)
+	if e != nil {
+		if e == leveldb.ErrNotFound {
+			e = fmt.Errorf("tx of seq '%d': %w", seq, ErrTxNotFound)
+		}
+		return txWrap, e
+	}
+	return txWrap, c.codec.DecodeTx(raw, &txWrap)
+}
+
+// getTxsOfSeqRange obtains a page of the txs of the reader, of which the chain
+// is of length 'cLen'.
+func (c *LevelChain) getTxsOfSeqRange(r levelReader, cLen, startSeq, pageSize uint64) ([]TxWrapper, error) {
+	if pageSize == 0 {
+		return nil, fmt.Errorf("invalid pageSize: %d", pageSize)
+	}
+	if startSeq >= cLen {
+		return nil, fmt.Errorf("invalid startSeq: %d", startSeq)
+	}
+	if startSeq+pageSize > cLen {
+		pageSize = cLen - startSeq
+	}
+	var (
+		txWraps = make([]TxWrapper, 0, pageSize)
+		it      = r.NewIterator(&util.Range{
+			Start: levelTxKey(startSeq),
+			Limit: levelTxKey(startSeq + pageSize),
+		}, nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		var txWrap TxWrapper
+		if e := c.codec.DecodeTx(it.Value(), &txWrap); e != nil {
+			return nil, e
+		}
+		txWraps = append(txWraps, txWrap)
+	}
+	if e := it.Error(); e != nil {
+		return nil, e
+	}
+	return txWraps, nil
+}
+
+func levelSeq(seq uint64) []byte {
+	out := make([]byte, 8)
+	binary.BigEndian.PutUint64(out, seq)
+	return out
+}
+
+func levelTxKey(seq uint64) []byte {
+	return append(append([]byte{}, levelTxPrefix...), levelSeq(seq)...)
+}
+
+func levelHashKey(hash TxHash) []byte {
+	return append(append([]byte{}, levelHashPrefix...), hash[:]...)
+}
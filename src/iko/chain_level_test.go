@@ -0,0 +1,94 @@
+package iko
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tempLevelDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "kc_chain_level_test")
+	require.NoError(t, err, "creation of temp dir should succeed")
+
+	return dir, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func newLevelChainDB(t *testing.T, dir string) *LevelChain {
+	chainDB, err := NewLevelChain(&LevelChainConfig{Dir: dir})
+	require.NoError(t, err, "creation of leveldb chain should succeed")
+
+	return chainDB
+}
+
+/*
+	<<< TESTS BEGIN >>>
+*/
+
+func TestChainDB_LevelChain(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	chainDB := newLevelChainDB(t, dir)
+	defer chainDB.Close()
+
+	runChainDBTest(t, chainDB)
+}
+
+func TestChainDB_LevelChain_CBOR(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	chainDB, err := NewLevelChain(&LevelChainConfig{Dir: dir, Codec: CBORTxCodecType})
+	require.NoError(t, err, "creation of leveldb chain should succeed")
+	defer chainDB.Close()
+
+	runChainDBTest(t, chainDB)
+}
+
+func TestLevelChain_CodecMismatch(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	chainDB, err := NewLevelChain(&LevelChainConfig{Dir: dir, Codec: CBORTxCodecType})
+	require.NoError(t, err, "creation of leveldb chain should succeed")
+	chainDB.Close()
+
+	_, err = NewLevelChain(&LevelChainConfig{Dir: dir})
+	require.Equal(t, ErrCodecMismatch, err,
+		"opening chain with another codec should fail")
+}
+
+func TestLevelChain_RecoverOnRestart(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	chainDB := newLevelChainDB(t, dir)
+
+	txWraps := genTxWraps(10, 0)
+	for _, txWrap := range txWraps {
+		require.NoError(t, chainDB.AddTx(txWrap, addTxAlwaysApprove),
+			"should add tx with no error")
+	}
+	chainDB.Close()
+
+	chainDB = newLevelChainDB(t, dir)
+	defer chainDB.Close()
+
+	require.Equal(t, uint64(len(txWraps)), chainDB.Len(),
+		"chain length should be recovered")
+
+	head, err := chainDB.Head()
+	require.NoError(t, err, "should obtain head tx")
+	require.Equal(t, txWraps[len(txWraps)-1], head,
+		"head tx should be recovered")
+
+	for _, txWrap := range txWraps {
+		reqTxWrap, err := chainDB.GetTxOfHash(txWrap.Tx.Hash())
+		require.NoError(t, err, "should obtain tx of hash")
+		require.Equal(t, txWrap, reqTxWrap, "tx should be recovered")
+	}
+}
@@ -0,0 +1,71 @@
+package iko
+
+import "sync"
+
+// lockedChainSnapshot is the ChainSnapshot of a ChainDB that does not support
+// snapshots. The chain is read directly, with the read lock of the BlockChain
+// held until the snapshot is released, so writes are blocked as before.
+type lockedChainSnapshot struct {
+	bc   *BlockChain
+	len  uint64
+	once sync.Once
+}
+
+func (s *lockedChainSnapshot) Len() uint64 {
+	return s.len
+}
+
+func (s *lockedChainSnapshot) GetTxOfHash(hash TxHash) (TxWrapper, error) {
+	return s.bc.chain.GetTxOfHash(hash)
+}
+
+func (s *lockedChainSnapshot) GetTxOfSeq(seq uint64) (TxWrapper, error) {
+	return s.bc.chain.GetTxOfSeq(seq)
+}
+
+func (s *lockedChainSnapshot) GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapper, error) {
+	return s.bc.chain.GetTxsOfSeqRange(startSeq, pageSize)
+}
+
+func (s *lockedChainSnapshot) Release() {
+	s.once.Do(s.bc.mux.RUnlock)
+}
+
+// chainView reads the txs of a snapshot of the chain, looking them up in the
+// tx cache first. Txs read from the snapshot are not added to the cache, as
+// they may be removed from the chain by then.
+type chainView struct {
+	ChainSnapshot
+	cache *txCache
+}
+
+func (v chainView) txOfHash(hash TxHash) (TxWrapper, error) {
+	if txWrap, ok := v.cache.getOfHash(hash); ok && txWrap.Meta.Seq < v.Len() {
+		return txWrap, nil
+	}
+	return v.GetTxOfHash(hash)
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// snapshotChain obtains a view of the chain that is consistent with the state.
+// The caller should hold the read lock, and should release the view in place
+// of the read lock (the read lock is released on error). When the chain db
+// supports snapshots, the read lock is released straight away, so that long
+// reads of the view (such as of large pages) do not block writes.
+func (bc *BlockChain) snapshotChain() (chainView, error) {
+	snapDB, ok := bc.chain.(SnapshotChainDB)
+	if !ok {
+		snap := &lockedChainSnapshot{bc: bc, len: bc.chain.Len()}
+		return chainView{ChainSnapshot: snap, cache: bc.txCache}, nil
+	}
+	defer bc.mux.RUnlock()
+
+	snap, e := snapDB.Snapshot()
+	if e != nil {
+		return chainView{}, e
+	}
+	return chainView{ChainSnapshot: snap, cache: bc.txCache}, nil
+}
@@ -0,0 +1,69 @@
+package iko
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_SnapshotChain(t *testing.T) {
+	t.Run("Snapshot", func(t *testing.T) {
+		dir, rmTemp := tempLevelDir(t)
+		defer rmTemp()
+
+		chainDB := newLevelChainDB(t, dir)
+		defer chainDB.Close()
+
+		bc, err := NewBlockChain(context.Background(),
+			&BlockChainConfig{GenerationPK: GenPK}, chainDB, NewMemoryState())
+		require.NoError(t, err)
+		defer bc.Close()
+
+		injectGenTxs(t, bc, 3)
+
+		bc.mux.RLock()
+		chain, err := bc.snapshotChain()
+		require.NoError(t, err)
+		defer chain.Release()
+
+		_, err = bc.InjectTx(NewGenTx(3, GenSK))
+		require.NoError(t, err, "writes should not be blocked by the snapshot")
+
+		require.Equal(t, uint64(3), chain.Len())
+		txWraps, err := chain.GetTxsOfSeqRange(0, 10)
+		require.NoError(t, err)
+		require.Len(t, txWraps, 3, "snapshot should not see later writes")
+
+		page, err := bc.GetTransactionPage(0, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, page.Transactions, 4)
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+		defer closeBC()
+
+		injectGenTxs(t, bc, 3)
+
+		bc.mux.RLock()
+		chain, err := bc.snapshotChain()
+		require.NoError(t, err)
+
+		injected := make(chan error, 1)
+		go func() {
+			_, err := bc.InjectTx(NewGenTx(3, GenSK))
+			injected <- err
+		}()
+
+		require.Equal(t, uint64(3), chain.Len())
+		select {
+		case <-injected:
+			t.Fatal("writes should be blocked until the chain is released")
+		default:
+		}
+		chain.Release()
+		chain.Release()
+		require.NoError(t, <-injected)
+	})
+}
@@ -0,0 +1,495 @@
+package iko
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/sirupsen/logrus.v1"
+
+	"github.com/kittycash/wallet/src/util"
+)
+
+// SQLiteDriverName is the default 'database/sql' driver used by SQLChain.
+// The driver ("github.com/mattn/go-sqlite3", as imported by 'cmd/iko' and
+// 'cmd/wallet') needs to be imported by the executable for SQLChain to be
+// usable.
+const SQLiteDriverName = "sqlite3"
+
+// sqlSchema creates the transactions table. Columns other than 'raw' are
+// stored so that operators can run reporting queries against the chain.
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS transactions (
+	seq          INTEGER PRIMARY KEY,
+	hash         TEXT    NOT NULL UNIQUE,
+	kitty_id     INTEGER NOT NULL,
+	in_hash      TEXT    NOT NULL,
+	from_address TEXT    NOT NULL,
+	to_address   TEXT    NOT NULL,
+	sig          TEXT    NOT NULL,
+	timestamp    INTEGER NOT NULL,
+	raw          BLOB    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS transactions_kitty_id ON transactions (kitty_id);
+CREATE INDEX IF NOT EXISTS transactions_from_address ON transactions (from_address);
+CREATE INDEX IF NOT EXISTS transactions_to_address ON transactions (to_address);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+type SQLChainConfig struct {
+	Driver     string      // Defaults to 'SQLiteDriverName'.
+	DataSource string      // E.g. the path of the SQLite database file.
+	Codec      TxCodecType // Encoding of the 'raw' column, defaults to 'BinaryTxCodecType'.
+}
+
+func (c *SQLChainConfig) Process(log *logrus.Logger) error {
+	if c.Driver == "" {
+		c.Driver = SQLiteDriverName
+	}
+	if c.DataSource == "" {
+		return errors.New("no data source specified for sql chain")
+	}
+	if c.Codec == "" {
+		c.Codec = BinaryTxCodecType
+	}
+	return nil
+}
+
+// SQLChain is a ChainDB implementation that stores transactions in a
+// relational 'transactions' table (by default, in SQLite). The table can be
+// queried by other processes while the node is running.
+type SQLChain struct {
+	mux      sync.Mutex // serializes writes
+	c        *SQLChainConfig
+	l        *logrus.Logger
+	db       *sql.DB
+	codec    TxCodec
+	accepted chan *TxWrapper
+
+	len util.SafeInt
+}
+
+func NewSQLChain(config *SQLChainConfig) (*SQLChain, error) {
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.DebugLevel,
+	}
+	if e := config.Process(log); e != nil {
+		return nil, e
+	}
+
+	codec, e := NewTxCodec(config.Codec)
+	if e != nil {
+		return nil, e
+	}
+	db, e := sql.Open(config.Driver, config.DataSource)
+	if e != nil {
+		return nil, e
+	}
+	if _, e := db.Exec(sqlSchema); e != nil {
+		db.Close()
+		return nil, e
+	}
+
+	chain := &SQLChain{
+		c:        config,
+		l:        log,
+		db:       db,
+		codec:    codec,
+		accepted: make(chan *TxWrapper, txChanBufSize),
+	}
+
+	// The length is of the last seq, as older txs may be pruned.
+	var cLen int
+	if e := db.QueryRow(`SELECT COALESCE(MAX(seq) + 1, 0) FROM transactions`).Scan(&cLen); e != nil {
+		db.Close()
+		return nil, e
+	}
+	chain.len.Set(cLen)
+
+	var stored []byte
+	e = db.QueryRow(`SELECT value FROM meta WHERE key = 'codec'`).Scan(&stored)
+	if e != nil && e != sql.ErrNoRows {
+		db.Close()
+		return nil, e
+	}
+	if e := chain.migrate(&stored); e != nil {
+		db.Close()
+		return nil, e
+	}
+	if e := checkStoredCodec(codec, stored, cLen); e != nil {
+		db.Close()
+		return nil, e
+	}
+	if stored == nil {
+		_, e := db.Exec(`INSERT INTO meta (key, value) VALUES ('codec', ?)`,
+			string(codec.Type()))
+		if e != nil {
+			db.Close()
+			return nil, e
+		}
+	}
+
+	log.WithField("height", cLen).
+		Info("sql blockchain initialized")
+
+	return chain, nil
+}
+
+func (c *SQLChain) Close() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	close(c.accepted)
+
+	if e := c.db.Close(); e != nil {
+		c.l.WithError(e).
+			Error("error on sql db close")
+	}
+	c.l.Print("closed sql chain DB")
+}
+
+// migrate upgrades the data to 'ChainSchemaVersion', in a single sql tx. The
+// stored codec is updated by the migrations.
+func (c *SQLChain) migrate(storedCodec *[]byte) error {
+	var raw []byte
+	e := c.db.QueryRow(`SELECT value FROM meta WHERE key = 'schema'`).Scan(&raw)
+	if e != nil && e != sql.ErrNoRows {
+		return e
+	}
+	version, e := storedSchemaVersion(raw, c.len.Val(), *storedCodec)
+	if e != nil {
+		return e
+	}
+	dbTx, e := c.db.Begin()
+	if e != nil {
+		return e
+	}
+	updated := *storedCodec
+	e = migrateSchema(c.l, version, []func() error{
+		func() error {
+			if v := migrateRecordCodec(updated, c.len.Val()); v != nil {
+				updated = v
+				_, e := dbTx.Exec(`INSERT INTO meta (key, value) VALUES ('codec', ?)`, string(v))
+				return e
+			}
+			return nil
+		},
+	})
+	if e == nil {
+		_, e = dbTx.Exec(`DELETE FROM meta WHERE key = 'schema'`)
+	}
+	if e == nil {
+		_, e = dbTx.Exec(`INSERT INTO meta (key, value) VALUES ('schema', ?)`,
+			string(encodeSchemaVersion(ChainSchemaVersion)))
+	}
+	if e != nil {
+		dbTx.Rollback()
+		return e
+	}
+	if e := dbTx.Commit(); e != nil {
+		return e
+	}
+	*storedCodec = updated
+	return nil
+}
+
+func (c *SQLChain) attemptPushAccepted(txWrap *TxWrapper) {
+	select {
+	case c.accepted <- txWrap:
+	default:
+	}
+}
+
+/*
+	<<< PUBLIC FUNCTIONS >>>
+*/
+
+func (c *SQLChain) Head() (TxWrapper, error) {
+	cLen := uint64(c.len.Val())
+	if cLen < 1 {
+		return TxWrapper{}, errors.New("no transactions available")
+	}
+	return c.GetTxOfSeq(cLen - 1)
+}
+
+func (c *SQLChain) Len() uint64 {
+	return uint64(c.len.Val())
+}
+
+func (c *SQLChain) AddTx(txWrap TxWrapper, check TxChecker) error {
+	defer observeChainDBOp("sql", "add_tx", time.Now())
+
+	return c.AddTxs([]TxWrapper{txWrap}, check)
+}
+
+// AddTxs adds the transactions in a single SQL transaction.
+func (c *SQLChain) AddTxs(txWraps []TxWrapper, check TxChecker) error {
+	defer observeChainDBOp("sql", "add_txs", time.Now())
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if e := checkTxsNew(txWraps, c.hasTxOfHash); e != nil {
+		return e
+	}
+	for i := range txWraps {
+		if e := check(&txWraps[i].Tx); e != nil {
+			c.l.WithError(e).Error("failed")
+			return e
+		}
+	}
+
+	dbTx, e := c.db.Begin()
+	if e != nil {
+		return e
+	}
+	cLen := int64(c.len.Val())
+	for i, txWrap := range txWraps {
+		if e := c.insertTx(dbTx, cLen+int64(i), txWrap); e != nil {
+			dbTx.Rollback()
+			return e
+		}
+	}
+	if e := dbTx.Commit(); e != nil {
+		return e
+	}
+	for i := range txWraps {
+		c.len.Inc()
+		c.attemptPushAccepted(&txWraps[i])
+	}
+	return nil
+}
+
+// hasTxOfHash determines whether a tx of the hash is stored.
+func (c *SQLChain) hasTxOfHash(hash TxHash) (bool, error) {
+	var seq int64
+	e := c.db.
+		QueryRow(`SELECT seq FROM transactions WHERE hash = ?`, hash.Hex()).
+		Scan(&seq)
+	switch e {
+	case nil:
+		return true, nil
+	case sql.ErrNoRows:
+		return false, nil
+	default:
+		return false, e
+	}
+}
+
+func (c *SQLChain) insertTx(dbTx *sql.Tx, seq int64, txWrap TxWrapper) error {
+
+	// The 'from' address is the output address of the input tx.
+	var from string
+	if txWrap.Tx.In != EmptyTxHash() {
+		e := dbTx.
+			QueryRow(`SELECT to_address FROM transactions WHERE hash = ?`,
+				txWrap.Tx.In.Hex()).
+			Scan(&from)
+		if e != nil && e != sql.ErrNoRows {
+			return e
+		}
+	}
+
+	_, e := dbTx.Exec(
+		`INSERT INTO transactions
+		(seq, hash, kitty_id, in_hash, from_address, to_address, sig, timestamp, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		seq,
+		txWrap.Tx.Hash().Hex(),
+		int64(txWrap.Tx.KittyID),
+		txWrap.Tx.In.Hex(),
+		from,
+		txWrap.Tx.Out.String(),
+		txWrap.Tx.Sig.Hex(),
+		txWrap.Meta.TS,
+		c.codec.EncodeTx(txWrap),
+	)
+	return e
+}
+
+func (c *SQLChain) RemoveHeadTx() (TxWrapper, error) {
+	defer observeChainDBOp("sql", "remove_head_tx", time.Now())
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	cLen := uint64(c.len.Val())
+	if cLen < 1 {
+		return TxWrapper{}, errors.New("no transactions available")
+	}
+	txWrap, e := c.GetTxOfSeq(cLen - 1)
+	if e != nil {
+		return TxWrapper{}, e
+	}
+	if _, e := c.db.Exec(`DELETE FROM transactions WHERE seq = ?`, int64(cLen-1)); e != nil {
+		return TxWrapper{}, e
+	}
+	c.len.Dec()
+	return txWrap, nil
+}
+
+// PruneTxs removes the txs of seqs [start, end) that are not kept, in a single
+// SQL transaction.
+func (c *SQLChain) PruneTxs(start, end uint64, keep func(txWrap *TxWrapper) bool) (uint64, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	rows, e := c.db.Query(
+		`SELECT raw FROM transactions WHERE seq >= ? AND seq < ? ORDER BY seq`,
+		int64(start), int64(end))
+	if e != nil {
+		return 0, e
+	}
+	var seqs []int64
+	for rows.Next() {
+		var (
+			raw    []byte
+			txWrap TxWrapper
+		)
+		if e := rows.Scan(&raw); e != nil {
+			rows.Close()
+			return 0, e
+		}
+		if e := c.codec.DecodeTx(raw, &txWrap); e != nil {
+			rows.Close()
+			return 0, e
+		}
+		if !keep(&txWrap) {
+			seqs = append(seqs, int64(txWrap.Meta.Seq))
+		}
+	}
+	rows.Close()
+	if e := rows.Err(); e != nil {
+		return 0, e
+	}
+
+	dbTx, e := c.db.Begin()
+	if e != nil {
+		return 0, e
+	}
+	for _, seq := range seqs {
+		if _, e := dbTx.Exec(`DELETE FROM transactions WHERE seq = ?`, seq); e != nil {
+			dbTx.Rollback()
+			return 0, e
+		}
+	}
+	if e := dbTx.Commit(); e != nil {
+		return 0, e
+	}
+	return uint64(len(seqs)), nil
+}
+
+func (c *SQLChain) GetTxOfHash(hash TxHash) (TxWrapper, error) {
+	defer observeChainDBOp("sql", "get_tx_of_hash", time.Now())
+
+	txWrap, e := c.getTx(`SELECT raw FROM transactions WHERE hash = ?`, hash.Hex())
+	if e == sql.ErrNoRows {
+		e = fmt.Errorf("tx of hash '%s': %w", hash.Hex(), ErrTxNotFound)
+	}
+	return txWrap, e
+}
+
+func (c *SQLChain) GetTxOfSeq(seq uint64) (TxWrapper, error) {
+	defer observeChainDBOp("sql", "get_tx_of_seq", time.Now())
+
+	txWrap, e := c.getTx(`SELECT raw FROM transactions WHERE seq = ?`, int64(seq))
+	if e == sql.ErrNoRows {
+		e = fmt.Errorf("tx of seq '%d': %w", seq, ErrTxNotFound)
+	}
+	return txWrap, e
+}
+
+func (c *SQLChain) TxChan() <-chan *TxWrapper {
+	return c.accepted
+}
+
+func (c *SQLChain) GetTxsOfSeqRange(startSeq uint64, pageSize uint64) ([]TxWrapper, error) {
+	if pageSize == 0 {
+		return nil, fmt.Errorf("invalid pageSize: %d", pageSize)
+	}
+	cLen := uint64(c.len.Val())
+	if startSeq >= cLen {
+		return nil, fmt.Errorf("invalid startSeq: %d", startSeq)
+	}
+	if startSeq+pageSize > cLen {
+		pageSize = cLen - startSeq
+	}
+	rows, e := c.db.Query(
+		`SELECT raw FROM transactions WHERE seq >= ? AND seq < ? ORDER BY seq`,
+		int64(startSeq), int64(startSeq+pageSize))
+	if e != nil {
+		return nil, e
+	}
+	defer rows.Close()
+
+	txWraps := make([]TxWrapper, 0, pageSize)
+	for rows.Next() {
+		var (
+			raw    []byte
+			txWrap TxWrapper
+		)
+		if e := rows.Scan(&raw); e != nil {
+			return nil, e
+		}
+		if e := c.codec.DecodeTx(raw, &txWrap); e != nil {
+			return nil, e
+		}
+		txWraps = append(txWraps, txWrap)
+	}
+	if e := rows.Err(); e != nil {
+		return nil, e
+	}
+	return txWraps, nil
+}
+
+// Range calls 'fn' with the txs of seqs [start, end), obtained in pages.
+func (c *SQLChain) Range(start, end uint64, fn func(txWrap TxWrapper) bool) error {
+	return rangeTxPages(c, start, end, fn)
+}
+
+// Compact rebuilds the database file with 'VACUUM'.
+func (c *SQLChain) Compact(ctx context.Context) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	_, e := c.db.ExecContext(ctx, `VACUUM`)
+	return e
+}
+
+// DiskUsage obtains the size of the SQLite database of it's page count, which
+// includes the free pages that are reclaimed by 'Compact'.
+func (c *SQLChain) DiskUsage() (uint64, error) {
+	var pageCount, pageSize uint64
+	if e := c.db.QueryRow(`PRAGMA page_count`).Scan(&pageCount); e != nil {
+		return 0, e
+	}
+	if e := c.db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); e != nil {
+		return 0, e
+	}
+	return pageCount * pageSize, nil
+}
+
+/*
+	<<< HELPER FUNCTIONS >>>
+*/
+
+func (c *SQLChain) getTx(query string, arg interface{}) (TxWrapper, error) {
+	var (
+		raw    []byte
+		txWrap TxWrapper
+	)
+	if e := c.db.QueryRow(query, arg).Scan(&raw); e != nil {
+		return txWrap, e
+	}
+	return txWrap, c.codec.DecodeTx(raw, &txWrap)
+}
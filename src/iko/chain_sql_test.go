@@ -0,0 +1,74 @@
+package iko
+
+import (
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLChainDB(t *testing.T, dir string) *SQLChain {
+	chainDB, err := NewSQLChain(&SQLChainConfig{
+		DataSource: filepath.Join(dir, "chain.sqlite"),
+	})
+	require.NoError(t, err, "creation of sql chain should succeed")
+
+	return chainDB
+}
+
+/*
+	<<< TESTS BEGIN >>>
+*/
+
+func TestChainDB_SQLChain(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	chainDB := newSQLChainDB(t, dir)
+	defer chainDB.Close()
+
+	runChainDBTest(t, chainDB)
+}
+
+func TestSQLChain_Query(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	chainDB := newSQLChainDB(t, dir)
+	defer chainDB.Close()
+
+	txWraps := genTxWraps(5, 0)
+	for _, txWrap := range txWraps {
+		require.NoError(t, chainDB.AddTx(txWrap, addTxAlwaysApprove),
+			"should add tx with no error")
+	}
+
+	for _, txWrap := range txWraps {
+		var (
+			kittyID uint64
+			to      string
+		)
+		err := chainDB.db.
+			QueryRow(`SELECT kitty_id, to_address FROM transactions WHERE seq = ?`,
+				int64(txWrap.Meta.Seq)).
+			Scan(&kittyID, &to)
+		require.NoError(t, err, "should query transactions table")
+		require.Equal(t, uint64(txWrap.Tx.KittyID), kittyID,
+			"kitty_id column should match")
+		require.Equal(t, txWrap.Tx.Out.String(), to,
+			"to_address column should match")
+	}
+}
+
+func TestSQLChainConfig_Process(t *testing.T) {
+	config := &SQLChainConfig{}
+	require.Error(t, config.Process(nil),
+		"config with no data source should fail")
+
+	config.DataSource = "chain.sqlite"
+	require.NoError(t, config.Process(nil),
+		"config with data source should succeed")
+	require.Equal(t, SQLiteDriverName, config.Driver,
+		"driver should default to sqlite")
+}
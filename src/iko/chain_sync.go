@@ -0,0 +1,131 @@
+package iko
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SyncMode determines when writes of a persistent ChainDB are synced to disk.
+type SyncMode string
+
+const (
+	// SyncEveryWrite syncs every write (of a tx, or of a batch of txs).
+	SyncEveryWrite SyncMode = "write"
+
+	// SyncEveryN syncs every 'SyncPolicy.Count' writes.
+	SyncEveryN SyncMode = "count"
+
+	// SyncPeriodic syncs unsynced writes every 'SyncPolicy.Interval'.
+	SyncPeriodic SyncMode = "interval"
+)
+
+// SyncPolicy is the policy of syncing writes of BoltChain and LevelChain to
+// disk. Syncing less often speeds up bulk writes (such as minting thousands of
+// kitties), but unsynced writes may be lost on a crash of the OS (not of the
+// node). Unsynced writes are always synced on close.
+type SyncPolicy struct {
+	Mode     SyncMode      // Defaults to 'SyncEveryWrite'.
+	Count    int           // Writes between syncs of 'SyncEveryN'.
+	Interval time.Duration // Time between syncs of 'SyncPeriodic'.
+}
+
+func (p *SyncPolicy) Process() error {
+	switch p.Mode {
+	case "":
+		p.Mode = SyncEveryWrite
+	case SyncEveryWrite:
+	case SyncEveryN:
+		if p.Count <= 0 {
+			return fmt.Errorf("sync count of %d is invalid", p.Count)
+		}
+	case SyncPeriodic:
+		if p.Interval <= 0 {
+			return fmt.Errorf("sync interval of %v is invalid", p.Interval)
+		}
+	default:
+		return fmt.Errorf("unknown sync mode '%s'", p.Mode)
+	}
+	return nil
+}
+
+// chainSyncer tracks the unsynced writes of a ChainDB, as of it's policy.
+type chainSyncer struct {
+	p        SyncPolicy
+	sync     func() error // syncs the writes of the ChainDB
+	mux      sync.Mutex
+	unsynced int
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newChainSyncer(p SyncPolicy, sync func() error, onError func(error)) *chainSyncer {
+	s := &chainSyncer{
+		p:    p,
+		sync: sync,
+		quit: make(chan struct{}),
+	}
+	if p.Mode == SyncPeriodic {
+		s.wg.Add(1)
+		go s.service(onError)
+	}
+	return s
+}
+
+func (s *chainSyncer) service(onError func(error)) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-ticker.C:
+			if e := s.flush(); e != nil {
+				onError(e)
+			}
+		}
+	}
+}
+
+// wrote records a write, returning whether the write should be synced.
+func (s *chainSyncer) wrote() bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	switch s.p.Mode {
+	case SyncEveryN:
+		if s.unsynced++; s.unsynced < s.p.Count {
+			return false
+		}
+	case SyncPeriodic:
+		s.unsynced++
+		return false
+	}
+	s.unsynced = 0
+	return true
+}
+
+// flush syncs the unsynced writes, if any. The writes are counted as synced
+// before syncing, so 'sync' may block on writes.
+func (s *chainSyncer) flush() error {
+	s.mux.Lock()
+	unsynced := s.unsynced
+	s.unsynced = 0
+	s.mux.Unlock()
+
+	if unsynced == 0 {
+		return nil
+	}
+	return s.sync()
+}
+
+// stop stops the periodic syncs, which should be done before the ChainDB is
+// closed. Unsynced writes are then synced by the ChainDB.
+func (s *chainSyncer) stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
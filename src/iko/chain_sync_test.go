@@ -0,0 +1,103 @@
+package iko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncPolicy_Process(t *testing.T) {
+	cases := []struct {
+		name  string
+		p     SyncPolicy
+		valid bool
+	}{
+		{"Default", SyncPolicy{}, true},
+		{"EveryN", SyncPolicy{Mode: SyncEveryN, Count: 10}, true},
+		{"EveryNWithoutCount", SyncPolicy{Mode: SyncEveryN}, false},
+		{"Periodic", SyncPolicy{Mode: SyncPeriodic, Interval: time.Second}, true},
+		{"PeriodicWithoutInterval", SyncPolicy{Mode: SyncPeriodic}, false},
+		{"Unknown", SyncPolicy{Mode: "never"}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.p.Process()
+			if !c.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, c.p.Mode)
+		})
+	}
+}
+
+func TestChainSyncer(t *testing.T) {
+	t.Run("EveryN", func(t *testing.T) {
+		s := newChainSyncer(SyncPolicy{Mode: SyncEveryN, Count: 3}, nil, nil)
+		defer s.stop()
+
+		var synced []bool
+		for i := 0; i < 6; i++ {
+			synced = append(synced, s.wrote())
+		}
+		require.Equal(t, []bool{false, false, true, false, false, true}, synced)
+	})
+
+	t.Run("Periodic", func(t *testing.T) {
+		syncs := make(chan struct{}, 1)
+		s := newChainSyncer(SyncPolicy{Mode: SyncPeriodic, Interval: time.Millisecond},
+			func() error {
+				syncs <- struct{}{}
+				return nil
+			},
+			func(err error) { t.Error(err) })
+		defer s.stop()
+
+		require.False(t, s.wrote(), "writes should be synced periodically")
+		select {
+		case <-syncs:
+		case <-time.After(time.Second):
+			t.Fatal("unsynced write should be synced")
+		}
+	})
+}
+
+func TestChainDB_SyncPolicies(t *testing.T) {
+	policies := []SyncPolicy{
+		{Mode: SyncEveryN, Count: 4},
+		{Mode: SyncPeriodic, Interval: time.Millisecond},
+	}
+	for _, p := range policies {
+		t.Run(string(p.Mode), func(t *testing.T) {
+			t.Run("BoltChain", func(t *testing.T) {
+				path, rmTemp := tempBoltPath(t)
+				defer rmTemp()
+
+				chainDB, err := NewBoltChain(&BoltChainConfig{Path: path, Sync: p})
+				require.NoError(t, err)
+				runChainDBTest(t, chainDB)
+				chainDB.Close()
+
+				reopened := newBoltChainDB(t, path)
+				defer reopened.Close()
+				require.NotZero(t, reopened.Len(), "unsynced txs should be synced on close")
+			})
+
+			t.Run("LevelChain", func(t *testing.T) {
+				dir, rmTemp := tempLevelDir(t)
+				defer rmTemp()
+
+				chainDB, err := NewLevelChain(&LevelChainConfig{Dir: dir, Sync: p})
+				require.NoError(t, err)
+				runChainDBTest(t, chainDB)
+				chainDB.Close()
+
+				reopened := newLevelChainDB(t, dir)
+				defer reopened.Close()
+				require.NotZero(t, reopened.Len(), "unsynced txs should be synced on close")
+			})
+		})
+	}
+}
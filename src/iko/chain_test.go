@@ -1,8 +1,10 @@
 package iko
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -88,6 +90,8 @@ func runChainDBTest(t *testing.T, chainDB ChainDB) {
 
 		require.NotNil(t, err,
 			"Should give us an error because there are no transactions yet")
+		require.True(t, errors.Is(err, ErrTxNotFound),
+			"Error should be ErrTxNotFound")
 	})
 
 	t.Run("GetTxOfSeq_NonexistentSeq", func(t *testing.T) {
@@ -95,6 +99,14 @@ func runChainDBTest(t *testing.T, chainDB ChainDB) {
 
 		require.NotNil(t, err,
 			"Should give us an error because there are no transactions yet")
+		require.True(t, errors.Is(err, ErrTxNotFound),
+			"Error should be ErrTxNotFound")
+	})
+
+	t.Run("RemoveHeadTx_Empty", func(t *testing.T) {
+		_, err := chainDB.RemoveHeadTx()
+		require.Error(t, err,
+			"Should give us an error because there are no transactions yet")
 	})
 
 	t.Run("withTransactions", func(t *testing.T) {
@@ -121,6 +133,17 @@ func runChainDBTest(t *testing.T, chainDB ChainDB) {
 		require.NoError(t, err,
 			"We should be able to successfully add our first transaction")
 
+		t.Run("AddTx_Duplicate", func(t *testing.T) {
+			err := chainDB.AddTx(firstTxWrap, func(*Transaction) error {
+				t.Fatal("duplicate tx should not be checked")
+				return nil
+			})
+			require.True(t, errors.Is(err, ErrTxAlreadyExists),
+				"Error should be ErrTxAlreadyExists")
+			require.Equal(t, uint64(1), chainDB.Len(),
+				"duplicate tx should not be added")
+		})
+
 		t.Run("Head_Success_01", func(t *testing.T) {
 			txWrap, err := chainDB.Head()
 
@@ -165,6 +188,8 @@ func runChainDBTest(t *testing.T, chainDB ChainDB) {
 
 			require.Error(t, err,
 				"Should still give us an error because there are no transactions by that hash")
+			require.True(t, errors.Is(err, ErrTxNotFound),
+				"Error should be ErrTxNotFound")
 		})
 
 		for idx, txWrap := range txWraps {
@@ -232,6 +257,196 @@ func runChainDBTest(t *testing.T, chainDB ChainDB) {
 		})
 
 		testChainDBPagination(t, chainDB, 2)
+
+		t.Run("Range", func(t *testing.T) {
+			var got []TxWrapper
+			require.NoError(t, chainDB.Range(0, 100, func(txWrap TxWrapper) bool {
+				got = append(got, txWrap)
+				return true
+			}))
+			require.Equal(t, append(txWraps, thirdTxWrap), got,
+				"all transactions should be walked in order")
+
+			got = got[:0]
+			require.NoError(t, chainDB.Range(1, 3, func(txWrap TxWrapper) bool {
+				got = append(got, txWrap)
+				return false
+			}))
+			require.Equal(t, []TxWrapper{txWraps[1]}, got,
+				"walking should stop when fn returns false")
+
+			require.NoError(t, chainDB.Range(5, 10, func(TxWrapper) bool {
+				t.Fatal("seqs past the head should be skipped")
+				return false
+			}))
+		})
+
+		t.Run("Compact", func(t *testing.T) {
+			require.NoError(t, chainDB.Compact(context.Background()),
+				"compaction should succeed")
+
+			txWraps := append(txWraps, thirdTxWrap)
+			for _, txWrap := range txWraps {
+				reqTxWrap, err := chainDB.GetTxOfSeq(txWrap.Meta.Seq)
+				require.NoError(t, err,
+					"all transactions should still be retrievable after compaction")
+				require.Equal(t, txWrap, reqTxWrap,
+					"Should correctly return the right transaction")
+			}
+		})
+
+		t.Run("Compact_Cancelled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			require.Error(t, chainDB.Compact(ctx),
+				"compaction should stop with a cancelled context")
+		})
+
+		t.Run("DiskUsage", func(t *testing.T) {
+			_, err := chainDB.DiskUsage()
+			require.NoError(t, err,
+				"disk usage should be obtained")
+		})
+
+		t.Run("RemoveHeadTx", func(t *testing.T) {
+			headTxWrap := TxWrapper{
+				Tx: *NewGenTx(KittyID(20), GenSK),
+				Meta: TxMeta{
+					Seq: 3,
+					TS:  time.Now().UnixNano(),
+				},
+			}
+			require.NoError(t, chainDB.AddTx(headTxWrap, addTxAlwaysApprove))
+
+			removed, err := chainDB.RemoveHeadTx()
+			require.NoError(t, err, "removing the head tx should succeed")
+			require.Equal(t, headTxWrap, removed,
+				"Should return the removed transaction")
+			require.Equal(t, uint64(3), chainDB.Len())
+
+			_, err = chainDB.GetTxOfHash(headTxWrap.Tx.Hash())
+			require.True(t, errors.Is(err, ErrTxNotFound),
+				"removed transaction should not be retrievable")
+			head, err := chainDB.Head()
+			require.NoError(t, err)
+			require.Equal(t, thirdTxWrap, head,
+				"previous transaction should be the head")
+		})
+
+		t.Run("Snapshot", func(t *testing.T) {
+			snapDB, ok := chainDB.(SnapshotChainDB)
+			if !ok {
+				t.Skip("chain db does not support snapshots")
+			}
+			snap, err := snapDB.Snapshot()
+			require.NoError(t, err)
+			defer snap.Release()
+
+			headTxWrap := TxWrapper{
+				Tx: *NewGenTx(KittyID(21), GenSK),
+				Meta: TxMeta{
+					Seq: 3,
+					TS:  time.Now().UnixNano(),
+				},
+			}
+			// Bolt writes may wait on the snapshot to be released.
+			added := make(chan error, 1)
+			go func() { added <- chainDB.AddTx(headTxWrap, addTxAlwaysApprove) }()
+
+			require.Equal(t, uint64(3), snap.Len(),
+				"snapshot should not see later writes")
+			_, err = snap.GetTxOfHash(headTxWrap.Tx.Hash())
+			require.True(t, errors.Is(err, ErrTxNotFound),
+				"snapshot should not see later writes")
+			got, err := snap.GetTxsOfSeqRange(0, 10)
+			require.NoError(t, err)
+			require.Equal(t, append(txWraps, thirdTxWrap), got)
+			snap.Release()
+
+			require.NoError(t, <-added)
+			_, err = chainDB.RemoveHeadTx()
+			require.NoError(t, err)
+		})
+
+		batchDB, ok := chainDB.(BatchChainDB)
+		if !ok {
+			return
+		}
+
+		t.Run("AddTxs", func(t *testing.T) {
+			var batch []TxWrapper
+			for i := 0; i < 3; i++ {
+				batch = append(batch, TxWrapper{
+					Tx: *NewGenTx(KittyID(10+i), GenSK),
+					Meta: TxMeta{
+						Seq: uint64(3 + i),
+						TS:  time.Now().UnixNano(),
+					},
+				})
+			}
+			require.Error(t, batchDB.AddTxs(batch, addTxAlwaysReject),
+				"batch should be rejected")
+			require.Equal(t, uint64(3), chainDB.Len(),
+				"rejected batch should not be added")
+
+			err := batchDB.AddTxs(append(batch, batch[0]), addTxAlwaysApprove)
+			require.True(t, errors.Is(err, ErrTxAlreadyExists),
+				"batch that repeats a tx should be rejected")
+			require.Equal(t, uint64(3), chainDB.Len())
+
+			require.NoError(t, batchDB.AddTxs(batch, addTxAlwaysApprove),
+				"batch should be added")
+			require.Equal(t, uint64(6), chainDB.Len())
+
+			err = batchDB.AddTxs(batch[2:], addTxAlwaysApprove)
+			require.True(t, errors.Is(err, ErrTxAlreadyExists),
+				"batch of a stored tx should be rejected")
+			require.Equal(t, uint64(6), chainDB.Len())
+
+			for _, txWrap := range batch {
+				reqTxWrap, err := chainDB.GetTxOfHash(txWrap.Tx.Hash())
+				require.NoError(t, err)
+				require.Equal(t, txWrap, reqTxWrap,
+					"Should correctly return the right transaction")
+			}
+			head, err := chainDB.Head()
+			require.NoError(t, err)
+			require.Equal(t, batch[2], head)
+		})
+
+		prunableDB, ok := chainDB.(PrunableChainDB)
+		if !ok {
+			return
+		}
+
+		t.Run("PruneTxs", func(t *testing.T) {
+			keep := func(txWrap *TxWrapper) bool {
+				return txWrap.Meta.Seq == 2
+			}
+			pruned, err := prunableDB.PruneTxs(1, 4, keep)
+			require.NoError(t, err, "pruning should succeed")
+			require.Equal(t, uint64(2), pruned)
+			require.Equal(t, uint64(6), chainDB.Len(),
+				"pruning should not change the length of the chain")
+
+			_, err = chainDB.GetTxOfSeq(1)
+			require.True(t, errors.Is(err, ErrTxNotFound),
+				"pruned transaction should not be retrievable")
+			_, err = chainDB.GetTxOfHash(secondTxWrap.Tx.Hash())
+			require.True(t, errors.Is(err, ErrTxNotFound),
+				"pruned transaction should not be retrievable by hash")
+
+			page, err := chainDB.GetTxsOfSeqRange(0, 4)
+			require.NoError(t, err)
+			require.Len(t, page, 2, "page should skip pruned transactions")
+			require.Equal(t, uint64(0), page[0].Meta.Seq)
+			require.Equal(t, uint64(2), page[1].Meta.Seq)
+
+			pruned, err = prunableDB.PruneTxs(1, 4, keep)
+			require.NoError(t, err)
+			require.Zero(t, pruned, "pruned transactions should be skipped")
+		})
 	})
 }
 
@@ -245,3 +460,42 @@ func TestChainDB_CXOChain(t *testing.T) {
 
 	runChainDBTest(t, chainDB)
 }
+
+func TestNewChainDB(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	cases := []ChainDBConfig{
+		{
+			Type: BoltChainDBType,
+			Bolt: &BoltChainConfig{Path: filepath.Join(dir, "bolt", "chain.db")},
+		},
+		{
+			Type:  LevelChainDBType,
+			Level: &LevelChainConfig{Dir: filepath.Join(dir, "leveldb")},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.Type), func(t *testing.T) {
+			chainDB, err := NewChainDB(&c)
+			require.NoError(t, err,
+				"creation of chain db should succeed")
+			defer chainDB.Close()
+
+			runChainDBTest(t, chainDB)
+		})
+	}
+
+	t.Run("InvalidType", func(t *testing.T) {
+		_, err := NewChainDB(&ChainDBConfig{Type: "invalid"})
+		require.Error(t, err,
+			"creation of chain db of invalid type should fail")
+	})
+
+	t.Run("MissingConfig", func(t *testing.T) {
+		_, err := NewChainDB(&ChainDBConfig{Type: BoltChainDBType})
+		require.Error(t, err,
+			"creation of chain db with missing config should fail")
+	})
+}
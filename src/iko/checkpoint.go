@@ -0,0 +1,132 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+var (
+	// ErrCheckpointMismatch occurs when the state at the seq of a checkpoint
+	// does not match the state hash of the checkpoint.
+	ErrCheckpointMismatch = errors.New("state does not match checkpoint")
+)
+
+// Checkpoint is a trusted state of the chain. When replaying the chain, the
+// state after the tx of 'Seq' should have the hash 'StateHash' (see
+// 'StateSnapshot.Hash'). A snapshot of the latest checkpoint can be loaded
+// on start, so that txs before it are not verified again.
+type Checkpoint struct {
+	Seq       uint64        // Seq of the last tx applied to the state.
+	StateHash cipher.SHA256 // Hash of the state snapshot after the tx of 'Seq'.
+}
+
+// CheckpointFromString parses a checkpoint of the form '<seq>:<state_hash>'.
+func CheckpointFromString(s string) (Checkpoint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return Checkpoint{}, fmt.Errorf("checkpoint '%s' is not of the form <seq>:<state_hash>", s)
+	}
+	seq, e := strconv.ParseUint(parts[0], 10, 64)
+	if e != nil {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint seq: %v", e)
+	}
+	hash, e := cipher.SHA256FromHex(parts[1])
+	if e != nil {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint state hash: %v", e)
+	}
+	return Checkpoint{Seq: seq, StateHash: hash}, nil
+}
+
+func (c Checkpoint) String() string {
+	return fmt.Sprintf("%d:%s", c.Seq, c.StateHash.Hex())
+}
+
+// Hash returns the state hash of the snapshot, as trusted by checkpoints. The
+// snapshot should be of a chain height, so that the hash also commits to the
+// head of the chain.
+func (s StateSnapshot) Hash() cipher.SHA256 {
+	return cipher.SumSHA256(s.Serialize())
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// Checkpoint returns the checkpoint of the current state and head of the
+// chain, which can be configured as a trusted checkpoint of other nodes.
+func (bc *BlockChain) Checkpoint() (Checkpoint, error) {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	snapshot, e := bc.snapshotState()
+	if e != nil {
+		return Checkpoint{}, e
+	}
+	if snapshot.Height == 0 {
+		return Checkpoint{}, errors.New("chain is empty")
+	}
+	return Checkpoint{Seq: snapshot.Height - 1, StateHash: snapshot.Hash()}, nil
+}
+
+// latestCheckpoint returns the configured checkpoint of the highest seq.
+func (bc *BlockChain) latestCheckpoint() (Checkpoint, bool) {
+	if n := len(bc.c.Checkpoints); n > 0 {
+		return bc.c.Checkpoints[n-1], true
+	}
+	return Checkpoint{}, false
+}
+
+// verifyCheckpoint checks the state against the configured checkpoint of seq
+// (if any), after the tx of seq is applied. The snapshot of the latest
+// checkpoint is saved to 'CheckpointSnapshotPath', if specified.
+func (bc *BlockChain) verifyCheckpoint(txWrap *TxWrapper) error {
+	seq := txWrap.Meta.Seq
+	for i, cp := range bc.c.Checkpoints {
+		if cp.Seq != seq {
+			continue
+		}
+		ss, ok := bc.state.(SnapshotStateDB)
+		if !ok {
+			return ErrStateNotSnapshottable
+		}
+		snapshot := ss.Snapshot()
+		snapshot.Height = seq + 1
+		snapshot.HeadHash = txWrap.Tx.Hash()
+		if snapshot.Hash() != cp.StateHash {
+			return ErrCheckpointMismatch
+		}
+		bc.log.
+			WithField("checkpoint", cp.String()).
+			Info("verified checkpoint")
+
+		path := bc.c.CheckpointSnapshotPath
+		if i == len(bc.c.Checkpoints)-1 && path != "" {
+			return SaveStateSnapshot(path, snapshot)
+		}
+		return nil
+	}
+	return nil
+}
+
+// restoreCheckpoint restores the state from the snapshot of the latest
+// checkpoint, which should have the checkpoint's state hash. Only txs after
+// the checkpoint are replayed.
+func (bc *BlockChain) restoreCheckpoint() error {
+	cp, ok := bc.latestCheckpoint()
+	if !ok || bc.c.CheckpointSnapshotPath == "" {
+		return os.ErrNotExist
+	}
+	snapshot, e := LoadStateSnapshot(bc.c.CheckpointSnapshotPath)
+	if e != nil {
+		return e
+	}
+	if snapshot.Height != cp.Seq+1 || snapshot.Hash() != cp.StateHash {
+		return ErrCheckpointMismatch
+	}
+	return bc.restoreState(snapshot)
+}
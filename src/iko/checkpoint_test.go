@@ -0,0 +1,87 @@
+package iko
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointFromString(t *testing.T) {
+	cp := Checkpoint{Seq: 12, StateHash: StateSnapshot{Height: 13}.Hash()}
+	got, err := CheckpointFromString(cp.String())
+	require.NoError(t, err)
+	require.Equal(t, cp, got)
+
+	_, err = CheckpointFromString("12")
+	require.Error(t, err, "checkpoint should have a state hash")
+	_, err = CheckpointFromString("a:" + cp.StateHash.Hex())
+	require.Error(t, err, "checkpoint should have a valid seq")
+}
+
+func TestBlockChain_Checkpoint(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	snapshotPath := filepath.Join(filepath.Dir(path), "checkpoint.snapshot")
+
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	bc, err := NewBlockChain(context.Background(), &BlockChainConfig{GenerationPK: GenPK}, chainDB, NewMemoryState())
+	require.NoError(t, err)
+	injectGenTxs(t, bc, 3)
+	cp, err := bc.Checkpoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), cp.Seq)
+	for i := 3; i < 5; i++ {
+		_, err := bc.InjectTx(NewGenTx(KittyID(i), GenSK))
+		require.NoError(t, err)
+	}
+	snapshot := bc.state.(SnapshotStateDB).Snapshot()
+	bc.Close()
+
+	config := &BlockChainConfig{
+		GenerationPK:           GenPK,
+		Checkpoints:            []Checkpoint{cp},
+		CheckpointSnapshotPath: snapshotPath,
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err, "replayed state should match checkpoint")
+		defer bc.Close()
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot())
+
+		cpSnapshot, err := LoadStateSnapshot(snapshotPath)
+		require.NoError(t, err, "snapshot of checkpoint should be saved")
+		require.Equal(t, cp.StateHash, cpSnapshot.Hash())
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err, "state should be restored from checkpoint")
+		defer bc.Close()
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot())
+	})
+
+	t.Run("BadSnapshot", func(t *testing.T) {
+		cpSnapshot, err := LoadStateSnapshot(snapshotPath)
+		require.NoError(t, err)
+		cpSnapshot.Kitties = cpSnapshot.Kitties[1:]
+		require.NoError(t, SaveStateSnapshot(snapshotPath, cpSnapshot))
+
+		bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err, "tampered snapshot should fall back to replay")
+		defer bc.Close()
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot())
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		_, err := NewBlockChain(context.Background(), &BlockChainConfig{
+			GenerationPK: GenPK,
+			Checkpoints:  []Checkpoint{{Seq: 1, StateHash: cp.StateHash}},
+		}, chainDB, NewMemoryState())
+		require.Equal(t, ErrCheckpointMismatch, err)
+	})
+}
@@ -0,0 +1,605 @@
+package iko
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+var (
+	// ErrCodecMismatch occurs when a ChainDB is opened with a codec that differs
+	// from the codec it's transactions were stored with.
+	ErrCodecMismatch = errors.New("chain db was created with a different tx codec")
+
+	// ErrCBORTruncated occurs when CBOR data ends unexpectedly.
+	ErrCBORTruncated = errors.New("cbor data is truncated")
+)
+
+// TxCodecType determines how transactions are encoded for storage.
+type TxCodecType string
+
+const (
+	// BinaryTxCodecType encodes transactions with the Skycoin binary encoder.
+	BinaryTxCodecType TxCodecType = "binary"
+
+	// CBORTxCodecType encodes transactions as CBOR maps with named fields,
+	// so fields can be added without breaking existing data.
+	CBORTxCodecType TxCodecType = "cbor"
+)
+
+// TxCodec encodes and decodes transactions for storage in a ChainDB.
+type TxCodec interface {
+	Type() TxCodecType
+	EncodeTx(txWrap TxWrapper) []byte
+	DecodeTx(raw []byte, txWrap *TxWrapper) error
+}
+
+// NewTxCodec obtains the TxCodec of the given type.
+// An empty type results in the binary codec.
+func NewTxCodec(codecType TxCodecType) (TxCodec, error) {
+	switch codecType {
+	case BinaryTxCodecType, "":
+		return BinaryTxCodec{}, nil
+	case CBORTxCodecType:
+		return CBORTxCodec{}, nil
+	default:
+		return nil, fmt.Errorf("invalid tx codec type '%s'", codecType)
+	}
+}
+
+/*
+	<<< BINARY >>>
+*/
+
+type BinaryTxCodec struct{}
+
+func (BinaryTxCodec) Type() TxCodecType {
+	return BinaryTxCodecType
+}
+
+// EncodeTx appends the optional tx fields (if any) after the wrapper, so
+// transactions without them are encoded as before.
+func (BinaryTxCodec) EncodeTx(txWrap TxWrapper) []byte {
+	return append(encoder.Serialize(txWrap), txWrap.Tx.serializeFields(false)...)
+}
+
+func (BinaryTxCodec) DecodeTx(raw []byte, txWrap *TxWrapper) error {
+	*txWrap = TxWrapper{}
+	n, e := encoder.DeserializeRawToValue(raw, reflect.ValueOf(txWrap))
+	if e != nil {
+		return e
+	}
+	if n == len(raw) {
+		return nil
+	}
+	return txWrap.Tx.deserializeFields(raw[n:])
+}
+
+/*
+	<<< CBOR >>>
+*/
+
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+)
+
+// CBORTxCodec encodes transactions as CBOR (RFC 7049) maps. Unknown keys are
+// skipped when decoding.
+type CBORTxCodec struct{}
+
+func (CBORTxCodec) Type() TxCodecType {
+	return CBORTxCodecType
+}
+
+func (CBORTxCodec) EncodeTx(txWrap TxWrapper) []byte {
+	fields := uint64(6)
+	if txWrap.Tx.Witness != nil {
+		fields++
+	}
+	if len(txWrap.Tx.Inputs) > 0 {
+		fields++
+	}
+	if len(txWrap.Tx.Memo) > 0 {
+		fields++
+	}
+	if txWrap.Tx.Fee > 0 {
+		fields++
+	}
+	if txWrap.Tx.Lock.Seq > 0 {
+		fields++
+	}
+	if txWrap.Tx.Lock.TS != 0 {
+		fields++
+	}
+	if txWrap.Tx.Escrow != nil {
+		fields++
+	}
+	if txWrap.Tx.Metadata != nil {
+		fields++
+	}
+	if txWrap.Tx.Breed != nil {
+		fields++
+	}
+	if txWrap.Tx.Auction != nil {
+		fields++
+	}
+	if txWrap.Tx.Royalty != nil {
+		fields++
+	}
+	if txWrap.Tx.Rotation != nil {
+		fields++
+	}
+	if txWrap.Tx.Admin != nil {
+		fields++
+	}
+	if txWrap.Tx.Genesis != nil {
+		fields++
+	}
+	if txWrap.Tx.Network != "" {
+		fields++
+	}
+	if txWrap.Tx.Version != TxVersionLegacy {
+		fields++
+	}
+	b := cborAppendHead(nil, cborMap, fields)
+
+	b = cborAppendText(b, "kitty_id")
+	b = cborAppendHead(b, cborUint, uint64(txWrap.Tx.KittyID))
+
+	b = cborAppendText(b, "in")
+	b = cborAppendBytes(b, txWrap.Tx.In[:])
+
+	b = cborAppendText(b, "out")
+	b = cborAppendBytes(b, append([]byte{txWrap.Tx.Out.Version}, txWrap.Tx.Out.Key[:]...))
+
+	b = cborAppendText(b, "sig")
+	b = cborAppendBytes(b, txWrap.Tx.Sig[:])
+
+	b = cborAppendText(b, "seq")
+	b = cborAppendHead(b, cborUint, txWrap.Meta.Seq)
+
+	b = cborAppendText(b, "ts")
+	b = cborAppendInt(b, txWrap.Meta.TS)
+
+	if txWrap.Tx.Witness != nil {
+		b = cborAppendText(b, "witness")
+		b = cborAppendBytes(b, txWrap.Tx.Witness.Serialize())
+	}
+
+	if len(txWrap.Tx.Inputs) > 0 {
+		b = cborAppendText(b, "inputs")
+		b = cborAppendBytes(b, encoder.Serialize(txWrap.Tx.Inputs))
+	}
+
+	if len(txWrap.Tx.Memo) > 0 {
+		b = cborAppendText(b, "memo")
+		b = cborAppendBytes(b, txWrap.Tx.Memo)
+	}
+
+	if txWrap.Tx.Fee > 0 {
+		b = cborAppendText(b, "fee")
+		b = cborAppendHead(b, cborUint, txWrap.Tx.Fee)
+	}
+
+	if txWrap.Tx.Lock.Seq > 0 {
+		b = cborAppendText(b, "lock_seq")
+		b = cborAppendHead(b, cborUint, txWrap.Tx.Lock.Seq)
+	}
+
+	if ts := txWrap.Tx.Lock.TS; ts != 0 {
+		b = cborAppendText(b, "lock_ts")
+		b = cborAppendInt(b, ts)
+	}
+
+	if txWrap.Tx.Escrow != nil {
+		b = cborAppendText(b, "escrow")
+		b = cborAppendBytes(b, txWrap.Tx.Escrow.Serialize())
+	}
+
+	if txWrap.Tx.Metadata != nil {
+		b = cborAppendText(b, "metadata")
+		b = cborAppendBytes(b, txWrap.Tx.Metadata.Serialize())
+	}
+
+	if txWrap.Tx.Breed != nil {
+		b = cborAppendText(b, "breed_child_id")
+		b = cborAppendHead(b, cborUint, uint64(txWrap.Tx.Breed.ChildID))
+	}
+
+	if txWrap.Tx.Auction != nil {
+		b = cborAppendText(b, "auction")
+		b = cborAppendBytes(b, txWrap.Tx.Auction.Serialize())
+	}
+
+	if txWrap.Tx.Royalty != nil {
+		b = cborAppendText(b, "royalty")
+		b = cborAppendBytes(b, txWrap.Tx.Royalty.Serialize())
+	}
+
+	if txWrap.Tx.Rotation != nil {
+		b = cborAppendText(b, "rotation")
+		b = cborAppendBytes(b, txWrap.Tx.Rotation.Serialize())
+	}
+
+	if txWrap.Tx.Admin != nil {
+		b = cborAppendText(b, "admin")
+		b = cborAppendBytes(b, txWrap.Tx.Admin.Serialize())
+	}
+
+	if txWrap.Tx.Genesis != nil {
+		b = cborAppendText(b, "genesis")
+		b = cborAppendBytes(b, txWrap.Tx.Genesis.Serialize())
+	}
+
+	if txWrap.Tx.Network != "" {
+		b = cborAppendText(b, "network")
+		b = cborAppendText(b, txWrap.Tx.Network)
+	}
+
+	if txWrap.Tx.Version != TxVersionLegacy {
+		b = cborAppendText(b, "version")
+		b = cborAppendHead(b, cborUint, uint64(txWrap.Tx.Version))
+	}
+	return b
+}
+
+func (CBORTxCodec) DecodeTx(raw []byte, txWrap *TxWrapper) error {
+	*txWrap = TxWrapper{}
+
+	d := &cborDecoder{b: raw}
+	major, n, e := d.head()
+	if e != nil {
+		return e
+	}
+	if major != cborMap {
+		return fmt.Errorf("cbor tx should be a map, got major type %d", major)
+	}
+	for i := uint64(0); i < n; i++ {
+		key, e := d.text()
+		if e != nil {
+			return e
+		}
+		switch key {
+		case "kitty_id":
+			v, e := d.uint()
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.KittyID = KittyID(v)
+		case "in":
+			if e := d.fixedBytes(txWrap.Tx.In[:], key); e != nil {
+				return e
+			}
+		case "out":
+			var out [21]byte
+			if e := d.fixedBytes(out[:], key); e != nil {
+				return e
+			}
+			txWrap.Tx.Out.Version = out[0]
+			copy(txWrap.Tx.Out.Key[:], out[1:])
+		case "sig":
+			if e := d.fixedBytes(txWrap.Tx.Sig[:], key); e != nil {
+				return e
+			}
+		case "seq":
+			if txWrap.Meta.Seq, e = d.uint(); e != nil {
+				return e
+			}
+		case "ts":
+			if txWrap.Meta.TS, e = d.int(); e != nil {
+				return e
+			}
+		case "witness":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Witness = new(Witness)
+			if e := encoder.DeserializeRaw(raw, txWrap.Tx.Witness); e != nil {
+				return e
+			}
+		case "inputs":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			if e := encoder.DeserializeRaw(raw, &txWrap.Tx.Inputs); e != nil {
+				return e
+			}
+		case "memo":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Memo = append([]byte(nil), raw...)
+		case "fee":
+			if txWrap.Tx.Fee, e = d.uint(); e != nil {
+				return e
+			}
+		case "lock_seq":
+			if txWrap.Tx.Lock.Seq, e = d.uint(); e != nil {
+				return e
+			}
+		case "lock_ts":
+			if txWrap.Tx.Lock.TS, e = d.int(); e != nil {
+				return e
+			}
+		case "escrow":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Escrow = new(Escrow)
+			if e := encoder.DeserializeRaw(raw, txWrap.Tx.Escrow); e != nil {
+				return e
+			}
+		case "metadata":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Metadata = new(KittyMetadata)
+			if e := encoder.DeserializeRaw(raw, txWrap.Tx.Metadata); e != nil {
+				return e
+			}
+		case "breed_child_id":
+			childID, e := d.uint()
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Breed = &TxBreed{ChildID: KittyID(childID)}
+		case "auction":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Auction = new(TxAuction)
+			if e := encoder.DeserializeRaw(raw, txWrap.Tx.Auction); e != nil {
+				return e
+			}
+		case "royalty":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Royalty = new(TxRoyalty)
+			if e := encoder.DeserializeRaw(raw, txWrap.Tx.Royalty); e != nil {
+				return e
+			}
+		case "rotation":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Rotation = new(TxRotation)
+			if e := encoder.DeserializeRaw(raw, txWrap.Tx.Rotation); e != nil {
+				return e
+			}
+		case "admin":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Admin = new(TxAdmin)
+			if e := encoder.DeserializeRaw(raw, txWrap.Tx.Admin); e != nil {
+				return e
+			}
+		case "genesis":
+			raw, e := d.raw(cborBytes)
+			if e != nil {
+				return e
+			}
+			txWrap.Tx.Genesis = new(Genesis)
+			if e := encoder.DeserializeRaw(raw, txWrap.Tx.Genesis); e != nil {
+				return e
+			}
+		case "network":
+			if txWrap.Tx.Network, e = d.text(); e != nil {
+				return e
+			}
+		case "version":
+			v, e := d.uint()
+			if e != nil {
+				return e
+			}
+			if v > 0xff {
+				return fmt.Errorf("invalid tx version %d", v)
+			}
+			txWrap.Tx.Version = uint8(v)
+		default:
+			if e := d.skip(); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func cborAppendHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= 0xff:
+		return append(b, major|24, byte(n))
+	case n <= 0xffff:
+		b = append(b, major|25, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-2:], uint16(n))
+	case n <= 0xffffffff:
+		b = append(b, major|26, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], uint32(n))
+	default:
+		b = append(b, major|27, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(b[len(b)-8:], n)
+	}
+	return b
+}
+
+func cborAppendInt(b []byte, v int64) []byte {
+	if v >= 0 {
+		return cborAppendHead(b, cborUint, uint64(v))
+	}
+	return cborAppendHead(b, cborNegint, uint64(-1-v))
+}
+
+func cborAppendBytes(b []byte, v []byte) []byte {
+	return append(cborAppendHead(b, cborBytes, uint64(len(v))), v...)
+}
+
+func cborAppendText(b []byte, v string) []byte {
+	return append(cborAppendHead(b, cborText, uint64(len(v))), v...)
+}
+
+type cborDecoder struct {
+	b []byte
+}
+
+// head reads the initial byte (and argument) of a data item.
+// Indefinite lengths are not supported.
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if len(d.b) < 1 {
+		return 0, 0, ErrCBORTruncated
+	}
+	major, info := d.b[0]>>5, d.b[0]&0x1f
+	d.b = d.b[1:]
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("unsupported cbor additional info %d", info)
+	}
+	if len(d.b) < size {
+		return 0, 0, ErrCBORTruncated
+	}
+	var n uint64
+	for _, v := range d.b[:size] {
+		n = n<<8 | uint64(v)
+	}
+	d.b = d.b[size:]
+	return major, n, nil
+}
+
+func (d *cborDecoder) uint() (uint64, error) {
+	major, n, e := d.head()
+	if e != nil {
+		return 0, e
+	}
+	if major != cborUint {
+		return 0, fmt.Errorf("expected cbor unsigned integer, got major type %d", major)
+	}
+	return n, nil
+}
+
+func (d *cborDecoder) int() (int64, error) {
+	major, n, e := d.head()
+	if e != nil {
+		return 0, e
+	}
+	switch major {
+	case cborUint:
+		return int64(n), nil
+	case cborNegint:
+		return -1 - int64(n), nil
+	default:
+		return 0, fmt.Errorf("expected cbor integer, got major type %d", major)
+	}
+}
+
+func (d *cborDecoder) raw(expMajor byte) ([]byte, error) {
+	major, n, e := d.head()
+	if e != nil {
+		return nil, e
+	}
+	if major != expMajor {
+		return nil, fmt.Errorf("expected cbor major type %d, got %d", expMajor, major)
+	}
+	if uint64(len(d.b)) < n {
+		return nil, ErrCBORTruncated
+	}
+	out := d.b[:n]
+	d.b = d.b[n:]
+	return out, nil
+}
+
+func (d *cborDecoder) text() (string, error) {
+	raw, e := d.raw(cborText)
+	return string(raw), e
+}
+
+func (d *cborDecoder) fixedBytes(dst []byte, name string) error {
+	raw, e := d.raw(cborBytes)
+	if e != nil {
+		return e
+	}
+	if len(raw) != len(dst) {
+		return fmt.Errorf("cbor field '%s' has %d bytes, expected %d",
+			name, len(raw), len(dst))
+	}
+	copy(dst, raw)
+	return nil
+}
+
+// skip skips over a data item of any type.
+func (d *cborDecoder) skip() error {
+	major, n, e := d.head()
+	if e != nil {
+		return e
+	}
+	switch major {
+	case cborBytes, cborText:
+		if uint64(len(d.b)) < n {
+			return ErrCBORTruncated
+		}
+		d.b = d.b[n:]
+	case cborArray:
+		for i := uint64(0); i < n; i++ {
+			if e := d.skip(); e != nil {
+				return e
+			}
+		}
+	case cborMap:
+		for i := uint64(0); i < n*2; i++ {
+			if e := d.skip(); e != nil {
+				return e
+			}
+		}
+	case cborTag:
+		return d.skip()
+	}
+	return nil
+}
+
+// checkStoredCodec compares the codec type stored in a ChainDB with that of
+// the configured codec. Chains without a stored codec type were created before
+// codecs were configurable, and hence use the binary codec if not empty.
+func checkStoredCodec(codec TxCodec, stored []byte, cLen int) error {
+	if stored == nil {
+		if cLen > 0 && codec.Type() != BinaryTxCodecType {
+			return ErrCodecMismatch
+		}
+		return nil
+	}
+	if TxCodecType(stored) != codec.Type() {
+		return ErrCodecMismatch
+	}
+	return nil
+}
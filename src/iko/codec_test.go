@@ -0,0 +1,96 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func runTxCodecTest(t *testing.T, codec TxCodec) {
+	txWraps := genTxWraps(3, 300)
+
+	multi, err := NewMultiTransferTx(
+		[]*Transaction{&txWraps[0].Tx, &txWraps[1].Tx},
+		KittyIDs{txWraps[0].Tx.KittyID, txWraps[1].Tx.KittyID},
+		txWraps[2].Tx.Out, GenSK)
+	require.NoError(t, err)
+	txWraps = append(txWraps, TxWrapper{Tx: *multi, Meta: TxMeta{Seq: 3, TS: 400}})
+
+	memo := NewUnsignedTransferTx(&txWraps[2].Tx, multi.Out)
+	memo.Memo = []byte("order:1")
+	memo.Fee = 300
+	memo.Lock = TxLock{Seq: 10, TS: 600}
+	memo.Escrow = &Escrow{Sender: multi.Out, Receiver: cipher.AddressFromPubKey(GenPK), Deadline: 700}
+	memo.Metadata = &KittyMetadata{Name: "Kitty", DNA: []byte{1}, Generation: 1}
+	memo.Breed = &TxBreed{ChildID: 9}
+	memo.Auction = &TxAuction{Action: AuctionBid, Listing: Listing{Seller: multi.Out, Reserve: 1, Deadline: 800}, Bid: 2}
+	memo.Royalty = NewTxRoyalty(10, 500)
+	memo.Admin = &TxAdmin{Revoke: []cipher.PubKey{GenPK}, Freeze: true}
+	memo.Genesis = &Genesis{ChainID: "kittycash-test", CreatorPK: GenPK, TS: 100}
+	memo.Network = "kittycash-test"
+	memo.Version = TxVersion1
+	memo.Sig = memo.Sign(GenSK)
+	txWraps = append(txWraps, TxWrapper{Tx: *memo, Meta: TxMeta{Seq: 4, TS: 500}})
+
+	for _, txWrap := range txWraps {
+		var reqTxWrap TxWrapper
+		require.NoError(t, codec.DecodeTx(codec.EncodeTx(txWrap), &reqTxWrap),
+			"decoding encoded tx should succeed")
+		require.Equal(t, txWrap, reqTxWrap,
+			"decoded tx should equal original")
+	}
+}
+
+func TestTxCodec_Binary(t *testing.T) {
+	runTxCodecTest(t, BinaryTxCodec{})
+}
+
+func TestTxCodec_CBOR(t *testing.T) {
+	runTxCodecTest(t, CBORTxCodec{})
+
+	txWrap := genTxWraps(1, 0)[0]
+
+	t.Run("NegativeTS", func(t *testing.T) {
+		txWrap := txWrap
+		txWrap.Meta.TS = -1000
+
+		var reqTxWrap TxWrapper
+		require.NoError(t, CBORTxCodec{}.DecodeTx(CBORTxCodec{}.EncodeTx(txWrap), &reqTxWrap))
+		require.Equal(t, txWrap, reqTxWrap, "negative ts should be decoded")
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		raw := CBORTxCodec{}.EncodeTx(txWrap)
+
+		// Increase map size by one and append an unknown key-value pair.
+		raw[0]++
+		raw = cborAppendText(raw, "unknown")
+		raw = cborAppendHead(raw, cborArray, 2)
+		raw = cborAppendText(raw, "hello")
+		raw = cborAppendHead(raw, cborUint, 70000)
+
+		var reqTxWrap TxWrapper
+		require.NoError(t, CBORTxCodec{}.DecodeTx(raw, &reqTxWrap),
+			"unknown keys should be skipped")
+		require.Equal(t, txWrap, reqTxWrap, "known fields should be decoded")
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		raw := CBORTxCodec{}.EncodeTx(txWrap)
+
+		var reqTxWrap TxWrapper
+		require.Equal(t, ErrCBORTruncated, CBORTxCodec{}.DecodeTx(raw[:len(raw)-1], &reqTxWrap),
+			"truncated data should fail")
+	})
+}
+
+func TestNewTxCodec(t *testing.T) {
+	codec, err := NewTxCodec("")
+	require.NoError(t, err)
+	require.Equal(t, BinaryTxCodecType, codec.Type(),
+		"codec should default to binary")
+
+	_, err = NewTxCodec("invalid")
+	require.Error(t, err, "invalid codec type should fail")
+}
@@ -0,0 +1,238 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+const (
+	// MaxCreatorKeys is the maximum number of creator keys.
+	MaxCreatorKeys = 16
+)
+
+var (
+	// ErrNotCreator occurs when a creator rotation tx is not signed by a
+	// current creator key.
+	ErrNotCreator = errors.New("tx is not signed by a creator key")
+
+	// ErrRotationPending occurs when submitting a creator rotation tx to the
+	// mempool, as they can only be injected.
+	ErrRotationPending = errors.New("creator rotation txs cannot be submitted to the mempool")
+
+	// ErrRotationEpoch occurs when a rotation tx is not of the current creator
+	// epoch, such as when a rotation tx is replayed.
+	ErrRotationEpoch = errors.New("rotation tx is not of the current creator epoch")
+
+	// ErrRotationNotQuorum occurs when a rotation tx that is signed by a single
+	// creator key does more than rotate the signer's key out.
+	ErrRotationNotQuorum = errors.New("rotation tx should be authorised by the creator quorum")
+)
+
+// TxRotation replaces the creator keys, which are trusted to create kitties
+// (and sign further rotations), with 'Creators'. Rotation txs are authorised by
+// a quorum of the creator keys (see 'BlockChain.CreatorQuorum'), except those
+// that only remove the signer's key, which may be signed by that key alone.
+type TxRotation struct {
+	Creators []cipher.PubKey
+	Epoch    uint64 // Creator epoch the rotation replaces (see 'BlockChain.CreatorEpoch').
+}
+
+func (r TxRotation) Serialize() []byte {
+	return encoder.Serialize(r)
+}
+
+// Verify checks that there are between 1 and 'MaxCreatorKeys' creator keys,
+// which are valid and unique.
+func (r TxRotation) Verify() error {
+	return verifyCreatorPKs(r.Creators)
+}
+
+func verifyCreatorPKs(pks []cipher.PubKey) error {
+	if n := len(pks); n == 0 || n > MaxCreatorKeys {
+		return fmt.Errorf("there should be between 1 and %d creator keys, got %d",
+			MaxCreatorKeys, n)
+	}
+	seen := make(map[cipher.PubKey]struct{}, len(pks))
+	for i, pk := range pks {
+		if e := pk.Verify(); e != nil {
+			return fmt.Errorf("creator key %d is invalid: %v", i, e)
+		}
+		if _, ok := seen[pk]; ok {
+			return fmt.Errorf("creator key %d is duplicated", i)
+		}
+		seen[pk] = struct{}{}
+	}
+	return nil
+}
+
+// NewRotationTx creates a tx that replaces the creator keys of the epoch with
+// 'creators', signed by a current creator key. As it is not authorised by the
+// creator quorum, 'creators' should be the creator keys without that of 'sk'.
+func NewRotationTx(creators []cipher.PubKey, epoch uint64, sk cipher.SecKey) (*Transaction, error) {
+	tx := NewUnsignedRotationTx(creators, epoch, cipher.AddressFromSecKey(sk))
+	if e := tx.Rotation.Verify(); e != nil {
+		return nil, e
+	}
+	tx.Sig = tx.Sign(sk)
+	return tx, nil
+}
+
+// NewUnsignedRotationTx creates an unsigned tx that replaces the creator keys
+// of the epoch with 'creators'. It should be signed by the current creator key
+// of the 'signer' address.
+func NewUnsignedRotationTx(creators []cipher.PubKey, epoch uint64, signer cipher.Address) *Transaction {
+	return &Transaction{
+		In:       EmptyTxHash(),
+		Out:      signer,
+		Rotation: &TxRotation{Creators: creators, Epoch: epoch},
+	}
+}
+
+// NewQuorumRotationTx creates an unsigned tx that replaces the creator keys of
+// the epoch with 'creators', authorised by the quorum. The signatures of the
+// quorum's keys are added with 'AddMultisigSig'.
+func NewQuorumRotationTx(quorum *Multisig, creators []cipher.PubKey, epoch uint64) (*Transaction, error) {
+	if e := quorum.Verify(); e != nil {
+		return nil, e
+	}
+	tx := &Transaction{
+		In:       EmptyTxHash(),
+		Out:      quorum.Address(),
+		Rotation: &TxRotation{Creators: creators, Epoch: epoch},
+		Witness:  &Witness{Multisig: *quorum},
+	}
+	return tx, tx.VerifyRotation()
+}
+
+// IsRotation returns true if the tx replaces the creator keys. Rotation txs
+// have no kitties.
+func (tx Transaction) IsRotation() bool {
+	return tx.Rotation != nil
+}
+
+// VerifyRotation checks the structure of a rotation tx. The signer (or
+// witness) is not checked, as the creator keys are of the state.
+func (tx Transaction) VerifyRotation() error {
+	if e := tx.VerifyMemo(); e != nil {
+		return e
+	}
+	if tx.KittyID != 0 || tx.In != EmptyTxHash() {
+		return errors.New("rotation tx should have no kitty or input")
+	}
+	if len(tx.Inputs) > 0 || tx.Fee > 0 || !tx.Lock.IsZero() || tx.Escrow != nil ||
+		tx.Metadata != nil || tx.Breed != nil || tx.Auction != nil || tx.Royalty != nil ||
+		tx.Admin != nil || tx.Genesis != nil {
+		return errors.New("rotation tx should only replace the creator keys")
+	}
+	return tx.Rotation.Verify()
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// CreatorPKs returns the public keys that are trusted to create kitties. These
+// are those of the last rotation tx, or 'GenerationPK' followed by
+// 'CreatorPKs' of the config if there were none.
+func (bc *BlockChain) CreatorPKs() []cipher.PubKey {
+	if pks := bc.state.GetCreators(); len(pks) > 0 {
+		return pks
+	}
+	return append([]cipher.PubKey{bc.c.GenerationPK}, bc.c.CreatorPKs...)
+}
+
+// CreatorEpoch returns the number of times the creator keys were replaced, by
+// rotation or admin txs. Rotation txs are of the epoch of the creator keys
+// they replace, so that they cannot be replayed.
+func (bc *BlockChain) CreatorEpoch() uint64 {
+	return bc.state.GetCreatorEpoch()
+}
+
+// IsCreator returns true if the address is of a creator key.
+func (bc *BlockChain) IsCreator(address cipher.Address) bool {
+	for _, pk := range bc.CreatorPKs() {
+		if address.Verify(pk) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// generationPK returns the creator key of a generation tx, or false if the tx
+// is not a generation tx of any creator key.
+func (bc *BlockChain) generationPK(tx *Transaction) (cipher.PubKey, bool) {
+	if tx.IsRotation() || tx.IsAdmin() {
+		return cipher.PubKey{}, false
+	}
+	for _, pk := range bc.CreatorPKs() {
+		if tx.IsKittyGen(pk) {
+			return pk, true
+		}
+	}
+	return cipher.PubKey{}, false
+}
+
+// isKittyGen returns true if the tx is a generation tx of a creator key.
+func (bc *BlockChain) isKittyGen(tx *Transaction) bool {
+	_, ok := bc.generationPK(tx)
+	return ok
+}
+
+// checkRotation checks that a rotation tx is valid and of the current creator
+// epoch. It should be authorised by the quorum of the current creator keys,
+// unless it only removes the key of it's signer, which should be the current
+// creator key of it's output address.
+func (bc *BlockChain) checkRotation(tx *Transaction) error {
+	if e := tx.VerifyRotation(); e != nil {
+		return e
+	}
+	if tx.Rotation.Epoch != bc.CreatorEpoch() {
+		return ErrRotationEpoch
+	}
+	for _, pk := range tx.Rotation.Creators {
+		if bc.state.IsCreatorRevoked(pk) {
+			return ErrCreatorRevoked
+		}
+	}
+	if tx.Witness != nil {
+		quorum := bc.CreatorQuorum()
+		if tx.Out != quorum.Address() {
+			return ErrQuorumMismatch
+		}
+		return tx.VerifyOwner(quorum.Address())
+	}
+	signer, e := pubKeyFromSig(tx.Sig, tx.HashInner())
+	if e != nil {
+		return e
+	}
+	if tx.Out.Verify(signer) != nil || !bc.IsCreator(tx.Out) {
+		return ErrNotCreator
+	}
+	if !bc.isRotationOut(signer, tx.Rotation.Creators) {
+		return ErrRotationNotQuorum
+	}
+	return verifySignature(signer, tx.Sig, tx.HashInner())
+}
+
+// isRotationOut returns true if 'creators' are the current creator keys, in
+// order, without the key 'pk'.
+func (bc *BlockChain) isRotationOut(pk cipher.PubKey, creators []cipher.PubKey) bool {
+	var remaining []cipher.PubKey
+	for _, v := range bc.CreatorPKs() {
+		if v != pk {
+			remaining = append(remaining, v)
+		}
+	}
+	if len(remaining) != len(creators) {
+		return false
+	}
+	for i, v := range remaining {
+		if creators[i] != v {
+			return false
+		}
+	}
+	return true
+}
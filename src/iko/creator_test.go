@@ -0,0 +1,113 @@
+package iko
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxRotation_Verify(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+
+	require.Error(t, TxRotation{}.Verify(), "rotation should have creators")
+	require.Error(t, TxRotation{Creators: []cipher.PubKey{pk, pk}}.Verify(),
+		"creators should be unique")
+	require.Error(t, TxRotation{Creators: []cipher.PubKey{{}}}.Verify(),
+		"creators should be valid")
+	require.NoError(t, TxRotation{Creators: []cipher.PubKey{pk, GenPK}}.Verify())
+}
+
+func TestBlockChain_CreatorRotation(t *testing.T) {
+	var (
+		pk1, sk1 = cipher.GenerateKeyPair()
+		pk2, sk2 = cipher.GenerateKeyPair()
+		addr1    = cipher.AddressFromPubKey(pk1)
+		addr2    = cipher.AddressFromPubKey(pk2)
+	)
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		CreatorPKs:   []cipher.PubKey{pk1},
+	})
+	defer closeBC()
+
+	injectGenTxs(t, bc, 1)
+	require.Equal(t, []cipher.PubKey{GenPK, pk1}, bc.CreatorPKs())
+	require.Equal(t, cipher.AddressFromPubKey(GenPK), bc.CreatorAddress())
+
+	_, err := bc.InjectTx(NewGenTx(1, sk1))
+	require.NoError(t, err, "additional creator should create kitties")
+	_, err = bc.InjectTx(NewGenTx(2, sk2))
+	require.Error(t, err, "non-creator should not create kitties")
+
+	rotation, err := NewRotationTx([]cipher.PubKey{pk2}, 0, sk2)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(rotation)
+	require.Equal(t, ErrNotCreator, err)
+	require.Equal(t, ErrRotationPending, bc.SubmitTx(rotation))
+
+	rotation, err = NewRotationTx([]cipher.PubKey{pk2, pk1}, 0, sk1)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(rotation)
+	require.Equal(t, ErrRotationNotQuorum, err,
+		"single creator should not replace other creator keys")
+
+	// Replace GenPK with pk2, with the signatures of both creators.
+	rotation, err = NewQuorumRotationTx(bc.CreatorQuorum(), []cipher.PubKey{pk2, pk1}, 1)
+	require.NoError(t, err)
+	require.NoError(t, rotation.AddMultisigSig(0, NewSecKeySigner(GenSK)))
+	require.NoError(t, rotation.AddMultisigSig(1, NewSecKeySigner(sk1)))
+	_, err = bc.InjectTx(rotation)
+	require.Equal(t, ErrRotationEpoch, err)
+
+	rotation, err = NewQuorumRotationTx(bc.CreatorQuorum(), []cipher.PubKey{pk2, pk1}, 0)
+	require.NoError(t, err)
+	require.NoError(t, rotation.AddMultisigSig(0, NewSecKeySigner(GenSK)))
+	_, err = bc.InjectTx(rotation)
+	require.Equal(t, ErrMultisigThreshold, err, "rotation should be of the quorum")
+	require.NoError(t, rotation.AddMultisigSig(1, NewSecKeySigner(sk1)))
+	_, err = bc.InjectTx(rotation)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), bc.CreatorEpoch())
+
+	_, err = bc.InjectTx(rotation)
+	require.True(t, errors.Is(err, ErrTxAlreadyExists), "rotation should not be replayed")
+
+	require.Equal(t, []cipher.PubKey{pk2, pk1}, bc.CreatorPKs())
+	require.Equal(t, addr2, bc.CreatorAddress(),
+		"cached creator address should be invalidated by rotations")
+	require.Equal(t, []cipher.Address{addr2, addr1}, bc.CreatorAddresses())
+
+	_, err = bc.InjectTx(NewGenTx(3, GenSK))
+	require.Error(t, err, "rotated key should not create kitties")
+	gen, err := bc.InjectTx(NewGenTx(3, sk2))
+	require.NoError(t, err, "new creator should create kitties")
+	require.Equal(t, uint64(3), gen.Seq)
+
+	kState, ok := bc.GetKittyState(3)
+	require.True(t, ok)
+	require.Equal(t, addr2, kState.Address)
+
+	// Rotate pk1 out, with it's signature alone.
+	rotation, err = NewRotationTx([]cipher.PubKey{pk2}, 1, sk1)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(rotation)
+	require.NoError(t, err)
+	require.Equal(t, []cipher.PubKey{pk2}, bc.CreatorPKs())
+	require.Equal(t, uint64(2), bc.CreatorEpoch())
+
+	_, err = bc.InjectTx(NewGenTx(4, sk1))
+	require.Error(t, err, "rotated key should not create kitties")
+
+	t.Run("Replay", func(t *testing.T) {
+		snapshot := bc.state.(SnapshotStateDB).Snapshot()
+		require.Equal(t, []cipher.PubKey{pk2}, snapshot.Creators)
+		require.Equal(t, uint64(2), snapshot.Epoch)
+
+		bc.state = NewMemoryState()
+		require.NoError(t, bc.InitState())
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot(),
+			"replayed state should match")
+	})
+}
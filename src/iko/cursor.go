@@ -0,0 +1,86 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GetTxsAfter obtains at most 'limit' txs after the tx of hash 'cursor', from
+// oldest to newest. An empty cursor obtains txs from the start of the chain.
+// Unlike pages of 'GetTransactionPage', the txs following a cursor do not
+// shift as the chain grows, so the hash of the last tx can be used as the
+// cursor of the next call.
+func (bc *BlockChain) GetTxsAfter(cursor TxHash, limit uint64) ([]TxWrapper, error) {
+	if limit == 0 {
+		return nil, errors.New("'limit' should be greater than zero")
+	}
+
+	bc.mux.RLock()
+	chain, e := bc.snapshotChain()
+	if e != nil {
+		return nil, e
+	}
+	defer chain.Release()
+
+	var start uint64
+	if cursor != EmptyTxHash() {
+		seq, e := seqOfCursor(chain, cursor)
+		if e != nil {
+			return nil, e
+		}
+		start = seq + 1
+	}
+	if start >= chain.Len() {
+		return []TxWrapper{}, nil
+	}
+	return chain.GetTxsOfSeqRange(start, limit)
+}
+
+// GetTxsBefore obtains at most 'limit' txs before the tx of hash 'cursor',
+// from newest to oldest, such as for a list that scrolls into the past. An
+// empty cursor obtains txs from the head of the chain. The hash of the last
+// tx can be used as the cursor of the next call.
+func (bc *BlockChain) GetTxsBefore(cursor TxHash, limit uint64) ([]TxWrapper, error) {
+	if limit == 0 {
+		return nil, errors.New("'limit' should be greater than zero")
+	}
+
+	bc.mux.RLock()
+	chain, e := bc.snapshotChain()
+	if e != nil {
+		return nil, e
+	}
+	defer chain.Release()
+
+	end := chain.Len()
+	if cursor != EmptyTxHash() {
+		seq, e := seqOfCursor(chain, cursor)
+		if e != nil {
+			return nil, e
+		}
+		end = seq
+	}
+	var start uint64
+	if end > limit {
+		start = end - limit
+	}
+	if start == end {
+		return []TxWrapper{}, nil
+	}
+	txWraps, e := chain.GetTxsOfSeqRange(start, end-start)
+	if e != nil {
+		return nil, e
+	}
+	reverseTxs(txWraps)
+	return txWraps, nil
+}
+
+// seqOfCursor obtains the seq of the tx of the cursor, from the view of the
+// chain.
+func seqOfCursor(chain chainView, cursor TxHash) (uint64, error) {
+	txWrap, e := chain.txOfHash(cursor)
+	if e != nil {
+		return 0, fmt.Errorf("cursor '%s': %w", cursor.Hex(), e)
+	}
+	return txWrap.Meta.Seq, nil
+}
@@ -0,0 +1,66 @@
+package iko
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_GetTxsAfter(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 5)
+
+	seqsOf := func(txWraps []TxWrapper) []uint64 {
+		out := make([]uint64, len(txWraps))
+		for i, txWrap := range txWraps {
+			out[i] = txWrap.Meta.Seq
+		}
+		return out
+	}
+
+	got, err := bc.GetTxsAfter(EmptyTxHash(), 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 1}, seqsOf(got))
+
+	got, err = bc.GetTxsAfter(got[1].Tx.Hash(), 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 3}, seqsOf(got))
+
+	// Pages following a cursor are stable as the chain grows.
+	cursor := got[1].Tx.Hash()
+	_, err = bc.InjectTx(NewGenTx(5, GenSK))
+	require.NoError(t, err)
+	got, err = bc.GetTxsAfter(cursor, 10)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4, 5}, seqsOf(got))
+
+	got, err = bc.GetTxsAfter(got[1].Tx.Hash(), 10)
+	require.NoError(t, err)
+	require.Empty(t, got)
+
+	_, err = bc.GetTxsAfter(TxHash(cipher.SumSHA256([]byte("missing"))), 10)
+	require.True(t, errors.Is(err, ErrTxNotFound))
+	_, err = bc.GetTxsAfter(EmptyTxHash(), 0)
+	require.Error(t, err)
+
+	t.Run("Before", func(t *testing.T) {
+		got, err := bc.GetTxsBefore(EmptyTxHash(), 4)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{5, 4, 3, 2}, seqsOf(got))
+
+		got, err = bc.GetTxsBefore(got[3].Tx.Hash(), 4)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 0}, seqsOf(got))
+
+		got, err = bc.GetTxsBefore(txWraps[0].Tx.Hash(), 4)
+		require.NoError(t, err)
+		require.Empty(t, got)
+
+		_, err = bc.GetTxsBefore(TxHash(cipher.SumSHA256([]byte("missing"))), 4)
+		require.True(t, errors.Is(err, ErrTxNotFound))
+	})
+}
@@ -0,0 +1,166 @@
+package iko
+
+import (
+	"errors"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+const (
+	// escrowAddressDomain separates escrow address hashes from those of public
+	// keys and multisigs.
+	escrowAddressDomain = "kittycash escrow"
+)
+
+var (
+	// ErrEscrowMismatch occurs when the escrow of a tx is not of the owner of
+	// it's input.
+	ErrEscrowMismatch = errors.New("escrow does not match owner address")
+
+	// ErrEscrowOutput occurs when a tx settling an escrow does not output to
+	// either the sender or the receiver of the escrow.
+	ErrEscrowOutput = errors.New("escrow tx should output to the escrow sender or receiver")
+
+	// ErrEscrowSigner occurs when a tx settling an escrow is not signed by the
+	// party that is allowed to settle it that way.
+	ErrEscrowSigner = errors.New("escrow tx is not signed by an authorised party")
+
+	// ErrEscrowExpired occurs when completing an escrow after it's deadline.
+	ErrEscrowExpired = errors.New("escrow deadline has passed, it can only be cancelled")
+
+	// ErrEscrowNotExpired occurs when the sender cancels an escrow before it's
+	// deadline.
+	ErrEscrowNotExpired = errors.New("escrow can only be cancelled by the sender after it's deadline")
+)
+
+// Escrow is a two-phase transfer condition. A kitty sent to the address of an
+// escrow is held until it is settled by a tx that reveals the escrow:
+//   - Complete: the sender releases the kitty to the receiver, before the
+//     deadline.
+//   - Cancel: the receiver returns the kitty to the sender at any time, or
+//     the sender reclaims it after the deadline.
+type Escrow struct {
+	Sender   cipher.Address
+	Receiver cipher.Address
+	Deadline int64 // Unix time (in nanoseconds).
+}
+
+// NewEscrow creates an escrow from the sender to the receiver, which can be
+// completed until the deadline.
+func NewEscrow(sender, receiver cipher.Address, deadline time.Time) (*Escrow, error) {
+	esc := &Escrow{
+		Sender:   sender,
+		Receiver: receiver,
+		Deadline: deadline.UnixNano(),
+	}
+	return esc, esc.Verify()
+}
+
+// Verify checks that the parties of the escrow are distinct, and that it has a
+// deadline.
+func (esc *Escrow) Verify() error {
+	if esc.Sender == (cipher.Address{}) || esc.Receiver == (cipher.Address{}) {
+		return errors.New("escrow should have a sender and a receiver")
+	}
+	if esc.Sender == esc.Receiver {
+		return errors.New("escrow sender and receiver should differ")
+	}
+	if esc.Deadline <= 0 {
+		return errors.New("escrow should have a deadline")
+	}
+	return nil
+}
+
+// Address returns the address that kitties are sent to, to be held by the
+// escrow. Like multisig addresses, the escrow is only revealed when it is
+// settled.
+func (esc *Escrow) Address() cipher.Address {
+	data := append([]byte(escrowAddressDomain), encoder.Serialize(*esc)...)
+	hash := cipher.DoubleSHA256(data)
+	return cipher.Address{
+		Version: 0,
+		Key:     cipher.HashRipemd160(hash[:]),
+	}
+}
+
+func (esc Escrow) Serialize() []byte {
+	return encoder.Serialize(esc)
+}
+
+// IsExpired returns true if the deadline has passed at time 'ts' (in
+// nanoseconds).
+func (esc *Escrow) IsExpired(ts int64) bool {
+	return ts >= esc.Deadline
+}
+
+// VerifySpend checks that a tx of the signer and output may settle the escrow
+// which owns the kitty. The deadline is not checked, see 'CheckDeadline'.
+func (esc *Escrow) VerifySpend(owner, out, signer cipher.Address) error {
+	if e := esc.Verify(); e != nil {
+		return e
+	}
+	if esc.Address() != owner {
+		return ErrEscrowMismatch
+	}
+	switch out {
+	case esc.Receiver:
+		if signer != esc.Sender {
+			return ErrEscrowSigner
+		}
+	case esc.Sender:
+		if signer != esc.Receiver && signer != esc.Sender {
+			return ErrEscrowSigner
+		}
+	default:
+		return ErrEscrowOutput
+	}
+	return nil
+}
+
+// CheckDeadline checks a tx of the signer and output against the deadline of
+// the escrow at time 'ts' (in nanoseconds).
+func (esc *Escrow) CheckDeadline(out, signer cipher.Address, ts int64) error {
+	switch {
+	case out == esc.Receiver && esc.IsExpired(ts):
+		return ErrEscrowExpired
+	case out == esc.Sender && signer == esc.Sender && !esc.IsExpired(ts):
+		return ErrEscrowNotExpired
+	}
+	return nil
+}
+
+// NewEscrowTx creates a transfer of the kitty into the escrow, signed by the
+// owner of the input tx (who is usually the escrow's sender).
+func NewEscrowTx(in *Transaction, esc *Escrow, sk cipher.SecKey) (*Transaction, error) {
+	if e := esc.Verify(); e != nil {
+		return nil, e
+	}
+	return NewTransferTx(in, esc.Address(), sk)
+}
+
+// NewEscrowCompleteTx creates an unsigned tx that releases the kitty held by
+// the escrow to it's receiver. It should be signed by the sender.
+func NewEscrowCompleteTx(in *Transaction, esc *Escrow) (*Transaction, error) {
+	return newEscrowSettleTx(in, esc, esc.Receiver)
+}
+
+// NewEscrowCancelTx creates an unsigned tx that returns the kitty held by the
+// escrow to it's sender. It should be signed by the receiver, or by the sender
+// after the deadline.
+func NewEscrowCancelTx(in *Transaction, esc *Escrow) (*Transaction, error) {
+	return newEscrowSettleTx(in, esc, esc.Sender)
+}
+
+func newEscrowSettleTx(in *Transaction, esc *Escrow, out cipher.Address) (*Transaction, error) {
+	if e := esc.Verify(); e != nil {
+		return nil, e
+	}
+	if in.Out != esc.Address() {
+		return nil, ErrEscrowMismatch
+	}
+	tx := NewUnsignedTransferTx(in, out)
+	tx.Escrow = esc
+	return tx, nil
+}
@@ -0,0 +1,176 @@
+package iko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEscrow(t *testing.T) {
+	var (
+		pk, _    = cipher.GenerateKeyPair()
+		addr     = cipher.AddressFromPubKey(pk)
+		creator  = cipher.AddressFromPubKey(GenPK)
+		deadline = time.Now().Add(time.Hour)
+	)
+	_, err := NewEscrow(addr, addr, deadline)
+	require.Error(t, err, "escrow to self should be rejected")
+
+	_, err = NewEscrow(creator, cipher.Address{}, deadline)
+	require.Error(t, err, "escrow without receiver should be rejected")
+
+	_, err = NewEscrow(creator, addr, time.Unix(0, 0))
+	require.Error(t, err, "escrow without deadline should be rejected")
+
+	esc, err := NewEscrow(creator, addr, deadline)
+	require.NoError(t, err)
+	require.NotEqual(t, creator, esc.Address())
+	require.NotEqual(t, addr, esc.Address())
+
+	var (
+		now     = deadline.UnixNano() - 1
+		expired = deadline.UnixNano()
+	)
+	require.NoError(t, esc.CheckDeadline(addr, creator, now))
+	require.Equal(t, ErrEscrowExpired, esc.CheckDeadline(addr, creator, expired))
+	require.NoError(t, esc.CheckDeadline(creator, addr, expired))
+	require.Equal(t, ErrEscrowNotExpired, esc.CheckDeadline(creator, creator, now))
+	require.NoError(t, esc.CheckDeadline(creator, creator, expired))
+}
+
+func TestEscrowTx(t *testing.T) {
+	var (
+		pk, sk = cipher.GenerateKeyPair()
+		addr   = cipher.AddressFromPubKey(pk)
+		gen    = NewGenTx(0, GenSK)
+	)
+	esc, err := NewEscrow(cipher.AddressFromPubKey(GenPK), addr, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	held, err := NewEscrowTx(gen, esc, GenSK)
+	require.NoError(t, err)
+
+	t.Run("Complete", func(t *testing.T) {
+		tx, err := NewEscrowCompleteTx(held, esc)
+		require.NoError(t, err)
+		require.Equal(t, addr, tx.Out)
+
+		require.Equal(t, ErrEscrowSigner, tx.SignWith(NewSecKeySigner(sk)),
+			"receiver should not complete the escrow")
+		require.NoError(t, tx.SignWith(NewSecKeySigner(GenSK)))
+		require.NoError(t, tx.VerifyWith(held, GenPK))
+
+		reqTx, err := DeserializeTx(tx.Serialize())
+		require.NoError(t, err)
+		require.Equal(t, *tx, *reqTx, "escrow should be serialized")
+
+		tampered := *tx
+		tampered.Out = cipher.AddressFromPubKey(GenPK)
+		require.Error(t, tampered.VerifyWith(held, GenPK),
+			"output should be covered by the signature")
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		tx, err := NewEscrowCancelTx(held, esc)
+		require.NoError(t, err)
+		require.NoError(t, tx.SignWith(NewSecKeySigner(sk)))
+		require.NoError(t, tx.VerifyWith(held, GenPK))
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		_, err := NewEscrowCompleteTx(gen, esc)
+		require.Equal(t, ErrEscrowMismatch, err)
+
+		other := *esc
+		other.Deadline++
+		tx := NewUnsignedTransferTx(held, addr)
+		tx.Escrow = &other
+		tx.Sig = tx.Sign(GenSK)
+		require.Equal(t, ErrEscrowMismatch, tx.VerifyWith(held, GenPK),
+			"escrow should be that of the owner")
+	})
+}
+
+func TestBlockChain_Escrow(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 3)
+		pk, sk  = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+	// openEscrow transfers the kitty into an escrow to 'addr'.
+	openEscrow := func(in *Transaction, deadline time.Time) (*Transaction, *Escrow) {
+		esc, err := NewEscrow(bc.CreatorAddress(), addr, deadline)
+		require.NoError(t, err)
+		tx, err := NewEscrowTx(in, esc, GenSK)
+		require.NoError(t, err)
+		_, err = bc.InjectTx(tx)
+		require.NoError(t, err)
+
+		kState, ok := bc.GetKittyState(in.KittyID)
+		require.True(t, ok)
+		require.Equal(t, esc.Address(), kState.Address, "escrow should hold the kitty")
+		return tx, esc
+	}
+
+	t.Run("Complete", func(t *testing.T) {
+		held, esc := openEscrow(&txWraps[0].Tx, time.Now().Add(time.Hour))
+
+		cancel, err := NewEscrowCancelTx(held, esc)
+		require.NoError(t, err)
+		require.NoError(t, cancel.SignWith(NewSecKeySigner(GenSK)))
+		_, err = bc.InjectTx(cancel)
+		require.Equal(t, ErrEscrowNotExpired, err,
+			"sender should not reclaim the kitty before the deadline")
+
+		complete, err := NewEscrowCompleteTx(held, esc)
+		require.NoError(t, err)
+		require.NoError(t, complete.SignWith(NewSecKeySigner(GenSK)))
+		require.NoError(t, bc.SubmitTx(complete))
+		require.Equal(t, 1, bc.CommitPending())
+
+		kState, ok := bc.GetKittyState(0)
+		require.True(t, ok)
+		require.Equal(t, addr, kState.Address)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		held, esc := openEscrow(&txWraps[1].Tx, time.Now().Add(-time.Hour))
+
+		complete, err := NewEscrowCompleteTx(held, esc)
+		require.NoError(t, err)
+		require.NoError(t, complete.SignWith(NewSecKeySigner(GenSK)))
+		_, err = bc.InjectTx(complete)
+		require.Equal(t, ErrEscrowExpired, err)
+
+		cancel, err := NewEscrowCancelTx(held, esc)
+		require.NoError(t, err)
+		require.NoError(t, cancel.SignWith(NewSecKeySigner(sk)))
+		_, err = bc.InjectTx(cancel)
+		require.NoError(t, err, "receiver should cancel the escrow")
+
+		kState, ok := bc.GetKittyState(1)
+		require.True(t, ok)
+		require.Equal(t, bc.CreatorAddress(), kState.Address)
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		held, esc := openEscrow(&txWraps[2].Tx, time.Now().Add(50*time.Millisecond))
+
+		complete, err := NewEscrowCompleteTx(held, esc)
+		require.NoError(t, err)
+		require.NoError(t, complete.SignWith(NewSecKeySigner(GenSK)))
+		_, err = bc.InjectTx(complete)
+		require.NoError(t, err)
+
+		// Txs are replayed at the time of their meta, so the completion is
+		// still accepted after the deadline.
+		time.Sleep(100 * time.Millisecond)
+		bc.state = NewMemoryState()
+		require.NoError(t, bc.InitState())
+	})
+}
@@ -0,0 +1,209 @@
+package iko
+
+import (
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"gopkg.in/sirupsen/logrus.v1"
+)
+
+// EventType is the type of an event published by the BlockChain.
+type EventType string
+
+const (
+	// EventTxAccepted is published for every tx accepted into the chain.
+	EventTxAccepted EventType = "tx_accepted"
+
+	// EventKittyGenerated is published for every kitty created by a tx, being
+	// the kitty of a generation tx, or the child of a breed tx.
+	EventKittyGenerated EventType = "kitty_generated"
+
+	// EventKittyTransferred is published for every kitty of a tx that changes
+	// it's owner. This includes burns and auction listings.
+	EventKittyTransferred EventType = "kitty_transferred"
+)
+
+// Event is an event of an accepted tx. 'KittyID', 'From' and 'To' are only set
+// for kitty events, and 'From' is empty for generated kitties.
+type Event struct {
+	Type    EventType
+	Tx      TxWrapper
+	KittyID KittyID
+	From    cipher.Address
+	To      cipher.Address
+}
+
+// EventHandler handles an event delivered to a subscriber.
+type EventHandler func(event Event)
+
+// eventSub is a subscriber of an EventBus.
+type eventSub struct {
+	types map[EventType]struct{} // types of interest, empty for all types
+	queue chan Event
+}
+
+func (s *eventSub) match(t EventType) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	_, ok := s.types[t]
+	return ok
+}
+
+// EventBus delivers events to multiple subscribers. Each subscriber receives
+// events on it's own goroutine, so that a slow handler does not delay the
+// others.
+type EventBus struct {
+	log  *logrus.Logger
+	subs map[*eventSub]struct{}
+	mux  sync.Mutex
+	wg   sync.WaitGroup
+}
+
+func NewEventBus(log *logrus.Logger) *EventBus {
+	return &EventBus{
+		log:  log,
+		subs: make(map[*eventSub]struct{}),
+	}
+}
+
+// Subscribe registers the handler for events of the types, or of all types if
+// none are given. Up to 'bufSize' events are queued for the handler, after
+// which events are dropped. The returned function unsubscribes, after which
+// events that are already queued are still delivered.
+func (b *EventBus) Subscribe(handler EventHandler, bufSize int, types ...EventType) func() {
+	sub := &eventSub{
+		types: make(map[EventType]struct{}, len(types)),
+		queue: make(chan Event, bufSize),
+	}
+	for _, t := range types {
+		sub.types[t] = struct{}{}
+	}
+
+	b.mux.Lock()
+	b.subs[sub] = struct{}{}
+	b.mux.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for event := range sub.queue {
+			handler(event)
+		}
+	}()
+
+	return func() {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+		b.remove(sub)
+	}
+}
+
+// Publish queues the event for every subscriber of it's type.
+func (b *EventBus) Publish(event Event) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	for sub := range b.subs {
+		if !sub.match(event.Type) {
+			continue
+		}
+		select {
+		case sub.queue <- event:
+		default:
+			b.log.
+				WithField("type", event.Type).
+				WithField("seq", event.Tx.Meta.Seq).
+				Warning("dropped event for slow subscriber")
+		}
+	}
+}
+
+// Close unsubscribes all subscribers, and waits for their queued events to be
+// delivered.
+func (b *EventBus) Close() {
+	b.mux.Lock()
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+	b.mux.Unlock()
+	b.wg.Wait()
+}
+
+func (b *EventBus) hasSubscribers() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return len(b.subs) > 0
+}
+
+// remove removes the subscriber, if it is not already removed. The caller
+// should hold the lock.
+func (b *EventBus) remove(sub *eventSub) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.queue)
+	}
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// Events obtains the event bus of the BlockChain, which publishes the events
+// of accepted txs.
+func (bc *BlockChain) Events() *EventBus {
+	return bc.events
+}
+
+// publishEvents publishes the events of an accepted tx.
+func (bc *BlockChain) publishEvents(txWrap *TxWrapper) {
+	if !bc.events.hasSubscribers() {
+		return
+	}
+	bc.events.Publish(Event{Type: EventTxAccepted, Tx: *txWrap})
+
+	tx := &txWrap.Tx
+	if tx.Rotation != nil || tx.Admin != nil {
+		return
+	}
+	if tx.In == EmptyTxHash() {
+		bc.events.Publish(Event{
+			Type:    EventKittyGenerated,
+			Tx:      *txWrap,
+			KittyID: tx.KittyID,
+			To:      tx.Out,
+		})
+		return
+	}
+	if tx.Breed != nil {
+		bc.events.Publish(Event{
+			Type:    EventKittyGenerated,
+			Tx:      *txWrap,
+			KittyID: tx.Breed.ChildID,
+			To:      tx.Out,
+		})
+	}
+	if tx.IsMetadata() {
+		return
+	}
+	in, e := bc.chain.GetTxOfHash(tx.In)
+	if e != nil {
+		bc.log.
+			WithError(e).
+			WithField("seq", txWrap.Meta.Seq).
+			Warning("failed to obtain input tx of event")
+		return
+	}
+	if in.Tx.Out == tx.Out {
+		return
+	}
+	for _, kittyID := range tx.KittyIDs() {
+		bc.events.Publish(Event{
+			Type:    EventKittyTransferred,
+			Tx:      *txWrap,
+			KittyID: kittyID,
+			From:    in.Tx.Out,
+			To:      tx.Out,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package iko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_Events(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		all         = make(chan Event, 10)
+		transferred = make(chan Event, 10)
+		dropped     = make(chan Event, 10)
+		timeout     = time.Second
+	)
+	bc.Events().Subscribe(func(event Event) { all <- event }, 10)
+	bc.Events().Subscribe(func(event Event) { transferred <- event }, 10, EventKittyTransferred)
+	unsub := bc.Events().Subscribe(func(event Event) { dropped <- event }, 10)
+	unsub()
+	unsub()
+
+	recv := func(t *testing.T, events chan Event) Event {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(timeout):
+			t.Fatal("event should be delivered")
+			return Event{}
+		}
+	}
+
+	gen := NewGenTx(0, GenSK)
+	_, err := bc.InjectTx(gen)
+	require.NoError(t, err)
+	event := recv(t, all)
+	require.Equal(t, EventTxAccepted, event.Type)
+	require.Equal(t, gen.Hash(), event.Tx.Tx.Hash())
+	event = recv(t, all)
+	require.Equal(t, EventKittyGenerated, event.Type)
+	require.Equal(t, KittyID(0), event.KittyID)
+	require.Equal(t, gen.Out, event.To)
+
+	pk, _ := cipher.GenerateKeyPair()
+	to := cipher.AddressFromPubKey(pk)
+	transfer, err := NewTransferTx(gen, to, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err)
+	require.Equal(t, EventTxAccepted, recv(t, all).Type)
+	event = recv(t, transferred)
+	require.Equal(t, EventKittyTransferred, event.Type)
+	require.Equal(t, gen.Out, event.From)
+	require.Equal(t, to, event.To)
+	require.Equal(t, event, recv(t, all))
+
+	require.Len(t, transferred, 0, "subscriber should only receive events of it's types")
+	require.Len(t, dropped, 0, "unsubscribed handler should not receive events")
+}
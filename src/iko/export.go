@@ -0,0 +1,192 @@
+package iko
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// ChainExportVersion is the version of the chain export format.
+const ChainExportVersion = 1
+
+// exportPageSize is the number of transactions read from the ChainDB at once
+// when exporting.
+const exportPageSize = 100
+
+// ChainExport is the portable JSON representation of a chain.
+type ChainExport struct {
+	Version      int        `json:"version"`
+	GenerationPK string     `json:"generation_pk"`
+	Transactions []TxExport `json:"transactions"`
+}
+
+// TxExport is the portable JSON representation of a transaction.
+type TxExport struct {
+	Seq     uint64  `json:"seq"`
+	TS      int64   `json:"ts"`
+	Hash    string  `json:"hash"`
+	KittyID KittyID `json:"kitty_id"`
+	In      string  `json:"in"`
+	Out     string  `json:"out"`
+	Sig     string  `json:"sig"`
+}
+
+func NewTxExport(txWrap *TxWrapper) TxExport {
+	return TxExport{
+		Seq:     txWrap.Meta.Seq,
+		TS:      txWrap.Meta.TS,
+		Hash:    txWrap.Tx.Hash().Hex(),
+		KittyID: txWrap.Tx.KittyID,
+		In:      txWrap.Tx.In.Hex(),
+		Out:     txWrap.Tx.Out.String(),
+		Sig:     txWrap.Tx.Sig.Hex(),
+	}
+}
+
+// TxWrapper parses the exported transaction. It fails if the recorded hash
+// does not match that of the parsed transaction.
+func (t TxExport) TxWrapper() (TxWrapper, error) {
+	in, e := cipher.SHA256FromHex(t.In)
+	if e != nil {
+		return TxWrapper{}, fmt.Errorf("invalid 'in' of tx of seq %d: %v", t.Seq, e)
+	}
+	out, e := cipher.DecodeBase58Address(t.Out)
+	if e != nil {
+		return TxWrapper{}, fmt.Errorf("invalid 'out' of tx of seq %d: %v", t.Seq, e)
+	}
+	sig, e := cipher.SigFromHex(t.Sig)
+	if e != nil {
+		return TxWrapper{}, fmt.Errorf("invalid 'sig' of tx of seq %d: %v", t.Seq, e)
+	}
+	txWrap := TxWrapper{
+		Tx: Transaction{
+			KittyID: t.KittyID,
+			In:      TxHash(in),
+			Out:     out,
+			Sig:     sig,
+		},
+		Meta: TxMeta{
+			Seq: t.Seq,
+			TS:  t.TS,
+		},
+	}
+	if hash := txWrap.Tx.Hash().Hex(); hash != t.Hash {
+		return TxWrapper{}, fmt.Errorf("tx of seq %d has hash '%s', expected '%s'",
+			t.Seq, hash, t.Hash)
+	}
+	return txWrap, nil
+}
+
+// ExportChain writes the full ordered list of transactions to 'w' as a JSON
+// encoded ChainExport. Transactions are streamed page by page, so the whole
+// chain is never held in memory.
+func (bc *BlockChain) ExportChain(w io.Writer) error {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	header := fmt.Sprintf(`{"version":%d,"generation_pk":"%s","transactions":[`,
+		ChainExportVersion, bc.c.GenerationPK.Hex())
+	if _, e := io.WriteString(w, header); e != nil {
+		return e
+	}
+
+	cLen := bc.chain.Len()
+	for seq := uint64(0); seq < cLen; seq += exportPageSize {
+		txWraps, e := bc.chain.GetTxsOfSeqRange(seq, exportPageSize)
+		if e != nil {
+			return e
+		}
+		for i := range txWraps {
+			raw, e := json.Marshal(NewTxExport(&txWraps[i]))
+			if e != nil {
+				return e
+			}
+			if seq+uint64(i) > 0 {
+				raw = append([]byte{','}, raw...)
+			}
+			if _, e := w.Write(raw); e != nil {
+				return e
+			}
+		}
+	}
+
+	_, e := io.WriteString(w, "]}\n")
+	return e
+}
+
+// ImportChain reads a JSON encoded ChainExport from 'r' and appends it's
+// transactions to the chain. Every transaction is validated as with
+// 'MakeTxChecker' (but at the time of it's meta), and the sequence of the
+// first imported transaction needs to follow the head of the chain.
+// It returns the number of transactions imported.
+func (bc *BlockChain) ImportChain(r io.Reader) (uint64, error) {
+	var export ChainExport
+	if e := json.NewDecoder(r).Decode(&export); e != nil {
+		return 0, e
+	}
+	if export.Version != ChainExportVersion {
+		return 0, fmt.Errorf("unsupported chain export version %d", export.Version)
+	}
+	if genPK := bc.c.GenerationPK.Hex(); export.GenerationPK != genPK {
+		return 0, ErrCreatorMismatch
+	}
+	// Transactions before one that fails to decode are still imported.
+	txWraps := make([]TxWrapper, 0, len(export.Transactions))
+	for _, txExport := range export.Transactions {
+		txWrap, e := txExport.TxWrapper()
+		if e != nil {
+			count, appendErr := bc.AppendTxs(txWraps)
+			if appendErr != nil {
+				return count, appendErr
+			}
+			return count, e
+		}
+		txWraps = append(txWraps, txWrap)
+	}
+	return bc.AppendTxs(txWraps)
+}
+
+// AppendTxs appends txs of another chain (such as of a peer), keeping their
+// metas. Every transaction is validated as with 'MakeTxChecker' (but at the
+// time of it's meta), and the sequence of the first transaction needs to
+// follow the head of the chain. It returns the number of transactions
+// appended.
+func (bc *BlockChain) AppendTxs(txWraps []TxWrapper) (uint64, error) {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	defer bc.autoSnapshotState(bc.chain.Len())
+
+	if bc.ctx.Err() != nil {
+		return 0, ErrChainStopped
+	}
+	if e := bc.redoWAL(); e != nil {
+		return 0, e
+	}
+
+	var (
+		at    TxMeta
+		check = makeTxChecker(bc, bc.getTx, &at)
+		count uint64
+	)
+	for _, txWrap := range txWraps {
+		if seq := bc.chain.Len(); txWrap.Meta.Seq != seq {
+			return count, fmt.Errorf("appended tx has seq %d, expected %d",
+				txWrap.Meta.Seq, seq)
+		}
+		if max := bc.c.MaxSequence; max > 0 && bc.chain.Len() >= max {
+			return count, ErrChainFull
+		}
+		at = txWrap.Meta
+		if e := bc.addTx(txWrap, check); e != nil {
+			return count, e
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,207 @@
+package iko
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// CSVColumn is a column of a CSV export.
+type CSVColumn string
+
+const (
+	CSVSeq     CSVColumn = "seq"
+	CSVTime    CSVColumn = "time"
+	CSVHash    CSVColumn = "hash"
+	CSVKittyID CSVColumn = "kitty_id"
+	CSVIn      CSVColumn = "in"
+	CSVFrom    CSVColumn = "from"
+	CSVTo      CSVColumn = "to"
+	CSVSig     CSVColumn = "sig"
+)
+
+// DefaultCSVColumns are the columns exported when none are specified.
+var DefaultCSVColumns = []CSVColumn{
+	CSVSeq, CSVTime, CSVHash, CSVKittyID, CSVIn, CSVFrom, CSVTo, CSVSig,
+}
+
+// HistoryCSVColumns are the columns of the ownership history export.
+var HistoryCSVColumns = []CSVColumn{
+	CSVKittyID, CSVSeq, CSVTime, CSVFrom, CSVTo, CSVHash,
+}
+
+type CSVExportConfig struct {
+	Columns []CSVColumn // Columns to export, defaults to 'DefaultCSVColumns'.
+
+	FromSeq uint64 // First seq to export (inclusive).
+	ToSeq   uint64 // Last seq to export (exclusive), 0 exports up to the head.
+
+	FromTime time.Time // Earliest tx time to export (inclusive), zero is unbounded.
+	ToTime   time.Time // Latest tx time to export (exclusive), zero is unbounded.
+}
+
+func (c *CSVExportConfig) Prepare() error {
+	if len(c.Columns) == 0 {
+		c.Columns = DefaultCSVColumns
+	}
+	for _, col := range c.Columns {
+		switch col {
+		case CSVSeq, CSVTime, CSVHash, CSVKittyID, CSVIn, CSVFrom, CSVTo, CSVSig:
+		default:
+			return fmt.Errorf("invalid csv column '%s'", col)
+		}
+	}
+	if c.ToSeq != 0 && c.ToSeq < c.FromSeq {
+		return fmt.Errorf("invalid seq range [%d, %d)", c.FromSeq, c.ToSeq)
+	}
+	return nil
+}
+
+// includes determines whether the tx is within the configured time range.
+func (c *CSVExportConfig) includes(txWrap *TxWrapper) bool {
+	if !c.FromTime.IsZero() && txWrap.Meta.TS < c.FromTime.UnixNano() {
+		return false
+	}
+	if !c.ToTime.IsZero() && txWrap.Meta.TS >= c.ToTime.UnixNano() {
+		return false
+	}
+	return true
+}
+
+// ExportCSV writes transactions within the configured range to 'w' as CSV.
+// The first row holds the column names.
+func (bc *BlockChain) ExportCSV(w io.Writer, config *CSVExportConfig) error {
+	if e := config.Prepare(); e != nil {
+		return e
+	}
+	cw := csv.NewWriter(w)
+	if e := cw.Write(csvHeader(config.Columns)); e != nil {
+		return e
+	}
+	e := bc.rangeExportTxs(config, func(txWrap *TxWrapper, from string) error {
+		return cw.Write(csvRecord(config.Columns, txWrap, from))
+	})
+	if e != nil {
+		return e
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportHistoryCSV writes the ownership history of every kitty to 'w' as CSV.
+// Rows are ordered by kitty ID, then by seq. Only the range fields of the
+// config are used, columns are always 'HistoryCSVColumns'.
+func (bc *BlockChain) ExportHistoryCSV(w io.Writer, config *CSVExportConfig) error {
+	config.Columns = HistoryCSVColumns
+	if e := config.Prepare(); e != nil {
+		return e
+	}
+	var records [][]string
+	var kittyIDs []KittyID
+	e := bc.rangeExportTxs(config, func(txWrap *TxWrapper, from string) error {
+		records = append(records, csvRecord(config.Columns, txWrap, from))
+		kittyIDs = append(kittyIDs, txWrap.Tx.KittyID)
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+
+	// Transactions are already ordered by seq, so a stable sort by kitty ID
+	// keeps the history of each kitty in order.
+	order := make([]int, len(records))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return kittyIDs[order[i]] < kittyIDs[order[j]]
+	})
+
+	cw := csv.NewWriter(w)
+	if e := cw.Write(csvHeader(config.Columns)); e != nil {
+		return e
+	}
+	for _, i := range order {
+		if e := cw.Write(records[i]); e != nil {
+			return e
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// rangeExportTxs calls 'action' for every tx within the configured range, with
+// the address that the kitty was transferred from (empty for generation txs).
+func (bc *BlockChain) rangeExportTxs(config *CSVExportConfig, action func(txWrap *TxWrapper, from string) error) error {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	toSeq := bc.chain.Len()
+	if config.ToSeq != 0 && config.ToSeq < toSeq {
+		toSeq = config.ToSeq
+	}
+	for seq := config.FromSeq; seq < toSeq; seq += exportPageSize {
+		pageSize := uint64(exportPageSize)
+		if seq+pageSize > toSeq {
+			pageSize = toSeq - seq
+		}
+		txWraps, e := bc.chain.GetTxsOfSeqRange(seq, pageSize)
+		if e != nil {
+			return e
+		}
+		for i := range txWraps {
+			txWrap := &txWraps[i]
+			if !config.includes(txWrap) {
+				continue
+			}
+			var from string
+			if txWrap.Tx.In != EmptyTxHash() {
+				in, e := bc.chain.GetTxOfHash(txWrap.Tx.In)
+				if e != nil {
+					return e
+				}
+				from = in.Tx.Out.String()
+			}
+			if e := action(txWrap, from); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func csvHeader(columns []CSVColumn) []string {
+	out := make([]string, len(columns))
+	for i, col := range columns {
+		out[i] = string(col)
+	}
+	return out
+}
+
+func csvRecord(columns []CSVColumn, txWrap *TxWrapper, from string) []string {
+	out := make([]string, len(columns))
+	for i, col := range columns {
+		switch col {
+		case CSVSeq:
+			out[i] = strconv.FormatUint(txWrap.Meta.Seq, 10)
+		case CSVTime:
+			out[i] = time.Unix(0, txWrap.Meta.TS).UTC().Format(time.RFC3339Nano)
+		case CSVHash:
+			out[i] = txWrap.Tx.Hash().Hex()
+		case CSVKittyID:
+			out[i] = strconv.FormatUint(uint64(txWrap.Tx.KittyID), 10)
+		case CSVIn:
+			out[i] = txWrap.Tx.In.Hex()
+		case CSVFrom:
+			out[i] = from
+		case CSVTo:
+			out[i] = txWrap.Tx.Out.String()
+		case CSVSig:
+			out[i] = txWrap.Tx.Sig.Hex()
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package iko
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func readCSV(t *testing.T, buf *bytes.Buffer) [][]string {
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err, "output should be valid csv")
+	return records
+}
+
+func TestBlockChain_ExportCSV(t *testing.T) {
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 3)
+
+	toAddr := cipher.AddressFromPubKey(RootPK)
+	transfer, err := NewTransferTx(&txWraps[0].Tx, toAddr, GenSK)
+	require.NoError(t, err, "should create transfer tx")
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err, "should inject transfer tx")
+
+	t.Run("AllColumns", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, bc.ExportCSV(buf, &CSVExportConfig{}))
+
+		records := readCSV(t, buf)
+		require.Len(t, records, 5, "should have header and 4 rows")
+		require.Equal(t, csvHeader(DefaultCSVColumns), records[0],
+			"first row should be header")
+
+		last := records[4]
+		require.Equal(t, "3", last[0], "seq should match")
+		require.Equal(t, transfer.Hash().Hex(), last[2], "hash should match")
+		require.Equal(t, txWraps[0].Tx.Out.String(), last[5], "from should match")
+		require.Equal(t, toAddr.String(), last[6], "to should match")
+	})
+
+	t.Run("ColumnsAndSeqRange", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, bc.ExportCSV(buf, &CSVExportConfig{
+			Columns: []CSVColumn{CSVKittyID, CSVSeq},
+			FromSeq: 1,
+			ToSeq:   3,
+		}))
+		require.Equal(t, [][]string{
+			{"kitty_id", "seq"},
+			{"1", "1"},
+			{"2", "2"},
+		}, readCSV(t, buf))
+	})
+
+	t.Run("TimeRange", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, bc.ExportCSV(buf, &CSVExportConfig{
+			Columns:  []CSVColumn{CSVSeq},
+			FromTime: time.Now().Add(time.Hour),
+		}))
+		require.Len(t, readCSV(t, buf), 1, "should only have header")
+	})
+
+	t.Run("InvalidColumn", func(t *testing.T) {
+		err := bc.ExportCSV(new(bytes.Buffer), &CSVExportConfig{
+			Columns: []CSVColumn{"invalid"},
+		})
+		require.Error(t, err, "invalid column should fail")
+	})
+
+	t.Run("History", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, bc.ExportHistoryCSV(buf, &CSVExportConfig{}))
+
+		records := readCSV(t, buf)
+		require.Len(t, records, 5, "should have header and 4 rows")
+
+		var kittyIDs, seqs []string
+		for _, record := range records[1:] {
+			kittyIDs = append(kittyIDs, record[0])
+			seqs = append(seqs, record[1])
+		}
+		require.Equal(t, []string{"0", "0", "1", "2"}, kittyIDs,
+			"rows should be ordered by kitty")
+		require.Equal(t, []string{"0", "3", "1", "2"}, seqs,
+			"history of each kitty should be ordered by seq")
+	})
+}
@@ -0,0 +1,71 @@
+package iko
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_ExportChain(t *testing.T) {
+	src, closeSrc := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeSrc()
+
+	txWraps := injectGenTxs(t, src, exportPageSize+5)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, src.ExportChain(buf),
+		"export should succeed")
+
+	var export ChainExport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &export),
+		"export should be valid json")
+	require.Len(t, export.Transactions, len(txWraps),
+		"all transactions should be exported")
+
+	t.Run("ImportChain", func(t *testing.T) {
+		dst, closeDst := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+		defer closeDst()
+
+		count, err := dst.ImportChain(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err, "import should succeed")
+		require.Equal(t, uint64(len(txWraps)), count,
+			"all transactions should be imported")
+
+		for _, txWrap := range txWraps {
+			reqTxWrap, err := dst.GetTxOfSeq(txWrap.Meta.Seq)
+			require.NoError(t, err, "imported tx should exist")
+			require.Equal(t, txWrap, reqTxWrap,
+				"imported tx should equal exported tx")
+
+			_, ok := dst.GetKittyState(txWrap.Tx.KittyID)
+			require.True(t, ok, "imported kitty should exist in state")
+		}
+	})
+
+	t.Run("ImportChain_Tampered", func(t *testing.T) {
+		dst, closeDst := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+		defer closeDst()
+
+		tampered := export
+		tampered.Transactions = append([]TxExport{}, export.Transactions...)
+		tampered.Transactions[2].KittyID = 1000
+		raw, err := json.Marshal(tampered)
+		require.NoError(t, err)
+
+		count, err := dst.ImportChain(bytes.NewReader(raw))
+		require.Error(t, err, "import of tampered tx should fail")
+		require.Equal(t, uint64(2), count,
+			"transactions before the tampered tx should be imported")
+	})
+
+	t.Run("ImportChain_CreatorMismatch", func(t *testing.T) {
+		dst, closeDst := newTestBlockChain(t, &BlockChainConfig{GenerationPK: RootPK})
+		defer closeDst()
+
+		_, err := dst.ImportChain(bytes.NewReader(buf.Bytes()))
+		require.Equal(t, ErrCreatorMismatch, err,
+			"import of chain of another creator should fail")
+	})
+}
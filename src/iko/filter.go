@@ -0,0 +1,289 @@
+package iko
+
+import (
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// TxFilter determines the transactions that are of interest to a subscriber.
+// An empty filter matches all transactions.
+type TxFilter struct {
+	Addresses map[cipher.Address]struct{}
+	KittyIDs  map[KittyID]struct{}
+	Bloom     *BloomFilter // Optional, matches addresses that may be in the filter.
+}
+
+func NewTxFilter(addresses []cipher.Address, kittyIDs []KittyID) *TxFilter {
+	f := &TxFilter{
+		Addresses: make(map[cipher.Address]struct{}, len(addresses)),
+		KittyIDs:  make(map[KittyID]struct{}, len(kittyIDs)),
+	}
+	for _, address := range addresses {
+		f.Addresses[address] = struct{}{}
+	}
+	for _, kittyID := range kittyIDs {
+		f.KittyIDs[kittyID] = struct{}{}
+	}
+	return f
+}
+
+// Match determines whether the transaction passes the filter. A transaction
+// matches an address if the kitty is transferred either to or from it.
+func (f *TxFilter) Match(bc Chain, tx *Transaction) bool {
+	if len(f.Addresses) == 0 && len(f.KittyIDs) == 0 && f.Bloom == nil {
+		return true
+	}
+	for _, kittyID := range tx.KittyIDs() {
+		if _, ok := f.KittyIDs[kittyID]; ok {
+			return true
+		}
+	}
+	if len(f.Addresses) == 0 && f.Bloom == nil {
+		return false
+	}
+	if f.matchAddress(tx.Out) {
+		return true
+	}
+	if tx.In == EmptyTxHash() {
+		return false
+	}
+	in, e := bc.GetTxOfHash(tx.In)
+	if e != nil {
+		return false
+	}
+	return f.matchAddress(in.Tx.Out)
+}
+
+func (f *TxFilter) matchAddress(address cipher.Address) bool {
+	if _, ok := f.Addresses[address]; ok {
+		return true
+	}
+	return f.Bloom != nil && f.Bloom.MayContain(address)
+}
+
+// TxOrder is the order of the txs of a page.
+type TxOrder string
+
+const (
+	// TxOrderAscending orders txs from oldest to newest. It is the default.
+	TxOrderAscending TxOrder = "asc"
+
+	// TxOrderDescending orders txs from newest to oldest, such as for
+	// explorers.
+	TxOrderDescending TxOrder = "desc"
+)
+
+// TxPageFilter restricts and orders the txs of 'GetTransactionPage'. Fields
+// of zero value do not restrict the txs.
+type TxPageFilter struct {
+	Address  *cipher.Address // Txs to or from the address.
+	KittyID  *KittyID        // Txs of the kitty.
+	SeqStart uint64          // Minimum seq, inclusive.
+	SeqEnd   uint64          // Maximum seq, exclusive.
+	TSStart  int64           // Minimum timestamp in unix nanoseconds, inclusive.
+	TSEnd    int64           // Maximum timestamp in unix nanoseconds, exclusive.
+	Order    TxOrder         // Ascending if empty.
+}
+
+// hasRange determines whether the filter has a seq or timestamp range.
+func (f *TxPageFilter) hasRange() bool {
+	return f.SeqStart != 0 || f.SeqEnd != 0 || f.TSStart != 0 || f.TSEnd != 0
+}
+
+// matchMeta determines whether the tx of meta is within the seq and timestamp
+// ranges of the filter.
+func (f *TxPageFilter) matchMeta(meta TxMeta) bool {
+	return meta.Seq >= f.SeqStart &&
+		(f.SeqEnd == 0 || meta.Seq < f.SeqEnd) &&
+		meta.TS >= f.TSStart &&
+		(f.TSEnd == 0 || meta.TS < f.TSEnd)
+}
+
+// indexedTxs obtains the hashes of the txs of the address and kitty of the
+// filter from the tx indexes of the state, in chain order. It returns false
+// if the filter has neither an address nor a kitty. The caller should hold
+// the read lock.
+func (bc *BlockChain) indexedTxs(f *TxPageFilter) (TxHashes, bool) {
+	var kittyTxs TxHashes
+	if f.KittyID != nil {
+		if kState, ok := bc.state.GetKittyState(*f.KittyID); ok {
+			kittyTxs = kState.Transactions
+		}
+	}
+	switch {
+	case f.Address != nil && f.KittyID != nil:
+		addressTxs := make(map[TxHash]struct{})
+		for _, txHash := range bc.state.GetAddressState(*f.Address).Transactions {
+			addressTxs[txHash] = struct{}{}
+		}
+		var out TxHashes
+		for _, txHash := range kittyTxs {
+			if _, ok := addressTxs[txHash]; ok {
+				out = append(out, txHash)
+			}
+		}
+		return out, true
+	case f.KittyID != nil:
+		return kittyTxs, true
+	case f.Address != nil:
+		return bc.state.GetAddressState(*f.Address).Transactions, true
+	default:
+		return nil, false
+	}
+}
+
+// getFilteredTxPage obtains a page of the txs that match the filter. Txs of
+// an address or kitty are found with the tx indexes of the state, and txs of
+// a seq range are read directly from the chain. Only a timestamp range
+// without an address or kitty requires scanning the txs of the seq range. The
+// txs are read from a snapshot of the chain, so that writes are not blocked.
+func (bc *BlockChain) getFilteredTxPage(currentPage, perPage uint64, f *TxPageFilter) (PaginatedTransactions, error) {
+	bc.mux.RLock()
+	txHashes, indexed := bc.indexedTxs(f)
+	// The indexes are copied, as they may change once the read lock is
+	// released.
+	txHashes = append(TxHashes(nil), txHashes...)
+	chain, e := bc.snapshotChain()
+	if e != nil {
+		return PaginatedTransactions{}, e
+	}
+	defer chain.Release()
+
+	var (
+		out = PaginatedTransactions{
+			CurrentPage: currentPage,
+			PerPage:     perPage,
+		}
+		desc  = f.Order == TxOrderDescending
+		start = currentPage * perPage
+		count uint64
+	)
+	// add adds the tx to the page if it is of the page. Txs should be added
+	// in the order of the filter.
+	add := func(txWrap TxWrapper) {
+		if count >= start && count < start+perPage {
+			out.Transactions = append(out.Transactions, txWrap)
+		}
+		count++
+	}
+
+	end := chain.Len()
+	if f.SeqEnd != 0 && f.SeqEnd < end {
+		end = f.SeqEnd
+	}
+
+	if indexed {
+		if !f.hasRange() {
+			// Only the txs of the page are read.
+			count = uint64(len(txHashes))
+			lo, hi := pageBounds(count, start, perPage, desc)
+			for _, txHash := range txHashes[lo:hi] {
+				txWrap, e := chain.txOfHash(txHash)
+				if e != nil {
+					return PaginatedTransactions{}, fmt.Errorf("tx '%s': %w", txHash.Hex(), e)
+				}
+				out.Transactions = append(out.Transactions, txWrap)
+			}
+			if desc {
+				reverseTxs(out.Transactions)
+			}
+		} else {
+			for i := range txHashes {
+				txHash := txHashes[i]
+				if desc {
+					txHash = txHashes[len(txHashes)-1-i]
+				}
+				txWrap, e := chain.txOfHash(txHash)
+				if e != nil {
+					return PaginatedTransactions{}, fmt.Errorf("tx '%s': %w", txHash.Hex(), e)
+				}
+				if f.matchMeta(txWrap.Meta) {
+					add(txWrap)
+				}
+			}
+		}
+	} else if f.TSStart == 0 && f.TSEnd == 0 {
+		if end > f.SeqStart {
+			count = end - f.SeqStart
+		}
+		if lo, hi := pageBounds(count, start, perPage, desc); lo < hi {
+			txWraps, e := chain.GetTxsOfSeqRange(f.SeqStart+lo, hi-lo)
+			if e != nil {
+				return PaginatedTransactions{}, e
+			}
+			if desc {
+				reverseTxs(txWraps)
+			}
+			out.Transactions = txWraps
+		}
+	} else if desc {
+		for next := end; next > f.SeqStart; {
+			n := uint64(streamBatchSize)
+			if next-f.SeqStart < n {
+				n = next - f.SeqStart
+			}
+			next -= n
+			txWraps, e := chain.GetTxsOfSeqRange(next, n)
+			if e != nil {
+				return PaginatedTransactions{}, e
+			}
+			for i := len(txWraps) - 1; i >= 0; i-- {
+				if f.matchMeta(txWraps[i].Meta) {
+					add(txWraps[i])
+				}
+			}
+		}
+	} else {
+		for next := f.SeqStart; next < end; {
+			n := uint64(streamBatchSize)
+			if end-next < n {
+				n = end - next
+			}
+			txWraps, e := chain.GetTxsOfSeqRange(next, n)
+			if e != nil {
+				return PaginatedTransactions{}, e
+			}
+			if len(txWraps) == 0 {
+				// The whole batch is pruned.
+				next += n
+				continue
+			}
+			for _, txWrap := range txWraps {
+				if f.matchMeta(txWrap.Meta) {
+					add(txWrap)
+				}
+			}
+			next = txWraps[len(txWraps)-1].Meta.Seq + 1
+		}
+	}
+
+	out.TotalPageCount = totalPageCount(count, perPage)
+	out.HasNext = currentPage+1 < out.TotalPageCount
+	out.HasPrev = currentPage > 0
+	return out, nil
+}
+
+// pageBounds obtains the range [lo, hi) of ascending indexes of the txs of a
+// page, of which the first tx is at index 'start' in the order of the page.
+// The last page may be partial in either order.
+func pageBounds(count, start, perPage uint64, desc bool) (uint64, uint64) {
+	if start >= count {
+		return 0, 0
+	}
+	n := count - start
+	if n > perPage {
+		n = perPage
+	}
+	if desc {
+		return count - start - n, count - start
+	}
+	return start, start + n
+}
+
+// reverseTxs reverses the order of the txs in place.
+func reverseTxs(txWraps []TxWrapper) {
+	for i, j := 0, len(txWraps)-1; i < j; i, j = i+1, j-1 {
+		txWraps[i], txWraps[j] = txWraps[j], txWraps[i]
+	}
+}
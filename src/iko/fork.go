@@ -0,0 +1,78 @@
+package iko
+
+import (
+	"time"
+)
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// SpeculateTxs applies an ordered batch of candidate txs to a fork of the
+// state (see 'StateDB.Fork'), as if they were injected at the head of the
+// chain, and calls 'inspect' with the fork and the error of each tx (nil for
+// txs that would be accepted). Txs of the batch may spend txs that precede
+// them in the batch. Neither the chain nor the state are changed, so that
+// candidate txs (such as a bid and the settlement of it's auction) can be
+// evaluated before they are submitted. The fork is only valid during the call
+// of 'inspect', which should not inject txs.
+func (bc *BlockChain) SpeculateTxs(txs []Transaction, inspect func(state StateDB, errs []error)) error {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	if bc.ctx.Err() != nil {
+		return ErrChainStopped
+	}
+	fork, errs := bc.forkTxs(txs)
+	inspect(fork.state, errs)
+	return nil
+}
+
+// fork obtains a BlockChain of a fork of the state, against which txs can be
+// checked without changing the state. Should be called with 'bc.mux' held,
+// and the fork should not be used after it is released.
+func (bc *BlockChain) fork() *BlockChain {
+	return &BlockChain{
+		c:       bc.c,
+		chain:   bc.chain,
+		state:   bc.state.Fork(),
+		log:     bc.log,
+		ctx:     bc.ctx,
+		pool:    bc.pool,
+		genAddr: bc.genAddr,
+		txCache: bc.txCache,
+	}
+}
+
+// forkTxs checks the txs against a fork of the state, at successive seqs
+// after the head of the chain. The fork and the error of each tx are
+// returned. Should be called with 'bc.mux' held.
+func (bc *BlockChain) forkTxs(txs []Transaction) (*BlockChain, []error) {
+	var (
+		fork  = bc.fork()
+		errs  = make([]error, len(txs))
+		batch = make(map[TxHash]*Transaction) // accepted txs of the batch
+		at    = TxMeta{TS: time.Now().UnixNano()}
+		check = makeTxChecker(fork, func(hash TxHash) (*Transaction, error) {
+			if tx, ok := batch[hash]; ok {
+				return tx, nil
+			}
+			return fork.getTx(hash)
+		}, &at)
+	)
+	if txWrap, e := bc.chain.Head(); e == nil {
+		at.Seq = txWrap.Meta.Seq + 1
+	}
+	for i := range txs {
+		if max := bc.c.MaxSequence; max > 0 && at.Seq >= max {
+			errs[i] = ErrChainFull
+			continue
+		}
+		if errs[i] = check(&txs[i]); errs[i] != nil {
+			continue
+		}
+		batch[txs[i].Hash()] = &txs[i]
+		at.Seq++
+	}
+	return fork, errs
+}
@@ -0,0 +1,70 @@
+package iko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_SpeculateTxs(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	pk, _ := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pk)
+
+	injectGenTxs(t, bc, 1)
+	gen1 := NewGenTx(1, GenSK)
+	transfer, err := NewTransferTx(gen1, addr, GenSK)
+	require.NoError(t, err)
+
+	var called bool
+	require.NoError(t, bc.SpeculateTxs([]Transaction{*gen1, *transfer, *NewGenTx(0, GenSK)},
+		func(state StateDB, errs []error) {
+			called = true
+			require.NoError(t, errs[0])
+			require.NoError(t, errs[1], "txs should spend txs that precede them in the batch")
+			require.Error(t, errs[2], "generation of existing kitty should fail")
+
+			kState, ok := state.GetKittyState(1)
+			require.True(t, ok)
+			require.Equal(t, addr, kState.Address)
+		}))
+	require.True(t, called)
+
+	_, ok := bc.GetKittyState(1)
+	require.False(t, ok, "speculated txs should not change the state")
+	require.Equal(t, uint64(1), bc.chain.Len())
+
+	bc.Close()
+	require.Equal(t, ErrChainStopped, bc.SpeculateTxs(nil, func(StateDB, []error) {}))
+}
+
+func TestBlockChain_SubmitTx_PendingAuction(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	_, sk := cipher.GenerateKeyPair()
+	txWraps := injectGenTxs(t, bc, 1)
+
+	listing, err := NewListing(bc.CreatorAddress(), 10, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	list, err := NewAuctionListTx(&txWraps[0].Tx, listing)
+	require.NoError(t, err)
+	list.Sig = list.Sign(GenSK)
+	require.NoError(t, bc.SubmitTx(list))
+
+	bid, err := NewAuctionBidTx(list, listing, 9)
+	require.NoError(t, err)
+	bid.Sig = bid.Sign(sk)
+	require.Equal(t, ErrBidTooLow, bc.SubmitTx(bid),
+		"auction of pending listing should be checked")
+
+	bid, err = NewAuctionBidTx(list, listing, 10)
+	require.NoError(t, err)
+	bid.Sig = bid.Sign(sk)
+	require.NoError(t, bc.SubmitTx(bid))
+	require.Equal(t, 2, bc.CommitPending())
+}
@@ -0,0 +1,106 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+const (
+	// MaxChainIDSize is the maximum size of a chain ID, in bytes.
+	MaxChainIDSize = 64
+)
+
+var (
+	// ErrGenesisMismatch occurs when the genesis tx does not define the
+	// configured 'Genesis', such as for a chain of another network.
+	ErrGenesisMismatch = errors.New("genesis tx does not match the configured genesis")
+
+	// ErrGenesisNotFirst occurs when a tx other than the genesis tx defines a
+	// genesis.
+	ErrGenesisNotFirst = errors.New("only the genesis tx can define a genesis")
+)
+
+// Genesis defines a chain, so that a node does not load a chain of another
+// network. It is embedded in the genesis tx (of seq 0), and hence signed by
+// the genesis creator.
+type Genesis struct {
+	ChainID   string        // Identifies the network, such as "kittycash-mainnet".
+	CreatorPK cipher.PubKey // Of the genesis creator, the configured 'GenerationPK'.
+	TS        int64         // Unix time (in nanoseconds) of the genesis.
+}
+
+func (g Genesis) Serialize() []byte {
+	return encoder.Serialize(g)
+}
+
+// Verify checks that the chain ID is of 1 to 'MaxChainIDSize' bytes, and that
+// the creator key is valid.
+func (g Genesis) Verify() error {
+	if n := len(g.ChainID); n == 0 || n > MaxChainIDSize {
+		return fmt.Errorf("chain id should be of 1 to %d bytes, got %d",
+			MaxChainIDSize, n)
+	}
+	if e := g.CreatorPK.Verify(); e != nil {
+		return fmt.Errorf("genesis creator key is invalid: %v", e)
+	}
+	return nil
+}
+
+// NewGenesisTx creates the generation tx of seq 0, which defines the genesis.
+// The secret key should be of the genesis creator.
+func NewGenesisTx(genesis *Genesis, kittyID KittyID, sk cipher.SecKey) (*Transaction, error) {
+	if cipher.PubKeyFromSecKey(sk) != genesis.CreatorPK {
+		return nil, errors.New("secret key is not of the genesis creator")
+	}
+	tx := NewUnsignedGenTx(kittyID, cipher.AddressFromSecKey(sk))
+	tx.Genesis = genesis
+	tx.Sig = tx.Sign(sk)
+	return tx, nil
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// Genesis returns the genesis defined by the genesis tx, or nil if the chain
+// is empty or the genesis tx does not define one.
+func (bc *BlockChain) Genesis() (*Genesis, error) {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	if bc.chain.Len() == 0 {
+		return nil, nil
+	}
+	genesis, e := bc.txOfSeq(0)
+	if e != nil {
+		return nil, e
+	}
+	return genesis.Tx.Genesis, nil
+}
+
+// checkGenesisTx checks the genesis of a tx of seq: only the genesis tx can
+// define a genesis, which should be the configured 'Genesis' (if any) and of
+// the 'GenerationPK'.
+func (bc *BlockChain) checkGenesisTx(tx *Transaction, seq uint64) error {
+	if seq > 0 {
+		if tx.Genesis != nil {
+			return ErrGenesisNotFirst
+		}
+		return nil
+	}
+	if tx.Genesis != nil {
+		if e := tx.Genesis.Verify(); e != nil {
+			return e
+		}
+		if tx.Genesis.CreatorPK != bc.c.GenerationPK {
+			return ErrCreatorMismatch
+		}
+	}
+	if exp := bc.c.Genesis; exp != nil && (tx.Genesis == nil || *tx.Genesis != *exp) {
+		return ErrGenesisMismatch
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package iko
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGenesisTx(t *testing.T) {
+	genesis := &Genesis{ChainID: "kittycash-test", CreatorPK: GenPK, TS: 100}
+	tx, err := NewGenesisTx(genesis, 0, GenSK)
+	require.NoError(t, err)
+	require.NoError(t, tx.VerifyWith(nil, GenPK))
+
+	decoded, err := DeserializeTx(tx.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, tx, decoded, "genesis should be decoded")
+	require.NotEqual(t, NewGenTx(0, GenSK).Hash(), tx.Hash(),
+		"genesis should be part of the tx hash")
+
+	_, sk := cipher.GenerateKeyPair()
+	_, err = NewGenesisTx(genesis, 0, sk)
+	require.Error(t, err, "genesis tx should be signed by the genesis creator")
+
+	require.Error(t, Genesis{CreatorPK: GenPK}.Verify(), "chain id should not be empty")
+}
+
+func TestBlockChain_ChainID(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	genesis := &Genesis{ChainID: "kittycash-test", CreatorPK: GenPK, TS: 100}
+	config := func(genesis *Genesis) *BlockChainConfig {
+		return &BlockChainConfig{GenerationPK: GenPK, Genesis: genesis}
+	}
+
+	bc, err := NewBlockChain(context.Background(), config(genesis), chainDB, NewMemoryState())
+	require.NoError(t, err)
+
+	_, err = bc.InjectTx(NewGenTx(0, GenSK))
+	require.Equal(t, ErrGenesisMismatch, err, "genesis tx should define the genesis")
+
+	other, err := NewGenesisTx(&Genesis{ChainID: "kittycash-other", CreatorPK: GenPK}, 0, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(other)
+	require.Equal(t, ErrGenesisMismatch, err, "genesis tx should define the configured genesis")
+
+	tx, err := NewGenesisTx(genesis, 0, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(tx)
+	require.NoError(t, err)
+
+	tx, err = NewGenesisTx(genesis, 1, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(tx)
+	require.Equal(t, ErrGenesisNotFirst, err, "only the genesis tx can define a genesis")
+
+	got, err := bc.Genesis()
+	require.NoError(t, err)
+	require.Equal(t, genesis, got)
+	bc.Close()
+
+	t.Run("Reopen", func(t *testing.T) {
+		bc, err := NewBlockChain(context.Background(), config(genesis), chainDB, NewMemoryState())
+		require.NoError(t, err, "chain of the configured genesis should be loaded")
+		bc.Close()
+
+		bc, err = NewBlockChain(context.Background(), config(nil), chainDB, NewMemoryState())
+		require.NoError(t, err, "genesis should not be checked if not configured")
+		bc.Close()
+	})
+
+	t.Run("OtherNetwork", func(t *testing.T) {
+		_, err := NewBlockChain(context.Background(),
+			config(&Genesis{ChainID: "kittycash-other", CreatorPK: GenPK}), chainDB, NewMemoryState())
+		require.Equal(t, ErrGenesisMismatch, err, "chain of another network should be refused")
+	})
+}
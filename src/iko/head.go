@@ -0,0 +1,66 @@
+package iko
+
+// HeadUpdate is the head of the chain after a tx is accepted.
+type HeadUpdate struct {
+	Seq  uint64
+	Hash TxHash
+}
+
+// HeadChanges returns a channel that receives the new head after each tx
+// accepted into the chain. Updates are coalesced: if the receiver falls
+// behind, only the latest head is kept, so a slow receiver never blocks the
+// chain and always observes the current head. The channel is closed when the
+// BlockChain is closed.
+func (bc *BlockChain) HeadChanges() <-chan HeadUpdate {
+	bc.headMux.Lock()
+	defer bc.headMux.Unlock()
+
+	ch := make(chan HeadUpdate, 1)
+	if bc.headsClosed {
+		close(ch)
+		return ch
+	}
+	bc.heads = append(bc.heads, ch)
+	return ch
+}
+
+// headChanged obtains a channel that is closed when the next tx is accepted
+// into the chain.
+func (bc *BlockChain) headChanged() <-chan struct{} {
+	bc.headMux.Lock()
+	defer bc.headMux.Unlock()
+	return bc.headCh
+}
+
+// notifyHeadChanged closes the channel of 'headChanged', replacing it for the
+// next tx, and sends the new head to the channels of 'HeadChanges'.
+func (bc *BlockChain) notifyHeadChanged(txWrap *TxWrapper) {
+	bc.headMux.Lock()
+	defer bc.headMux.Unlock()
+
+	close(bc.headCh)
+	bc.headCh = make(chan struct{})
+
+	update := HeadUpdate{Seq: txWrap.Meta.Seq, Hash: txWrap.Tx.Hash()}
+	for _, ch := range bc.heads {
+		// Replace the pending update, if any. This is the only sender, so the
+		// buffer has room once drained.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- update
+	}
+}
+
+// closeHeadChanges closes the channels of 'HeadChanges'. It should be called
+// once no more txs are processed.
+func (bc *BlockChain) closeHeadChanges() {
+	bc.headMux.Lock()
+	defer bc.headMux.Unlock()
+
+	for _, ch := range bc.heads {
+		close(ch)
+	}
+	bc.heads, bc.headsClosed = nil, true
+}
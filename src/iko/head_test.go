@@ -0,0 +1,47 @@
+package iko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_HeadChanges(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	heads := bc.HeadChanges()
+
+	receive := func() HeadUpdate {
+		select {
+		case update := <-heads:
+			return update
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for head update")
+			return HeadUpdate{}
+		}
+	}
+
+	txWraps := injectGenTxs(t, bc, 1)
+	require.Equal(t, HeadUpdate{Seq: 0, Hash: txWraps[0].Tx.Hash()}, receive())
+
+	// Updates are coalesced for a slow receiver, keeping the latest head.
+	for i := 1; i < 5; i++ {
+		_, err := bc.InjectTx(NewGenTx(KittyID(i), GenSK))
+		require.NoError(t, err)
+	}
+	head, err := bc.GetHeadTx()
+	require.NoError(t, err)
+	update := receive()
+	for update.Seq < 4 {
+		update = receive()
+	}
+	require.Equal(t, HeadUpdate{Seq: 4, Hash: head.Tx.Hash()}, update)
+
+	bc.Close()
+	_, ok := <-heads
+	require.False(t, ok, "channel should be closed with the chain")
+	_, ok = <-bc.HeadChanges()
+	require.False(t, ok, "channel of a closed chain should be closed")
+}
@@ -0,0 +1,49 @@
+package iko
+
+import (
+	"time"
+)
+
+// Health describes whether the BlockChain is operational, for health checks.
+type Health struct {
+	ChainDB error   // Error of reaching the ChainDB, if any.
+	Stopped bool    // Whether the BlockChain is stopped or closed.
+	Len     uint64  // Length of the chain.
+	Head    *TxMeta // Meta of the head tx, nil if the chain is empty.
+}
+
+// OK returns true if the ChainDB is reachable and the BlockChain is running.
+func (h Health) OK() bool {
+	return h.ChainDB == nil && !h.Stopped
+}
+
+// SinceLastTx returns the time elapsed since the head tx was accepted, or 0 if
+// the chain is empty.
+func (h Health) SinceLastTx() time.Duration {
+	if h.Head == nil {
+		return 0
+	}
+	return time.Since(time.Unix(0, h.Head.TS))
+}
+
+// Health reports whether the BlockChain is operational. The ChainDB is probed
+// by reading the head tx.
+func (bc *BlockChain) Health() Health {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	h := Health{
+		Stopped: bc.ctx.Err() != nil,
+		Len:     bc.chain.Len(),
+	}
+	if h.Len == 0 {
+		return h
+	}
+	head, e := bc.chain.Head()
+	if e != nil {
+		h.ChainDB = e
+		return h
+	}
+	h.Head = &head.Meta
+	return h
+}
@@ -0,0 +1,39 @@
+package iko
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_Health(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	chainDB := newBoltChainDB(t, path)
+
+	bc, err := NewBlockChain(context.Background(),
+		&BlockChainConfig{GenerationPK: GenPK}, chainDB, NewMemoryState())
+	require.NoError(t, err)
+	defer bc.Close()
+
+	h := bc.Health()
+	require.True(t, h.OK())
+	require.Nil(t, h.Head, "an empty chain should have no head")
+	require.Zero(t, h.SinceLastTx())
+
+	txWraps := injectGenTxs(t, bc, 2)
+	h = bc.Health()
+	require.True(t, h.OK())
+	require.Equal(t, uint64(2), h.Len)
+	require.Equal(t, txWraps[1].Meta, *h.Head)
+	require.True(t, h.SinceLastTx() > 0)
+
+	chainDB.Close()
+	h = bc.Health()
+	require.False(t, h.OK())
+	require.Error(t, h.ChainDB, "a closed chain db should be unreachable")
+
+	bc.Close()
+	require.True(t, bc.Health().Stopped)
+}
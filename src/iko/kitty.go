@@ -40,6 +40,11 @@ func (ids *KittyIDs) Remove(id KittyID) {
 type KittyState struct {
 	Address      cipher.Address
 	Transactions TxHashes
+	Burned       bool // Burned kitties are retired, and can never be spent.
+	Metadata     KittyMetadata
+	CooldownSeq  uint64       // Sequence of the chain from which the kitty can breed.
+	Auction      AuctionState // Auction of the kitty, zero if not listed.
+	Royalty      KittyRoyalty // Royalty registered at generation, zero if none.
 }
 
 func (s KittyState) Serialize() []byte {
@@ -49,6 +54,8 @@ func (s KittyState) Serialize() []byte {
 type AddressState struct {
 	Kitties      KittyIDs
 	Transactions TxHashes
+	FeesPaid     uint64 // Total fees paid by txs sent from the address.
+	Royalties    uint64 // Total royalties accrued to the address, as a creator.
 }
 
 func NewAddressState() *AddressState {
@@ -0,0 +1,435 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMempoolSize is the maximum number of pending txs of a mempool,
+	// when not configured.
+	DefaultMempoolSize = 1024
+)
+
+var (
+	// ErrMempoolFull occurs when submitting a tx to a mempool that already
+	// holds it's maximum number of pending txs.
+	ErrMempoolFull = errors.New("mempool is full")
+
+	// ErrTxPending occurs when submitting a tx that is already pending.
+	ErrTxPending = errors.New("tx is already pending")
+
+	// ErrTxNotPending occurs when a tx is not found in the mempool.
+	ErrTxNotPending = errors.New("tx is not pending")
+)
+
+// PendingTx is a transaction that is valid, but not yet committed to the
+// chain.
+type PendingTx struct {
+	Tx       Transaction
+	Received int64 // Unix time (in nanoseconds) of submission.
+}
+
+// Mempool holds pending transactions in order of submission. Pending txs of a
+// kitty may spend one another, so that a kitty can be transferred more than
+// once before the txs are committed.
+type Mempool struct {
+	mux     sync.Mutex
+	size    int
+	order   []TxHash
+	txs     map[TxHash]*PendingTx
+	kitties map[KittyID][]TxHash // pending txs of each kitty, in order
+}
+
+// NewMempool creates a mempool that holds at most 'size' txs. A size of 0
+// results in 'DefaultMempoolSize'.
+func NewMempool(size int) *Mempool {
+	if size <= 0 {
+		size = DefaultMempoolSize
+	}
+	return &Mempool{
+		size:    size,
+		txs:     make(map[TxHash]*PendingTx),
+		kitties: make(map[KittyID][]TxHash),
+	}
+}
+
+// Add adds a tx to the mempool. The tx is not verified.
+func (p *Mempool) Add(tx *Transaction) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	hash := tx.Hash()
+	if _, ok := p.txs[hash]; ok {
+		return ErrTxPending
+	}
+	if len(p.order) >= p.size {
+		return ErrMempoolFull
+	}
+	p.txs[hash] = &PendingTx{
+		Tx:       *tx,
+		Received: time.Now().UnixNano(),
+	}
+	p.order = append(p.order, hash)
+	for _, kittyID := range tx.OutputKittyIDs() {
+		p.kitties[kittyID] = append(p.kitties[kittyID], hash)
+	}
+	return nil
+}
+
+// Get obtains a pending tx of hash.
+func (p *Mempool) Get(hash TxHash) (PendingTx, bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	ptx, ok := p.txs[hash]
+	if !ok {
+		return PendingTx{}, false
+	}
+	return *ptx, true
+}
+
+// Txs obtains all pending txs, in order of submission.
+func (p *Mempool) Txs() []PendingTx {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	out := make([]PendingTx, len(p.order))
+	for i, hash := range p.order {
+		out[i] = *p.txs[hash]
+	}
+	return out
+}
+
+// Len returns the number of pending txs.
+func (p *Mempool) Len() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	return len(p.order)
+}
+
+// KittyHead obtains the last pending tx of the kitty, which is the input of
+// it's next transfer.
+func (p *Mempool) KittyHead(kittyID KittyID) (*Transaction, bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	hashes := p.kitties[kittyID]
+	if len(hashes) == 0 {
+		return nil, false
+	}
+	tx := p.txs[hashes[len(hashes)-1]].Tx
+	return &tx, true
+}
+
+// Remove removes a single pending tx, such as when it is committed.
+func (p *Mempool) Remove(hash TxHash) bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	return p.remove(hash)
+}
+
+// Evict removes a pending tx along with the pending txs of the same kitties
+// that were submitted after it, as they depend on it. The hashes of the removed
+// txs are returned.
+func (p *Mempool) Evict(hash TxHash) ([]TxHash, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if _, ok := p.txs[hash]; !ok {
+		return nil, ErrTxNotPending
+	}
+	var out []TxHash
+	p.evict(hash, &out)
+	return out, nil
+}
+
+func (p *Mempool) evict(hash TxHash, out *[]TxHash) {
+	ptx, ok := p.txs[hash]
+	if !ok {
+		return
+	}
+	var dependents []TxHash
+	for _, kittyID := range ptx.Tx.OutputKittyIDs() {
+		hashes := p.kitties[kittyID]
+		for i, h := range hashes {
+			if h == hash {
+				dependents = append(dependents, hashes[i+1:]...)
+				break
+			}
+		}
+	}
+	p.remove(hash)
+	*out = append(*out, hash)
+	for _, h := range dependents {
+		p.evict(h, out)
+	}
+}
+
+// Expire evicts pending txs that were submitted before 'before' (and the
+// pending txs that depend on them).
+func (p *Mempool) Expire(before time.Time) []TxHash {
+	var expired []TxHash
+	for _, ptx := range p.Txs() {
+		if ptx.Received >= before.UnixNano() {
+			break
+		}
+		hashes, e := p.Evict(ptx.Tx.Hash())
+		if e == nil {
+			expired = append(expired, hashes...)
+		}
+	}
+	return expired
+}
+
+func (p *Mempool) remove(hash TxHash) bool {
+	ptx, ok := p.txs[hash]
+	if !ok {
+		return false
+	}
+	delete(p.txs, hash)
+	p.order = removeTxHash(p.order, hash)
+
+	for _, kittyID := range ptx.Tx.OutputKittyIDs() {
+		if hashes := removeTxHash(p.kitties[kittyID], hash); len(hashes) > 0 {
+			p.kitties[kittyID] = hashes
+		} else {
+			delete(p.kitties, kittyID)
+		}
+	}
+	return true
+}
+
+func removeTxHash(hashes []TxHash, hash TxHash) []TxHash {
+	for i, h := range hashes {
+		if h == hash {
+			return append(hashes[:i:i], hashes[i+1:]...)
+		}
+	}
+	return hashes
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// SubmitTx verifies the tx against the state and the pending txs, and adds it
+// to the mempool. Unlike 'InjectTx', the tx is not committed to the chain until
+// 'CommitPending' is called (or the configured 'MempoolCommitInterval').
+func (bc *BlockChain) SubmitTx(tx *Transaction) error {
+	if bc.c.Replica {
+		return ErrReplica
+	}
+
+	bc.poolMux.Lock()
+	defer bc.poolMux.Unlock()
+
+	if e := bc.checkPendingTx(tx); e != nil {
+		return e
+	}
+	return bc.pool.Add(tx)
+}
+
+// checkPendingTx verifies the tx against the last pending tx of each of it's
+// kitties, or the kitty's unspent tx of the chain if there are none.
+func (bc *BlockChain) checkPendingTx(tx *Transaction) error {
+	if e := tx.VerifyNetwork(bc.c.NetworkID); e != nil {
+		return e
+	}
+	if e := bc.c.TxVersionPolicy(tx.Version, bc.chain.Len()); e != nil {
+		return e
+	}
+	if tx.IsRotation() {
+		return ErrRotationPending
+	}
+	if tx.IsAdmin() {
+		return ErrAdminPending
+	}
+	var (
+		kittyIDs = tx.KittyIDs()
+		ins      = make([]*Transaction, len(kittyIDs))
+	)
+	for i, kittyID := range kittyIDs {
+		in, e := bc.pendingKittyHead(kittyID)
+		if e != nil {
+			return e
+		}
+		if in == nil && !bc.isKittyGen(tx) {
+			return fmt.Errorf("kitty %d: %w", kittyID, ErrKittyNotFound)
+		}
+		if in != nil && in.IsBurn() {
+			return ErrKittyBurned
+		}
+		ins[i] = in
+	}
+	if e := bc.checkFee(tx); e != nil {
+		return e
+	}
+	if e := bc.checkRoyalty(tx); e != nil {
+		return e
+	}
+	if tx.IsAuction() {
+		return bc.checkPendingAuctionTx(tx, ins[0])
+	}
+	if tx.IsMetadata() {
+		return bc.verifyMetadataTx(tx, ins[0])
+	}
+	seq, ts := bc.txClock(nil)
+	if e := bc.checkLocks(ins, seq, ts); e != nil {
+		return e
+	}
+	if e := bc.checkEscrow(tx, ts); e != nil {
+		return e
+	}
+	if tx.IsBreed() {
+		if in, e := bc.pendingKittyHead(tx.Breed.ChildID); e != nil {
+			return e
+		} else if in != nil {
+			return fmt.Errorf("kitty of id '%d': %w", tx.Breed.ChildID, ErrKittyAlreadyExists)
+		}
+		if _, e := bc.breedChild(tx, seq); e != nil {
+			return e
+		}
+		return tx.VerifyBreedWith(ins)
+	}
+	if tx.IsMultiTransfer() {
+		return tx.VerifyWithInputs(ins)
+	}
+	genPK, isGen := bc.generationPK(tx)
+	if isGen && bc.state.IsGenerationFrozen() {
+		return ErrGenerationFrozen
+	}
+	return tx.VerifyWith(ins[0], genPK)
+}
+
+// checkPendingAuctionTx verifies an auction tx. If the kitty has pending txs,
+// the state of the auction is checked against a fork of the state to which the
+// pending txs are applied, as the state is otherwise outdated.
+func (bc *BlockChain) checkPendingAuctionTx(tx *Transaction, in *Transaction) error {
+	if e := tx.VerifyAuctionWith(in); e != nil {
+		return e
+	}
+	seq, ts := bc.txClock(nil)
+	if e := bc.checkLocks([]*Transaction{in}, seq, ts); e != nil {
+		return e
+	}
+	if _, ok := bc.pool.KittyHead(tx.KittyID); !ok {
+		return bc.checkAuction(tx, ts)
+	}
+
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	var (
+		pending = bc.pool.Txs()
+		txs     = make([]Transaction, len(pending))
+	)
+	for i := range pending {
+		txs[i] = pending[i].Tx
+	}
+	// Pending txs that fail are evicted when committed, so are ignored.
+	fork, _ := bc.forkTxs(txs)
+	return fork.checkAuction(tx, ts)
+}
+
+// pendingKittyHead obtains the last pending tx of the kitty, or the kitty's
+// unspent tx of the chain if there are none. Nil is returned if the kitty does
+// not exist.
+func (bc *BlockChain) pendingKittyHead(kittyID KittyID) (*Transaction, error) {
+	if in, ok := bc.pool.KittyHead(kittyID); ok {
+		return in, nil
+	}
+
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	hash, ok := bc.state.GetKittyUnspentTx(kittyID)
+	if !ok {
+		return nil, nil
+	}
+	txWrap, e := bc.chain.GetTxOfHash(hash)
+	if e != nil {
+		return nil, e
+	}
+	return &txWrap.Tx, nil
+}
+
+// CommitPending injects the pending txs into the chain, in order of
+// submission. Txs that fail to be injected are evicted, along with the pending
+// txs that depend on them. Expired txs are evicted beforehand. The number of
+// committed txs is returned.
+func (bc *BlockChain) CommitPending() int {
+	bc.commitMux.Lock()
+	defer bc.commitMux.Unlock()
+
+	if expiry := bc.c.MempoolExpiry; expiry > 0 {
+		if expired := bc.pool.Expire(time.Now().Add(-expiry)); len(expired) > 0 {
+			bc.log.
+				WithField("count", len(expired)).
+				Warning("evicted expired pending txs")
+		}
+	}
+
+	var count int
+	for _, ptx := range bc.pool.Txs() {
+		hash := ptx.Tx.Hash()
+		if _, ok := bc.pool.Get(hash); !ok {
+			continue // evicted as it depended on a failed tx
+		}
+		if _, e := bc.InjectTx(&ptx.Tx); e != nil {
+			evicted, _ := bc.pool.Evict(hash)
+			bc.log.
+				WithError(e).
+				WithField("tx", ptx.Tx.String()).
+				WithField("evicted", len(evicted)).
+				Warning("failed to commit pending tx")
+			continue
+		}
+		bc.pool.Remove(hash)
+		count++
+	}
+	return count
+}
+
+// PendingTxs obtains the pending txs of the mempool, in order of submission.
+func (bc *BlockChain) PendingTxs() []PendingTx {
+	return bc.pool.Txs()
+}
+
+// GetPendingTx obtains a pending tx of hash.
+func (bc *BlockChain) GetPendingTx(hash TxHash) (PendingTx, bool) {
+	return bc.pool.Get(hash)
+}
+
+// EvictPendingTx removes a pending tx from the mempool, along with the pending
+// txs that depend on it. The hashes of the evicted txs are returned.
+func (bc *BlockChain) EvictPendingTx(hash TxHash) ([]TxHash, error) {
+	bc.poolMux.Lock()
+	defer bc.poolMux.Unlock()
+
+	return bc.pool.Evict(hash)
+}
+
+func (bc *BlockChain) mempoolService(interval time.Duration) {
+	defer bc.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-bc.ctx.Done():
+			return
+		case <-ticker.C:
+			if count := bc.CommitPending(); count > 0 {
+				bc.log.
+					WithField("count", count).
+					Debug("committed pending txs")
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package iko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMempool(t *testing.T) {
+	var (
+		pool   = NewMempool(4)
+		gen0   = NewGenTx(0, GenSK)
+		gen1   = NewGenTx(1, GenSK)
+		pk, sk = cipher.GenerateKeyPair()
+	)
+	transfer, err := NewTransferTx(gen0, cipher.AddressFromPubKey(pk), GenSK)
+	require.NoError(t, err)
+	back, err := NewTransferTx(transfer, cipher.AddressFromPubKey(GenPK), sk)
+	require.NoError(t, err)
+
+	for _, tx := range []*Transaction{gen0, gen1, transfer, back} {
+		require.NoError(t, pool.Add(tx))
+	}
+	require.Equal(t, ErrTxPending, pool.Add(gen0))
+	require.Equal(t, ErrMempoolFull, pool.Add(NewGenTx(2, GenSK)))
+
+	head, ok := pool.KittyHead(0)
+	require.True(t, ok)
+	require.Equal(t, *back, *head, "kitty head should be it's last pending tx")
+
+	evicted, err := pool.Evict(transfer.Hash())
+	require.NoError(t, err)
+	require.Equal(t, []TxHash{transfer.Hash(), back.Hash()}, evicted,
+		"dependent txs should be evicted")
+	_, err = pool.Evict(transfer.Hash())
+	require.Equal(t, ErrTxNotPending, err)
+
+	require.True(t, pool.Remove(gen0.Hash()))
+	_, ok = pool.KittyHead(0)
+	require.False(t, ok)
+
+	require.Len(t, pool.Txs(), 1)
+	require.Empty(t, pool.Expire(time.Unix(0, 0)))
+	require.Equal(t, []TxHash{gen1.Hash()}, pool.Expire(time.Now().Add(time.Second)))
+	require.Equal(t, 0, pool.Len())
+}
+
+func TestBlockChain_Mempool(t *testing.T) {
+	bc, closeBC := newTestBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	pk, sk := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pk)
+
+	gen := NewGenTx(0, GenSK)
+	require.NoError(t, bc.SubmitTx(gen))
+
+	transfer, err := NewTransferTx(gen, addr, GenSK)
+	require.NoError(t, err)
+	require.NoError(t, bc.SubmitTx(transfer),
+		"transfer of a pending tx should be accepted")
+
+	doubleSpend, err := NewTransferTx(gen, cipher.AddressFromPubKey(GenPK), GenSK)
+	require.NoError(t, err)
+	require.Error(t, bc.SubmitTx(doubleSpend),
+		"double spend of a pending tx should be rejected")
+
+	require.Error(t, bc.SubmitTx(NewGenTx(1, sk)),
+		"generation tx of another creator should be rejected")
+
+	require.Len(t, bc.PendingTxs(), 2)
+	_, ok := bc.GetKittyState(0)
+	require.False(t, ok, "pending txs should not be committed")
+
+	require.Equal(t, 2, bc.CommitPending())
+	require.Empty(t, bc.PendingTxs())
+
+	kState, ok := bc.GetKittyState(0)
+	require.True(t, ok)
+	require.Equal(t, addr, kState.Address)
+
+	_, ok = bc.GetPendingTx(transfer.Hash())
+	require.False(t, ok)
+
+	t.Run("EvictFailed", func(t *testing.T) {
+		dup := NewGenTx(0, GenSK)
+		require.Error(t, bc.SubmitTx(dup),
+			"generation of existing kitty should be rejected")
+
+		gen := NewGenTx(1, GenSK)
+		require.NoError(t, bc.SubmitTx(gen))
+		_, err := bc.InjectTx(gen)
+		require.NoError(t, err)
+
+		require.Equal(t, 0, bc.CommitPending(),
+			"already committed tx should fail")
+		require.Empty(t, bc.PendingTxs(), "failed tx should be evicted")
+	})
+}
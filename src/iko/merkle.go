@@ -0,0 +1,208 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+var (
+	// ErrInvalidProof occurs when a tx proof does not prove the inclusion of
+	// it's tx against the given Merkle root.
+	ErrInvalidProof = errors.New("invalid tx proof")
+)
+
+const (
+	// MerkleSegmentSize is the number of txs of a segment of the chain, of
+	// which a Merkle root is computed. The segment of a tx is it's seq
+	// divided by the segment size.
+	MerkleSegmentSize = 1024
+)
+
+// Domain prefixes of Merkle tree hashes, so that leaves cannot be passed off
+// as nodes (and vice versa).
+const (
+	merkleLeafPrefix byte = 0
+	merkleNodePrefix byte = 1
+)
+
+// SegmentRoot is the Merkle root of the txs of a segment of the chain. The
+// root of the last segment changes as txs are added, until the segment holds
+// 'MerkleSegmentSize' txs.
+type SegmentRoot struct {
+	Segment uint64
+	Size    uint64 // Number of txs of the segment, of which the root is.
+	Root    cipher.SHA256
+}
+
+// TxProof proves the inclusion of a tx in a segment of the chain, against the
+// Merkle root of the first 'Size' txs of the segment.
+type TxProof struct {
+	TxHash  TxHash
+	Seq     uint64
+	Segment uint64
+	Size    uint64
+	Path    []cipher.SHA256 // Sibling hashes, from the leaf upwards.
+	Root    cipher.SHA256
+}
+
+// Verify checks that the proof proves the inclusion of it's tx against the
+// root, which should be obtained from a trusted source.
+func (p *TxProof) Verify(root SegmentRoot) error {
+	if root.Segment != p.Segment || root.Size != p.Size || root.Root != p.Root {
+		return ErrInvalidProof
+	}
+	if p.Seq/MerkleSegmentSize != p.Segment {
+		return ErrInvalidProof
+	}
+	var (
+		index = p.Seq % MerkleSegmentSize
+		last  = p.Size - 1
+		hash  = merkleLeaf(p.TxHash)
+	)
+	if p.Size == 0 || index > last {
+		return ErrInvalidProof
+	}
+	for _, sibling := range p.Path {
+		if last == 0 {
+			return ErrInvalidProof
+		}
+		if index&1 == 1 || index == last {
+			hash = merkleNode(sibling, hash)
+			for index&1 == 0 && index != 0 {
+				index >>= 1
+				last >>= 1
+			}
+		} else {
+			hash = merkleNode(hash, sibling)
+		}
+		index >>= 1
+		last >>= 1
+	}
+	if last != 0 || hash != p.Root {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+func merkleLeaf(hash TxHash) cipher.SHA256 {
+	return cipher.SumSHA256(append([]byte{merkleLeafPrefix}, hash[:]...))
+}
+
+func merkleNode(left, right cipher.SHA256) cipher.SHA256 {
+	b := make([]byte, 0, 1+2*len(left))
+	b = append(b, merkleNodePrefix)
+	b = append(b, left[:]...)
+	b = append(b, right[:]...)
+	return cipher.SumSHA256(b)
+}
+
+// merkleSplit returns the largest power of two that is less than n, where n
+// is greater than 1.
+func merkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// merkleRoot computes the root of the (non-empty) leaves, as of RFC 6962.
+func merkleRoot(leaves []cipher.SHA256) cipher.SHA256 {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := merkleSplit(len(leaves))
+	return merkleNode(merkleRoot(leaves[:k]), merkleRoot(leaves[k:]))
+}
+
+// merklePath computes the path of the leaf of index, as of RFC 6962.
+func merklePath(index int, leaves []cipher.SHA256) []cipher.SHA256 {
+	if len(leaves) == 1 {
+		return nil
+	}
+	k := merkleSplit(len(leaves))
+	if index < k {
+		return append(merklePath(index, leaves[:k]), merkleRoot(leaves[k:]))
+	}
+	return append(merklePath(index-k, leaves[k:]), merkleRoot(leaves[:k]))
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// GetSegmentRoot obtains the Merkle root of the txs of the segment, which can
+// be published for light clients to verify tx proofs against.
+func (bc *BlockChain) GetSegmentRoot(segment uint64) (SegmentRoot, error) {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	leaves, e := bc.segmentLeaves(segment)
+	if e != nil {
+		return SegmentRoot{}, e
+	}
+	return SegmentRoot{
+		Segment: segment,
+		Size:    uint64(len(leaves)),
+		Root:    merkleRoot(leaves),
+	}, nil
+}
+
+// GetTxProof obtains a proof of inclusion of the tx of hash, against the root
+// of it's segment (as of the current length of the chain).
+func (bc *BlockChain) GetTxProof(hash TxHash) (*TxProof, error) {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	txWrap, e := bc.txOfHash(hash)
+	if e != nil {
+		return nil, e
+	}
+	var (
+		seq     = txWrap.Meta.Seq
+		segment = seq / MerkleSegmentSize
+	)
+	leaves, e := bc.segmentLeaves(segment)
+	if e != nil {
+		return nil, e
+	}
+	index := int(seq % MerkleSegmentSize)
+	return &TxProof{
+		TxHash:  hash,
+		Seq:     seq,
+		Segment: segment,
+		Size:    uint64(len(leaves)),
+		Path:    merklePath(index, leaves),
+		Root:    merkleRoot(leaves),
+	}, nil
+}
+
+// segmentLeaves obtains the leaves of the txs of the segment. It fails if the
+// segment is not in the chain, or some of it's txs are pruned.
+func (bc *BlockChain) segmentLeaves(segment uint64) ([]cipher.SHA256, error) {
+	var (
+		start = segment * MerkleSegmentSize
+		end   = start + MerkleSegmentSize
+		cLen  = bc.chain.Len()
+	)
+	if start >= cLen {
+		return nil, fmt.Errorf("segment '%d': %w", segment, ErrTxNotFound)
+	}
+	if end > cLen {
+		end = cLen
+	}
+	txWraps, e := bc.chain.GetTxsOfSeqRange(start, end-start)
+	if e != nil {
+		return nil, e
+	}
+	if uint64(len(txWraps)) != end-start {
+		return nil, fmt.Errorf("txs of segment '%d' are pruned: %w", segment, ErrTxNotFound)
+	}
+	leaves := make([]cipher.SHA256, len(txWraps))
+	for i := range txWraps {
+		leaves[i] = merkleLeaf(txWraps[i].Tx.Hash())
+	}
+	return leaves, nil
+}
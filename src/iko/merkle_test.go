@@ -0,0 +1,105 @@
+package iko
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxProof_Verify(t *testing.T) {
+	for _, size := range []int{1, 2, 3, 5, 8, 13, 64, 100} {
+		hashes := make([]TxHash, size)
+		leaves := make([]cipher.SHA256, size)
+		for i := range hashes {
+			hashes[i] = TxHash(cipher.SumSHA256([]byte{byte(i), byte(size)}))
+			leaves[i] = merkleLeaf(hashes[i])
+		}
+		root := SegmentRoot{Segment: 1, Size: uint64(size), Root: merkleRoot(leaves)}
+
+		for i := range hashes {
+			proof := &TxProof{
+				TxHash:  hashes[i],
+				Seq:     MerkleSegmentSize + uint64(i),
+				Segment: 1,
+				Size:    uint64(size),
+				Path:    merklePath(i, leaves),
+				Root:    root.Root,
+			}
+			require.NoError(t, proof.Verify(root), "size %d index %d", size, i)
+
+			bad := *proof
+			bad.TxHash = TxHash(cipher.SumSHA256([]byte("bad")))
+			require.Equal(t, ErrInvalidProof, bad.Verify(root))
+
+			if size > 1 {
+				bad = *proof
+				bad.Seq = MerkleSegmentSize + uint64((i+1)%size)
+				require.Equal(t, ErrInvalidProof, bad.Verify(root))
+
+				bad = *proof
+				bad.Path = append([]cipher.SHA256{}, proof.Path...)
+				bad.Path[0] = cipher.SumSHA256([]byte("bad"))
+				require.Equal(t, ErrInvalidProof, bad.Verify(root))
+
+				bad = *proof
+				bad.Path = proof.Path[1:]
+				require.Equal(t, ErrInvalidProof, bad.Verify(root))
+			}
+
+			bad = *proof
+			bad.Seq = uint64(i)
+			require.Equal(t, ErrInvalidProof, bad.Verify(root), "proof of other segment should fail")
+
+			require.Equal(t, ErrInvalidProof, proof.Verify(SegmentRoot{
+				Segment: 1,
+				Size:    uint64(size),
+				Root:    cipher.SumSHA256([]byte("bad")),
+			}))
+		}
+	}
+}
+
+func TestBlockChain_GetTxProof(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	_, err := bc.GetSegmentRoot(0)
+	require.True(t, errors.Is(err, ErrTxNotFound))
+
+	txWraps := injectGenTxs(t, bc, 7)
+
+	root, err := bc.GetSegmentRoot(0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), root.Size)
+
+	for _, txWrap := range txWraps {
+		proof, err := bc.GetTxProof(txWrap.Tx.Hash())
+		require.NoError(t, err)
+		require.Equal(t, txWrap.Meta.Seq, proof.Seq)
+		require.NoError(t, proof.Verify(root))
+	}
+
+	_, err = bc.GetTxProof(TxHash(cipher.SumSHA256([]byte("missing"))))
+	require.True(t, errors.Is(err, ErrTxNotFound))
+
+	_, err = bc.GetSegmentRoot(1)
+	require.True(t, errors.Is(err, ErrTxNotFound))
+
+	t.Run("PartialSegment", func(t *testing.T) {
+		proof, err := bc.GetTxProof(txWraps[0].Tx.Hash())
+		require.NoError(t, err)
+
+		_, err = bc.InjectTx(NewGenTx(7, GenSK))
+		require.NoError(t, err)
+		newRoot, err := bc.GetSegmentRoot(0)
+		require.NoError(t, err)
+		require.Equal(t, uint64(8), newRoot.Size)
+		require.NotEqual(t, root.Root, newRoot.Root)
+
+		require.Equal(t, ErrInvalidProof, proof.Verify(newRoot),
+			"proof should only verify against the root of it's size")
+		require.NoError(t, proof.Verify(root))
+	})
+}
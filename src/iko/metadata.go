@@ -0,0 +1,118 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// MetadataPolicy determines who may set the metadata of kitties.
+type MetadataPolicy string
+
+const (
+	// MetadataByCreator only allows the creator to set the metadata of
+	// kitties, regardless of their owner.
+	MetadataByCreator MetadataPolicy = "creator"
+
+	// MetadataByOwner allows the owner of a kitty to set it's metadata.
+	MetadataByOwner MetadataPolicy = "owner"
+)
+
+const (
+	// MaxKittyNameSize is the maximum size of a kitty name, in bytes.
+	MaxKittyNameSize = 64
+
+	// MaxKittyDNASize is the maximum size of a kitty's DNA, in bytes.
+	MaxKittyDNASize = 64
+
+	// MaxKittyImageURISize is the maximum size of a kitty's image URI, in
+	// bytes.
+	MaxKittyImageURISize = 256
+)
+
+var (
+	// ErrMetadataNotOwner occurs when a metadata tx changes the owner of the
+	// kitty.
+	ErrMetadataNotOwner = errors.New("metadata tx should output to the owner of the kitty")
+)
+
+// KittyMetadata describes a kitty. It is set with a metadata tx, and recorded
+// in the state of the kitty.
+type KittyMetadata struct {
+	Name       string
+	DNA        []byte
+	Generation uint32
+	ImageURI   string
+}
+
+func (m KittyMetadata) Serialize() []byte {
+	return encoder.Serialize(m)
+}
+
+// Verify checks that the fields of the metadata are within their maximum
+// sizes.
+func (m *KittyMetadata) Verify() error {
+	switch {
+	case len(m.Name) > MaxKittyNameSize:
+		return fmt.Errorf("kitty name has %d bytes, the maximum is %d",
+			len(m.Name), MaxKittyNameSize)
+	case len(m.DNA) > MaxKittyDNASize:
+		return fmt.Errorf("kitty dna has %d bytes, the maximum is %d",
+			len(m.DNA), MaxKittyDNASize)
+	case len(m.ImageURI) > MaxKittyImageURISize:
+		return fmt.Errorf("kitty image uri has %d bytes, the maximum is %d",
+			len(m.ImageURI), MaxKittyImageURISize)
+	}
+	return nil
+}
+
+// IsZero returns true if no metadata is set.
+func (m KittyMetadata) IsZero() bool {
+	return m.Name == "" && len(m.DNA) == 0 && m.Generation == 0 && m.ImageURI == ""
+}
+
+// NewUnsignedMetadataTx creates an unsigned tx that sets the metadata of the
+// kitty of the input tx. The owner (and lock) of the kitty is unchanged.
+// Depending on the 'MetadataPolicy' of the chain, it should be signed by the
+// creator or owner.
+func NewUnsignedMetadataTx(in *Transaction, metadata KittyMetadata) *Transaction {
+	tx := NewUnsignedTransferTx(in, in.Out)
+	tx.Lock = in.Lock
+	tx.Metadata = &metadata
+	return tx
+}
+
+// IsMetadata returns true if the tx sets the metadata of it's kitty, rather
+// than transferring it.
+func (tx Transaction) IsMetadata() bool {
+	return tx.Metadata != nil
+}
+
+// VerifyMetadataWith checks a metadata tx against the unspent tx of it's kitty.
+// The signature is not checked, as the signer depends on the 'MetadataPolicy'.
+func (tx Transaction) VerifyMetadataWith(in *Transaction) error {
+	if in == nil {
+		return fmt.Errorf("kitty %d: %w", tx.KittyID, ErrKittyNotFound)
+	}
+	if e := tx.VerifyMemo(); e != nil {
+		return e
+	}
+	if e := tx.Metadata.Verify(); e != nil {
+		return e
+	}
+	if len(tx.Inputs) > 0 || tx.Escrow != nil {
+		return errors.New("metadata tx should be of a single kitty, and not settle an escrow")
+	}
+	if e := tx.VerifyInputs([]*Transaction{in}); e != nil {
+		return e
+	}
+	if tx.Out != in.Out {
+		return ErrMetadataNotOwner
+	}
+	// Locks carry over, so that metadata txs cannot be used to unlock kitties.
+	if tx.Lock != in.Lock {
+		return errors.New("metadata tx should keep the lock of it's input")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKittyMetadata_Verify(t *testing.T) {
+	m := KittyMetadata{Name: "Kitty", DNA: []byte{1, 2, 3}, Generation: 1, ImageURI: "ipfs://kitty"}
+	require.NoError(t, m.Verify())
+	require.False(t, m.IsZero())
+	require.True(t, KittyMetadata{}.IsZero())
+
+	m.ImageURI = string(make([]byte, MaxKittyImageURISize+1))
+	require.Error(t, m.Verify(), "oversized image uri should be rejected")
+}
+
+func TestBlockChain_Metadata(t *testing.T) {
+	var (
+		pk, sk   = cipher.GenerateKeyPair()
+		addr     = cipher.AddressFromPubKey(pk)
+		metadata = KittyMetadata{Name: "Kitty", DNA: []byte{0xca, 0x75}, Generation: 2, ImageURI: "ipfs://kitty"}
+	)
+	// transferred injects a kitty that is transferred to 'addr'.
+	transferred := func(bc *BlockChain) *Transaction {
+		txWraps := injectGenTxs(t, bc, 1)
+		tx, err := NewTransferTx(&txWraps[0].Tx, addr, GenSK)
+		require.NoError(t, err)
+		_, err = bc.InjectTx(tx)
+		require.NoError(t, err)
+		return tx
+	}
+
+	t.Run("Creator", func(t *testing.T) {
+		bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+		defer closeBC()
+
+		in := transferred(bc)
+		tx := NewUnsignedMetadataTx(in, metadata)
+		tx.Sig = tx.Sign(sk)
+		_, err := bc.InjectTx(tx)
+		require.Equal(t, ErrNotOwner, err, "owner should not set metadata")
+
+		tx.Sig = tx.Sign(GenSK)
+		require.NoError(t, bc.SubmitTx(tx))
+		require.Equal(t, 1, bc.CommitPending())
+
+		kState, ok := bc.GetKittyState(0)
+		require.True(t, ok)
+		require.Equal(t, metadata, kState.Metadata)
+		require.Equal(t, addr, kState.Address, "metadata tx should not change owner")
+		require.Contains(t, bc.GetAddressState(addr).Transactions, tx.Hash())
+
+		stolen := NewUnsignedMetadataTx(tx, metadata)
+		stolen.Out = bc.CreatorAddress()
+		stolen.Sig = stolen.Sign(GenSK)
+		_, err = bc.InjectTx(stolen)
+		require.Equal(t, ErrMetadataNotOwner, err)
+	})
+
+	t.Run("Owner", func(t *testing.T) {
+		bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+			GenerationPK:   GenPK,
+			MetadataPolicy: MetadataByOwner,
+		})
+		defer closeBC()
+
+		in := transferred(bc)
+		tx := NewUnsignedMetadataTx(in, metadata)
+		tx.Sig = tx.Sign(GenSK)
+		_, err := bc.InjectTx(tx)
+		require.Equal(t, ErrNotOwner, err, "creator should not set metadata")
+
+		tx.Sig = tx.Sign(sk)
+		_, err = bc.InjectTx(tx)
+		require.NoError(t, err)
+
+		kState, ok := bc.GetKittyState(0)
+		require.True(t, ok)
+		require.Equal(t, metadata, kState.Metadata)
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+		defer closeBC()
+
+		gen := NewGenTx(0, GenSK)
+		gen.Lock = TxLock{Seq: 100}
+		gen.Sig = gen.Sign(GenSK)
+		_, err := bc.InjectTx(gen)
+		require.NoError(t, err)
+
+		unlocked := NewUnsignedMetadataTx(gen, metadata)
+		unlocked.Lock = TxLock{}
+		unlocked.Sig = unlocked.Sign(GenSK)
+		_, err = bc.InjectTx(unlocked)
+		require.Error(t, err, "metadata tx should not unlock the kitty")
+
+		tx := NewUnsignedMetadataTx(gen, metadata)
+		tx.Sig = tx.Sign(GenSK)
+		_, err = bc.InjectTx(tx)
+		require.NoError(t, err, "metadata of locked kitties should be set")
+	})
+
+	t.Run("InvalidPolicy", func(t *testing.T) {
+		config := &BlockChainConfig{GenerationPK: GenPK, MetadataPolicy: "anyone"}
+		require.Error(t, config.Prepare())
+	})
+}
@@ -0,0 +1,59 @@
+package iko
+
+import (
+	"time"
+
+	"github.com/kittycash/wallet/src/util/metrics"
+)
+
+// Metrics of the BlockChain and ChainDB implementations, registered to
+// 'metrics.DefaultRegistry'.
+var (
+	metricTxsInjected = metrics.NewCounter(
+		"iko_txs_injected_total",
+		"Number of transactions injected to the chain.")
+
+	metricVerifyFailures = metrics.NewCounter(
+		"iko_tx_verification_failures_total",
+		"Number of transactions rejected by verification on injection.")
+
+	metricChainLen = metrics.NewGauge(
+		"iko_chain_length",
+		"Number of transactions in the chain.")
+
+	metricInitState = metrics.NewGauge(
+		"iko_init_state_duration_seconds",
+		"Duration of the last initialisation of the state.")
+
+	metricChainDBOps = metrics.NewHistogramVec(
+		"iko_chaindb_operation_duration_seconds",
+		"Latency of ChainDB operations.",
+		metrics.DefBuckets, "db", "op")
+)
+
+func init() {
+	metrics.DefaultRegistry.MustRegister(
+		metricTxsInjected,
+		metricVerifyFailures,
+		metricChainLen,
+		metricInitState,
+		metricChainDBOps,
+	)
+}
+
+// observeChainDBOp records the latency of a ChainDB operation that began at
+// 'start'. It is intended to be deferred.
+func observeChainDBOp(db, op string, start time.Time) {
+	metricChainDBOps.With(db, op).ObserveSince(start)
+}
+
+// countVerifyFailures wraps 'check' to count the txs it rejects.
+func countVerifyFailures(check TxChecker) TxChecker {
+	return func(tx *Transaction) error {
+		e := check(tx)
+		if e != nil {
+			metricVerifyFailures.Inc()
+		}
+		return e
+	}
+}
@@ -0,0 +1,45 @@
+package iko
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/util/metrics"
+)
+
+func TestBlockChain_Metrics(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		injected = metricTxsInjected.Value()
+		failures = metricVerifyFailures.Value()
+		adds     = metricChainDBOps.With("bolt", "add_tx").Count()
+	)
+	require.Equal(t, float64(0), metricChainLen.Value(),
+		"chain length should be set on initialising the state")
+
+	injectGenTxs(t, bc, 3)
+	_, e := bc.InjectTx(NewGenTx(0, GenSK))
+	require.Error(t, e, "injecting a duplicate kitty should fail")
+
+	require.Equal(t, injected+3, metricTxsInjected.Value())
+	require.Equal(t, failures+1, metricVerifyFailures.Value())
+	require.Equal(t, adds+4, metricChainDBOps.With("bolt", "add_tx").Count())
+
+	for deadline := time.Now().Add(5 * time.Second); metricChainLen.Value() != 3; {
+		require.True(t, time.Now().Before(deadline),
+			"chain length should be updated as txs are processed")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var b bytes.Buffer
+	_, e = metrics.DefaultRegistry.WriteTo(&b)
+	require.NoError(t, e)
+	require.Contains(t, b.String(), "iko_chain_length 3\n")
+	require.Contains(t, b.String(), `iko_chaindb_operation_duration_seconds_count{db="bolt",op="add_tx"}`)
+	require.Contains(t, b.String(), "# TYPE iko_init_state_duration_seconds gauge\n")
+}
@@ -0,0 +1,182 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+const (
+	// MaxMultisigKeys is the maximum number of public keys of a multisig.
+	MaxMultisigKeys = 16
+
+	// multisigAddressDomain separates multisig address hashes from those of
+	// public keys.
+	multisigAddressDomain = "kittycash multisig"
+)
+
+var (
+	ErrMultisigThreshold  = errors.New("not enough valid multisig signatures")
+	ErrMultisigMismatch   = errors.New("multisig does not match owner address")
+	ErrMultisigSigOrder   = errors.New("multisig signatures should be in ascending order of key index")
+	ErrWitnessUnsupported = errors.New("chain db does not support tx witnesses")
+)
+
+// Multisig is an m-of-n ownership condition. A kitty sent to the address of a
+// multisig can only be transferred with signatures of 'M' of the public keys.
+type Multisig struct {
+	M       uint8
+	PubKeys []cipher.PubKey
+}
+
+// NewMultisig creates an m-of-n multisig of the public keys.
+func NewMultisig(m int, pks ...cipher.PubKey) (*Multisig, error) {
+	ms := &Multisig{
+		M:       uint8(m),
+		PubKeys: pks,
+	}
+	if m < 0 || m > MaxMultisigKeys {
+		return nil, fmt.Errorf("invalid multisig threshold %d", m)
+	}
+	return ms, ms.Verify()
+}
+
+// Verify checks that the threshold is attainable and that the public keys are
+// valid and unique.
+func (ms *Multisig) Verify() error {
+	if n := len(ms.PubKeys); n == 0 || n > MaxMultisigKeys {
+		return fmt.Errorf("multisig should have between 1 and %d keys, got %d",
+			MaxMultisigKeys, n)
+	}
+	if ms.M == 0 || int(ms.M) > len(ms.PubKeys) {
+		return fmt.Errorf("multisig threshold should be between 1 and %d, got %d",
+			len(ms.PubKeys), ms.M)
+	}
+	seen := make(map[cipher.PubKey]struct{}, len(ms.PubKeys))
+	for i, pk := range ms.PubKeys {
+		if e := pk.Verify(); e != nil {
+			return fmt.Errorf("multisig key %d is invalid: %v", i, e)
+		}
+		if _, ok := seen[pk]; ok {
+			return fmt.Errorf("multisig key %d is duplicated", i)
+		}
+		seen[pk] = struct{}{}
+	}
+	return nil
+}
+
+// Address returns the address that kitties are sent to, to be owned by the
+// multisig. Like addresses of public keys, it is a hash of the multisig, so
+// the multisig is only revealed when a kitty is transferred from it.
+func (ms *Multisig) Address() cipher.Address {
+	data := append([]byte(multisigAddressDomain), encoder.Serialize(*ms)...)
+	hash := cipher.DoubleSHA256(data)
+	return cipher.Address{
+		Version: 0,
+		Key:     cipher.HashRipemd160(hash[:]),
+	}
+}
+
+// MultisigSig is a signature of the multisig key of index.
+type MultisigSig struct {
+	Index uint8
+	Sig   cipher.Sig
+}
+
+// Witness proves that a transfer is authorised by the multisig which owns the
+// kitty. It is not part of the tx hash, as the signatures sign the hash.
+type Witness struct {
+	Multisig Multisig
+	Sigs     []MultisigSig
+}
+
+func (w Witness) Serialize() []byte {
+	return encoder.Serialize(w)
+}
+
+// Verify checks that the witness is of the owner's multisig, and that enough
+// distinct keys of the multisig signed the hash.
+func (w *Witness) Verify(owner cipher.Address, hash cipher.SHA256) error {
+	if e := w.Multisig.Verify(); e != nil {
+		return e
+	}
+	if w.Multisig.Address() != owner {
+		return ErrMultisigMismatch
+	}
+	var valid int
+	for i, ms := range w.Sigs {
+		if i > 0 && ms.Index <= w.Sigs[i-1].Index {
+			return ErrMultisigSigOrder
+		}
+		if int(ms.Index) >= len(w.Multisig.PubKeys) {
+			return fmt.Errorf("multisig signature %d is of invalid key index %d", i, ms.Index)
+		}
+		if e := verifySignature(w.Multisig.PubKeys[ms.Index], ms.Sig, hash); e != nil {
+			return fmt.Errorf("multisig signature %d is invalid: %w", i, e)
+		}
+		valid++
+	}
+	if valid < int(w.Multisig.M) {
+		return ErrMultisigThreshold
+	}
+	return nil
+}
+
+// NewMultisigTransferTx creates an unsigned transfer of a kitty owned by the
+// multisig. Signatures are then added with 'AddMultisigSig'.
+func NewMultisigTransferTx(in *Transaction, out cipher.Address, ms *Multisig) (*Transaction, error) {
+	if e := ms.Verify(); e != nil {
+		return nil, e
+	}
+	if in.Out != ms.Address() {
+		return nil, ErrMultisigMismatch
+	}
+	return &Transaction{
+		KittyID: in.KittyID,
+		In:      in.Hash(),
+		Out:     out,
+		Witness: &Witness{Multisig: *ms},
+	}, nil
+}
+
+// AddMultisigSig signs the transaction with the signer, which should be of the
+// multisig key of index. Signatures can be added in any order.
+func (tx *Transaction) AddMultisigSig(index int, s Signer) error {
+	if tx.Witness == nil {
+		return errors.New("tx has no multisig witness")
+	}
+	pks := tx.Witness.Multisig.PubKeys
+	if index < 0 || index >= len(pks) {
+		return fmt.Errorf("invalid multisig key index %d", index)
+	}
+	if s.Address() != cipher.AddressFromPubKey(pks[index]) {
+		return fmt.Errorf("signer is not of multisig key %d", index)
+	}
+	hash := tx.HashInner()
+	sig, e := tx.signInner(s)
+	if e != nil {
+		return e
+	}
+	if e := cipher.VerifySignature(pks[index], sig, hash); e != nil {
+		return e
+	}
+	var (
+		sigs = tx.Witness.Sigs
+		i    = 0
+	)
+	for i < len(sigs) && int(sigs[i].Index) < index {
+		i++
+	}
+	ms := MultisigSig{Index: uint8(index), Sig: sig}
+	if i < len(sigs) && int(sigs[i].Index) == index {
+		sigs[i] = ms
+	} else {
+		sigs = append(sigs, MultisigSig{})
+		copy(sigs[i+1:], sigs[i:])
+		sigs[i] = ms
+	}
+	tx.Witness.Sigs = sigs
+	return nil
+}
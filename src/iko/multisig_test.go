@@ -0,0 +1,128 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMultisig(t *testing.T, m, n int) (*Multisig, []Signer) {
+	var (
+		pks     = make([]cipher.PubKey, n)
+		signers = make([]Signer, n)
+	)
+	for i := range pks {
+		pk, sk := cipher.GenerateKeyPair()
+		pks[i], signers[i] = pk, NewSecKeySigner(sk)
+	}
+	ms, err := NewMultisig(m, pks...)
+	require.NoError(t, err, "multisig should be created")
+	return ms, signers
+}
+
+func TestNewMultisig(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+
+	_, err := NewMultisig(0, pk)
+	require.Error(t, err, "zero threshold should be rejected")
+
+	_, err = NewMultisig(2, pk)
+	require.Error(t, err, "unattainable threshold should be rejected")
+
+	_, err = NewMultisig(1, pk, pk)
+	require.Error(t, err, "duplicate keys should be rejected")
+
+	_, err = NewMultisig(1)
+	require.Error(t, err, "multisig without keys should be rejected")
+
+	ms, err := NewMultisig(1, pk)
+	require.NoError(t, err)
+	require.NotEqual(t, cipher.AddressFromPubKey(pk), ms.Address(),
+		"multisig address should differ from that of it's only key")
+}
+
+func TestMultisigTransfer(t *testing.T) {
+	ms, signers := newTestMultisig(t, 2, 3)
+	to, _ := cipher.GenerateKeyPair()
+	toAddr := cipher.AddressFromPubKey(to)
+
+	genTx := NewGenTx(1, GenSK)
+	genTx.Out = ms.Address()
+	genTx.Sig = cipher.SignHash(genTx.HashInner(), GenSK)
+	require.NoError(t, genTx.VerifyWith(nil, GenPK))
+
+	tx, err := NewMultisigTransferTx(genTx, toAddr, ms)
+	require.NoError(t, err)
+	hash := tx.Hash()
+
+	require.NoError(t, tx.AddMultisigSig(2, signers[2]))
+	require.Equal(t, ErrMultisigThreshold, tx.VerifyWith(genTx, GenPK),
+		"one of two signatures should not be enough")
+
+	require.Error(t, tx.AddMultisigSig(1, signers[0]),
+		"signer of another key should be rejected")
+
+	require.NoError(t, tx.AddMultisigSig(0, signers[0]))
+	require.NoError(t, tx.VerifyWith(genTx, GenPK),
+		"two of three signatures should be enough")
+	require.Equal(t, hash, tx.Hash(),
+		"signatures should not change the tx hash")
+	require.Equal(t, []uint8{0, 2},
+		[]uint8{tx.Witness.Sigs[0].Index, tx.Witness.Sigs[1].Index},
+		"signatures should be sorted by key index")
+
+	t.Run("SigOrder", func(t *testing.T) {
+		w := *tx.Witness
+		w.Sigs = []MultisigSig{w.Sigs[1], w.Sigs[0]}
+		require.Equal(t, ErrMultisigSigOrder, w.Verify(ms.Address(), tx.HashInner()))
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		other, _ := newTestMultisig(t, 2, 3)
+		_, err := NewMultisigTransferTx(genTx, toAddr, other)
+		require.Equal(t, ErrMultisigMismatch, err)
+
+		forged := *tx
+		forged.Witness = &Witness{Multisig: *other, Sigs: tx.Witness.Sigs}
+		require.Equal(t, ErrMultisigMismatch, forged.VerifyWith(genTx, GenPK))
+	})
+
+	t.Run("Serialize", func(t *testing.T) {
+		got, err := DeserializeTx(tx.Serialize())
+		require.NoError(t, err)
+		require.Equal(t, tx, got)
+
+		plain := NewGenTx(2, GenSK)
+		got, err = DeserializeTx(plain.Serialize())
+		require.NoError(t, err)
+		require.Equal(t, plain, got, "txs without witnesses should be unchanged")
+
+		var gotProto Transaction
+		require.NoError(t, gotProto.UnmarshalProto(tx.MarshalProto()))
+		require.Equal(t, *tx, gotProto)
+
+		txWrap := TxWrapper{Tx: *tx, Meta: genTxMeta(1)}
+		for _, codec := range []TxCodec{BinaryTxCodec{}, CBORTxCodec{}} {
+			var gotWrap TxWrapper
+			require.NoError(t, codec.DecodeTx(codec.EncodeTx(txWrap), &gotWrap))
+			require.Equal(t, txWrap, gotWrap,
+				"%s codec should preserve the witness", codec.Type())
+		}
+	})
+
+	t.Run("ChainDB", func(t *testing.T) {
+		path, rmTemp := tempBoltPath(t)
+		defer rmTemp()
+
+		chainDB := newBoltChainDB(t, path)
+		defer chainDB.Close()
+
+		txWrap := TxWrapper{Tx: *tx, Meta: genTxMeta(0)}
+		require.NoError(t, chainDB.AddTx(txWrap, addTxAlwaysApprove))
+
+		got, err := chainDB.GetTxOfHash(tx.Hash())
+		require.NoError(t, err)
+		require.Equal(t, txWrap, got, "stored tx should keep it's witness")
+	})
+}
@@ -0,0 +1,48 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxNetworkSize is the maximum size of a network identifier, in bytes.
+	MaxNetworkSize = 32
+)
+
+var (
+	// ErrNetworkMismatch occurs when a tx is signed for a network other than
+	// the configured 'NetworkID', such as a testnet tx replayed on mainnet.
+	ErrNetworkMismatch = errors.New("tx is signed for another network")
+)
+
+// VerifyNetworkID checks that the network identifier is within
+// 'MaxNetworkSize'. An empty identifier is of the legacy network, of which
+// txs do not set 'Network'.
+func VerifyNetworkID(network string) error {
+	if n := len(network); n > MaxNetworkSize {
+		return fmt.Errorf("network id should be of at most %d bytes, got %d",
+			MaxNetworkSize, n)
+	}
+	return nil
+}
+
+// VerifyNetwork checks that the tx is signed for the network. As 'Network' is
+// part of the tx hash, a tx signed for one network cannot be replayed on
+// another.
+func (tx Transaction) VerifyNetwork(network string) error {
+	if tx.Network != network {
+		return ErrNetworkMismatch
+	}
+	return nil
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// NetworkID returns the configured network identifier, which txs should set as
+// their 'Network' before signing.
+func (bc *BlockChain) NetworkID() string {
+	return bc.c.NetworkID
+}
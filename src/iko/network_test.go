@@ -0,0 +1,63 @@
+package iko
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+// newNetworkGenTx creates a generation tx signed for the network.
+func newNetworkGenTx(kittyID KittyID, network string) *Transaction {
+	tx := NewUnsignedGenTx(kittyID, cipher.AddressFromPubKey(GenPK))
+	tx.Network = network
+	tx.Sig = tx.Sign(GenSK)
+	return tx
+}
+
+func TestTransaction_VerifyNetwork(t *testing.T) {
+	tx := newNetworkGenTx(0, "kittycash-testnet")
+	require.NoError(t, tx.VerifyWith(nil, GenPK))
+	require.NoError(t, tx.VerifyNetwork("kittycash-testnet"))
+	require.Equal(t, ErrNetworkMismatch, tx.VerifyNetwork("kittycash-mainnet"))
+	require.Equal(t, ErrNetworkMismatch, tx.VerifyNetwork(""),
+		"tx of a network should not be valid on the legacy network")
+	require.Equal(t, ErrNetworkMismatch, NewGenTx(0, GenSK).VerifyNetwork("kittycash-mainnet"),
+		"tx of the legacy network should not be valid on another network")
+
+	decoded, err := DeserializeTx(tx.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, tx, decoded, "network should be decoded")
+	require.NotEqual(t, NewGenTx(0, GenSK).Hash(), tx.Hash(),
+		"network should be part of the tx hash")
+
+	// Changing the network invalidates the signature.
+	replayed := *tx
+	replayed.Network = "kittycash-mainnet"
+	require.Error(t, replayed.VerifyWith(nil, GenPK))
+
+	require.NoError(t, VerifyNetworkID(""))
+	require.Error(t, VerifyNetworkID(strings.Repeat("a", MaxNetworkSize+1)))
+}
+
+func TestBlockChain_NetworkID(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		NetworkID:    "kittycash-testnet",
+	})
+	defer closeBC()
+
+	require.Equal(t, "kittycash-testnet", bc.NetworkID())
+
+	_, err := bc.InjectTx(NewGenTx(0, GenSK))
+	require.Equal(t, ErrNetworkMismatch, err, "tx of the legacy network should be refused")
+
+	_, err = bc.InjectTx(newNetworkGenTx(0, "kittycash-mainnet"))
+	require.Equal(t, ErrNetworkMismatch, err, "tx of another network should be refused")
+	require.Equal(t, ErrNetworkMismatch, bc.SubmitTx(newNetworkGenTx(0, "kittycash-mainnet")),
+		"tx of another network should not enter the mempool")
+
+	_, err = bc.InjectTx(newNetworkGenTx(0, "kittycash-testnet"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,326 @@
+package iko
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// This file implements the encoding of unsigned transactions, so that they can
+// be built on an online machine, signed on an offline (air-gapped) machine, and
+// then injected by the online machine again.
+
+var (
+	// ErrSignHashMismatch occurs when the 'sign_hash' of an OfflineTx is not
+	// the inner hash of it's transaction, as the transaction was altered.
+	ErrSignHashMismatch = errors.New("sign hash does not match transaction")
+)
+
+// NewUnsignedGenTx creates a generation transaction of the creator's address,
+// to be signed with 'SignWith'.
+func NewUnsignedGenTx(kittyID KittyID, creator cipher.Address) *Transaction {
+	return &Transaction{
+		KittyID: kittyID,
+		In:      EmptyTxHash(),
+		Out:     creator,
+	}
+}
+
+// NewUnsignedTransferTx creates a transfer transaction, to be signed with
+// 'SignWith' by the owner of the input tx's output address.
+func NewUnsignedTransferTx(in *Transaction, out cipher.Address) *Transaction {
+	return &Transaction{
+		KittyID: in.KittyID,
+		In:      in.Hash(),
+		Out:     out,
+	}
+}
+
+// IsSigned determines whether the transaction has a signature, or a multisig
+// signature in it's witness.
+func (tx Transaction) IsSigned() bool {
+	if tx.Witness != nil {
+		return len(tx.Witness.Sigs) > 0
+	}
+	return tx.Sig != cipher.Sig{}
+}
+
+// EncodeTxHex encodes the transaction as hex, as accepted by the node's inject
+// endpoints.
+func EncodeTxHex(tx *Transaction) string {
+	return hex.EncodeToString(tx.Serialize())
+}
+
+// DecodeTxHex decodes a transaction encoded with 'EncodeTxHex'.
+func DecodeTxHex(s string) (*Transaction, error) {
+	raw, e := hex.DecodeString(strings.TrimSpace(s))
+	if e != nil {
+		return nil, e
+	}
+	return DeserializeTx(raw)
+}
+
+// OfflineTx is the JSON representation of a transaction that is passed between
+// the machine that builds it and the machine that signs it. Unlike the hex
+// encoding, it is human readable and records the address expected to sign.
+type OfflineTx struct {
+	KittyID  KittyID          `json:"kitty_id"`
+	In       string           `json:"in"`
+	Out      string           `json:"out"`
+	Inputs   []OfflineTxInput `json:"inputs,omitempty"` // Additional kitties of multi-kitty transfers.
+	Memo     string           `json:"memo,omitempty"`   // Hex encoded.
+	Fee      uint64           `json:"fee,omitempty"`
+	LockSeq  uint64           `json:"lock_seq,omitempty"`
+	LockTS   int64            `json:"lock_ts,omitempty"` // Unix time in nanoseconds.
+	Owner    string           `json:"owner,omitempty"`   // Address that should sign, if known.
+	SignHash string           `json:"sign_hash"`         // Inner hash that is signed.
+	Sig      string           `json:"sig,omitempty"`
+	Witness  string           `json:"witness,omitempty"`  // Hex encoded multisig witness.
+	Escrow   string           `json:"escrow,omitempty"`   // Hex encoded escrow being settled.
+	Metadata string           `json:"metadata,omitempty"` // Hex encoded kitty metadata, of metadata txs.
+	ChildID  *KittyID         `json:"child_id,omitempty"` // Child of breed txs.
+	Auction  string           `json:"auction,omitempty"`  // Hex encoded auction action, of auction txs.
+	Royalty  string           `json:"royalty,omitempty"`  // Hex encoded royalty, of generation and transfer txs.
+	Creators []string         `json:"creators,omitempty"` // Hex encoded public keys, of rotation txs.
+	Admin    string           `json:"admin,omitempty"`    // Hex encoded admin action, of admin txs.
+	Genesis  string           `json:"genesis,omitempty"`  // Hex encoded genesis, of the genesis tx.
+	Network  string           `json:"network,omitempty"`  // Network the tx is signed for.
+	Version  uint8            `json:"version,omitempty"`  // Version of the tx encoding.
+}
+
+type OfflineTxInput struct {
+	KittyID KittyID `json:"kitty_id"`
+	In      string  `json:"in"`
+}
+
+// NewOfflineTx creates the JSON representation of the transaction. The owner
+// is the address expected to sign, which may be empty if unknown.
+func NewOfflineTx(tx *Transaction, owner cipher.Address) *OfflineTx {
+	out := &OfflineTx{
+		KittyID:  tx.KittyID,
+		In:       tx.In.Hex(),
+		Out:      tx.Out.String(),
+		SignHash: tx.HashInner().Hex(),
+		Fee:      tx.Fee,
+		LockSeq:  tx.Lock.Seq,
+		LockTS:   tx.Lock.TS,
+	}
+	for _, in := range tx.Inputs {
+		out.Inputs = append(out.Inputs, OfflineTxInput{
+			KittyID: in.KittyID,
+			In:      in.In.Hex(),
+		})
+	}
+	if len(tx.Memo) > 0 {
+		out.Memo = hex.EncodeToString(tx.Memo)
+	}
+	if owner != (cipher.Address{}) {
+		out.Owner = owner.String()
+	}
+	if tx.Sig != (cipher.Sig{}) {
+		out.Sig = tx.Sig.Hex()
+	}
+	if tx.Witness != nil {
+		out.Witness = hex.EncodeToString(tx.Witness.Serialize())
+	}
+	if tx.Escrow != nil {
+		out.Escrow = hex.EncodeToString(tx.Escrow.Serialize())
+	}
+	if tx.Metadata != nil {
+		out.Metadata = hex.EncodeToString(tx.Metadata.Serialize())
+	}
+	if tx.Breed != nil {
+		childID := tx.Breed.ChildID
+		out.ChildID = &childID
+	}
+	if tx.Auction != nil {
+		out.Auction = hex.EncodeToString(tx.Auction.Serialize())
+	}
+	if tx.Royalty != nil {
+		out.Royalty = hex.EncodeToString(tx.Royalty.Serialize())
+	}
+	if tx.Rotation != nil {
+		for _, pk := range tx.Rotation.Creators {
+			out.Creators = append(out.Creators, pk.Hex())
+		}
+	}
+	if tx.Admin != nil {
+		out.Admin = hex.EncodeToString(tx.Admin.Serialize())
+	}
+	if tx.Genesis != nil {
+		out.Genesis = hex.EncodeToString(tx.Genesis.Serialize())
+	}
+	out.Network = tx.Network
+	out.Version = tx.Version
+	return out
+}
+
+// Decode obtains the transaction and the address expected to sign it (empty
+// if unknown). It fails with ErrSignHashMismatch if the sign hash is not that
+// of the transaction.
+func (o *OfflineTx) Decode() (*Transaction, cipher.Address, error) {
+	var (
+		tx = &Transaction{
+			KittyID: o.KittyID,
+			Fee:     o.Fee,
+			Lock:    TxLock{Seq: o.LockSeq, TS: o.LockTS},
+		}
+		owner cipher.Address
+	)
+	in, e := cipher.SHA256FromHex(o.In)
+	if e != nil {
+		return nil, owner, e
+	}
+	tx.In = TxHash(in)
+	if tx.Out, e = cipher.DecodeBase58Address(o.Out); e != nil {
+		return nil, owner, e
+	}
+	for _, oIn := range o.Inputs {
+		in, e := cipher.SHA256FromHex(oIn.In)
+		if e != nil {
+			return nil, owner, e
+		}
+		tx.Inputs = append(tx.Inputs, KittyInput{
+			KittyID: oIn.KittyID,
+			In:      TxHash(in),
+		})
+	}
+	if o.Memo != "" {
+		if tx.Memo, e = hex.DecodeString(o.Memo); e != nil {
+			return nil, owner, e
+		}
+	}
+	if o.Owner != "" {
+		if owner, e = cipher.DecodeBase58Address(o.Owner); e != nil {
+			return nil, owner, e
+		}
+	}
+	if o.Sig != "" {
+		if tx.Sig, e = cipher.SigFromHex(o.Sig); e != nil {
+			return nil, owner, e
+		}
+	}
+	if o.Witness != "" {
+		raw, e := hex.DecodeString(o.Witness)
+		if e != nil {
+			return nil, owner, e
+		}
+		tx.Witness = new(Witness)
+		if e := encoder.DeserializeRaw(raw, tx.Witness); e != nil {
+			return nil, owner, e
+		}
+	}
+	if o.Escrow != "" {
+		raw, e := hex.DecodeString(o.Escrow)
+		if e != nil {
+			return nil, owner, e
+		}
+		tx.Escrow = new(Escrow)
+		if e := encoder.DeserializeRaw(raw, tx.Escrow); e != nil {
+			return nil, owner, e
+		}
+	}
+	if o.Metadata != "" {
+		raw, e := hex.DecodeString(o.Metadata)
+		if e != nil {
+			return nil, owner, e
+		}
+		tx.Metadata = new(KittyMetadata)
+		if e := encoder.DeserializeRaw(raw, tx.Metadata); e != nil {
+			return nil, owner, e
+		}
+	}
+	if o.ChildID != nil {
+		tx.Breed = &TxBreed{ChildID: *o.ChildID}
+	}
+	if o.Auction != "" {
+		raw, e := hex.DecodeString(o.Auction)
+		if e != nil {
+			return nil, owner, e
+		}
+		tx.Auction = new(TxAuction)
+		if e := encoder.DeserializeRaw(raw, tx.Auction); e != nil {
+			return nil, owner, e
+		}
+	}
+	if o.Royalty != "" {
+		raw, e := hex.DecodeString(o.Royalty)
+		if e != nil {
+			return nil, owner, e
+		}
+		tx.Royalty = new(TxRoyalty)
+		if e := encoder.DeserializeRaw(raw, tx.Royalty); e != nil {
+			return nil, owner, e
+		}
+	}
+	if len(o.Creators) > 0 {
+		tx.Rotation = new(TxRotation)
+		for _, v := range o.Creators {
+			pk, e := cipher.PubKeyFromHex(v)
+			if e != nil {
+				return nil, owner, e
+			}
+			tx.Rotation.Creators = append(tx.Rotation.Creators, pk)
+		}
+	}
+	if o.Admin != "" {
+		raw, e := hex.DecodeString(o.Admin)
+		if e != nil {
+			return nil, owner, e
+		}
+		tx.Admin = new(TxAdmin)
+		if e := encoder.DeserializeRaw(raw, tx.Admin); e != nil {
+			return nil, owner, e
+		}
+	}
+	if o.Genesis != "" {
+		raw, e := hex.DecodeString(o.Genesis)
+		if e != nil {
+			return nil, owner, e
+		}
+		tx.Genesis = new(Genesis)
+		if e := encoder.DeserializeRaw(raw, tx.Genesis); e != nil {
+			return nil, owner, e
+		}
+	}
+	tx.Network = o.Network
+	tx.Version = o.Version
+	if o.SignHash != tx.HashInner().Hex() {
+		return nil, owner, ErrSignHashMismatch
+	}
+	return tx, owner, nil
+}
+
+// Sign signs the transaction with the signer, which should be of the owner (if
+// known). Multisig transfers are instead signed with the multisig key of the
+// signer.
+func (o *OfflineTx) Sign(s Signer) error {
+	tx, owner, e := o.Decode()
+	if e != nil {
+		return e
+	}
+	if tx.Witness != nil {
+		for i, pk := range tx.Witness.Multisig.PubKeys {
+			if cipher.AddressFromPubKey(pk) != s.Address() {
+				continue
+			}
+			if e := tx.AddMultisigSig(i, s); e != nil {
+				return e
+			}
+			o.Witness = hex.EncodeToString(tx.Witness.Serialize())
+			return nil
+		}
+		return ErrSignerNotOwner
+	}
+	if owner != (cipher.Address{}) && owner != s.Address() && tx.Escrow == nil && tx.Auction == nil {
+		return ErrSignerNotOwner
+	}
+	if e := tx.SignWith(s); e != nil {
+		return e
+	}
+	o.Sig = tx.Sig.Hex()
+	return nil
+}
@@ -0,0 +1,83 @@
+package iko
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfflineTx(t *testing.T) {
+	var (
+		creator = cipher.AddressFromPubKey(GenPK)
+		genTx   = NewGenTx(1, GenSK)
+		to, _   = cipher.GenerateKeyPair()
+	)
+
+	tx := NewUnsignedTransferTx(genTx, cipher.AddressFromPubKey(to))
+	require.False(t, tx.IsSigned())
+
+	raw, err := json.Marshal(NewOfflineTx(tx, creator))
+	require.NoError(t, err)
+
+	t.Run("Sign", func(t *testing.T) {
+		var o OfflineTx
+		require.NoError(t, json.Unmarshal(raw, &o))
+		require.NoError(t, o.Sign(NewSecKeySigner(GenSK)))
+
+		signed, owner, err := o.Decode()
+		require.NoError(t, err)
+		require.Equal(t, creator, owner)
+		require.True(t, signed.IsSigned())
+		require.NoError(t, signed.VerifyWith(genTx, GenPK))
+	})
+
+	t.Run("NotOwner", func(t *testing.T) {
+		_, sk := cipher.GenerateKeyPair()
+		var o OfflineTx
+		require.NoError(t, json.Unmarshal(raw, &o))
+		require.Equal(t, ErrSignerNotOwner, o.Sign(NewSecKeySigner(sk)))
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		var o OfflineTx
+		require.NoError(t, json.Unmarshal(raw, &o))
+		o.Out = creator.String()
+		require.Equal(t, ErrSignHashMismatch, o.Sign(NewSecKeySigner(GenSK)),
+			"altered transactions should not be signed")
+	})
+
+	t.Run("Multisig", func(t *testing.T) {
+		ms, signers := newTestMultisig(t, 2, 3)
+		in := NewUnsignedGenTx(2, ms.Address())
+		tx, err := NewMultisigTransferTx(in, creator, ms)
+		require.NoError(t, err)
+
+		o := NewOfflineTx(tx, ms.Address())
+		for _, i := range []int{1, 2} {
+			require.NoError(t, o.Sign(signers[i]))
+		}
+		signed, _, err := o.Decode()
+		require.NoError(t, err)
+		require.NoError(t, signed.VerifyOwner(ms.Address()))
+	})
+	t.Run("MultiTransferMemo", func(t *testing.T) {
+		gens := []*Transaction{genTx, NewGenTx(2, GenSK)}
+		tx, err := NewUnsignedMultiTransferTx(gens, KittyIDs{1, 2}, cipher.AddressFromPubKey(to))
+		require.NoError(t, err)
+		tx.Memo = []byte("order:1")
+
+		raw, err := json.Marshal(NewOfflineTx(tx, creator))
+		require.NoError(t, err)
+
+		var o OfflineTx
+		require.NoError(t, json.Unmarshal(raw, &o))
+		require.NoError(t, o.Sign(NewSecKeySigner(GenSK)))
+
+		signed, _, err := o.Decode()
+		require.NoError(t, err)
+		require.Equal(t, tx.Memo, signed.Memo)
+		require.NoError(t, signed.VerifyWithInputs(gens))
+	})
+}
@@ -0,0 +1,400 @@
+package iko
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/kittycash/wallet/src/util/protowire"
+)
+
+// This file implements the protobuf encoding of the messages defined in
+// 'iko.proto'. Unknown fields are skipped when decoding, so the schema can be
+// extended in a backwards compatible manner.
+
+var (
+	// ErrProtoTruncated occurs when a protobuf message ends unexpectedly.
+	ErrProtoTruncated = protowire.ErrTruncated
+)
+
+/*
+	<<< TRANSACTION >>>
+*/
+
+func (tx Transaction) MarshalProto() []byte {
+	var b []byte
+	if tx.KittyID != 0 {
+		b = protowire.AppendVarint(b, 1, uint64(tx.KittyID))
+	}
+	b = protowire.AppendBytes(b, 2, tx.In[:])
+	b = protowire.AppendBytes(b, 3, []byte(tx.Out.String()))
+	b = protowire.AppendBytes(b, 4, tx.Sig[:])
+	if tx.Witness != nil {
+		b = protowire.AppendBytes(b, 5, tx.Witness.Serialize())
+	}
+	for _, in := range tx.Inputs {
+		b = protowire.AppendBytes(b, 6, in.MarshalProto())
+	}
+	if len(tx.Memo) > 0 {
+		b = protowire.AppendBytes(b, 7, tx.Memo)
+	}
+	if tx.Fee > 0 {
+		b = protowire.AppendVarint(b, 8, tx.Fee)
+	}
+	if tx.Lock.Seq > 0 {
+		b = protowire.AppendVarint(b, 9, tx.Lock.Seq)
+	}
+	if tx.Lock.TS != 0 {
+		b = protowire.AppendVarint(b, 10, uint64(tx.Lock.TS))
+	}
+	if tx.Escrow != nil {
+		b = protowire.AppendBytes(b, 11, tx.Escrow.Serialize())
+	}
+	if tx.Metadata != nil {
+		b = protowire.AppendBytes(b, 12, tx.Metadata.MarshalProto())
+	}
+	if tx.Breed != nil {
+		b = protowire.AppendBytes(b, 13, tx.Breed.MarshalProto())
+	}
+	if tx.Auction != nil {
+		b = protowire.AppendBytes(b, 14, tx.Auction.Serialize())
+	}
+	if tx.Royalty != nil {
+		b = protowire.AppendBytes(b, 15, tx.Royalty.Serialize())
+	}
+	if tx.Rotation != nil {
+		b = protowire.AppendBytes(b, 16, tx.Rotation.Serialize())
+	}
+	if tx.Admin != nil {
+		b = protowire.AppendBytes(b, 17, tx.Admin.Serialize())
+	}
+	if tx.Genesis != nil {
+		b = protowire.AppendBytes(b, 18, tx.Genesis.Serialize())
+	}
+	if tx.Network != "" {
+		b = protowire.AppendBytes(b, 19, []byte(tx.Network))
+	}
+	if tx.Version != TxVersionLegacy {
+		b = protowire.AppendVarint(b, 20, uint64(tx.Version))
+	}
+	return b
+}
+
+func (tx *Transaction) UnmarshalProto(b []byte) error {
+	*tx = Transaction{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		var e error
+		switch field {
+		case 1:
+			tx.KittyID = KittyID(v)
+		case 2:
+			e = protowire.CopyFixed(tx.In[:], raw, "in")
+		case 3:
+			tx.Out, e = cipher.DecodeBase58Address(string(raw))
+		case 4:
+			e = protowire.CopyFixed(tx.Sig[:], raw, "sig")
+		case 5:
+			tx.Witness = new(Witness)
+			e = encoder.DeserializeRaw(raw, tx.Witness)
+		case 6:
+			var in KittyInput
+			if e = in.UnmarshalProto(raw); e == nil {
+				tx.Inputs = append(tx.Inputs, in)
+			}
+		case 7:
+			tx.Memo = append([]byte(nil), raw...)
+		case 8:
+			tx.Fee = v
+		case 9:
+			tx.Lock.Seq = v
+		case 10:
+			tx.Lock.TS = int64(v)
+		case 11:
+			tx.Escrow = new(Escrow)
+			e = encoder.DeserializeRaw(raw, tx.Escrow)
+		case 12:
+			tx.Metadata = new(KittyMetadata)
+			e = tx.Metadata.UnmarshalProto(raw)
+		case 13:
+			tx.Breed = new(TxBreed)
+			e = tx.Breed.UnmarshalProto(raw)
+		case 14:
+			tx.Auction = new(TxAuction)
+			e = encoder.DeserializeRaw(raw, tx.Auction)
+		case 15:
+			tx.Royalty = new(TxRoyalty)
+			e = encoder.DeserializeRaw(raw, tx.Royalty)
+		case 16:
+			tx.Rotation = new(TxRotation)
+			e = encoder.DeserializeRaw(raw, tx.Rotation)
+		case 17:
+			tx.Admin = new(TxAdmin)
+			e = encoder.DeserializeRaw(raw, tx.Admin)
+		case 18:
+			tx.Genesis = new(Genesis)
+			e = encoder.DeserializeRaw(raw, tx.Genesis)
+		case 19:
+			tx.Network = string(raw)
+		case 20:
+			tx.Version = uint8(v)
+		}
+		return e
+	})
+}
+
+func (in KittyInput) MarshalProto() []byte {
+	var b []byte
+	if in.KittyID != 0 {
+		b = protowire.AppendVarint(b, 1, uint64(in.KittyID))
+	}
+	b = protowire.AppendBytes(b, 2, in.In[:])
+	return b
+}
+
+func (in *KittyInput) UnmarshalProto(b []byte) error {
+	*in = KittyInput{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		var e error
+		switch field {
+		case 1:
+			in.KittyID = KittyID(v)
+		case 2:
+			e = protowire.CopyFixed(in.In[:], raw, "in")
+		}
+		return e
+	})
+}
+
+func (br TxBreed) MarshalProto() []byte {
+	var b []byte
+	if br.ChildID != 0 {
+		b = protowire.AppendVarint(b, 1, uint64(br.ChildID))
+	}
+	return b
+}
+
+func (br *TxBreed) UnmarshalProto(b []byte) error {
+	*br = TxBreed{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		if field == 1 {
+			br.ChildID = KittyID(v)
+		}
+		return nil
+	})
+}
+
+func (m KittyMetadata) MarshalProto() []byte {
+	var b []byte
+	if m.Name != "" {
+		b = protowire.AppendBytes(b, 1, []byte(m.Name))
+	}
+	if len(m.DNA) > 0 {
+		b = protowire.AppendBytes(b, 2, m.DNA)
+	}
+	if m.Generation > 0 {
+		b = protowire.AppendVarint(b, 3, uint64(m.Generation))
+	}
+	if m.ImageURI != "" {
+		b = protowire.AppendBytes(b, 4, []byte(m.ImageURI))
+	}
+	return b
+}
+
+func (m *KittyMetadata) UnmarshalProto(b []byte) error {
+	*m = KittyMetadata{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			m.Name = string(raw)
+		case 2:
+			m.DNA = append([]byte(nil), raw...)
+		case 3:
+			m.Generation = uint32(v)
+		case 4:
+			m.ImageURI = string(raw)
+		}
+		return nil
+	})
+}
+
+func (m TxMeta) MarshalProto() []byte {
+	var b []byte
+	if m.Seq != 0 {
+		b = protowire.AppendVarint(b, 1, m.Seq)
+	}
+	if m.TS != 0 {
+		b = protowire.AppendVarint(b, 2, uint64(m.TS))
+	}
+	return b
+}
+
+func (m *TxMeta) UnmarshalProto(b []byte) error {
+	*m = TxMeta{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			m.Seq = v
+		case 2:
+			m.TS = int64(v)
+		}
+		return nil
+	})
+}
+
+func (w TxWrapper) MarshalProto() []byte {
+	var b []byte
+	b = protowire.AppendBytes(b, 1, w.Tx.MarshalProto())
+	b = protowire.AppendBytes(b, 2, w.Meta.MarshalProto())
+	return b
+}
+
+func (w *TxWrapper) UnmarshalProto(b []byte) error {
+	*w = TxWrapper{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			return w.Tx.UnmarshalProto(raw)
+		case 2:
+			return w.Meta.UnmarshalProto(raw)
+		}
+		return nil
+	})
+}
+
+/*
+	<<< STATE >>>
+*/
+
+func (s KittyState) MarshalProto() []byte {
+	var b []byte
+	b = protowire.AppendBytes(b, 1, []byte(s.Address.String()))
+	for _, hash := range s.Transactions {
+		b = protowire.AppendBytes(b, 2, hash[:])
+	}
+	if s.Burned {
+		b = protowire.AppendVarint(b, 3, 1)
+	}
+	if !s.Metadata.IsZero() {
+		b = protowire.AppendBytes(b, 4, s.Metadata.MarshalProto())
+	}
+	if s.CooldownSeq > 0 {
+		b = protowire.AppendVarint(b, 5, s.CooldownSeq)
+	}
+	if !s.Auction.IsZero() {
+		b = protowire.AppendBytes(b, 6, encoder.Serialize(s.Auction))
+	}
+	if !s.Royalty.IsZero() {
+		b = protowire.AppendBytes(b, 7, encoder.Serialize(s.Royalty))
+	}
+	return b
+}
+
+func (s *KittyState) UnmarshalProto(b []byte) error {
+	*s = KittyState{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		var e error
+		switch field {
+		case 1:
+			s.Address, e = cipher.DecodeBase58Address(string(raw))
+		case 2:
+			var hash TxHash
+			if e = protowire.CopyFixed(hash[:], raw, "transactions"); e == nil {
+				s.Transactions = append(s.Transactions, hash)
+			}
+		case 3:
+			s.Burned = v != 0
+		case 4:
+			e = s.Metadata.UnmarshalProto(raw)
+		case 5:
+			s.CooldownSeq = v
+		case 6:
+			e = encoder.DeserializeRaw(raw, &s.Auction)
+		case 7:
+			e = encoder.DeserializeRaw(raw, &s.Royalty)
+		}
+		return e
+	})
+}
+
+func (a AddressState) MarshalProto() []byte {
+	var (
+		b      []byte
+		packed []byte
+	)
+	for _, id := range a.Kitties {
+		packed = protowire.AppendUvarint(packed, uint64(id))
+	}
+	if len(packed) > 0 {
+		b = protowire.AppendBytes(b, 1, packed)
+	}
+	for _, hash := range a.Transactions {
+		b = protowire.AppendBytes(b, 2, hash[:])
+	}
+	if a.FeesPaid > 0 {
+		b = protowire.AppendVarint(b, 3, a.FeesPaid)
+	}
+	if a.Royalties > 0 {
+		b = protowire.AppendVarint(b, 4, a.Royalties)
+	}
+	return b
+}
+
+func (a *AddressState) UnmarshalProto(b []byte) error {
+	*a = *NewAddressState()
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			// Repeated scalars may either be packed or not.
+			if wire == protowire.Varint {
+				a.Kitties = append(a.Kitties, KittyID(v))
+				return nil
+			}
+			ids, e := protowire.Uvarints(raw)
+			if e != nil {
+				return e
+			}
+			for _, id := range ids {
+				a.Kitties = append(a.Kitties, KittyID(id))
+			}
+		case 2:
+			var hash TxHash
+			if e := protowire.CopyFixed(hash[:], raw, "transactions"); e != nil {
+				return e
+			}
+			a.Transactions = append(a.Transactions, hash)
+		case 3:
+			a.FeesPaid = v
+		case 4:
+			a.Royalties = v
+		}
+		return nil
+	})
+}
+
+func (f BloomFilter) MarshalProto() []byte {
+	var b []byte
+	if len(f.Bits) > 0 {
+		b = protowire.AppendBytes(b, 1, f.Bits)
+	}
+	if f.Hashes != 0 {
+		b = protowire.AppendVarint(b, 2, uint64(f.Hashes))
+	}
+	if f.Tweak != 0 {
+		b = protowire.AppendVarint(b, 3, uint64(f.Tweak))
+	}
+	return b
+}
+
+func (f *BloomFilter) UnmarshalProto(b []byte) error {
+	*f = BloomFilter{}
+	return protowire.Range(b, func(field int, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			f.Bits = append([]byte(nil), raw...)
+		case 2:
+			f.Hashes = uint32(v)
+		case 3:
+			f.Tweak = uint32(v)
+		}
+		return nil
+	})
+}
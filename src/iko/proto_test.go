@@ -0,0 +1,106 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/util/protowire"
+)
+
+func TestTransaction_Proto(t *testing.T) {
+	txWrap := genTxWraps(2, 7)[1]
+
+	t.Run("Transaction", func(t *testing.T) {
+		raw := txWrap.Tx.MarshalProto()
+		require.Equal(t, []byte{0x08, 0x08}, raw[:2],
+			"first field should be kitty_id as varint")
+
+		var tx Transaction
+		require.NoError(t, tx.UnmarshalProto(raw))
+		require.Equal(t, txWrap.Tx, tx, "decoded tx should match")
+	})
+
+	t.Run("MultiTransfer", func(t *testing.T) {
+		gens := genTxWraps(2, 0)
+		multi, err := NewMultiTransferTx(
+			[]*Transaction{&gens[0].Tx, &gens[1].Tx}, KittyIDs{0, 1}, txWrap.Tx.Out, GenSK)
+		require.NoError(t, err)
+
+		var tx Transaction
+		require.NoError(t, tx.UnmarshalProto(multi.MarshalProto()))
+		require.Equal(t, *multi, tx, "decoded tx should match")
+	})
+
+	t.Run("OptionalFields", func(t *testing.T) {
+		opt := NewUnsignedMetadataTx(&txWrap.Tx, KittyMetadata{Name: "Kitty", DNA: []byte{1}, Generation: 1})
+		opt.Memo = []byte("memo")
+		opt.Fee = 5
+		opt.Lock = TxLock{Seq: 3, TS: 4}
+		opt.Escrow = &Escrow{Sender: txWrap.Tx.Out, Receiver: cipher.AddressFromPubKey(GenPK), Deadline: 9}
+		opt.Breed = &TxBreed{} // child of ID 0 should still be decoded
+		opt.Auction = &TxAuction{Action: AuctionList, Listing: Listing{Seller: txWrap.Tx.Out, Deadline: 10}}
+		opt.Royalty = NewTxRoyalty(5, 1000)
+		opt.Admin = &TxAdmin{Freeze: true}
+		opt.Genesis = &Genesis{ChainID: "kittycash-test", CreatorPK: GenPK, TS: 11}
+		opt.Network = "kittycash-test"
+		opt.Version = TxVersion1
+		opt.Sig = opt.Sign(GenSK)
+
+		var tx Transaction
+		require.NoError(t, tx.UnmarshalProto(opt.MarshalProto()))
+		require.Equal(t, *opt, tx, "decoded tx should match")
+	})
+
+	t.Run("TxWrapper", func(t *testing.T) {
+		var reqTxWrap TxWrapper
+		require.NoError(t, reqTxWrap.UnmarshalProto(txWrap.MarshalProto()))
+		require.Equal(t, txWrap, reqTxWrap, "decoded tx wrapper should match")
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		raw := append(txWrap.Tx.MarshalProto(), protowire.AppendVarint(nil, 99, 42)...)
+
+		var tx Transaction
+		require.NoError(t, tx.UnmarshalProto(raw),
+			"unknown fields should be skipped")
+		require.Equal(t, txWrap.Tx, tx, "decoded tx should match")
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		raw := txWrap.Tx.MarshalProto()
+
+		var tx Transaction
+		require.Equal(t, ErrProtoTruncated, tx.UnmarshalProto(raw[:len(raw)-1]),
+			"truncated message should fail")
+	})
+}
+
+func TestState_Proto(t *testing.T) {
+	var (
+		addr = cipher.AddressFromPubKey(GenPK)
+		tx1  = TxHash(cipher.SumSHA256([]byte("tx1")))
+		tx2  = TxHash(cipher.SumSHA256([]byte("tx2")))
+	)
+
+	kState := KittyState{Address: addr, Transactions: TxHashes{tx1, tx2}, Burned: true, CooldownSeq: 7,
+		Auction:  AuctionState{Listing: Listing{Seller: addr, Reserve: 1, Deadline: 2}, Bidder: addr, Bid: 3},
+		Royalty:  KittyRoyalty{Creator: addr, Percent: 10},
+		Metadata: KittyMetadata{Name: "Kitty", DNA: []byte{1, 2}, Generation: 3, ImageURI: "ipfs://kitty"}}
+	var reqKState KittyState
+	require.NoError(t, reqKState.UnmarshalProto(kState.MarshalProto()))
+	require.Equal(t, kState, reqKState, "decoded kitty state should match")
+
+	aState := AddressState{Kitties: KittyIDs{1, 300, 70000}, Transactions: TxHashes{tx1}, FeesPaid: 42, Royalties: 7}
+	var reqAState AddressState
+	require.NoError(t, reqAState.UnmarshalProto(aState.MarshalProto()))
+	require.Equal(t, aState, reqAState, "decoded address state should match")
+
+	// Unpacked repeated kitties should also be accepted.
+	raw := protowire.AppendVarint(nil, 1, 5)
+	raw = protowire.AppendVarint(raw, 1, 6)
+	require.NoError(t, reqAState.UnmarshalProto(raw))
+	require.Equal(t, KittyIDs{5, 6}, reqAState.Kitties,
+		"unpacked kitties should be decoded")
+}
@@ -0,0 +1,86 @@
+package iko
+
+import (
+	"errors"
+)
+
+var (
+	// ErrChainNotPrunable occurs when using 'PrunedChainMode' with a ChainDB
+	// that is not a 'PrunableChainDB'.
+	ErrChainNotPrunable = errors.New("chain db does not support pruning")
+)
+
+// ChainMode determines whether the BlockChain keeps every tx of the chain.
+type ChainMode string
+
+const (
+	// ArchivalChainMode keeps every tx of the chain.
+	ArchivalChainMode ChainMode = "archival"
+
+	// PrunedChainMode removes the bodies of txs that are older than the
+	// 'PruneRetention' most recent txs, and the oldest automatic snapshot.
+	// The genesis tx, and the unspent txs of kitties are always kept, so that
+	// the state stays correct. As the chain cannot be replayed from the
+	// start, the state is restored from automatic snapshots on start.
+	PrunedChainMode ChainMode = "pruned"
+)
+
+const (
+	// DefaultPruneRetention is the default number of most recent txs that
+	// are kept in 'PrunedChainMode'.
+	DefaultPruneRetention = 10000
+)
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// pruneTxs removes the txs that are older than both the 'PruneRetention' most
+// recent txs and the automatic snapshot of height 'oldest', so that the state
+// can be restored from any of the kept snapshots. Kept txs of older seqs are
+// checked again, as kitties may have been transferred since. Failures are
+// logged, as the txs are already committed. The caller should hold the locks
+// of the BlockChain.
+func (bc *BlockChain) pruneTxs(oldest uint64) {
+	if bc.c.ChainMode != PrunedChainMode || oldest == 0 {
+		return
+	}
+	cLen := bc.chain.Len()
+	if cLen <= bc.c.PruneRetention {
+		return
+	}
+	end := cLen - bc.c.PruneRetention
+	if end > oldest-1 {
+		end = oldest - 1
+	}
+	if end <= 1 {
+		return
+	}
+	pruned, e := bc.chain.(PrunableChainDB).PruneTxs(1, end, bc.keepTx)
+	bc.txCache.purge()
+	if e != nil {
+		bc.log.WithError(e).Error("failed to prune txs")
+		return
+	}
+	if pruned > 0 {
+		bc.log.
+			WithField("pruned", pruned).
+			WithField("end_seq", end).
+			Info("pruned txs")
+	}
+}
+
+// keepTx returns true if the tx should not be pruned, as it is the genesis tx
+// or the unspent tx of a kitty.
+func (bc *BlockChain) keepTx(txWrap *TxWrapper) bool {
+	if txWrap.Meta.Seq == 0 {
+		return true
+	}
+	hash := txWrap.Tx.Hash()
+	for _, kittyID := range txWrap.Tx.OutputKittyIDs() {
+		if unspent, ok := bc.state.GetKittyUnspentTx(kittyID); ok && unspent == hash {
+			return true
+		}
+	}
+	return false
+}
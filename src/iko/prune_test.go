@@ -0,0 +1,90 @@
+package iko
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_Prune(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	dir, rmDir := tempLevelDir(t)
+	defer rmDir()
+
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	config := &BlockChainConfig{
+		GenerationPK:     GenPK,
+		SnapshotInterval: 2,
+		SnapshotDir:      dir,
+		SnapshotKeep:     1,
+		ChainMode:        PrunedChainMode,
+		PruneRetention:   2,
+	}
+	bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+	require.NoError(t, err)
+
+	// Kitties 1 and 2 are transferred, so their generation txs are spent.
+	gens := injectGenTxs(t, bc, 3)
+	pk, _ := cipher.GenerateKeyPair()
+	for _, gen := range gens[1:] {
+		transfer, err := NewTransferTx(&gen.Tx, cipher.AddressFromPubKey(pk), GenSK)
+		require.NoError(t, err)
+		_, err = bc.InjectTx(transfer)
+		require.NoError(t, err)
+	}
+	for i := 3; i < 6; i++ {
+		_, err := bc.InjectTx(NewGenTx(KittyID(i), GenSK))
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(8), bc.chain.Len())
+
+	for seq := uint64(0); seq < 8; seq++ {
+		_, err := bc.GetTxOfSeq(seq)
+		if seq == 1 || seq == 2 {
+			require.Errorf(t, err, "spent tx of seq %d should be pruned", seq)
+		} else {
+			require.NoErrorf(t, err, "tx of seq %d should be kept", seq)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		unspent, ok := bc.state.GetKittyUnspentTx(KittyID(i))
+		require.True(t, ok)
+		_, err := bc.GetTxOfHash(unspent)
+		require.NoErrorf(t, err, "unspent tx of kitty %d should be kept", i)
+	}
+
+	snapshot := bc.state.(SnapshotStateDB).Snapshot()
+	bc.Close()
+
+	bc, err = NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+	require.NoError(t, err, "state should be restored from snapshot")
+	defer bc.Close()
+	require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot())
+}
+
+func TestBlockChain_PruneUnsupported(t *testing.T) {
+	chainDB, err := newCXOChainDB("", true, true, "", nil)
+	require.NoError(t, err)
+	defer chainDB.Close()
+
+	dir, rmDir := tempLevelDir(t)
+	defer rmDir()
+
+	_, err = NewBlockChain(context.Background(), &BlockChainConfig{
+		GenerationPK:     GenPK,
+		SnapshotInterval: 2,
+		SnapshotDir:      dir,
+		ChainMode:        PrunedChainMode,
+	}, chainDB, NewMemoryState())
+	require.Equal(t, ErrChainNotPrunable, err)
+
+	require.Error(t, (&BlockChainConfig{
+		GenerationPK: GenPK,
+		ChainMode:    PrunedChainMode,
+	}).Prepare(), "pruning should require automatic snapshots")
+}
@@ -0,0 +1,74 @@
+package iko
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrReplica occurs when injecting txs into a BlockChain in replica mode,
+	// as only it's primary injects txs.
+	ErrReplica = errors.New("blockchain is a read replica")
+)
+
+// DefaultReplicaRetry is the default delay before a replica reconnects to the
+// tx stream of it's primary.
+const DefaultReplicaRetry = time.Second
+
+// TxSource is the tx stream of the primary of a replica, such as of a remote
+// node ('http.RPCClient') or of a BlockChain of the same process.
+type TxSource interface {
+
+	// StreamTxs should call 'fn' with every tx from seq 'start', in order,
+	// and then with every tx accepted by the primary, until the context is
+	// done or 'fn' returns an error (see 'BlockChain.StreamTxs').
+	StreamTxs(ctx context.Context, start uint64, fn func(TxWrapper) error) error
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// IsReplica determines whether the BlockChain is a read replica (see
+// 'BlockChainConfig.Replica').
+func (bc *BlockChain) IsReplica() bool {
+	return bc.c.Replica
+}
+
+// Replicate tails the tx stream of the primary from the head of the chain,
+// appending it's txs until the context is done or the BlockChain is closed.
+// Txs are validated as with 'AppendTxs', so a replica does not follow a
+// primary that diverges from it. The stream is reconnected every
+// 'ReplicaRetry' after it fails. The primary should be archival, as pruned
+// txs are not streamed.
+func (bc *BlockChain) Replicate(ctx context.Context, src TxSource) error {
+	for {
+		e := src.StreamTxs(ctx, bc.chain.Len(), func(txWrap TxWrapper) error {
+			if txWrap.Meta.Seq < bc.chain.Len() {
+				// Already appended, such as by a peer of the p2p node.
+				return nil
+			}
+			_, e := bc.AppendTxs([]TxWrapper{txWrap})
+			return e
+		})
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case bc.ctx.Err() != nil:
+			return ErrChainStopped
+		}
+		bc.log.
+			WithError(e).
+			WithField("head_seq", bc.chain.Len()).
+			Warning("replica stream failed, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-bc.ctx.Done():
+			return ErrChainStopped
+		case <-time.After(bc.c.ReplicaRetry):
+		}
+	}
+}
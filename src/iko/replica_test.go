@@ -0,0 +1,69 @@
+package iko
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// failingTxSource fails the stream once, after the first tx.
+type failingTxSource struct {
+	TxSource
+	failed bool
+}
+
+func (s *failingTxSource) StreamTxs(ctx context.Context, start uint64, fn func(TxWrapper) error) error {
+	if s.failed {
+		return s.TxSource.StreamTxs(ctx, start, fn)
+	}
+	s.failed = true
+	return s.TxSource.StreamTxs(ctx, start, func(txWrap TxWrapper) error {
+		if e := fn(txWrap); e != nil {
+			return e
+		}
+		return errors.New("stream failed")
+	})
+}
+
+func TestBlockChain_Replicate(t *testing.T) {
+	primary, closePrimary := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closePrimary()
+
+	replica, closeReplica := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		Replica:      true,
+		ReplicaRetry: 1,
+	})
+	defer closeReplica()
+	require.True(t, replica.IsReplica())
+
+	txWraps := injectGenTxs(t, primary, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- replica.Replicate(ctx, &failingTxSource{TxSource: primary}) }()
+
+	waitFor(t, "replica to catch up", func() bool { return replica.chain.Len() == 2 })
+
+	// Txs accepted by the primary after catching up are replicated.
+	_, err := primary.InjectTx(NewGenTx(2, GenSK))
+	require.NoError(t, err)
+	waitFor(t, "replica to follow", func() bool { return replica.chain.Len() == 3 })
+
+	txWrap, err := replica.GetTxOfSeq(1)
+	require.NoError(t, err)
+	require.Equal(t, txWraps[1], txWrap, "txs should keep the metas of the primary")
+	_, ok := replica.GetKittyState(2)
+	require.True(t, ok, "replicated txs should be applied to the state")
+
+	_, err = replica.InjectTx(NewGenTx(3, GenSK))
+	require.Equal(t, ErrReplica, err)
+	_, err = replica.InjectTxs([]Transaction{*NewGenTx(3, GenSK)})
+	require.Equal(t, ErrReplica, err)
+	require.Equal(t, ErrReplica, replica.SubmitTx(NewGenTx(3, GenSK)))
+
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+}
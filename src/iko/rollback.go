@@ -0,0 +1,165 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrRollbackCheckpoint occurs when rolling back txs of which the state
+	// is verified by a configured checkpoint.
+	ErrRollbackCheckpoint = errors.New("cannot roll back past a checkpoint")
+
+	// ErrRollbackPruned occurs when rolling back txs of a pruned chain, past
+	// the oldest state snapshot from which the state can be restored.
+	ErrRollbackPruned = errors.New("cannot roll back past the oldest state snapshot of a pruned chain")
+)
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// RemoveHeadTx removes the head tx from the chain, and reverts it's changes to
+// the state.
+func (bc *BlockChain) RemoveHeadTx() (TxWrapper, error) {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	if bc.chain.Len() == 0 {
+		return TxWrapper{}, errors.New("no transactions available")
+	}
+	removed, e := bc.rollback(1)
+	if e != nil {
+		return TxWrapper{}, e
+	}
+	return removed[0], nil
+}
+
+// RollbackToSeq removes the txs after seq from the chain, so that the tx of
+// seq is the head, and reverts their changes to the state. The removed txs are
+// returned, starting with the previous head.
+func (bc *BlockChain) RollbackToSeq(seq uint64) ([]TxWrapper, error) {
+	bc.wmux.Lock()
+	defer bc.wmux.Unlock()
+
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	cLen := bc.chain.Len()
+	if seq >= cLen {
+		return nil, fmt.Errorf("tx of seq '%d': %w", seq, ErrTxNotFound)
+	}
+	return bc.rollback(cLen - seq - 1)
+}
+
+// rollback removes 'n' txs from the head of the chain, and rebuilds the state
+// from the newest state snapshot at or below the new height (see
+// 'rollbackBase'), so that only the txs after the snapshot are replayed. If
+// removing a tx fails, the state is rebuilt of the txs that are removed so
+// far. The caller should hold the locks of the BlockChain.
+func (bc *BlockChain) rollback(n uint64) ([]TxWrapper, error) {
+	if _, ok := bc.state.(SnapshotStateDB); !ok {
+		return nil, ErrStateNotSnapshottable
+	}
+	height := bc.chain.Len() - n
+	if cp, ok := bc.latestCheckpoint(); ok && height <= cp.Seq {
+		return nil, ErrRollbackCheckpoint
+	}
+	base, e := bc.rollbackBase(height)
+	if e != nil {
+		return nil, e
+	}
+
+	removed := make([]TxWrapper, 0, n)
+	for uint64(len(removed)) < n {
+		var txWrap TxWrapper
+		if txWrap, e = bc.chain.RemoveHeadTx(); e != nil {
+			break
+		}
+		removed = append(removed, txWrap)
+	}
+	if len(removed) == 0 {
+		return nil, e
+	}
+	bc.txCache.purge()
+	bc.log.
+		WithField("removed", len(removed)).
+		WithField("height", bc.chain.Len()).
+		Warning("rolled back chain")
+
+	bc.removeSnapshotsAbove(bc.chain.Len())
+	if e := bc.restoreState(base); e != nil {
+		return removed, e
+	}
+	return removed, e // error of removing txs, if any
+}
+
+// rollbackBase obtains the newest state snapshot of height at or below
+// 'height' that matches the chain, of the automatic snapshots, the
+// 'StateSnapshotPath' snapshot and the snapshot of the latest checkpoint. An
+// empty snapshot (of which the whole chain is replayed) is returned if there
+// are none, unless the chain is pruned.
+func (bc *BlockChain) rollbackBase(height uint64) (*StateSnapshot, error) {
+	var (
+		base     = new(StateSnapshot)
+		consider = func(snapshot *StateSnapshot, e error) bool {
+			if e != nil || snapshot.Height > height || snapshot.Height <= base.Height {
+				return false
+			}
+			if e := bc.matchSnapshot(snapshot); e != nil {
+				return false
+			}
+			base = snapshot
+			return true
+		}
+	)
+	if dir := bc.c.SnapshotDir; dir != "" {
+		autos, e := listAutoSnapshots(dir)
+		if e != nil {
+			bc.log.WithError(e).Warning("failed to list automatic state snapshots")
+		}
+		for _, auto := range autos {
+			if auto.Height <= height && consider(LoadStateSnapshot(auto.Path)) {
+				break
+			}
+		}
+	}
+	if path := bc.c.StateSnapshotPath; path != "" {
+		consider(LoadStateSnapshot(path))
+	}
+	if cp, ok := bc.latestCheckpoint(); ok && bc.c.CheckpointSnapshotPath != "" {
+		if snapshot, e := LoadStateSnapshot(bc.c.CheckpointSnapshotPath); e == nil &&
+			snapshot.Height == cp.Seq+1 && snapshot.Hash() == cp.StateHash {
+			consider(snapshot, nil)
+		}
+	}
+	if base.Height == 0 && bc.c.ChainMode == PrunedChainMode {
+		return nil, ErrRollbackPruned
+	}
+	return base, nil
+}
+
+// removeSnapshotsAbove removes the automatic snapshots of heights above the
+// height of the chain, as they include removed txs.
+func (bc *BlockChain) removeSnapshotsAbove(height uint64) {
+	if bc.c.SnapshotDir == "" {
+		return
+	}
+	snapshots, e := listAutoSnapshots(bc.c.SnapshotDir)
+	if e != nil {
+		bc.log.WithError(e).Warning("failed to list automatic state snapshots")
+		return
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Height <= height {
+			break
+		}
+		if e := os.Remove(snapshot.Path); e != nil {
+			bc.log.WithError(e).Warning("failed to remove rolled back state snapshot")
+		}
+	}
+}
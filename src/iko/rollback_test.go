@@ -0,0 +1,133 @@
+package iko
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_Rollback(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	var progress []uint64 // of the txs that are done, as the chain is replayed
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK:     GenPK,
+		SnapshotInterval: 2,
+		SnapshotDir:      dir,
+		ProgressFn:       func(done, _ uint64) { progress = append(progress, done) },
+	})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 3)
+	snapshot := bc.state.(SnapshotStateDB).Snapshot()
+
+	pk, _ := cipher.GenerateKeyPair()
+	transfer, err := NewTransferTx(&txWraps[0].Tx, cipher.AddressFromPubKey(pk), GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err)
+
+	t.Run("RollbackToSeq", func(t *testing.T) {
+		_, err := bc.RollbackToSeq(4)
+		require.True(t, errors.Is(err, ErrTxNotFound))
+
+		progress = nil
+		removed, err := bc.RollbackToSeq(2)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2, 3}, progress,
+			"only the tx after the snapshot of height 2 should be replayed")
+		require.Len(t, removed, 1)
+		require.Equal(t, transfer.Hash(), removed[0].Tx.Hash())
+		require.Equal(t, uint64(3), bc.chain.Len())
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot(),
+			"state should be reverted")
+
+		snapshots, err := listAutoSnapshots(dir)
+		require.NoError(t, err)
+		require.Equal(t, []autoSnapshot{{Height: 2, Path: filepath.Join(dir, autoSnapshotName(2))}}, snapshots,
+			"snapshots of removed txs should be removed")
+	})
+
+	t.Run("RemoveHeadTx", func(t *testing.T) {
+		removed, err := bc.RemoveHeadTx()
+		require.NoError(t, err)
+		require.Equal(t, txWraps[2], removed)
+		_, ok := bc.GetKittyState(2)
+		require.False(t, ok, "kitty of removed tx should not exist")
+
+		_, err = bc.InjectTx(&txWraps[2].Tx)
+		require.NoError(t, err, "removed tx should be injectable again")
+		require.Equal(t, snapshot.Kitties, bc.state.(SnapshotStateDB).Snapshot().Kitties)
+	})
+
+	t.Run("Checkpoint", func(t *testing.T) {
+		cp, err := bc.Checkpoint()
+		require.NoError(t, err)
+		bc.c.Checkpoints = []Checkpoint{cp}
+		defer func() { bc.c.Checkpoints = nil }()
+
+		_, err = bc.RemoveHeadTx()
+		require.Equal(t, ErrRollbackCheckpoint, err)
+		require.Equal(t, uint64(3), bc.chain.Len())
+	})
+}
+
+func TestBlockChain_RollbackPruned(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	dir, rmDir := tempLevelDir(t)
+	defer rmDir()
+
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	bc, err := NewBlockChain(context.Background(), &BlockChainConfig{
+		GenerationPK:     GenPK,
+		SnapshotInterval: 2,
+		SnapshotDir:      dir,
+		SnapshotKeep:     2,
+		ChainMode:        PrunedChainMode,
+		PruneRetention:   2,
+	}, chainDB, NewMemoryState())
+	require.NoError(t, err)
+	defer bc.Close()
+
+	// Kitties 1 and 2 are transferred, so their generation txs are pruned.
+	snapshots := make(map[uint64]*StateSnapshot) // of each height
+	inject := func(tx *Transaction) {
+		_, err := bc.InjectTx(tx)
+		require.NoError(t, err)
+		snapshots[bc.chain.Len()] = bc.state.(SnapshotStateDB).Snapshot()
+	}
+	var gens []*Transaction
+	for i := 0; i < 3; i++ {
+		gens = append(gens, NewGenTx(KittyID(i), GenSK))
+		inject(gens[i])
+	}
+	pk, _ := cipher.GenerateKeyPair()
+	for _, gen := range gens[1:] {
+		transfer, err := NewTransferTx(gen, cipher.AddressFromPubKey(pk), GenSK)
+		require.NoError(t, err)
+		inject(transfer)
+	}
+	for i := 3; i < 6; i++ {
+		inject(NewGenTx(KittyID(i), GenSK))
+	}
+	_, err = bc.GetTxOfSeq(1)
+	require.Error(t, err, "spent tx should be pruned")
+
+	removed, err := bc.RollbackToSeq(6)
+	require.NoError(t, err, "state should be restored from the snapshot of height 6")
+	require.Len(t, removed, 1)
+	require.Equal(t, snapshots[7], bc.state.(SnapshotStateDB).Snapshot())
+
+	_, err = bc.RollbackToSeq(4)
+	require.Equal(t, ErrRollbackPruned, err)
+	require.Equal(t, uint64(7), bc.chain.Len(), "no txs should be removed")
+	require.Equal(t, snapshots[7], bc.state.(SnapshotStateDB).Snapshot())
+}
@@ -0,0 +1,136 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+const (
+	// MaxRoyaltyPercent is the maximum royalty of a kitty.
+	MaxRoyaltyPercent = 100
+)
+
+var (
+	// ErrRoyaltyMissing occurs when a transfer tx does not acknowledge the
+	// royalty of it's kitty.
+	ErrRoyaltyMissing = errors.New("tx does not acknowledge the royalty of it's kitty")
+
+	// ErrRoyaltyMismatch occurs when the royalty acknowledged by a transfer
+	// tx is not that registered for it's kitty.
+	ErrRoyaltyMismatch = errors.New("tx royalty does not match the royalty of it's kitty")
+)
+
+// TxRoyalty is the royalty of a tx. Generation txs register 'Percent' as the
+// royalty of the kitty. Subsequent transfers of the kitty acknowledge the
+// royalty with the same 'Percent', along with the 'Price' of each kitty, of
+// which 'Percent' is paid to the creator. As there are no coins on the chain,
+// the payment is made outside of the chain.
+type TxRoyalty struct {
+	Percent uint8
+	Price   uint64 // Sale price of each kitty, zero for generation txs.
+}
+
+// NewTxRoyalty creates the royalty of a transfer, for a kitty with the
+// royalty 'percent' sold at 'price'.
+func NewTxRoyalty(percent uint8, price uint64) *TxRoyalty {
+	return &TxRoyalty{Percent: percent, Price: price}
+}
+
+func (r TxRoyalty) Serialize() []byte {
+	return encoder.Serialize(r)
+}
+
+// Amount returns the royalty paid for each kitty of the tx.
+func (r TxRoyalty) Amount() uint64 {
+	pct := uint64(r.Percent)
+	return r.Price/100*pct + r.Price%100*pct/100
+}
+
+// verifyGen checks the royalty registered by a generation tx.
+func (r TxRoyalty) verifyGen() error {
+	if r.Percent == 0 || r.Percent > MaxRoyaltyPercent {
+		return fmt.Errorf("royalty should be of 1 to %d percent, got %d",
+			MaxRoyaltyPercent, r.Percent)
+	}
+	if r.Price != 0 {
+		return errors.New("generation tx royalty cannot have a price")
+	}
+	return nil
+}
+
+// KittyRoyalty is the royalty registered for a kitty when it is generated.
+type KittyRoyalty struct {
+	Creator cipher.Address // Address that the royalty is paid to.
+	Percent uint8
+}
+
+// IsZero returns true if the kitty has no royalty.
+func (r KittyRoyalty) IsZero() bool {
+	return r == KittyRoyalty{}
+}
+
+// IsRoyaltyTransfer returns true if the tx transfers it's kitties to another
+// owner, and hence should acknowledge their royalties. Burn, metadata, breed
+// and auction txs do not.
+func (tx Transaction) IsRoyaltyTransfer() bool {
+	return !tx.IsBurn() && !tx.IsMetadata() && !tx.IsBreed() && !tx.IsAuction()
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// checkRoyalty checks that a non-generation tx acknowledges the royalties of
+// it's kitties, if it should. Kitties that are not in the state (such as
+// pending kitties) have no royalty.
+func (bc *BlockChain) checkRoyalty(tx *Transaction) error {
+	if bc.isKittyGen(tx) {
+		return nil
+	}
+	if !tx.IsRoyaltyTransfer() {
+		if tx.Royalty != nil {
+			return errors.New("only transfer txs can pay a royalty")
+		}
+		return nil
+	}
+	var acknowledged bool
+	for _, kittyID := range tx.KittyIDs() {
+		kState, ok := bc.state.GetKittyState(kittyID)
+		if !ok || kState.Royalty.IsZero() {
+			continue
+		}
+		if tx.Royalty == nil {
+			return ErrRoyaltyMissing
+		}
+		if tx.Royalty.Percent != kState.Royalty.Percent {
+			return ErrRoyaltyMismatch
+		}
+		acknowledged = true
+	}
+	if tx.Royalty != nil && !acknowledged {
+		return ErrRoyaltyMismatch
+	}
+	return nil
+}
+
+// payRoyalties accrues the royalties of a transfer tx to the creators of it's
+// kitties.
+func (bc *BlockChain) payRoyalties(tx *Transaction) error {
+	if tx.Royalty == nil {
+		return nil
+	}
+	amount := tx.Royalty.Amount()
+	for _, kittyID := range tx.KittyIDs() {
+		kState, ok := bc.state.GetKittyState(kittyID)
+		if !ok || kState.Royalty.IsZero() || amount == 0 {
+			continue
+		}
+		if e := bc.state.AddRoyalty(kState.Royalty.Creator, amount); e != nil {
+			return e
+		}
+	}
+	return nil
+}
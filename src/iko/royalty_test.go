@@ -0,0 +1,80 @@
+package iko
+
+import (
+	"math"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxRoyalty_Amount(t *testing.T) {
+	require.Equal(t, uint64(0), NewTxRoyalty(10, 0).Amount())
+	require.Equal(t, uint64(12), NewTxRoyalty(10, 125).Amount())
+	require.Equal(t, uint64(125), NewTxRoyalty(100, 125).Amount())
+	require.Equal(t, uint64(math.MaxUint64/100*50+15*50/100), NewTxRoyalty(50, math.MaxUint64).Amount(),
+		"amount should not overflow")
+}
+
+func TestBlockChain_Royalty(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 1)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+	genTx := func(kittyID KittyID, percent uint8) (*Transaction, error) {
+		tx := NewUnsignedGenTx(kittyID, bc.CreatorAddress())
+		tx.Royalty = NewTxRoyalty(percent, 0)
+		tx.Sig = tx.Sign(GenSK)
+		_, err := bc.InjectTx(tx)
+		return tx, err
+	}
+	transfer := func(in *Transaction, royalty *TxRoyalty) (*Transaction, error) {
+		tx := NewUnsignedTransferTx(in, addr)
+		tx.Royalty = royalty
+		tx.Sig = tx.Sign(GenSK)
+		_, err := bc.InjectTx(tx)
+		return tx, err
+	}
+
+	_, err := genTx(10, MaxRoyaltyPercent+1)
+	require.Error(t, err, "royalty should be at most 100 percent")
+
+	gen, err := genTx(10, 10)
+	require.NoError(t, err)
+	kState, ok := bc.GetKittyState(10)
+	require.True(t, ok)
+	require.Equal(t, KittyRoyalty{Creator: bc.CreatorAddress(), Percent: 10}, kState.Royalty)
+
+	_, err = transfer(gen, nil)
+	require.Equal(t, ErrRoyaltyMissing, err)
+	_, err = transfer(gen, NewTxRoyalty(5, 1000))
+	require.Equal(t, ErrRoyaltyMismatch, err)
+	_, err = transfer(&txWraps[0].Tx, NewTxRoyalty(10, 1000))
+	require.Equal(t, ErrRoyaltyMismatch, err, "kitty without royalty should not acknowledge one")
+
+	burn := NewUnsignedBurnTx(gen)
+	burn.Royalty = NewTxRoyalty(10, 1000)
+	burn.Sig = burn.Sign(GenSK)
+	_, err = bc.InjectTx(burn)
+	require.Error(t, err, "burn tx should not pay a royalty")
+
+	_, err = transfer(gen, NewTxRoyalty(10, 1000))
+	require.NoError(t, err)
+	_, err = transfer(&txWraps[0].Tx, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(100), bc.GetAddressState(bc.CreatorAddress()).Royalties)
+	require.Equal(t, uint64(0), bc.GetAddressState(addr).Royalties)
+
+	t.Run("Replay", func(t *testing.T) {
+		snapshot := bc.state.(SnapshotStateDB).Snapshot()
+		bc.state = NewMemoryState()
+		require.NoError(t, bc.InitState())
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot(),
+			"replayed state should match")
+	})
+}
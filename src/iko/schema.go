@@ -0,0 +1,87 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/sirupsen/logrus.v1"
+)
+
+const (
+	// ChainSchemaVersion is the version of the on-disk layout of the
+	// persistent chain dbs (bolt, leveldb and sql), which is stored with the
+	// data. Chain dbs of an older version are upgraded when opened, by
+	// applying the migrations of each newer version in order, so that
+	// upgrading the node does not require a re-sync.
+	//
+	// Versions:
+	//  0: Chains created before the schema version was stored.
+	//  1: The tx codec is always stored (see 'migrateRecordCodec').
+	ChainSchemaVersion uint64 = 1
+)
+
+var (
+	// ErrSchemaTooNew occurs when a chain db is of a schema version newer than
+	// 'ChainSchemaVersion', such as after downgrading the node.
+	ErrSchemaTooNew = errors.New("chain db is of a newer schema version")
+)
+
+// migrateSchema upgrades the data of a chain db of schema version 'stored' to
+// 'ChainSchemaVersion'. The migration 'migrations[v]' upgrades the data of
+// version 'v' to 'v+1'. Backends run it within a single write of the db, with
+// the write of the new version, so that a failed migration leaves the data
+// unchanged.
+func migrateSchema(log logrus.FieldLogger, stored uint64, migrations []func() error) error {
+	if stored > ChainSchemaVersion {
+		return fmt.Errorf("%w: %d, the node supports up to %d",
+			ErrSchemaTooNew, stored, ChainSchemaVersion)
+	}
+	if uint64(len(migrations)) != ChainSchemaVersion {
+		return fmt.Errorf("got %d migrations for schema version %d",
+			len(migrations), ChainSchemaVersion)
+	}
+	for v := stored; v < ChainSchemaVersion; v++ {
+		log.WithField("from", v).
+			WithField("to", v+1).
+			Info("migrating chain db schema")
+
+		if e := migrations[v](); e != nil {
+			return fmt.Errorf("failed to migrate chain db to schema version %d: %v", v+1, e)
+		}
+	}
+	return nil
+}
+
+// storedSchemaVersion decodes the stored schema version. Chain dbs without a
+// stored version are of version 0, unless they are new (with no txs nor a
+// stored codec), which are of 'ChainSchemaVersion'.
+func storedSchemaVersion(raw []byte, cLen int, storedCodec []byte) (uint64, error) {
+	if raw == nil {
+		if cLen == 0 && storedCodec == nil {
+			return ChainSchemaVersion, nil
+		}
+		return 0, nil
+	}
+	v, e := strconv.ParseUint(string(raw), 10, 64)
+	if e != nil {
+		return 0, fmt.Errorf("invalid chain db schema version '%s'", raw)
+	}
+	return v, nil
+}
+
+// encodeSchemaVersion encodes the schema version to be stored.
+func encodeSchemaVersion(v uint64) []byte {
+	return []byte(strconv.FormatUint(v, 10))
+}
+
+// migrateRecordCodec returns the codec to store for a chain of schema version
+// 0, or nil if none. Chains created before the codec was stored have txs of the
+// binary codec. The codec need not be stored for a chain with no txs, as it is
+// stored on open.
+func migrateRecordCodec(storedCodec []byte, cLen int) []byte {
+	if storedCodec == nil && cLen > 0 {
+		return []byte(BinaryTxCodecType)
+	}
+	return nil
+}
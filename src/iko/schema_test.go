@@ -0,0 +1,102 @@
+package iko
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestStoredSchemaVersion(t *testing.T) {
+	v, err := storedSchemaVersion(nil, 0, nil)
+	require.NoError(t, err)
+	require.Equal(t, ChainSchemaVersion, v, "new chain should be of the latest version")
+
+	v, err = storedSchemaVersion(nil, 1, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), v, "chain with txs and no version should be of version 0")
+
+	v, err = storedSchemaVersion(encodeSchemaVersion(7), 1, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), v)
+
+	_, err = storedSchemaVersion([]byte("x"), 1, nil)
+	require.Error(t, err)
+}
+
+func TestBoltChain_Migrate(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+
+	chainDB := newBoltChainDB(t, path)
+	for _, txWrap := range genTxWraps(3, 0) {
+		require.NoError(t, chainDB.AddTx(txWrap, addTxAlwaysApprove))
+	}
+	chainDB.Close()
+
+	// Revert the chain to schema version 0, which stored neither the schema
+	// version nor the codec.
+	update := func(f func(meta *bolt.Bucket) error) {
+		db, err := bolt.Open(path, 0600, nil)
+		require.NoError(t, err)
+		require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+			return f(tx.Bucket(boltMetaBucket))
+		}))
+		require.NoError(t, db.Close())
+	}
+	update(func(meta *bolt.Bucket) error {
+		if err := meta.Delete(boltSchemaKey); err != nil {
+			return err
+		}
+		return meta.Delete(boltCodecKey)
+	})
+
+	_, err := NewBoltChain(&BoltChainConfig{Path: path, Codec: CBORTxCodecType})
+	require.Equal(t, ErrCodecMismatch, err, "txs of version 0 should be of the binary codec")
+
+	chainDB = newBoltChainDB(t, path)
+	require.Equal(t, uint64(3), chainDB.Len(), "txs should be kept")
+	require.NoError(t, chainDB.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(boltMetaBucket)
+		require.Equal(t, encodeSchemaVersion(ChainSchemaVersion), meta.Get(boltSchemaKey))
+		require.Equal(t, []byte(BinaryTxCodecType), meta.Get(boltCodecKey))
+		return nil
+	}))
+	chainDB.Close()
+
+	update(func(meta *bolt.Bucket) error {
+		return meta.Put(boltSchemaKey, encodeSchemaVersion(ChainSchemaVersion+1))
+	})
+	_, err = NewBoltChain(&BoltChainConfig{Path: path})
+	require.True(t, errors.Is(err, ErrSchemaTooNew), err)
+}
+
+func TestLevelChain_Migrate(t *testing.T) {
+	dir, rmTemp := tempLevelDir(t)
+	defer rmTemp()
+
+	chainDB := newLevelChainDB(t, dir)
+	for _, txWrap := range genTxWraps(3, 0) {
+		require.NoError(t, chainDB.AddTx(txWrap, addTxAlwaysApprove))
+	}
+	chainDB.Close()
+
+	db, err := leveldb.OpenFile(dir, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Delete(levelSchemaKey, nil))
+	require.NoError(t, db.Delete(levelCodecKey, nil))
+	require.NoError(t, db.Close())
+
+	chainDB = newLevelChainDB(t, dir)
+	defer chainDB.Close()
+
+	require.Equal(t, uint64(3), chainDB.Len(), "txs should be kept")
+	version, err := chainDB.db.Get(levelSchemaKey, nil)
+	require.NoError(t, err)
+	require.Equal(t, encodeSchemaVersion(ChainSchemaVersion), version)
+	codec, err := chainDB.db.Get(levelCodecKey, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte(BinaryTxCodecType), codec)
+}
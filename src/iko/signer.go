@@ -0,0 +1,103 @@
+package iko
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+var (
+	// ErrSignerNotOwner occurs when a transfer tx is constructed with a signer
+	// that does not own the input tx's output address.
+	ErrSignerNotOwner = errors.New("signer does not own input tx address")
+)
+
+// Signer signs transactions. It allows the secret key to be held outside of
+// the process, such as within a hardware wallet or by a remote signer.
+type Signer interface {
+
+	// Address returns the address of the key that the signer signs with.
+	Address() cipher.Address
+
+	// SignHash signs the inner hash of a transaction (see 'HashInner').
+	SignHash(hash cipher.SHA256) (cipher.Sig, error)
+}
+
+// TxSigner is a Signer that signs with knowledge of the whole transaction,
+// such as a remote signer that checks transactions against a policy.
+type TxSigner interface {
+	Signer
+
+	// SignTx signs the transaction, which has an empty signature.
+	SignTx(tx *Transaction) (cipher.Sig, error)
+}
+
+// SecKeySigner signs with an in-process secret key.
+type SecKeySigner cipher.SecKey
+
+func NewSecKeySigner(sk cipher.SecKey) SecKeySigner {
+	return SecKeySigner(sk)
+}
+
+func (s SecKeySigner) Address() cipher.Address {
+	return cipher.AddressFromSecKey(cipher.SecKey(s))
+}
+
+func (s SecKeySigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	return cipher.SignHash(hash, cipher.SecKey(s)), nil
+}
+
+// NewGenTxWithSigner creates a generation transaction, signed by the signer.
+func NewGenTxWithSigner(kittyID KittyID, s Signer) (*Transaction, error) {
+	tx := NewUnsignedGenTx(kittyID, s.Address())
+	if e := tx.SignWith(s); e != nil {
+		return nil, e
+	}
+	return tx, nil
+}
+
+// NewTransferTxWithSigner creates a transfer transaction, signed by the
+// signer. The signer should own the output address of the input tx.
+func NewTransferTxWithSigner(in *Transaction, out cipher.Address, s Signer) (*Transaction, error) {
+	if in.Out != s.Address() {
+		return nil, ErrSignerNotOwner
+	}
+	tx := NewUnsignedTransferTx(in, out)
+	if e := tx.SignWith(s); e != nil {
+		return nil, e
+	}
+	return tx, nil
+}
+
+// SignWith signs the transaction with the signer. As external signers are not
+// trusted to behave, the signature is checked to be of the signer's address
+// (or of a party allowed to settle the tx's escrow).
+func (tx *Transaction) SignWith(s Signer) error {
+	sig, e := tx.signInner(s)
+	if e != nil {
+		return e
+	}
+	tx.Sig = sig
+	if tx.Escrow != nil {
+		if e := tx.VerifyOwner(tx.Escrow.Address()); e != nil {
+			return e
+		}
+		if signer, _ := tx.SignerAddress(); signer != s.Address() {
+			return ErrNotOwner
+		}
+		return nil
+	}
+	return tx.VerifyOwner(s.Address())
+}
+
+// signInner signs the inner hash of the transaction, handing the whole
+// transaction to signers that need it.
+func (tx *Transaction) signInner(s Signer) (cipher.Sig, error) {
+	if ts, ok := s.(TxSigner); ok {
+		unsigned := *tx
+		unsigned.Sig = cipher.Sig{}
+		unsigned.Witness = nil
+		return ts.SignTx(&unsigned)
+	}
+	return s.SignHash(tx.HashInner())
+}
@@ -0,0 +1,54 @@
+package iko
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+// faultySigner signs with a key other than that of it's address.
+type faultySigner struct {
+	SecKeySigner
+	other cipher.SecKey
+}
+
+func (s faultySigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	return cipher.SignHash(hash, s.other), nil
+}
+
+type failingSigner struct {
+	SecKeySigner
+}
+
+func (s failingSigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	return cipher.Sig{}, errors.New("device disconnected")
+}
+
+func TestNewTxWithSigner(t *testing.T) {
+	var (
+		_, sk0 = cipher.GenerateDeterministicKeyPair([]byte("signer 0"))
+		_, sk1 = cipher.GenerateDeterministicKeyPair([]byte("signer 1"))
+		s0     = NewSecKeySigner(sk0)
+		s1     = NewSecKeySigner(sk1)
+	)
+
+	genTx, err := NewGenTxWithSigner(3, s0)
+	require.NoError(t, err)
+	require.NoError(t, genTx.VerifyWith(nil, cipher.PubKeyFromSecKey(sk0)))
+
+	transferTx, err := NewTransferTxWithSigner(genTx, s1.Address(), s0)
+	require.NoError(t, err)
+	require.NoError(t, transferTx.VerifyWith(genTx, cipher.PubKeyFromSecKey(sk0)))
+
+	_, err = NewTransferTxWithSigner(genTx, s1.Address(), s1)
+	require.Equal(t, ErrSignerNotOwner, err)
+
+	_, err = NewGenTxWithSigner(3, faultySigner{SecKeySigner: s0, other: sk1})
+	require.Equal(t, ErrNotOwner, err,
+		"signatures not of the signer's address should be rejected")
+
+	_, err = NewTransferTxWithSigner(genTx, s1.Address(), failingSigner{s0})
+	require.EqualError(t, err, "device disconnected")
+}
@@ -0,0 +1,137 @@
+package iko
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+var (
+	// ErrInvalidSignature occurs when a signature of a tx is malformed, or is
+	// not of the expected signer.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// replayBatchSize is the number of txs of which signatures are verified in
+// parallel at once, while the previous batch is applied to the state.
+const replayBatchSize = 256
+
+// sigKey is a signature of a hash.
+type sigKey struct {
+	Sig  cipher.Sig
+	Hash cipher.SHA256
+}
+
+// verifiedSigs holds the signers of signatures that are already verified by
+// 'verifySigs', so that checking txs does not verify them again. An entry
+// holds for any chain, so the cache is shared by all BlockChains.
+var verifiedSigs sync.Map // map[sigKey]cipher.PubKey
+
+// pubKeyFromSig recovers the signer of the signature, as 'cipher.PubKeyFromSig'.
+// Failures wrap 'ErrInvalidSignature'.
+func pubKeyFromSig(sig cipher.Sig, hash cipher.SHA256) (cipher.PubKey, error) {
+	if pk, ok := verifiedSigs.Load(sigKey{Sig: sig, Hash: hash}); ok {
+		return pk.(cipher.PubKey), nil
+	}
+	pk, e := cipher.PubKeyFromSig(sig, hash)
+	if e != nil {
+		return pk, fmt.Errorf("%w: %v", ErrInvalidSignature, e)
+	}
+	return pk, nil
+}
+
+// verifySignature checks the signature of pk, as 'cipher.VerifySignature'.
+// Failures wrap 'ErrInvalidSignature'.
+func verifySignature(pk cipher.PubKey, sig cipher.Sig, hash cipher.SHA256) error {
+	if signer, ok := verifiedSigs.Load(sigKey{Sig: sig, Hash: hash}); ok && signer.(cipher.PubKey) == pk {
+		return nil
+	}
+	if e := cipher.VerifySignature(pk, sig, hash); e != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, e)
+	}
+	return nil
+}
+
+// txSigs returns the signatures of the tx, along with the signed hash.
+func txSigs(tx *Transaction) []sigKey {
+	var (
+		hash = tx.HashInner()
+		out  []sigKey
+	)
+	if tx.Sig != (cipher.Sig{}) {
+		out = append(out, sigKey{Sig: tx.Sig, Hash: hash})
+	}
+	if tx.Witness != nil {
+		for _, ms := range tx.Witness.Sigs {
+			out = append(out, sigKey{Sig: ms.Sig, Hash: hash})
+		}
+	}
+	return out
+}
+
+// verifySigs verifies the signatures of the txs across 'workers' goroutines,
+// caching the signers of valid signatures until 'forgetSigs'. Invalid
+// signatures are not cached, so that checking their txs fails as usual.
+func verifySigs(txWraps []TxWrapper, workers int) {
+	var (
+		jobs = make(chan sigKey)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				pk, e := cipher.PubKeyFromSig(key.Sig, key.Hash)
+				if e != nil || cipher.VerifySignature(pk, key.Sig, key.Hash) != nil {
+					continue
+				}
+				verifiedSigs.Store(key, pk)
+			}
+		}()
+	}
+	for i := range txWraps {
+		for _, key := range txSigs(&txWraps[i].Tx) {
+			jobs <- key
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// forgetSigs removes the signatures of the txs from the cache.
+func forgetSigs(txWraps []TxWrapper) {
+	for i := range txWraps {
+		for _, key := range txSigs(&txWraps[i].Tx) {
+			verifiedSigs.Delete(key)
+		}
+	}
+}
+
+// replayBatch is a batch of txs of which signatures are verified.
+type replayBatch struct {
+	txWraps []TxWrapper
+	err     error
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// loadReplayBatch reads the txs of seqs [start, end) and verifies their
+// signatures in parallel.
+func (bc *BlockChain) loadReplayBatch(start, end uint64) replayBatch {
+	b := replayBatch{txWraps: make([]TxWrapper, 0, end-start)}
+	for seq := start; seq < end; seq++ {
+		txWrap, e := bc.chain.GetTxOfSeq(seq)
+		if e != nil {
+			b.err = e
+			break
+		}
+		b.txWraps = append(b.txWraps, txWrap)
+	}
+	verifySigs(b.txWraps, bc.c.VerifyWorkers)
+	return b
+}
@@ -0,0 +1,66 @@
+package iko
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func cachedSigCount() int {
+	var n int
+	verifiedSigs.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func TestVerifySigs(t *testing.T) {
+	valid := NewGenTx(1, GenSK)
+	invalid := NewGenTx(2, GenSK)
+	for i := range invalid.Sig[:64] {
+		invalid.Sig[i] = 0xff // signer cannot be recovered
+	}
+
+	txWraps := []TxWrapper{{Tx: *valid}, {Tx: *invalid}}
+	verifySigs(txWraps, 2)
+
+	_, ok := verifiedSigs.Load(sigKey{Sig: valid.Sig, Hash: valid.HashInner()})
+	require.True(t, ok, "valid signature should be cached")
+	_, ok = verifiedSigs.Load(sigKey{Sig: invalid.Sig, Hash: invalid.HashInner()})
+	require.False(t, ok, "invalid signature should not be cached")
+	require.NoError(t, verifySignature(GenPK, valid.Sig, valid.HashInner()))
+	require.True(t, errors.Is(verifySignature(GenPK, invalid.Sig, invalid.HashInner()), ErrInvalidSignature))
+
+	forgetSigs(txWraps)
+	require.Zero(t, cachedSigCount())
+}
+
+func TestBlockChain_ParallelReplay(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK:  GenPK,
+		VerifyWorkers: 4,
+	})
+	defer closeBC()
+
+	txs := make([]Transaction, replayBatchSize*2+10)
+	for i := range txs {
+		txs[i] = *NewGenTx(KittyID(i), GenSK)
+	}
+	_, err := bc.InjectTxs(txs)
+	require.NoError(t, err)
+	pk, _ := cipher.GenerateKeyPair()
+	transfer, err := NewTransferTx(&txs[0], cipher.AddressFromPubKey(pk), GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err)
+
+	snapshot := bc.state.(SnapshotStateDB).Snapshot()
+	bc.state = NewMemoryState()
+	require.NoError(t, bc.InitState())
+	require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot(),
+		"replayed state should match")
+	require.Zero(t, cachedSigCount(), "verified signatures should be forgotten")
+}
@@ -0,0 +1,168 @@
+package iko
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// DefaultSnapshotKeep is the default number of automatic state snapshots
+	// that are kept.
+	DefaultSnapshotKeep = 3
+)
+
+// autoSnapshot is an automatic state snapshot file of 'SnapshotDir'.
+type autoSnapshot struct {
+	Height uint64
+	Path   string
+}
+
+// autoSnapshotName returns the file name of the automatic snapshot of height,
+// which is zero padded so that names sort by height.
+func autoSnapshotName(height uint64) string {
+	return fmt.Sprintf("state-%020d.snapshot", height)
+}
+
+// listAutoSnapshots lists the automatic snapshots of the directory, newest
+// first. Files that are not automatic snapshots are ignored.
+func listAutoSnapshots(dir string) ([]autoSnapshot, error) {
+	infos, e := ioutil.ReadDir(dir)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return nil, nil
+		}
+		return nil, e
+	}
+	var out []autoSnapshot
+	for _, info := range infos {
+		var height uint64
+		if _, e := fmt.Sscanf(info.Name(), "state-%d.snapshot", &height); e != nil ||
+			info.Name() != autoSnapshotName(height) {
+			continue
+		}
+		out = append(out, autoSnapshot{
+			Height: height,
+			Path:   filepath.Join(dir, info.Name()),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Height > out[j].Height
+	})
+	return out, nil
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// snapshotState obtains a snapshot of the current state at the height of the
+// chain. The caller should hold the locks of the BlockChain.
+func (bc *BlockChain) snapshotState() (*StateSnapshot, error) {
+	ss, ok := bc.state.(SnapshotStateDB)
+	if !ok {
+		return nil, ErrStateNotSnapshottable
+	}
+	snapshot := ss.Snapshot()
+	snapshot.Height = bc.chain.Len()
+	if snapshot.Height > 0 {
+		head, e := bc.chain.GetTxOfSeq(snapshot.Height - 1)
+		if e != nil {
+			return nil, e
+		}
+		snapshot.HeadHash = head.Tx.Hash()
+	}
+	return snapshot, nil
+}
+
+// autoSnapshotState saves an automatic snapshot to 'SnapshotDir' if the chain
+// has passed a multiple of 'SnapshotInterval' since it was of height
+// 'prevLen'. Older snapshots beyond 'SnapshotKeep' are removed. Failures are
+// logged, as the txs are already committed. The caller should hold the locks
+// of the BlockChain.
+func (bc *BlockChain) autoSnapshotState(prevLen uint64) {
+	interval := bc.c.SnapshotInterval
+	if interval == 0 || bc.chain.Len()/interval == prevLen/interval {
+		return
+	}
+	snapshot, e := bc.snapshotState()
+	if e != nil {
+		bc.log.WithError(e).Error("failed to take automatic state snapshot")
+		return
+	}
+	path := filepath.Join(bc.c.SnapshotDir, autoSnapshotName(snapshot.Height))
+	if e := SaveStateSnapshot(path, snapshot); e != nil {
+		bc.log.WithError(e).Error("failed to save automatic state snapshot")
+		return
+	}
+	bc.log.
+		WithField("height", snapshot.Height).
+		Info("saved automatic state snapshot")
+
+	snapshots, e := listAutoSnapshots(bc.c.SnapshotDir)
+	if e != nil {
+		bc.log.WithError(e).Warning("failed to list automatic state snapshots")
+		return
+	}
+	for i := bc.c.SnapshotKeep; i < len(snapshots); i++ {
+		if e := os.Remove(snapshots[i].Path); e != nil {
+			bc.log.WithError(e).Warning("failed to remove old state snapshot")
+		}
+	}
+	if len(snapshots) > bc.c.SnapshotKeep {
+		snapshots = snapshots[:bc.c.SnapshotKeep]
+	}
+	if len(snapshots) > 0 {
+		bc.pruneTxs(snapshots[len(snapshots)-1].Height)
+	}
+}
+
+// restoreNewestSnapshot restores the state from the newest valid snapshot of
+// 'StateSnapshotPath' and 'SnapshotDir', trying older snapshots if newer ones
+// are corrupt or do not match the chain. It returns false if no snapshot
+// could be restored.
+func (bc *BlockChain) restoreNewestSnapshot() bool {
+	var (
+		closing, closingErr = loadStateSnapshotOf(bc.c.StateSnapshotPath)
+		autos               []autoSnapshot
+	)
+	if dir := bc.c.SnapshotDir; dir != "" {
+		var e error
+		if autos, e = listAutoSnapshots(dir); e != nil {
+			bc.log.WithError(e).Warning("failed to list automatic state snapshots")
+		}
+	}
+	restore := func(snapshot *StateSnapshot, e error) bool {
+		if e == nil {
+			e = bc.restoreState(snapshot)
+		}
+		if e == nil {
+			return true
+		}
+		bc.resetState(e, "failed to restore state snapshot")
+		return false
+	}
+	for _, auto := range autos {
+		if closing != nil && closing.Height >= auto.Height {
+			if restore(closing, nil) {
+				return true
+			}
+			closing = nil
+		}
+		if restore(LoadStateSnapshot(auto.Path)) {
+			return true
+		}
+	}
+	return (closing != nil || closingErr != nil) && restore(closing, closingErr)
+}
+
+// loadStateSnapshotOf loads the snapshot of path, or returns nil if the path
+// is empty.
+func loadStateSnapshotOf(path string) (*StateSnapshot, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return LoadStateSnapshot(path)
+}
@@ -0,0 +1,64 @@
+package iko
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_AutoSnapshot(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	dir := filepath.Join(filepath.Dir(path), "snapshots")
+
+	chainDB := newBoltChainDB(t, path)
+	defer chainDB.Close()
+
+	config := &BlockChainConfig{
+		GenerationPK:     GenPK,
+		SnapshotInterval: 2,
+		SnapshotDir:      dir,
+		SnapshotKeep:     2,
+	}
+	bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+	require.NoError(t, err)
+	injectGenTxs(t, bc, 5)
+	errs, err := bc.InjectTxs([]Transaction{*NewGenTx(5, GenSK), *NewGenTx(6, GenSK)})
+	require.NoError(t, err)
+	require.Equal(t, []error{nil, nil}, errs)
+	snapshot := bc.state.(SnapshotStateDB).Snapshot()
+	bc.Close()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0600))
+	snapshots, err := listAutoSnapshots(dir)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2, "only the newest snapshots should be kept")
+	require.Equal(t, uint64(7), snapshots[0].Height, "batches should be snapshotted once written")
+	require.Equal(t, uint64(4), snapshots[1].Height)
+
+	t.Run("Restore", func(t *testing.T) {
+		bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err)
+		defer bc.Close()
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot())
+	})
+
+	t.Run("Corrupt", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(snapshots[0].Path, []byte("corrupt"), 0600))
+
+		bc, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err, "older snapshot should be restored")
+		defer bc.Close()
+		require.Equal(t, snapshot, bc.state.(SnapshotStateDB).Snapshot())
+
+		require.NoError(t, ioutil.WriteFile(snapshots[1].Path, []byte("corrupt"), 0600))
+
+		bc2, err := NewBlockChain(context.Background(), config, chainDB, NewMemoryState())
+		require.NoError(t, err, "chain should be replayed")
+		defer bc2.Close()
+		require.Equal(t, snapshot, bc2.state.(SnapshotStateDB).Snapshot())
+	})
+}
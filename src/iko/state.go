@@ -1,10 +1,26 @@
 package iko
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+var (
+	// ErrKittyNotFound occurs when a kitty of the requested ID does not exist
+	// in the state.
+	ErrKittyNotFound = errors.New("kitty does not exist")
+
+	// ErrKittyAlreadyExists occurs when adding a kitty of which the ID is
+	// already taken in the state.
+	ErrKittyAlreadyExists = errors.New("kitty already exists")
 )
 
 // StateDB records the state of the blockchain.
@@ -40,34 +56,226 @@ type StateDB interface {
 	//		- kitty of specified ID does not exist.
 	//		- kitty of specified ID does not originally belong to the 'from' address.
 	MoveKitty(tx TxHash, kittyID KittyID, from, to cipher.Address) error
+
+	// MoveKitties moves multiple kitties from one address to another, in a
+	// single tx. It should fail (without moving any kitties) under the same
+	// conditions as 'MoveKitty', or if a kitty ID is repeated.
+	MoveKitties(tx TxHash, kittyIDs []KittyID, from, to cipher.Address) error
+
+	// BurnKitties retires kitties of the 'from' address, so that they are
+	// under 'BurnAddress' and marked as burned. It should fail (without
+	// burning any kitties) under the same conditions as 'MoveKitties'.
+	BurnKitties(tx TxHash, kittyIDs []KittyID, from cipher.Address) error
+
+	// SetKittyMetadata sets the metadata of a kitty, without changing it's
+	// owner. The tx is recorded for both the kitty and it's owner.
+	// This should fail if:
+	//		- kitty of specified ID does not exist.
+	//		- kitty of specified ID is burned.
+	SetKittyMetadata(tx TxHash, kittyID KittyID, metadata KittyMetadata) error
+
+	// BreedKitties adds the child of the parent kitties under the 'owner'
+	// address, with the metadata and cooldown given. The cooldown of the
+	// parents is also set, and the tx is recorded for the parents, the child
+	// and the owner.
+	// This should fail (without changing the state) if:
+	//		- a parent kitty does not exist, or is repeated.
+	//		- a parent kitty does not belong to the 'owner' address.
+	//		- kitty of the child ID already exists in state.
+	BreedKitties(tx TxHash, parentIDs []KittyID, childID KittyID, owner cipher.Address, child KittyMetadata, cooldownSeq uint64) error
+
+	// ListKitty moves a kitty from the seller of the listing to the address
+	// of the listing, and records the listing as the kitty's auction.
+	// It should fail under the same conditions as 'MoveKitty'.
+	ListKitty(tx TxHash, kittyID KittyID, listing Listing) error
+
+	// BidKitty records the bid as the highest bid of the kitty's auction. The
+	// tx is recorded for the kitty, the address of the listing and the bidder.
+	// This should fail if:
+	//		- kitty of specified ID does not exist.
+	//		- kitty of specified ID is not listed.
+	BidKitty(tx TxHash, kittyID KittyID, bidder cipher.Address, bid uint64) error
+
+	// CloseAuction moves a listed kitty from the address of the listing to the
+	// 'to' address, and clears the kitty's auction. It should fail under the
+	// same conditions as 'BidKitty'.
+	CloseAuction(tx TxHash, kittyID KittyID, to cipher.Address) error
+
+	// GetAuctions obtains the auction state of listed kitties, in ascending
+	// order of kitty ID.
+	GetAuctions() []AuctionEntry
+
+	// AddFee records a fee paid by the address, which is accumulated in the
+	// 'FeesPaid' of the address state.
+	AddFee(address cipher.Address, fee uint64) error
+
+	// SetKittyRoyalty sets the royalty of a kitty, as registered by it's
+	// generation tx. This should fail if the kitty does not exist.
+	SetKittyRoyalty(kittyID KittyID, royalty KittyRoyalty) error
+
+	// AddRoyalty records a royalty accrued to the creator address, which is
+	// accumulated in the 'Royalties' of the address state.
+	AddRoyalty(creator cipher.Address, amount uint64) error
+
+	// GetCreators obtains the creator keys set by the last rotation tx, or nil
+	// if there were none.
+	GetCreators() []cipher.PubKey
+
+	// SetCreators replaces the creator keys, as done by a rotation tx. This
+	// increments the creator epoch.
+	SetCreators(pks []cipher.PubKey) error
+
+	// RevokeCreators replaces the creator keys with the remaining 'creators',
+	// and records the 'revoked' keys, as done by an admin tx. This increments
+	// the creator epoch.
+	RevokeCreators(creators, revoked []cipher.PubKey) error
+
+	// GetCreatorEpoch obtains the number of times the creator keys were
+	// replaced.
+	GetCreatorEpoch() uint64
+
+	// IsCreatorRevoked returns true if the key was revoked by an admin tx.
+	IsCreatorRevoked(pk cipher.PubKey) bool
+
+	// FreezeGeneration permanently freezes the generation of kitties, as done
+	// by an admin tx.
+	FreezeGeneration() error
+
+	// IsGenerationFrozen returns true if the generation of kitties is frozen.
+	IsGenerationFrozen() bool
+
+	// Fork obtains a copy-on-write overlay of the state, to which txs can be
+	// applied (such as to evaluate them) without changing the state. The state
+	// should not be changed while the fork is in use.
+	Fork() StateDB
+}
+
+// SnapshotStateDB is a StateDB that can be persisted to disk via a
+// StateSnapshot, so that it does not need to be rebuilt from the whole chain.
+type SnapshotStateDB interface {
+	StateDB
+
+	// Snapshot obtains a copy of the state.
+	// The 'Height' and 'HeadHash' fields are left for the caller to fill.
+	Snapshot() *StateSnapshot
+
+	// Restore replaces the state with that of the snapshot.
+	Restore(snapshot *StateSnapshot)
+}
+
+type KittyStateEntry struct {
+	KittyID KittyID
+	State   KittyState
+}
+
+type AddressStateEntry struct {
+	Address cipher.Address
+	State   AddressState
 }
 
+// StateSnapshot is a serializable copy of a StateDB at a given chain height.
+type StateSnapshot struct {
+	Height    uint64 // Number of transactions applied to the state.
+	HeadHash  TxHash // Hash of tx of seq 'Height-1'.
+	Kitties   []KittyStateEntry
+	Addresses []AddressStateEntry
+	Creators  []cipher.PubKey // Creator keys of the last rotation or admin tx, if any.
+	Revoked   []cipher.PubKey // Creator keys revoked by admin txs.
+	Frozen    bool            // Whether kitty generation is frozen.
+	Epoch     uint64          // Number of times the creator keys were replaced.
+}
+
+func (s StateSnapshot) Serialize() []byte {
+	return encoder.Serialize(s)
+}
+
+// SaveStateSnapshot writes the snapshot to the file of path. The snapshot is
+// first written to a temporary file, so that a crash never leaves a partially
+// written snapshot.
+func SaveStateSnapshot(path string, snapshot *StateSnapshot) error {
+	if e := os.MkdirAll(filepath.Dir(path), os.FileMode(0700)); e != nil {
+		return e
+	}
+	tempPath := path + ".tmp"
+	if e := ioutil.WriteFile(tempPath, snapshot.Serialize(), 0600); e != nil {
+		return e
+	}
+	return os.Rename(tempPath, path)
+}
+
+// LoadStateSnapshot reads a snapshot from the file of path.
+func LoadStateSnapshot(path string) (*StateSnapshot, error) {
+	raw, e := ioutil.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	snapshot := new(StateSnapshot)
+	if e := encoder.DeserializeRaw(raw, snapshot); e != nil {
+		return nil, e
+	}
+	return snapshot, nil
+}
+
+// MemoryState is a StateDB of memory. A MemoryState may be a fork of another
+// (see 'Fork'), in which case the kitty and address states that the fork has
+// not changed are read from it's parent.
 type MemoryState struct {
 	sync.Mutex
+	parent    *MemoryState // of forks, nil otherwise
 	kitties   map[KittyID]*KittyState
 	addresses map[cipher.Address]*AddressState
+	auctions  map[KittyID]struct{} // listed kitties
+	creators  []cipher.PubKey
+	revoked   []cipher.PubKey
+	epoch     uint64 // creator epoch
+	frozen    bool
 }
 
 func NewMemoryState() *MemoryState {
 	return &MemoryState{
 		kitties:   make(map[KittyID]*KittyState),
 		addresses: make(map[cipher.Address]*AddressState),
+		auctions:  make(map[KittyID]struct{}),
 	}
 }
 
+// Fork obtains a copy-on-write overlay of the state, to which txs can be
+// applied (such as to evaluate them) without changing the state. Kitty and
+// address states are only copied from the state as the fork changes them, so
+// forking is cheap. The state should not be changed while the fork is in use,
+// as the fork reads the states it has not changed from it.
+func (s *MemoryState) Fork() StateDB {
+	s.Lock()
+	defer s.Unlock()
+
+	fork := &MemoryState{
+		parent:    s,
+		kitties:   make(map[KittyID]*KittyState),
+		addresses: make(map[cipher.Address]*AddressState),
+		auctions:  make(map[KittyID]struct{}, len(s.auctions)),
+		creators:  append([]cipher.PubKey(nil), s.creators...),
+		revoked:   append([]cipher.PubKey(nil), s.revoked...),
+		epoch:     s.epoch,
+		frozen:    s.frozen,
+	}
+	for kittyID := range s.auctions {
+		fork.auctions[kittyID] = struct{}{}
+	}
+	return fork
+}
+
 func (s *MemoryState) GetKittyState(kittyID KittyID) (*KittyState, bool) {
 	s.Lock()
 	defer s.Unlock()
 
-	kState, ok := s.kitties[kittyID]
-	return kState, ok
+	return s.kitty(kittyID)
 }
 
 func (s *MemoryState) GetKittyUnspentTx(kittyID KittyID) (TxHash, bool) {
 	s.Lock()
 	defer s.Unlock()
 
-	kState, ok := s.kitties[kittyID]
+	kState, ok := s.kitty(kittyID)
 	if !ok {
 		return EmptyTxHash(), ok
 	}
@@ -75,11 +283,11 @@ func (s *MemoryState) GetKittyUnspentTx(kittyID KittyID) (TxHash, bool) {
 	return kState.Transactions[len(kState.Transactions)-1], true
 }
 
-func (s MemoryState) GetAddressState(address cipher.Address) *AddressState {
+func (s *MemoryState) GetAddressState(address cipher.Address) *AddressState {
 	s.Lock()
 	defer s.Unlock()
 
-	aState, ok := s.addresses[address]
+	aState, ok := s.address(address)
 	if !ok {
 		aState = NewAddressState()
 	}
@@ -90,9 +298,9 @@ func (s *MemoryState) AddKitty(tx TxHash, kittyID KittyID, address cipher.Addres
 	s.Lock()
 	defer s.Unlock()
 
-	if _, ok := s.kitties[kittyID]; ok {
-		return fmt.Errorf("kitty of id '%d' already exists",
-			kittyID)
+	if _, ok := s.kitty(kittyID); ok {
+		return fmt.Errorf("kitty of id '%d': %w",
+			kittyID, ErrKittyAlreadyExists)
 	}
 
 	if kState, ok := s.kitties[kittyID]; !ok {
@@ -105,7 +313,7 @@ func (s *MemoryState) AddKitty(tx TxHash, kittyID KittyID, address cipher.Addres
 		kState.Transactions = append(kState.Transactions, tx)
 	}
 
-	if aState, ok := s.addresses[address]; !ok {
+	if aState, ok := s.writeAddress(address); !ok {
 		s.addresses[address] = &AddressState{
 			Kitties:      KittyIDs{kittyID},
 			Transactions: TxHashes{tx},
@@ -119,43 +327,519 @@ func (s *MemoryState) AddKitty(tx TxHash, kittyID KittyID, address cipher.Addres
 }
 
 func (s *MemoryState) MoveKitty(tx TxHash, kittyID KittyID, from, to cipher.Address) error {
+	return s.MoveKitties(tx, KittyIDs{kittyID}, from, to)
+}
+
+func (s *MemoryState) MoveKitties(tx TxHash, kittyIDs []KittyID, from, to cipher.Address) error {
 	s.Lock()
 	defer s.Unlock()
 
-	if from == to {
-		return fmt.Errorf("kitty of id '%d' already belongs to address '%s'",
-			kittyID, from)
+	if e := s.checkKitties(kittyIDs, from, to); e != nil {
+		return e
+	}
+	s.moveKitties(tx, kittyIDs, from, to)
+	return nil
+}
+
+// moveKitties moves kitties that are checked with 'checkKitties'.
+func (s *MemoryState) moveKitties(tx TxHash, kittyIDs []KittyID, from, to cipher.Address) {
+	for _, kittyID := range kittyIDs {
+		kState, _ := s.writeKitty(kittyID)
+		kState.Address = to
+		kState.Transactions = append(kState.Transactions, tx)
+	}
+
+	s.removeFrom(tx, kittyIDs, from)
 
-	} else if kState, ok := s.kitties[kittyID]; !ok {
-		return fmt.Errorf("kitty of id '%d' does not exist",
-			kittyID)
+	toState, ok := s.writeAddress(to)
+	if !ok {
+		toState = NewAddressState()
+		s.addresses[to] = toState
+	}
+	for _, kittyID := range kittyIDs {
+		toState.Kitties.Add(kittyID)
+	}
+	toState.Transactions = append(toState.Transactions, tx)
+}
 
-	} else if kState.Address != from {
-		return fmt.Errorf("kitty of id '%d' does not belong to address '%s'",
-			kittyID, from)
+func (s *MemoryState) BurnKitties(tx TxHash, kittyIDs []KittyID, from cipher.Address) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if e := s.checkKitties(kittyIDs, from, BurnAddress); e != nil {
+		return e
 	}
 
-	kState := s.kitties[kittyID]
-	kState.Address = to
-	kState.Transactions = append(kState.Transactions, tx)
+	for _, kittyID := range kittyIDs {
+		kState, _ := s.writeKitty(kittyID)
+		kState.Address = BurnAddress
+		kState.Transactions = append(kState.Transactions, tx)
+		kState.Burned = true
+	}
+
+	s.removeFrom(tx, kittyIDs, from)
+	return nil
+}
+
+// checkKitties checks that the kitties can be moved from the 'from' address
+// to the 'to' address.
+func (s *MemoryState) checkKitties(kittyIDs []KittyID, from, to cipher.Address) error {
+	seen := make(map[KittyID]struct{}, len(kittyIDs))
+	for _, kittyID := range kittyIDs {
+		if from == to {
+			return fmt.Errorf("kitty of id '%d' already belongs to address '%s'",
+				kittyID, from)
+
+		} else if kState, ok := s.kitty(kittyID); !ok {
+			return fmt.Errorf("kitty of id '%d': %w",
+				kittyID, ErrKittyNotFound)
+
+		} else if kState.Address != from {
+			return fmt.Errorf("kitty of id '%d' does not belong to address '%s': %w",
+				kittyID, from, ErrNotOwner)
+
+		} else if _, ok := seen[kittyID]; ok {
+			return fmt.Errorf("kitty of id '%d' is repeated",
+				kittyID)
+		}
+		seen[kittyID] = struct{}{}
+	}
+	return nil
+}
 
-	if fromState, ok := s.addresses[from]; !ok {
+// removeFrom removes the kitties from the state of the 'from' address.
+func (s *MemoryState) removeFrom(tx TxHash, kittyIDs []KittyID, from cipher.Address) {
+	if fromState, ok := s.writeAddress(from); !ok {
 		panic(fmt.Errorf(
 			"state of 'from' address '%s' does not exist in state",
 			from.String()))
 	} else {
-		fromState.Kitties.Remove(kittyID)
+		for _, kittyID := range kittyIDs {
+			fromState.Kitties.Remove(kittyID)
+		}
 		fromState.Transactions = append(fromState.Transactions, tx)
 	}
+}
 
-	if toState, ok := s.addresses[to]; !ok {
-		s.addresses[to] = &AddressState{
-			Kitties:      KittyIDs{kittyID},
-			Transactions: TxHashes{tx},
+func (s *MemoryState) SetKittyMetadata(tx TxHash, kittyID KittyID, metadata KittyMetadata) error {
+	s.Lock()
+	defer s.Unlock()
+
+	kState, ok := s.kitty(kittyID)
+	if !ok {
+		return fmt.Errorf("kitty of id '%d': %w",
+			kittyID, ErrKittyNotFound)
+	}
+	if kState.Burned {
+		return ErrKittyBurned
+	}
+	kState, _ = s.writeKitty(kittyID)
+	kState.Metadata = metadata
+	kState.Transactions = append(kState.Transactions, tx)
+
+	if aState, ok := s.writeAddress(kState.Address); ok {
+		aState.Transactions = append(aState.Transactions, tx)
+	}
+	return nil
+}
+
+func (s *MemoryState) BreedKitties(tx TxHash, parentIDs []KittyID, childID KittyID, owner cipher.Address, child KittyMetadata, cooldownSeq uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if e := s.checkKitties(parentIDs, owner, BurnAddress); e != nil {
+		return e
+	}
+	if _, ok := s.kitty(childID); ok {
+		return fmt.Errorf("kitty of id '%d': %w",
+			childID, ErrKittyAlreadyExists)
+	}
+
+	for _, kittyID := range parentIDs {
+		kState, _ := s.writeKitty(kittyID)
+		kState.Transactions = append(kState.Transactions, tx)
+		kState.CooldownSeq = cooldownSeq
+	}
+	s.kitties[childID] = &KittyState{
+		Address:      owner,
+		Transactions: TxHashes{tx},
+		Metadata:     child,
+		CooldownSeq:  cooldownSeq,
+	}
+
+	aState, _ := s.writeAddress(owner)
+	aState.Kitties.Add(childID)
+	aState.Transactions = append(aState.Transactions, tx)
+	return nil
+}
+
+func (s *MemoryState) ListKitty(tx TxHash, kittyID KittyID, listing Listing) error {
+	s.Lock()
+	defer s.Unlock()
+
+	kittyIDs := KittyIDs{kittyID}
+	if e := s.checkKitties(kittyIDs, listing.Seller, listing.Address()); e != nil {
+		return e
+	}
+	s.moveKitties(tx, kittyIDs, listing.Seller, listing.Address())
+	s.kitties[kittyID].Auction = AuctionState{Listing: listing}
+	s.auctions[kittyID] = struct{}{}
+	return nil
+}
+
+func (s *MemoryState) BidKitty(tx TxHash, kittyID KittyID, bidder cipher.Address, bid uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	kState, e := s.listedKitty(kittyID)
+	if e != nil {
+		return e
+	}
+	kState.Auction.Bidder = bidder
+	kState.Auction.Bid = bid
+	kState.Transactions = append(kState.Transactions, tx)
+
+	listingState, _ := s.writeAddress(kState.Address)
+	listingState.Transactions = append(listingState.Transactions, tx)
+
+	bidderState, ok := s.writeAddress(bidder)
+	if !ok {
+		bidderState = NewAddressState()
+		s.addresses[bidder] = bidderState
+	}
+	bidderState.Transactions = append(bidderState.Transactions, tx)
+	return nil
+}
+
+func (s *MemoryState) CloseAuction(tx TxHash, kittyID KittyID, to cipher.Address) error {
+	s.Lock()
+	defer s.Unlock()
+
+	kState, e := s.listedKitty(kittyID)
+	if e != nil {
+		return e
+	}
+	s.moveKitties(tx, KittyIDs{kittyID}, kState.Address, to)
+	kState.Auction = AuctionState{}
+	delete(s.auctions, kittyID)
+	return nil
+}
+
+// listedKitty obtains the state of a listed kitty, to be changed.
+func (s *MemoryState) listedKitty(kittyID KittyID) (*KittyState, error) {
+	kState, ok := s.kitty(kittyID)
+	if !ok {
+		return nil, fmt.Errorf("kitty of id '%d': %w",
+			kittyID, ErrKittyNotFound)
+	}
+	if kState.Auction.IsZero() {
+		return nil, fmt.Errorf("kitty of id '%d' is not listed",
+			kittyID)
+	}
+	kState, _ = s.writeKitty(kittyID)
+	return kState, nil
+}
+
+func (s *MemoryState) GetAuctions() []AuctionEntry {
+	s.Lock()
+	defer s.Unlock()
+
+	out := make([]AuctionEntry, 0, len(s.auctions))
+	for kittyID := range s.auctions {
+		kState, _ := s.kitty(kittyID)
+		out = append(out, AuctionEntry{
+			KittyID: kittyID,
+			State:   kState.Auction,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].KittyID < out[j].KittyID
+	})
+	return out
+}
+
+func (s *MemoryState) AddFee(address cipher.Address, fee uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	aState, ok := s.writeAddress(address)
+	if !ok {
+		return fmt.Errorf("address '%s' does not exist in state",
+			address.String())
+	}
+	aState.FeesPaid += fee
+	return nil
+}
+
+func (s *MemoryState) SetKittyRoyalty(kittyID KittyID, royalty KittyRoyalty) error {
+	s.Lock()
+	defer s.Unlock()
+
+	kState, ok := s.writeKitty(kittyID)
+	if !ok {
+		return fmt.Errorf("kitty of id '%d': %w",
+			kittyID, ErrKittyNotFound)
+	}
+	kState.Royalty = royalty
+	return nil
+}
+
+func (s *MemoryState) AddRoyalty(creator cipher.Address, amount uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	aState, ok := s.writeAddress(creator)
+	if !ok {
+		aState = NewAddressState()
+		s.addresses[creator] = aState
+	}
+	aState.Royalties += amount
+	return nil
+}
+
+func (s *MemoryState) GetCreators() []cipher.PubKey {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.creators) == 0 {
+		return nil
+	}
+	return append([]cipher.PubKey{}, s.creators...)
+}
+
+func (s *MemoryState) SetCreators(pks []cipher.PubKey) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(pks) == 0 {
+		return errors.New("creator keys should not be empty")
+	}
+	s.creators = append([]cipher.PubKey{}, pks...)
+	s.epoch++
+	return nil
+}
+
+func (s *MemoryState) RevokeCreators(creators, revoked []cipher.PubKey) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(creators) == 0 {
+		return errors.New("creator keys should not be empty")
+	}
+	s.creators = append([]cipher.PubKey{}, creators...)
+	s.revoked = append(s.revoked, revoked...)
+	s.epoch++
+	return nil
+}
+
+func (s *MemoryState) GetCreatorEpoch() uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.epoch
+}
+
+func (s *MemoryState) IsCreatorRevoked(pk cipher.PubKey) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, v := range s.revoked {
+		if v == pk {
+			return true
 		}
-	} else {
-		toState.Kitties.Add(kittyID)
-		toState.Transactions = append(toState.Transactions, tx)
 	}
+	return false
+}
+
+func (s *MemoryState) FreezeGeneration() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.frozen = true
 	return nil
 }
+
+func (s *MemoryState) IsGenerationFrozen() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.frozen
+}
+
+func (s *MemoryState) Snapshot() *StateSnapshot {
+	s.Lock()
+	defer s.Unlock()
+
+	kitties, addresses := s.flatten()
+	snapshot := &StateSnapshot{
+		Kitties:   make([]KittyStateEntry, 0, len(kitties)),
+		Addresses: make([]AddressStateEntry, 0, len(addresses)),
+	}
+	if len(s.creators) > 0 {
+		snapshot.Creators = append([]cipher.PubKey{}, s.creators...)
+	}
+	if len(s.revoked) > 0 {
+		snapshot.Revoked = append([]cipher.PubKey{}, s.revoked...)
+	}
+	snapshot.Epoch = s.epoch
+	snapshot.Frozen = s.frozen
+	for kittyID, kState := range kitties {
+		snapshot.Kitties = append(snapshot.Kitties, KittyStateEntry{
+			KittyID: kittyID,
+			State: KittyState{
+				Address:      kState.Address,
+				Transactions: append(TxHashes{}, kState.Transactions...),
+				Burned:       kState.Burned,
+				Metadata: KittyMetadata{
+					Name:       kState.Metadata.Name,
+					DNA:        append([]byte{}, kState.Metadata.DNA...),
+					Generation: kState.Metadata.Generation,
+					ImageURI:   kState.Metadata.ImageURI,
+				},
+				CooldownSeq: kState.CooldownSeq,
+				Auction:     kState.Auction,
+				Royalty:     kState.Royalty,
+			},
+		})
+	}
+	for address, aState := range addresses {
+		snapshot.Addresses = append(snapshot.Addresses, AddressStateEntry{
+			Address: address,
+			State: AddressState{
+				Kitties:      append(KittyIDs{}, aState.Kitties...),
+				Transactions: append(TxHashes{}, aState.Transactions...),
+				FeesPaid:     aState.FeesPaid,
+				Royalties:    aState.Royalties,
+			},
+		})
+	}
+
+	// Sort entries so that snapshots of the same state are identical.
+	sort.Slice(snapshot.Kitties, func(i, j int) bool {
+		return snapshot.Kitties[i].KittyID < snapshot.Kitties[j].KittyID
+	})
+	sort.Slice(snapshot.Addresses, func(i, j int) bool {
+		return snapshot.Addresses[i].Address.String() < snapshot.Addresses[j].Address.String()
+	})
+	return snapshot
+}
+
+func (s *MemoryState) Restore(snapshot *StateSnapshot) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.parent = nil
+	s.kitties = make(map[KittyID]*KittyState, len(snapshot.Kitties))
+	s.auctions = make(map[KittyID]struct{})
+	for _, entry := range snapshot.Kitties {
+		kState := entry.State
+		s.kitties[entry.KittyID] = &kState
+		if !kState.Auction.IsZero() {
+			s.auctions[entry.KittyID] = struct{}{}
+		}
+	}
+	s.addresses = make(map[cipher.Address]*AddressState, len(snapshot.Addresses))
+	for _, entry := range snapshot.Addresses {
+		aState := entry.State
+		s.addresses[entry.Address] = &aState
+	}
+	s.creators = append([]cipher.PubKey(nil), snapshot.Creators...)
+	s.revoked = append([]cipher.PubKey(nil), snapshot.Revoked...)
+	s.epoch = snapshot.Epoch
+	s.frozen = snapshot.Frozen
+}
+
+// kitty obtains the state of the kitty, which is of the parent if the fork has
+// not changed it. It should not be changed. The caller should hold the lock.
+func (s *MemoryState) kitty(kittyID KittyID) (*KittyState, bool) {
+	if kState, ok := s.kitties[kittyID]; ok || s.parent == nil {
+		return kState, ok
+	}
+	s.parent.Lock()
+	defer s.parent.Unlock()
+	return s.parent.kitty(kittyID)
+}
+
+// writeKitty obtains the state of the kitty to be changed, which the fork
+// copies from the parent on it's first change. The caller should hold the
+// lock.
+func (s *MemoryState) writeKitty(kittyID KittyID) (*KittyState, bool) {
+	if kState, ok := s.kitties[kittyID]; ok || s.parent == nil {
+		return kState, ok
+	}
+	kState, ok := s.kitty(kittyID)
+	if !ok {
+		return nil, false
+	}
+	kState = copyKittyState(kState)
+	s.kitties[kittyID] = kState
+	return kState, true
+}
+
+// address obtains the state of the address, as with 'kitty'.
+func (s *MemoryState) address(address cipher.Address) (*AddressState, bool) {
+	if aState, ok := s.addresses[address]; ok || s.parent == nil {
+		return aState, ok
+	}
+	s.parent.Lock()
+	defer s.parent.Unlock()
+	return s.parent.address(address)
+}
+
+// writeAddress obtains the state of the address to be changed, as with
+// 'writeKitty'.
+func (s *MemoryState) writeAddress(address cipher.Address) (*AddressState, bool) {
+	if aState, ok := s.addresses[address]; ok || s.parent == nil {
+		return aState, ok
+	}
+	aState, ok := s.address(address)
+	if !ok {
+		return nil, false
+	}
+	aState = copyAddressState(aState)
+	s.addresses[address] = aState
+	return aState, true
+}
+
+// flatten obtains the kitty and address states, including those of the
+// parent that the fork has not changed. The caller should hold the lock.
+func (s *MemoryState) flatten() (map[KittyID]*KittyState, map[cipher.Address]*AddressState) {
+	if s.parent == nil {
+		return s.kitties, s.addresses
+	}
+	s.parent.Lock()
+	pKitties, pAddresses := s.parent.flatten()
+	kitties := make(map[KittyID]*KittyState, len(pKitties)+len(s.kitties))
+	for kittyID, kState := range pKitties {
+		kitties[kittyID] = kState
+	}
+	addresses := make(map[cipher.Address]*AddressState, len(pAddresses)+len(s.addresses))
+	for address, aState := range pAddresses {
+		addresses[address] = aState
+	}
+	s.parent.Unlock()
+
+	for kittyID, kState := range s.kitties {
+		kitties[kittyID] = kState
+	}
+	for address, aState := range s.addresses {
+		addresses[address] = aState
+	}
+	return kitties, addresses
+}
+
+// copyKittyState obtains a deep copy of the kitty state.
+func copyKittyState(kState *KittyState) *KittyState {
+	out := *kState
+	out.Transactions = append(TxHashes{}, kState.Transactions...)
+	out.Metadata.DNA = append([]byte{}, kState.Metadata.DNA...)
+	return &out
+}
+
+// copyAddressState obtains a deep copy of the address state.
+func copyAddressState(aState *AddressState) *AddressState {
+	out := *aState
+	out.Kitties = append(KittyIDs{}, aState.Kitties...)
+	out.Transactions = append(TxHashes{}, aState.Transactions...)
+	return &out
+}
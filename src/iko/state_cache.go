@@ -0,0 +1,193 @@
+package iko
+
+import (
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// cachedState is a read-through cache of the kitty and address states of a
+// StateDB. Mutations invalidate the states of the kitties and addresses that
+// they affect, including the owners of the kitties before and after the
+// mutation. Other methods are of the underlying StateDB.
+type cachedState struct {
+	StateDB
+
+	mux       sync.Mutex
+	size      int
+	kitties   map[KittyID]*KittyState
+	addresses map[cipher.Address]*AddressState
+}
+
+// cachedSnapshotState is a cachedState of a SnapshotStateDB.
+type cachedSnapshotState struct {
+	*cachedState
+	ss SnapshotStateDB
+}
+
+// NewCachedState wraps the StateDB with a read-through cache of at most
+// 'size' kitty states and 'size' address states, to reduce the latency of
+// read-heavy queries of slower StateDBs. The returned StateDB is a
+// SnapshotStateDB if 'db' is.
+func NewCachedState(db StateDB, size int) StateDB {
+	cs := &cachedState{
+		StateDB:   db,
+		size:      size,
+		kitties:   make(map[KittyID]*KittyState),
+		addresses: make(map[cipher.Address]*AddressState),
+	}
+	if ss, ok := db.(SnapshotStateDB); ok {
+		return &cachedSnapshotState{cachedState: cs, ss: ss}
+	}
+	return cs
+}
+
+func (s *cachedState) GetKittyState(kittyID KittyID) (*KittyState, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if kState, ok := s.kitties[kittyID]; ok {
+		return kState, true
+	}
+	kState, ok := s.StateDB.GetKittyState(kittyID)
+	if !ok {
+		return nil, false
+	}
+	if len(s.kitties) >= s.size {
+		for k := range s.kitties {
+			delete(s.kitties, k) // evict an arbitrary state
+			break
+		}
+	}
+	s.kitties[kittyID] = kState
+	return kState, true
+}
+
+func (s *cachedState) GetAddressState(address cipher.Address) *AddressState {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if aState, ok := s.addresses[address]; ok {
+		return aState
+	}
+	aState := s.StateDB.GetAddressState(address)
+	if len(s.addresses) >= s.size {
+		for k := range s.addresses {
+			delete(s.addresses, k) // evict an arbitrary state
+			break
+		}
+	}
+	s.addresses[address] = aState
+	return aState
+}
+
+// mutate runs the mutation 'fn', invalidating the states of the kitties and
+// addresses, and of the owners of the kitties before and after 'fn'.
+func (s *cachedState) mutate(kittyIDs []KittyID, addresses []cipher.Address, fn func() error) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.invalidate(kittyIDs, addresses)
+	e := fn()
+	s.invalidate(kittyIDs, addresses)
+	return e
+}
+
+func (s *cachedState) invalidate(kittyIDs []KittyID, addresses []cipher.Address) {
+	for _, kittyID := range kittyIDs {
+		delete(s.kitties, kittyID)
+		if kState, ok := s.StateDB.GetKittyState(kittyID); ok {
+			delete(s.addresses, kState.Address)
+		}
+	}
+	for _, address := range addresses {
+		delete(s.addresses, address)
+	}
+}
+
+func (s *cachedState) AddKitty(tx TxHash, kittyID KittyID, address cipher.Address) error {
+	return s.mutate([]KittyID{kittyID}, []cipher.Address{address}, func() error {
+		return s.StateDB.AddKitty(tx, kittyID, address)
+	})
+}
+
+func (s *cachedState) MoveKitty(tx TxHash, kittyID KittyID, from, to cipher.Address) error {
+	return s.mutate([]KittyID{kittyID}, []cipher.Address{from, to}, func() error {
+		return s.StateDB.MoveKitty(tx, kittyID, from, to)
+	})
+}
+
+func (s *cachedState) MoveKitties(tx TxHash, kittyIDs []KittyID, from, to cipher.Address) error {
+	return s.mutate(kittyIDs, []cipher.Address{from, to}, func() error {
+		return s.StateDB.MoveKitties(tx, kittyIDs, from, to)
+	})
+}
+
+func (s *cachedState) BurnKitties(tx TxHash, kittyIDs []KittyID, from cipher.Address) error {
+	return s.mutate(kittyIDs, []cipher.Address{from, BurnAddress}, func() error {
+		return s.StateDB.BurnKitties(tx, kittyIDs, from)
+	})
+}
+
+func (s *cachedState) SetKittyMetadata(tx TxHash, kittyID KittyID, metadata KittyMetadata) error {
+	return s.mutate([]KittyID{kittyID}, nil, func() error {
+		return s.StateDB.SetKittyMetadata(tx, kittyID, metadata)
+	})
+}
+
+func (s *cachedState) BreedKitties(tx TxHash, parentIDs []KittyID, childID KittyID, owner cipher.Address, child KittyMetadata, cooldownSeq uint64) error {
+	kittyIDs := append(append([]KittyID{}, parentIDs...), childID)
+	return s.mutate(kittyIDs, []cipher.Address{owner}, func() error {
+		return s.StateDB.BreedKitties(tx, parentIDs, childID, owner, child, cooldownSeq)
+	})
+}
+
+func (s *cachedState) ListKitty(tx TxHash, kittyID KittyID, listing Listing) error {
+	return s.mutate([]KittyID{kittyID}, []cipher.Address{listing.Seller}, func() error {
+		return s.StateDB.ListKitty(tx, kittyID, listing)
+	})
+}
+
+func (s *cachedState) BidKitty(tx TxHash, kittyID KittyID, bidder cipher.Address, bid uint64) error {
+	return s.mutate([]KittyID{kittyID}, []cipher.Address{bidder}, func() error {
+		return s.StateDB.BidKitty(tx, kittyID, bidder, bid)
+	})
+}
+
+func (s *cachedState) CloseAuction(tx TxHash, kittyID KittyID, to cipher.Address) error {
+	return s.mutate([]KittyID{kittyID}, []cipher.Address{to}, func() error {
+		return s.StateDB.CloseAuction(tx, kittyID, to)
+	})
+}
+
+func (s *cachedState) AddFee(address cipher.Address, fee uint64) error {
+	return s.mutate(nil, []cipher.Address{address}, func() error {
+		return s.StateDB.AddFee(address, fee)
+	})
+}
+
+func (s *cachedState) SetKittyRoyalty(kittyID KittyID, royalty KittyRoyalty) error {
+	return s.mutate([]KittyID{kittyID}, nil, func() error {
+		return s.StateDB.SetKittyRoyalty(kittyID, royalty)
+	})
+}
+
+func (s *cachedState) AddRoyalty(creator cipher.Address, amount uint64) error {
+	return s.mutate(nil, []cipher.Address{creator}, func() error {
+		return s.StateDB.AddRoyalty(creator, amount)
+	})
+}
+
+func (s *cachedSnapshotState) Snapshot() *StateSnapshot {
+	return s.ss.Snapshot()
+}
+
+// Restore replaces the state with that of the snapshot, clearing the cache.
+func (s *cachedSnapshotState) Restore(snapshot *StateSnapshot) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.ss.Restore(snapshot)
+	s.kitties = make(map[KittyID]*KittyState)
+	s.addresses = make(map[cipher.Address]*AddressState)
+}
@@ -0,0 +1,123 @@
+package iko
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+// copyingState is a StateDB that returns copies of states, as a StateDB that
+// is not in memory would, and counts the reads of states.
+type copyingState struct {
+	*MemoryState
+	reads int
+}
+
+func (s *copyingState) GetKittyState(kittyID KittyID) (*KittyState, bool) {
+	s.reads++
+	kState, ok := s.MemoryState.GetKittyState(kittyID)
+	if !ok {
+		return nil, false
+	}
+	out := *kState
+	out.Transactions = append(TxHashes{}, kState.Transactions...)
+	return &out, true
+}
+
+func (s *copyingState) GetAddressState(address cipher.Address) *AddressState {
+	s.reads++
+	aState := *s.MemoryState.GetAddressState(address)
+	aState.Kitties = append(KittyIDs{}, aState.Kitties...)
+	aState.Transactions = append(TxHashes{}, aState.Transactions...)
+	return &aState
+}
+
+func TestCachedState(t *testing.T) {
+	var (
+		db      = &copyingState{MemoryState: NewMemoryState()}
+		s       = NewCachedState(db, 10)
+		pk1, _  = cipher.GenerateKeyPair()
+		pk2, _  = cipher.GenerateKeyPair()
+		addr1   = cipher.AddressFromPubKey(pk1)
+		addr2   = cipher.AddressFromPubKey(pk2)
+		genHash = TxHash(cipher.SumSHA256([]byte("gen")))
+		txHash  = TxHash(cipher.SumSHA256([]byte("transfer")))
+	)
+	_, ok := s.(SnapshotStateDB)
+	require.True(t, ok, "cache of a SnapshotStateDB should be a SnapshotStateDB")
+
+	require.NoError(t, s.AddKitty(genHash, 1, addr1))
+
+	kState, ok := s.GetKittyState(1)
+	require.True(t, ok)
+	require.Equal(t, addr1, kState.Address)
+	reads := db.reads
+	kState, ok = s.GetKittyState(1)
+	require.True(t, ok)
+	require.Equal(t, reads, db.reads, "kitty state should be cached")
+
+	require.Equal(t, KittyIDs{1}, s.GetAddressState(addr1).Kitties)
+	require.Empty(t, s.GetAddressState(addr2).Kitties)
+
+	require.NoError(t, s.MoveKitty(txHash, 1, addr1, addr2))
+
+	kState, ok = s.GetKittyState(1)
+	require.True(t, ok)
+	require.Equal(t, addr2, kState.Address, "moved kitty should be invalidated")
+	require.Equal(t, TxHashes{genHash, txHash}, kState.Transactions)
+	require.Empty(t, s.GetAddressState(addr1).Kitties, "'from' address should be invalidated")
+	require.Equal(t, KittyIDs{1}, s.GetAddressState(addr2).Kitties, "'to' address should be invalidated")
+
+	require.NoError(t, s.SetKittyMetadata(txHash, 1, KittyMetadata{Name: "kitty"}))
+	kState, _ = s.GetKittyState(1)
+	require.Equal(t, "kitty", kState.Metadata.Name)
+
+	require.NoError(t, s.AddFee(addr2, 5))
+	require.Equal(t, uint64(5), s.GetAddressState(addr2).FeesPaid)
+
+	t.Run("Restore", func(t *testing.T) {
+		s.(SnapshotStateDB).Restore(new(StateSnapshot))
+		_, ok := s.GetKittyState(1)
+		require.False(t, ok, "cache should be cleared on restore")
+		require.Empty(t, s.GetAddressState(addr2).Kitties)
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		s := NewCachedState(db, 2)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, s.AddKitty(genHash, KittyID(10+i), addr1))
+			s.GetKittyState(KittyID(10 + i))
+		}
+		require.Len(t, s.(*cachedSnapshotState).kitties, 2)
+	})
+}
+
+func TestBlockChain_StateCache(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK:   GenPK,
+		StateCacheSize: 8,
+	})
+	defer closeBC()
+
+	var (
+		txWraps = injectGenTxs(t, bc, 2)
+		pk, _   = cipher.GenerateKeyPair()
+		addr    = cipher.AddressFromPubKey(pk)
+	)
+	require.Equal(t, KittyIDs{0, 1}, bc.GetAddressState(bc.CreatorAddress()).Kitties)
+
+	tx, err := NewTransferTx(&txWraps[0].Tx, addr, GenSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(tx)
+	require.NoError(t, err)
+	require.Equal(t, KittyIDs{1}, bc.GetAddressState(bc.CreatorAddress()).Kitties)
+	require.Equal(t, KittyIDs{0}, bc.GetAddressState(addr).Kitties)
+
+	_, err = bc.RemoveHeadTx()
+	require.NoError(t, err, "rollback should work with the cached state")
+	kState, ok := bc.GetKittyState(0)
+	require.True(t, ok)
+	require.Equal(t, bc.CreatorAddress(), kState.Address)
+	require.Empty(t, bc.GetAddressState(addr).Kitties)
+}
@@ -1,6 +1,10 @@
 package iko
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/skycoin/skycoin/src/cipher"
@@ -132,3 +136,149 @@ func TestStateDB_MemoryState(t *testing.T) {
 
 	runStateDBTest(t, stateDB)
 }
+
+func TestMemoryState_Snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kc_state_test")
+	require.NoError(t, err, "creation of temp dir should succeed")
+	defer os.RemoveAll(dir)
+
+	var (
+		stateDB = NewMemoryState()
+		addr1   = cipher.AddressFromPubKey(GenPK)
+		addr2   = cipher.AddressFromPubKey(RootPK)
+		tx1     = TxHash(cipher.SumSHA256([]byte("tx1")))
+		tx2     = TxHash(cipher.SumSHA256([]byte("tx2")))
+		tx3     = TxHash(cipher.SumSHA256([]byte("tx3")))
+	)
+	require.NoError(t, stateDB.AddKitty(tx1, KittyID(1), addr1))
+	require.NoError(t, stateDB.AddKitty(tx2, KittyID(2), addr1))
+	require.NoError(t, stateDB.MoveKitty(tx3, KittyID(1), addr1, addr2))
+
+	path := filepath.Join(dir, "state.snapshot")
+	require.NoError(t, SaveStateSnapshot(path, stateDB.Snapshot()),
+		"saving snapshot should succeed")
+
+	snapshot, err := LoadStateSnapshot(path)
+	require.NoError(t, err, "loading snapshot should succeed")
+
+	restored := NewMemoryState()
+	restored.Restore(snapshot)
+
+	require.Equal(t, stateDB.Snapshot(), restored.Snapshot(),
+		"restored state should equal original state")
+
+	kState, ok := restored.GetKittyState(KittyID(1))
+	require.True(t, ok, "restored kitty should exist")
+	require.Equal(t, addr2, kState.Address,
+		"restored kitty should be under moved address")
+	require.Equal(t, KittyIDs{2}, restored.GetAddressState(addr1).Kitties,
+		"restored address should own remaining kitty")
+}
+
+func TestMemoryState_Errors(t *testing.T) {
+	var (
+		state  = NewMemoryState()
+		owner  = cipher.AddressFromPubKey(GenPK)
+		pk, _  = cipher.GenerateKeyPair()
+		other  = cipher.AddressFromPubKey(pk)
+		txHash = TxHash(cipher.SumSHA256([]byte("errors")))
+	)
+	require.NoError(t, state.AddKitty(txHash, 0, owner))
+
+	err := state.AddKitty(txHash, 0, owner)
+	require.True(t, errors.Is(err, ErrKittyAlreadyExists), err)
+	err = state.MoveKitty(txHash, 1, owner, other)
+	require.True(t, errors.Is(err, ErrKittyNotFound), err)
+	err = state.MoveKitty(txHash, 0, other, owner)
+	require.True(t, errors.Is(err, ErrNotOwner), err)
+}
+
+func TestMemoryState_MoveKitties(t *testing.T) {
+	var (
+		state  = NewMemoryState()
+		from   = cipher.AddressFromPubKey(GenPK)
+		pk, _  = cipher.GenerateKeyPair()
+		to     = cipher.AddressFromPubKey(pk)
+		txHash = TxHash(cipher.SumSHA256([]byte("multi")))
+	)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, state.AddKitty(TxHash(cipher.SumSHA256([]byte{byte(i)})), KittyID(i), from))
+	}
+
+	require.Error(t, state.MoveKitties(txHash, KittyIDs{0, 0}, from, to),
+		"repeated kitty should fail")
+	require.Error(t, state.MoveKitties(txHash, KittyIDs{0, 5}, from, to),
+		"non-existent kitty should fail")
+	require.Equal(t, KittyIDs{0, 1, 2}, state.GetAddressState(from).Kitties,
+		"failed moves should not move any kitties")
+
+	require.NoError(t, state.MoveKitties(txHash, KittyIDs{2, 0}, from, to))
+	require.Equal(t, KittyIDs{1}, state.GetAddressState(from).Kitties)
+	require.Equal(t, KittyIDs{0, 2}, state.GetAddressState(to).Kitties)
+	require.Equal(t, TxHashes{txHash}, state.GetAddressState(to).Transactions)
+
+	unspent, ok := state.GetKittyUnspentTx(2)
+	require.True(t, ok)
+	require.Equal(t, txHash, unspent)
+}
+
+func TestMemoryState_BurnKitties(t *testing.T) {
+	var (
+		state  = NewMemoryState()
+		owner  = cipher.AddressFromPubKey(GenPK)
+		txHash = TxHash(cipher.SumSHA256([]byte("burn")))
+	)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, state.AddKitty(TxHash(cipher.SumSHA256([]byte{byte(i)})), KittyID(i), owner))
+	}
+
+	require.Error(t, state.BurnKitties(txHash, KittyIDs{0, 5}, owner),
+		"non-existent kitty should fail")
+	require.NoError(t, state.BurnKitties(txHash, KittyIDs{0}, owner))
+	require.Equal(t, KittyIDs{1}, state.GetAddressState(owner).Kitties)
+	require.Empty(t, state.GetAddressState(BurnAddress).Kitties,
+		"burn address should not hold kitties")
+
+	kState, ok := state.GetKittyState(0)
+	require.True(t, ok)
+	require.True(t, kState.Burned)
+	require.Equal(t, BurnAddress, kState.Address)
+
+	require.Error(t, state.AddKitty(txHash, 0, owner),
+		"burned kitty should not be regenerated")
+	require.Error(t, state.BurnKitties(txHash, KittyIDs{0}, owner),
+		"burned kitty should not be burned again")
+}
+
+func TestMemoryState_Fork(t *testing.T) {
+	var (
+		state  = NewMemoryState()
+		owner  = cipher.AddressFromPubKey(GenPK)
+		pk, _  = cipher.GenerateKeyPair()
+		other  = cipher.AddressFromPubKey(pk)
+		txHash = TxHash(cipher.SumSHA256([]byte("fork")))
+	)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, state.AddKitty(TxHash(cipher.SumSHA256([]byte{byte(i)})), KittyID(i), owner))
+	}
+	before := state.Snapshot()
+
+	fork := state.Fork()
+	require.NoError(t, fork.MoveKitty(txHash, 0, owner, other))
+	require.NoError(t, fork.AddKitty(txHash, 2, other))
+	require.Equal(t, before, state.Snapshot(), "fork should not change it's parent")
+
+	kState, ok := fork.GetKittyState(1)
+	require.True(t, ok, "unchanged kitties should be read from the parent")
+	require.Equal(t, owner, kState.Address)
+	require.Equal(t, KittyIDs{1}, fork.GetAddressState(owner).Kitties)
+	require.Equal(t, KittyIDs{0, 2}, fork.GetAddressState(other).Kitties)
+	require.Error(t, fork.AddKitty(txHash, 1, owner), "kitties of the parent should exist")
+
+	snapshot := fork.(*MemoryState).Snapshot()
+	require.Len(t, snapshot.Kitties, 3, "snapshot should merge the fork and it's parent")
+
+	t.Run("StateDB", func(t *testing.T) {
+		runStateDBTest(t, NewMemoryState().Fork())
+	})
+}
@@ -0,0 +1,351 @@
+package iko
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// streamBatchSize is the number of txs read from the chain at once when
+	// streaming txs.
+	streamBatchSize = 256
+)
+
+// StreamTxs calls 'fn' with every tx of the chain from seq 'start', in order,
+// and then with every tx that is accepted into the chain, until the context
+// is done or 'fn' returns an error. Txs are read from the chain as 'fn'
+// returns, so a slow consumer slows the stream rather than having txs
+// dropped. Pruned txs are skipped.
+func (bc *BlockChain) StreamTxs(ctx context.Context, start uint64, fn func(TxWrapper) error) error {
+	next := start
+	for {
+		// Obtain the notification before reading the chain, so that txs
+		// accepted after reading are not missed.
+		changed := bc.headChanged()
+
+		bc.mux.RLock()
+		cLen := bc.chain.Len()
+		var (
+			txWraps []TxWrapper
+			e       error
+		)
+		if next < cLen {
+			txWraps, e = bc.chain.GetTxsOfSeqRange(next, streamBatchSize)
+		}
+		bc.mux.RUnlock()
+		if e != nil {
+			return e
+		}
+
+		if len(txWraps) == 0 && next < cLen {
+			// The whole batch is pruned.
+			next += streamBatchSize
+			continue
+		}
+		for _, txWrap := range txWraps {
+			if e := fn(txWrap); e != nil {
+				return e
+			}
+			next = txWrap.Meta.Seq + 1
+		}
+		if len(txWraps) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-bc.ctx.Done():
+			return ErrChainStopped
+		case <-changed:
+		}
+	}
+}
+
+// GetTxsSinceSeq streams the txs of seq greater than 'seq' with 'StreamTxs',
+// such as for an indexer that has processed the txs up to 'seq'.
+func (bc *BlockChain) GetTxsSinceSeq(ctx context.Context, seq uint64, fn func(TxWrapper) error) error {
+	return bc.StreamTxs(ctx, seq+1, fn)
+}
+
+// TxStreamPolicy determines what a TxStream does with txs while it's consumer
+// is behind.
+type TxStreamPolicy string
+
+const (
+	// TxStreamBlock holds txs back until the consumer catches up. As txs are
+	// read from the chain, injection is not blocked, and no tx is lost.
+	TxStreamBlock TxStreamPolicy = "block"
+
+	// TxStreamDrop drops txs that do not fit the buffer (or the window of
+	// unacknowledged txs), counting them in 'TxStreamStats.Dropped'.
+	TxStreamDrop TxStreamPolicy = "drop"
+)
+
+// DefaultTxStreamBufSize is the default buffer size of a TxStream.
+const DefaultTxStreamBufSize = 128
+
+// TxStreamConfig configures a TxStream, see 'BlockChain.NewTxStream'.
+type TxStreamConfig struct {
+	// Start is the seq of the first tx to stream.
+	Start uint64
+
+	// BufSize is the buffer size of the channel of txs. A value of 0 results
+	// in 'DefaultTxStreamBufSize'.
+	BufSize int
+
+	// Policy determines what happens to txs while the consumer is behind. It
+	// defaults to 'TxStreamBlock'.
+	Policy TxStreamPolicy
+
+	// MaxUnacked is the number of received txs that the consumer can have
+	// not acknowledged with 'TxStream.Ack', after which further txs are held
+	// back (or dropped). A value of 0 means that acks are not required.
+	MaxUnacked int
+
+	// StallTimeout is the time after which a consumer that does not receive
+	// (or acknowledge) txs is reported as stalled, which is logged and shown
+	// by 'TxStreamStats.Stalled'. A value of 0 disables stall detection.
+	StallTimeout time.Duration
+}
+
+func (c *TxStreamConfig) Process() error {
+	if c.BufSize < 0 {
+		return fmt.Errorf("tx stream buffer size of %d is invalid", c.BufSize)
+	}
+	if c.BufSize == 0 {
+		c.BufSize = DefaultTxStreamBufSize
+	}
+	switch c.Policy {
+	case "":
+		c.Policy = TxStreamBlock
+	case TxStreamBlock, TxStreamDrop:
+	default:
+		return fmt.Errorf("invalid tx stream policy '%s'", c.Policy)
+	}
+	if c.MaxUnacked < 0 {
+		return fmt.Errorf("tx stream max unacked of %d is invalid", c.MaxUnacked)
+	}
+	return nil
+}
+
+// TxStreamStats reports the progress of a TxStream.
+type TxStreamStats struct {
+	Next    uint64 // Seq after the last tx that is streamed (or dropped).
+	Unacked int    // Number of received txs that are not acknowledged.
+	Dropped uint64 // Number of txs dropped by the 'TxStreamDrop' policy.
+	Stalled bool   // Whether the consumer is stalled, see 'StallTimeout'.
+}
+
+// unackedTx is a tx sent to the consumer of a TxStream, which is not yet
+// acknowledged.
+type unackedTx struct {
+	seq  uint64
+	sent time.Time
+}
+
+// TxStream streams the txs of the chain to a consumer through a buffered
+// channel, with the slow consumer policy and acknowledgements of it's config.
+// Txs are read from the chain as the consumer keeps up, so a stalled consumer
+// does not block injection, and is reported rather than going unnoticed.
+type TxStream struct {
+	bc    *BlockChain
+	c     TxStreamConfig
+	txs   chan TxWrapper
+	acked chan struct{} // signalled when txs are acknowledged
+
+	mux     sync.Mutex
+	next    uint64
+	unacked []unackedTx
+	dropped uint64
+	stalled bool
+	since   time.Time // of the last progress of the consumer
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewTxStream starts a TxStream of the txs from seq 'config.Start', and then
+// of every tx accepted into the chain. The stream ends when it is closed, or
+// when the BlockChain is closed.
+func (bc *BlockChain) NewTxStream(config TxStreamConfig) (*TxStream, error) {
+	if e := config.Process(); e != nil {
+		return nil, e
+	}
+	ctx, cancel := context.WithCancel(bc.ctx)
+	s := &TxStream{
+		bc:     bc,
+		c:      config,
+		txs:    make(chan TxWrapper, config.BufSize),
+		acked:  make(chan struct{}, 1),
+		next:   config.Start,
+		since:  time.Now(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s, nil
+}
+
+func (s *TxStream) run(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.txs)
+
+	e := s.bc.StreamTxs(ctx, s.c.Start, func(txWrap TxWrapper) error {
+		return s.deliver(ctx, txWrap)
+	})
+	if e != nil && e != context.Canceled && e != ErrChainStopped {
+		s.bc.log.WithError(e).Error("tx stream failed")
+	}
+}
+
+// deliver sends the tx to the consumer as of the policy, waiting for the
+// consumer to catch up with 'TxStreamBlock'.
+func (s *TxStream) deliver(ctx context.Context, txWrap TxWrapper) error {
+	if s.windowOpen() {
+		select {
+		case s.txs <- txWrap:
+			s.sent(txWrap.Meta.Seq)
+			return nil
+		default:
+		}
+	}
+	if s.c.Policy == TxStreamDrop {
+		s.drop(txWrap.Meta.Seq)
+		return nil
+	}
+
+	var stallCheck <-chan time.Time
+	if s.c.StallTimeout > 0 {
+		ticker := time.NewTicker(s.c.StallTimeout)
+		defer ticker.Stop()
+		stallCheck = ticker.C
+	}
+	for {
+		var send chan<- TxWrapper
+		if s.windowOpen() {
+			send = s.txs
+		}
+		select {
+		case send <- txWrap:
+			s.sent(txWrap.Meta.Seq)
+			return nil
+		case <-s.acked:
+		case <-stallCheck:
+			s.checkStalled()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *TxStream) windowOpen() bool {
+	if s.c.MaxUnacked == 0 {
+		return true
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.unacked) < s.c.MaxUnacked
+}
+
+func (s *TxStream) sent(seq uint64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.next = seq + 1
+	if s.c.MaxUnacked > 0 {
+		s.unacked = append(s.unacked, unackedTx{seq: seq, sent: time.Now()})
+	}
+	s.progressed()
+}
+
+func (s *TxStream) drop(seq uint64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.next = seq + 1
+	if s.dropped++; s.dropped == 1 || s.dropped%1000 == 0 {
+		s.bc.log.
+			WithField("seq", seq).
+			WithField("dropped", s.dropped).
+			Warning("dropped tx for slow tx stream consumer")
+	}
+}
+
+// progressed records progress of the consumer, clearing a stall. The lock
+// should be held.
+func (s *TxStream) progressed() {
+	s.since = time.Now()
+	if s.stalled {
+		s.stalled = false
+		s.bc.log.
+			WithField("next", s.next).
+			Info("tx stream consumer recovered")
+	}
+}
+
+// checkStalled reports the consumer as stalled if it has not received nor
+// acknowledged txs within the stall timeout, while txs are held back.
+func (s *TxStream) checkStalled() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.stalled || time.Since(s.since) < s.c.StallTimeout {
+		return
+	}
+	s.stalled = true
+	s.bc.log.
+		WithField("next", s.next).
+		WithField("unacked", len(s.unacked)).
+		WithField("since", s.since).
+		Warning("tx stream consumer is stalled")
+}
+
+// Txs obtains the channel of streamed txs, which is closed when the stream
+// ends.
+func (s *TxStream) Txs() <-chan TxWrapper {
+	return s.txs
+}
+
+// Ack acknowledges the received txs up to (and including) seq, opening the
+// window of 'MaxUnacked' for further txs.
+func (s *TxStream) Ack(seq uint64) {
+	s.mux.Lock()
+	i := 0
+	for i < len(s.unacked) && s.unacked[i].seq <= seq {
+		i++
+	}
+	s.unacked = s.unacked[i:]
+	if i > 0 {
+		s.progressed()
+	}
+	s.mux.Unlock()
+
+	if i > 0 {
+		select {
+		case s.acked <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Stats obtains the progress of the stream.
+func (s *TxStream) Stats() TxStreamStats {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return TxStreamStats{
+		Next:    s.next,
+		Unacked: len(s.unacked),
+		Dropped: s.dropped,
+		Stalled: s.stalled,
+	}
+}
+
+// Close ends the stream, and waits for it to stop.
+func (s *TxStream) Close() {
+	s.cancel()
+	<-s.done
+}
@@ -0,0 +1,182 @@
+package iko
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_StreamTxs(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	txWraps := injectGenTxs(t, bc, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streamed := make(chan TxWrapper)
+	done := make(chan error, 1)
+	go func() {
+		done <- bc.GetTxsSinceSeq(ctx, 1, func(txWrap TxWrapper) error {
+			select {
+			case streamed <- txWrap:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	receive := func() TxWrapper {
+		select {
+		case txWrap := <-streamed:
+			return txWrap
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for streamed tx")
+			return TxWrapper{}
+		}
+	}
+
+	// Existing txs after seq 1 are streamed in order.
+	for _, txWrap := range txWraps[2:] {
+		got := receive()
+		require.Equal(t, txWrap.Meta.Seq, got.Meta.Seq)
+		require.Equal(t, txWrap.Tx.Hash(), got.Tx.Hash())
+	}
+
+	// Txs accepted afterwards are streamed, although the consumer is slow.
+	for i := 5; i < 8; i++ {
+		_, err := bc.InjectTx(NewGenTx(KittyID(i), GenSK))
+		require.NoError(t, err)
+	}
+	for seq := uint64(5); seq < 8; seq++ {
+		require.Equal(t, seq, receive().Meta.Seq)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		require.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream should end once the context is done")
+	}
+
+	t.Run("ConsumerError", func(t *testing.T) {
+		errStop := errors.New("stop")
+		var count int
+		err := bc.StreamTxs(context.Background(), 0, func(TxWrapper) error {
+			if count++; count == 3 {
+				return errStop
+			}
+			return nil
+		})
+		require.Equal(t, errStop, err)
+		require.Equal(t, 3, count)
+	})
+}
+
+func TestBlockChain_NewTxStream(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{GenerationPK: GenPK})
+	defer closeBC()
+
+	injectGenTxs(t, bc, 5)
+
+	receive := func(s *TxStream) TxWrapper {
+		select {
+		case txWrap := <-s.Txs():
+			return txWrap
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for streamed tx")
+			return TxWrapper{}
+		}
+	}
+
+	t.Run("Acks", func(t *testing.T) {
+		s, err := bc.NewTxStream(TxStreamConfig{MaxUnacked: 3})
+		require.NoError(t, err)
+		defer s.Close()
+
+		for seq := uint64(0); seq < 3; seq++ {
+			require.Equal(t, seq, receive(s).Meta.Seq)
+		}
+		select {
+		case <-s.Txs():
+			t.Fatal("txs past the unacked window should be held back")
+		case <-time.After(50 * time.Millisecond):
+		}
+		require.Equal(t, 3, s.Stats().Unacked)
+
+		s.Ack(2)
+		for seq := uint64(3); seq < 5; seq++ {
+			require.Equal(t, seq, receive(s).Meta.Seq)
+		}
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		s, err := bc.NewTxStream(TxStreamConfig{BufSize: 1, Policy: TxStreamDrop})
+		require.NoError(t, err)
+		defer s.Close()
+
+		waitFor(t, "txs should be streamed", func() bool { return s.Stats().Next == 5 })
+		require.Equal(t, uint64(4), s.Stats().Dropped,
+			"txs that do not fit the buffer should be dropped")
+		require.Equal(t, uint64(0), receive(s).Meta.Seq)
+	})
+
+	t.Run("Stalled", func(t *testing.T) {
+		s, err := bc.NewTxStream(TxStreamConfig{BufSize: 1, StallTimeout: 10 * time.Millisecond})
+		require.NoError(t, err)
+		defer s.Close()
+
+		waitFor(t, "consumer should be reported as stalled", func() bool { return s.Stats().Stalled })
+
+		for seq := uint64(0); seq < 5; seq++ {
+			require.Equal(t, seq, receive(s).Meta.Seq)
+		}
+		require.False(t, s.Stats().Stalled, "stall should be cleared once the consumer catches up")
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		_, err := bc.NewTxStream(TxStreamConfig{Policy: "never"})
+		require.Error(t, err)
+	})
+}
+
+func TestBlockChain_ProcessDroppedTxs(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		actions = make(chan KittyID, 2*txChanBufSize)
+	)
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK: GenPK,
+		TxAction: func(tx *Transaction) error {
+			<-release
+			actions <- tx.KittyID
+			return nil
+		},
+	})
+	defer closeBC()
+
+	// The tx action is stalled, so txs past the buffer of 'TxChan' are dropped
+	// by the chain db.
+	txs := make([]Transaction, 2*txChanBufSize)
+	for i := range txs {
+		txs[i] = *NewGenTx(KittyID(i), GenSK)
+	}
+	_, err := bc.InjectTxs(txs)
+	require.NoError(t, err)
+	close(release)
+
+	for i := range txs {
+		select {
+		case kittyID := <-actions:
+			require.Equal(t, KittyID(i), kittyID, "txs should be processed in order")
+		case <-time.After(5 * time.Second):
+			t.Fatalf("tx action of kitty %d should run", i)
+		}
+	}
+}
@@ -4,11 +4,53 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"reflect"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
+var (
+	// ErrNotOwner occurs when a transfer tx is not signed by the owner of the
+	// kitty, as recorded in the output of the unspent tx.
+	ErrNotOwner = errors.New("tx is not signed by the kitty owner")
+
+	// ErrMixedOwners occurs when the kitties of a multi-kitty transfer are not
+	// all owned by the same address.
+	ErrMixedOwners = errors.New("kitties of tx are not of the same owner")
+
+	// ErrKittyLocked occurs when transferring a kitty of which the input tx
+	// is time-locked, and the lock has not yet expired.
+	ErrKittyLocked = errors.New("kitty is locked by it's input tx")
+)
+
+const (
+	// MaxTxInputs is the maximum number of kitties of a multi-kitty transfer.
+	MaxTxInputs = 256
+
+	// MaxMemoSize is the maximum size of a tx memo, in bytes.
+	MaxMemoSize = 64
+)
+
+// Tags of the optional fields of a transaction.
+const (
+	txFieldWitness  uint8 = 1
+	txFieldInputs   uint8 = 2
+	txFieldMemo     uint8 = 3
+	txFieldFee      uint8 = 4
+	txFieldLock     uint8 = 5
+	txFieldEscrow   uint8 = 6
+	txFieldMeta     uint8 = 7
+	txFieldBreed    uint8 = 8
+	txFieldAuction  uint8 = 9
+	txFieldRoyalty  uint8 = 10
+	txFieldRotation uint8 = 11
+	txFieldAdmin    uint8 = 12
+	txFieldGenesis  uint8 = 13
+	txFieldNetwork  uint8 = 14
+	txFieldVersion  uint8 = 15
+)
+
 type TxHash cipher.SHA256
 
 func EmptyTxHash() TxHash {
@@ -38,6 +80,102 @@ type Transaction struct {
 	In      TxHash
 	Out     cipher.Address
 	Sig     cipher.Sig
+
+	// Inputs are the additional kitties of a multi-kitty transfer, which are
+	// transferred to 'Out' along with 'KittyID'. Unlike the witness, they are
+	// part of the tx hash.
+	Inputs []KittyInput `enc:"-"`
+
+	// Memo is arbitrary data attached to the tx (such as an order ID), of at
+	// most 'MaxMemoSize' bytes. It is part of the tx hash, and hence signed.
+	Memo []byte `enc:"-"`
+
+	// Fee is paid by the sender of the tx, as required by the 'FeePolicy' of
+	// the chain. It is part of the tx hash.
+	Fee uint64 `enc:"-"`
+
+	// Lock prevents the receiver from transferring the kitty again until the
+	// lock expires. It is part of the tx hash.
+	Lock TxLock `enc:"-"`
+
+	// Escrow reveals the escrow which holds the kitty, when settling it. It is
+	// part of the tx hash, and 'Sig' is of the settling party.
+	Escrow *Escrow `enc:"-"`
+
+	// Metadata is set by metadata txs, which describe the kitty rather than
+	// transfer it. It is part of the tx hash.
+	Metadata *KittyMetadata `enc:"-"`
+
+	// Breed is set by breed txs, where the kitties of the tx breed a new
+	// child kitty. It is part of the tx hash.
+	Breed *TxBreed `enc:"-"`
+
+	// Auction is set by auction txs, which list, bid on, settle or cancel the
+	// auction of the kitty. It is part of the tx hash.
+	Auction *TxAuction `enc:"-"`
+
+	// Royalty is registered by generation txs, and acknowledged by subsequent
+	// transfers of the kitty. It is part of the tx hash.
+	Royalty *TxRoyalty `enc:"-"`
+
+	// Rotation is set by rotation txs, which replace the creator keys rather
+	// than transfer a kitty. It is part of the tx hash.
+	Rotation *TxRotation `enc:"-"`
+
+	// Admin is set by admin txs, which revoke creator keys or freeze the
+	// generation of kitties. It is part of the tx hash, and the tx is
+	// authorised by the witness of the creator quorum.
+	Admin *TxAdmin `enc:"-"`
+
+	// Genesis is set only by the genesis tx (of seq 0), and defines the chain.
+	// It is part of the tx hash.
+	Genesis *Genesis `enc:"-"`
+
+	// Network identifies the network the tx is signed for, so that it cannot
+	// be replayed on another network (see 'VerifyNetwork'). It is part of the
+	// tx hash, and is empty for the legacy network.
+	Network string `enc:"-"`
+
+	// Version is the version of the tx encoding (see 'TxVersionLatest'), which
+	// is accepted according to the configured 'TxVersionPolicy'. It is part of
+	// the tx hash, and is not encoded for legacy txs.
+	Version uint8 `enc:"-"`
+
+	// Witness authorises transfers of kitties owned by a multisig, in place of
+	// 'Sig'. It is not part of the tx hash, and is serialized after the
+	// other fields only when present.
+	Witness *Witness `enc:"-"`
+}
+
+// KittyInput is an additional input of a multi-kitty transfer.
+type KittyInput struct {
+	KittyID KittyID
+	In      TxHash
+}
+
+// TxLock locks the output of a tx until the chain reaches the sequence 'Seq'
+// and the time reaches 'TS'. A zero value of either is not checked.
+type TxLock struct {
+	Seq uint64 // Sequence of the chain from which the output can be spent.
+	TS  int64  // Unix time (in nanoseconds) from which the output can be spent.
+}
+
+// IsZero returns true if the lock does not lock anything.
+func (l TxLock) IsZero() bool {
+	return l == TxLock{}
+}
+
+// IsLocked returns true if the lock has not expired at the sequence and time.
+func (l TxLock) IsLocked(seq uint64, ts int64) bool {
+	return seq < l.Seq || ts < l.TS
+}
+
+// txField is an optional field of a transaction. Optional fields are
+// serialized after the other fields only when present, so that txs without
+// them are encoded (and hashed) as before.
+type txField struct {
+	Tag  uint8
+	Data []byte
 }
 
 // TxMeta records meta information for a transaction.
@@ -86,12 +224,258 @@ func NewTransferTx(in *Transaction, out cipher.Address, sk cipher.SecKey) (*Tran
 	return tx, nil
 }
 
+// NewMultiTransferTx creates a transaction where several kitties are
+// transferred from one address to another. The kitties are of the input txs
+// 'ins', which should all be owned by the secret key.
+func NewMultiTransferTx(ins []*Transaction, kittyIDs []KittyID, out cipher.Address, sk cipher.SecKey) (*Transaction, error) {
+	tx, e := NewUnsignedMultiTransferTx(ins, kittyIDs, out)
+	if e != nil {
+		return nil, e
+	}
+	if expAddr := cipher.AddressFromSecKey(sk); ins[0].Out != expAddr {
+		return nil, errors.New("secret key does not own input tx address")
+	}
+	tx.Sig = tx.Sign(sk)
+	return tx, nil
+}
+
+// NewUnsignedMultiTransferTx creates an unsigned multi-kitty transfer, where
+// the kitty 'kittyIDs[i]' is spent from the input tx 'ins[i]'.
+func NewUnsignedMultiTransferTx(ins []*Transaction, kittyIDs []KittyID, out cipher.Address) (*Transaction, error) {
+	if len(ins) == 0 || len(ins) > MaxTxInputs+1 {
+		return nil, fmt.Errorf("transfer tx should have between 1 and %d inputs, got %d",
+			MaxTxInputs+1, len(ins))
+	}
+	if len(kittyIDs) != len(ins) {
+		return nil, fmt.Errorf("got %d kitty IDs for %d inputs", len(kittyIDs), len(ins))
+	}
+	tx := &Transaction{
+		KittyID: kittyIDs[0],
+		In:      ins[0].Hash(),
+		Out:     out,
+	}
+	for i, in := range ins[1:] {
+		tx.Inputs = append(tx.Inputs, KittyInput{
+			KittyID: kittyIDs[i+1],
+			In:      in.Hash(),
+		})
+	}
+	if e := tx.VerifyInputs(ins); e != nil {
+		return nil, e
+	}
+	return tx, nil
+}
+
+// KittyIDs returns the kitties of the tx, starting with 'KittyID'. Rotation
+// and admin txs have no kitties.
+func (tx Transaction) KittyIDs() KittyIDs {
+	if tx.Rotation != nil || tx.Admin != nil {
+		return nil
+	}
+	out := make(KittyIDs, 1, len(tx.Inputs)+1)
+	out[0] = tx.KittyID
+	for _, in := range tx.Inputs {
+		out = append(out, in.KittyID)
+	}
+	return out
+}
+
+// HasKitty returns true if the kitty of ID is one of the kitties of the tx,
+// or the child of a breed tx.
+func (tx Transaction) HasKitty(kittyID KittyID) bool {
+	if tx.Rotation != nil || tx.Admin != nil {
+		return false
+	}
+	if tx.KittyID == kittyID {
+		return true
+	}
+	if tx.Breed != nil && tx.Breed.ChildID == kittyID {
+		return true
+	}
+	for _, in := range tx.Inputs {
+		if in.KittyID == kittyID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMultiTransfer returns true if the tx transfers more than one kitty.
+func (tx Transaction) IsMultiTransfer() bool {
+	return len(tx.Inputs) > 0
+}
+
+// Serialize encodes the transaction, followed by the optional fields (if any).
 func (tx Transaction) Serialize() []byte {
-	return encoder.Serialize(tx)
+	return append(encoder.Serialize(tx), tx.serializeFields(false)...)
+}
+
+// serializeFields encodes the optional fields of the tx, or returns nil if there
+// are none. The witness is excluded if 'hashed' is set, as it is not part of the
+// tx hash.
+func (tx Transaction) serializeFields(hashed bool) []byte {
+	var fields []txField
+	if tx.Version != TxVersionLegacy {
+		fields = append(fields, txField{Tag: txFieldVersion, Data: []byte{tx.Version}})
+	}
+	if len(tx.Inputs) > 0 {
+		fields = append(fields, txField{Tag: txFieldInputs, Data: encoder.Serialize(tx.Inputs)})
+	}
+	if len(tx.Memo) > 0 {
+		fields = append(fields, txField{Tag: txFieldMemo, Data: tx.Memo})
+	}
+	if tx.Fee > 0 {
+		fields = append(fields, txField{Tag: txFieldFee, Data: encoder.Serialize(tx.Fee)})
+	}
+	if !tx.Lock.IsZero() {
+		fields = append(fields, txField{Tag: txFieldLock, Data: encoder.Serialize(tx.Lock)})
+	}
+	if tx.Escrow != nil {
+		fields = append(fields, txField{Tag: txFieldEscrow, Data: tx.Escrow.Serialize()})
+	}
+	if tx.Metadata != nil {
+		fields = append(fields, txField{Tag: txFieldMeta, Data: tx.Metadata.Serialize()})
+	}
+	if tx.Breed != nil {
+		fields = append(fields, txField{Tag: txFieldBreed, Data: encoder.Serialize(*tx.Breed)})
+	}
+	if tx.Auction != nil {
+		fields = append(fields, txField{Tag: txFieldAuction, Data: tx.Auction.Serialize()})
+	}
+	if tx.Royalty != nil {
+		fields = append(fields, txField{Tag: txFieldRoyalty, Data: tx.Royalty.Serialize()})
+	}
+	if tx.Rotation != nil {
+		fields = append(fields, txField{Tag: txFieldRotation, Data: tx.Rotation.Serialize()})
+	}
+	if tx.Admin != nil {
+		fields = append(fields, txField{Tag: txFieldAdmin, Data: tx.Admin.Serialize()})
+	}
+	if tx.Genesis != nil {
+		fields = append(fields, txField{Tag: txFieldGenesis, Data: tx.Genesis.Serialize()})
+	}
+	if tx.Network != "" {
+		fields = append(fields, txField{Tag: txFieldNetwork, Data: []byte(tx.Network)})
+	}
+	if tx.Witness != nil && !hashed {
+		fields = append(fields, txField{Tag: txFieldWitness, Data: tx.Witness.Serialize()})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return encoder.Serialize(fields)
 }
 
+// deserializeFields decodes the optional fields, dispatching on the version of
+// the tx (see 'decodeTxFields'). The version is the first field, if any.
+func (tx *Transaction) deserializeFields(raw []byte) error {
+	var fields []txField
+	if e := encoder.DeserializeRaw(raw, &fields); e != nil {
+		return e
+	}
+	if len(fields) > 0 && fields[0].Tag == txFieldVersion {
+		if len(fields[0].Data) != 1 || fields[0].Data[0] == TxVersionLegacy {
+			return errors.New("tx has an invalid version field")
+		}
+		tx.Version = fields[0].Data[0]
+		fields = fields[1:]
+	}
+	return tx.decodeTxFields(fields, raw)
+}
+
+func (tx *Transaction) decodeFields(fields []txField) error {
+	for _, f := range fields {
+		switch f.Tag {
+		case txFieldWitness:
+			tx.Witness = new(Witness)
+			if e := encoder.DeserializeRaw(f.Data, tx.Witness); e != nil {
+				return e
+			}
+		case txFieldInputs:
+			if e := encoder.DeserializeRaw(f.Data, &tx.Inputs); e != nil {
+				return e
+			}
+		case txFieldMemo:
+			tx.Memo = f.Data
+		case txFieldFee:
+			if e := encoder.DeserializeRaw(f.Data, &tx.Fee); e != nil {
+				return e
+			}
+		case txFieldLock:
+			if e := encoder.DeserializeRaw(f.Data, &tx.Lock); e != nil {
+				return e
+			}
+		case txFieldEscrow:
+			tx.Escrow = new(Escrow)
+			if e := encoder.DeserializeRaw(f.Data, tx.Escrow); e != nil {
+				return e
+			}
+		case txFieldMeta:
+			tx.Metadata = new(KittyMetadata)
+			if e := encoder.DeserializeRaw(f.Data, tx.Metadata); e != nil {
+				return e
+			}
+		case txFieldBreed:
+			tx.Breed = new(TxBreed)
+			if e := encoder.DeserializeRaw(f.Data, tx.Breed); e != nil {
+				return e
+			}
+		case txFieldAuction:
+			tx.Auction = new(TxAuction)
+			if e := encoder.DeserializeRaw(f.Data, tx.Auction); e != nil {
+				return e
+			}
+		case txFieldRoyalty:
+			tx.Royalty = new(TxRoyalty)
+			if e := encoder.DeserializeRaw(f.Data, tx.Royalty); e != nil {
+				return e
+			}
+		case txFieldRotation:
+			tx.Rotation = new(TxRotation)
+			if e := encoder.DeserializeRaw(f.Data, tx.Rotation); e != nil {
+				return e
+			}
+		case txFieldAdmin:
+			tx.Admin = new(TxAdmin)
+			if e := encoder.DeserializeRaw(f.Data, tx.Admin); e != nil {
+				return e
+			}
+		case txFieldGenesis:
+			tx.Genesis = new(Genesis)
+			if e := encoder.DeserializeRaw(f.Data, tx.Genesis); e != nil {
+				return e
+			}
+		case txFieldNetwork:
+			tx.Network = string(f.Data)
+		default:
+			return fmt.Errorf("tx has unknown field of tag %d", f.Tag)
+		}
+	}
+	return nil
+}
+
+// DeserializeTx decodes a transaction encoded with 'Serialize'.
+func DeserializeTx(raw []byte) (*Transaction, error) {
+	tx := new(Transaction)
+	return tx, deserializeTxInto(raw, tx)
+}
+
+func deserializeTxInto(raw []byte, tx *Transaction) error {
+	*tx = Transaction{}
+	n, e := encoder.DeserializeRawToValue(raw, reflect.ValueOf(tx))
+	if e != nil {
+		return e
+	}
+	if n == len(raw) {
+		return nil
+	}
+	return tx.deserializeFields(raw[n:])
+}
+
+// Hash returns the hash of the transaction, excluding the witness.
 func (tx Transaction) Hash() TxHash {
-	return TxHash(cipher.SumSHA256(tx.Serialize()))
+	return TxHash(cipher.SumSHA256(
+		append(encoder.Serialize(tx), tx.serializeFields(true)...)))
 }
 
 func (tx Transaction) HashInner() cipher.SHA256 {
@@ -118,38 +502,174 @@ func (tx Transaction) Sign(sk cipher.SecKey) cipher.Sig {
 func (tx Transaction) VerifyWith(in *Transaction, genPK cipher.PubKey) error {
 	var isGen = in == nil
 
+	if e := tx.VerifyMemo(); e != nil {
+		return e
+	}
+
 	// Check input.
 	if isGen == true {
+		if tx.Witness != nil {
+			return errors.New("generation tx cannot have a witness")
+		}
+		if len(tx.Inputs) > 0 {
+			return errors.New("generation tx cannot have multiple inputs")
+		}
+		if tx.Escrow != nil {
+			return errors.New("generation tx cannot settle an escrow")
+		}
+		if tx.Metadata != nil {
+			return errors.New("generation tx cannot set metadata")
+		}
+		if tx.Breed != nil {
+			return errors.New("generation tx cannot breed kitties")
+		}
+		if tx.Auction != nil {
+			return errors.New("generation tx cannot perform an auction action")
+		}
+		if tx.Rotation != nil {
+			return errors.New("generation tx cannot rotate creator keys")
+		}
+		if tx.Admin != nil {
+			return errors.New("generation tx cannot perform an admin action")
+		}
+		if tx.Royalty != nil {
+			if e := tx.Royalty.verifyGen(); e != nil {
+				return e
+			}
+		}
+		if tx.Genesis != nil {
+			if e := tx.Genesis.Verify(); e != nil {
+				return e
+			}
+		}
 		if exp := EmptyTxHash(); tx.In != exp {
 			return fmt.Errorf("generation tx expected 'in:%s', but we got 'in:%s'",
 				exp.Hex(), tx.In.Hex())
 		}
 
 		// Check signature based on trusted generation public key 'genPK'.
-		return cipher.VerifySignature(genPK, tx.Sig, tx.HashInner())
+		return verifySignature(genPK, tx.Sig, tx.HashInner())
 
 	} else {
-		if exp := in.Hash(); tx.In != exp {
+		return tx.VerifyWithInputs([]*Transaction{in})
+	}
+}
+
+// VerifyWithInputs checks the inputs and signature of a transfer tx, where
+// 'ins' are the unspent txs of the kitties of the tx (in the order of
+// 'KittyIDs'). All kitties should be owned by the same address.
+func (tx Transaction) VerifyWithInputs(ins []*Transaction) error {
+	if e := tx.VerifyMemo(); e != nil {
+		return e
+	}
+	if e := tx.VerifyInputs(ins); e != nil {
+		return e
+	}
+
+	// Check signature is of the owner of the previous unspent outputs.
+	// This is independent of the generation public key.
+	return tx.VerifyOwner(ins[0].Out)
+}
+
+// VerifyMemo checks that the memo is within 'MaxMemoSize'.
+func (tx Transaction) VerifyMemo() error {
+	if len(tx.Memo) > MaxMemoSize {
+		return fmt.Errorf("tx memo has %d bytes, the maximum is %d",
+			len(tx.Memo), MaxMemoSize)
+	}
+	return nil
+}
+
+// VerifyInputs checks that 'ins' are the input txs of the kitties of the tx,
+// and that they are of the same owner. The signature is not checked.
+func (tx Transaction) VerifyInputs(ins []*Transaction) error {
+	if tx.Genesis != nil {
+		return ErrGenesisNotFirst
+	}
+	if len(tx.Inputs) > MaxTxInputs {
+		return fmt.Errorf("transfer tx has %d additional inputs, the maximum is %d",
+			len(tx.Inputs), MaxTxInputs)
+	}
+	if exp := len(tx.Inputs) + 1; len(ins) != exp {
+		return fmt.Errorf("transfer tx expected %d inputs, but we got %d",
+			exp, len(ins))
+	}
+	var (
+		kittyIDs = tx.KittyIDs()
+		seen     = make(map[KittyID]struct{}, len(kittyIDs))
+	)
+	for i, in := range ins {
+		inHash, kittyID := tx.In, tx.KittyID
+		if i > 0 {
+			inHash, kittyID = tx.Inputs[i-1].In, tx.Inputs[i-1].KittyID
+		}
+		if in == nil {
+			return fmt.Errorf("no input tx for 'kitty_id:%d'", kittyID)
+		}
+		if exp := in.Hash(); inHash != exp {
 			return fmt.Errorf("transfer tx expected 'in:%s', but we got 'in:%s'",
-				exp.Hex(), tx.In.Hex())
+				exp.Hex(), inHash.Hex())
 		}
 		// Check kitty.
-		if exp := in.KittyID; tx.KittyID != exp {
+		if !in.HasKitty(kittyID) {
 			return fmt.Errorf("tx expected 'kitty_id:%d', but we got 'kitty_id:%d'",
-				exp, tx.KittyID)
+				in.KittyID, kittyID)
 		}
+		if _, ok := seen[kittyID]; ok {
+			return fmt.Errorf("tx has repeated 'kitty_id:%d'", kittyID)
+		}
+		seen[kittyID] = struct{}{}
 
-		// Check signature based on previous unspent output.
-		return cipher.ChkSig(in.Out, tx.HashInner(), tx.Sig)
+		if in.Out != ins[0].Out {
+			return ErrMixedOwners
+		}
 	}
+	return nil
+}
+
+// VerifyOwner recovers the address of the tx signer from the signature, and
+// returns ErrNotOwner if it is not the expected 'owner' address.
+// Transactions with a witness are instead checked to be signed by the owner's
+// multisig, and transactions settling an escrow by an authorised party of the
+// owner's escrow.
+func (tx Transaction) VerifyOwner(owner cipher.Address) error {
+	hash := tx.HashInner()
+	if tx.Witness != nil {
+		if tx.Escrow != nil {
+			return errors.New("tx cannot have both a witness and an escrow")
+		}
+		return tx.Witness.Verify(owner, hash)
+	}
+	signer, e := pubKeyFromSig(tx.Sig, hash)
+	if e != nil {
+		return e
+	}
+	if tx.Escrow != nil {
+		e = tx.Escrow.VerifySpend(owner, tx.Out, cipher.AddressFromPubKey(signer))
+	} else if cipher.AddressFromPubKey(signer) != owner {
+		e = ErrNotOwner
+	}
+	if e != nil {
+		return e
+	}
+	return verifySignature(signer, tx.Sig, hash)
+}
+
+// SignerAddress recovers the address of the tx signer from the signature.
+func (tx Transaction) SignerAddress() (cipher.Address, error) {
+	signer, e := pubKeyFromSig(tx.Sig, tx.HashInner())
+	if e != nil {
+		return cipher.Address{}, e
+	}
+	return cipher.AddressFromPubKey(signer), nil
 }
 
 // IsKittyGen returns true if tx is a generation tx:
 //		- Tx is of the correct structure to create a new kitty.
 //		- Tx is of the right address to create a new kitty.
 func (tx Transaction) IsKittyGen(pk cipher.PubKey) bool {
-	// Check input tx hash is empty.
-	if tx.In != EmptyTxHash() {
+	// Check input tx hash is empty, and that tx is not a rotation or admin tx.
+	if tx.In != EmptyTxHash() || tx.Rotation != nil || tx.Admin != nil {
 		return false
 	}
 	// Check output address.
@@ -162,6 +682,56 @@ func (tx Transaction) IsKittyGen(pk cipher.PubKey) bool {
 
 // String returns human readable string of transaction.
 func (tx Transaction) String() string {
-	return fmt.Sprintf("kitty_id:%d|in:%s|out:%s|sig:%s",
+	str := fmt.Sprintf("kitty_id:%d|in:%s|out:%s|sig:%s",
 		tx.KittyID, tx.In.Hex(), tx.Out.String(), tx.Sig.Hex())
+	for _, in := range tx.Inputs {
+		str += fmt.Sprintf("|kitty_id:%d|in:%s", in.KittyID, in.In.Hex())
+	}
+	if len(tx.Memo) > 0 {
+		str += fmt.Sprintf("|memo:%x", tx.Memo)
+	}
+	if tx.Fee > 0 {
+		str += fmt.Sprintf("|fee:%d", tx.Fee)
+	}
+	if !tx.Lock.IsZero() {
+		str += fmt.Sprintf("|lock_seq:%d|lock_ts:%d", tx.Lock.Seq, tx.Lock.TS)
+	}
+	if tx.Escrow != nil {
+		str += fmt.Sprintf("|escrow:%s", tx.Escrow.Address().String())
+	}
+	if tx.Metadata != nil {
+		str += fmt.Sprintf("|metadata:%x", tx.Metadata.Serialize())
+	}
+	if tx.Breed != nil {
+		str += fmt.Sprintf("|child_id:%d", tx.Breed.ChildID)
+	}
+	if tx.Auction != nil {
+		str += fmt.Sprintf("|auction:%x", tx.Auction.Serialize())
+	}
+	if tx.Royalty != nil {
+		str += fmt.Sprintf("|royalty:%d%%|price:%d", tx.Royalty.Percent, tx.Royalty.Price)
+	}
+	if tx.Rotation != nil {
+		for _, pk := range tx.Rotation.Creators {
+			str += fmt.Sprintf("|creator:%s", pk.Hex())
+		}
+	}
+	if tx.Admin != nil {
+		for _, pk := range tx.Admin.Revoke {
+			str += fmt.Sprintf("|revoke:%s", pk.Hex())
+		}
+		if tx.Admin.Freeze {
+			str += "|freeze"
+		}
+	}
+	if tx.Genesis != nil {
+		str += fmt.Sprintf("|chain_id:%s|genesis_ts:%d", tx.Genesis.ChainID, tx.Genesis.TS)
+	}
+	if tx.Network != "" {
+		str += fmt.Sprintf("|network:%s", tx.Network)
+	}
+	if tx.Version != TxVersionLegacy {
+		str += fmt.Sprintf("|version:%d", tx.Version)
+	}
+	return str
 }
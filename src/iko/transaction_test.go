@@ -67,10 +67,170 @@ func runTransactionIsKittyGen(t *testing.T) {
 	})
 }
 
+func runTransactionVerifyOwnerTest(t *testing.T) {
+	var (
+		_, genSK   = cipher.GenerateDeterministicKeyPair([]byte("gen seed"))
+		pk1, sk1   = cipher.GenerateDeterministicKeyPair([]byte("owner seed"))
+		_, sk2     = cipher.GenerateDeterministicKeyPair([]byte("thief seed"))
+		ownerAddr  = cipher.AddressFromPubKey(pk1)
+		thiefAddr  = cipher.AddressFromSecKey(sk2)
+		genPK      = cipher.PubKeyFromSecKey(genSK)
+		genTx      = NewGenTx(KittyID(7), genSK)
+		toOwner, _ = NewTransferTx(genTx, ownerAddr, genSK)
+	)
+
+	t.Run("Transaction_VerifyOwner_Accepted", func(t *testing.T) {
+		tx, e := NewTransferTx(toOwner, thiefAddr, sk1)
+		require.NoError(t, e,
+			"should succeed")
+		require.NoError(t, tx.VerifyWith(toOwner, genPK),
+			"transfer signed by the owner should be accepted")
+	})
+
+	t.Run("Transaction_VerifyOwner_Rejected", func(t *testing.T) {
+		tx := &Transaction{
+			KittyID: toOwner.KittyID,
+			In:      toOwner.Hash(),
+			Out:     thiefAddr,
+		}
+		tx.Sig = tx.Sign(sk2)
+
+		require.NoError(t,
+			cipher.VerifySignedHash(tx.Sig, tx.HashInner()),
+			"signature should be internally valid")
+		require.Equal(t, ErrNotOwner, tx.VerifyWith(toOwner, genPK),
+			"transfer signed by a non-owner should be rejected")
+	})
+
+	t.Run("Transaction_VerifyOwner_GenKeyRejected", func(t *testing.T) {
+		tx := &Transaction{
+			KittyID: toOwner.KittyID,
+			In:      toOwner.Hash(),
+			Out:     thiefAddr,
+		}
+		tx.Sig = tx.Sign(genSK)
+
+		require.Equal(t, ErrNotOwner, tx.VerifyWith(toOwner, genPK),
+			"generation key should not be able to transfer a kitty it does not own")
+	})
+}
+
 func TestTransaction_Verify(t *testing.T) {
 	runTransactionVerifyTest(t)
 }
 
+func TestTransaction_VerifyOwner(t *testing.T) {
+	runTransactionVerifyOwnerTest(t)
+}
+
 func TestTransaction_IsKittyGen(t *testing.T) {
 	runTransactionIsKittyGen(t)
 }
+
+func TestTransaction_MultiTransfer(t *testing.T) {
+	var (
+		gens     = []*Transaction{NewGenTx(0, GenSK), NewGenTx(1, GenSK), NewGenTx(2, GenSK)}
+		kittyIDs = []KittyID{0, 1, 2}
+		pk, sk   = cipher.GenerateKeyPair()
+		addr     = cipher.AddressFromPubKey(pk)
+	)
+	tx, err := NewMultiTransferTx(gens, kittyIDs, addr, GenSK)
+	require.NoError(t, err)
+	require.True(t, tx.IsMultiTransfer())
+	require.Equal(t, KittyIDs{0, 1, 2}, tx.KittyIDs())
+	require.NoError(t, tx.VerifyWithInputs(gens))
+
+	t.Run("Serialize", func(t *testing.T) {
+		reqTx, err := DeserializeTx(tx.Serialize())
+		require.NoError(t, err)
+		require.Equal(t, *tx, *reqTx)
+		require.Equal(t, tx.Hash(), reqTx.Hash())
+
+		single := *tx
+		single.Inputs = nil
+		require.NotEqual(t, single.Hash(), tx.Hash(),
+			"inputs should be part of the tx hash")
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := *tx
+		tampered.Inputs = tx.Inputs[:1]
+		require.Error(t, tampered.VerifyWithInputs(gens[:2]),
+			"removing an input should invalidate the signature")
+
+		require.Error(t, tx.VerifyWith(gens[0], GenPK),
+			"multi-kitty transfer should be verified with all inputs")
+		require.Error(t, tx.VerifyWithInputs([]*Transaction{gens[0], gens[2], gens[1]}),
+			"inputs should be in order")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := NewMultiTransferTx(gens[:2], KittyIDs{0, 0}, addr, GenSK)
+		require.Error(t, err, "repeated kitty should be rejected")
+
+		_, err = NewMultiTransferTx(gens, kittyIDs, addr, sk)
+		require.Error(t, err, "non-owner should not sign")
+
+		moved, err := NewTransferTx(gens[1], addr, GenSK)
+		require.NoError(t, err)
+		_, err = NewMultiTransferTx([]*Transaction{gens[0], moved}, KittyIDs{0, 1}, addr, GenSK)
+		require.Equal(t, ErrMixedOwners, err)
+	})
+
+	t.Run("SpendMulti", func(t *testing.T) {
+		back, err := NewTransferTx(tx, cipher.AddressFromPubKey(GenPK), sk)
+		require.NoError(t, err, "kitty of multi-kitty transfer should be spendable")
+		require.NoError(t, back.VerifyWith(tx, GenPK))
+
+		back2, err := NewMultiTransferTx([]*Transaction{tx, tx}, KittyIDs{2, 1},
+			cipher.AddressFromPubKey(GenPK), sk)
+		require.NoError(t, err)
+		require.NoError(t, back2.VerifyWithInputs([]*Transaction{tx, tx}))
+	})
+}
+
+func TestTransaction_Memo(t *testing.T) {
+	gen := NewGenTx(0, GenSK)
+	tx := NewUnsignedTransferTx(gen, cipher.AddressFromPubKey(GenPK))
+	tx.Memo = []byte("order:1234")
+	tx.Sig = tx.Sign(GenSK)
+	require.NoError(t, tx.VerifyWith(gen, GenPK))
+
+	reqTx, err := DeserializeTx(tx.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, *tx, *reqTx, "memo should be serialized")
+	require.Contains(t, tx.String(), "memo:")
+
+	tampered := *tx
+	tampered.Memo = []byte("order:4321")
+	require.Error(t, tampered.VerifyWith(gen, GenPK),
+		"memo should be covered by the signature")
+
+	tx.Memo = make([]byte, MaxMemoSize+1)
+	tx.Sig = tx.Sign(GenSK)
+	require.Error(t, tx.VerifyWith(gen, GenPK), "oversized memo should be rejected")
+}
+
+func TestTxLock(t *testing.T) {
+	lock := TxLock{Seq: 10, TS: 100}
+	require.False(t, lock.IsZero())
+	require.True(t, lock.IsLocked(9, 100), "should be locked before seq")
+	require.True(t, lock.IsLocked(10, 99), "should be locked before timestamp")
+	require.False(t, lock.IsLocked(10, 100), "should expire at seq and timestamp")
+	require.False(t, TxLock{}.IsLocked(0, 0), "zero lock should never lock")
+
+	gen := NewGenTx(0, GenSK)
+	tx := NewUnsignedTransferTx(gen, cipher.AddressFromPubKey(GenPK))
+	tx.Lock = lock
+	tx.Sig = tx.Sign(GenSK)
+	require.NoError(t, tx.VerifyWith(gen, GenPK))
+
+	reqTx, err := DeserializeTx(tx.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, *tx, *reqTx, "lock should be serialized")
+
+	tampered := *tx
+	tampered.Lock.Seq = 0
+	require.Error(t, tampered.VerifyWith(gen, GenPK),
+		"lock should be covered by the signature")
+}
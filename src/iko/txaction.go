@@ -0,0 +1,84 @@
+package iko
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrChainStopped occurs when injecting txs into a BlockChain that is
+	// closed, or stopped by the 'TxActionStop' policy.
+	ErrChainStopped = errors.New("blockchain is stopped")
+)
+
+// TxActionPolicy determines how the BlockChain handles a 'TxAction' that
+// returns an error.
+type TxActionPolicy string
+
+const (
+	// TxActionLog logs the error, and continues with the next tx. The failed
+	// tx is still broadcast to subscribers, as it is accepted to the chain.
+	TxActionLog TxActionPolicy = "log"
+
+	// TxActionRetry retries the action with exponential backoff (see
+	// 'TxActionRetries' and 'TxActionBackoff'), logging the error and
+	// continuing with the next tx if every retry fails.
+	TxActionRetry TxActionPolicy = "retry"
+
+	// TxActionStop stops the BlockChain, so that no further txs are accepted
+	// or processed. Injecting txs then fails with 'ErrChainStopped'.
+	TxActionStop TxActionPolicy = "stop"
+)
+
+const (
+	// DefaultTxActionRetries is the default number of retries of the
+	// 'TxActionRetry' policy.
+	DefaultTxActionRetries = 3
+
+	// DefaultTxActionBackoff is the default delay before the first retry of
+	// the 'TxActionRetry' policy, which doubles with each retry.
+	DefaultTxActionBackoff = 100 * time.Millisecond
+)
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// runTxAction runs the 'TxAction' of an accepted tx, applying the configured
+// 'TxActionPolicy' if it fails. It returns false if the BlockChain should
+// stop.
+func (bc *BlockChain) runTxAction(txWrap *TxWrapper) bool {
+	e := bc.c.TxAction(&txWrap.Tx)
+	if e == nil {
+		return true
+	}
+	if bc.c.TxActionPolicy == TxActionRetry {
+		backoff := bc.c.TxActionBackoff
+		for i := 0; i < bc.c.TxActionRetries && e != nil; i++ {
+			bc.log.
+				WithError(e).
+				WithField("seq", txWrap.Meta.Seq).
+				WithField("retry", i+1).
+				Warning("tx action failed, retrying")
+
+			select {
+			case <-time.After(backoff):
+			case <-bc.ctx.Done():
+				return true
+			}
+			backoff *= 2
+			e = bc.c.TxAction(&txWrap.Tx)
+		}
+		if e == nil {
+			return true
+		}
+	}
+	bc.log.
+		WithError(e).
+		WithField("seq", txWrap.Meta.Seq).
+		WithField("policy", bc.c.TxActionPolicy).
+		Error("tx action failed")
+
+	bc.c.OnTxActionError(&txWrap.Tx, e)
+	return bc.c.TxActionPolicy != TxActionStop
+}
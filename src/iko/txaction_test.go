@@ -0,0 +1,101 @@
+package iko
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockChain_TxActionPolicy(t *testing.T) {
+	var (
+		errAction = errors.New("action failed")
+		timeout   = time.Second
+	)
+	// newChain creates a chain of which the tx action fails 'fails' times.
+	newChain := func(t *testing.T, policy TxActionPolicy, fails int) (*BlockChain, chan error, func()) {
+		var (
+			mux      sync.Mutex
+			failed   = make(chan error, 10)
+			attempts int
+		)
+		bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+			GenerationPK:    GenPK,
+			TxActionPolicy:  policy,
+			TxActionRetries: 2,
+			TxActionBackoff: time.Millisecond,
+			TxAction: func(tx *Transaction) error {
+				mux.Lock()
+				defer mux.Unlock()
+				if attempts++; attempts <= fails {
+					return errAction
+				}
+				return nil
+			},
+			OnTxActionError: func(tx *Transaction, e error) {
+				failed <- e
+			},
+		})
+		return bc, failed, closeBC
+	}
+
+	t.Run("Log", func(t *testing.T) {
+		bc, failed, closeBC := newChain(t, TxActionLog, 1)
+		defer closeBC()
+
+		sub, unsub := bc.SubscribeTxs(2)
+		defer unsub()
+		injectGenTxs(t, bc, 2)
+		select {
+		case e := <-failed:
+			require.Equal(t, errAction, e)
+		case <-time.After(timeout):
+			t.Fatal("error callback should be called")
+		}
+		for seq := uint64(0); seq < 2; seq++ {
+			select {
+			case txWrap := <-sub:
+				require.Equal(t, seq, txWrap.Meta.Seq, "failed and next tx should be processed")
+			case <-time.After(timeout):
+				t.Fatal("failed and next tx should be processed")
+			}
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		bc, failed, closeBC := newChain(t, TxActionRetry, 2)
+		defer closeBC()
+
+		sub, unsub := bc.SubscribeTxs(2)
+		defer unsub()
+		injectGenTxs(t, bc, 1)
+		select {
+		case txWrap := <-sub:
+			require.Equal(t, uint64(0), txWrap.Meta.Seq, "retried tx should be processed")
+		case <-time.After(timeout):
+			t.Fatal("retried tx should be processed")
+		}
+		require.Len(t, failed, 0, "succeeding retry should not call callback")
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		bc, failed, closeBC := newChain(t, TxActionStop, 1)
+		defer closeBC()
+
+		injectGenTxs(t, bc, 1)
+		select {
+		case <-failed:
+		case <-time.After(timeout):
+			t.Fatal("error callback should be called")
+		}
+		select {
+		case <-bc.ctx.Done():
+		case <-time.After(timeout):
+			t.Fatal("chain should stop")
+		}
+		_, err := bc.InjectTx(NewGenTx(1, GenSK))
+		require.Equal(t, ErrChainStopped, err)
+	})
+}
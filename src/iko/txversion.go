@@ -0,0 +1,82 @@
+package iko
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const (
+	// TxVersionLegacy is the version of txs encoded before versioning. It is
+	// not encoded, so that legacy txs are encoded (and hashed) as before.
+	TxVersionLegacy uint8 = 0
+
+	// TxVersion1 encodes the version byte as the first optional field, and
+	// requires the optional fields to be encoded canonically: in the order of
+	// 'serializeFields', and without duplicates.
+	TxVersion1 uint8 = 1
+
+	// TxVersionLatest is the latest version that txs can be encoded with.
+	TxVersionLatest = TxVersion1
+)
+
+var (
+	// ErrUnknownTxVersion occurs when a tx is of a version that this node
+	// cannot decode or verify.
+	ErrUnknownTxVersion = errors.New("tx is of an unknown version")
+
+	// ErrTxVersionRefused occurs when a tx is of a version that is not
+	// accepted at it's seq by the configured 'TxVersionPolicy'.
+	ErrTxVersionRefused = errors.New("tx version is not accepted")
+
+	// ErrTxNotCanonical occurs when the optional fields of a versioned tx are
+	// not encoded canonically.
+	ErrTxNotCanonical = errors.New("tx fields are not encoded canonically")
+)
+
+// TxVersionPolicy determines whether txs of the version are accepted at the
+// seq of the chain. As the chain is replayed with the same policy, a version
+// should be accepted for the seqs of which it's txs are already in the chain.
+type TxVersionPolicy func(version uint8, seq uint64) error
+
+// AnyTxVersionPolicy accepts txs of every known version, at every seq.
+func AnyTxVersionPolicy(version uint8, _ uint64) error {
+	if version > TxVersionLatest {
+		return fmt.Errorf("%w: %d", ErrUnknownTxVersion, version)
+	}
+	return nil
+}
+
+// TxVersionSchedule accepts txs of a version from the seq it is activated at,
+// so that the nodes of a network can upgrade to a new version before it's
+// txs are injected. Versions that are not scheduled are refused, hence
+// 'TxVersionLegacy' should be scheduled at 0 for existing chains.
+func TxVersionSchedule(activations map[uint8]uint64) TxVersionPolicy {
+	return func(version uint8, seq uint64) error {
+		if version > TxVersionLatest {
+			return fmt.Errorf("%w: %d", ErrUnknownTxVersion, version)
+		}
+		if from, ok := activations[version]; !ok || seq < from {
+			return fmt.Errorf("%w: version %d at seq %d", ErrTxVersionRefused, version, seq)
+		}
+		return nil
+	}
+}
+
+// decodeTxFields decodes the optional fields of the version of the tx.
+func (tx *Transaction) decodeTxFields(fields []txField, raw []byte) error {
+	switch tx.Version {
+	case TxVersionLegacy:
+		return tx.decodeFields(fields)
+	case TxVersion1:
+		if e := tx.decodeFields(fields); e != nil {
+			return e
+		}
+		if !bytes.Equal(tx.serializeFields(false), raw) {
+			return ErrTxNotCanonical
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknownTxVersion, tx.Version)
+	}
+}
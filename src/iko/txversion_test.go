@@ -0,0 +1,89 @@
+package iko
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/stretchr/testify/require"
+)
+
+// newVersionedGenTx creates a generation tx of the version.
+func newVersionedGenTx(kittyID KittyID, version uint8) *Transaction {
+	tx := NewUnsignedGenTx(kittyID, cipher.AddressFromPubKey(GenPK))
+	tx.Version = version
+	tx.Sig = tx.Sign(GenSK)
+	return tx
+}
+
+func TestTransaction_Version(t *testing.T) {
+	legacy := NewGenTx(0, GenSK)
+	require.Equal(t, encoder.Serialize(*legacy), legacy.Serialize(),
+		"legacy tx should not encode a version")
+
+	tx := newVersionedGenTx(0, TxVersion1)
+	require.NoError(t, tx.VerifyWith(nil, GenPK))
+	require.NotEqual(t, legacy.Hash(), tx.Hash(), "version should be part of the tx hash")
+
+	decoded, err := DeserializeTx(tx.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, tx, decoded, "version should be decoded")
+
+	encode := func(fields ...txField) []byte {
+		return append(encoder.Serialize(*tx), encoder.Serialize(fields)...)
+	}
+	version := func(v uint8) txField {
+		return txField{Tag: txFieldVersion, Data: []byte{v}}
+	}
+	memo := txField{Tag: txFieldMemo, Data: []byte("memo")}
+	fee := txField{Tag: txFieldFee, Data: encoder.Serialize(uint64(1))}
+
+	t.Run("Canonical", func(t *testing.T) {
+		_, err := DeserializeTx(encode(version(TxVersion1), memo, fee))
+		require.NoError(t, err)
+
+		_, err = DeserializeTx(encode(version(TxVersion1), memo, memo))
+		require.Equal(t, ErrTxNotCanonical, err, "duplicate fields should be refused")
+
+		_, err = DeserializeTx(encode(version(TxVersion1), fee, memo))
+		require.Equal(t, ErrTxNotCanonical, err, "unordered fields should be refused")
+
+		_, err = DeserializeTx(encode(memo, memo))
+		require.NoError(t, err, "legacy txs need not be canonical")
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		_, err := DeserializeTx(encode(version(TxVersionLatest + 1)))
+		require.True(t, errors.Is(err, ErrUnknownTxVersion), err)
+
+		_, err = DeserializeTx(encode(version(TxVersionLegacy)))
+		require.Error(t, err, "legacy version should not be encoded")
+
+		_, err = DeserializeTx(encode(memo, version(TxVersion1)))
+		require.Error(t, err, "version should be the first field")
+	})
+}
+
+func TestBlockChain_TxVersionPolicy(t *testing.T) {
+	bc, closeBC := newTestBoltBlockChain(t, &BlockChainConfig{
+		GenerationPK:    GenPK,
+		TxVersionPolicy: TxVersionSchedule(map[uint8]uint64{TxVersionLegacy: 0, TxVersion1: 2}),
+	})
+	defer closeBC()
+
+	_, err := bc.InjectTx(newVersionedGenTx(0, TxVersion1))
+	require.True(t, errors.Is(err, ErrTxVersionRefused), "version should not be accepted before activation")
+	require.True(t, errors.Is(bc.SubmitTx(newVersionedGenTx(0, TxVersion1)), ErrTxVersionRefused),
+		"version should not enter the mempool before activation")
+
+	injectGenTxs(t, bc, 2)
+	_, err = bc.InjectTx(newVersionedGenTx(2, TxVersion1))
+	require.NoError(t, err, "version should be accepted after activation")
+
+	_, err = bc.InjectTx(newVersionedGenTx(3, TxVersionLatest+1))
+	require.True(t, errors.Is(err, ErrUnknownTxVersion), err)
+
+	require.NoError(t, AnyTxVersionPolicy(TxVersionLegacy, 0))
+	require.NoError(t, AnyTxVersionPolicy(TxVersionLatest, 0))
+}
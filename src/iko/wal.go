@@ -0,0 +1,207 @@
+package iko
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WAL is a write-ahead log of txs that are about to be appended to the chain.
+// Txs are logged (and synced) before they are applied to the state, and the
+// log is reset once they are appended. Txs that are applied to the state, but
+// fail to be appended (or are interrupted by a crash), are redone from the log
+// so that the chain and state do not diverge.
+type WAL struct {
+	mux sync.Mutex
+	f   *os.File
+}
+
+// OpenWAL opens the write-ahead log of path, creating it if it does not exist.
+func OpenWAL(path string) (*WAL, error) {
+	if e := os.MkdirAll(filepath.Dir(path), os.FileMode(0700)); e != nil {
+		return nil, e
+	}
+	f, e := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if e != nil {
+		return nil, e
+	}
+	return &WAL{f: f}, nil
+}
+
+// Log appends the txs to the log, and syncs it. Each record is of the length
+// and checksum of the tx, then the tx encoded with 'BinaryTxCodec'.
+func (w *WAL) Log(txWraps ...TxWrapper) error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if _, e := w.f.Seek(0, io.SeekEnd); e != nil {
+		return e
+	}
+	bw := bufio.NewWriter(w.f)
+	for _, txWrap := range txWraps {
+		raw := BinaryTxCodec{}.EncodeTx(txWrap)
+		var head [8]byte
+		binary.BigEndian.PutUint32(head[:4], uint32(len(raw)))
+		binary.BigEndian.PutUint32(head[4:], crc32.ChecksumIEEE(raw))
+		if _, e := bw.Write(head[:]); e != nil {
+			return e
+		}
+		if _, e := bw.Write(raw); e != nil {
+			return e
+		}
+	}
+	if e := bw.Flush(); e != nil {
+		return e
+	}
+	return w.f.Sync()
+}
+
+// Records reads the logged txs, in order. A record that is partially written
+// (such as by a crash while logging) ends the log, as it's tx was never
+// applied.
+func (w *WAL) Records() ([]TxWrapper, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if _, e := w.f.Seek(0, io.SeekStart); e != nil {
+		return nil, e
+	}
+	var (
+		br  = bufio.NewReader(w.f)
+		out []TxWrapper
+	)
+	for {
+		var head [8]byte
+		if _, e := io.ReadFull(br, head[:]); e != nil {
+			return out, nil
+		}
+		raw := make([]byte, binary.BigEndian.Uint32(head[:4]))
+		if _, e := io.ReadFull(br, raw); e != nil {
+			return out, nil
+		}
+		if crc32.ChecksumIEEE(raw) != binary.BigEndian.Uint32(head[4:]) {
+			return out, nil
+		}
+		var txWrap TxWrapper
+		if e := (BinaryTxCodec{}).DecodeTx(raw, &txWrap); e != nil {
+			return out, nil
+		}
+		out = append(out, txWrap)
+	}
+}
+
+// Reset empties the log, once the logged txs are appended to the chain (or
+// are discarded).
+func (w *WAL) Reset() error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if e := w.f.Truncate(0); e != nil {
+		return e
+	}
+	return w.f.Sync()
+}
+
+// Close closes the file of the log.
+func (w *WAL) Close() error {
+	return w.f.Close()
+}
+
+/*
+	<<< BLOCKCHAIN >>>
+*/
+
+// addTx adds the tx to the chain with 'check', which applies it to the state.
+// With a WAL, the tx is logged beforehand, and if the chain write fails after
+// the tx is applied, the write is redone from the log. If redoing fails too,
+// the tx is kept in the log, and is redone before the next write (see
+// 'redoWAL') or on the next start.
+func (bc *BlockChain) addTx(txWrap TxWrapper, check TxChecker) error {
+	if bc.wal == nil {
+		return bc.chain.AddTx(txWrap, check)
+	}
+	if e := bc.wal.Log(txWrap); e != nil {
+		return e
+	}
+	applied := false
+	e := bc.chain.AddTx(txWrap, func(tx *Transaction) error {
+		if e := check(tx); e != nil {
+			return e
+		}
+		applied = true
+		return nil
+	})
+	if e != nil && applied {
+		bc.log.
+			WithError(e).
+			WithField("seq", txWrap.Meta.Seq).
+			Warning("failed to append applied tx, redoing from wal")
+		return bc.redoWAL()
+	}
+	if e := bc.wal.Reset(); e != nil {
+		bc.log.WithError(e).Error("failed to reset wal")
+	}
+	return e
+}
+
+// redoWAL appends the logged txs, which are already applied to the state, to
+// the chain without checking them again. Txs already in the chain are skipped.
+// The log is reset once all txs are appended. Writes redo the log before
+// they start, so that txs are not appended past a tx that is not.
+func (bc *BlockChain) redoWAL() error {
+	if bc.wal == nil {
+		return nil
+	}
+	txWraps, e := bc.wal.Records()
+	if e != nil || len(txWraps) == 0 {
+		return e
+	}
+	for _, txWrap := range txWraps {
+		if txWrap.Meta.Seq < bc.chain.Len() {
+			continue
+		}
+		e := bc.chain.AddTx(txWrap, func(*Transaction) error { return nil })
+		if e != nil {
+			return e
+		}
+		bc.log.
+			WithField("seq", txWrap.Meta.Seq).
+			Info("redone tx from wal")
+	}
+	return bc.wal.Reset()
+}
+
+// walPending reports whether the WAL holds txs that are applied to the state,
+// but are not yet appended to the chain.
+func (bc *BlockChain) walPending() bool {
+	if bc.wal == nil {
+		return false
+	}
+	txWraps, e := bc.wal.Records()
+	return e != nil || len(txWraps) > 0
+}
+
+// closeWAL closes the WAL, if any.
+func (bc *BlockChain) closeWAL() {
+	if bc.wal == nil {
+		return
+	}
+	if e := bc.wal.Close(); e != nil {
+		bc.log.WithError(e).Error("failed to close wal")
+	}
+}
+
+// recoverWAL redoes the txs logged before the last stop, before the state is
+// built, so that the txs are applied by the replay. Txs that cannot be
+// redone are discarded, as the state is rebuilt from the chain.
+func (bc *BlockChain) recoverWAL() error {
+	if e := bc.redoWAL(); e != nil {
+		bc.log.WithError(e).Warning("failed to redo txs of wal, discarding them")
+		return bc.wal.Reset()
+	}
+	return nil
+}
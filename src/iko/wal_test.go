@@ -0,0 +1,123 @@
+package iko
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errFlakyWrite = errors.New("flaky write")
+
+// flakyChainDB fails the next 'fails' writes after the tx is checked (and so
+// applied to the state).
+type flakyChainDB struct {
+	ChainDB
+	fails int
+}
+
+func (c *flakyChainDB) AddTx(txWrap TxWrapper, check TxChecker) error {
+	if c.fails > 0 {
+		c.fails--
+		if e := check(&txWrap.Tx); e != nil {
+			return e
+		}
+		return errFlakyWrite
+	}
+	return c.ChainDB.AddTx(txWrap, check)
+}
+
+func TestWAL_Records(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	path = filepath.Join(filepath.Dir(path), "chain.wal")
+
+	wal, err := OpenWAL(path)
+	require.NoError(t, err)
+	defer wal.Close()
+
+	txWraps := []TxWrapper{
+		{Tx: *NewGenTx(0, GenSK), Meta: TxMeta{Seq: 0}},
+		{Tx: *NewGenTx(1, GenSK), Meta: TxMeta{Seq: 1}},
+	}
+	require.NoError(t, wal.Log(txWraps...))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 1, 0, 1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	got, err := wal.Records()
+	require.NoError(t, err)
+	require.Len(t, got, 2, "torn record should end the log")
+	for i := range txWraps {
+		require.Equal(t, txWraps[i].Tx.Hash(), got[i].Tx.Hash())
+		require.Equal(t, txWraps[i].Meta.Seq, got[i].Meta.Seq)
+	}
+
+	require.NoError(t, wal.Reset())
+	got, err = wal.Records()
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestBlockChain_WAL(t *testing.T) {
+	path, rmTemp := tempBoltPath(t)
+	defer rmTemp()
+	walPath := filepath.Join(filepath.Dir(path), "chain.wal")
+
+	boltDB := newBoltChainDB(t, path)
+	defer boltDB.Close()
+	chainDB := &flakyChainDB{ChainDB: boltDB}
+
+	bc, err := NewBlockChain(context.Background(),
+		&BlockChainConfig{GenerationPK: GenPK, WALPath: walPath}, chainDB, NewMemoryState())
+	require.NoError(t, err)
+
+	t.Run("Redo", func(t *testing.T) {
+		chainDB.fails = 1
+		meta, err := bc.InjectTx(NewGenTx(0, GenSK))
+		require.NoError(t, err, "failed write should be redone")
+		require.Equal(t, uint64(0), meta.Seq)
+		require.Equal(t, uint64(1), bc.chain.Len())
+		require.False(t, bc.walPending())
+	})
+
+	t.Run("RedoOnNextWrite", func(t *testing.T) {
+		chainDB.fails = 2
+		tx := NewGenTx(1, GenSK)
+		_, err := bc.InjectTx(tx)
+		require.Equal(t, errFlakyWrite, err)
+		require.True(t, bc.walPending(), "applied tx should be kept in the wal")
+		_, ok := bc.GetKittyState(1)
+		require.True(t, ok, "tx should be applied to the state")
+
+		meta, err := bc.InjectTx(NewGenTx(2, GenSK))
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), meta.Seq, "pending tx should be appended first")
+		txWrap, err := bc.GetTxOfSeq(1)
+		require.NoError(t, err)
+		require.Equal(t, tx.Hash(), txWrap.Tx.Hash())
+	})
+
+	t.Run("RedoOnStart", func(t *testing.T) {
+		chainDB.fails = 2
+		_, err := bc.InjectTx(NewGenTx(3, GenSK))
+		require.Equal(t, errFlakyWrite, err)
+		bc.Close()
+
+		bc, err = NewBlockChain(context.Background(),
+			&BlockChainConfig{GenerationPK: GenPK, WALPath: walPath}, boltDB, NewMemoryState())
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), bc.chain.Len(), "logged tx should be appended on start")
+		require.False(t, bc.walPending())
+		_, ok := bc.GetKittyState(3)
+		require.True(t, ok, "logged tx should be replayed")
+	})
+
+	bc.Close()
+}
@@ -0,0 +1,257 @@
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/http"
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var (
+	// ErrRootMismatch occurs when the node replies a segment root that
+	// differs from a trusted root, or from a root of a complete segment that
+	// is already known.
+	ErrRootMismatch = errors.New("segment root does not match the known root")
+
+	// ErrNoRoot occurs when verifying a tx of a segment of which no root is
+	// known, even after synchronizing with the node.
+	ErrNoRoot = errors.New("no known root of segment")
+)
+
+// Node is the API of a full node that the light client uses. It is
+// implemented by 'http.RPCClient'.
+type Node interface {
+	GetStatus() (*http.StatusReply, error)
+	GetSegmentRoot(segment uint64) (*http.SegmentRootReply, error)
+	GetTxProof(txHash iko.TxHash) (*http.TxProofReply, error)
+	GetTxOfHash(txHash iko.TxHash) (*http.TxReply, error)
+	GetKitty(kittyID iko.KittyID) (*http.KittyReply, error)
+}
+
+type Config struct {
+	NodeURL      string            // Address of the full node's web RPC.
+	TrustedRoots []iko.SegmentRoot // Optional, roots published by a trusted source.
+}
+
+// Client is a light client, which only keeps the head of the chain and the
+// Merkle roots of it's segments. Txs are obtained from a full node on demand,
+// and verified with Merkle proofs against the known roots.
+type Client struct {
+	c    *Config
+	node Node
+
+	mux     sync.RWMutex
+	empty   bool
+	headSeq uint64
+	roots   map[uint64]iko.SegmentRoot
+	trusted map[uint64]iko.SegmentRoot
+}
+
+// New creates a light client of the node of 'NodeURL'. 'Sync' should be
+// called to obtain the head and roots of the chain.
+func New(c *Config) *Client {
+	return NewWithNode(c, http.NewRPCClient(c.NodeURL))
+}
+
+// NewWithNode creates a light client of the given node, of which 'NodeURL' of
+// the config is not used.
+func NewWithNode(c *Config, node Node) *Client {
+	client := &Client{
+		c:       c,
+		node:    node,
+		empty:   true,
+		roots:   make(map[uint64]iko.SegmentRoot),
+		trusted: make(map[uint64]iko.SegmentRoot),
+	}
+	for _, root := range c.TrustedRoots {
+		client.trusted[root.Segment] = root
+	}
+	return client
+}
+
+// HeadSeq returns the seq of the head tx, as of the last 'Sync'. It returns
+// false if the chain is empty.
+func (c *Client) HeadSeq() (uint64, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.headSeq, !c.empty
+}
+
+// Root returns the known root of the segment.
+func (c *Client) Root(segment uint64) (iko.SegmentRoot, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	root, ok := c.roots[segment]
+	return root, ok
+}
+
+// Sync obtains the head of the chain from the node, and the roots of the
+// segments that are not known to be complete. Roots are checked against the
+// trusted roots, and the roots of complete segments should never change.
+func (c *Client) Sync() error {
+	status, e := c.node.GetStatus()
+	if e != nil {
+		return e
+	}
+	if status.Empty {
+		return nil
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for segment := uint64(0); segment <= status.HeadSeq/iko.MerkleSegmentSize; segment++ {
+		if root, ok := c.roots[segment]; ok && root.Size == iko.MerkleSegmentSize {
+			continue
+		}
+		if e := c.syncRoot(segment); e != nil {
+			return e
+		}
+	}
+	c.empty, c.headSeq = false, status.HeadSeq
+	return nil
+}
+
+// syncRoot obtains the root of the segment from the node. The caller should
+// hold the lock.
+func (c *Client) syncRoot(segment uint64) error {
+	reply, e := c.node.GetSegmentRoot(segment)
+	if e != nil {
+		return e
+	}
+	root, e := reply.SegmentRoot()
+	if e != nil {
+		return e
+	}
+	if root.Segment != segment || root.Size == 0 || root.Size > iko.MerkleSegmentSize {
+		return fmt.Errorf("node replied invalid root of segment %d", segment)
+	}
+	if trusted, ok := c.trusted[segment]; ok && trusted.Size == root.Size && trusted != root {
+		return fmt.Errorf("segment %d: %w", segment, ErrRootMismatch)
+	}
+	if known, ok := c.roots[segment]; ok {
+		if known.Size == iko.MerkleSegmentSize && known != root {
+			return fmt.Errorf("segment %d: %w", segment, ErrRootMismatch)
+		}
+		if root.Size < known.Size {
+			return fmt.Errorf("segment %d shrunk from %d to %d txs",
+				segment, known.Size, root.Size)
+		}
+	}
+	c.roots[segment] = root
+	return nil
+}
+
+// VerifyTx obtains the tx of hash from the node, and verifies it's inclusion
+// in the chain against the known root of it's segment. If the proof is of a
+// root that is not known, the client synchronizes once. The seq of the tx is
+// returned.
+func (c *Client) VerifyTx(txHash iko.TxHash) (*iko.Transaction, uint64, error) {
+	reply, e := c.node.GetTxOfHash(txHash)
+	if e != nil {
+		return nil, 0, e
+	}
+	tx, e := reply.Transaction()
+	if e != nil {
+		return nil, 0, e
+	}
+	if tx.Hash() != txHash {
+		return nil, 0, fmt.Errorf("node replied tx '%s' for hash '%s'",
+			tx.Hash().Hex(), txHash.Hex())
+	}
+	proofReply, e := c.node.GetTxProof(txHash)
+	if e != nil {
+		return nil, 0, e
+	}
+	proof, e := proofReply.TxProof()
+	if e != nil {
+		return nil, 0, e
+	}
+	if proof.TxHash != txHash || proof.Seq != reply.Meta.Seq {
+		return nil, 0, iko.ErrInvalidProof
+	}
+	root, ok := c.Root(proof.Segment)
+	if !ok || root.Size != proof.Size {
+		if e := c.Sync(); e != nil {
+			return nil, 0, e
+		}
+		if root, ok = c.Root(proof.Segment); !ok {
+			return nil, 0, fmt.Errorf("segment %d: %w", proof.Segment, ErrNoRoot)
+		}
+	}
+	if e := proof.Verify(root); e != nil {
+		return nil, 0, e
+	}
+	return tx, proof.Seq, nil
+}
+
+// VerifyOwnership checks that the kitty is owned by the address. The txs of
+// the kitty are obtained from the node, and each is verified to be included
+// in the chain, and to spend the previous tx of the kitty. The first tx should
+// create the kitty, and the last tx should output the kitty to the address.
+//
+// Inclusion proofs cannot prove that the node omitted a later tx of the
+// kitty, so ownership is as of the node's view of the kitty's history.
+func (c *Client) VerifyOwnership(kittyID iko.KittyID, owner cipher.Address) error {
+	kitty, e := c.node.GetKitty(kittyID)
+	if e != nil {
+		return e
+	}
+	if len(kitty.Transactions) == 0 {
+		return fmt.Errorf("kitty '%d' has no transactions", kittyID)
+	}
+	var (
+		prev    *iko.Transaction
+		prevSeq uint64
+	)
+	for i, v := range kitty.Transactions {
+		txHash, e := cipher.SHA256FromHex(v)
+		if e != nil {
+			return e
+		}
+		tx, seq, e := c.VerifyTx(iko.TxHash(txHash))
+		if e != nil {
+			return fmt.Errorf("tx %d of kitty '%d': %w", i, kittyID, e)
+		}
+		if !tx.HasKitty(kittyID) {
+			return fmt.Errorf("tx %d of kitty '%d' does not output the kitty", i, kittyID)
+		}
+		if prev == nil {
+			if in, ok := inputOfKitty(tx, kittyID); ok && in != iko.EmptyTxHash() {
+				return fmt.Errorf("first tx of kitty '%d' does not create the kitty", kittyID)
+			}
+		} else {
+			if seq <= prevSeq {
+				return fmt.Errorf("tx %d of kitty '%d' is not after the previous tx", i, kittyID)
+			}
+			if in, ok := inputOfKitty(tx, kittyID); !ok || in != prev.Hash() {
+				return fmt.Errorf("tx %d of kitty '%d' does not spend the previous tx", i, kittyID)
+			}
+		}
+		prev, prevSeq = tx, seq
+	}
+	if prev.Out != owner {
+		return fmt.Errorf("kitty '%d': %w", kittyID, iko.ErrNotOwner)
+	}
+	return nil
+}
+
+// inputOfKitty returns the hash of the tx that the tx spends the kitty of.
+func inputOfKitty(tx *iko.Transaction, kittyID iko.KittyID) (iko.TxHash, bool) {
+	if tx.Rotation != nil || tx.Admin != nil {
+		return iko.TxHash{}, false
+	}
+	if tx.KittyID == kittyID {
+		return tx.In, true
+	}
+	for _, in := range tx.Inputs {
+		if in.KittyID == kittyID {
+			return in.In, true
+		}
+	}
+	return iko.TxHash{}, false
+}
@@ -0,0 +1,112 @@
+package lightclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/http"
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var genPK, genSK = cipher.GenerateDeterministicKeyPair([]byte("light client test"))
+
+func newTestNode(t *testing.T) (*iko.BlockChain, string, func()) {
+	dir, err := ioutil.TempDir("", "kc_lightclient_test")
+	require.NoError(t, err)
+
+	chainDB, err := iko.NewBoltChain(&iko.BoltChainConfig{
+		Path: filepath.Join(dir, "chain.db"),
+	})
+	require.NoError(t, err)
+
+	bc, err := iko.NewBlockChain(context.Background(), &iko.BlockChainConfig{
+		GenerationPK: genPK,
+	}, chainDB, iko.NewMemoryState())
+	require.NoError(t, err)
+
+	handler, err := (&http.Gateway{IKO: bc}).Handler()
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+
+	return bc, srv.URL, func() {
+		srv.Close()
+		bc.Close()
+		chainDB.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestClient(t *testing.T) {
+	bc, url, closeNode := newTestNode(t)
+	defer closeNode()
+
+	c := New(&Config{NodeURL: url})
+	require.NoError(t, c.Sync())
+	_, ok := c.HeadSeq()
+	require.False(t, ok, "chain should be empty")
+
+	var gens []*iko.Transaction
+	for i := 0; i < 3; i++ {
+		tx := iko.NewGenTx(iko.KittyID(i), genSK)
+		_, err := bc.InjectTx(tx)
+		require.NoError(t, err)
+		gens = append(gens, tx)
+	}
+	require.NoError(t, c.Sync())
+	head, ok := c.HeadSeq()
+	require.True(t, ok)
+	require.Equal(t, uint64(2), head)
+	root, ok := c.Root(0)
+	require.True(t, ok)
+	require.Equal(t, uint64(3), root.Size)
+
+	toPK, _ := cipher.GenerateKeyPair()
+	to := cipher.AddressFromPubKey(toPK)
+	transfer, err := iko.NewTransferTx(gens[1], to, genSK)
+	require.NoError(t, err)
+	_, err = bc.InjectTx(transfer)
+	require.NoError(t, err)
+
+	t.Run("VerifyTx", func(t *testing.T) {
+		tx, seq, err := c.VerifyTx(transfer.Hash())
+		require.NoError(t, err, "client should sync to the new root")
+		require.Equal(t, transfer.Hash(), tx.Hash())
+		require.Equal(t, uint64(3), seq)
+
+		_, _, err = c.VerifyTx(iko.TxHash(cipher.SumSHA256([]byte("missing"))))
+		require.Error(t, err)
+	})
+
+	t.Run("VerifyOwnership", func(t *testing.T) {
+		require.NoError(t, c.VerifyOwnership(0, bc.CreatorAddress()))
+		require.NoError(t, c.VerifyOwnership(1, to))
+
+		err := c.VerifyOwnership(1, bc.CreatorAddress())
+		require.True(t, errors.Is(err, iko.ErrNotOwner))
+
+		require.Error(t, c.VerifyOwnership(5, to), "kitty should not exist")
+	})
+
+	t.Run("TrustedRoots", func(t *testing.T) {
+		bad := New(&Config{
+			NodeURL: url,
+			TrustedRoots: []iko.SegmentRoot{
+				{Segment: 0, Size: 4, Root: cipher.SumSHA256([]byte("bad"))},
+			},
+		})
+		require.True(t, errors.Is(bad.Sync(), ErrRootMismatch))
+
+		root, err := bc.GetSegmentRoot(0)
+		require.NoError(t, err)
+		good := New(&Config{NodeURL: url, TrustedRoots: []iko.SegmentRoot{root}})
+		require.NoError(t, good.Sync())
+	})
+}
@@ -0,0 +1,128 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+const (
+	// DefaultSeenCacheSize is the number of hashes of relayed txs that are
+	// remembered, so that they are not relayed again.
+	DefaultSeenCacheSize = 8192
+)
+
+var (
+	// ErrTxSeen occurs when submitting a tx that is already relayed.
+	ErrTxSeen = errors.New("tx is already relayed")
+
+	// ErrTxInChain occurs when submitting a tx that is already in the chain.
+	ErrTxInChain = errors.New("tx is already in the chain")
+)
+
+// seenCache remembers the hashes of the most recent relayed txs. The oldest
+// hash is forgotten when the cache is full. The caller should hold the lock
+// of the Node.
+type seenCache struct {
+	hashes map[iko.TxHash]struct{}
+	ring   []iko.TxHash
+	next   int
+}
+
+func newSeenCache(size int) *seenCache {
+	return &seenCache{
+		hashes: make(map[iko.TxHash]struct{}, size),
+		ring:   make([]iko.TxHash, 0, size),
+	}
+}
+
+// add adds the hash, returning false if it is already seen.
+func (c *seenCache) add(hash iko.TxHash) bool {
+	if _, ok := c.hashes[hash]; ok {
+		return false
+	}
+	if len(c.ring) < cap(c.ring) {
+		c.ring = append(c.ring, hash)
+	} else {
+		delete(c.hashes, c.ring[c.next])
+		c.ring[c.next] = hash
+		c.next = (c.next + 1) % len(c.ring)
+	}
+	c.hashes[hash] = struct{}{}
+	return true
+}
+
+// SubmitTx submits a signed tx to the network, so that it reaches the ordering
+// node. If this node is the ordering node, the tx is added to the mempool of
+// the BlockChain, and it fails if the tx is rejected. The tx is then relayed
+// to all peers.
+func (n *Node) SubmitTx(tx *iko.Transaction) error {
+	if !tx.IsSigned() {
+		return errors.New("tx is not signed")
+	}
+	if _, e := n.bc.GetTxOfHash(tx.Hash()); e == nil {
+		return fmt.Errorf("tx of hash '%s': %w", tx.Hash().Hex(), ErrTxInChain)
+	}
+	n.mux.Lock()
+	seen := !n.seen.add(tx.Hash())
+	n.mux.Unlock()
+	if seen {
+		return ErrTxSeen
+	}
+	if n.c.Ordering {
+		if e := n.bc.SubmitTx(tx); e != nil {
+			return e
+		}
+	}
+	n.relayTx(tx, nil)
+	return nil
+}
+
+// handleTx handles a tx relayed by the peer. Txs that are already seen are
+// ignored. The ordering node adds the tx to the mempool, and every node
+// relays it to it's other peers.
+func (n *Node) handleTx(p *peer, msg TxMsg) error {
+	tx, e := iko.DeserializeTx(msg.Tx)
+	if e != nil {
+		return e
+	}
+	if !tx.IsSigned() {
+		return errors.New("relayed tx is not signed")
+	}
+	n.mux.Lock()
+	seen := !n.seen.add(tx.Hash())
+	n.mux.Unlock()
+	if seen {
+		return nil
+	}
+	if n.c.Ordering {
+		if e := n.bc.SubmitTx(tx); e != nil {
+			// The tx may have been valid when relayed, so the peer is not
+			// penalized.
+			n.l.
+				WithError(e).
+				WithField("tx", tx.Hash().Hex()).
+				Debug("rejected relayed tx")
+		}
+	}
+	n.relayTx(tx, p)
+	return nil
+}
+
+// relayTx sends the tx to all peers except 'from'.
+func (n *Node) relayTx(tx *iko.Transaction, from *peer) {
+	msg := TxMsg{Tx: tx.Serialize()}
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	for _, p := range n.peers {
+		if p == from {
+			continue
+		}
+		if e := p.send(MsgTx, msg); e != nil {
+			n.l.WithError(e).WithField("peer", p.address).Warning("failed to relay tx")
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+func TestSeenCache(t *testing.T) {
+	c := newSeenCache(2)
+	hash := func(i byte) iko.TxHash {
+		return iko.TxHash(cipher.SumSHA256([]byte{i}))
+	}
+	require.True(t, c.add(hash(0)))
+	require.False(t, c.add(hash(0)))
+	require.True(t, c.add(hash(1)))
+	require.True(t, c.add(hash(2)), "oldest hash should be evicted")
+	require.True(t, c.add(hash(0)))
+	require.False(t, c.add(hash(2)))
+}
+
+func TestNode_Gossip(t *testing.T) {
+	bcA, closeA := newTestBlockChain(t)
+	defer closeA()
+	bcB, closeB := newTestBlockChain(t)
+	defer closeB()
+	bcC, closeC := newTestBlockChain(t)
+	defer closeC()
+
+	gen := iko.NewGenTx(0, genSK)
+	_, err := bcA.InjectTx(gen)
+	require.NoError(t, err)
+
+	nodeA, err := NewNode(&NodeConfig{Address: "127.0.0.1:0", Ordering: true}, bcA)
+	require.NoError(t, err)
+	defer nodeA.Close()
+	nodeB, err := NewNode(&NodeConfig{
+		Address: "127.0.0.1:0",
+		Peers:   []string{nodeA.Address()},
+	}, bcB)
+	require.NoError(t, err)
+	defer nodeB.Close()
+	nodeC, err := NewNode(&NodeConfig{Peers: []string{nodeB.Address()}}, bcC)
+	require.NoError(t, err)
+	defer nodeC.Close()
+	requireSynced(t, 1, nodeA, nodeB, nodeC)
+
+	toPK, _ := cipher.GenerateKeyPair()
+	transfer, err := iko.NewTransferTx(gen, cipher.AddressFromPubKey(toPK), genSK)
+	require.NoError(t, err)
+
+	unsigned := *transfer
+	unsigned.Sig = cipher.Sig{}
+	require.Error(t, nodeC.SubmitTx(&unsigned))
+	require.True(t, errors.Is(nodeC.SubmitTx(gen), ErrTxInChain))
+
+	require.NoError(t, nodeC.SubmitTx(transfer))
+	require.Equal(t, ErrTxSeen, nodeC.SubmitTx(transfer))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := bcA.GetPendingTx(transfer.Hash()); ok {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "relayed tx should reach the ordering node")
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, ok := bcB.GetPendingTx(transfer.Hash())
+	require.False(t, ok, "only the ordering node should add relayed txs to it's mempool")
+
+	require.Equal(t, 1, bcA.CommitPending())
+	requireSynced(t, 2, nodeA, nodeB, nodeC)
+	txWrap, err := bcC.GetHeadTx()
+	require.NoError(t, err)
+	require.Equal(t, transfer.Hash(), txWrap.Tx.Hash())
+}
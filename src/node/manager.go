@@ -0,0 +1,141 @@
+package node
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxKnownPeers is the maximum number of known peer addresses, beyond
+	// which addresses learned from peer exchange are ignored.
+	maxKnownPeers = 1024
+
+	// maxPeersReply is the maximum number of addresses of a 'MsgPeers'.
+	maxPeersReply = 64
+
+	// misbehaviorScore is added to the ban score of a peer that is dropped for
+	// misbehaving.
+	misbehaviorScore = 50
+)
+
+// Ban is a host that is banned from connecting.
+type Ban struct {
+	Host  string
+	Until time.Time
+}
+
+// peerManager keeps the addresses of known peers, and the ban scores of peer
+// hosts. Scores and bans are of hosts rather than addresses, so that a peer
+// cannot avoid a ban by reconnecting from another port.
+type peerManager struct {
+	mux       sync.Mutex
+	known     map[string]struct{}
+	scores    map[string]int
+	banned    map[string]time.Time
+	threshold int
+	duration  time.Duration
+}
+
+func newPeerManager(threshold int, duration time.Duration) *peerManager {
+	return &peerManager{
+		known:     make(map[string]struct{}),
+		scores:    make(map[string]int),
+		banned:    make(map[string]time.Time),
+		threshold: threshold,
+		duration:  duration,
+	}
+}
+
+// addKnown adds addresses of peers that can be connected to. Invalid
+// addresses are ignored.
+func (m *peerManager) addKnown(addresses ...string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, address := range addresses {
+		if len(m.known) >= maxKnownPeers {
+			return
+		}
+		if _, _, e := net.SplitHostPort(address); e != nil {
+			continue
+		}
+		m.known[address] = struct{}{}
+	}
+}
+
+// knownAddresses returns the known addresses of hosts that are not banned, in
+// ascending order.
+func (m *peerManager) knownAddresses() []string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	out := make([]string, 0, len(m.known))
+	for address := range m.known {
+		if !m.isBannedLocked(hostOf(address)) {
+			out = append(out, address)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// penalize adds to the ban score of the host of address, and bans the host if
+// the score reaches the threshold. It returns true if the host is banned.
+func (m *peerManager) penalize(address string, score int) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	host := hostOf(address)
+	m.scores[host] += score
+	if m.scores[host] < m.threshold {
+		return false
+	}
+	delete(m.scores, host)
+	m.banned[host] = time.Now().Add(m.duration)
+	return true
+}
+
+// isBanned returns true if the host of address is banned.
+func (m *peerManager) isBanned(address string) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.isBannedLocked(hostOf(address))
+}
+
+func (m *peerManager) isBannedLocked(host string) bool {
+	until, ok := m.banned[host]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(m.banned, host)
+		return false
+	}
+	return true
+}
+
+// bans returns the current bans, in ascending order of host.
+func (m *peerManager) bans() []Ban {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	out := make([]Ban, 0, len(m.banned))
+	for host, until := range m.banned {
+		if m.isBannedLocked(host) {
+			out = append(out, Ban{Host: host, Until: until})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+// hostOf returns the host of address, or the address if it has no port.
+func hostOf(address string) string {
+	host, _, e := net.SplitHostPort(address)
+	if e != nil {
+		return address
+	}
+	return host
+}
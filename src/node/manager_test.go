@@ -0,0 +1,35 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerManager(t *testing.T) {
+	m := newPeerManager(100, time.Hour)
+
+	m.addKnown("10.0.0.2:7910", "invalid", "10.0.0.1:7910", "10.0.0.1:7911")
+	require.Equal(t, []string{"10.0.0.1:7910", "10.0.0.1:7911", "10.0.0.2:7910"}, m.knownAddresses())
+
+	require.False(t, m.penalize("10.0.0.1:1234", 50))
+	require.False(t, m.isBanned("10.0.0.1:7910"))
+	require.True(t, m.penalize("10.0.0.1:5678", 50), "score of host should accumulate")
+	require.True(t, m.isBanned("10.0.0.1:7910"), "ban should be of the host")
+	require.False(t, m.isBanned("10.0.0.2:7910"))
+	require.Equal(t, []string{"10.0.0.2:7910"}, m.knownAddresses(),
+		"addresses of banned hosts should be excluded")
+
+	bans := m.bans()
+	require.Len(t, bans, 1)
+	require.Equal(t, "10.0.0.1", bans[0].Host)
+
+	t.Run("Expiry", func(t *testing.T) {
+		m := newPeerManager(10, time.Millisecond)
+		require.True(t, m.penalize("10.0.0.1:1234", 50))
+		time.Sleep(5 * time.Millisecond)
+		require.False(t, m.isBanned("10.0.0.1:1234"))
+		require.Empty(t, m.bans())
+	})
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+const (
+	// MaxMessageSize is the maximum size of the payload of a message.
+	MaxMessageSize = 32 << 20
+
+	msgHeaderSize = 5 // 1 byte type, 4 bytes payload size.
+)
+
+// MsgType is the type of a message of the peer protocol.
+type MsgType byte
+
+const (
+	// MsgHead announces the length of the sender's chain. It is sent on
+	// connect, and after each tx accepted into the sender's chain.
+	MsgHead MsgType = iota + 1
+
+	// MsgGetTxs requests a range of txs of the receiver's chain.
+	MsgGetTxs
+
+	// MsgTxs replies the txs requested with 'MsgGetTxs'.
+	MsgTxs
+
+	// MsgAddress announces the address that the sender accepts peers on. It
+	// is sent on connect, if the sender accepts peers.
+	MsgAddress
+
+	// MsgGetPeers requests the addresses of peers known to the receiver.
+	MsgGetPeers
+
+	// MsgPeers replies the addresses requested with 'MsgGetPeers'.
+	MsgPeers
+
+	// MsgTx relays a tx submitted to the network, to reach the ordering node.
+	MsgTx
+)
+
+func (t MsgType) String() string {
+	switch t {
+	case MsgHead:
+		return "head"
+	case MsgGetTxs:
+		return "get_txs"
+	case MsgTxs:
+		return "txs"
+	case MsgAddress:
+		return "address"
+	case MsgGetPeers:
+		return "get_peers"
+	case MsgPeers:
+		return "peers"
+	case MsgTx:
+		return "tx"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(t))
+	}
+}
+
+// HeadMsg is the payload of 'MsgHead'.
+type HeadMsg struct {
+	Len uint64 // Number of txs of the chain, being the head seq + 1.
+}
+
+// GetTxsMsg is the payload of 'MsgGetTxs'.
+type GetTxsMsg struct {
+	Start uint64
+	Count uint64
+}
+
+// TxsMsg is the payload of 'MsgTxs'. Txs are encoded with the binary tx
+// codec, and are in ascending order of seq starting from 'Start'.
+type TxsMsg struct {
+	Start uint64
+	Txs   [][]byte
+}
+
+// AddressMsg is the payload of 'MsgAddress'. If the host of the address is
+// empty or unspecified, the receiver substitutes the host of the connection.
+type AddressMsg struct {
+	Address string
+}
+
+// GetPeersMsg is the payload of 'MsgGetPeers'.
+type GetPeersMsg struct{}
+
+// PeersMsg is the payload of 'MsgPeers', of at most 64 addresses.
+type PeersMsg struct {
+	Addresses []string
+}
+
+// TxMsg is the payload of 'MsgTx'.
+type TxMsg struct {
+	Tx []byte // Serialized tx.
+}
+
+// writeMsg writes the message of type and payload to 'w'.
+func writeMsg(w io.Writer, t MsgType, payload interface{}) error {
+	raw := encoder.Serialize(payload)
+	if len(raw) > MaxMessageSize {
+		return fmt.Errorf("%s message of %d bytes exceeds maximum of %d bytes",
+			t, len(raw), MaxMessageSize)
+	}
+	b := make([]byte, msgHeaderSize, msgHeaderSize+len(raw))
+	b[0] = byte(t)
+	binary.BigEndian.PutUint32(b[1:], uint32(len(raw)))
+	_, e := w.Write(append(b, raw...))
+	return e
+}
+
+// readMsg reads a message from 'r', returning it's type and raw payload.
+func readMsg(r io.Reader) (MsgType, []byte, error) {
+	header := make([]byte, msgHeaderSize)
+	if _, e := io.ReadFull(r, header); e != nil {
+		return 0, nil, e
+	}
+	t, size := MsgType(header[0]), binary.BigEndian.Uint32(header[1:])
+	if size > MaxMessageSize {
+		return t, nil, fmt.Errorf("%s message of %d bytes exceeds maximum of %d bytes",
+			t, size, MaxMessageSize)
+	}
+	raw := make([]byte, size)
+	if _, e := io.ReadFull(r, raw); e != nil {
+		return t, nil, e
+	}
+	return t, raw, nil
+}
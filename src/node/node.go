@@ -0,0 +1,519 @@
+// Package node synchronizes the chain of a BlockChain with peer nodes over
+// TCP. Nodes announce the length of their chain, and request the txs that
+// they are missing from peers with longer chains, until they converge.
+package node
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/sirupsen/logrus.v1"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+const (
+	DefaultSyncBatchSize = 256
+	DefaultDialTimeout   = 10 * time.Second
+	DefaultMaxPeers      = 16
+	DefaultBanThreshold  = 100
+	DefaultBanDuration   = time.Hour
+	DefaultDialInterval  = 30 * time.Second
+
+	subBufSize = 128
+)
+
+var (
+	// ErrAlreadyConnected occurs when connecting to a peer of an address that
+	// is already connected.
+	ErrAlreadyConnected = errors.New("peer is already connected")
+
+	// ErrNodeClosed occurs when connecting to a peer after the node is closed.
+	ErrNodeClosed = errors.New("node is closed")
+
+	// ErrTooManyPeers occurs when connecting to (or accepting) a peer while
+	// 'MaxPeers' peers are connected.
+	ErrTooManyPeers = errors.New("too many peers")
+
+	// ErrPeerBanned occurs when connecting to (or accepting) a peer of a
+	// banned host.
+	ErrPeerBanned = errors.New("peer is banned")
+)
+
+type NodeConfig struct {
+	// Address to accept peers on, such as "127.0.0.1:7910". Peers are not
+	// accepted if empty, but the node can still connect to peers.
+	Address string
+
+	// Peers are the addresses of bootstrap peers. They are connected to on
+	// start, and reconnected to along with peers learned from peer exchange.
+	Peers []string
+
+	// MaxPeers is the maximum number of connected peers, inbound and outbound.
+	// A value of 0 results in 'DefaultMaxPeers'.
+	MaxPeers int
+
+	// BanThreshold is the ban score at which the host of a peer is banned.
+	// Peers dropped for misbehaving add 50 to the score of their host. A
+	// value of 0 results in 'DefaultBanThreshold'.
+	BanThreshold int
+
+	// BanDuration is the duration of bans. A value of 0 results in
+	// 'DefaultBanDuration'.
+	BanDuration time.Duration
+
+	// DialInterval is the interval in which the node requests peers from
+	// it's peers, and connects to known peers while below 'MaxPeers'. A value
+	// of 0 results in 'DefaultDialInterval'.
+	DialInterval time.Duration
+
+	// SyncBatchSize is the maximum number of txs that are requested from (and
+	// replied to) a peer at once. A value of 0 results in
+	// 'DefaultSyncBatchSize'.
+	SyncBatchSize uint64
+
+	// DialTimeout is the timeout of connecting to a peer. A value of 0
+	// results in 'DefaultDialTimeout'.
+	DialTimeout time.Duration
+
+	// Ordering is whether this node orders txs. Txs submitted to the network
+	// are added to the mempool of the ordering node, which commits them to
+	// the chain, and other nodes obtain them with chain synchronization.
+	// Other nodes should not commit txs.
+	Ordering bool
+
+	// SeenCacheSize is the number of hashes of relayed txs that are
+	// remembered, so that they are not relayed again. A value of 0 results in
+	// 'DefaultSeenCacheSize'.
+	SeenCacheSize int
+
+	// TLS is the config to encrypt connections of peers, such as of
+	// 'util.MutualTLSConfig' so that only peers of a private CA are accepted.
+	// Connections are not encrypted if nil.
+	TLS *tls.Config
+}
+
+func (c *NodeConfig) Prepare() error {
+	if c.SyncBatchSize == 0 {
+		c.SyncBatchSize = DefaultSyncBatchSize
+	}
+	if c.DialTimeout == 0 {
+		c.DialTimeout = DefaultDialTimeout
+	}
+	if c.MaxPeers == 0 {
+		c.MaxPeers = DefaultMaxPeers
+	}
+	if c.BanThreshold == 0 {
+		c.BanThreshold = DefaultBanThreshold
+	}
+	if c.BanDuration == 0 {
+		c.BanDuration = DefaultBanDuration
+	}
+	if c.DialInterval == 0 {
+		c.DialInterval = DefaultDialInterval
+	}
+	if c.SeenCacheSize == 0 {
+		c.SeenCacheSize = DefaultSeenCacheSize
+	}
+	return nil
+}
+
+// Node synchronizes the chain of a BlockChain with it's peers. Txs of peers
+// are appended with 'BlockChain.AppendTxs', so that they are validated and
+// keep the metas of the chain they originate from.
+type Node struct {
+	c     *NodeConfig
+	l     *logrus.Logger
+	bc    *iko.BlockChain
+	codec iko.BinaryTxCodec
+	lis   net.Listener
+
+	mux    sync.Mutex
+	peers  map[string]*peer
+	closed bool
+	pm     *peerManager
+	seen   *seenCache
+
+	unsub func()
+	quit  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+func NewNode(c *NodeConfig, bc *iko.BlockChain) (*Node, error) {
+	if e := c.Prepare(); e != nil {
+		return nil, e
+	}
+	n := &Node{
+		c:     c,
+		l:     logrus.New(),
+		bc:    bc,
+		peers: make(map[string]*peer),
+		pm:    newPeerManager(c.BanThreshold, c.BanDuration),
+		seen:  newSeenCache(c.SeenCacheSize),
+		quit:  make(chan struct{}),
+	}
+	if c.Address != "" {
+		var e error
+		if n.lis, e = net.Listen("tcp", c.Address); e != nil {
+			return nil, e
+		}
+		if c.TLS != nil {
+			n.lis = tls.NewListener(n.lis, c.TLS)
+		}
+		n.wg.Add(1)
+		go n.accept()
+		n.l.Infof("node listening on: '%s'", n.lis.Addr())
+	}
+
+	var txs <-chan iko.TxWrapper
+	txs, n.unsub = bc.SubscribeTxs(subBufSize)
+	n.wg.Add(1)
+	go n.announce(txs)
+
+	n.pm.addKnown(c.Peers...)
+	n.dialKnown()
+	n.wg.Add(1)
+	go n.maintain()
+	return n, nil
+}
+
+// Address returns the address that the node accepts peers on, or an empty
+// string if it does not accept peers.
+func (n *Node) Address() string {
+	if n.lis == nil {
+		return ""
+	}
+	return n.lis.Addr().String()
+}
+
+// Connect connects to the peer of address, and starts synchronizing with it.
+// The address is added to the known peers.
+func (n *Node) Connect(address string) error {
+	if _, _, e := net.SplitHostPort(address); e != nil {
+		return e
+	}
+	if n.pm.isBanned(address) {
+		return fmt.Errorf("peer '%s': %w", address, ErrPeerBanned)
+	}
+	conn, e := n.dial(address)
+	if e != nil {
+		return e
+	}
+	if e := n.addPeer(conn, address, false); e != nil {
+		return e
+	}
+	n.pm.addKnown(address)
+	return nil
+}
+
+// dial connects to the address, over TLS if configured.
+func (n *Node) dial(address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: n.c.DialTimeout}
+	if n.c.TLS != nil {
+		return tls.DialWithDialer(dialer, "tcp", address, n.c.TLS)
+	}
+	return dialer.Dial("tcp", address)
+}
+
+// PeerInfo describes a connected peer.
+type PeerInfo struct {
+	Address string // Address of the connection.
+	Listen  string // Address the peer accepts peers on, if known.
+	Inbound bool   // Whether the peer connected to this node.
+	Len     uint64 // Length of the peer's chain, as last announced.
+}
+
+// PeerInfos returns the connected peers, in ascending order of address.
+func (n *Node) PeerInfos() []PeerInfo {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	out := make([]PeerInfo, 0, len(n.peers))
+	for _, p := range n.peers {
+		out = append(out, PeerInfo{
+			Address: p.address,
+			Listen:  p.listen,
+			Inbound: p.inbound,
+			Len:     p.chainLen(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// SyncStatus describes how far the chain is synced with the connected peers.
+type SyncStatus struct {
+	Peers   int    // Number of connected peers.
+	Len     uint64 // Length of the local chain.
+	BestLen uint64 // Longest chain announced by a connected peer.
+	Synced  bool   // Whether the local chain is at least as long as any peer's.
+}
+
+// SyncStatus returns how far the chain is synced with the connected peers.
+// The chain is considered synced if no peers are connected.
+func (n *Node) SyncStatus() SyncStatus {
+	s := SyncStatus{Len: n.chainLen()}
+
+	n.mux.Lock()
+	s.Peers = len(n.peers)
+	for _, p := range n.peers {
+		if pLen := p.chainLen(); pLen > s.BestLen {
+			s.BestLen = pLen
+		}
+	}
+	n.mux.Unlock()
+
+	s.Synced = s.Len >= s.BestLen
+	return s
+}
+
+// KnownPeers returns the addresses of known peers that are not banned, in
+// ascending order. These are the bootstrap peers, and peers learned from
+// connections and peer exchange.
+func (n *Node) KnownPeers() []string {
+	return n.pm.knownAddresses()
+}
+
+// Bans returns the hosts that are banned, in ascending order.
+func (n *Node) Bans() []Ban {
+	return n.pm.bans()
+}
+
+// Peers returns the addresses of the connected peers, in ascending order.
+func (n *Node) Peers() []string {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	out := make([]string, 0, len(n.peers))
+	for address := range n.peers {
+		out = append(out, address)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Close disconnects all peers, and stops accepting peers.
+func (n *Node) Close() {
+	n.once.Do(func() {
+		close(n.quit)
+		n.unsub()
+		if n.lis != nil {
+			if e := n.lis.Close(); e != nil {
+				n.l.WithError(e).Error("error on close")
+			}
+		}
+		n.mux.Lock()
+		n.closed = true
+		for _, p := range n.peers {
+			p.close()
+		}
+		n.mux.Unlock()
+		n.wg.Wait()
+	})
+}
+
+func (n *Node) accept() {
+	defer n.wg.Done()
+	for {
+		conn, e := n.lis.Accept()
+		if e != nil {
+			select {
+			case <-n.quit:
+			default:
+				n.l.WithError(e).Error("stopped accepting peers")
+			}
+			return
+		}
+		if e := n.addPeer(conn, conn.RemoteAddr().String(), true); e != nil {
+			n.l.WithError(e).WithField("peer", conn.RemoteAddr()).Warning("rejected peer")
+		}
+	}
+}
+
+// addPeer adds the peer of the connection, which is closed if the peer cannot
+// be added.
+func (n *Node) addPeer(conn net.Conn, address string, inbound bool) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	var e error
+	switch {
+	case n.closed:
+		e = ErrNodeClosed
+	case n.pm.isBanned(address):
+		e = fmt.Errorf("peer '%s': %w", address, ErrPeerBanned)
+	case n.isConnected(address):
+		e = fmt.Errorf("peer '%s': %w", address, ErrAlreadyConnected)
+	case len(n.peers) >= n.c.MaxPeers:
+		e = ErrTooManyPeers
+	}
+	if e != nil {
+		conn.Close()
+		return e
+	}
+	p := newPeer(conn, address, inbound)
+	if !inbound {
+		p.listen = address
+	}
+	n.peers[address] = p
+	n.wg.Add(1)
+	go n.runPeer(p)
+	return nil
+}
+
+func (n *Node) removePeer(p *peer) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	if n.peers[p.address] == p {
+		delete(n.peers, p.address)
+	}
+	p.close()
+}
+
+// runPeer handles the messages of the peer, until the connection fails or the
+// peer misbehaves.
+func (n *Node) runPeer(p *peer) {
+	defer n.wg.Done()
+	defer n.removePeer(p)
+
+	log := n.l.WithField("peer", p.address)
+	log.Info("peer connected")
+
+	if e := n.greet(p); e != nil {
+		log.WithError(e).Warning("failed to greet peer")
+		return
+	}
+	for {
+		t, raw, e := readMsg(p.conn)
+		if e != nil {
+			select {
+			case <-n.quit:
+			default:
+				log.WithError(e).Info("peer disconnected")
+			}
+			return
+		}
+		if e := n.handleMsg(p, t, raw); e != nil {
+			log.WithError(e).WithField("msg", t).Warning("dropping misbehaving peer")
+			if n.pm.penalize(p.address, misbehaviorScore) {
+				log.Warning("banned peer")
+			}
+			return
+		}
+	}
+}
+
+// greet sends the address the node accepts peers on (if any), the length of
+// the chain, and requests the peers of the peer.
+func (n *Node) greet(p *peer) error {
+	if address := n.Address(); address != "" {
+		if e := p.send(MsgAddress, AddressMsg{Address: address}); e != nil {
+			return e
+		}
+	}
+	if e := p.send(MsgHead, HeadMsg{Len: n.chainLen()}); e != nil {
+		return e
+	}
+	return p.send(MsgGetPeers, GetPeersMsg{})
+}
+
+// isConnected returns true if a peer of the address is connected, either of
+// the connection's address or of the address the peer accepts peers on. The
+// caller should hold the lock.
+func (n *Node) isConnected(address string) bool {
+	if _, ok := n.peers[address]; ok {
+		return true
+	}
+	for _, p := range n.peers {
+		if p.listen == address {
+			return true
+		}
+	}
+	return false
+}
+
+// maintain requests peers from the connected peers, and connects to known
+// peers, every 'DialInterval'.
+func (n *Node) maintain() {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(n.c.DialInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.quit:
+			return
+		case <-ticker.C:
+			n.mux.Lock()
+			for _, p := range n.peers {
+				if e := p.send(MsgGetPeers, GetPeersMsg{}); e != nil {
+					n.l.WithError(e).WithField("peer", p.address).Warning("failed to request peers")
+				}
+			}
+			n.mux.Unlock()
+			n.dialKnown()
+		}
+	}
+}
+
+// dialKnown connects to the known peers that are not connected, while below
+// 'MaxPeers'.
+func (n *Node) dialKnown() {
+	self := n.Address()
+	for _, address := range n.pm.knownAddresses() {
+		n.mux.Lock()
+		full := n.closed || len(n.peers) >= n.c.MaxPeers
+		connected := n.isConnected(address)
+		n.mux.Unlock()
+		if full {
+			return
+		}
+		if address == self || connected {
+			continue
+		}
+		if e := n.Connect(address); e != nil {
+			n.l.WithError(e).WithField("peer", address).Debug("failed to connect to peer")
+		}
+	}
+}
+
+// announce announces the length of the chain to all peers, after each tx
+// accepted into the chain.
+func (n *Node) announce(txs <-chan iko.TxWrapper) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.quit:
+			return
+		case txWrap, ok := <-txs:
+			if !ok {
+				return
+			}
+			msg := HeadMsg{Len: txWrap.Meta.Seq + 1}
+			n.mux.Lock()
+			for _, p := range n.peers {
+				if e := p.send(MsgHead, msg); e != nil {
+					n.l.WithError(e).WithField("peer", p.address).Warning("failed to announce head")
+				}
+			}
+			n.mux.Unlock()
+		}
+	}
+}
+
+// chainLen returns the number of txs of the chain.
+func (n *Node) chainLen() uint64 {
+	head, e := n.bc.GetHeadTx()
+	if e != nil {
+		return 0
+	}
+	return head.Meta.Seq + 1
+}
@@ -0,0 +1,291 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var genPK, genSK = cipher.GenerateDeterministicKeyPair([]byte("node test"))
+
+func newTestBlockChain(t *testing.T) (*iko.BlockChain, func()) {
+	dir, err := ioutil.TempDir("", "kc_node_test")
+	require.NoError(t, err)
+
+	chainDB, err := iko.NewBoltChain(&iko.BoltChainConfig{
+		Path: filepath.Join(dir, "chain.db"),
+	})
+	require.NoError(t, err)
+
+	bc, err := iko.NewBlockChain(context.Background(), &iko.BlockChainConfig{
+		GenerationPK: genPK,
+		LogLevel:     "warning",
+	}, chainDB, iko.NewMemoryState())
+	require.NoError(t, err)
+
+	return bc, func() {
+		bc.Close()
+		chainDB.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func injectGenTxs(t *testing.T, bc *iko.BlockChain, kittyIDs ...iko.KittyID) {
+	for _, kittyID := range kittyIDs {
+		_, err := bc.InjectTx(iko.NewGenTx(kittyID, genSK))
+		require.NoError(t, err)
+	}
+}
+
+// requireSynced waits until the chains of the nodes are of length 'cLen', and
+// checks that they are the same.
+func requireSynced(t *testing.T, cLen uint64, nodes ...*Node) {
+	deadline := time.Now().Add(5 * time.Second)
+	for _, n := range nodes {
+		for n.chainLen() != cLen {
+			if time.Now().After(deadline) {
+				t.Fatalf("chain of length %d should sync to length %d", n.chainLen(), cLen)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	for seq := uint64(0); seq < cLen; seq++ {
+		exp, err := nodes[0].bc.GetTxOfSeq(seq)
+		require.NoError(t, err)
+		for _, n := range nodes[1:] {
+			txWrap, err := n.bc.GetTxOfSeq(seq)
+			require.NoError(t, err)
+			require.Equal(t, exp, txWrap, "synced txs should keep their metas")
+		}
+	}
+}
+
+func TestNode_Sync(t *testing.T) {
+	bcA, closeA := newTestBlockChain(t)
+	defer closeA()
+	bcB, closeB := newTestBlockChain(t)
+	defer closeB()
+	bcC, closeC := newTestBlockChain(t)
+	defer closeC()
+
+	injectGenTxs(t, bcA, 0, 1, 2, 3, 4)
+
+	nodeA, err := NewNode(&NodeConfig{Address: "127.0.0.1:0", SyncBatchSize: 2}, bcA)
+	require.NoError(t, err)
+	defer nodeA.Close()
+
+	nodeB, err := NewNode(&NodeConfig{
+		Address:       "127.0.0.1:0",
+		Peers:         []string{nodeA.Address()},
+		SyncBatchSize: 2,
+	}, bcB)
+	require.NoError(t, err)
+	defer nodeB.Close()
+
+	requireSynced(t, 5, nodeA, nodeB)
+	require.Equal(t, []string{nodeA.Address()}, nodeB.Peers())
+	require.Equal(t, SyncStatus{Peers: 1, Len: 5, BestLen: 5, Synced: true},
+		nodeB.SyncStatus())
+
+	t.Run("Relay", func(t *testing.T) {
+		nodeC, err := NewNode(&NodeConfig{Peers: []string{nodeB.Address()}}, bcC)
+		require.NoError(t, err)
+		defer nodeC.Close()
+		requireSynced(t, 5, nodeA, nodeC)
+
+		injectGenTxs(t, bcA, 5)
+		requireSynced(t, 6, nodeA, nodeB, nodeC)
+
+		injectGenTxs(t, bcC, 6, 7)
+		requireSynced(t, 8, nodeC, nodeB, nodeA)
+	})
+
+	t.Run("AlreadyConnected", func(t *testing.T) {
+		require.Error(t, nodeB.Connect(nodeA.Address()))
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		nodeB.Close()
+		nodeB.Close()
+		require.Empty(t, nodeB.Peers())
+		require.Equal(t, ErrNodeClosed, nodeB.Connect(nodeA.Address()))
+	})
+}
+
+// newTestTLSConfig creates a mutual tls config of a self-signed certificate,
+// which is also it's own CA.
+func newTestTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "node"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+func TestNode_TLS(t *testing.T) {
+	bcA, closeA := newTestBlockChain(t)
+	defer closeA()
+	bcB, closeB := newTestBlockChain(t)
+	defer closeB()
+	bcC, closeC := newTestBlockChain(t)
+	defer closeC()
+
+	injectGenTxs(t, bcA, 0, 1, 2)
+	config := newTestTLSConfig(t)
+
+	nodeA, err := NewNode(&NodeConfig{Address: "127.0.0.1:0", TLS: config}, bcA)
+	require.NoError(t, err)
+	defer nodeA.Close()
+
+	nodeB, err := NewNode(&NodeConfig{Peers: []string{nodeA.Address()}, TLS: config}, bcB)
+	require.NoError(t, err)
+	defer nodeB.Close()
+	requireSynced(t, 3, nodeA, nodeB)
+
+	plain, err := NewNode(&NodeConfig{Address: "127.0.0.1:0"}, bcC)
+	require.NoError(t, err)
+	defer plain.Close()
+	require.Error(t, nodeB.Connect(plain.Address()),
+		"tls nodes should not connect to plain nodes")
+}
+
+func TestNode_PeerManagement(t *testing.T) {
+	bcA, closeA := newTestBlockChain(t)
+	defer closeA()
+	bcB, closeB := newTestBlockChain(t)
+	defer closeB()
+	bcC, closeC := newTestBlockChain(t)
+	defer closeC()
+
+	nodeA, err := NewNode(&NodeConfig{Address: "127.0.0.1:0", MaxPeers: 2}, bcA)
+	require.NoError(t, err)
+	defer nodeA.Close()
+
+	nodeB, err := NewNode(&NodeConfig{
+		Address:      "127.0.0.1:0",
+		Peers:        []string{nodeA.Address()},
+		DialInterval: 20 * time.Millisecond,
+	}, bcB)
+	require.NoError(t, err)
+	defer nodeB.Close()
+
+	waitFor := func(msg string, cond func() bool) {
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal(msg)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	t.Run("PeerExchange", func(t *testing.T) {
+		nodeC, err := NewNode(&NodeConfig{
+			Address:      "127.0.0.1:0",
+			Peers:        []string{nodeB.Address()},
+			DialInterval: 20 * time.Millisecond,
+		}, bcC)
+		require.NoError(t, err)
+		defer nodeC.Close()
+
+		waitFor("node should connect to peer learned from peer exchange", func() bool {
+			for _, info := range nodeC.PeerInfos() {
+				if info.Listen == nodeA.Address() {
+					return true
+				}
+			}
+			return false
+		})
+		require.Contains(t, nodeC.KnownPeers(), nodeA.Address())
+
+		waitFor("inbound peers should be of their listen addresses", func() bool {
+			for _, info := range nodeA.PeerInfos() {
+				if info.Inbound && info.Listen == nodeC.Address() {
+					return true
+				}
+			}
+			return false
+		})
+	})
+
+	t.Run("MaxPeers", func(t *testing.T) {
+		waitFor("peer should be disconnected", func() bool {
+			return len(nodeA.Peers()) < 2
+		})
+		bcD, closeD := newTestBlockChain(t)
+		defer closeD()
+		bcE, closeE := newTestBlockChain(t)
+		defer closeE()
+
+		nodeD, err := NewNode(&NodeConfig{Peers: []string{nodeA.Address()}}, bcD)
+		require.NoError(t, err)
+		defer nodeD.Close()
+		waitFor("peer should be accepted", func() bool {
+			return len(nodeA.Peers()) == 2
+		})
+
+		nodeE, err := NewNode(&NodeConfig{}, bcE)
+		require.NoError(t, err)
+		defer nodeE.Close()
+		require.NoError(t, nodeE.Connect(nodeA.Address()))
+		waitFor("peer beyond 'MaxPeers' should be rejected", func() bool {
+			return len(nodeE.Peers()) == 0
+		})
+		require.Len(t, nodeA.Peers(), 2)
+	})
+
+	t.Run("Ban", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			conn, err := net.Dial("tcp", nodeB.Address())
+			require.NoError(t, err)
+			require.NoError(t, writeMsg(conn, MsgType(100), GetPeersMsg{}))
+			_, err = ioutil.ReadAll(conn)
+			require.NoError(t, err, "misbehaving peer should be dropped")
+			conn.Close()
+		}
+		bans := nodeB.Bans()
+		require.Len(t, bans, 1)
+		require.Equal(t, "127.0.0.1", bans[0].Host)
+
+		err := nodeB.Connect(nodeA.Address())
+		require.True(t, errors.Is(err, ErrPeerBanned))
+	})
+}
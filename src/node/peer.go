@@ -0,0 +1,57 @@
+package node
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	writeTimeout = 30 * time.Second
+)
+
+// peer is a connected peer node. It's sync state is only accessed by the
+// goroutine that handles it's messages, except for 'len' which is accessed
+// atomically. 'listen' is guarded by the lock of the Node.
+type peer struct {
+	address string
+	inbound bool
+	listen  string // Address the peer accepts peers on, if known.
+	conn    net.Conn
+	wmux    sync.Mutex
+	once    sync.Once
+
+	len        uint64 // Length of the peer's chain, as last announced.
+	requesting bool   // Whether txs are requested from the peer.
+}
+
+func newPeer(conn net.Conn, address string, inbound bool) *peer {
+	return &peer{
+		address: address,
+		inbound: inbound,
+		conn:    conn,
+	}
+}
+
+// chainLen returns the length of the peer's chain, as last announced.
+func (p *peer) chainLen() uint64 {
+	return atomic.LoadUint64(&p.len)
+}
+
+// send writes a message to the peer. It is safe for concurrent use.
+func (p *peer) send(t MsgType, payload interface{}) error {
+	p.wmux.Lock()
+	defer p.wmux.Unlock()
+
+	if e := p.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); e != nil {
+		return e
+	}
+	return writeMsg(p.conn, t, payload)
+}
+
+func (p *peer) close() {
+	p.once.Do(func() {
+		p.conn.Close()
+	})
+}
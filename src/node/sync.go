@@ -0,0 +1,184 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+// handleMsg handles a message of the peer. An error is returned if the peer
+// misbehaves, in which case it should be dropped.
+func (n *Node) handleMsg(p *peer, t MsgType, raw []byte) error {
+	switch t {
+	case MsgHead:
+		var msg HeadMsg
+		if e := encoder.DeserializeRaw(raw, &msg); e != nil {
+			return e
+		}
+		atomic.StoreUint64(&p.len, msg.Len)
+		return n.requestTxs(p)
+
+	case MsgGetTxs:
+		var msg GetTxsMsg
+		if e := encoder.DeserializeRaw(raw, &msg); e != nil {
+			return e
+		}
+		return n.replyTxs(p, msg)
+
+	case MsgTxs:
+		var msg TxsMsg
+		if e := encoder.DeserializeRaw(raw, &msg); e != nil {
+			return e
+		}
+		if !p.requesting {
+			return fmt.Errorf("received %d txs that were not requested", len(msg.Txs))
+		}
+		p.requesting = false
+		if len(msg.Txs) == 0 {
+			// The peer does not have the txs (such as if they are pruned), so
+			// they are requested again when it announces it's head.
+			return nil
+		}
+		if e := n.appendTxs(msg); e != nil {
+			return e
+		}
+		return n.requestTxs(p)
+
+	case MsgAddress:
+		var msg AddressMsg
+		if e := encoder.DeserializeRaw(raw, &msg); e != nil {
+			return e
+		}
+		return n.handleAddress(p, msg)
+
+	case MsgGetPeers:
+		n.mux.Lock()
+		self := p.listen
+		n.mux.Unlock()
+		reply := PeersMsg{}
+		for _, address := range n.pm.knownAddresses() {
+			if len(reply.Addresses) == maxPeersReply {
+				break
+			}
+			if address != self {
+				reply.Addresses = append(reply.Addresses, address)
+			}
+		}
+		return p.send(MsgPeers, reply)
+
+	case MsgPeers:
+		var msg PeersMsg
+		if e := encoder.DeserializeRaw(raw, &msg); e != nil {
+			return e
+		}
+		if len(msg.Addresses) > maxPeersReply {
+			return fmt.Errorf("received %d peers, the maximum is %d",
+				len(msg.Addresses), maxPeersReply)
+		}
+		n.pm.addKnown(msg.Addresses...)
+		return nil
+
+	case MsgTx:
+		var msg TxMsg
+		if e := encoder.DeserializeRaw(raw, &msg); e != nil {
+			return e
+		}
+		return n.handleTx(p, msg)
+
+	default:
+		return fmt.Errorf("unknown message type %d", t)
+	}
+}
+
+// handleAddress records the address that an inbound peer accepts peers on, so
+// that it can be shared with other peers.
+func (n *Node) handleAddress(p *peer, msg AddressMsg) error {
+	host, port, e := net.SplitHostPort(msg.Address)
+	if e != nil {
+		return e
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = hostOf(p.address)
+	}
+	address := net.JoinHostPort(host, port)
+
+	n.mux.Lock()
+	p.listen = address
+	n.mux.Unlock()
+
+	n.pm.addKnown(address)
+	return nil
+}
+
+// requestTxs requests the next batch of txs from the peer, if it's chain is
+// longer and no txs are already requested from it.
+func (n *Node) requestTxs(p *peer) error {
+	cLen, pLen := n.chainLen(), p.chainLen()
+	if pLen <= cLen || p.requesting {
+		return nil
+	}
+	count := pLen - cLen
+	if count > n.c.SyncBatchSize {
+		count = n.c.SyncBatchSize
+	}
+	p.requesting = true
+	return p.send(MsgGetTxs, GetTxsMsg{Start: cLen, Count: count})
+}
+
+// replyTxs replies the requested txs that are available, which are less than
+// requested if the range exceeds the chain, or if txs are pruned.
+func (n *Node) replyTxs(p *peer, msg GetTxsMsg) error {
+	count := msg.Count
+	if count > n.c.SyncBatchSize {
+		count = n.c.SyncBatchSize
+	}
+	reply := TxsMsg{Start: msg.Start}
+	for seq := msg.Start; seq < msg.Start+count; seq++ {
+		txWrap, e := n.bc.GetTxOfSeq(seq)
+		if e != nil {
+			break
+		}
+		reply.Txs = append(reply.Txs, n.codec.EncodeTx(txWrap))
+	}
+	return p.send(MsgTxs, reply)
+}
+
+// appendTxs appends the txs of the message that are not yet in the chain.
+// Another peer may have replied the same txs, in which case appending fails
+// but the chain already has them.
+func (n *Node) appendTxs(msg TxsMsg) error {
+	var (
+		cLen    = n.chainLen()
+		txWraps = make([]iko.TxWrapper, 0, len(msg.Txs))
+	)
+	for i, raw := range msg.Txs {
+		var txWrap iko.TxWrapper
+		if e := n.codec.DecodeTx(raw, &txWrap); e != nil {
+			return e
+		}
+		if exp := msg.Start + uint64(i); txWrap.Meta.Seq != exp {
+			return fmt.Errorf("received tx of seq %d, expected %d", txWrap.Meta.Seq, exp)
+		}
+		if txWrap.Meta.Seq >= cLen {
+			txWraps = append(txWraps, txWrap)
+		}
+	}
+	if len(txWraps) == 0 {
+		return nil
+	}
+	count, e := n.bc.AppendTxs(txWraps)
+	if count > 0 {
+		n.l.
+			WithField("count", count).
+			WithField("head_seq", txWraps[count-1].Meta.Seq).
+			Debug("appended txs of peer")
+	}
+	if e != nil && n.chainLen() <= txWraps[count].Meta.Seq {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Package signer implements a remote signing daemon and it's client, so that
+// creator and owner secret keys can be kept on a separate hardened machine.
+// Requests are authenticated with a HMAC of a shared secret.
+package signer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/kittycash/wallet/src/util"
+)
+
+const (
+	// MinSecretSize is the minimum size of the shared secret.
+	MinSecretSize = 16
+
+	// MaxClockSkew is the maximum difference between the timestamp of a
+	// request and the time of the server.
+	MaxClockSkew = 30 * time.Second
+)
+
+// authHeaders are the headers of authenticated requests.
+var authHeaders = util.HMACHeaders{
+	Timestamp: "X-Signer-Timestamp",
+	Nonce:     "X-Signer-Nonce",
+	MAC:       "X-Signer-MAC",
+}
+
+var (
+	ErrSecretTooShort = fmt.Errorf("shared secret should be at least %d bytes", MinSecretSize)
+	ErrUnauthorized   = util.ErrUnauthorized
+)
+
+// ReadSecretFile reads a shared secret from file, ignoring surrounding
+// whitespace.
+func ReadSecretFile(path string) ([]byte, error) {
+	raw, e := ioutil.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	secret := bytes.TrimSpace(raw)
+	if len(secret) < MinSecretSize {
+		return nil, ErrSecretTooShort
+	}
+	return secret, nil
+}
+
+// authenticate adds authentication headers to a request of the body.
+func authenticate(secret []byte, r *http.Request, body []byte) {
+	util.SignHMAC(secret, authHeaders, r, body)
+}
+
+// newVerifier creates a verifier of the authentication headers of requests.
+func newVerifier(secret []byte) *util.HMACVerifier {
+	return util.NewHMACVerifier(secret, authHeaders, MaxClockSkew)
+}
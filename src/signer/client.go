@@ -0,0 +1,150 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/util"
+)
+
+const (
+	DefaultClientTimeout = 30 * time.Second
+)
+
+var (
+	// ErrHashSigning occurs when a remote signer is asked to sign a bare hash.
+	// The signer only signs whole transactions, so that it can log and check
+	// them against it's policy.
+	ErrHashSigning = errors.New("remote signer only signs whole transactions")
+)
+
+type ClientConfig struct {
+	URL     string        // Such as "https://10.0.0.2:7909".
+	Secret  []byte        // Shared secret of the signer.
+	CAFile  string        // Optional, to trust a self-signed signer certificate.
+	Timeout time.Duration // Optional.
+}
+
+// Client requests signatures from a remote signer.
+type Client struct {
+	c    *ClientConfig
+	http *http.Client
+}
+
+func NewClient(c *ClientConfig) (*Client, error) {
+	if len(c.Secret) < MinSecretSize {
+		return nil, ErrSecretTooShort
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultClientTimeout
+	}
+	client := &Client{
+		c:    c,
+		http: &http.Client{Timeout: timeout},
+	}
+	if c.CAFile != "" {
+		pool, e := util.LoadCertPool(c.CAFile)
+		if e != nil {
+			return nil, e
+		}
+		client.http.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		}
+	}
+	return client, nil
+}
+
+// Addresses obtains the addresses that the signer holds keys of.
+func (c *Client) Addresses() ([]cipher.Address, error) {
+	var reply AddressesReply
+	if e := c.do("GET", "/api/addresses", nil, &reply); e != nil {
+		return nil, e
+	}
+	out := make([]cipher.Address, len(reply.Addresses))
+	for i, s := range reply.Addresses {
+		var e error
+		if out[i], e = cipher.DecodeBase58Address(s); e != nil {
+			return nil, e
+		}
+	}
+	return out, nil
+}
+
+// SignTx requests the signature of the transaction by the address.
+func (c *Client) SignTx(address cipher.Address, tx *iko.Transaction) (cipher.Sig, error) {
+	unsigned := *tx
+	unsigned.Sig = cipher.Sig{}
+	req := SignRequest{
+		Address: address.String(),
+		Tx:      hex.EncodeToString(unsigned.Serialize()),
+	}
+	var reply SignReply
+	if e := c.do("POST", "/api/sign", req, &reply); e != nil {
+		return cipher.Sig{}, e
+	}
+	return cipher.SigFromHex(reply.Sig)
+}
+
+// Signer obtains a signer of the address, for use in transaction construction.
+func (c *Client) Signer(address cipher.Address) iko.TxSigner {
+	return &remoteSigner{c: c, address: address}
+}
+
+func (c *Client) do(method, path string, req, reply interface{}) error {
+	var body []byte
+	if req != nil {
+		var e error
+		if body, e = json.Marshal(req); e != nil {
+			return e
+		}
+	}
+	r, e := http.NewRequest(method, strings.TrimSuffix(c.c.URL, "/")+path, bytes.NewReader(body))
+	if e != nil {
+		return e
+	}
+	r.Header.Set("Content-Type", "application/json")
+	authenticate(c.c.Secret, r, body)
+
+	resp, e := c.http.Do(r)
+	if e != nil {
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errReply ErrorReply
+		if e := json.NewDecoder(resp.Body).Decode(&errReply); e != nil || errReply.Error == "" {
+			return fmt.Errorf("signer replied with status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("signer replied with status %d: %s", resp.StatusCode, errReply.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
+
+type remoteSigner struct {
+	c       *Client
+	address cipher.Address
+}
+
+func (s *remoteSigner) Address() cipher.Address {
+	return s.address
+}
+
+func (s *remoteSigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	return cipher.Sig{}, ErrHashSigning
+}
+
+func (s *remoteSigner) SignTx(tx *iko.Transaction) (cipher.Sig, error) {
+	return s.c.SignTx(s.address, tx)
+}
@@ -0,0 +1,218 @@
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"gopkg.in/sirupsen/logrus.v1"
+
+	"github.com/kittycash/wallet/src/iko"
+	"github.com/kittycash/wallet/src/util"
+)
+
+const (
+	maxBodySize = 64 << 10
+)
+
+// KeyStore holds the secret keys of the signer. It is satisfied by
+// '*wallet.KeyStore'.
+type KeyStore interface {
+	Addresses() []cipher.Address
+	SecKey(address cipher.Address) (cipher.SecKey, error)
+}
+
+// Policy decides whether the address may sign the transaction. A non-nil error
+// denies the signature.
+type Policy func(address cipher.Address, tx *iko.Transaction) error
+
+type SignRequest struct {
+	Address string `json:"address"`
+	Tx      string `json:"tx"` // Hex encoded serialized tx, without signature.
+}
+
+type SignReply struct {
+	Sig string `json:"sig"`
+}
+
+type AddressesReply struct {
+	Addresses []string `json:"addresses"`
+}
+
+type ErrorReply struct {
+	Error string `json:"error"`
+}
+
+type ServerConfig struct {
+	Address     string
+	Secret      []byte
+	TLSCertFile string // Optional.
+	TLSKeyFile  string // Optional.
+	Policy      Policy // Optional.
+}
+
+// Server serves signatures of the keys of a KeyStore.
+type Server struct {
+	c   *ServerConfig
+	l   *logrus.Logger
+	lis net.Listener
+	srv *http.Server
+	wg  sync.WaitGroup
+}
+
+func NewServer(c *ServerConfig, keys KeyStore) (*Server, error) {
+	h, e := NewHandler(c.Secret, keys, c.Policy)
+	if e != nil {
+		return nil, e
+	}
+	s := &Server{
+		c:   c,
+		l:   h.l,
+		srv: &http.Server{Handler: h},
+	}
+	if s.lis, e = net.Listen("tcp", c.Address); e != nil {
+		return nil, e
+	}
+	useTLS := c.TLSCertFile != "" && c.TLSKeyFile != ""
+	if !useTLS {
+		s.l.Warning("signer is serving without tls, requests are authenticated but not private")
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		var e error
+		if useTLS {
+			e = s.srv.ServeTLS(s.lis, c.TLSCertFile, c.TLSKeyFile)
+		} else {
+			e = s.srv.Serve(s.lis)
+		}
+		if e != http.ErrServerClosed {
+			s.l.WithError(e).Error("signer server stopped")
+		}
+	}()
+	s.l.Infof("signer listening on: '%s'", c.Address)
+	return s, nil
+}
+
+func (s *Server) Close() {
+	if e := s.srv.Close(); e != nil {
+		s.l.WithError(e).Error("error on close")
+	}
+	s.wg.Wait()
+}
+
+// Handler serves the signer's endpoints:
+//
+//	GET  /api/addresses -> AddressesReply
+//	POST /api/sign      -> SignRequest -> SignReply
+//
+// Errors are replied with ErrorReply.
+type Handler struct {
+	v      *util.HMACVerifier
+	keys   KeyStore
+	policy Policy
+	l      *logrus.Logger
+}
+
+func NewHandler(secret []byte, keys KeyStore, policy Policy) (*Handler, error) {
+	if len(secret) < MinSecretSize {
+		return nil, ErrSecretTooShort
+	}
+	return &Handler{
+		v:      newVerifier(secret),
+		keys:   keys,
+		policy: policy,
+		l:      logrus.New(),
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, e := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if e != nil {
+		sendError(w, http.StatusBadRequest, e)
+		return
+	}
+	if e := h.v.Verify(r, body); e != nil {
+		h.l.WithField("remote", r.RemoteAddr).WithError(e).
+			Warning("rejected unauthorized request")
+		sendError(w, http.StatusUnauthorized, e)
+		return
+	}
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/api/addresses":
+		h.addresses(w)
+	case r.Method == "POST" && r.URL.Path == "/api/sign":
+		h.sign(w, r, body)
+	default:
+		sendError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (h *Handler) addresses(w http.ResponseWriter) {
+	addresses := h.keys.Addresses()
+	reply := AddressesReply{Addresses: make([]string, len(addresses))}
+	for i, address := range addresses {
+		reply.Addresses[i] = address.String()
+	}
+	sendJson(w, http.StatusOK, reply)
+}
+
+func (h *Handler) sign(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req SignRequest
+	if e := json.Unmarshal(body, &req); e != nil {
+		sendError(w, http.StatusBadRequest, e)
+		return
+	}
+	address, e := cipher.DecodeBase58Address(req.Address)
+	if e != nil {
+		sendError(w, http.StatusBadRequest, e)
+		return
+	}
+	raw, e := hex.DecodeString(req.Tx)
+	if e != nil {
+		sendError(w, http.StatusBadRequest, e)
+		return
+	}
+	tx, e := iko.DeserializeTx(raw)
+	if e != nil {
+		sendError(w, http.StatusBadRequest, e)
+		return
+	}
+	log := h.l.
+		WithField("remote", r.RemoteAddr).
+		WithField("address", req.Address).
+		WithField("tx", tx.String())
+
+	if h.policy != nil {
+		if e := h.policy(address, tx); e != nil {
+			log.WithError(e).Warning("denied signature by policy")
+			sendError(w, http.StatusForbidden, e)
+			return
+		}
+	}
+	sk, e := h.keys.SecKey(address)
+	if e != nil {
+		log.WithError(e).Warning("failed to obtain secret key")
+		sendError(w, http.StatusServiceUnavailable, e)
+		return
+	}
+	sig := cipher.SignHash(tx.HashInner(), sk)
+	log.Info("signed tx")
+	sendJson(w, http.StatusOK, SignReply{Sig: sig.Hex()})
+}
+
+func sendJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func sendError(w http.ResponseWriter, status int, e error) {
+	sendJson(w, status, ErrorReply{Error: e.Error()})
+}
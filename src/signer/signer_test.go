@@ -0,0 +1,126 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+)
+
+type memoryKeys map[cipher.Address]cipher.SecKey
+
+func (k memoryKeys) Addresses() []cipher.Address {
+	var out []cipher.Address
+	for address := range k {
+		out = append(out, address)
+	}
+	return out
+}
+
+func (k memoryKeys) SecKey(address cipher.Address) (cipher.SecKey, error) {
+	sk, ok := k[address]
+	if !ok {
+		return cipher.SecKey{}, errors.New("unknown address")
+	}
+	return sk, nil
+}
+
+func newTestSigner(t *testing.T, policy Policy) (*Client, cipher.SecKey, *httptest.Server) {
+	pk, sk := cipher.GenerateKeyPair()
+	h, err := NewHandler(testSecret, memoryKeys{cipher.AddressFromPubKey(pk): sk}, policy)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(h)
+	c, err := NewClient(&ClientConfig{URL: srv.URL, Secret: testSecret})
+	require.NoError(t, err)
+
+	return c, sk, srv
+}
+
+func TestClient_SignTx(t *testing.T) {
+	c, sk, srv := newTestSigner(t, nil)
+	defer srv.Close()
+
+	address := cipher.AddressFromSecKey(sk)
+	addresses, err := c.Addresses()
+	require.NoError(t, err)
+	require.Equal(t, []cipher.Address{address}, addresses)
+
+	genTx, err := iko.NewGenTxWithSigner(5, c.Signer(address))
+	require.NoError(t, err)
+	require.NoError(t, genTx.VerifyWith(nil, cipher.PubKeyFromSecKey(sk)))
+
+	toPK, _ := cipher.GenerateKeyPair()
+	toAddr := cipher.AddressFromPubKey(toPK)
+	transferTx, err := iko.NewTransferTxWithSigner(genTx, toAddr, c.Signer(address))
+	require.NoError(t, err)
+	require.NoError(t, transferTx.VerifyWith(genTx, cipher.PubKey{}))
+
+	// Keys the signer does not hold.
+	_, err = iko.NewGenTxWithSigner(5, c.Signer(toAddr))
+	require.Error(t, err)
+
+	_, err = c.Signer(address).SignHash(genTx.HashInner())
+	require.Equal(t, ErrHashSigning, err)
+}
+
+func TestHandler_Unauthorized(t *testing.T) {
+	_, sk, srv := newTestSigner(t, nil)
+	defer srv.Close()
+
+	wrong, err := NewClient(&ClientConfig{
+		URL:    srv.URL,
+		Secret: []byte("fedcba9876543210fedcba9876543210"),
+	})
+	require.NoError(t, err)
+	_, err = wrong.Addresses()
+	require.Error(t, err)
+	_, err = iko.NewGenTxWithSigner(1, wrong.Signer(cipher.AddressFromSecKey(sk)))
+	require.Error(t, err)
+
+	// Requests cannot be replayed.
+	body := []byte(`{}`)
+	req, err := http.NewRequest("GET", srv.URL+"/api/addresses", bytes.NewReader(body))
+	require.NoError(t, err)
+	authenticate(testSecret, req, body)
+
+	for i, exp := range []int{http.StatusOK, http.StatusUnauthorized} {
+		replay, err := http.NewRequest("GET", srv.URL+"/api/addresses", bytes.NewReader(body))
+		require.NoError(t, err)
+		replay.Header = req.Header
+		resp, err := http.DefaultClient.Do(replay)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, exp, resp.StatusCode, "request %d", i)
+	}
+
+	_, err = NewClient(&ClientConfig{URL: srv.URL, Secret: []byte("short")})
+	require.Equal(t, ErrSecretTooShort, err)
+}
+
+func TestHandler_Policy(t *testing.T) {
+	policy := func(address cipher.Address, tx *iko.Transaction) error {
+		if tx.KittyID > 10 {
+			return errors.New("kitty id is out of range")
+		}
+		return nil
+	}
+	c, sk, srv := newTestSigner(t, policy)
+	defer srv.Close()
+
+	s := c.Signer(cipher.AddressFromSecKey(sk))
+	_, err := iko.NewGenTxWithSigner(10, s)
+	require.NoError(t, err)
+	_, err = iko.NewGenTxWithSigner(11, s)
+	require.EqualError(t, err, "signer replied with status 403: kitty id is out of range")
+}
@@ -0,0 +1,239 @@
+// Package graphql executes GraphQL queries against dynamically resolved
+// objects. Only a subset of GraphQL is supported: queries with nested
+// selection sets, aliases, arguments, variables and '__typename'. Mutations,
+// subscriptions, fragments and directives are rejected.
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Object is a GraphQL object, which resolves it's own fields.
+// A resolved value is either nil, an Object, a []Object or a scalar that
+// can be encoded as JSON.
+type Object interface {
+	TypeName() string
+	Resolve(field string, args Args) (interface{}, error)
+}
+
+// UnknownField returns the error for when an object has no field of the
+// given name.
+func UnknownField(o Object, field string) error {
+	return fmt.Errorf("unknown field '%s' on type '%s'", field, o.TypeName())
+}
+
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Execute executes the request against the root query object. Fields that
+// fail to resolve are set to null, with the error recorded in the response.
+func Execute(root Object, req *Request) *Response {
+	op, e := parse(req.Query, req.OperationName)
+	if e != nil {
+		return &Response{Errors: []Error{{Message: e.Error()}}}
+	}
+	ex := &executor{
+		vars:     req.Variables,
+		defaults: op.defaults,
+	}
+	data := ex.object(root, op.sel, nil)
+	return &Response{Data: data, Errors: ex.errs}
+}
+
+type executor struct {
+	vars     map[string]interface{}
+	defaults map[string]interface{}
+	errs     []Error
+}
+
+func (ex *executor) fail(path []interface{}, e error) {
+	ex.errs = append(ex.errs, Error{
+		Message: e.Error(),
+		Path:    append([]interface{}{}, path...),
+	})
+}
+
+func (ex *executor) object(o Object, sel []*field, path []interface{}) orderedMap {
+	out := make(orderedMap, 0, len(sel))
+	for _, f := range sel {
+		fPath := append(path, f.key())
+		if f.name == "__typename" {
+			out = append(out, keyValue{f.key(), o.TypeName()})
+			continue
+		}
+		v, e := o.Resolve(f.name, ex.args(f.args))
+		if e == nil {
+			v, e = ex.value(v, f, fPath)
+		}
+		if e != nil {
+			ex.fail(fPath, e)
+			v = nil
+		}
+		out = append(out, keyValue{f.key(), v})
+	}
+	return out
+}
+
+func (ex *executor) value(v interface{}, f *field, path []interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case Object:
+		if f.sel == nil {
+			return nil, fmt.Errorf("field '%s' of type '%s' must have a selection",
+				f.name, v.TypeName())
+		}
+		return ex.object(v, f.sel, path), nil
+	case []Object:
+		if f.sel == nil {
+			return nil, fmt.Errorf("field '%s' of object list must have a selection", f.name)
+		}
+		list := make([]interface{}, len(v))
+		for i, o := range v {
+			list[i] = ex.object(o, f.sel, append(path, i))
+		}
+		return list, nil
+	default:
+		if f.sel != nil {
+			return nil, fmt.Errorf("field '%s' is a scalar and cannot have a selection", f.name)
+		}
+		return v, nil
+	}
+}
+
+// args substitutes the variables of the field arguments.
+func (ex *executor) args(in map[string]interface{}) Args {
+	out := make(Args, len(in))
+	for k, v := range in {
+		out[k] = ex.substitute(v)
+	}
+	return out
+}
+
+func (ex *executor) substitute(v interface{}) interface{} {
+	switch v := v.(type) {
+	case variable:
+		if value, ok := ex.vars[string(v)]; ok {
+			return value
+		}
+		return ex.defaults[string(v)]
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = ex.substitute(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = ex.substitute(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+/*
+	<<< ARGUMENTS >>>
+*/
+
+// Args are the arguments of a field. Values are nil, bool, int64, float64,
+// string, []interface{} or map[string]interface{}. Numbers from variables are
+// always float64.
+type Args map[string]interface{}
+
+// Has returns true if the argument is given and not null.
+func (a Args) Has(name string) bool {
+	return a[name] != nil
+}
+
+func (a Args) String(name string) (string, error) {
+	switch v := a[name].(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("argument '%s' is required", name)
+	default:
+		return "", fmt.Errorf("argument '%s' should be a string", name)
+	}
+}
+
+func (a Args) Int(name string) (int64, error) {
+	switch v := a[name].(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return 0, fmt.Errorf("argument '%s' should be an integer", name)
+		}
+		return int64(v), nil
+	case nil:
+		return 0, fmt.Errorf("argument '%s' is required", name)
+	default:
+		return 0, fmt.Errorf("argument '%s' should be an integer", name)
+	}
+}
+
+// Uint is as Int, but rejects negative values.
+func (a Args) Uint(name string) (uint64, error) {
+	v, e := a.Int(name)
+	if e != nil {
+		return 0, e
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("argument '%s' should not be negative", name)
+	}
+	return uint64(v), nil
+}
+
+/*
+	<<< HELPER FUNCTIONS >>>
+*/
+
+type keyValue struct {
+	key   string
+	value interface{}
+}
+
+// orderedMap is a JSON object that keeps the order of the selection set.
+type orderedMap []keyValue
+
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, e := json.Marshal(kv.key)
+		if e != nil {
+			return nil, e
+		}
+		v, e := json.Marshal(kv.value)
+		if e != nil {
+			return nil, e
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
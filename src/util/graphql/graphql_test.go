@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testNode struct {
+	id       int64
+	children []Object
+}
+
+func (n testNode) TypeName() string {
+	return "Node"
+}
+
+func (n testNode) Resolve(field string, args Args) (interface{}, error) {
+	switch field {
+	case "id":
+		return n.id, nil
+	case "children":
+		return n.children, nil
+	case "child":
+		i, e := args.Int("index")
+		if e != nil {
+			return nil, e
+		}
+		if i < 0 || i >= int64(len(n.children)) {
+			return nil, nil
+		}
+		return n.children[i], nil
+	case "echo":
+		return args.String("text")
+	case "fail":
+		return nil, errors.New("failed")
+	default:
+		return nil, UnknownField(n, field)
+	}
+}
+
+func testRoot() Object {
+	return testNode{id: 1, children: []Object{
+		testNode{id: 2},
+		testNode{id: 3, children: []Object{testNode{id: 4}}},
+	}}
+}
+
+func execute(t *testing.T, req *Request) string {
+	out, err := json.Marshal(Execute(testRoot(), req))
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestExecute(t *testing.T) {
+	cases := []struct {
+		name  string
+		req   Request
+		reply string
+	}{
+		{
+			name:  "Shorthand",
+			req:   Request{Query: `{ id children { id } }`},
+			reply: `{"data":{"id":1,"children":[{"id":2},{"id":3}]}}`,
+		},
+		{
+			name: "NestedWithArgsAndAlias",
+			req: Request{Query: `
+				# A comment.
+				query Q {
+					second: child(index: 1) { __typename id children { id } }
+					missing: child(index: 5) { id }
+				}`},
+			reply: `{"data":{"second":{"__typename":"Node","id":3,"children":[{"id":4}]},"missing":null}}`,
+		},
+		{
+			name: "Variables",
+			req: Request{
+				Query:     `query Q($i: Int!, $text: String = "default") { child(index: $i) { id } echo(text: $text) }`,
+				Variables: map[string]interface{}{"i": float64(0)},
+			},
+			reply: `{"data":{"child":{"id":2},"echo":"default"}}`,
+		},
+		{
+			name: "OperationName",
+			req: Request{
+				Query:         `query A { id } query B { echo(text: "b\n") }`,
+				OperationName: "B",
+			},
+			reply: `{"data":{"echo":"b\n"}}`,
+		},
+		{
+			name:  "FieldErrors",
+			req:   Request{Query: `{ id fail children { id nope } }`},
+			reply: `{"data":{"id":1,"fail":null,"children":[{"id":2,"nope":null},{"id":3,"nope":null}]},"errors":[{"message":"failed","path":["fail"]},{"message":"unknown field 'nope' on type 'Node'","path":["children",0,"nope"]},{"message":"unknown field 'nope' on type 'Node'","path":["children",1,"nope"]}]}`,
+		},
+		{
+			name:  "MissingSelection",
+			req:   Request{Query: `{ children }`},
+			reply: `{"data":{"children":null},"errors":[{"message":"field 'children' of object list must have a selection","path":["children"]}]}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.JSONEq(t, c.reply, execute(t, &c.req))
+			require.Equal(t, c.reply, execute(t, &c.req), "field order should be kept")
+		})
+	}
+}
+
+func TestExecute_Invalid(t *testing.T) {
+	for _, query := range []string{
+		``,
+		`{ id `,
+		`{ }`,
+		`mutation { id }`,
+		`{ ...frag }`,
+		`{ id @skip(if: true) }`,
+		`query A { id } query B { id }`,
+		`{ echo(text: "unterminated) }`,
+		`{ a { b { c { d { e { f { g { h { i { j { k { l { m } } } } } } } } } } } } }`,
+	} {
+		resp := Execute(testRoot(), &Request{Query: query})
+		require.Nil(t, resp.Data, query)
+		require.Len(t, resp.Errors, 1, query)
+	}
+}
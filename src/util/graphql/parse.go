@@ -0,0 +1,408 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxDepth is the maximum nesting of selection sets in a query.
+	MaxDepth = 12
+)
+
+var (
+	ErrNoOperation = errors.New("query has no operation")
+)
+
+// operation is a parsed query operation.
+type operation struct {
+	name     string
+	defaults map[string]interface{} // Default values of variables.
+	sel      []*field
+}
+
+// field is a field selection.
+type field struct {
+	alias string
+	name  string
+	args  map[string]interface{} // Values may contain 'variable's.
+	sel   []*field
+}
+
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// variable references a variable from within an argument value.
+type variable string
+
+// parse parses a query document, returning the operation of the given name.
+// The name can be empty if the document only contains one operation.
+func parse(query, opName string) (*operation, error) {
+	p := &parser{s: query}
+	var ops []*operation
+	for p.skipIgnored(); p.pos < len(p.s); p.skipIgnored() {
+		op, e := p.operation()
+		if e != nil {
+			return nil, e
+		}
+		ops = append(ops, op)
+	}
+	switch {
+	case len(ops) == 0:
+		return nil, ErrNoOperation
+	case opName == "" && len(ops) == 1:
+		return ops[0], nil
+	case opName == "":
+		return nil, errors.New("operation name is required for documents with multiple operations")
+	}
+	for _, op := range ops {
+		if op.name == opName {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("operation '%s' not found", opName)
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("syntax error at %d: %s", p.pos, fmt.Sprintf(format, a...))
+}
+
+// skipIgnored skips whitespace, commas and comments.
+func (p *parser) skipIgnored() {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\n', '\r', ',':
+			p.pos++
+		case '#':
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' && p.s[p.pos] != '\r' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) peek() byte {
+	p.skipIgnored()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func (p *parser) name() (string, error) {
+	p.skipIgnored()
+	start := p.pos
+	if p.pos >= len(p.s) || !isNameStart(p.s[p.pos]) {
+		return "", p.errorf("expected name")
+	}
+	for p.pos < len(p.s) && isNameContinue(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{defaults: make(map[string]interface{})}
+	if p.peek() == '{' {
+		sel, e := p.selectionSet(1)
+		op.sel = sel
+		return op, e
+	}
+	keyword, e := p.name()
+	if e != nil {
+		return nil, e
+	}
+	switch keyword {
+	case "query":
+	case "mutation", "subscription", "fragment":
+		return nil, fmt.Errorf("%s is not supported", keyword)
+	default:
+		return nil, p.errorf("unexpected '%s'", keyword)
+	}
+	if isNameStart(p.peek()) {
+		if op.name, e = p.name(); e != nil {
+			return nil, e
+		}
+	}
+	if p.peek() == '(' {
+		if e := p.variableDefinitions(op); e != nil {
+			return nil, e
+		}
+	}
+	if p.peek() == '@' {
+		return nil, errors.New("directives are not supported")
+	}
+	if op.sel, e = p.selectionSet(1); e != nil {
+		return nil, e
+	}
+	return op, nil
+}
+
+func (p *parser) variableDefinitions(op *operation) error {
+	if e := p.expect('('); e != nil {
+		return e
+	}
+	for p.peek() != ')' {
+		if e := p.expect('$'); e != nil {
+			return e
+		}
+		name, e := p.name()
+		if e != nil {
+			return e
+		}
+		if e := p.expect(':'); e != nil {
+			return e
+		}
+		if e := p.typeRef(); e != nil {
+			return e
+		}
+		if p.peek() == '=' {
+			p.pos++
+			v, e := p.value(true)
+			if e != nil {
+				return e
+			}
+			op.defaults[name] = v
+		}
+	}
+	p.pos++
+	return nil
+}
+
+// typeRef skips over a type reference, as variables are not type checked.
+func (p *parser) typeRef() error {
+	if p.peek() == '[' {
+		p.pos++
+		if e := p.typeRef(); e != nil {
+			return e
+		}
+		if e := p.expect(']'); e != nil {
+			return e
+		}
+	} else if _, e := p.name(); e != nil {
+		return e
+	}
+	if p.peek() == '!' {
+		p.pos++
+	}
+	return nil
+}
+
+func (p *parser) selectionSet(depth int) ([]*field, error) {
+	if depth > MaxDepth {
+		return nil, fmt.Errorf("query exceeds maximum depth of %d", MaxDepth)
+	}
+	if e := p.expect('{'); e != nil {
+		return nil, e
+	}
+	var sel []*field
+	for p.peek() != '}' {
+		if p.peek() == 0 {
+			return nil, p.errorf("unexpected end of query")
+		}
+		if strings.HasPrefix(p.s[p.pos:], "...") {
+			return nil, errors.New("fragments are not supported")
+		}
+		f, e := p.field(depth)
+		if e != nil {
+			return nil, e
+		}
+		sel = append(sel, f)
+	}
+	p.pos++
+	if len(sel) == 0 {
+		return nil, p.errorf("selection set is empty")
+	}
+	return sel, nil
+}
+
+func (p *parser) field(depth int) (*field, error) {
+	var (
+		f = new(field)
+		e error
+	)
+	if f.name, e = p.name(); e != nil {
+		return nil, e
+	}
+	if p.peek() == ':' {
+		p.pos++
+		f.alias = f.name
+		if f.name, e = p.name(); e != nil {
+			return nil, e
+		}
+	}
+	if p.peek() == '(' {
+		p.pos++
+		f.args = make(map[string]interface{})
+		for p.peek() != ')' {
+			name, e := p.name()
+			if e != nil {
+				return nil, e
+			}
+			if e := p.expect(':'); e != nil {
+				return nil, e
+			}
+			if f.args[name], e = p.value(false); e != nil {
+				return nil, e
+			}
+		}
+		p.pos++
+	}
+	if p.peek() == '@' {
+		return nil, errors.New("directives are not supported")
+	}
+	if p.peek() == '{' {
+		if f.sel, e = p.selectionSet(depth + 1); e != nil {
+			return nil, e
+		}
+	}
+	return f, nil
+}
+
+// value parses an input value. Const values may not contain variables.
+func (p *parser) value(isConst bool) (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '$':
+		if isConst {
+			return nil, p.errorf("unexpected variable")
+		}
+		p.pos++
+		name, e := p.name()
+		return variable(name), e
+
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+
+	case c == '"':
+		return p.string()
+
+	case c == '[':
+		p.pos++
+		list := make([]interface{}, 0)
+		for p.peek() != ']' {
+			v, e := p.value(isConst)
+			if e != nil {
+				return nil, e
+			}
+			list = append(list, v)
+		}
+		p.pos++
+		return list, nil
+
+	case c == '{':
+		p.pos++
+		obj := make(map[string]interface{})
+		for p.peek() != '}' {
+			name, e := p.name()
+			if e != nil {
+				return nil, e
+			}
+			if e := p.expect(':'); e != nil {
+				return nil, e
+			}
+			if obj[name], e = p.value(isConst); e != nil {
+				return nil, e
+			}
+		}
+		p.pos++
+		return obj, nil
+
+	case isNameStart(c):
+		name, _ := p.name()
+		switch name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return name, nil // Enum values are represented as strings.
+		}
+
+	default:
+		return nil, p.errorf("expected value")
+	}
+}
+
+func (p *parser) number() (interface{}, error) {
+	start, isFloat := p.pos, false
+	if p.s[p.pos] == '-' {
+		p.pos++
+	}
+	for ; p.pos < len(p.s); p.pos++ {
+		c := p.s[p.pos]
+		if c == '.' || c == 'e' || c == 'E' || ((c == '+' || c == '-') && isFloat) {
+			isFloat = true
+		} else if c < '0' || c > '9' {
+			break
+		}
+	}
+	raw := p.s[start:p.pos]
+	if isFloat {
+		v, e := strconv.ParseFloat(raw, 64)
+		if e != nil {
+			return nil, p.errorf("invalid float '%s'", raw)
+		}
+		return v, nil
+	}
+	v, e := strconv.ParseInt(raw, 10, 64)
+	if e != nil {
+		return nil, p.errorf("invalid int '%s'", raw)
+	}
+	return v, nil
+}
+
+func (p *parser) string() (interface{}, error) {
+	if strings.HasPrefix(p.s[p.pos:], `"""`) {
+		return nil, errors.New("block strings are not supported")
+	}
+	start := p.pos
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		switch p.s[p.pos] {
+		case '\\':
+			p.pos++
+		case '\n', '\r':
+			return nil, p.errorf("unterminated string")
+		case '"':
+			p.pos++
+			// GraphQL string escapes are a subset of those of JSON.
+			var v string
+			if e := json.Unmarshal([]byte(p.s[start:p.pos]), &v); e != nil {
+				return nil, p.errorf("invalid string: %v", e)
+			}
+			return v, nil
+		}
+	}
+	return nil, p.errorf("unterminated string")
+}
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// HMACNonceSize is the size of the nonce of HMAC-signed requests.
+	HMACNonceSize = 16
+)
+
+var (
+	// ErrUnauthorized occurs when a request has no valid HMAC signature.
+	ErrUnauthorized = errors.New("request is not authorized")
+)
+
+// HMACHeaders are the names of the headers of HMAC-signed requests.
+type HMACHeaders struct {
+	Timestamp string // Unix time of the request.
+	Nonce     string // Hex encoded random nonce, unique to each request.
+	MAC       string // Hex encoded HMAC of the request (see 'RequestMAC').
+}
+
+// RequestMAC returns the HMAC-SHA256 of the shared secret, of a request of the
+// method, path, raw query, timestamp, nonce and body.
+func RequestMAC(secret []byte, method, path, query, ts, nonce string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	for _, v := range []string{method, path, query, ts, nonce} {
+		mac.Write([]byte(v))
+		mac.Write([]byte{'\n'})
+	}
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// SignHMAC adds the HMAC authentication headers of the shared secret to a
+// request of the body.
+func SignHMAC(secret []byte, headers HMACHeaders, r *http.Request, body []byte) {
+	var (
+		ts    = strconv.FormatInt(time.Now().Unix(), 10)
+		nonce = hex.EncodeToString(cipher.RandByte(HMACNonceSize))
+		mac   = RequestMAC(secret, r.Method, r.URL.Path, r.URL.RawQuery, ts, nonce, body)
+	)
+	r.Header.Set(headers.Timestamp, ts)
+	r.Header.Set(headers.Nonce, nonce)
+	r.Header.Set(headers.MAC, hex.EncodeToString(mac))
+}
+
+// HMACVerifier checks the HMAC authentication headers of requests. Nonces are
+// remembered for as long as their timestamps are valid, so that requests
+// cannot be replayed.
+type HMACVerifier struct {
+	secret  []byte
+	headers HMACHeaders
+	maxSkew time.Duration
+	mux     sync.Mutex
+	nonces  map[string]time.Time
+}
+
+// NewHMACVerifier creates a HMACVerifier of the shared secret, that accepts
+// timestamps within 'maxSkew' of the time of the server.
+func NewHMACVerifier(secret []byte, headers HMACHeaders, maxSkew time.Duration) *HMACVerifier {
+	return &HMACVerifier{
+		secret:  secret,
+		headers: headers,
+		maxSkew: maxSkew,
+		nonces:  make(map[string]time.Time),
+	}
+}
+
+// Verify checks that the request of the body is signed with the shared
+// secret, is recent, and is not replayed.
+func (v *HMACVerifier) Verify(r *http.Request, body []byte) error {
+	var (
+		ts    = r.Header.Get(v.headers.Timestamp)
+		nonce = r.Header.Get(v.headers.Nonce)
+	)
+	mac, e := hex.DecodeString(r.Header.Get(v.headers.MAC))
+	if e != nil || ts == "" || len(nonce) != HMACNonceSize*2 {
+		return ErrUnauthorized
+	}
+	if !hmac.Equal(mac, RequestMAC(v.secret, r.Method, r.URL.Path, r.URL.RawQuery, ts, nonce, body)) {
+		return ErrUnauthorized
+	}
+	unix, e := strconv.ParseInt(ts, 10, 64)
+	if e != nil {
+		return ErrUnauthorized
+	}
+	var (
+		now     = time.Now()
+		reqTime = time.Unix(unix, 0)
+	)
+	if reqTime.Before(now.Add(-v.maxSkew)) || reqTime.After(now.Add(v.maxSkew)) {
+		return fmt.Errorf("request timestamp is not within %v of server time", v.maxSkew)
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	for n, expiry := range v.nonces {
+		if now.After(expiry) {
+			delete(v.nonces, n)
+		}
+	}
+	if _, ok := v.nonces[nonce]; ok {
+		return errors.New("request is replayed")
+	}
+	v.nonces[nonce] = reqTime.Add(v.maxSkew)
+	return nil
+}
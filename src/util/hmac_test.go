@@ -0,0 +1,73 @@
+package util
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMACVerifier(t *testing.T) {
+	var (
+		secret  = []byte("0123456789abcdef0123456789abcdef")
+		headers = HMACHeaders{Timestamp: "X-Test-Timestamp", Nonce: "X-Test-Nonce", MAC: "X-Test-MAC"}
+		body    = []byte(`{"kitty_id":1}`)
+		v       = NewHMACVerifier(secret, headers, time.Minute)
+	)
+	newReq := func(secret, body []byte) *http.Request {
+		r := httptest.NewRequest("POST", "/api/test", bytes.NewReader(body))
+		SignHMAC(secret, headers, r, body)
+		return r
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		require.NoError(t, v.Verify(newReq(secret, body), body))
+	})
+
+	t.Run("Replayed", func(t *testing.T) {
+		r := newReq(secret, body)
+		require.NoError(t, v.Verify(r, body))
+		require.Error(t, v.Verify(r, body), "replayed request should be refused")
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/api/test", bytes.NewReader(body))
+		require.Equal(t, ErrUnauthorized, v.Verify(r, body))
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		r := newReq([]byte("fedcba9876543210fedcba9876543210"), body)
+		require.Equal(t, ErrUnauthorized, v.Verify(r, body))
+	})
+
+	t.Run("ModifiedBody", func(t *testing.T) {
+		r := newReq(secret, body)
+		require.Equal(t, ErrUnauthorized, v.Verify(r, []byte(`{"kitty_id":2}`)))
+	})
+
+	t.Run("ModifiedQuery", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/api/test?hash=a", bytes.NewReader(body))
+		SignHMAC(secret, headers, r, body)
+		r.URL.RawQuery = "hash=b"
+		require.Equal(t, ErrUnauthorized, v.Verify(r, body),
+			"requests of a rewritten query should be refused")
+	})
+
+	t.Run("OldTimestamp", func(t *testing.T) {
+		var (
+			r     = httptest.NewRequest("POST", "/api/test", bytes.NewReader(body))
+			ts    = strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+			nonce = hex.EncodeToString(make([]byte, HMACNonceSize))
+		)
+		r.Header.Set(headers.Timestamp, ts)
+		r.Header.Set(headers.Nonce, nonce)
+		r.Header.Set(headers.MAC, hex.EncodeToString(
+			RequestMAC(secret, r.Method, r.URL.Path, r.URL.RawQuery, ts, nonce, body)))
+		require.Error(t, v.Verify(r, body), "stale request should be refused")
+	})
+}
@@ -0,0 +1,375 @@
+// Package metrics implements counters, gauges and histograms that are exposed
+// in the Prometheus text format, without depending on the Prometheus client.
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDuplicateMetric occurs when registering a metric of a name that is
+	// already registered.
+	ErrDuplicateMetric = errors.New("metric of name is already registered")
+)
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are the default histogram buckets, for latencies in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultRegistry is the registry that the metrics of packages are registered
+// to.
+var DefaultRegistry = NewRegistry()
+
+// Metric is a metric that can be exposed by a Registry.
+type Metric interface {
+	Name() string
+	write(b *bytes.Buffer)
+}
+
+/*
+	<<< REGISTRY >>>
+*/
+
+// Registry holds metrics to be exposed.
+type Registry struct {
+	mux     sync.Mutex
+	metrics map[string]Metric
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]Metric)}
+}
+
+// Register adds a metric to the registry.
+func (r *Registry) Register(m Metric) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, ok := r.metrics[m.Name()]; ok {
+		return fmt.Errorf("%v: '%s'", ErrDuplicateMetric, m.Name())
+	}
+	r.metrics[m.Name()] = m
+	return nil
+}
+
+// MustRegister adds metrics to the registry, panicking on error.
+func (r *Registry) MustRegister(ms ...Metric) {
+	for _, m := range ms {
+		if e := r.Register(m); e != nil {
+			panic(e)
+		}
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text format, ordered by name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mux.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ms := make([]Metric, len(names))
+	for i, name := range names {
+		ms[i] = r.metrics[name]
+	}
+	r.mux.Unlock()
+
+	var b bytes.Buffer
+	for _, m := range ms {
+		m.write(&b)
+	}
+	return b.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteTo(w)
+}
+
+/*
+	<<< COUNTER >>>
+*/
+
+// Counter is a value that only increases.
+type Counter struct {
+	name, help string
+	mux        sync.Mutex
+	v          float64
+}
+
+// NewCounter creates a Counter.
+func NewCounter(name, help string) *Counter {
+	return &Counter{name: name, help: help}
+}
+
+func (c *Counter) Name() string { return c.name }
+
+// Inc increments the counter by 1.
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add increases the counter by 'v', which should not be negative.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.mux.Lock()
+	c.v += v
+	c.mux.Unlock()
+}
+
+// Value obtains the value of the counter.
+func (c *Counter) Value() float64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.v
+}
+
+func (c *Counter) write(b *bytes.Buffer) {
+	writeHeader(b, c.name, c.help, "counter")
+	writeSample(b, c.name, "", c.Value())
+}
+
+/*
+	<<< GAUGE >>>
+*/
+
+// Gauge is a value that can be set arbitrarily.
+type Gauge struct {
+	name, help string
+	mux        sync.Mutex
+	v          float64
+}
+
+// NewGauge creates a Gauge.
+func NewGauge(name, help string) *Gauge {
+	return &Gauge{name: name, help: help}
+}
+
+func (g *Gauge) Name() string { return g.name }
+
+// Set sets the value of the gauge.
+func (g *Gauge) Set(v float64) {
+	g.mux.Lock()
+	g.v = v
+	g.mux.Unlock()
+}
+
+// Add adds 'v' to the gauge, which may be negative.
+func (g *Gauge) Add(v float64) {
+	g.mux.Lock()
+	g.v += v
+	g.mux.Unlock()
+}
+
+// Value obtains the value of the gauge.
+func (g *Gauge) Value() float64 {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	return g.v
+}
+
+func (g *Gauge) write(b *bytes.Buffer) {
+	writeHeader(b, g.name, g.help, "gauge")
+	writeSample(b, g.name, "", g.Value())
+}
+
+/*
+	<<< HISTOGRAM >>>
+*/
+
+// Histogram counts observations in cumulative buckets.
+type Histogram struct {
+	name, help string
+	buckets    []float64
+	mux        sync.Mutex
+	counts     []uint64
+	count      uint64
+	sum        float64
+}
+
+// NewHistogram creates a Histogram of the given upper bounds, which are
+// sorted. 'DefBuckets' are used if none are given.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *Histogram) Name() string { return h.name }
+
+// Observe adds an observation.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveSince observes the seconds elapsed since 'start'.
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// Count obtains the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(b *bytes.Buffer) {
+	writeHeader(b, h.name, h.help, "histogram")
+	h.writeSamples(b, "")
+}
+
+func (h *Histogram) writeSamples(b *bytes.Buffer, labels string) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	var cum uint64
+	for i, bound := range h.buckets {
+		cum += h.counts[i]
+		writeSample(b, h.name+"_bucket",
+			labels+sep+`le="`+formatFloat(bound)+`"`, float64(cum))
+	}
+	writeSample(b, h.name+"_bucket", labels+sep+`le="+Inf"`, float64(h.count))
+	writeSample(b, h.name+"_sum", labels, h.sum)
+	writeSample(b, h.name+"_count", labels, float64(h.count))
+}
+
+// HistogramVec is a set of histograms partitioned by label values.
+type HistogramVec struct {
+	name, help string
+	buckets    []float64
+	labels     []string
+	mux        sync.Mutex
+	hs         map[string]*Histogram
+}
+
+// NewHistogramVec creates a HistogramVec of the given label names.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		labels:  labels,
+		hs:      make(map[string]*Histogram),
+	}
+}
+
+func (v *HistogramVec) Name() string { return v.name }
+
+// With obtains the histogram of the given label values, which are matched to
+// the label names in order.
+func (v *HistogramVec) With(values ...string) *Histogram {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metric '%s' expects %d label values, got %d",
+			v.name, len(v.labels), len(values)))
+	}
+	key := formatLabels(v.labels, values)
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	h, ok := v.hs[key]
+	if !ok {
+		h = NewHistogram(v.name, v.help, v.buckets)
+		v.hs[key] = h
+	}
+	return h
+}
+
+func (v *HistogramVec) write(b *bytes.Buffer) {
+	v.mux.Lock()
+	keys := make([]string, 0, len(v.hs))
+	for key := range v.hs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hs := make([]*Histogram, len(keys))
+	for i, key := range keys {
+		hs[i] = v.hs[key]
+	}
+	v.mux.Unlock()
+
+	writeHeader(b, v.name, v.help, "histogram")
+	for i, h := range hs {
+		h.writeSamples(b, keys[i])
+	}
+}
+
+/*
+	<<< FORMAT >>>
+*/
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func writeHeader(b *bytes.Buffer, name, help, typ string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, helpEscaper.Replace(help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, typ)
+}
+
+func writeSample(b *bytes.Buffer, name, labels string, v float64) {
+	b.WriteString(name)
+	if labels != "" {
+		b.WriteString("{" + labels + "}")
+	}
+	b.WriteString(" " + formatFloat(v) + "\n")
+}
+
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + labelEscaper.Replace(values[i]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, +1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+
+	c := NewCounter("test_total", "A counter.")
+	g := NewGauge("test_gauge", "A gauge.")
+	h := NewHistogram("test_seconds", "A histogram.", []float64{1, 0.1})
+	v := NewHistogramVec("test_vec_seconds", "A vec.", []float64{1}, "path")
+	r.MustRegister(c, g, h, v)
+
+	require.Error(t, r.Register(NewCounter("test_total", "Duplicate.")))
+
+	c.Inc()
+	c.Add(1.5)
+	c.Add(-1)
+	g.Set(10)
+	g.Add(-3)
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+	v.With(`/a"b`).Observe(0.5)
+	v.With("/").Observe(2)
+
+	var b bytes.Buffer
+	_, e := r.WriteTo(&b)
+	require.NoError(t, e)
+	require.Equal(t, `# HELP test_gauge A gauge.
+# TYPE test_gauge gauge
+test_gauge 7
+# HELP test_seconds A histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 5.55
+test_seconds_count 3
+# HELP test_total A counter.
+# TYPE test_total counter
+test_total 2.5
+# HELP test_vec_seconds A vec.
+# TYPE test_vec_seconds histogram
+test_vec_seconds_bucket{path="/",le="1"} 0
+test_vec_seconds_bucket{path="/",le="+Inf"} 1
+test_vec_seconds_sum{path="/"} 2
+test_vec_seconds_count{path="/"} 1
+test_vec_seconds_bucket{path="/a\"b",le="1"} 1
+test_vec_seconds_bucket{path="/a\"b",le="+Inf"} 1
+test_vec_seconds_sum{path="/a\"b"} 0.5
+test_vec_seconds_count{path="/a\"b"} 1
+`, b.String())
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCounter("test_total", "A counter."))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	require.Contains(t, w.Body.String(), "test_total 0\n")
+}
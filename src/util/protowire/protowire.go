@@ -0,0 +1,116 @@
+// Package protowire implements the protobuf wire format, for encoding messages
+// without generated code.
+package protowire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTruncated occurs when a protobuf message ends unexpectedly.
+	ErrTruncated = errors.New("protobuf message is truncated")
+)
+
+// Wire types.
+const (
+	Varint  = 0
+	Fixed64 = 1
+	Bytes   = 2
+	Fixed32 = 5
+)
+
+// AppendUvarint appends 'v' encoded as a varint.
+func AppendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// AppendVarint appends a varint field.
+func AppendVarint(b []byte, field int, v uint64) []byte {
+	b = AppendUvarint(b, uint64(field)<<3|Varint)
+	return AppendUvarint(b, v)
+}
+
+// AppendBytes appends a length-delimited field.
+func AppendBytes(b []byte, field int, v []byte) []byte {
+	b = AppendUvarint(b, uint64(field)<<3|Bytes)
+	b = AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// CopyFixed copies a length-delimited field of fixed size into 'dst'.
+func CopyFixed(dst, raw []byte, name string) error {
+	if len(raw) != len(dst) {
+		return fmt.Errorf("protobuf field '%s' has %d bytes, expected %d",
+			name, len(raw), len(dst))
+	}
+	copy(dst, raw)
+	return nil
+}
+
+// Uvarints decodes a packed repeated varint field.
+func Uvarints(raw []byte) ([]uint64, error) {
+	var out []uint64
+	for len(raw) > 0 {
+		v, n := binary.Uvarint(raw)
+		if n <= 0 {
+			return nil, ErrTruncated
+		}
+		out = append(out, v)
+		raw = raw[n:]
+	}
+	return out, nil
+}
+
+// Range calls 'action' for every field of the message. For varint fields the
+// value is passed as 'v', for length-delimited fields the content is passed as
+// 'raw'. Fixed-size fields are skipped.
+func Range(b []byte, action func(field int, wire int, v uint64, raw []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrTruncated
+		}
+		b = b[n:]
+
+		var (
+			field = int(key >> 3)
+			wire  = int(key & 7)
+			v     uint64
+			raw   []byte
+		)
+		switch wire {
+		case Varint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return ErrTruncated
+			}
+			b = b[n:]
+		case Bytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return ErrTruncated
+			}
+			raw, b = b[n:n+int(l)], b[n+int(l):]
+		case Fixed64:
+			if len(b) < 8 {
+				return ErrTruncated
+			}
+			b = b[8:]
+			continue
+		case Fixed32:
+			if len(b) < 4 {
+				return ErrTruncated
+			}
+			b = b[4:]
+			continue
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wire)
+		}
+		if e := action(field, wire, v, raw); e != nil {
+			return e
+		}
+	}
+	return nil
+}
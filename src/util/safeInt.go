@@ -25,3 +25,10 @@ func (i *SafeInt) Inc() int {
 	i.v += 1
 	return i.v
 }
+
+func (i *SafeInt) Dec() int {
+	i.m.Lock()
+	defer i.m.Unlock()
+	i.v -= 1
+	return i.v
+}
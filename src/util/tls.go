@@ -0,0 +1,78 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// LoadCertPool reads the PEM encoded certificates of a file into a pool.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	pem, e := ioutil.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in '%s'", path)
+	}
+	return pool, nil
+}
+
+// ServerTLSConfig creates the tls config of a server of the certificate and
+// key files. If 'clientCAFile' is given, clients are required to present a
+// certificate signed by one of it's CAs (mutual TLS).
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, e := tls.LoadX509KeyPair(certFile, keyFile)
+	if e != nil {
+		return nil, e
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if clientCAFile != "" {
+		if config.ClientCAs, e = LoadCertPool(clientCAFile); e != nil {
+			return nil, e
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ClientTLSConfig creates the tls config of a client. Servers are verified
+// against the CAs of 'caFile' if given, or else the system CAs. A client
+// certificate is presented if 'certFile' and 'keyFile' are given (mutual TLS).
+func ClientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	var (
+		config = new(tls.Config)
+		e      error
+	)
+	if caFile != "" {
+		if config.RootCAs, e = LoadCertPool(caFile); e != nil {
+			return nil, e
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		cert, e := tls.LoadX509KeyPair(certFile, keyFile)
+		if e != nil {
+			return nil, e
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// MutualTLSConfig creates the tls config of a peer that both accepts and makes
+// connections, where both sides present a certificate signed by one of the CAs
+// of 'caFile'.
+func MutualTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if caFile == "" {
+		return nil, errors.New("mutual tls requires a ca file")
+	}
+	config, e := ServerTLSConfig(certFile, keyFile, caFile)
+	if e != nil {
+		return nil, e
+	}
+	config.RootCAs = config.ClientCAs
+	return config, nil
+}
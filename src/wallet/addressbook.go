@@ -0,0 +1,89 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+var (
+	ErrContactNameEmpty = errors.New("contact name cannot be empty")
+	ErrContactNotFound  = errors.New("contact of address is not found")
+)
+
+// Contact is an address book entry, naming an address.
+type Contact struct {
+	Address cipher.Address
+	Name    string
+	Notes   string
+}
+
+// FloatingContact represents a readable address book entry.
+type FloatingContact struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+	Notes   string `json:"notes,omitempty"`
+}
+
+func (c *Contact) ToFloating() *FloatingContact {
+	return &FloatingContact{
+		Address: c.Address.String(),
+		Name:    c.Name,
+		Notes:   c.Notes,
+	}
+}
+
+// AddressBook holds contacts in order of being added. It is saved with the
+// wallet file.
+type AddressBook []Contact
+
+// Get obtains the contact of an address.
+func (b AddressBook) Get(address cipher.Address) (Contact, bool) {
+	for _, c := range b {
+		if c.Address == address {
+			return c, true
+		}
+	}
+	return Contact{}, false
+}
+
+// Name obtains the name of an address, or an empty string if the address is
+// not in the address book.
+func (b AddressBook) Name(address cipher.Address) string {
+	c, _ := b.Get(address)
+	return c.Name
+}
+
+// Set adds a contact, or replaces the contact of the same address.
+func (b *AddressBook) Set(contact Contact) error {
+	if contact.Name == "" {
+		return ErrContactNameEmpty
+	}
+	for i, c := range *b {
+		if c.Address == contact.Address {
+			(*b)[i] = contact
+			return nil
+		}
+	}
+	*b = append(*b, contact)
+	return nil
+}
+
+// Remove removes the contact of an address.
+func (b *AddressBook) Remove(address cipher.Address) error {
+	for i, c := range *b {
+		if c.Address == address {
+			*b = append((*b)[:i], (*b)[i+1:]...)
+			return nil
+		}
+	}
+	return ErrContactNotFound
+}
+
+func (b AddressBook) ToFloating() []*FloatingContact {
+	out := make([]*FloatingContact, len(b))
+	for i := range b {
+		out[i] = b[i].ToFloating()
+	}
+	return out
+}
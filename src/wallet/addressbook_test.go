@@ -0,0 +1,89 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressBook(t *testing.T) {
+	var (
+		book AddressBook
+		a0   = newTestAddress()
+		a1   = newTestAddress()
+	)
+	require.Equal(t, ErrContactNameEmpty, book.Set(Contact{Address: a0}))
+	require.NoError(t, book.Set(Contact{Address: a0, Name: "alice"}))
+	require.NoError(t, book.Set(Contact{Address: a1, Name: "bob", Notes: "breeder"}))
+	require.NoError(t, book.Set(Contact{Address: a0, Name: "alice (cold)"}))
+
+	require.Len(t, book, 2)
+	require.Equal(t, "alice (cold)", book.Name(a0))
+	c, ok := book.Get(a1)
+	require.True(t, ok)
+	require.Equal(t, "breeder", c.Notes)
+
+	require.NoError(t, book.Remove(a0))
+	require.Equal(t, ErrContactNotFound, book.Remove(a0))
+	require.Equal(t, "", book.Name(a0))
+}
+
+func TestWallet_AddressBook(t *testing.T) {
+	rmTemp := initTempDir(t)
+	defer rmTemp()
+
+	w, err := NewFloatingWallet(&Options{
+		Label:     "book",
+		Seed:      "secure seed",
+		Encrypted: true,
+		Password:  "password",
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.EnsureEntries(1))
+
+	own := w.Entries[0].Address
+	friend := newTestAddress()
+	require.NoError(t, w.AddressBook.Set(Contact{Address: own, Name: "savings"}))
+	require.NoError(t, w.AddressBook.Set(Contact{Address: friend, Name: "friend"}))
+	require.NoError(t, w.Save())
+
+	loaded := loadWallet(t, "book", "password")
+	require.Equal(t, w.AddressBook, loaded.AddressBook)
+
+	fw := loaded.ToFloating()
+	require.Equal(t, "savings", fw.Entries[0].Name)
+	require.Len(t, fw.AddressBook, 2)
+	require.Equal(t, friend.String(), fw.AddressBook[1].Address)
+}
+
+func TestLoadFloatingWallet_Version0(t *testing.T) {
+	rmTemp := initTempDir(t)
+	defer rmTemp()
+
+	_, sk := cipher.GenerateKeyPair()
+	entry, err := NewEntry(sk)
+	require.NoError(t, err)
+
+	v0 := fileV0{
+		Meta:    Meta{AssetType: KittyAsset, Seed: "old seed"},
+		Entries: []Entry{*entry},
+	}
+	prefix := NewPrefix(0, EmptyNonce())
+	require.NoError(t, SaveBinary(LabelPath("old"),
+		append(prefix[:], encoder.Serialize(v0)...)))
+
+	w := loadWallet(t, "old", "")
+	require.Equal(t, uint64(0), w.Meta.Version)
+	require.Equal(t, v0.Entries, w.Entries)
+	require.Empty(t, w.AddressBook)
+
+	// Saving upgrades the file.
+	require.NoError(t, w.AddressBook.Set(Contact{Address: entry.Address, Name: "mine"}))
+	require.NoError(t, w.Save())
+
+	w = loadWallet(t, "old", "")
+	require.Equal(t, Version, w.Meta.Version)
+	require.Equal(t, "mine", w.AddressBook.Name(entry.Address))
+}
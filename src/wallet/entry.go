@@ -11,6 +11,7 @@ type FloatingEntry struct {
 	Address string `json:"address"`
 	PubKey  string `json:"public_key"`
 	SecKey  string `json:"secret_key"`
+	Name    string `json:"name,omitempty"` // From the address book.
 }
 
 // Entry represents a wallet entry.
@@ -0,0 +1,118 @@
+package hd
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// BIP32 test vector 1.
+var (
+	testSeed, _ = hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	testVectors = []struct {
+		path      string
+		secKey    string
+		chainCode string
+	}{
+		{
+			path:      "m",
+			secKey:    "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+			chainCode: "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508",
+		},
+		{
+			path:      "m/0H",
+			secKey:    "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+			chainCode: "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141",
+		},
+		{
+			path:      "m/0H/1",
+			secKey:    "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+			chainCode: "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19",
+		},
+		{
+			path:      "m/0H/1/2H",
+			secKey:    "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca",
+			chainCode: "04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f",
+		},
+		{
+			path:      "m/0H/1/2H/2",
+			secKey:    "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4",
+			chainCode: "cfb71883f01676f587d023cc53a35bc7f88f724b1f8c2892ac1275ac822a3edd",
+		},
+	}
+)
+
+func TestKey_Derive(t *testing.T) {
+	master, err := NewMasterKey(testSeed)
+	require.NoError(t, err)
+
+	for _, v := range testVectors {
+		t.Run(v.path, func(t *testing.T) {
+			path, err := ParsePath(v.path)
+			require.NoError(t, err)
+
+			key, err := master.Derive(path)
+			require.NoError(t, err)
+			require.Equal(t, v.secKey, key.SecKey().Hex())
+
+			chainCode := key.ChainCode()
+			require.Equal(t, v.chainCode, hex.EncodeToString(chainCode[:]))
+			require.Equal(t, uint8(len(path)), key.Depth())
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath("m/44'/8000'/0H/0/7")
+	require.NoError(t, err)
+	require.Equal(t, Path{44 + HardenedOffset, 8000 + HardenedOffset, HardenedOffset, 0, 7}, path)
+	require.Equal(t, "m/44'/8000'/0'/0/7", path.String())
+	require.Equal(t, append(AccountPath(0), ExternalChain, 7), path)
+
+	for _, s := range []string{"", "44/0", "m/", "m/a", "m/2147483648", "m/-1"} {
+		_, err := ParsePath(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestNewMasterKey_SeedSize(t *testing.T) {
+	_, err := NewMasterKey(make([]byte, MinSeedSize-1))
+	require.Equal(t, ErrInvalidSeedSize, err)
+	_, err = NewMasterKey(make([]byte, MaxSeedSize+1))
+	require.Equal(t, ErrInvalidSeedSize, err)
+}
+
+func TestWallet_NewAddress(t *testing.T) {
+	w, err := NewWallet(testSeed, 0)
+	require.NoError(t, err)
+
+	master, err := NewMasterKey(testSeed)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		address, err := w.NewAddress()
+		require.NoError(t, err)
+
+		key, err := master.Derive(append(AccountPath(0), ExternalChain, uint32(i)))
+		require.NoError(t, err)
+		require.Equal(t, key.Address(), address, "should derive m/44'/8000'/0'/0/%d", i)
+
+		entry, ok := w.Entry(address)
+		require.True(t, ok)
+		require.Equal(t, uint32(i), entry.Index)
+		require.Equal(t, key.SecKey(), entry.SecKey)
+	}
+
+	// Wallets of the same seed derive the same addresses.
+	w2, err := NewWallet(testSeed, 0)
+	require.NoError(t, err)
+	require.NoError(t, w2.EnsureAddresses(3))
+	require.Equal(t, w.Addresses(), w2.Addresses())
+
+	// Different accounts derive different addresses.
+	w3, err := NewWallet(testSeed, 1)
+	require.NoError(t, err)
+	require.NoError(t, w3.EnsureAddresses(3))
+	require.NotEqual(t, w.Addresses(), w3.Addresses())
+}
@@ -0,0 +1,188 @@
+// Package hd implements hierarchical deterministic key derivation as of BIP32,
+// with wallets following the BIP44 path layout.
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// HardenedOffset is added to indexes of hardened children.
+	HardenedOffset uint32 = 0x80000000
+
+	// MinSeedSize and MaxSeedSize are the seed size limits of BIP32.
+	MinSeedSize = 16
+	MaxSeedSize = 64
+
+	masterKeySalt = "Bitcoin seed"
+)
+
+var (
+	// ErrInvalidKey occurs when a derived key is not a valid secp256k1 secret
+	// key. This is extremely unlikely; BIP32 specifies to proceed with the next
+	// index when it happens.
+	ErrInvalidKey = errors.New("derived key is invalid")
+
+	ErrInvalidSeedSize = fmt.Errorf("seed should be between %d and %d bytes",
+		MinSeedSize, MaxSeedSize)
+)
+
+// curveOrder is the order of the secp256k1 curve.
+var curveOrder, _ = new(big.Int).SetString(
+	"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+
+// Key is an extended secret key.
+type Key struct {
+	sk        cipher.SecKey
+	chainCode [32]byte
+	depth     uint8
+	index     uint32
+}
+
+// NewMasterKey derives the master key from a seed.
+func NewMasterKey(seed []byte) (*Key, error) {
+	if len(seed) < MinSeedSize || len(seed) > MaxSeedSize {
+		return nil, ErrInvalidSeedSize
+	}
+	mac := hmac.New(sha512.New, []byte(masterKeySalt))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	k := new(Key)
+	if e := setSecKey(&k.sk, new(big.Int).SetBytes(sum[:32])); e != nil {
+		return nil, e
+	}
+	copy(k.chainCode[:], sum[32:])
+	return k, nil
+}
+
+// Child derives the child key of index. Indexes of 'HardenedOffset' and above
+// derive hardened keys.
+func (k *Key) Child(index uint32) (*Key, error) {
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0}, k.sk[:]...)
+	} else {
+		pk := cipher.PubKeyFromSecKey(k.sk)
+		data = append(data, pk[:]...)
+	}
+	var indexBytes [4]byte
+	binary.BigEndian.PutUint32(indexBytes[:], index)
+	data = append(data, indexBytes[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode[:])
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(curveOrder) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	il.Add(il, new(big.Int).SetBytes(k.sk[:]))
+	il.Mod(il, curveOrder)
+
+	child := &Key{
+		depth: k.depth + 1,
+		index: index,
+	}
+	if e := setSecKey(&child.sk, il); e != nil {
+		return nil, e
+	}
+	copy(child.chainCode[:], sum[32:])
+	return child, nil
+}
+
+// Derive derives the descendant key of the path, relative to this key.
+func (k *Key) Derive(path Path) (*Key, error) {
+	var e error
+	for _, index := range path {
+		if k, e = k.Child(index); e != nil {
+			return nil, e
+		}
+	}
+	return k, nil
+}
+
+func (k *Key) SecKey() cipher.SecKey {
+	return k.sk
+}
+
+func (k *Key) PubKey() cipher.PubKey {
+	return cipher.PubKeyFromSecKey(k.sk)
+}
+
+func (k *Key) Address() cipher.Address {
+	return cipher.AddressFromSecKey(k.sk)
+}
+
+func (k *Key) ChainCode() [32]byte {
+	return k.chainCode
+}
+
+func (k *Key) Depth() uint8 {
+	return k.depth
+}
+
+// Index returns the index of the key within it's parent.
+func (k *Key) Index() uint32 {
+	return k.index
+}
+
+func setSecKey(sk *cipher.SecKey, v *big.Int) error {
+	if v.Sign() == 0 || v.Cmp(curveOrder) >= 0 {
+		return ErrInvalidKey
+	}
+	b := v.Bytes()
+	copy(sk[len(sk)-len(b):], b)
+	return nil
+}
+
+/*
+	<<< PATH >>>
+*/
+
+// Path is a derivation path of child indexes.
+type Path []uint32
+
+// ParsePath parses a path of form "m/44'/8000'/0'/0/0". Hardened indexes are
+// suffixed with either "'" or "H".
+func ParsePath(s string) (Path, error) {
+	parts := strings.Split(s, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("path '%s' should start with 'm'", s)
+	}
+	path := make(Path, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "H") {
+			part, offset = part[:len(part)-1], HardenedOffset
+		}
+		index, e := strconv.ParseUint(part, 10, 32)
+		if e != nil || uint32(index) >= HardenedOffset {
+			return nil, fmt.Errorf("invalid index '%s' in path '%s'", part, s)
+		}
+		path = append(path, uint32(index)+offset)
+	}
+	return path, nil
+}
+
+func (p Path) String() string {
+	out := "m"
+	for _, index := range p {
+		if index >= HardenedOffset {
+			out += fmt.Sprintf("/%d'", index-HardenedOffset)
+		} else {
+			out += fmt.Sprintf("/%d", index)
+		}
+	}
+	return out
+}
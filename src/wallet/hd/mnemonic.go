@@ -0,0 +1,90 @@
+package hd
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/go-bip39"
+)
+
+var (
+	ErrMnemonicWordCount = errors.New("mnemonic should have 12, 15, 18, 21 or 24 words")
+	ErrMnemonicChecksum  = errors.New("mnemonic checksum is invalid")
+)
+
+// NewMnemonic generates a BIP39 mnemonic of 'words' words, which should be
+// either 12, 15, 18, 21 or 24.
+func NewMnemonic(words int) (string, error) {
+	if words%3 != 0 || words < 12 || words > 24 {
+		return "", ErrMnemonicWordCount
+	}
+	return NewMnemonicFromEntropy(cipher.RandByte(words / 3 * 4))
+}
+
+// NewMnemonicFromEntropy encodes entropy of 16 to 32 bytes (in multiples of 4)
+// as a BIP39 mnemonic.
+func NewMnemonicFromEntropy(entropy []byte) (string, error) {
+	return bip39.NewMnemonic(entropy)
+}
+
+// ValidateMnemonic checks the word count, words and checksum of a mnemonic.
+func ValidateMnemonic(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return ErrMnemonicWordCount
+	}
+	// Each word encodes 11 bits; the last 'len(words)/3' bits are the checksum
+	// of the entropy.
+	var (
+		bits     = make([]bool, 0, len(words)*11)
+		csLen    = len(words) / 3
+		entropy  = make([]byte, len(words)/3*4)
+		checksum []bool
+	)
+	for _, word := range words {
+		index, ok := bip39.ReverseWordMap[word]
+		if !ok {
+			return fmt.Errorf("mnemonic word '%s' is not in the word list", word)
+		}
+		for i := 10; i >= 0; i-- {
+			bits = append(bits, index&(1<<uint(i)) != 0)
+		}
+	}
+	bits, checksum = bits[:len(bits)-csLen], bits[len(bits)-csLen:]
+	for i, bit := range bits {
+		if bit {
+			entropy[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	hash := sha256.Sum256(entropy)
+	for i, bit := range checksum {
+		if bit != (hash[i/8]&(1<<uint(7-i%8)) != 0) {
+			return ErrMnemonicChecksum
+		}
+	}
+	return nil
+}
+
+// MnemonicToSeed validates the mnemonic and derives the 64 byte BIP39 seed,
+// protected by an optional passphrase. Non-ASCII mnemonics and passphrases
+// should be NFKD normalized by the caller.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if e := ValidateMnemonic(mnemonic); e != nil {
+		return nil, e
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// NewWalletFromMnemonic restores the wallet of the account from a mnemonic
+// and passphrase.
+func NewWalletFromMnemonic(mnemonic, passphrase string, account uint32) (*Wallet, error) {
+	seed, e := MnemonicToSeed(mnemonic, passphrase)
+	if e != nil {
+		return nil, e
+	}
+	return NewWallet(seed, account)
+}
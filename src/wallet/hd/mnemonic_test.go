@@ -0,0 +1,86 @@
+package hd
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// BIP39 test vectors, with the passphrase "TREZOR".
+var mnemonicVectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		entropy:  "00000000000000000000000000000000",
+		mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		entropy:  "0000000000000000000000000000000000000000000000000000000000000000",
+		mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		seed:     "bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+	},
+	{
+		entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+		seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+	},
+}
+
+func TestMnemonicVectors(t *testing.T) {
+	for _, v := range mnemonicVectors {
+		entropy, err := hex.DecodeString(v.entropy)
+		require.NoError(t, err)
+
+		mnemonic, err := NewMnemonicFromEntropy(entropy)
+		require.NoError(t, err)
+		require.Equal(t, v.mnemonic, mnemonic)
+
+		seed, err := MnemonicToSeed(mnemonic, "TREZOR")
+		require.NoError(t, err)
+		require.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, words := range []int{12, 24} {
+		mnemonic, err := NewMnemonic(words)
+		require.NoError(t, err)
+		require.Len(t, strings.Fields(mnemonic), words)
+		require.NoError(t, ValidateMnemonic(mnemonic))
+	}
+	_, err := NewMnemonic(13)
+	require.Equal(t, ErrMnemonicWordCount, err)
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	require.Equal(t, ErrMnemonicWordCount, ValidateMnemonic("abandon about"))
+	require.Equal(t, ErrMnemonicChecksum, ValidateMnemonic(
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"))
+	require.Error(t, ValidateMnemonic(
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon kitty"))
+}
+
+func TestNewWalletFromMnemonic(t *testing.T) {
+	mnemonic, err := NewMnemonic(12)
+	require.NoError(t, err)
+
+	restore := func(passphrase string) string {
+		w, err := NewWalletFromMnemonic(mnemonic, passphrase, 0)
+		require.NoError(t, err)
+		address, err := w.NewAddress()
+		require.NoError(t, err)
+		return address.String()
+	}
+	require.Equal(t, restore("pass"), restore("pass"), "restoring should derive the same addresses")
+	require.NotEqual(t, restore("pass"), restore(""), "passphrase should change derived addresses")
+}
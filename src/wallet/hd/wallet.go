@@ -0,0 +1,152 @@
+package hd
+
+import (
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// Purpose is the BIP44 purpose index.
+	Purpose = 44
+
+	// CoinType is the SLIP44 coin type used for derivation. Kitty addresses
+	// are Skycoin addresses, hence Skycoin's coin type is used.
+	CoinType = 8000
+
+	// ExternalChain is the BIP44 chain of receiving addresses.
+	ExternalChain = 0
+)
+
+// AccountPath returns the BIP44 path of an account: m/44'/8000'/account'.
+func AccountPath(account uint32) Path {
+	return Path{
+		Purpose + HardenedOffset,
+		CoinType + HardenedOffset,
+		account + HardenedOffset,
+	}
+}
+
+// Wallet derives receiving addresses of a single BIP44 account
+// (m/44'/8000'/account'/0/index) from a master seed.
+type Wallet struct {
+	mux     sync.RWMutex
+	account uint32
+	chain   *Key
+	next    uint32
+	entries []Entry
+	index   map[cipher.Address]int
+}
+
+// Entry is a derived key.
+type Entry struct {
+	Index   uint32
+	Address cipher.Address
+	PubKey  cipher.PubKey
+	SecKey  cipher.SecKey
+}
+
+// NewWallet creates a wallet of the account, with no addresses derived yet.
+func NewWallet(seed []byte, account uint32) (*Wallet, error) {
+	master, e := NewMasterKey(seed)
+	if e != nil {
+		return nil, e
+	}
+	chain, e := master.Derive(append(AccountPath(account), ExternalChain))
+	if e != nil {
+		return nil, e
+	}
+	return &Wallet{
+		account: account,
+		chain:   chain,
+		index:   make(map[cipher.Address]int),
+	}, nil
+}
+
+func (w *Wallet) Account() uint32 {
+	return w.account
+}
+
+// NewAddress derives the next receiving address.
+func (w *Wallet) NewAddress() (cipher.Address, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	entry, e := w.deriveNext()
+	if e != nil {
+		return cipher.Address{}, e
+	}
+	return entry.Address, nil
+}
+
+// EnsureAddresses derives addresses until there are at least 'n'.
+func (w *Wallet) EnsureAddresses(n int) error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	for len(w.entries) < n {
+		if _, e := w.deriveNext(); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (w *Wallet) deriveNext() (*Entry, error) {
+	for {
+		if w.next >= HardenedOffset {
+			return nil, ErrInvalidKey
+		}
+		index := w.next
+		w.next++
+
+		key, e := w.chain.Child(index)
+		if e == ErrInvalidKey {
+			continue // Skip as of BIP32.
+		}
+		if e != nil {
+			return nil, e
+		}
+		w.entries = append(w.entries, Entry{
+			Index:   index,
+			Address: key.Address(),
+			PubKey:  key.PubKey(),
+			SecKey:  key.SecKey(),
+		})
+		entry := &w.entries[len(w.entries)-1]
+		w.index[entry.Address] = len(w.entries) - 1
+		return entry, nil
+	}
+}
+
+// Addresses returns the derived addresses, in order of derivation.
+func (w *Wallet) Addresses() []cipher.Address {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	out := make([]cipher.Address, len(w.entries))
+	for i, entry := range w.entries {
+		out[i] = entry.Address
+	}
+	return out
+}
+
+// Entries returns a copy of the derived entries.
+func (w *Wallet) Entries() []Entry {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	return append([]Entry(nil), w.entries...)
+}
+
+// Entry obtains the derived entry of the address.
+func (w *Wallet) Entry(address cipher.Address) (Entry, bool) {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	i, ok := w.index[address]
+	if !ok {
+		return Entry{}, false
+	}
+	return w.entries[i], true
+}
@@ -0,0 +1,424 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	skycipher "github.com/skycoin/skycoin/src/cipher"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KeyStoreVersion is the version of the key store file format.
+	KeyStoreVersion = 1
+
+	keyStoreKeySize   = 32 // AES-256.
+	keyStoreSaltSize  = 32
+	keyStoreNonceSize = 12
+)
+
+var (
+	ErrKeyStoreLocked    = errors.New("key store is locked")
+	ErrInvalidPassword   = errors.New("invalid password")
+	ErrAddressNotInStore = errors.New("address is not in key store")
+)
+
+// ScryptParams are the parameters of the scrypt key derivation function.
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// DefaultScryptParams are the recommended interactive login parameters.
+var DefaultScryptParams = ScryptParams{N: 1 << 15, R: 8, P: 1}
+
+// KeyStoreEntry is the public part of a key store entry.
+type KeyStoreEntry struct {
+	Address skycipher.Address
+	Label   string
+}
+
+// keyStoreFile is the on-disk format of the key store. Addresses and labels
+// are readable while locked, but are authenticated as additional data of the
+// encrypted secret keys.
+type keyStoreFile struct {
+	Version    int                 `json:"version"`
+	KDF        ScryptParams        `json:"scrypt"`
+	Salt       string              `json:"salt"`
+	Nonce      string              `json:"nonce"`
+	Entries    []keyStoreFileEntry `json:"entries"`
+	Ciphertext string              `json:"ciphertext"`
+}
+
+type keyStoreFileEntry struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+}
+
+// KeyStore is a set of secret keys and address labels, stored in a file
+// encrypted with AES-256-GCM under a key derived from a password by scrypt.
+// Secret keys are only available while unlocked.
+type KeyStore struct {
+	mux     sync.Mutex
+	path    string
+	params  ScryptParams
+	salt    []byte
+	entries []KeyStoreEntry
+
+	// Only set while unlocked.
+	key     []byte
+	secKeys map[skycipher.Address]skycipher.SecKey
+
+	lockTimeout time.Duration
+	lockTimer   *time.Timer
+	lockGen     uint64 // incremented as the lock timer is stopped
+	lockAt      time.Time
+}
+
+// NewKeyStore creates an empty, unlocked key store to be saved at 'path'.
+// Nil params results in 'DefaultScryptParams'.
+func NewKeyStore(path, password string, params *ScryptParams) (*KeyStore, error) {
+	if password == "" {
+		return nil, errors.New("password is required")
+	}
+	if params == nil {
+		params = &DefaultScryptParams
+	}
+	ks := &KeyStore{
+		path:    path,
+		params:  *params,
+		salt:    skycipher.RandByte(keyStoreSaltSize),
+		secKeys: make(map[skycipher.Address]skycipher.SecKey),
+	}
+	var e error
+	if ks.key, e = ks.deriveKey(password); e != nil {
+		return nil, e
+	}
+	return ks, nil
+}
+
+// LoadKeyStore loads a locked key store from file.
+func LoadKeyStore(path string) (*KeyStore, error) {
+	f, e := readKeyStoreFile(path)
+	if e != nil {
+		return nil, e
+	}
+	ks := &KeyStore{
+		path:    path,
+		params:  f.KDF,
+		entries: make([]KeyStoreEntry, len(f.Entries)),
+	}
+	if ks.salt, e = hex.DecodeString(f.Salt); e != nil {
+		return nil, e
+	}
+	for i, fe := range f.Entries {
+		address, e := skycipher.DecodeBase58Address(fe.Address)
+		if e != nil {
+			return nil, e
+		}
+		ks.entries[i] = KeyStoreEntry{Address: address, Label: fe.Label}
+	}
+	return ks, nil
+}
+
+func readKeyStoreFile(path string) (*keyStoreFile, error) {
+	raw, e := ioutil.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	f := new(keyStoreFile)
+	if e := json.Unmarshal(raw, f); e != nil {
+		return nil, e
+	}
+	if f.Version != KeyStoreVersion {
+		return nil, fmt.Errorf("unsupported key store version %d", f.Version)
+	}
+	return f, nil
+}
+
+func (ks *KeyStore) deriveKey(password string) ([]byte, error) {
+	return scrypt.Key([]byte(password), ks.salt,
+		ks.params.N, ks.params.R, ks.params.P, keyStoreKeySize)
+}
+
+// Unlock decrypts the secret keys. If 'timeout' is positive, the key store
+// locks itself after not being used for that duration.
+func (ks *KeyStore) Unlock(password string, timeout time.Duration) error {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	f, e := readKeyStoreFile(ks.path)
+	if e != nil {
+		return e
+	}
+	key, e := ks.deriveKey(password)
+	if e != nil {
+		return e
+	}
+	nonce, e := hex.DecodeString(f.Nonce)
+	if e != nil {
+		return e
+	}
+	ciphertext, e := hex.DecodeString(f.Ciphertext)
+	if e != nil {
+		return e
+	}
+	aead, e := newKeyStoreAEAD(key)
+	if e != nil {
+		return e
+	}
+	plain, e := aead.Open(nil, nonce, ciphertext, keyStoreAdditionalData(f.Entries))
+	if e != nil {
+		return ErrInvalidPassword
+	}
+	defer wipe(plain)
+
+	var skHexes []string
+	if e := json.Unmarshal(plain, &skHexes); e != nil {
+		return e
+	}
+	if len(skHexes) != len(f.Entries) {
+		return errors.New("key store entry count mismatch")
+	}
+	secKeys := make(map[skycipher.Address]skycipher.SecKey, len(skHexes))
+	for i, skHex := range skHexes {
+		sk, e := skycipher.SecKeyFromHex(skHex)
+		if e != nil {
+			return e
+		}
+		if skycipher.AddressFromSecKey(sk).String() != f.Entries[i].Address {
+			return fmt.Errorf("secret key of entry %d does not match it's address", i)
+		}
+		secKeys[skycipher.AddressFromSecKey(sk)] = sk
+	}
+
+	ks.lock()
+	ks.key, ks.secKeys = key, secKeys
+	ks.lockTimeout = timeout
+	if timeout > 0 {
+		ks.lockAt = time.Now().Add(timeout)
+		gen := ks.lockGen
+		ks.lockTimer = time.AfterFunc(timeout, func() { ks.autoLock(gen) })
+	}
+	return nil
+}
+
+// autoLock locks the key store once it has been left unused for the timeout,
+// if the timer of the generation 'gen' is still armed. The timer is re-armed
+// when the key store was used in the meantime.
+func (ks *KeyStore) autoLock(gen uint64) {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	if ks.lockTimer == nil || ks.lockGen != gen {
+		return // Locked or unlocked again since.
+	}
+	if wait := time.Until(ks.lockAt); wait > 0 {
+		ks.lockTimer.Reset(wait)
+		return
+	}
+	ks.lock()
+}
+
+// Lock wipes the secret keys from memory.
+func (ks *KeyStore) Lock() {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	ks.lock()
+}
+
+func (ks *KeyStore) lock() {
+	if ks.lockTimer != nil {
+		ks.lockTimer.Stop()
+		ks.lockTimer = nil
+		ks.lockGen++
+	}
+	for address, sk := range ks.secKeys {
+		wipe(sk[:])
+		delete(ks.secKeys, address)
+	}
+	ks.secKeys = nil
+	wipe(ks.key)
+	ks.key = nil
+}
+
+// IsLocked returns whether the secret keys are unavailable.
+func (ks *KeyStore) IsLocked() bool {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	return ks.key == nil
+}
+
+// touch checks that the key store is unlocked, and postpones the auto-lock.
+func (ks *KeyStore) touch() error {
+	if ks.key == nil {
+		return ErrKeyStoreLocked
+	}
+	if ks.lockTimer != nil {
+		ks.lockAt = time.Now().Add(ks.lockTimeout)
+	}
+	return nil
+}
+
+// Save encrypts and writes the key store to file. It must be unlocked.
+func (ks *KeyStore) Save() error {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	if e := ks.touch(); e != nil {
+		return e
+	}
+	f := &keyStoreFile{
+		Version: KeyStoreVersion,
+		KDF:     ks.params,
+		Salt:    hex.EncodeToString(ks.salt),
+		Entries: make([]keyStoreFileEntry, len(ks.entries)),
+	}
+	skHexes := make([]string, len(ks.entries))
+	for i, entry := range ks.entries {
+		f.Entries[i] = keyStoreFileEntry{
+			Address: entry.Address.String(),
+			Label:   entry.Label,
+		}
+		sk := ks.secKeys[entry.Address]
+		skHexes[i] = sk.Hex()
+	}
+	plain, e := json.Marshal(skHexes)
+	if e != nil {
+		return e
+	}
+	defer wipe(plain)
+
+	aead, e := newKeyStoreAEAD(ks.key)
+	if e != nil {
+		return e
+	}
+	nonce := skycipher.RandByte(keyStoreNonceSize)
+	f.Nonce = hex.EncodeToString(nonce)
+	f.Ciphertext = hex.EncodeToString(
+		aead.Seal(nil, nonce, plain, keyStoreAdditionalData(f.Entries)))
+
+	raw, e := json.MarshalIndent(f, "", "    ")
+	if e != nil {
+		return e
+	}
+	// Write to a temporary file first so that an existing key store is never
+	// left half written.
+	tmpPath := ks.path + ".tmp"
+	if e := os.MkdirAll(filepath.Dir(ks.path), os.FileMode(0700)); e != nil {
+		return e
+	}
+	if e := ioutil.WriteFile(tmpPath, raw, os.FileMode(0600)); e != nil {
+		return e
+	}
+	return os.Rename(tmpPath, ks.path)
+}
+
+// Add adds a secret key with a label. It must be unlocked.
+func (ks *KeyStore) Add(sk skycipher.SecKey, label string) (skycipher.Address, error) {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	if e := ks.touch(); e != nil {
+		return skycipher.Address{}, e
+	}
+	if e := sk.Verify(); e != nil {
+		return skycipher.Address{}, e
+	}
+	address := skycipher.AddressFromSecKey(sk)
+	if _, ok := ks.secKeys[address]; ok {
+		return address, fmt.Errorf("address '%s' is already in key store", address)
+	}
+	ks.entries = append(ks.entries, KeyStoreEntry{Address: address, Label: label})
+	ks.secKeys[address] = sk
+	return address, nil
+}
+
+// SetLabel changes the label of an address. It must be unlocked, as the labels
+// are authenticated with the key.
+func (ks *KeyStore) SetLabel(address skycipher.Address, label string) error {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	if e := ks.touch(); e != nil {
+		return e
+	}
+	for i := range ks.entries {
+		if ks.entries[i].Address == address {
+			ks.entries[i].Label = label
+			return nil
+		}
+	}
+	return ErrAddressNotInStore
+}
+
+// Entries returns the addresses and labels, which are available while locked.
+func (ks *KeyStore) Entries() []KeyStoreEntry {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	return append([]KeyStoreEntry(nil), ks.entries...)
+}
+
+// Addresses returns the addresses of the keys, which are available while
+// locked.
+func (ks *KeyStore) Addresses() []skycipher.Address {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	out := make([]skycipher.Address, len(ks.entries))
+	for i, entry := range ks.entries {
+		out[i] = entry.Address
+	}
+	return out
+}
+
+// SecKey obtains the secret key of an address. It must be unlocked.
+func (ks *KeyStore) SecKey(address skycipher.Address) (skycipher.SecKey, error) {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+
+	if e := ks.touch(); e != nil {
+		return skycipher.SecKey{}, e
+	}
+	sk, ok := ks.secKeys[address]
+	if !ok {
+		return skycipher.SecKey{}, ErrAddressNotInStore
+	}
+	return sk, nil
+}
+
+/*
+	<<< HELPER FUNCTIONS >>>
+*/
+
+func newKeyStoreAEAD(key []byte) (cipher.AEAD, error) {
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, e
+	}
+	return cipher.NewGCM(block)
+}
+
+func keyStoreAdditionalData(entries []keyStoreFileEntry) []byte {
+	data, _ := json.Marshal(entries)
+	return data
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+// Cheap parameters to keep tests fast.
+var testScryptParams = &ScryptParams{N: 1 << 4, R: 8, P: 1}
+
+func newTestKeyStore(t *testing.T, password string) (*KeyStore, string, func()) {
+	dir, e := ioutil.TempDir("", "kittycash_test")
+	require.NoError(t, e)
+
+	path := filepath.Join(dir, "keys.json")
+	ks, e := NewKeyStore(path, password, testScryptParams)
+	require.NoError(t, e)
+
+	return ks, path, func() { os.RemoveAll(dir) }
+}
+
+func TestKeyStore_SaveLoad(t *testing.T) {
+	ks, path, rm := newTestKeyStore(t, "password")
+	defer rm()
+
+	_, sk0 := cipher.GenerateKeyPair()
+	_, sk1 := cipher.GenerateKeyPair()
+	addr0, err := ks.Add(sk0, "cold")
+	require.NoError(t, err)
+	addr1, err := ks.Add(sk1, "hot")
+	require.NoError(t, err)
+	require.NoError(t, ks.SetLabel(addr1, "spending"))
+
+	_, err = ks.Add(sk0, "again")
+	require.Error(t, err)
+
+	require.NoError(t, ks.Save())
+
+	// Secret keys should never be written in plaintext.
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(raw), sk0.Hex()))
+	require.False(t, strings.Contains(string(raw), sk1.Hex()))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadKeyStore(path)
+	require.NoError(t, err)
+	require.True(t, loaded.IsLocked())
+	require.Equal(t, []KeyStoreEntry{
+		{Address: addr0, Label: "cold"},
+		{Address: addr1, Label: "spending"},
+	}, loaded.Entries())
+
+	_, err = loaded.SecKey(addr0)
+	require.Equal(t, ErrKeyStoreLocked, err)
+	require.Equal(t, ErrKeyStoreLocked, loaded.Save())
+
+	require.Equal(t, ErrInvalidPassword, loaded.Unlock("wrong", 0))
+	require.True(t, loaded.IsLocked())
+
+	require.NoError(t, loaded.Unlock("password", 0))
+	require.False(t, loaded.IsLocked())
+
+	got, err := loaded.SecKey(addr0)
+	require.NoError(t, err)
+	require.Equal(t, sk0, got)
+	got, err = loaded.SecKey(addr1)
+	require.NoError(t, err)
+	require.Equal(t, sk1, got)
+
+	loaded.Lock()
+	require.True(t, loaded.IsLocked())
+	_, err = loaded.SecKey(addr0)
+	require.Equal(t, ErrKeyStoreLocked, err)
+}
+
+func TestKeyStore_TamperedLabels(t *testing.T) {
+	ks, path, rm := newTestKeyStore(t, "password")
+	defer rm()
+
+	_, sk := cipher.GenerateKeyPair()
+	_, err := ks.Add(sk, "label")
+	require.NoError(t, err)
+	require.NoError(t, ks.Save())
+
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	raw = []byte(strings.Replace(string(raw), `"label"`, `"forged"`, 1))
+	require.NoError(t, ioutil.WriteFile(path, raw, 0600))
+
+	loaded, err := LoadKeyStore(path)
+	require.NoError(t, err)
+	require.Equal(t, ErrInvalidPassword, loaded.Unlock("password", 0))
+}
+
+func TestKeyStore_AutoLock(t *testing.T) {
+	ks, path, rm := newTestKeyStore(t, "password")
+	defer rm()
+
+	_, sk := cipher.GenerateKeyPair()
+	addr, err := ks.Add(sk, "")
+	require.NoError(t, err)
+	require.NoError(t, ks.Save())
+
+	loaded, err := LoadKeyStore(path)
+	require.NoError(t, err)
+
+	const timeout = 100 * time.Millisecond
+	require.NoError(t, loaded.Unlock("password", timeout))
+
+	// Using the key store postpones locking.
+	for i := 0; i < 4; i++ {
+		time.Sleep(timeout / 2)
+		_, err := loaded.SecKey(addr)
+		require.NoError(t, err)
+	}
+
+	time.Sleep(timeout * 3)
+	require.True(t, loaded.IsLocked())
+
+	// The timer may fire before Unlock returns.
+	require.NoError(t, loaded.Unlock("password", time.Nanosecond))
+	time.Sleep(timeout)
+	require.True(t, loaded.IsLocked(), "key store of a short timeout should be locked")
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"sort"
 	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
 )
 
 var (
@@ -38,9 +40,9 @@ func (m *Manager) Refresh() error {
 	m.labels = make([]string, 0)
 	m.wallets = make(map[string]*Wallet)
 	e := RangeLabels(func(f io.Reader, label, fPath string, prefix Prefix) {
-		if prefix.Version() != Version {
+		if prefix.Version() > Version {
 			log.Warningf(
-				"wallet file `%s` is of version %v, while only version %v is supported",
+				"wallet file `%s` is of version %v, while only up to version %v is supported",
 				label, prefix.Version(), Version)
 			return
 		}
@@ -140,34 +142,46 @@ func (m *Manager) DeleteWallet(label string) error {
 func (m *Manager) DisplayWallet(label, password string, addresses int) (*FloatingWallet, error) {
 	defer m.lock()()
 
-	switch w, e := m.getWallet(label); e {
-	case nil:
-		if e := w.EnsureEntries(addresses); e != nil {
-			return nil, e
-		}
-		return w.ToFloating(), nil
+	w, e := m.unlockWallet(label, password)
+	if e != nil {
+		return nil, e
+	}
+	if e := w.EnsureEntries(addresses); e != nil {
+		return nil, e
+	}
+	return w.ToFloating(), nil
+}
 
-	case ErrWalletNotFound:
-		return nil, ErrWalletNotFound
+// SetContact adds or replaces a contact in the address book of the wallet of
+// specified label, and saves the wallet.
+// Password needs to be given if a wallet is still locked.
+func (m *Manager) SetContact(label, password string, contact Contact) error {
+	defer m.lock()()
 
-	case ErrWalletLocked:
-		f, e := os.Open(LabelPath(label))
-		if e != nil {
-			return nil, e
-		}
-		defer f.Close()
-		if w, e = LoadFloatingWallet(f, label, password); e != nil {
-			return nil, e
-		}
-		m.wallets[label] = w
-		if e := w.EnsureEntries(addresses); e != nil {
-			return nil, e
-		}
-		return w.ToFloating(), nil
+	w, e := m.unlockWallet(label, password)
+	if e != nil {
+		return e
+	}
+	if e := w.AddressBook.Set(contact); e != nil {
+		return e
+	}
+	return w.Save()
+}
 
-	default:
-		return nil, errors.New("unknown error")
+// RemoveContact removes a contact from the address book of the wallet of
+// specified label, and saves the wallet.
+// Password needs to be given if a wallet is still locked.
+func (m *Manager) RemoveContact(label, password string, address cipher.Address) error {
+	defer m.lock()()
+
+	w, e := m.unlockWallet(label, password)
+	if e != nil {
+		return e
 	}
+	if e := w.AddressBook.Remove(address); e != nil {
+		return e
+	}
+	return w.Save()
 }
 
 /*
@@ -200,6 +214,33 @@ func (m *Manager) sort() error {
 	return nil
 }
 
+// unlockWallet obtains the wallet of label, loading it with the password if
+// it is still locked.
+func (m *Manager) unlockWallet(label, password string) (*Wallet, error) {
+	switch w, e := m.getWallet(label); e {
+	case nil:
+		return w, nil
+
+	case ErrWalletNotFound:
+		return nil, ErrWalletNotFound
+
+	case ErrWalletLocked:
+		f, e := os.Open(LabelPath(label))
+		if e != nil {
+			return nil, e
+		}
+		defer f.Close()
+		if w, e = LoadFloatingWallet(f, label, password); e != nil {
+			return nil, e
+		}
+		m.wallets[label] = w
+		return w, nil
+
+	default:
+		return nil, errors.New("unknown error")
+	}
+}
+
 func (m *Manager) getWallet(label string) (*Wallet, error) {
 	w, ok := m.wallets[label]
 	if !ok {
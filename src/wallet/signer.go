@@ -0,0 +1,93 @@
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+// CommandSigner signs with an external device, such as a hardware wallet,
+// through a helper program that talks to the device. The program is invoked
+// with the following trailing arguments, and prints the result to stdout:
+//
+//	address           -> the base58 address of the device's key.
+//	sign <hash_hex>   -> the hex encoded signature of the hash.
+//
+// The device is expected to ask the user for confirmation before signing.
+type CommandSigner struct {
+	program string
+	args    []string
+	address cipher.Address
+}
+
+// NewCommandSigner creates a signer of the helper program, obtaining the
+// address from the device.
+func NewCommandSigner(program string, args ...string) (*CommandSigner, error) {
+	s := &CommandSigner{
+		program: program,
+		args:    args,
+	}
+	out, e := s.run("address")
+	if e != nil {
+		return nil, e
+	}
+	if s.address, e = cipher.DecodeBase58Address(out); e != nil {
+		return nil, fmt.Errorf("signer program returned invalid address: %v", e)
+	}
+	return s, nil
+}
+
+func (s *CommandSigner) Address() cipher.Address {
+	return s.address
+}
+
+func (s *CommandSigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	out, e := s.run("sign", hash.Hex())
+	if e != nil {
+		return cipher.Sig{}, e
+	}
+	sig, e := cipher.SigFromHex(out)
+	if e != nil {
+		return cipher.Sig{}, fmt.Errorf("signer program returned invalid signature: %v", e)
+	}
+	return sig, nil
+}
+
+func (s *CommandSigner) run(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.program, append(append([]string(nil), s.args...), args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if e := cmd.Run(); e != nil {
+		return "", fmt.Errorf("signer program failed: %v: %s",
+			e, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Signer obtains a signer of the address's secret key. The key store needs to
+// be unlocked at the time of signing.
+func (ks *KeyStore) Signer(address cipher.Address) iko.Signer {
+	return &keyStoreSigner{ks: ks, address: address}
+}
+
+type keyStoreSigner struct {
+	ks      *KeyStore
+	address cipher.Address
+}
+
+func (s *keyStoreSigner) Address() cipher.Address {
+	return s.address
+}
+
+func (s *keyStoreSigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	sk, e := s.ks.SecKey(s.address)
+	if e != nil {
+		return cipher.Sig{}, e
+	}
+	return cipher.SignHash(hash, sk), nil
+}
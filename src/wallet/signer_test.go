@@ -0,0 +1,77 @@
+package wallet
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var (
+	_, testDeviceSK = cipher.GenerateDeterministicKeyPair([]byte("test device"))
+)
+
+// TestHelperSignerProgram is not a real test. It acts as the helper program of
+// a signing device when invoked by 'TestCommandSigner'.
+func TestHelperSignerProgram(t *testing.T) {
+	if os.Getenv("KITTYCASH_WANT_HELPER_SIGNER") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	switch args = args[1:]; {
+	case len(args) == 1 && args[0] == "address":
+		fmt.Println(cipher.AddressFromSecKey(testDeviceSK))
+	case len(args) == 2 && args[0] == "sign":
+		hash, e := cipher.SHA256FromHex(args[1])
+		if e != nil {
+			fmt.Fprintln(os.Stderr, e)
+			os.Exit(1)
+		}
+		fmt.Println(cipher.SignHash(hash, testDeviceSK).Hex())
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command")
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+func TestCommandSigner(t *testing.T) {
+	os.Setenv("KITTYCASH_WANT_HELPER_SIGNER", "1")
+	defer os.Unsetenv("KITTYCASH_WANT_HELPER_SIGNER")
+
+	s, err := NewCommandSigner(os.Args[0], "-test.run=TestHelperSignerProgram", "--")
+	require.NoError(t, err)
+	require.Equal(t, cipher.AddressFromSecKey(testDeviceSK), s.Address())
+
+	tx, err := iko.NewGenTxWithSigner(1, s)
+	require.NoError(t, err)
+	require.NoError(t, tx.VerifyWith(nil, cipher.PubKeyFromSecKey(testDeviceSK)))
+
+	_, err = NewCommandSigner(os.Args[0], "-test.run=TestHelperSignerProgram", "--", "extra")
+	require.Error(t, err)
+}
+
+func TestKeyStore_Signer(t *testing.T) {
+	ks, _, rm := newTestKeyStore(t, "password")
+	defer rm()
+
+	_, sk := cipher.GenerateKeyPair()
+	address, err := ks.Add(sk, "")
+	require.NoError(t, err)
+
+	s := ks.Signer(address)
+	tx, err := iko.NewGenTxWithSigner(1, s)
+	require.NoError(t, err)
+	require.NoError(t, tx.VerifyWith(nil, cipher.PubKeyFromSecKey(sk)))
+
+	ks.Lock()
+	_, err = iko.NewGenTxWithSigner(1, s)
+	require.Equal(t, ErrKeyStoreLocked, err)
+}
@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -22,7 +23,8 @@ type (
 
 const (
 	// Version determines the wallet file's version.
-	Version uint64 = 0
+	// Version 1 adds the address book.
+	Version uint64 = 1
 
 	// KittyAsset represents the "kittycash" asset type.
 	KittyAsset AssetType = "kittycash"
@@ -51,16 +53,25 @@ type Meta struct {
 }
 
 type FloatingWallet struct {
-	Meta    FloatingMeta     `json:"meta"`
-	Entries []*FloatingEntry `json:"entries"`
+	Meta        FloatingMeta       `json:"meta"`
+	Entries     []*FloatingEntry   `json:"entries"`
+	AddressBook []*FloatingContact `json:"address_book"`
 }
 
 type Wallet struct {
-	Meta    FloatingMeta
-	Entries []Entry
+	Meta        FloatingMeta
+	Entries     []Entry
+	AddressBook AddressBook
 }
 
 type File struct {
+	Meta        Meta
+	Entries     []Entry
+	AddressBook AddressBook
+}
+
+// fileV0 is the wallet file of version 0, which has no address book.
+type fileV0 struct {
 	Meta    Meta
 	Entries []Entry
 }
@@ -135,8 +146,19 @@ func LoadFloatingWallet(f io.Reader, label, password string) (*Wallet, error) {
 	}
 
 	var wallet File
-	if e := encoder.DeserializeRaw(data, &wallet); e != nil {
-		return nil, e
+	switch prefix.Version() {
+	case 0:
+		var v0 fileV0
+		if e := encoder.DeserializeRaw(data, &v0); e != nil {
+			return nil, e
+		}
+		wallet.Meta, wallet.Entries = v0.Meta, v0.Entries
+	case Version:
+		if e := encoder.DeserializeRaw(data, &wallet); e != nil {
+			return nil, e
+		}
+	default:
+		return nil, fmt.Errorf("unsupported wallet file version %d", prefix.Version())
 	}
 	return &Wallet{
 		Meta: FloatingMeta{
@@ -146,12 +168,14 @@ func LoadFloatingWallet(f io.Reader, label, password string) (*Wallet, error) {
 			Password:  password,
 			Meta:      wallet.Meta,
 		},
-		Entries: wallet.Entries,
+		Entries:     wallet.Entries,
+		AddressBook: wallet.AddressBook,
 	}, nil
 }
 
+// Save writes the wallet file. Files of older versions are upgraded.
 func (w *Wallet) Save() error {
-	version := w.Meta.Version
+	version := Version
 
 	nonce := EmptyNonce()
 	if w.Meta.Encrypted {
@@ -178,6 +202,7 @@ func (w *Wallet) Save() error {
 		return e
 	}
 
+	w.Meta.Version = version
 	w.Meta.Saved = true
 	return nil
 }
@@ -206,18 +231,21 @@ func (w *Wallet) Count() int {
 
 func (w *Wallet) ToFile() *File {
 	return &File{
-		Meta:    w.Meta.Meta,
-		Entries: w.Entries,
+		Meta:        w.Meta.Meta,
+		Entries:     w.Entries,
+		AddressBook: w.AddressBook,
 	}
 }
 
 func (w *Wallet) ToFloating() *FloatingWallet {
 	fw := &FloatingWallet{
-		Meta:    w.Meta,
-		Entries: make([]*FloatingEntry, len(w.Entries)),
+		Meta:        w.Meta,
+		Entries:     make([]*FloatingEntry, len(w.Entries)),
+		AddressBook: w.AddressBook.ToFloating(),
 	}
 	for i, entry := range w.Entries {
 		fw.Entries[i] = entry.ToFloating()
+		fw.Entries[i].Name = w.AddressBook.Name(entry.Address)
 	}
 	return fw
 }
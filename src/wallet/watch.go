@@ -0,0 +1,209 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var (
+	ErrAddressNotWatched = errors.New("address is not watched")
+)
+
+// WatchOnly tracks addresses for which no secret keys are held, such as those
+// of cold storage. Owned kitties and transfers can be monitored, but nothing
+// can be signed.
+type WatchOnly struct {
+	mux       sync.RWMutex
+	addresses []cipher.Address
+	index     map[cipher.Address]struct{}
+}
+
+// NewWatchOnly creates a watch-only wallet tracking the given addresses.
+func NewWatchOnly(addresses ...cipher.Address) *WatchOnly {
+	w := &WatchOnly{
+		index: make(map[cipher.Address]struct{}, len(addresses)),
+	}
+	for _, address := range addresses {
+		w.watch(address)
+	}
+	return w
+}
+
+// watchOnlyFile is the on-disk format of a watch-only wallet.
+type watchOnlyFile struct {
+	Addresses []string `json:"addresses"`
+}
+
+// LoadWatchOnly loads a watch-only wallet from file.
+func LoadWatchOnly(path string) (*WatchOnly, error) {
+	raw, e := ioutil.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	var f watchOnlyFile
+	if e := json.Unmarshal(raw, &f); e != nil {
+		return nil, e
+	}
+	w := NewWatchOnly()
+	for _, s := range f.Addresses {
+		address, e := cipher.DecodeBase58Address(s)
+		if e != nil {
+			return nil, e
+		}
+		w.watch(address)
+	}
+	return w, nil
+}
+
+// Save writes the watched addresses to file.
+func (w *WatchOnly) Save(path string) error {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	f := watchOnlyFile{Addresses: make([]string, len(w.addresses))}
+	for i, address := range w.addresses {
+		f.Addresses[i] = address.String()
+	}
+	raw, e := json.MarshalIndent(f, "", "    ")
+	if e != nil {
+		return e
+	}
+	return SaveBinary(path, raw)
+}
+
+// Watch starts tracking an address. It returns false if the address is
+// already watched.
+func (w *WatchOnly) Watch(address cipher.Address) bool {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	return w.watch(address)
+}
+
+func (w *WatchOnly) watch(address cipher.Address) bool {
+	if _, ok := w.index[address]; ok {
+		return false
+	}
+	w.addresses = append(w.addresses, address)
+	w.index[address] = struct{}{}
+	return true
+}
+
+// Unwatch stops tracking an address. It returns false if the address is not
+// watched.
+func (w *WatchOnly) Unwatch(address cipher.Address) bool {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if _, ok := w.index[address]; !ok {
+		return false
+	}
+	delete(w.index, address)
+	for i, a := range w.addresses {
+		if a == address {
+			w.addresses = append(w.addresses[:i], w.addresses[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// IsWatched returns whether the address is tracked.
+func (w *WatchOnly) IsWatched(address cipher.Address) bool {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	_, ok := w.index[address]
+	return ok
+}
+
+// Addresses returns the watched addresses, in order of being added.
+func (w *WatchOnly) Addresses() []cipher.Address {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	return append([]cipher.Address(nil), w.addresses...)
+}
+
+// WatchedAddress is the state of a watched address.
+type WatchedAddress struct {
+	Address cipher.Address
+	Kitties iko.KittyIDs
+}
+
+// Kitties obtains the kitties owned by each watched address from the state.
+func (w *WatchOnly) Kitties(state iko.StateDB) []WatchedAddress {
+	addresses := w.Addresses()
+	out := make([]WatchedAddress, len(addresses))
+	for i, address := range addresses {
+		out[i] = WatchedAddress{
+			Address: address,
+			Kitties: state.GetAddressState(address).Kitties,
+		}
+	}
+	return out
+}
+
+// IncomingTxs obtains the txs that moved kitties into the watched address,
+// in order of sequence.
+func (w *WatchOnly) IncomingTxs(bc iko.Chain, address cipher.Address) ([]iko.TxWrapper, error) {
+	if !w.IsWatched(address) {
+		return nil, ErrAddressNotWatched
+	}
+	var out []iko.TxWrapper
+	for _, hash := range bc.GetAddressState(address).Transactions {
+		txWrap, e := bc.GetTxOfHash(hash)
+		if e != nil {
+			return nil, e
+		}
+		if txWrap.Tx.Out == address {
+			out = append(out, txWrap)
+		}
+	}
+	return out, nil
+}
+
+// Monitor obtains a channel that receives new txs that move kitties to or from
+// the watched addresses. Addresses watched after calling Monitor are also
+// monitored. As with 'SubscribeTxs', txs are dropped when the channel is not
+// being kept up with. The returned function stops monitoring and closes the
+// channel.
+func (w *WatchOnly) Monitor(bc iko.Chain, bufSize int) (<-chan iko.TxWrapper, func()) {
+	var (
+		sub, unsub = bc.SubscribeTxs(bufSize)
+		out        = make(chan iko.TxWrapper, bufSize)
+	)
+	go func() {
+		defer close(out)
+		for txWrap := range sub {
+			if !w.match(bc, &txWrap.Tx) {
+				continue
+			}
+			select {
+			case out <- txWrap:
+			default:
+				log.
+					WithField("seq", txWrap.Meta.Seq).
+					Warning("dropped watched tx for slow receiver")
+			}
+		}
+	}()
+	return out, unsub
+}
+
+func (w *WatchOnly) match(bc iko.Chain, tx *iko.Transaction) bool {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	if len(w.index) == 0 {
+		return false
+	}
+	filter := &iko.TxFilter{Addresses: w.index}
+	return filter.Match(bc, tx)
+}
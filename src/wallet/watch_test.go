@@ -0,0 +1,124 @@
+package wallet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittycash/wallet/src/iko"
+)
+
+var (
+	testGenPK, testGenSK = cipher.GenerateDeterministicKeyPair([]byte("wallet test"))
+)
+
+func newTestBlockChain(t *testing.T) (*iko.BlockChain, iko.StateDB, func()) {
+	dir, err := ioutil.TempDir("", "kittycash_test")
+	require.NoError(t, err)
+
+	chainDB, err := iko.NewBoltChain(&iko.BoltChainConfig{
+		Path: filepath.Join(dir, "chain.db"),
+	})
+	require.NoError(t, err)
+
+	state := iko.NewMemoryState()
+	bc, err := iko.NewBlockChain(context.Background(), &iko.BlockChainConfig{
+		GenerationPK: testGenPK,
+	}, chainDB, state)
+	require.NoError(t, err)
+
+	return bc, state, func() {
+		bc.Close()
+		chainDB.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestWatchOnly_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kittycash_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a0 := newTestAddress()
+	a1 := newTestAddress()
+
+	w := NewWatchOnly(a0, a1)
+	require.False(t, w.Watch(a0))
+	require.True(t, w.IsWatched(a1))
+
+	path := filepath.Join(dir, "watch.json")
+	require.NoError(t, w.Save(path))
+
+	loaded, err := LoadWatchOnly(path)
+	require.NoError(t, err)
+	require.Equal(t, []cipher.Address{a0, a1}, loaded.Addresses())
+
+	require.True(t, loaded.Unwatch(a0))
+	require.False(t, loaded.Unwatch(a0))
+	require.Equal(t, []cipher.Address{a1}, loaded.Addresses())
+}
+
+func TestWatchOnly_Monitor(t *testing.T) {
+	bc, state, closeBC := newTestBlockChain(t)
+	defer closeBC()
+
+	cold := newTestAddress()
+	other := newTestAddress()
+
+	w := NewWatchOnly(cold)
+	watched, stop := w.Monitor(bc, 10)
+	defer stop()
+
+	sub, unsub := bc.SubscribeTxs(10)
+	defer unsub()
+
+	inject := func(tx *iko.Transaction, err error) {
+		require.NoError(t, err)
+		_, err = bc.InjectTx(tx)
+		require.NoError(t, err)
+		<-sub
+	}
+
+	gen0, gen1 := iko.NewGenTx(0, testGenSK), iko.NewGenTx(1, testGenSK)
+	inject(gen0, nil)
+	inject(gen1, nil)
+	toCold, err := iko.NewTransferTx(gen0, cold, testGenSK)
+	inject(toCold, err)
+	toOther, err := iko.NewTransferTx(gen1, other, testGenSK)
+	inject(toOther, err)
+
+	select {
+	case txWrap := <-watched:
+		require.Equal(t, toCold.Hash(), txWrap.Tx.Hash())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for watched tx")
+	}
+	select {
+	case txWrap := <-watched:
+		t.Fatalf("unexpected tx of seq %d", txWrap.Meta.Seq)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.Equal(t, []WatchedAddress{
+		{Address: cold, Kitties: iko.KittyIDs{0}},
+	}, w.Kitties(state))
+
+	incoming, err := w.IncomingTxs(bc, cold)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	require.Equal(t, toCold.Hash(), incoming[0].Tx.Hash())
+
+	_, err = w.IncomingTxs(bc, other)
+	require.Equal(t, ErrAddressNotWatched, err)
+}
+
+func newTestAddress() cipher.Address {
+	pk, _ := cipher.GenerateKeyPair()
+	return cipher.AddressFromPubKey(pk)
+}